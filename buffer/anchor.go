@@ -0,0 +1,49 @@
+package buffer
+
+// anchor returns a position on a raw line, tied to the line's content so that it stays with it as lines move up the
+// screen, e.g. in a scrolling region, or are removed from above it
+func (buffer *Buffer) anchor(line int, col int) *Position {
+	pos := &Position{Line: line, Col: col}
+	if line >= 0 && line < len(buffer.lines) {
+		pos.lineID = buffer.lines[line].id
+	}
+	return pos
+}
+
+// LineIDs returns ids of the first and last lines of the buffer. They change whenever lines are added to or dropped from
+// it, even once the scrollback is full and the height stays the same, so they show when what has been found in the
+// buffer needs finding again.
+func (buffer *Buffer) LineIDs() (uint64, uint64) {
+	if len(buffer.lines) == 0 {
+		return 0, 0
+	}
+	return buffer.lines[0].id, buffer.lines[len(buffer.lines)-1].id
+}
+
+// resolve moves a position to wherever its line is now, returning false if the line has gone
+func (buffer *Buffer) resolve(pos *Position) bool {
+	if pos.lineID == 0 {
+		return true
+	}
+	if pos.Line >= 0 && pos.Line < len(buffer.lines) && buffer.lines[pos.Line].id == pos.lineID {
+		return true
+	}
+	for i := range buffer.lines {
+		if buffer.lines[i].id == pos.lineID {
+			pos.Line = i
+			return true
+		}
+	}
+	return false
+}
+
+// anchorSelection moves the selection to follow the lines it was made on, clearing it if any of them have gone
+func (buffer *Buffer) anchorSelection() {
+	for _, pos := range []*Position{buffer.selectionStart, buffer.selectionEnd} {
+		if pos != nil && !buffer.resolve(pos) {
+			buffer.selectionStart = nil
+			buffer.selectionEnd = nil
+			return
+		}
+	}
+}
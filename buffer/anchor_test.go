@@ -0,0 +1,81 @@
+package buffer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectionFollowsScrollingRegion(t *testing.T) {
+	b := NewBuffer(10, 4, CellAttributes{})
+	b.Write([]rune("one\r\ntwo\r\nthree\r\nfour")...)
+	b.SetVerticalMargins(0, 2)
+
+	b.StartSelection(0, 1)
+	b.EndSelection(2, 1, true)
+	assert.Equal(t, "two", b.GetSelectedText())
+
+	// new output scrolls the region, moving "two" up a line
+	b.SetPosition(0, 2)
+	b.Index()
+
+	assert.Equal(t, "two", b.GetSelectedText())
+	assert.True(t, b.InSelection(0, 0))
+	assert.False(t, b.InSelection(0, 1))
+}
+
+func TestSelectionFollowsClearedScrollback(t *testing.T) {
+	b := NewBuffer(10, 3, CellAttributes{})
+	b.Write([]rune("one\r\ntwo\r\nthree\r\nfour\r\nfive")...)
+
+	b.StartSelection(0, 1)
+	b.EndSelection(3, 1, true)
+	assert.Equal(t, "four", b.GetSelectedText())
+
+	b.ClearScrollback()
+	assert.Equal(t, "four", b.GetSelectedText())
+}
+
+func TestSelectionOfRemovedLinesIsCleared(t *testing.T) {
+	b := NewBuffer(10, 3, CellAttributes{})
+	b.Write([]rune("one\r\ntwo\r\nthree\r\nfour\r\nfive")...)
+	b.ScrollUp(2)
+
+	b.StartSelection(0, 0)
+	b.EndSelection(2, 0, true)
+	assert.Equal(t, "one", b.GetSelectedText())
+
+	b.ClearScrollback()
+	assert.Equal(t, "", b.GetSelectedText())
+}
+
+func TestMultipleClicksSelectWordThenLine(t *testing.T) {
+	b := NewBuffer(20, 3, CellAttributes{})
+	b.Write([]rune("ls -la /tmp")...)
+
+	click := func() {
+		b.StartSelection(4, 0)
+		b.EndSelection(4, 0, true)
+	}
+
+	click()
+	assert.Equal(t, "", b.GetSelectedText())
+	click()
+	assert.Equal(t, "-la", b.GetSelectedText())
+	click()
+	assert.Equal(t, "ls -la /tmp", b.GetSelectedText())
+}
+
+func TestLineIDsChangeOnceScrollbackIsFull(t *testing.T) {
+	b := NewBuffer(10, 2, CellAttributes{})
+	b.SetScrollbackLimit(1)
+	b.Write([]rune("one\r\ntwo\r\nthree")...)
+	height := b.Height()
+	first, last := b.LineIDs()
+
+	b.Write([]rune("\r\nfour")...)
+	assert.Equal(t, height, b.Height())
+	newFirst, newLast := b.LineIDs()
+	assert.NotEqual(t, first, newFirst)
+	assert.NotEqual(t, last, newLast)
+}
@@ -0,0 +1,56 @@
+package buffer
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// attrID is the index of a combination of cell attributes in attributeTable
+type attrID uint32
+
+// attributeTable holds every distinct combination of cell attributes in use, so that each cell can store a small index
+// into it rather than a copy of its own. Output rarely uses more than a handful of combinations, so this saves a lot of
+// memory in a long scrollback. Entries are never changed or removed, so an index stays valid for good. The zero index
+// is the zero value of CellAttributes, so a zero Cell needs no interning. Hyperlinks, which are seldom shared, are kept
+// out of it, see hyperlinkTable.
+var attributeTable = struct {
+	sync.Mutex
+	ids     map[CellAttributes]attrID
+	entries atomic.Value // []CellAttributes, replaced as it grows so that cells can be read without locking
+}{
+	ids: map[CellAttributes]attrID{{}: 0},
+}
+
+func init() {
+	attributeTable.entries.Store([]CellAttributes{{}})
+}
+
+// internAttributes returns the index of a combination of attributes, adding it to the table if it is new
+func internAttributes(attr CellAttributes) attrID {
+	table := &attributeTable
+	table.Lock()
+	defer table.Unlock()
+	if id, ok := table.ids[attr]; ok {
+		return id
+	}
+	entries := table.entries.Load().([]CellAttributes)
+	id := attrID(len(entries))
+	// readers only look at the entries in the slice they loaded, so appending in place is safe
+	table.entries.Store(append(entries, attr))
+	table.ids[attr] = id
+	return id
+}
+
+func (id attrID) attributes() *CellAttributes {
+	return &attributeTable.entries.Load().([]CellAttributes)[id]
+}
+
+// cursorAttrID returns the index of the attributes cells are written with. They change far less often than cells are
+// written, so the index is kept until they do.
+func (buffer *Buffer) cursorAttrID() attrID {
+	if buffer.cursorAttr != buffer.internedAttr {
+		buffer.internedAttr = buffer.cursorAttr
+		buffer.internedAttrID = internAttributes(buffer.cursorAttr)
+	}
+	return buffer.internedAttrID
+}
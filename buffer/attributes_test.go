@@ -0,0 +1,31 @@
+package buffer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInternAttributes(t *testing.T) {
+	bold := CellAttributes{FgColour: [3]float32{1, 0, 0}, Bold: true}
+
+	assert.Equal(t, attrID(0), internAttributes(CellAttributes{}))
+	assert.Equal(t, internAttributes(bold), internAttributes(bold))
+	assert.NotEqual(t, internAttributes(bold), internAttributes(CellAttributes{Bold: true}))
+	assert.Equal(t, bold, *internAttributes(bold).attributes())
+}
+
+func TestCellsKeepTheirAttributes(t *testing.T) {
+	b := NewBuffer(10, 2, CellAttributes{})
+	b.Write('a')
+	b.CursorAttr().Underline = UnderlineSingle
+	b.CursorAttr().FgColour = [3]float32{0, 1, 0}
+	b.Write('b')
+	b.CursorAttr().Underline = UnderlineNone
+	b.Write('c')
+
+	assert.Equal(t, CellAttributes{}, b.GetCell(0, 0).Attr())
+	assert.Equal(t, CellAttributes{Underline: UnderlineSingle, FgColour: [3]float32{0, 1, 0}}, b.GetCell(1, 0).Attr())
+	assert.Equal(t, [3]float32{0, 1, 0}, b.GetCell(2, 0).Fg())
+	assert.Equal(t, UnderlineNone, b.GetCell(2, 0).Attr().Underline)
+}
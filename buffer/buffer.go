@@ -2,7 +2,6 @@ package buffer
 
 import (
 	"fmt"
-	"net/url"
 	"time"
 )
 
@@ -13,10 +12,21 @@ type Buffer struct {
 	viewHeight            uint16
 	viewWidth             uint16
 	cursorAttr            CellAttributes
+	internedAttr          CellAttributes // the cursor attributes when they were last interned, see cursorAttrID
+	internedAttrID        attrID
+	hyperlinks            hyperlinkTable // the targets of the links in the buffer, see hyperlinks.go
+	cursorLink            linkID         // the link cells are written with, see SetHyperlink
+	cursorLinkURL         string
 	displayChangeHandlers []chan bool
-	savedX                uint16
-	savedY                uint16
+	saved                 savedCursor // see SaveCursor
+	originMode            bool        // cursor positions are relative to the top margin and kept inside the margins
+	charsets              [2]Charset  // G0 and G1
+	shifted               bool        // G1 is used for output rather than G0, after a shift out
+	tabStops              []bool      // whether each column is a tab stop, see tabs.go
 	scrollLinesFromBottom uint
+	viewHeld              bool // the view stays where it is as output arrives, see follow.go
+	newLinesBelow         int  // lines of output added below the view since it was held
+	scrollbackLimit       int  // the most lines kept above the screen, or -1 for no limit
 	topMargin             uint // see DECSTBM docs - this is for scrollable regions
 	bottomMargin          uint // see DECSTBM docs - this is for scrollable regions
 	replaceMode           bool // overwrite character at cursor or insert new
@@ -27,79 +37,56 @@ type Buffer struct {
 	selectionComplete     bool // whether the selected text can update or whether it is final
 	selectionExpanded     bool // whether the selection to word expansion has already run on this point
 	selectionClickTime    time.Time
+	images                []*Image  // placed by programs, oldest first, see images.go
+	command               *Position // where the running command started, see landmarks.go
+	landmarks             landmarkCache
 }
 
 type Position struct {
 	Line int
 	Col  int
+
+	lineID uint64 // the line the position is on, so it can follow the line if it moves, see Buffer.anchor
 }
 
 // NewBuffer creates a new terminal buffer
 func NewBuffer(viewCols uint16, viewLines uint16, attr CellAttributes) *Buffer {
 	b := &Buffer{
-		cursorX:    0,
-		cursorY:    0,
-		lines:      []Line{},
-		cursorAttr: attr,
-		autoWrap:   true,
+		cursorX:         0,
+		cursorY:         0,
+		lines:           []Line{},
+		cursorAttr:      attr,
+		autoWrap:        true,
+		scrollbackLimit: -1,
+		saved:           savedCursor{attr: attr},
 	}
 	b.SetVerticalMargins(0, uint(viewLines-1))
 	b.ResizeView(viewCols, viewLines)
 	return b
 }
 
-func (buffer *Buffer) GetURLAtPosition(col uint16, viewRow uint16) string {
-
-	row := buffer.convertViewLineToRawLine((viewRow)) - uint64(buffer.scrollLinesFromBottom)
-
-	cell := buffer.GetRawCell(col, row)
-	if cell == nil || cell.Rune() == 0x00 {
-		return ""
-	}
-
-	candidate := ""
+func (buffer *Buffer) SelectWordAtPosition(col uint16, viewRow uint16) {
 
-	for i := col; i >= 0; i-- {
-		cell := buffer.GetRawCell(i, row)
-		if cell == nil {
-			break
-		}
-		if isRuneURLSelectionMarker(cell.Rune()) {
-			break
-		}
-		candidate = fmt.Sprintf("%c%s", cell.Rune(), candidate)
-	}
+	row := buffer.convertViewLineToRawLine(viewRow) - uint64(buffer.scrollLinesFromBottom)
 
-	for i := col + 1; i < buffer.viewWidth; i++ {
-		cell := buffer.GetRawCell(i, row)
-		if cell == nil {
-			break
-		}
-		if isRuneURLSelectionMarker(cell.Rune()) {
-			break
-		}
-		candidate = fmt.Sprintf("%s%c", candidate, cell.Rune())
+	start, end, ok := buffer.wordBounds(row, col)
+	if !ok {
+		return
 	}
 
-	if candidate == "" || candidate[0] == '/' {
-		return ""
-	}
+	buffer.selectionStart = buffer.anchor(int(row), int(start))
+	buffer.selectionEnd = buffer.anchor(int(row), int(end))
+	buffer.selectionComplete = true
+	buffer.emitDisplayChange()
 
-	// check if url
-	_, err := url.ParseRequestURI(candidate)
-	if err != nil {
-		return ""
-	}
-	return candidate
 }
 
-func (buffer *Buffer) SelectWordAtPosition(col uint16, viewRow uint16) {
-
-	row := buffer.convertViewLineToRawLine(viewRow) - uint64(buffer.scrollLinesFromBottom)
+// wordBounds returns the first and last columns of the word at a cell on a raw line, or false if there isn't one there
+func (buffer *Buffer) wordBounds(row uint64, col uint16) (uint16, uint16, bool) {
 
 	cell := buffer.GetRawCell(col, row)
-	if cell == nil || cell.Rune() == 0x00 {
-		return
+	if cell == nil || isWordSelectionMarker(cell) {
+		return 0, 0, false
 	}
 
 	start := col
@@ -110,7 +97,7 @@ func (buffer *Buffer) SelectWordAtPosition(col uint16, viewRow uint16) {
 		if cell == nil {
 			break
 		}
-		if isRuneWordSelectionMarker(cell.Rune()) {
+		if isWordSelectionMarker(cell) {
 			break
 		}
 		start = i
@@ -121,22 +108,26 @@ func (buffer *Buffer) SelectWordAtPosition(col uint16, viewRow uint16) {
 		if cell == nil {
 			break
 		}
-		if isRuneWordSelectionMarker(cell.Rune()) {
+		if isWordSelectionMarker(cell) {
 			break
 		}
 		end = i
 	}
 
-	buffer.selectionStart = &Position{
-		Col:  int(start),
-		Line: int(row),
-	}
-	buffer.selectionEnd = &Position{
-		Col:  int(end),
-		Line: int(row),
-	}
-	buffer.emitDisplayChange()
+	return start, end, true
+}
+
+// SelectAll selects everything in the buffer, including the scrollback
+func (buffer *Buffer) SelectAll() {
+	defer buffer.emitDisplayChange()
+	buffer.selectionComplete = true
+	buffer.selectionStart = buffer.anchor(0, 0)
+	buffer.selectionEnd = buffer.anchor(buffer.Height()-1, int(buffer.ViewWidth()-1))
+}
 
+// isWordSelectionMarker returns true if a cell ends a word. The right hand halves of wide cells are part of the word.
+func isWordSelectionMarker(cell *Cell) bool {
+	return !cell.continuation && isRuneWordSelectionMarker(cell.r)
 }
 
 // bounds for word selection
@@ -149,36 +140,22 @@ func isRuneWordSelectionMarker(r rune) bool {
 	return false
 }
 
-func isRuneURLSelectionMarker(r rune) bool {
-	switch r {
-	case ' ', 0, '\'', '"', '{', '}':
-		return true
-	}
-
-	return false
+func (buffer *Buffer) GetSelectedText() string {
+	return buffer.selectedText(nil)
 }
 
-func (buffer *Buffer) GetSelectedText() string {
+// selectedText returns the selected text, leaving out cells with attributes which exclude matches
+func (buffer *Buffer) selectedText(exclude func(attr *CellAttributes) bool) string {
+	buffer.anchorSelection()
 	if buffer.selectionStart == nil || buffer.selectionEnd == nil {
 		return ""
 	}
 
 	text := ""
 
-	var x1, x2, y1, y2 int
-
-	if buffer.selectionStart.Line > buffer.selectionEnd.Line || (buffer.selectionStart.Line == buffer.selectionEnd.Line && buffer.selectionStart.Col > buffer.selectionEnd.Col) {
-		y2 = buffer.selectionStart.Line
-		y1 = buffer.selectionEnd.Line
-		x2 = buffer.selectionStart.Col
-		x1 = buffer.selectionEnd.Col
-	} else {
-		y1 = buffer.selectionStart.Line
-		y2 = buffer.selectionEnd.Line
-		x1 = buffer.selectionStart.Col
-		x2 = buffer.selectionEnd.Col
-	}
+	x1, y1, x2, y2 := buffer.selectionBounds()
 
+	started := false
 	for row := y1; row <= y2; row++ {
 
 		if row >= len(buffer.lines) {
@@ -191,21 +168,38 @@ func (buffer *Buffer) GetSelectedText() string {
 		maxX := int(buffer.viewWidth) - 1
 		if row == y1 {
 			minX = x1
-		} else if !line.wrapped {
-			text += "\n"
 		}
 		if row == y2 {
 			maxX = x2
 		}
 
+		lineText := ""
+		excluded := false
+
 		for col := minX; col <= maxX; col++ {
 			if col >= len(line.cells) {
 				break
 			}
 			cell := line.cells[col]
-			text += string(cell.Rune())
+			if cell.continuation {
+				continue
+			}
+			if exclude != nil && exclude(cell.attr.attributes()) {
+				excluded = true
+				continue
+			}
+			lineText += string(cell.Runes())
 		}
 
+		if excluded && lineText == "" {
+			continue
+		}
+		if started && !line.wrapped {
+			text += "\n"
+		}
+		text += lineText
+		started = true
+
 	}
 
 	return text
@@ -213,36 +207,29 @@ func (buffer *Buffer) GetSelectedText() string {
 
 func (buffer *Buffer) StartSelection(col uint16, viewRow uint16) {
 	row := buffer.convertViewLineToRawLine(viewRow) - uint64(buffer.scrollLinesFromBottom)
+	// stop output moving the text being selected
+	buffer.holdView()
 	if buffer.selectionComplete {
-		buffer.selectionEnd = nil
 
 		if buffer.selectionStart != nil && time.Since(buffer.selectionClickTime) < time.Millisecond*500 {
-			if buffer.selectionExpanded {
-				//select whole line!
-				buffer.selectionStart = &Position{
-					Col:  0,
-					Line: int(row),
-				}
-				buffer.selectionEnd = &Position{
-					Col:  int(buffer.ViewWidth() - 1),
-					Line: int(row),
-				}
-				buffer.emitDisplayChange()
+			// each click in quick succession selects more: the word, then bigger and bigger units around it
+			if buffer.selectionExpanded && buffer.selectionEnd != nil {
+				buffer.ExpandSelection()
 			} else {
+				buffer.selectionEnd = nil
 				buffer.SelectWordAtPosition(col, viewRow)
 				buffer.selectionExpanded = true
 			}
+			buffer.selectionClickTime = time.Now()
 			return
 		}
 
+		buffer.selectionEnd = nil
 		buffer.selectionExpanded = false
 	}
 
 	buffer.selectionComplete = false
-	buffer.selectionStart = &Position{
-		Col:  int(col),
-		Line: int(row),
-	}
+	buffer.selectionStart = buffer.anchor(int(row), int(col))
 	buffer.selectionClickTime = time.Now()
 }
 
@@ -256,6 +243,7 @@ func (buffer *Buffer) EndSelection(col uint16, viewRow uint16, complete bool) {
 
 	defer buffer.emitDisplayChange()
 
+	buffer.anchorSelection()
 	if buffer.selectionStart == nil {
 		buffer.selectionEnd = nil
 		return
@@ -264,38 +252,74 @@ func (buffer *Buffer) EndSelection(col uint16, viewRow uint16, complete bool) {
 	row := buffer.convertViewLineToRawLine(viewRow) - uint64(buffer.scrollLinesFromBottom)
 
 	if int(col) == buffer.selectionStart.Col && int(row) == int(buffer.selectionStart.Line) && complete {
+		// a click rather than a selection, so there is nothing to hold the view for
+		buffer.releaseView()
 		return
 	}
 
-	buffer.selectionEnd = &Position{
-		Col:  int(col),
-		Line: int(row),
-	}
+	buffer.selectionEnd = buffer.anchor(int(row), int(col))
 }
 
-func (buffer *Buffer) InSelection(col uint16, row uint16) bool {
+// ViewSelection is the selection as it is in the view, worked out once so it can be checked for every cell in a frame
+type ViewSelection struct {
+	selected       bool
+	x1, y1, x2, y2 int // the selection's bounds, on raw lines
+	offset         int // added to a view row to give the raw line it shows
+}
 
+// ViewSelection resolves where the selection is now, for checking cells in the view against it
+func (buffer *Buffer) ViewSelection() ViewSelection {
+	buffer.anchorSelection()
 	if buffer.selectionStart == nil || buffer.selectionEnd == nil {
+		return ViewSelection{}
+	}
+	x1, y1, x2, y2 := buffer.selectionBounds()
+	return ViewSelection{
+		selected: true,
+		x1:       x1,
+		y1:       y1,
+		x2:       x2,
+		y2:       y2,
+		offset:   int(buffer.convertViewLineToRawLine(0)) - int(buffer.scrollLinesFromBottom),
+	}
+}
+
+// Contains returns true if the cell at a position in the view is selected
+func (selection ViewSelection) Contains(col uint16, row uint16) bool {
+	if !selection.selected {
 		return false
 	}
+	rawY := int(row) + selection.offset
+	return (rawY > selection.y1 || (rawY == selection.y1 && int(col) >= selection.x1)) &&
+		(rawY < selection.y2 || (rawY == selection.y2 && int(col) <= selection.x2))
+}
 
-	var x1, x2, y1, y2 int
+// InSelection returns true if the cell at a position in the view is selected. When checking many cells, resolve the
+// selection once with ViewSelection instead.
+func (buffer *Buffer) InSelection(col uint16, row uint16) bool {
+	return buffer.ViewSelection().Contains(col, row)
+}
 
-	// first, let's put the selection points in the correct order, earliest first
-	if buffer.selectionStart.Line > buffer.selectionEnd.Line || (buffer.selectionStart.Line == buffer.selectionEnd.Line && buffer.selectionStart.Col > buffer.selectionEnd.Col) {
-		y2 = buffer.selectionStart.Line
-		y1 = buffer.selectionEnd.Line
-		x2 = buffer.selectionStart.Col
-		x1 = buffer.selectionEnd.Col
-	} else {
-		y1 = buffer.selectionStart.Line
-		y2 = buffer.selectionEnd.Line
-		x1 = buffer.selectionStart.Col
-		x2 = buffer.selectionEnd.Col
+// selectionBounds returns the start and end of the selection, earliest first. Wide cells are selected whole, so an end
+// which falls on either half of one takes in the other half too.
+func (buffer *Buffer) selectionBounds() (x1 int, y1 int, x2 int, y2 int) {
+	start, end := buffer.selectionStart, buffer.selectionEnd
+	if start.Line > end.Line || (start.Line == end.Line && start.Col > end.Col) {
+		start, end = end, start
 	}
+	x1, y1, x2, y2 = start.Col, start.Line, end.Col, end.Line
 
-	rawY := int(buffer.convertViewLineToRawLine(row) - uint64(buffer.scrollLinesFromBottom))
-	return (rawY > y1 || (rawY == y1 && int(col) >= x1)) && (rawY < y2 || (rawY == y2 && int(col) <= x2))
+	if y1 < len(buffer.lines) {
+		if cells := buffer.lines[y1].cells; x1 > 0 && x1 < len(cells) && cells[x1].continuation {
+			x1--
+		}
+	}
+	if y2 < len(buffer.lines) {
+		if cells := buffer.lines[y2].cells; x2 >= 0 && x2+1 < len(cells) && cells[x2+1].continuation {
+			x2++
+		}
+	}
+	return x1, y1, x2, y2
 }
 
 func (buffer *Buffer) IsDirty() bool {
@@ -343,8 +367,9 @@ func (buffer *Buffer) ScrollDown(lines uint16) {
 		return
 	}
 
-	if uint(lines) > buffer.scrollLinesFromBottom {
-		lines = uint16(buffer.scrollLinesFromBottom)
+	if uint(lines) >= buffer.scrollLinesFromBottom {
+		buffer.follow()
+		return
 	}
 	buffer.scrollLinesFromBottom -= uint(lines)
 }
@@ -362,6 +387,9 @@ func (buffer *Buffer) ScrollUp(lines uint16) {
 	} else {
 		buffer.scrollLinesFromBottom += uint(lines)
 	}
+	if buffer.scrollLinesFromBottom > 0 {
+		buffer.holdView()
+	}
 }
 
 func (buffer *Buffer) ScrollPageDown() {
@@ -372,17 +400,7 @@ func (buffer *Buffer) ScrollPageUp() {
 }
 func (buffer *Buffer) ScrollToEnd() {
 	defer buffer.emitDisplayChange()
-	buffer.scrollLinesFromBottom = 0
-}
-
-func (buffer *Buffer) SaveCursor() {
-	buffer.savedX = buffer.cursorX
-	buffer.savedY = buffer.cursorY
-}
-
-func (buffer *Buffer) RestoreCursor() {
-	buffer.cursorX = buffer.savedX
-	buffer.cursorY = buffer.savedY
+	buffer.follow()
 }
 
 func (buffer *Buffer) CursorAttr() *CellAttributes {
@@ -408,6 +426,7 @@ func (buffer *Buffer) GetRawCell(viewCol uint16, rawLine uint64) *Cell {
 
 func (buffer *Buffer) emitDisplayChange() {
 	buffer.dirty = true
+	buffer.verifyInvariants()
 }
 
 // Column returns cursor column
@@ -466,84 +485,107 @@ func (buffer *Buffer) ViewHeight() uint16 {
 	return buffer.viewHeight
 }
 
-func (buffer *Buffer) deleteLine() {
-	index := int(buffer.RawLine())
-	buffer.lines = buffer.lines[:index+copy(buffer.lines[index:], buffer.lines[index+1:])]
-}
-
-func (buffer *Buffer) insertLine() {
-
-	defer buffer.emitDisplayChange()
-
-	if !buffer.InScrollableRegion() {
-		pos := buffer.RawLine()
-		out := make([]Line, len(buffer.lines)+1)
-		copy(out[:pos], buffer.lines[:pos])
-		out[pos] = newLine()
-		copy(out[pos+1:], buffer.lines[pos:])
-		buffer.lines = out
-	} else {
-		topIndex := buffer.convertViewLineToRawLine(uint16(buffer.topMargin))
-		bottomIndex := buffer.convertViewLineToRawLine(uint16(buffer.bottomMargin))
-		before := buffer.lines[:topIndex]
-		after := buffer.lines[bottomIndex+1:]
-		out := make([]Line, len(buffer.lines))
-		copy(out[0:], before)
-
-		pos := buffer.RawLine()
-		for i := topIndex; i < bottomIndex; i++ {
-			if i < pos {
-				out[i] = buffer.lines[i]
-			} else {
-				out[i+1] = buffer.lines[i]
-			}
-		}
-
-		copy(out[bottomIndex+1:], after)
-
-		out[pos] = newLine()
-		buffer.lines = out
+// editableLines returns the raw indexes of the cursor line and the last line lines can be inserted or deleted down to:
+// the bottom of the scrolling region, or of the screen if there isn't one. It returns false if the cursor is outside
+// the scrolling region, where inserting and deleting lines does nothing.
+func (buffer *Buffer) editableLines() (int, int, bool) {
+	if buffer.HasScrollableRegion() && !buffer.InScrollableRegion() {
+		return 0, 0, false
+	}
+	bottom := uint16(buffer.ViewHeight() - 1)
+	if buffer.InScrollableRegion() {
+		bottom = uint16(buffer.bottomMargin)
 	}
+	// make sure the lines exist before moving them about
+	buffer.getViewLine(bottom)
+	return int(buffer.RawLine()), int(buffer.convertViewLineToRawLine(bottom)), true
 }
 
+// InsertBlankCharacters inserts blanks at the cursor, as for ICH. The rest of the line moves right, and whatever is
+// pushed past the right edge is lost.
 func (buffer *Buffer) InsertBlankCharacters(count int) {
+	defer buffer.emitDisplayChange()
 
-	index := int(buffer.RawLine())
-	for i := 0; i < count; i++ {
-		cells := buffer.lines[index].cells
-		c := Cell{}
-		buffer.lines[index].cells = append(cells[:buffer.cursorX], append([]Cell{c}, cells[buffer.cursorX:]...)...)
+	line := buffer.getCurrentLine()
+	col := int(buffer.cursorX)
+	if col >= len(line.cells) || count < 1 {
+		// there is nothing after the cursor to shift right
+		return
+	}
+	if count > int(buffer.viewWidth)-col {
+		count = int(buffer.viewWidth) - col
+	}
+	if line.cells[col].continuation {
+		// inserting between the halves of a wide cell splits it, so it goes
+		line.clearWideCell(col)
+		line.cells[col].erase()
+	}
+	blanks := make([]Cell, count)
+	for i := range blanks {
+		blanks[i] = line.blank(buffer.cursorAttr)
+	}
+	cells := append(append(append([]Cell{}, line.cells[:col]...), blanks...), line.cells[col:]...)
+	if len(cells) > int(buffer.viewWidth) {
+		cells = cells[:buffer.viewWidth]
+	}
+	line.cells = cells
+	// a wide cell pushed off the edge leaves only its left half behind
+	if last := len(line.cells) - 1; last >= 0 && line.cells[last].Wide() {
+		line.cells[last].erase()
 	}
 }
 
+// InsertLines inserts blank lines at the cursor, as for IL. The lines below move down, and those pushed past the bottom
+// of the scrolling region are lost.
 func (buffer *Buffer) InsertLines(count int) {
+	defer buffer.emitDisplayChange()
 
-	if buffer.HasScrollableRegion() && !buffer.InScrollableRegion() {
-		// should have no effect outside of scrollable region
+	top, bottom, ok := buffer.editableLines()
+	if !ok || count < 1 {
 		return
 	}
-
 	buffer.cursorX = 0
-
-	for i := 0; i < count; i++ {
-		buffer.insertLine()
+	if count > bottom-top+1 {
+		count = bottom - top + 1
 	}
-
+	copy(buffer.lines[top+count:bottom+1], buffer.lines[top:bottom+1-count])
+	for i := top; i < top+count; i++ {
+		buffer.lines[i] = newLine()
+	}
+	// the line which was at the cursor doesn't carry on from the blank line now above it
+	if top+count <= bottom {
+		buffer.lines[top+count].setWrapped(false)
+	}
+	buffer.unwrapBelow(bottom)
 }
 
+// DeleteLines deletes lines at the cursor, as for DL. The lines below move up, and blank lines fill in at the bottom of
+// the scrolling region.
 func (buffer *Buffer) DeleteLines(count int) {
+	defer buffer.emitDisplayChange()
 
-	if buffer.HasScrollableRegion() && !buffer.InScrollableRegion() {
-		// should have no effect outside of scrollable region
+	top, bottom, ok := buffer.editableLines()
+	if !ok || count < 1 {
 		return
 	}
-
 	buffer.cursorX = 0
-
-	for i := 0; i < count; i++ {
-		buffer.deleteLine()
+	if count > bottom-top+1 {
+		count = bottom - top + 1
 	}
+	copy(buffer.lines[top:bottom+1-count], buffer.lines[top+count:bottom+1])
+	for i := bottom + 1 - count; i <= bottom; i++ {
+		buffer.lines[i] = newLine()
+	}
+	// the line now at the cursor can't carry on from the line above, which it was never next to
+	buffer.lines[top].setWrapped(false)
+	buffer.unwrapBelow(bottom)
+}
 
+// unwrapBelow stops the line after bottom carrying on from it, when the line at bottom has been replaced
+func (buffer *Buffer) unwrapBelow(bottom int) {
+	if bottom+1 < len(buffer.lines) {
+		buffer.lines[bottom+1].setWrapped(false)
+	}
 }
 
 func (buffer *Buffer) Index() {
@@ -559,6 +601,7 @@ func (buffer *Buffer) Index() {
 			buffer.cursorY++
 		} else {
 
+			buffer.getViewLine(uint16(buffer.bottomMargin))
 			topIndex := buffer.convertViewLineToRawLine(uint16(buffer.topMargin))
 			bottomIndex := buffer.convertViewLineToRawLine(uint16(buffer.bottomMargin))
 
@@ -567,6 +610,7 @@ func (buffer *Buffer) Index() {
 			}
 
 			buffer.lines[bottomIndex] = newLine()
+			buffer.lines[topIndex].setWrapped(false)
 		}
 
 		return
@@ -574,6 +618,8 @@ func (buffer *Buffer) Index() {
 
 	if buffer.cursorY >= buffer.ViewHeight()-1 {
 		buffer.lines = append(buffer.lines, newLine())
+		buffer.lineAdded()
+		buffer.trimScrollback()
 	} else {
 		buffer.cursorY++
 	}
@@ -589,6 +635,7 @@ func (buffer *Buffer) ReverseIndex() {
 			buffer.cursorY--
 		} else {
 
+			buffer.getViewLine(uint16(buffer.bottomMargin))
 			topIndex := buffer.convertViewLineToRawLine(uint16(buffer.topMargin))
 			bottomIndex := buffer.convertViewLineToRawLine(uint16(buffer.bottomMargin))
 
@@ -609,9 +656,7 @@ func (buffer *Buffer) ReverseIndex() {
 // Write will write a rune to the terminal at the position of the cursor, and increment the cursor position
 func (buffer *Buffer) Write(runes ...rune) {
 
-	// scroll to bottom on input
 	inc := true
-	buffer.scrollLinesFromBottom = 0
 
 	for _, r := range runes {
 		if r == 0x0a {
@@ -624,6 +669,18 @@ func (buffer *Buffer) Write(runes ...rune) {
 			buffer.Tab()
 			continue
 		}
+		r = buffer.translate(r)
+
+		if buffer.joinCluster(r) {
+			continue
+		}
+
+		wide := runeWidth(r) == 2
+		if wide && buffer.autoWrap && buffer.CursorColumn() == buffer.Width()-1 {
+			// a wide cell won't fit in the last column, so it wraps early
+			buffer.cursorX = buffer.Width()
+		}
+
 		line := buffer.getCurrentLine()
 
 		if buffer.replaceMode {
@@ -634,11 +691,16 @@ func (buffer *Buffer) Write(runes ...rune) {
 			}
 
 			for int(buffer.CursorColumn()) >= len(line.cells) {
-				line.cells = append(line.cells, NewBackgroundCell(buffer.cursorAttr.BgColour))
+				line.cells = append(line.cells, line.blank(buffer.cursorAttr))
 			}
-			line.cells[buffer.cursorX].attr = buffer.cursorAttr
+			line.clearWideCell(int(buffer.cursorX))
+			line.cells[buffer.cursorX].attr = buffer.cursorAttrID()
+			line.cells[buffer.cursorX].link = buffer.cursorLink
 			line.cells[buffer.cursorX].setRune(r)
 			buffer.incrementCursorPosition()
+			if wide && buffer.CursorColumn() < buffer.Width() {
+				buffer.writeContinuation()
+			}
 			continue
 		}
 
@@ -649,12 +711,16 @@ func (buffer *Buffer) Write(runes ...rune) {
 				buffer.NewLine()
 
 				newLine := buffer.getCurrentLine()
+				// mark the line as carrying on from the one before, so it can be joined back up if the view widens
+				newLine.setWrapped(buffer.RawLine() > 0)
 				if len(newLine.cells) == 0 {
 					newLine.cells = []Cell{{}}
 				}
+				newLine.clearWideCell(int(buffer.CursorColumn()))
 				cell := &newLine.cells[buffer.CursorColumn()]
 				cell.setRune(r)
-				cell.attr = buffer.cursorAttr
+				cell.attr = buffer.cursorAttrID()
+				cell.link = buffer.cursorLink
 
 			} else {
 				// no more room on line and wrapping is disabled
@@ -665,17 +731,22 @@ func (buffer *Buffer) Write(runes ...rune) {
 		} else {
 
 			for int(buffer.CursorColumn()) >= len(line.cells) {
-				line.cells = append(line.cells, NewBackgroundCell(buffer.cursorAttr.BgColour))
+				line.cells = append(line.cells, line.blank(buffer.cursorAttr))
 			}
 
+			line.clearWideCell(int(buffer.CursorColumn()))
 			cell := &line.cells[buffer.CursorColumn()]
 			cell.setRune(r)
-			cell.attr = buffer.cursorAttr
+			cell.attr = buffer.cursorAttrID()
+			cell.link = buffer.cursorLink
 
 		}
 
 		if inc {
 			buffer.incrementCursorPosition()
+			if wide && buffer.CursorColumn() < buffer.Width() {
+				buffer.writeContinuation()
+			}
 		}
 	}
 }
@@ -725,18 +796,6 @@ func (buffer *Buffer) CarriageReturn() {
 	buffer.cursorX = 0
 }
 
-func (buffer *Buffer) Tab() {
-	defer buffer.emitDisplayChange()
-	tabSize := 4
-	shift := int(buffer.cursorX-1) % tabSize
-	if shift == 0 {
-		shift = tabSize
-	}
-	for i := 0; i < shift; i++ {
-		buffer.Write(' ')
-	}
-}
-
 func (buffer *Buffer) NewLine() {
 	defer buffer.emitDisplayChange()
 
@@ -792,6 +851,51 @@ func (buffer *Buffer) GetVisibleLines() []Line {
 	return lines
 }
 
+// GetAllLines returns every line in the buffer, from the start of the scrollback to the bottom of the screen
+func (buffer *Buffer) GetAllLines() []Line {
+	return buffer.lines
+}
+
+// GetVisibleText returns the text currently in view, joining lines which were wrapped
+func (buffer *Buffer) GetVisibleText() string {
+	return linesToText(buffer.GetVisibleLines())
+}
+
+// GetAllText returns the text of the whole buffer including scrollback, joining lines which were wrapped
+func (buffer *Buffer) GetAllText() string {
+	return linesToText(buffer.lines)
+}
+
+// CursorLineText returns the text of the line the cursor is on
+func (buffer *Buffer) CursorLineText() string {
+	return buffer.getCurrentLine().String()
+}
+
+func linesToText(lines []Line) string {
+	text := []rune{}
+	for i, line := range lines {
+		if i > 0 && !line.wrapped {
+			text = append(text, '\n')
+		}
+		if i+1 < len(lines) && lines[i+1].wrapped {
+			// keep trailing spaces, they are part of the text which continues on the next line
+			for _, cell := range line.cells {
+				if !cell.continuation {
+					text = append(text, cell.Runes()...)
+				}
+			}
+			continue
+		}
+		text = append(text, []rune(line.String())...)
+	}
+	for i, r := range text {
+		if r == 0 {
+			text[i] = ' '
+		}
+	}
+	return string(text)
+}
+
 // tested to here
 
 func (buffer *Buffer) Clear() {
@@ -799,9 +903,18 @@ func (buffer *Buffer) Clear() {
 	for i := 0; i < int(buffer.ViewHeight()); i++ {
 		buffer.lines = append(buffer.lines, newLine())
 	}
+	buffer.trimScrollback()
 	buffer.SetPosition(0, 0) // do we need to set position?
 }
 
+// InsertMark draws a separator above the cursor line, so output from before it can be told apart from what follows.
+// It only changes the display - nothing is sent to the running program, and no lines are added.
+func (buffer *Buffer) InsertMark() {
+	defer buffer.emitDisplayChange()
+	buffer.getCurrentLine().marked = true
+	buffer.landmarks.changes++
+}
+
 // creates if necessary
 func (buffer *Buffer) getCurrentLine() *Line {
 	return buffer.getViewLine(buffer.cursorY)
@@ -830,37 +943,27 @@ func (buffer *Buffer) getViewLine(index uint16) *Line {
 func (buffer *Buffer) EraseLine() {
 	defer buffer.emitDisplayChange()
 	line := buffer.getCurrentLine()
-	line.cells = []Cell{}
+	line.clear()
 }
 
 func (buffer *Buffer) EraseLineToCursor() {
 	defer buffer.emitDisplayChange()
 	line := buffer.getCurrentLine()
-	for i := 0; i <= int(buffer.cursorX); i++ {
-		if i < len(line.cells) {
-			line.cells[i].erase()
-		}
-	}
+	line.eraseRange(0, int(buffer.cursorX)+1)
 }
 
 func (buffer *Buffer) EraseLineFromCursor() {
 	defer buffer.emitDisplayChange()
 	line := buffer.getCurrentLine()
 
-	if len(line.cells) > 0 {
-		cx := buffer.cursorX
-		if int(cx) < len(line.cells) {
-			line.cells = line.cells[:buffer.cursorX]
-		}
+	if int(buffer.cursorX) < len(line.cells) {
+		line.clearWideCell(int(buffer.cursorX))
+		line.cells = line.cells[:buffer.cursorX]
 	}
 
-	max := int(buffer.ViewWidth()) - len(line.cells)
-
-	buffer.SaveCursor()
-	for i := 0; i < max; i++ {
-		buffer.Write(0)
-	}
-	buffer.RestoreCursor()
+	// the rest of the line is blank in the current attributes, without moving the cursor or wrapping
+	line.filled = true
+	line.fill = buffer.cursorAttrID()
 }
 
 func (buffer *Buffer) EraseDisplay() {
@@ -868,11 +971,13 @@ func (buffer *Buffer) EraseDisplay() {
 	for i := uint16(0); i < (buffer.ViewHeight()); i++ {
 		rawLine := buffer.convertViewLineToRawLine(i)
 		if int(rawLine) < len(buffer.lines) {
-			buffer.lines[int(rawLine)].cells = []Cell{}
+			buffer.lines[int(rawLine)].clear()
 		}
 	}
+	buffer.deleteImagesOnScreen()
 }
 
+// DeleteChars deletes characters at the cursor, as for DCH, moving the rest of the line left
 func (buffer *Buffer) DeleteChars(n int) {
 	defer buffer.emitDisplayChange()
 
@@ -880,27 +985,25 @@ func (buffer *Buffer) DeleteChars(n int) {
 	if int(buffer.cursorX) >= len(line.cells) {
 		return
 	}
-	before := line.cells[:buffer.cursorX]
 	if int(buffer.cursorX)+n >= len(line.cells) {
 		n = len(line.cells) - int(buffer.cursorX)
 	}
+	if n <= 0 {
+		return
+	}
+	line.clearWideCell(int(buffer.cursorX))
+	line.clearWideCell(int(buffer.cursorX) + n - 1)
+	before := line.cells[:buffer.cursorX]
 	after := line.cells[int(buffer.cursorX)+n:]
 	line.cells = append(before, after...)
 }
 
+// EraseCharacters blanks characters from the cursor on, as for ECH, without moving the rest of the line
 func (buffer *Buffer) EraseCharacters(n int) {
 	defer buffer.emitDisplayChange()
 
 	line := buffer.getCurrentLine()
-
-	max := int(buffer.cursorX) + n
-	if max > len(line.cells) {
-		max = len(line.cells)
-	}
-
-	for i := int(buffer.cursorX); i < max; i++ {
-		line.cells[i].erase()
-	}
+	line.eraseRange(int(buffer.cursorX), int(buffer.cursorX)+n)
 }
 
 func (buffer *Buffer) EraseDisplayFromCursor() {
@@ -916,7 +1019,7 @@ func (buffer *Buffer) EraseDisplayFromCursor() {
 	for i := buffer.cursorY + 1; i < buffer.ViewHeight(); i++ {
 		rawLine := buffer.convertViewLineToRawLine(i)
 		if int(rawLine) < len(buffer.lines) {
-			buffer.lines[int(rawLine)].cells = []Cell{}
+			buffer.lines[int(rawLine)].clear()
 		}
 	}
 }
@@ -925,13 +1028,11 @@ func (buffer *Buffer) EraseDisplayToCursor() {
 	defer buffer.emitDisplayChange()
 	line := buffer.getCurrentLine()
 
-	for i := 0; i < int(buffer.cursorX); i++ {
-		line.cells[i].erase()
-	}
+	line.eraseRange(0, int(buffer.cursorX))
 	for i := uint16(0); i < buffer.cursorY; i++ {
 		rawLine := buffer.convertViewLineToRawLine(i)
 		if int(rawLine) < len(buffer.lines) {
-			buffer.lines[int(rawLine)].cells = []Cell{}
+			buffer.lines[int(rawLine)].clear()
 		}
 	}
 }
@@ -947,18 +1048,29 @@ func (buffer *Buffer) ResizeView(width uint16, height uint16) {
 	}
 
 	// @todo scroll to bottom on resize
-	line := buffer.getCurrentLine()
-	cXFromEndOfLine := len(line.cells) - int(buffer.cursorX+1)
-
-	cursorYMovement := 0
+	cursorLineID, cursorOffset := buffer.logicalCursor()
 
 	if width < buffer.viewWidth { // wrap lines if we're shrinking
 		for i := 0; i < len(buffer.lines); i++ {
 			line := &buffer.lines[i]
 			//line.Cleanse()
 			if len(line.cells) > int(width) { // only try wrapping a line if it's too long
-				sillyCells := line.cells[width:] // grab the cells we need to wrap
-				line.cells = line.cells[:width]
+				cut := int(width)
+				if line.cells[cut].continuation {
+					// don't split a wide cell across lines - move all of it, unless it can't fit on a line at all
+					if cut > 1 {
+						cut--
+					} else {
+						line.cells[0].erase()
+						line.cells[1].erase()
+					}
+				}
+				// grab the cells we need to wrap, copying them so they don't share memory with what's left
+				sillyCells := append([]Cell{}, line.cells[cut:]...)
+				line.cells = line.cells[:cut]
+				if len(sillyCells) == 0 {
+					continue
+				}
 
 				// we need to move cut cells to the next line
 				// if the next line is wrapped anyway, we can push them onto the beginning of that line
@@ -971,13 +1083,12 @@ func (buffer *Buffer) ResizeView(width uint16, height uint16) {
 					}
 				}
 
-				if i+1 <= int(buffer.cursorY) {
-					cursorYMovement++
-				}
-
 				newLine := newLine()
 				newLine.setWrapped(true)
 				newLine.cells = sillyCells
+				// the blanks past the end of the line now follow the cells which were cut from it
+				newLine.filled, newLine.fill = line.filled, line.fill
+				line.filled, line.fill = false, 0
 				after := append([]Line{newLine}, buffer.lines[i+1:]...)
 				buffer.lines = append(buffer.lines[:i+1], after...)
 
@@ -1001,14 +1112,18 @@ func (buffer *Buffer) ResizeView(width uint16, height uint16) {
 				if moveCount > len(nextLine.cells) {
 					moveCount = len(nextLine.cells)
 				}
+				if moveCount < len(nextLine.cells) && nextLine.cells[moveCount].continuation {
+					// leave a wide cell which doesn't fit where it is, rather than splitting it
+					moveCount--
+					if moveCount == 0 {
+						break
+					}
+				}
 				line.cells = append(line.cells, nextLine.cells[:moveCount]...)
 				if moveCount == len(nextLine.cells) {
 
-					if i+offset <= int(buffer.cursorY) {
-						cursorYMovement--
-					}
-
 					// if we unwrapped all cells off the next line, delete it
+					line.filled, line.fill = nextLine.filled, nextLine.fill
 					buffer.lines = append(buffer.lines[:i+offset], buffer.lines[i+offset+1:]...)
 
 					offset--
@@ -1025,15 +1140,8 @@ func (buffer *Buffer) ResizeView(width uint16, height uint16) {
 	buffer.viewWidth = width
 	buffer.viewHeight = height
 
-	cY := uint16(len(buffer.lines) - 1)
-	if cY >= buffer.viewHeight {
-		cY = buffer.viewHeight - 1
-	}
-	buffer.cursorY = cY
-
-	// position cursorX
-	line = buffer.getCurrentLine()
-	buffer.cursorX = uint16((len(line.cells) - cXFromEndOfLine) - 1)
+	buffer.placeCursor(cursorLineID, cursorOffset)
 
 	buffer.SetVerticalMargins(0, uint(buffer.viewHeight-1))
+	buffer.trimScrollback()
 }
@@ -452,8 +452,108 @@ goodbyegoodbye`
 	require.Equal(t, uint16(14), b.cursorX)
 }
 
+func TestResizeKeepsCursorOnTheSameCharacter(t *testing.T) {
+	b := NewBuffer(20, 5, CellAttributes{})
+	b.Write([]rune("abcdefghijklmnopqrstuvwxyz\r\n$ ")...)
+	// put the cursor on the "x"
+	b.SetPosition(3, 1)
+
+	b.ResizeView(10, 5)
+	assert.Equal(t, "x", string(b.GetCell(b.CursorColumn(), b.CursorLine()).Rune()))
+
+	b.ResizeView(30, 5)
+	assert.Equal(t, uint16(23), b.CursorColumn())
+	assert.Equal(t, uint16(0), b.CursorLine())
+}
+
+func TestResizeKeepsCursorAboveOtherLines(t *testing.T) {
+	b := NewBuffer(10, 4, CellAttributes{})
+	b.Write([]rune("one\r\ntwo\r\nthree")...)
+	b.SetPosition(1, 0)
+
+	b.ResizeView(12, 4)
+
+	assert.Equal(t, uint16(1), b.CursorColumn())
+	assert.Equal(t, uint16(0), b.CursorLine())
+}
+
+func TestShorterResizeDropsBlankLinesBelowCursor(t *testing.T) {
+	b := NewBuffer(10, 4, CellAttributes{})
+	b.Write([]rune("one\r\ntwo\r\n\r\n")...)
+	b.SetPosition(2, 0)
+
+	b.ResizeView(10, 2)
+
+	assert.Equal(t, "one\ntwo", b.GetAllText())
+	assert.Equal(t, uint16(0), b.CursorLine())
+	assert.Equal(t, uint16(2), b.CursorColumn())
+}
+
 /*
 hellohellohellohellohellohellohellohellohellohellohellohello
 goodbyegoo
 dbye
 */
+
+func TestGetAllText(t *testing.T) {
+	b := NewBuffer(10, 2, CellAttributes{})
+	b.Write([]rune("one\r\n")...)
+	b.Write([]rune("two\r\n")...)
+	b.Write([]rune("wrap it")...)
+	assert.Equal(t, "one\ntwo\nwrap it", b.GetAllText())
+	assert.Equal(t, "two\nwrap it", b.GetVisibleText())
+}
+
+func TestSelectAll(t *testing.T) {
+	b := NewBuffer(10, 3, CellAttributes{})
+	b.Write([]rune("one\r\ntwo\r\nthree\r\nfour")...)
+
+	b.SelectAll()
+
+	assert.Equal(t, "one\ntwo\nthree\nfour", b.GetSelectedText())
+}
+
+func TestInsertMark(t *testing.T) {
+	b := NewBuffer(10, 3, CellAttributes{})
+	b.Write([]rune("old\r\n")...)
+	b.InsertMark()
+	b.Write([]rune("new\r\nmore\r\nlines")...)
+
+	assert.Equal(t, "old\nnew\nmore\nlines", b.GetAllText())
+	for i, line := range b.lines {
+		assert.Equal(t, i == 1, line.Marked(), "line %d", i)
+	}
+}
+
+func TestInsertLinesKeepsScrollback(t *testing.T) {
+	b := NewBuffer(10, 3, CellAttributes{})
+	b.Write([]rune("old\r\none\r\ntwo\r\nthree")...)
+	b.SetPosition(0, 1)
+	b.InsertLines(1)
+
+	assert.Equal(t, "old\none\n\ntwo", b.GetAllText())
+}
+
+func TestDeleteLinesUnwrapsTheLineWhichMovesUp(t *testing.T) {
+	b := NewBuffer(5, 3, CellAttributes{})
+	b.Write([]rune("abcdefgh")...)
+	require.True(t, b.lines[1].wrapped)
+	b.SetPosition(0, 0)
+	b.DeleteLines(1)
+
+	assert.False(t, b.lines[0].wrapped)
+	assert.Equal(t, "fgh", b.lines[0].String())
+}
+
+func TestIndexUnwrapsTheLineWhichMovesToTheTopOfTheRegion(t *testing.T) {
+	b := NewBuffer(5, 6, CellAttributes{})
+	b.Write([]rune("one\r\ntwo\r\nabcdefgh")...)
+	require.True(t, b.lines[3].wrapped)
+	b.SetVerticalMargins(2, 5)
+	b.SetPosition(0, 5)
+	b.Index()
+
+	assert.Equal(t, "fgh", b.lines[2].String())
+	assert.False(t, b.lines[2].wrapped)
+	assert.Equal(t, "one", b.lines[0].String())
+}
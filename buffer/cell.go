@@ -1,50 +1,91 @@
 package buffer
 
-import (
-	"image"
-)
-
 type Cell struct {
-	r     rune
-	attr  CellAttributes
-	image *image.RGBA
+	r            rune
+	attr         attrID // see attributeTable
+	combining    []rune // the rest of a multi-rune grapheme cluster e.g. a ZWJ emoji sequence or flag
+	link         linkID // see hyperlinkTable
+	continuation bool   // the right hand half of a wide cell, which is drawn by the cell before it
 }
 
-type CellAttributes struct {
-	FgColour  [3]float32
-	BgColour  [3]float32
-	Bold      bool
-	Dim       bool
-	Underline bool
-	Blink     bool
-	Reverse   bool
-	Hidden    bool
-}
+// UnderlineStyle is how a cell is underlined, as set by SGR 4:n
+type UnderlineStyle uint8
 
-func (cell *Cell) Image() *image.RGBA {
-	return cell.image
-}
+const (
+	UnderlineNone UnderlineStyle = iota
+	UnderlineSingle
+	UnderlineDouble
+	UnderlineCurly
+	UnderlineDotted
+	UnderlineDashed
+)
+
+// ColourIndex is where a cell's colour was chosen from: the palette, or the default foreground or background, which are
+// numbered after it. The colour is drawn in the current theme's colour for that index, or in the one the program set
+// for it. Colours given as RGB have no index, and are drawn as they are.
+type ColourIndex uint16
 
-func (cell *Cell) SetImage(img *image.RGBA) {
+// ColourRGB is the index of a colour given as RGB
+const ColourRGB ColourIndex = 0
 
-	cell.image = img
+// PaletteColour returns the index of a palette colour, or of one of the default colours numbered after the palette
+func PaletteColour(index int) ColourIndex {
+	return ColourIndex(index + 1)
+}
+
+// Palette returns the index in the palette of the colour, or false if it was given as RGB
+func (index ColourIndex) Palette() (int, bool) {
+	return int(index) - 1, index != ColourRGB
+}
 
+type CellAttributes struct {
+	FgColour          [3]float32
+	BgColour          [3]float32
+	FgIndex           ColourIndex // the palette colour FgColour is, if it is one
+	BgIndex           ColourIndex // the palette colour BgColour is, if it is one
+	UnderlineColour   [3]float32  // used for the underline instead of the foreground colour if UnderlineColoured is set
+	Bold              bool
+	Dim               bool
+	Underline         UnderlineStyle
+	UnderlineColoured bool
+	Blink             bool
+	Reverse           bool
+	Hidden            bool
+	Zone              Zone // the part of the session the cell was written in
 }
 
 func (cell *Cell) Attr() CellAttributes {
-	return cell.attr
+	return *cell.attr.attributes()
 }
 
 func (cell *Cell) Rune() rune {
 	return cell.r
 }
 
+// Runes returns every rune in the cell's grapheme cluster
+func (cell *Cell) Runes() []rune {
+	if len(cell.combining) == 0 {
+		return []rune{cell.r}
+	}
+	return append([]rune{cell.r}, cell.combining...)
+}
+
+// Wide returns true if the cell's content covers it and the cell after it
+func (cell *Cell) Wide() bool {
+	return runeWidth(cell.r) == 2
+}
+
+// IsContinuation returns true if the cell is the right hand half of a wide cell
+func (cell *Cell) IsContinuation() bool {
+	return cell.continuation
+}
+
 func (cell *Cell) Fg() [3]float32 {
-	return cell.attr.FgColour
+	return cell.attr.attributes().FgColour
 }
 
 func (cell *Cell) Bg() [3]float32 {
-	return cell.attr.BgColour
+	return cell.attr.attributes().BgColour
 }
 
 func (cell *Cell) erase() {
@@ -53,12 +94,21 @@ func (cell *Cell) erase() {
 
 func (cell *Cell) setRune(r rune) {
 	cell.r = r
+	cell.combining = nil
+	cell.continuation = false
 }
 
 func NewBackgroundCell(colour [3]float32) Cell {
+	return NewPaletteBackgroundCell(colour, ColourRGB)
+}
+
+// NewPaletteBackgroundCell returns a blank cell with a background chosen from the palette, which is drawn in the current
+// theme's colour for it
+func NewPaletteBackgroundCell(colour [3]float32, index ColourIndex) Cell {
 	return Cell{
-		attr: CellAttributes{
+		attr: internAttributes(CellAttributes{
 			BgColour: colour,
-		},
+			BgIndex:  index,
+		}),
 	}
 }
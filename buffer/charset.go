@@ -0,0 +1,65 @@
+package buffer
+
+// Charset is a character set which output can be drawn from, designated as G0 or G1 by ESC ( and ESC )
+type Charset int
+
+const (
+	CharsetASCII              Charset = iota
+	CharsetDECSpecialGraphics         // line drawing, used by curses for borders
+	CharsetUK                         // ASCII, with £ in place of #
+)
+
+// decSpecialGraphics maps the characters the DEC special graphics set replaces to their unicode equivalents
+var decSpecialGraphics = map[rune]rune{
+	'`': '◆', 'a': '▒', 'b': '␉', 'c': '␌', 'd': '␍', 'e': '␊', 'f': '°', 'g': '±',
+	'h': '␤', 'i': '␋', 'j': '┘', 'k': '┐', 'l': '┌', 'm': '└', 'n': '┼', 'o': '⎺',
+	'p': '⎻', 'q': '─', 'r': '⎼', 's': '⎽', 't': '├', 'u': '┤', 'v': '┴', 'w': '┬',
+	'x': '│', 'y': '≤', 'z': '≥', '{': 'π', '|': '≠', '}': '£', '~': '·',
+}
+
+// DesignateCharset sets the character set used as G0 (set 0) or G1 (set 1)
+func (buffer *Buffer) DesignateCharset(set int, charset Charset) {
+	if set < 0 || set >= len(buffer.charsets) {
+		return
+	}
+	buffer.charsets[set] = charset
+}
+
+// ResetCharsets designates ASCII as both G0 and G1 and shifts back in, as they are when the terminal starts
+func (buffer *Buffer) ResetCharsets() {
+	buffer.charsets = [2]Charset{CharsetASCII, CharsetASCII}
+	buffer.shifted = false
+}
+
+// ShiftOut makes output use G1, as for SO
+func (buffer *Buffer) ShiftOut() {
+	buffer.shifted = true
+}
+
+// ShiftIn makes output use G0 again, as for SI
+func (buffer *Buffer) ShiftIn() {
+	buffer.shifted = false
+}
+
+// Charset returns the character set output is currently drawn from
+func (buffer *Buffer) Charset() Charset {
+	if buffer.shifted {
+		return buffer.charsets[1]
+	}
+	return buffer.charsets[0]
+}
+
+// translate returns the rune to draw for r in the current character set
+func (buffer *Buffer) translate(r rune) rune {
+	switch buffer.Charset() {
+	case CharsetDECSpecialGraphics:
+		if t, ok := decSpecialGraphics[r]; ok {
+			return t
+		}
+	case CharsetUK:
+		if r == '#' {
+			return '£'
+		}
+	}
+	return r
+}
@@ -0,0 +1,61 @@
+package buffer
+
+// ClearScrollback removes the lines which have scrolled out of view, leaving the visible screen as it is
+func (buffer *Buffer) ClearScrollback() {
+	if scrolled := buffer.Height() - int(buffer.ViewHeight()); scrolled > 0 {
+		buffer.removeLinesBefore(scrolled)
+	}
+}
+
+// ClearAll removes the scrollback and every line on screen except the one the cursor is on, which moves to the top
+func (buffer *Buffer) ClearAll() {
+	buffer.removeLinesBefore(int(buffer.RawLine()))
+}
+
+// ClearToPreviousMark removes everything above the closest prompt or separator mark above the cursor line, returning
+// false if there isn't one. At a prompt, this leaves the previous command and its output.
+func (buffer *Buffer) ClearToPreviousMark() bool {
+	for i := int(buffer.RawLine()) - 1; i >= 0 && i < len(buffer.lines); i-- {
+		if buffer.lines[i].marked || buffer.lines[i].prompt {
+			buffer.removeLinesBefore(i)
+			return true
+		}
+	}
+	return false
+}
+
+// MarkPrompt records that a shell prompt starts on the cursor line, e.g. when the shell sends OSC 133;A
+func (buffer *Buffer) MarkPrompt() {
+	buffer.getCurrentLine().prompt = true
+	buffer.landmarks.changes++
+}
+
+// removeLinesBefore deletes the given number of lines from the top of the buffer. The cursor stays on the same line of
+// content, moving up the screen if lines which were visible have gone.
+func (buffer *Buffer) removeLinesBefore(count int) {
+
+	defer buffer.emitDisplayChange()
+
+	if count <= 0 {
+		return
+	}
+	if count > len(buffer.lines) {
+		count = len(buffer.lines)
+	}
+
+	cursor := int(buffer.RawLine()) - count
+	buffer.lines = buffer.lines[count:]
+	if len(buffer.lines) > 0 {
+		// the first line can't continue a line which has gone
+		buffer.lines[0].setWrapped(false)
+	}
+	for len(buffer.lines) < int(buffer.viewHeight) {
+		buffer.lines = append(buffer.lines, newLine())
+	}
+	if cursor < 0 {
+		cursor = 0
+	}
+	buffer.cursorY = buffer.convertRawLineToViewLine(uint64(cursor))
+
+	buffer.follow()
+}
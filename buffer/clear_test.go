@@ -0,0 +1,60 @@
+package buffer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClearScrollback(t *testing.T) {
+	b := NewBuffer(10, 3, CellAttributes{})
+	b.Write([]rune("one\r\ntwo\r\nthree\r\nfour\r\nfive")...)
+	require.Equal(t, 5, b.Height())
+	b.ScrollUp(2)
+
+	b.ClearScrollback()
+
+	assert.Equal(t, 3, b.Height())
+	assert.Equal(t, uint(0), b.GetScrollOffset())
+	assert.Equal(t, "three\nfour\nfive", b.GetAllText())
+	assert.Equal(t, uint16(2), b.CursorLine())
+}
+
+func TestClearAll(t *testing.T) {
+	b := NewBuffer(10, 3, CellAttributes{})
+	b.Write([]rune("one\r\ntwo\r\nthree\r\n$ ls")...)
+
+	b.ClearAll()
+
+	assert.Equal(t, 3, b.Height())
+	assert.Equal(t, "$ ls\n\n", b.GetAllText())
+	assert.Equal(t, uint16(0), b.CursorLine())
+	assert.Equal(t, uint16(4), b.CursorColumn())
+}
+
+func TestClearToPreviousMark(t *testing.T) {
+	b := NewBuffer(10, 3, CellAttributes{})
+	b.Write([]rune("$ make\r\nerror\r\n")...)
+	b.MarkPrompt()
+	b.Write([]rune("$ make\r\nok\r\n")...)
+	b.MarkPrompt()
+	b.Write([]rune("$ ")...)
+
+	require.True(t, b.ClearToPreviousMark())
+	assert.Equal(t, "$ make\nok\n$", b.GetAllText())
+	assert.Equal(t, uint16(2), b.CursorLine())
+
+	b.Write([]rune("ls\r\nfile\r\n")...)
+	require.True(t, b.ClearToPreviousMark())
+	assert.Equal(t, "$ ls\nfile\n", b.GetAllText())
+	assert.Equal(t, uint16(2), b.CursorLine())
+}
+
+func TestClearToPreviousMarkWithoutMarks(t *testing.T) {
+	b := NewBuffer(10, 3, CellAttributes{})
+	b.Write([]rune("one\r\ntwo")...)
+
+	assert.False(t, b.ClearToPreviousMark())
+	assert.Equal(t, "one\ntwo", b.GetAllText())
+}
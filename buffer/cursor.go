@@ -0,0 +1,73 @@
+package buffer
+
+// savedCursor is the state kept by SaveCursor and put back by RestoreCursor
+type savedCursor struct {
+	x          uint16
+	y          uint16
+	attr       CellAttributes
+	originMode bool
+	charsets   [2]Charset
+	shifted    bool
+}
+
+// SaveCursor keeps the cursor position along with the attributes, origin mode and character sets used for output, as
+// for DECSC. Only one save is kept.
+func (buffer *Buffer) SaveCursor() {
+	buffer.saved = savedCursor{
+		x:          buffer.cursorX,
+		y:          buffer.cursorY,
+		attr:       buffer.cursorAttr,
+		originMode: buffer.originMode,
+		charsets:   buffer.charsets,
+		shifted:    buffer.shifted,
+	}
+}
+
+// RestoreCursor puts back what SaveCursor kept, as for DECRC. Without a save, the cursor goes home with the attributes
+// the buffer was created with.
+func (buffer *Buffer) RestoreCursor() {
+	defer buffer.emitDisplayChange()
+
+	saved := buffer.saved
+	// the zone isn't a display attribute, and carries on as it is, as does the link
+	saved.attr.Zone = buffer.cursorAttr.Zone
+	buffer.cursorAttr = saved.attr
+	buffer.originMode = saved.originMode
+	buffer.charsets = saved.charsets
+	buffer.shifted = saved.shifted
+
+	// the screen may have shrunk since the save. The column can be one past the end, where a wrap is pending.
+	buffer.cursorX = saved.x
+	if buffer.cursorX > buffer.viewWidth {
+		buffer.cursorX = buffer.viewWidth
+	}
+	buffer.cursorY = saved.y
+	if buffer.cursorY >= buffer.viewHeight {
+		buffer.cursorY = buffer.viewHeight - 1
+	}
+}
+
+// SetOriginMode turns origin mode (DECOM) on or off, and moves the cursor to the new home position
+func (buffer *Buffer) SetOriginMode(enabled bool) {
+	buffer.originMode = enabled
+	buffer.MoveTo(0, 0)
+}
+
+// OriginMode returns true if cursor positions are relative to the scrolling region
+func (buffer *Buffer) OriginMode() bool {
+	return buffer.originMode
+}
+
+// MoveTo moves the cursor to a position given by the program, as for CUP. In origin mode, the line is counted from the
+// top margin, and the cursor can't leave the scrolling region.
+func (buffer *Buffer) MoveTo(col uint16, line uint16) {
+	if !buffer.originMode {
+		buffer.SetPosition(col, line)
+		return
+	}
+	line += uint16(buffer.topMargin)
+	if uint(line) > buffer.bottomMargin {
+		line = uint16(buffer.bottomMargin)
+	}
+	buffer.SetPosition(col, line)
+}
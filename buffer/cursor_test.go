@@ -0,0 +1,82 @@
+package buffer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRestoreCursorRestoresAttributesAndCharsets(t *testing.T) {
+	b := NewBuffer(10, 5, CellAttributes{FgColour: [3]float32{1, 1, 1}})
+	b.SetPosition(3, 2)
+	b.CursorAttr().Bold = true
+	b.DesignateCharset(0, CharsetDECSpecialGraphics)
+	b.SaveCursor()
+
+	b.SetPosition(0, 4)
+	*b.CursorAttr() = CellAttributes{}
+	b.SetHyperlink("https://example.com")
+	b.DesignateCharset(0, CharsetASCII)
+	b.RestoreCursor()
+
+	assert.Equal(t, uint16(3), b.CursorColumn())
+	assert.Equal(t, uint16(2), b.CursorLine())
+	assert.True(t, b.CursorAttr().Bold)
+	assert.Equal(t, [3]float32{1, 1, 1}, [3]float32(b.CursorAttr().FgColour))
+	// the link isn't part of what is saved
+	assert.Equal(t, "https://example.com", b.Hyperlink())
+	assert.Equal(t, CharsetDECSpecialGraphics, b.Charset())
+}
+
+func TestRestoreCursorWithoutSave(t *testing.T) {
+	attr := CellAttributes{FgColour: [3]float32{1, 0, 0}}
+	b := NewBuffer(10, 5, attr)
+	b.SetPosition(4, 4)
+	b.CursorAttr().Reverse = true
+	b.RestoreCursor()
+
+	assert.Equal(t, uint16(0), b.CursorColumn())
+	assert.Equal(t, uint16(0), b.CursorLine())
+	assert.Equal(t, attr, *b.CursorAttr())
+}
+
+func TestRestoreCursorAfterShrinking(t *testing.T) {
+	b := NewBuffer(10, 5, CellAttributes{})
+	b.SetPosition(9, 4)
+	b.SaveCursor()
+	b.ResizeView(5, 3)
+	b.RestoreCursor()
+
+	assert.True(t, b.CursorColumn() <= 5)
+	assert.Equal(t, uint16(2), b.CursorLine())
+}
+
+func TestOriginMode(t *testing.T) {
+	b := NewBuffer(10, 6, CellAttributes{})
+	b.SetVerticalMargins(1, 3)
+	b.SetOriginMode(true)
+	assert.Equal(t, uint16(1), b.CursorLine())
+
+	b.MoveTo(2, 1)
+	assert.Equal(t, uint16(2), b.CursorLine())
+	b.MoveTo(2, 5)
+	assert.Equal(t, uint16(3), b.CursorLine())
+
+	b.SaveCursor()
+	b.SetOriginMode(false)
+	assert.Equal(t, uint16(0), b.CursorLine())
+	b.RestoreCursor()
+	assert.True(t, b.OriginMode())
+}
+
+func TestCharsetTranslatesOutput(t *testing.T) {
+	b := NewBuffer(10, 2, CellAttributes{})
+	b.DesignateCharset(1, CharsetUK)
+	b.WritePlain([]rune("#"))
+	b.ShiftOut()
+	b.WritePlain([]rune("#"))
+	b.ShiftIn()
+	b.Write('#')
+
+	assert.Equal(t, "#£#", b.lines[0].String())
+}
@@ -0,0 +1,173 @@
+package buffer
+
+// span is a stretch of text from one cell to another, both included, on raw lines
+type span struct {
+	x1 int
+	y1 int
+	x2 int
+	y2 int
+}
+
+// contains returns true if the span covers all of another, and more
+func (s span) contains(other span) bool {
+	startsBefore := s.y1 < other.y1 || s.y1 == other.y1 && s.x1 <= other.x1
+	endsAfter := s.y2 > other.y2 || s.y2 == other.y2 && s.x2 >= other.x2
+	return startsBefore && endsAfter && s != other
+}
+
+// enclosingPairs are the brackets whose insides, and then the brackets with their insides, are selected in turn
+var enclosingPairs = [][2]rune{{'(', ')'}, {'[', ']'}, {'{', '}'}}
+
+// enclosingQuotes are the quotes which are selected like enclosingPairs, except that they don't nest
+var enclosingQuotes = []rune{'"', '\'', '`'}
+
+// ExpandSelection grows the selection to the next bigger unit of text around it, like an editor's expand selection:
+// the word, the inside of the quotes or brackets around it and then those with the quotes or brackets, the line, and
+// last the command output it is part of, if the shell marks prompts with OSC 133
+func (buffer *Buffer) ExpandSelection() {
+	buffer.anchorSelection()
+	if buffer.selectionStart == nil || buffer.selectionEnd == nil {
+		return
+	}
+	x1, y1, x2, y2 := buffer.selectionBounds()
+	current := span{x1: x1, y1: y1, x2: x2, y2: y2}
+
+	for _, unit := range []func(span) (span, bool){buffer.wordSpan, buffer.enclosingSpan, buffer.lineSpan, buffer.outputSpan} {
+		if next, ok := unit(current); ok && next.contains(current) {
+			buffer.selectionStart = buffer.anchor(next.y1, next.x1)
+			buffer.selectionEnd = buffer.anchor(next.y2, next.x2)
+			buffer.selectionComplete = true
+			buffer.emitDisplayChange()
+			return
+		}
+	}
+}
+
+// wordSpan returns the word the selection starts in, if the selection is within it
+func (buffer *Buffer) wordSpan(s span) (span, bool) {
+	if s.y1 != s.y2 {
+		return span{}, false
+	}
+	start, end, ok := buffer.wordBounds(uint64(s.y1), uint16(s.x1))
+	if !ok || s.x2 > int(end) {
+		return span{}, false
+	}
+	return span{x1: int(start), y1: s.y1, x2: int(end), y2: s.y1}, true
+}
+
+// enclosingSpan returns the smallest stretch of the selection's line around it which is the inside of a pair of quotes
+// or brackets, or the pair with its inside. Pairs are matched as for smart selection, so an apostrophe such as the one
+// in "it's" doesn't start a quote.
+func (buffer *Buffer) enclosingSpan(s span) (span, bool) {
+	line, ok := buffer.lineSpan(s)
+	if !ok {
+		return span{}, false
+	}
+	text := []smartRune{}
+	for y := line.y1; y <= line.y2; y++ {
+		for x, cell := range buffer.lines[y].cells {
+			if !cell.continuation {
+				text = append(text, smartRune{r: cell.r, row: y, col: x})
+			}
+		}
+	}
+
+	found := false
+	var best span
+	bestLength := 0
+	for _, pair := range matchSmartPairs(text) {
+		for _, ends := range [][2]int{{pair.open + 1, pair.close - 1}, {pair.open, pair.close}} {
+			if ends[0] > ends[1] {
+				continue
+			}
+			start, end := text[ends[0]], text[ends[1]]
+			candidate := span{x1: start.col, y1: start.row, x2: end.col, y2: end.row}
+			if candidate.contains(s) && (!found || ends[1]-ends[0] < bestLength) {
+				best, bestLength = candidate, ends[1]-ends[0]
+				found = true
+			}
+		}
+	}
+	return best, found
+}
+
+// lineSpan returns the whole of the line the selection is on, including the parts of it wrapped onto other rows
+func (buffer *Buffer) lineSpan(s span) (span, bool) {
+	if s.y2 >= len(buffer.lines) {
+		return span{}, false
+	}
+	start, end := s.y1, s.y2
+	for start > 0 && buffer.lines[start].wrapped {
+		start--
+	}
+	for end+1 < len(buffer.lines) && buffer.lines[end+1].wrapped {
+		end++
+	}
+	return span{x1: 0, y1: start, x2: int(buffer.viewWidth) - 1, y2: end}, true
+}
+
+// outputSpan returns the output of the command the selection is in, from after the command line to before the next
+// prompt. If the selection is in the command line, the command line is included.
+func (buffer *Buffer) outputSpan(s span) (span, bool) {
+	zone := ZoneUnknown
+	for y := s.y1; y <= s.y2 && y < len(buffer.lines) && zone == ZoneUnknown; y++ {
+		for _, cell := range buffer.lines[y].cells {
+			if z := cell.attr.attributes().Zone; z != ZoneUnknown {
+				zone = z
+				break
+			}
+		}
+	}
+	if zone != ZoneOutput && zone != ZoneInput {
+		return span{}, false
+	}
+	boundary := func(cell *Cell) bool {
+		z := cell.attr.attributes().Zone
+		return z == ZonePrompt || z == ZoneInput && zone == ZoneOutput
+	}
+
+	start := span{}
+backwards:
+	for y := s.y1; y >= 0; y-- {
+		cells := buffer.lines[y].cells
+		from := len(cells) - 1
+		if y == s.y1 && s.x1 < from {
+			from = s.x1
+		}
+		for x := from; x >= 0; x-- {
+			if boundary(&cells[x]) {
+				start.y1, start.x1 = y, x+1
+				break backwards
+			}
+		}
+	}
+	if start.x1 >= len(buffer.lines[start.y1].cells) && start.y1 < s.y1 {
+		// the rest of the row the command line ends on is empty, so the output starts on the next
+		start.y1, start.x1 = start.y1+1, 0
+	}
+
+	end := span{y2: len(buffer.lines) - 1, x2: int(buffer.viewWidth) - 1}
+forwards:
+	for y := s.y2; y < len(buffer.lines); y++ {
+		cells := buffer.lines[y].cells
+		from := 0
+		if y == s.y2 {
+			from = s.x2
+		}
+		for x := from; x < len(cells); x++ {
+			if boundary(&cells[x]) {
+				end.y2, end.x2 = y, x-1
+				if end.x2 < 0 {
+					end.y2, end.x2 = y-1, int(buffer.viewWidth)-1
+				}
+				break forwards
+			}
+		}
+	}
+	// leave out the blank rows below output which hasn't finished, down to the cursor
+	for end.y2 > start.y1 && end.y2 > s.y2 && buffer.lines[end.y2].String() == "" {
+		end.y2, end.x2 = end.y2-1, int(buffer.viewWidth)-1
+	}
+
+	return span{x1: start.x1, y1: start.y1, x2: end.x2, y2: end.y2}, true
+}
@@ -0,0 +1,81 @@
+package buffer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandSelection(t *testing.T) {
+	b := NewBuffer(40, 3, CellAttributes{})
+	b.Write([]rune(`echo "say (hello world)" ok`)...)
+
+	b.StartSelection(12, 0)
+	b.EndSelection(12, 0, true)
+	b.SelectWordAtPosition(12, 0)
+	require.Equal(t, "hello", b.GetSelectedText())
+
+	for _, expected := range []string{
+		"hello world",
+		"(hello world)",
+		"say (hello world)",
+		`"say (hello world)"`,
+		`echo "say (hello world)" ok`,
+	} {
+		b.ExpandSelection()
+		assert.Equal(t, expected, b.GetSelectedText())
+	}
+}
+
+func TestExpandSelectionSkipsApostrophes(t *testing.T) {
+	b := NewBuffer(40, 3, CellAttributes{})
+	b.Write([]rune(`say 'it's (here)' ok`)...)
+
+	b.SelectWordAtPosition(11, 0)
+	require.Equal(t, "here", b.GetSelectedText())
+
+	for _, expected := range []string{
+		"(here)",
+		"it's (here)",
+		"'it's (here)'",
+		`say 'it's (here)' ok`,
+	} {
+		b.ExpandSelection()
+		assert.Equal(t, expected, b.GetSelectedText())
+	}
+}
+
+func TestExpandSelectionToCommandOutput(t *testing.T) {
+	b := NewBuffer(20, 10, CellAttributes{})
+	write := func(zone Zone, s string) {
+		b.SetZone(zone)
+		b.Write([]rune(s)...)
+	}
+	newLine := func() {
+		b.CarriageReturn()
+		b.NewLine()
+	}
+
+	write(ZonePrompt, "$ ")
+	write(ZoneInput, "ls")
+	newLine()
+	write(ZoneOutput, "a.txt")
+	newLine()
+	write(ZoneOutput, "b.txt c.txt")
+	newLine()
+	write(ZonePrompt, "$ ")
+	write(ZoneInput, "pwd")
+
+	b.SelectWordAtPosition(0, 2)
+	require.Equal(t, "b.txt", b.GetSelectedText())
+
+	b.ExpandSelection()
+	assert.Equal(t, "b.txt c.txt", b.GetSelectedText())
+	b.ExpandSelection()
+	assert.Equal(t, "a.txt\nb.txt c.txt", b.GetSelectedText())
+
+	// there is nothing bigger to expand to
+	b.ExpandSelection()
+	assert.Equal(t, "a.txt\nb.txt c.txt", b.GetSelectedText())
+}
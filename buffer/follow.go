@@ -0,0 +1,43 @@
+package buffer
+
+// While the view is at the bottom it follows new output. Once the user scrolls up or starts selecting, it is held on
+// the lines they are reading instead, until they scroll back to the bottom.
+
+// holdView stops the view following output
+func (buffer *Buffer) holdView() {
+	buffer.viewHeld = true
+}
+
+// follow puts the view back at the bottom, following output again
+func (buffer *Buffer) follow() {
+	buffer.viewHeld = false
+	buffer.newLinesBelow = 0
+	buffer.scrollLinesFromBottom = 0
+}
+
+// releaseView goes back to following output if the view was only held by the user starting a selection, rather than
+// them scrolling up
+func (buffer *Buffer) releaseView() {
+	if buffer.viewHeld && buffer.scrollLinesFromBottom <= uint(buffer.newLinesBelow) {
+		buffer.follow()
+	}
+}
+
+// Following returns true if the view is following output, rather than being held where the user left it
+func (buffer *Buffer) Following() bool {
+	return !buffer.viewHeld
+}
+
+// NewLinesBelow returns how many lines of output have arrived below the view since it was held
+func (buffer *Buffer) NewLinesBelow() int {
+	return buffer.newLinesBelow
+}
+
+// lineAdded is called when output adds a line to the bottom of the buffer, moving everything else up a line
+func (buffer *Buffer) lineAdded() {
+	if !buffer.viewHeld {
+		return
+	}
+	buffer.newLinesBelow++
+	buffer.scrollLinesFromBottom++
+}
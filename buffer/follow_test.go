@@ -0,0 +1,64 @@
+package buffer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestViewFollowsOutputAtBottom(t *testing.T) {
+	b := NewBuffer(10, 2, CellAttributes{})
+	b.Write([]rune("one\r\ntwo\r\nthree")...)
+
+	assert.True(t, b.Following())
+	assert.Equal(t, uint(0), b.GetScrollOffset())
+	assert.Equal(t, 0, b.NewLinesBelow())
+}
+
+func TestScrollingUpHoldsView(t *testing.T) {
+	b := NewBuffer(10, 2, CellAttributes{})
+	b.Write([]rune("one\r\ntwo\r\nthree\r\nfour")...)
+	b.ScrollUp(1)
+
+	b.Write([]rune("\r\nfive\r\nsix")...)
+
+	assert.False(t, b.Following())
+	assert.Equal(t, 2, b.NewLinesBelow())
+	assert.Equal(t, uint(3), b.GetScrollOffset())
+	assert.Equal(t, "two", b.GetVisibleLines()[0].String())
+
+	b.ScrollDown(3)
+
+	assert.True(t, b.Following())
+	assert.Equal(t, 0, b.NewLinesBelow())
+	assert.Equal(t, "five", b.GetVisibleLines()[0].String())
+}
+
+func TestSelectingHoldsView(t *testing.T) {
+	b := NewBuffer(10, 2, CellAttributes{})
+	b.Write([]rune("one\r\ntwo")...)
+	b.StartSelection(0, 0)
+	b.EndSelection(2, 0, false)
+
+	b.Write([]rune("\r\nthree")...)
+
+	assert.Equal(t, 1, b.NewLinesBelow())
+	assert.Equal(t, "one", b.GetVisibleLines()[0].String())
+
+	b.ScrollToEnd()
+
+	assert.True(t, b.Following())
+	assert.Equal(t, "two", b.GetVisibleLines()[0].String())
+}
+
+func TestClickDoesNotHoldView(t *testing.T) {
+	b := NewBuffer(10, 2, CellAttributes{})
+	b.Write([]rune("one\r\ntwo")...)
+	b.StartSelection(1, 0)
+	b.EndSelection(1, 0, true)
+
+	b.Write([]rune("\r\nthree")...)
+
+	assert.True(t, b.Following())
+	assert.Equal(t, "two", b.GetVisibleLines()[0].String())
+}
@@ -0,0 +1,130 @@
+package buffer
+
+const (
+	zeroWidthJoiner   = 0x200D
+	combiningKeycap   = 0x20E3
+	textPresentation  = 0xFE0E
+	emojiPresentation = 0xFE0F
+)
+
+// isWideEmoji returns true for runes which start a two column emoji cluster
+func isWideEmoji(r rune) bool {
+	switch {
+	case r >= 0x1F1E6 && r <= 0x1F1FF: // regional indicators, which pair up into flags
+		return true
+	case r >= 0x1F300 && r <= 0x1F64F: // misc symbols and pictographs, emoticons
+		return true
+	case r >= 0x1F680 && r <= 0x1F6FF: // transport and map symbols
+		return true
+	case r >= 0x1F900 && r <= 0x1F9FF: // supplemental symbols and pictographs
+		return true
+	case r >= 0x1FA70 && r <= 0x1FAFF: // symbols and pictographs extended-A
+		return true
+	}
+	return false
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
+
+func isSkinToneModifier(r rune) bool {
+	return r >= 0x1F3FB && r <= 0x1F3FF
+}
+
+func isTag(r rune) bool {
+	return r >= 0xE0020 && r <= 0xE007F
+}
+
+// extendsCluster returns true if r belongs to the same grapheme cluster as the runes already in a cell, rather than
+// starting a cell of its own
+func extendsCluster(cluster []rune, r rune) bool {
+	if len(cluster) == 0 || cluster[0] == 0 {
+		return false
+	}
+	last := cluster[len(cluster)-1]
+	switch {
+	case r == zeroWidthJoiner, r == textPresentation, r == emojiPresentation, r == combiningKeycap:
+		return true
+	case isSkinToneModifier(r), isTag(r):
+		return true
+	case runeWidth(r) == 0:
+		// combining marks and the like are drawn over the character before them
+		return true
+	case last == zeroWidthJoiner:
+		return isWideEmoji(r) || r >= 0x2600 && r <= 0x27BF
+	case isRegionalIndicator(r):
+		// flags are made of exactly two regional indicators
+		return len(cluster) == 1 && isRegionalIndicator(cluster[0])
+	}
+	return false
+}
+
+// joinCluster appends r to the cluster in the cell before the cursor if it extends it, returning true if it did
+func (buffer *Buffer) joinCluster(r rune) bool {
+	line := buffer.getCurrentLine()
+	if line == nil {
+		return false
+	}
+	col := int(buffer.CursorColumn()) - 1
+	if col >= 0 && col < len(line.cells) && line.cells[col].continuation {
+		col--
+	}
+	if col < 0 || col >= len(line.cells) {
+		return false
+	}
+	cell := &line.cells[col]
+	if !extendsCluster(cell.Runes(), r) {
+		return false
+	}
+	cell.combining = append(cell.combining, r)
+	buffer.emitDisplayChange()
+	return true
+}
+
+// clearWideCell erases the other half of a wide cell when one half of it is about to be overwritten
+func (line *Line) clearWideCell(col int) {
+	if col < 0 || col >= len(line.cells) {
+		return
+	}
+	if line.cells[col].continuation && col > 0 {
+		line.cells[col-1].erase()
+	}
+	if col+1 < len(line.cells) && line.cells[col+1].continuation {
+		line.cells[col+1].erase()
+	}
+}
+
+// eraseRange erases the cells from one column up to but not including another, along with the other halves of any
+// wide cells cut in two at either end
+func (line *Line) eraseRange(from int, to int) {
+	if to > len(line.cells) {
+		to = len(line.cells)
+	}
+	if from >= to {
+		return
+	}
+	line.clearWideCell(from)
+	line.clearWideCell(to - 1)
+	for i := from; i < to; i++ {
+		line.cells[i].erase()
+	}
+}
+
+// writeContinuation fills the cell under the cursor with the right hand half of a wide cell
+func (buffer *Buffer) writeContinuation() {
+	line := buffer.getCurrentLine()
+	for int(buffer.CursorColumn()) >= len(line.cells) {
+		line.cells = append(line.cells, line.blank(buffer.cursorAttr))
+	}
+	col := int(buffer.CursorColumn())
+	if col+1 < len(line.cells) && line.cells[col+1].continuation {
+		line.cells[col+1].erase()
+	}
+	cell := &line.cells[col]
+	cell.setRune(0)
+	cell.attr = buffer.cursorAttrID()
+	cell.link = buffer.cursorLink
+	cell.continuation = true
+	buffer.incrementCursorPosition()
+}
@@ -0,0 +1,69 @@
+package buffer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmojiClusters(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "family", input: "\U0001F468\u200d\U0001F469\u200d\U0001F467"},
+		{name: "flag", input: "\U0001F1EC\U0001F1E7"},
+		{name: "skin tone", input: "\U0001F44D\U0001F3FD"},
+		{name: "presentation selector", input: "\U0001F600\ufe0f"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b := NewBuffer(10, 3, CellAttributes{})
+			b.Write([]rune(test.input + "x")...)
+
+			cells := b.lines[0].cells
+			require.Len(t, cells, 3)
+			assert.Equal(t, []rune(test.input), cells[0].Runes())
+			assert.True(t, cells[0].Wide())
+			assert.True(t, cells[1].IsContinuation())
+			assert.Equal(t, 'x', cells[2].Rune())
+			assert.Equal(t, uint16(3), b.CursorColumn())
+			assert.Equal(t, test.input+"x", b.lines[0].String())
+		})
+	}
+}
+
+func TestFlagsDoNotMerge(t *testing.T) {
+	b := NewBuffer(10, 3, CellAttributes{})
+	b.Write([]rune("\U0001F1EC\U0001F1E7\U0001F1EB\U0001F1F7")...)
+
+	cells := b.lines[0].cells
+	require.Len(t, cells, 4)
+	assert.Equal(t, []rune("\U0001F1EC\U0001F1E7"), cells[0].Runes())
+	assert.Equal(t, []rune("\U0001F1EB\U0001F1F7"), cells[2].Runes())
+}
+
+func TestWideCellWrapsEarly(t *testing.T) {
+	b := NewBuffer(5, 3, CellAttributes{})
+	b.Write([]rune("abcd\U0001F600")...)
+
+	assert.Equal(t, "abcd", b.lines[0].String())
+	assert.Equal(t, "\U0001F600", b.lines[1].String())
+	assert.True(t, b.lines[1].cells[1].IsContinuation())
+	assert.Equal(t, uint16(2), b.CursorColumn())
+}
+
+func TestOverwritingHalfOfWideCell(t *testing.T) {
+	b := NewBuffer(10, 3, CellAttributes{})
+	b.Write([]rune("\U0001F600")...)
+	b.SetPosition(1, 0)
+	b.Write('x')
+
+	cells := b.lines[0].cells
+	assert.Equal(t, rune(0), cells[0].Rune())
+	assert.False(t, cells[1].IsContinuation())
+	assert.Equal(t, 'x', cells[1].Rune())
+}
@@ -0,0 +1,107 @@
+package buffer
+
+import "sync"
+
+// linkID is the index of the target of an OSC 8 hyperlink in its buffer's hyperlinkTable, with 0 for no link
+type linkID uint32
+
+// the fewest links a buffer keeps before looking for ones which are no longer used
+const minHyperlinks = 256
+
+// hyperlinkTable holds the targets of the hyperlinks written to a buffer, so that each cell can store a small index
+// rather than the URL. Unlike other attributes, almost every link is different, e.g. one for each file listed, so they
+// aren't interned for good with the rest: once the table fills up, the indexes no longer used by any cell in the buffer
+// are given to new links.
+type hyperlinkTable struct {
+	sync.Mutex
+	urls  []string // by index, with "" for unused indexes
+	ids   map[string]linkID
+	free  []linkID // unused indexes, to be given out before the table grows
+	limit int      // the size the table grows to before looking for unused indexes
+}
+
+// SetHyperlink sets the target of the link cells are written with from now on, as for OSC 8, or "" for none
+func (buffer *Buffer) SetHyperlink(url string) {
+	buffer.cursorLinkURL = url
+	if url == "" {
+		buffer.cursorLink = 0
+		return
+	}
+	buffer.cursorLink = buffer.internHyperlink(url)
+}
+
+// Hyperlink returns the target of the link cells are being written with, or "" if there isn't one
+func (buffer *Buffer) Hyperlink() string {
+	return buffer.cursorLinkURL
+}
+
+// CellHyperlink returns the target of the link a cell in the buffer is part of, or "" if it isn't part of one
+func (buffer *Buffer) CellHyperlink(cell *Cell) string {
+	if cell.link == 0 {
+		return ""
+	}
+	table := &buffer.hyperlinks
+	table.Lock()
+	defer table.Unlock()
+	if int(cell.link) >= len(table.urls) {
+		return ""
+	}
+	return table.urls[cell.link]
+}
+
+func (buffer *Buffer) internHyperlink(url string) linkID {
+	table := &buffer.hyperlinks
+	table.Lock()
+	defer table.Unlock()
+	if id, ok := table.ids[url]; ok {
+		return id
+	}
+	if table.ids == nil {
+		table.urls = []string{""}
+		table.ids = map[string]linkID{}
+	}
+	if len(table.free) == 0 && len(table.urls) >= table.limit {
+		buffer.freeUnusedHyperlinks()
+	}
+
+	var id linkID
+	if n := len(table.free); n > 0 {
+		id = table.free[n-1]
+		table.free = table.free[:n-1]
+		table.urls[id] = url
+	} else {
+		id = linkID(len(table.urls))
+		table.urls = append(table.urls, url)
+	}
+	table.ids[url] = id
+	return id
+}
+
+// freeUnusedHyperlinks frees the indexes of the links no cell uses any more, e.g. as their lines have left the
+// scrollback, and lets the table grow to twice the links still in use, so it isn't searched on every new link
+func (buffer *Buffer) freeUnusedHyperlinks() {
+	table := &buffer.hyperlinks
+	used := make([]bool, len(table.urls))
+	used[buffer.cursorLink] = true
+	for i := range buffer.lines {
+		for j := range buffer.lines[i].cells {
+			used[buffer.lines[i].cells[j].link] = true
+		}
+	}
+
+	inUse := 0
+	for id := 1; id < len(table.urls); id++ {
+		switch {
+		case used[id]:
+			inUse++
+		case table.urls[id] != "":
+			delete(table.ids, table.urls[id])
+			table.urls[id] = ""
+			table.free = append(table.free, linkID(id))
+		}
+	}
+	table.limit = 2 * inUse
+	if table.limit < minHyperlinks {
+		table.limit = minHyperlinks
+	}
+}
@@ -0,0 +1,37 @@
+package buffer
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHyperlinksLeavingTheBufferAreFreed(t *testing.T) {
+	b := NewBuffer(10, 2, CellAttributes{})
+	b.SetScrollbackLimit(2)
+	for i := 0; i < 10*minHyperlinks; i++ {
+		b.SetHyperlink(fmt.Sprintf("file:///%d", i))
+		b.Write([]rune("\r\nfile")...)
+	}
+	last := fmt.Sprintf("file:///%d", 10*minHyperlinks-1)
+	b.SetHyperlink("")
+
+	assert.True(t, len(b.hyperlinks.urls) <= minHyperlinks+1, len(b.hyperlinks.urls))
+	assert.Equal(t, last, b.CellHyperlink(b.GetCell(0, 1)))
+	assert.Equal(t, fmt.Sprintf("file:///%d", 10*minHyperlinks-2), b.CellHyperlink(b.GetCell(0, 0)))
+}
+
+func TestHyperlinksAreShared(t *testing.T) {
+	b := NewBuffer(20, 2, CellAttributes{})
+	b.SetHyperlink("https://example.com")
+	b.Write('a')
+	b.SetHyperlink("")
+	b.Write('b')
+	b.SetHyperlink("https://example.com")
+	b.Write('c')
+
+	assert.Equal(t, b.GetCell(0, 0).link, b.GetCell(2, 0).link)
+	assert.Equal(t, "", b.CellHyperlink(b.GetCell(1, 0)))
+	assert.Equal(t, "https://example.com", b.Hyperlink())
+}
@@ -0,0 +1,99 @@
+package buffer
+
+import (
+	"image"
+	"math"
+)
+
+// the most images placed in a buffer, the oldest being removed first
+const maxImages = 256
+
+// Image is a picture placed over the cells by a program, e.g. with sixel or the kitty graphics protocol. Its top left
+// corner is anchored to a cell, so it moves with the line it was placed on and goes when that line leaves the
+// scrollback. Text in the cells it covers is drawn over it.
+type Image struct {
+	ID     uint32 // the kitty graphics image id, or 0 if it wasn't placed with one
+	Pixels *image.RGBA
+	Width  float32 // in cells, which may be fractional so the image keeps its size and shape
+	Height float32
+	pos    *Position
+}
+
+// Cols returns how many columns the image covers, at least in part
+func (img *Image) Cols() int {
+	return int(math.Ceil(float64(img.Width)))
+}
+
+// Rows returns how many lines the image covers, at least in part
+func (img *Image) Rows() int {
+	return int(math.Ceil(float64(img.Height)))
+}
+
+// ImagePlacement is where an image is in the view. Row is negative for an image which starts above it.
+type ImagePlacement struct {
+	*Image
+	Col int
+	Row int
+}
+
+// PlaceImage puts an image's top left corner at the cursor
+func (buffer *Buffer) PlaceImage(img *Image) {
+	defer buffer.emitDisplayChange()
+
+	buffer.getCurrentLine() // make sure the cursor line exists
+	img.pos = buffer.anchor(int(buffer.RawLine()), int(buffer.cursorX))
+	buffer.DeleteImages(func(ImagePlacement) bool { return false }) // drop those whose lines have gone
+	buffer.images = append(buffer.images, img)
+	if len(buffer.images) > maxImages {
+		buffer.images = append(buffer.images[:0], buffer.images[len(buffer.images)-maxImages:]...)
+	}
+}
+
+// DeleteImages removes the images for which match returns true
+func (buffer *Buffer) DeleteImages(match func(placement ImagePlacement) bool) {
+	defer buffer.emitDisplayChange()
+
+	kept := []*Image{}
+	for _, img := range buffer.images {
+		if buffer.resolve(img.pos) && !match(buffer.placement(img)) {
+			kept = append(kept, img)
+		}
+	}
+	buffer.images = kept
+}
+
+// deleteImagesOnScreen removes the images placed on the screen, rather than in the scrollback, e.g. as it is cleared
+func (buffer *Buffer) deleteImagesOnScreen() {
+	top := int(buffer.convertViewLineToRawLine(0))
+	kept := []*Image{}
+	for _, img := range buffer.images {
+		if buffer.resolve(img.pos) && img.pos.Line < top {
+			kept = append(kept, img)
+		}
+	}
+	buffer.images = kept
+}
+
+// VisibleImages returns the images which are at least partly in view, oldest first
+func (buffer *Buffer) VisibleImages() []ImagePlacement {
+	placements := []ImagePlacement{}
+	for _, img := range buffer.images {
+		if !buffer.resolve(img.pos) {
+			continue // its line has left the scrollback, and it is dropped when the next image is placed
+		}
+		placement := buffer.placement(img)
+		if placement.Row < int(buffer.viewHeight) && placement.Row+img.Rows() > 0 {
+			placements = append(placements, placement)
+		}
+	}
+	return placements
+}
+
+// placement returns where a resolved image is relative to the top of the view, as scrolled
+func (buffer *Buffer) placement(img *Image) ImagePlacement {
+	top := buffer.Height() - int(buffer.viewHeight) - int(buffer.scrollLinesFromBottom)
+	if buffer.Height() < int(buffer.viewHeight) {
+		top = 0
+	}
+	return ImagePlacement{Image: img, Col: img.pos.Col, Row: img.pos.Line - top}
+}
@@ -0,0 +1,65 @@
+package buffer
+
+import (
+	"image"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImagesMoveWithTheirLine(t *testing.T) {
+	b := NewBuffer(10, 3, CellAttributes{})
+	b.Write([]rune("one\r\ntwo")...)
+
+	img := &Image{Pixels: image.NewRGBA(image.Rect(0, 0, 20, 40)), Width: 2, Height: 2}
+	b.PlaceImage(img)
+
+	placed := b.VisibleImages()
+	require.Len(t, placed, 1)
+	assert.Equal(t, 3, placed[0].Col)
+	assert.Equal(t, 1, placed[0].Row)
+
+	// output scrolls the image up, until it is off the top of the screen
+	b.Write([]rune("\r\nthree\r\nfour")...)
+	placed = b.VisibleImages()
+	require.Len(t, placed, 1)
+	assert.Equal(t, 0, placed[0].Row)
+
+	b.Write([]rune("\r\nfive\r\nsix")...)
+	assert.Empty(t, b.VisibleImages())
+
+	// and back into view when the scrollback is looked at
+	b.ScrollUp(2)
+	placed = b.VisibleImages()
+	require.Len(t, placed, 1)
+	assert.Equal(t, 0, placed[0].Row)
+}
+
+func TestClearingTheScreenDeletesItsImages(t *testing.T) {
+	b := NewBuffer(10, 3, CellAttributes{})
+	b.PlaceImage(&Image{Pixels: image.NewRGBA(image.Rect(0, 0, 10, 10)), Width: 1, Height: 1})
+	b.Write([]rune("one\r\ntwo\r\nthree\r\nfour")...)
+	b.PlaceImage(&Image{Pixels: image.NewRGBA(image.Rect(0, 0, 10, 10)), Width: 1, Height: 1})
+
+	b.EraseDisplay()
+	assert.Empty(t, b.VisibleImages())
+
+	// the image in the scrollback is kept
+	b.ScrollUp(1)
+	assert.Len(t, b.VisibleImages(), 1)
+}
+
+func TestDeletingImages(t *testing.T) {
+	b := NewBuffer(10, 3, CellAttributes{})
+	b.PlaceImage(&Image{ID: 1, Pixels: image.NewRGBA(image.Rect(0, 0, 10, 10)), Width: 1, Height: 1})
+	b.PlaceImage(&Image{ID: 2, Pixels: image.NewRGBA(image.Rect(0, 0, 10, 10)), Width: 1, Height: 1})
+
+	b.DeleteImages(func(placement ImagePlacement) bool {
+		return placement.ID == 1
+	})
+
+	placed := b.VisibleImages()
+	require.Len(t, placed, 1)
+	assert.Equal(t, uint32(2), placed[0].ID)
+}
@@ -0,0 +1,48 @@
+package buffer
+
+import "fmt"
+
+// Verify checks the invariants every operation on a buffer should preserve, returning an error describing the first
+// one broken. Builds with the debug tag check them after every operation which changes the display, and panic if one
+// is broken, so bugs show up where they happen rather than as garbled output later.
+func (buffer *Buffer) Verify() error {
+
+	if buffer.viewWidth == 0 || buffer.viewHeight == 0 {
+		return fmt.Errorf("View is empty: %dx%d", buffer.viewWidth, buffer.viewHeight)
+	}
+	if buffer.cursorX > buffer.viewWidth {
+		return fmt.Errorf("Cursor column %d is beyond the width %d", buffer.cursorX, buffer.viewWidth)
+	}
+	if buffer.cursorY >= buffer.viewHeight {
+		return fmt.Errorf("Cursor line %d is beyond the height %d", buffer.cursorY, buffer.viewHeight)
+	}
+	if buffer.topMargin > buffer.bottomMargin || buffer.bottomMargin >= uint(buffer.viewHeight) {
+		return fmt.Errorf("Invalid margins %d-%d for height %d", buffer.topMargin, buffer.bottomMargin, buffer.viewHeight)
+	}
+
+	for i, line := range buffer.lines {
+		if len(line.cells) > int(buffer.viewWidth) {
+			return fmt.Errorf("Line %d has %d cells, more than the width %d", i, len(line.cells), buffer.viewWidth)
+		}
+		if line.wrapped && i == 0 {
+			return fmt.Errorf("Line 0 is marked as wrapped, but there is no line before it")
+		}
+		for col, cell := range line.cells {
+			if cell.continuation && (col == 0 || !line.cells[col-1].Wide()) {
+				return fmt.Errorf("Line %d has a continuation cell at column %d which doesn't follow a wide cell", i, col)
+			}
+		}
+	}
+
+	return nil
+}
+
+// verifyInvariants panics if an invariant is broken, in builds with the debug tag
+func (buffer *Buffer) verifyInvariants() {
+	if !invariantsEnabled {
+		return
+	}
+	if err := buffer.Verify(); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,87 @@
+package buffer
+
+import (
+	"math/rand"
+	"testing"
+)
+
+type operation struct {
+	name string
+	run  func(b *Buffer, r *rand.Rand)
+}
+
+var testRunes = []rune("abcXYZ 123\U0001F600é‍")
+
+var operations = []operation{
+	{"write", func(b *Buffer, r *rand.Rand) {
+		runes := make([]rune, r.Intn(30))
+		for i := range runes {
+			runes[i] = testRunes[r.Intn(len(testRunes))]
+		}
+		b.Write(runes...)
+	}},
+	{"newline", func(b *Buffer, r *rand.Rand) { b.NewLine() }},
+	{"carriage return", func(b *Buffer, r *rand.Rand) { b.CarriageReturn() }},
+	{"backspace", func(b *Buffer, r *rand.Rand) { b.Backspace() }},
+	{"tab", func(b *Buffer, r *rand.Rand) { b.Tab() }},
+	{"index", func(b *Buffer, r *rand.Rand) { b.Index() }},
+	{"reverse index", func(b *Buffer, r *rand.Rand) { b.ReverseIndex() }},
+	{"set position", func(b *Buffer, r *rand.Rand) {
+		b.SetPosition(uint16(r.Intn(int(b.ViewWidth())+5)), uint16(r.Intn(int(b.ViewHeight())+5)))
+	}},
+	{"move position", func(b *Buffer, r *rand.Rand) { b.MovePosition(int16(r.Intn(21)-10), int16(r.Intn(21)-10)) }},
+	{"insert lines", func(b *Buffer, r *rand.Rand) { b.InsertLines(r.Intn(5)) }},
+	{"delete lines", func(b *Buffer, r *rand.Rand) { b.DeleteLines(r.Intn(5)) }},
+	{"insert blanks", func(b *Buffer, r *rand.Rand) { b.InsertBlankCharacters(r.Intn(5)) }},
+	{"delete chars", func(b *Buffer, r *rand.Rand) { b.DeleteChars(r.Intn(5)) }},
+	{"erase chars", func(b *Buffer, r *rand.Rand) { b.EraseCharacters(r.Intn(5)) }},
+	{"erase line", func(b *Buffer, r *rand.Rand) { b.EraseLine() }},
+	{"erase line to cursor", func(b *Buffer, r *rand.Rand) { b.EraseLineToCursor() }},
+	{"erase line from cursor", func(b *Buffer, r *rand.Rand) { b.EraseLineFromCursor() }},
+	{"erase display", func(b *Buffer, r *rand.Rand) { b.EraseDisplay() }},
+	{"erase display to cursor", func(b *Buffer, r *rand.Rand) { b.EraseDisplayToCursor() }},
+	{"erase display from cursor", func(b *Buffer, r *rand.Rand) { b.EraseDisplayFromCursor() }},
+	{"margins", func(b *Buffer, r *rand.Rand) {
+		top := r.Intn(int(b.ViewHeight()))
+		bottom := top + r.Intn(int(b.ViewHeight())-top)
+		b.SetVerticalMargins(uint(top), uint(bottom))
+	}},
+	{"resize", func(b *Buffer, r *rand.Rand) {
+		b.ResizeView(uint16(r.Intn(30)+1), uint16(r.Intn(15)+1))
+	}},
+	{"scroll", func(b *Buffer, r *rand.Rand) {
+		b.ScrollUp(uint16(r.Intn(5)))
+		b.ScrollDown(uint16(r.Intn(5)))
+	}},
+	{"save and restore cursor", func(b *Buffer, r *rand.Rand) {
+		b.SaveCursor()
+		b.RestoreCursor()
+	}},
+	{"clear", func(b *Buffer, r *rand.Rand) { b.Clear() }},
+	{"clear scrollback", func(b *Buffer, r *rand.Rand) { b.ClearScrollback() }},
+}
+
+// TestRandomOperationsKeepInvariants hammers buffers with random sequences of operations, checking the invariants
+// after each one. A failure names the seed, so it can be reproduced.
+func TestRandomOperationsKeepInvariants(t *testing.T) {
+	for seed := int64(1); seed <= 500; seed++ {
+		r := rand.New(rand.NewSource(seed))
+		b := NewBuffer(uint16(r.Intn(30)+1), uint16(r.Intn(15)+1), CellAttributes{})
+		history := []string{}
+		for i := 0; i < 200; i++ {
+			op := operations[r.Intn(len(operations))]
+			history = append(history, op.name)
+			op.run(b, r)
+			if err := b.Verify(); err != nil {
+				t.Fatalf("Seed %d: %s, after %v", seed, err, history[max(0, len(history)-5):])
+			}
+		}
+	}
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,92 @@
+package buffer
+
+// LandmarkKind is why a line stands out when looking back through the session
+type LandmarkKind uint8
+
+const (
+	LandmarkPrompt LandmarkKind = iota // the shell's prompt started on the line, after OSC 133;A
+	LandmarkMark                       // a separator was drawn above the line, see InsertMark
+	LandmarkBell                       // the bell rang with the cursor on the line
+	LandmarkError                      // the prompt a command which failed was typed at
+)
+
+// Landmark is a line which stands out, by its raw line number
+type Landmark struct {
+	Line int
+	Kind LandmarkKind
+}
+
+// MarkBell records that the bell rang with the cursor on its line
+func (buffer *Buffer) MarkBell() {
+	buffer.getCurrentLine().bell = true
+	buffer.landmarks.changes++
+}
+
+// StartCommand records that the shell is running a command, e.g. after OSC 133;C. The shell has usually moved on to
+// the next line by then, so the command belongs to the closest prompt above the cursor, or the cursor line if there
+// isn't one.
+func (buffer *Buffer) StartCommand() {
+	buffer.getCurrentLine() // make sure the cursor line exists
+	line := int(buffer.RawLine())
+	for i := line; i >= 0 && i < len(buffer.lines); i-- {
+		if buffer.lines[i].prompt {
+			line = i
+			break
+		}
+	}
+	buffer.command = buffer.anchor(line, 0)
+}
+
+// EndCommand records that the command started with StartCommand has finished, marking its prompt if it failed.
+// Nothing is marked if the prompt has left the scrollback.
+func (buffer *Buffer) EndCommand(failed bool) {
+	command := buffer.command
+	buffer.command = nil
+	if command == nil || !failed || !buffer.resolve(command) {
+		return
+	}
+	if command.Line >= 0 && command.Line < len(buffer.lines) {
+		buffer.lines[command.Line].failed = true
+		buffer.landmarks.changes++
+	}
+}
+
+// landmarkCache holds the landmarks found in a buffer, which are looked for again only once lines have been added,
+// dropped or marked, rather than for every frame the scrollbar is drawn
+type landmarkCache struct {
+	landmarks []Landmark
+	key       [4]uint64 // the first and last line ids, the number of lines and changes when the landmarks were found
+	changes   uint64    // counts the lines marked as landmarks
+}
+
+// Landmarks returns the lines which stand out, from the top of the scrollback down. A line can be more than one kind
+// of landmark, e.g. the prompt a failed command was typed at. The slice is shared, so mustn't be changed.
+func (buffer *Buffer) Landmarks() []Landmark {
+	first, last := buffer.LineIDs()
+	key := [4]uint64{first, last, uint64(len(buffer.lines)), buffer.landmarks.changes}
+	if buffer.landmarks.landmarks == nil || buffer.landmarks.key != key {
+		buffer.landmarks.landmarks = buffer.findLandmarks()
+		buffer.landmarks.key = key
+	}
+	return buffer.landmarks.landmarks
+}
+
+func (buffer *Buffer) findLandmarks() []Landmark {
+	landmarks := []Landmark{}
+	for i := range buffer.lines {
+		line := &buffer.lines[i]
+		if line.prompt {
+			landmarks = append(landmarks, Landmark{Line: i, Kind: LandmarkPrompt})
+		}
+		if line.marked {
+			landmarks = append(landmarks, Landmark{Line: i, Kind: LandmarkMark})
+		}
+		if line.bell {
+			landmarks = append(landmarks, Landmark{Line: i, Kind: LandmarkBell})
+		}
+		if line.failed {
+			landmarks = append(landmarks, Landmark{Line: i, Kind: LandmarkError})
+		}
+	}
+	return landmarks
+}
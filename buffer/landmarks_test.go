@@ -0,0 +1,72 @@
+package buffer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLandmarks(t *testing.T) {
+	b := NewBuffer(10, 3, CellAttributes{})
+	b.MarkPrompt()
+	b.Write([]rune("$ false\r\n")...)
+	b.StartCommand()
+	b.EndCommand(true)
+	b.MarkPrompt()
+	b.Write([]rune("$ true\r\n")...)
+	b.StartCommand()
+	b.MarkBell()
+	b.EndCommand(false)
+	b.InsertMark()
+
+	assert.Equal(t, []Landmark{
+		{Line: 0, Kind: LandmarkPrompt},
+		{Line: 0, Kind: LandmarkError},
+		{Line: 1, Kind: LandmarkPrompt},
+		{Line: 2, Kind: LandmarkMark},
+		{Line: 2, Kind: LandmarkBell},
+	}, b.Landmarks())
+}
+
+func TestFailedCommandsWithoutPromptsMarkTheirLine(t *testing.T) {
+	b := NewBuffer(10, 3, CellAttributes{})
+	b.Write([]rune("one\r\ntwo")...)
+	b.StartCommand()
+	b.EndCommand(true)
+
+	// a command which finishes without starting isn't marked
+	b.EndCommand(true)
+
+	assert.Equal(t, []Landmark{{Line: 1, Kind: LandmarkError}}, b.Landmarks())
+}
+
+func TestLandmarksAreFoundAgainOnlyWhenLinesChange(t *testing.T) {
+	b := NewBuffer(10, 3, CellAttributes{})
+	b.MarkPrompt()
+	b.Write([]rune("$ ls")...)
+	landmarks := b.Landmarks()
+	assert.Equal(t, []Landmark{{Line: 0, Kind: LandmarkPrompt}}, landmarks)
+	assert.Equal(t, &landmarks[0], &b.Landmarks()[0], "nothing has changed, so the landmarks should be reused")
+
+	b.MarkBell()
+	assert.Equal(t, []Landmark{{Line: 0, Kind: LandmarkPrompt}, {Line: 0, Kind: LandmarkBell}}, b.Landmarks())
+
+	b.Write([]rune("\r\none\r\ntwo\r\nthree")...)
+	b.SetScrollbackLimit(0)
+	assert.Equal(t, []Landmark{}, b.Landmarks(), "the prompt has left the scrollback")
+}
+
+func TestScrollingALineToTheTop(t *testing.T) {
+	b := NewBuffer(10, 3, CellAttributes{})
+	b.Write([]rune("one\r\ntwo\r\nthree\r\nfour\r\nfive\r\nsix")...)
+
+	b.ScrollLineToTop(1)
+	assert.Equal(t, uint(2), b.GetScrollOffset())
+	assert.Equal(t, "two", b.GetVisibleLines()[0].String())
+
+	// lines near the bottom can't be scrolled any higher than it
+	b.ScrollLineToTop(4)
+	assert.Equal(t, uint(0), b.GetScrollOffset())
+	b.ScrollLineToTop(-1)
+	assert.Equal(t, uint(3), b.GetScrollOffset())
+}
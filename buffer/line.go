@@ -2,15 +2,27 @@ package buffer
 
 import (
 	"strings"
+	"sync/atomic"
 )
 
+// lastLineID is the ID given to the most recently created line
+var lastLineID uint64
+
 type Line struct {
-	wrapped bool // whether line was wrapped onto from the previous one
+	id      uint64 // unique to the line, following its content as it moves around the buffer
+	wrapped bool   // whether line was wrapped onto from the previous one
+	marked  bool   // whether a separator is drawn above the line, see Buffer.InsertMark
+	prompt  bool   // whether the shell reported a prompt starting on the line
+	bell    bool   // whether the bell rang with the cursor on the line
+	failed  bool   // whether a command which failed was typed at a prompt on the line
+	filled  bool   // whether the columns past the end of cells are blanks in fill, rather than the default background
+	fill    attrID
 	cells   []Cell
 }
 
 func newLine() Line {
 	return Line{
+		id:      atomic.AddUint64(&lastLineID, 1),
 		wrapped: false,
 		cells:   []Cell{},
 	}
@@ -20,11 +32,39 @@ func (line *Line) Cells() []Cell {
 	return line.cells
 }
 
+// Fill returns the blank cell shown in every column past the end of the line's cells, or false if the default
+// background shows there. Erasing to the end of a line in a background colour sets it, rather than allocating a cell
+// for every column, and cells are only made from it when something is written past the end of the line.
+func (line *Line) Fill() (Cell, bool) {
+	return Cell{attr: line.fill}, line.filled
+}
+
+// blank returns a cell in the background of attr to pad the line with up to a column being written, made from the fill
+// if there is one
+func (line *Line) blank(attr CellAttributes) Cell {
+	if line.filled {
+		return Cell{attr: line.fill}
+	}
+	return NewPaletteBackgroundCell(attr.BgColour, attr.BgIndex)
+}
+
+// clear empties the line, leaving the default background
+func (line *Line) clear() {
+	line.cells = []Cell{}
+	line.filled = false
+	line.fill = 0
+}
+
+// Marked returns true if a separator should be drawn above the line
+func (line *Line) Marked() bool {
+	return line.marked
+}
+
 // Cleanse removes null bytes from the end of the row
 func (line *Line) Cleanse() {
 	cut := 0
 	for i := len(line.cells) - 1; i >= 0; i-- {
-		if line.cells[i].r != 0 {
+		if line.cells[i].r != 0 || line.cells[i].continuation {
 			break
 		}
 		cut++
@@ -42,7 +82,10 @@ func (line *Line) setWrapped(wrapped bool) {
 func (line *Line) String() string {
 	runes := []rune{}
 	for _, cell := range line.cells {
-		runes = append(runes, cell.r)
+		if cell.continuation {
+			continue
+		}
+		runes = append(runes, cell.Runes()...)
 	}
 	return strings.TrimRight(string(runes), "\x00 ")
 }
@@ -27,3 +27,31 @@ func TestLine(t *testing.T) {
 	assert.False(t, line.wrapped)
 
 }
+
+func TestEraseToEndOfLineFillsWithoutCells(t *testing.T) {
+	blue := [3]float32{0, 0, 1}
+	b := NewBuffer(80, 3, CellAttributes{})
+	b.Write([]rune("hello")...)
+	b.CursorAttr().BgColour = blue
+	b.SetPosition(2, 0)
+
+	allocs := testing.AllocsPerRun(100, b.EraseLineFromCursor)
+	assert.Equal(t, float64(0), allocs)
+
+	line := b.getCurrentLine()
+	assert.Len(t, line.Cells(), 2)
+	fill, ok := line.Fill()
+	assert.True(t, ok)
+	assert.Equal(t, blue, fill.Bg())
+
+	// writing past the end pads the gap with the fill
+	b.CursorAttr().BgColour = [3]float32{}
+	b.SetPosition(5, 0)
+	b.Write('x')
+	assert.Equal(t, blue, line.Cells()[3].Bg())
+	assert.Equal(t, [3]float32{}, line.Cells()[5].Bg())
+
+	b.EraseLine()
+	_, ok = line.Fill()
+	assert.False(t, ok)
+}
@@ -0,0 +1,182 @@
+package buffer
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Link is a hyperlink on the screen, either set by a program with OSC 8 or found by looking for a URL, file location or
+// git commit hash in the text
+type Link struct {
+	URL    string
+	File   *FileLocation // set instead of the URL for a file location, e.g. from a compiler error
+	Commit string        // set instead of the URL for a git commit hash
+
+	explicit bool    // set with OSC 8, so it covers every cell carrying the URL
+	buffer   *Buffer // the buffer the cells carrying it are in
+	row      uint16  // otherwise, the view row it was found on and the columns it covers
+	start    uint16
+	end      uint16
+}
+
+// Covers returns whether a cell at a position in the view is part of the link
+func (link *Link) Covers(cell *Cell, col uint16, viewRow uint16) bool {
+	if link == nil {
+		return false
+	}
+	if link.explicit {
+		return link.buffer.CellHyperlink(cell) == link.URL
+	}
+	return viewRow == link.row && col >= link.start && col <= link.end
+}
+
+// GetLinkAtPosition returns the link at a position in the view, or nil if there isn't one. A link set with OSC 8
+// takes priority over a URL in the text.
+func (buffer *Buffer) GetLinkAtPosition(col uint16, viewRow uint16) *Link {
+
+	row := buffer.convertViewLineToRawLine((viewRow)) - uint64(buffer.scrollLinesFromBottom)
+
+	cell := buffer.GetRawCell(col, row)
+	if cell == nil || cell.Rune() == 0x00 {
+		return nil
+	}
+
+	if hyperlink := buffer.CellHyperlink(cell); hyperlink != "" {
+		return &Link{URL: hyperlink, explicit: true, buffer: buffer}
+	}
+	if isRuneURLSelectionMarker(cell.Rune()) {
+		return nil
+	}
+
+	start := col
+	for start > 0 {
+		cell := buffer.GetRawCell(start-1, row)
+		if cell == nil || isRuneURLSelectionMarker(cell.Rune()) {
+			break
+		}
+		start--
+	}
+	end := col
+	for end+1 < buffer.viewWidth {
+		cell := buffer.GetRawCell(end+1, row)
+		if cell == nil || isRuneURLSelectionMarker(cell.Rune()) {
+			break
+		}
+		end++
+	}
+
+	candidate := strings.Builder{}
+	for i := start; i <= end; i++ {
+		candidate.WriteRune(buffer.GetRawCell(i, row).Rune())
+	}
+
+	// file locations and commits come first, as main.go:12 is also a valid URL. Brackets and punctuation around them
+	// aren't part of them, e.g. "(main.go:12),".
+	text := []rune(candidate.String())
+	leading := len(text) - len([]rune(strings.TrimLeft(string(text), "([<")))
+	trailing := len(text) - len([]rune(strings.TrimRight(string(text), ")]>,.;")))
+	if leading+trailing < len(text) {
+		if file := parseFileLocation(string(text[leading : len(text)-trailing])); file != nil {
+			return &Link{File: file, row: viewRow, start: start + uint16(leading), end: end - uint16(trailing)}
+		}
+		// a commit or diff file may be followed by a colon, e.g. "a1b2c3d: fix the build"
+		trailing = len(text) - len([]rune(strings.TrimRight(string(text), ")]>,.;:")))
+		word := string(text[leading : len(text)-trailing])
+		link := &Link{row: viewRow, start: start + uint16(leading), end: end - uint16(trailing)}
+		if link.File = parseDiffFile(word, buffer.lines[row].String()); link.File != nil {
+			return link
+		}
+		if isCommitHash(word) {
+			link.Commit = word
+			return link
+		}
+	}
+
+	if candidate.Len() == 0 || strings.HasPrefix(candidate.String(), "/") {
+		return nil
+	}
+
+	// check if url
+	if _, err := url.ParseRequestURI(candidate.String()); err != nil {
+		return nil
+	}
+	return &Link{URL: candidate.String(), row: viewRow, start: start, end: end}
+}
+
+// GetURLAtPosition returns the target of the link at a position in the view, or an empty string if there isn't one
+func (buffer *Buffer) GetURLAtPosition(col uint16, viewRow uint16) string {
+	if link := buffer.GetLinkAtPosition(col, viewRow); link != nil {
+		return link.URL
+	}
+	return ""
+}
+
+func isRuneURLSelectionMarker(r rune) bool {
+	switch r {
+	case ' ', 0, '\'', '"', '{', '}':
+		return true
+	}
+
+	return false
+}
+
+// FileLocation is a position in a file, as printed by compilers, linters and test runners, e.g. main.go:12:5
+type FileLocation struct {
+	Path         string
+	Line         int  // 0 for a whole file, e.g. from a diff header
+	Column       int  // 0 if there wasn't one
+	RepoRelative bool // the path is relative to the root of the git repository rather than the working directory
+}
+
+func (location *FileLocation) String() string {
+	if location.Line == 0 {
+		return location.Path
+	}
+	if location.Column > 0 {
+		return fmt.Sprintf("%s:%d:%d", location.Path, location.Line, location.Column)
+	}
+	return fmt.Sprintf("%s:%d", location.Path, location.Line)
+}
+
+// the path needs a dot or a slash, so that times like 12:30 and words followed by a colon aren't taken for files
+var fileLocationPattern = regexp.MustCompile(`^([^:]*[./][^:]*):(\d+)(?::(\d+))?:?$`)
+
+// parseFileLocation returns the file location in text, or nil if it isn't one
+func parseFileLocation(text string) *FileLocation {
+	parts := fileLocationPattern.FindStringSubmatch(text)
+	if parts == nil || strings.Contains(parts[1], "//") {
+		return nil
+	}
+	line, err := strconv.Atoi(parts[2])
+	if err != nil || line == 0 {
+		return nil
+	}
+	column, _ := strconv.Atoi(parts[3])
+	return &FileLocation{Path: parts[1], Line: line, Column: column}
+}
+
+// parseDiffFile returns the file named by a path in a git diff header, e.g. b/main.go in "+++ b/main.go", or nil if the
+// text isn't one
+func parseDiffFile(text string, lineText string) *FileLocation {
+	if !strings.HasPrefix(lineText, "diff --git ") && !strings.HasPrefix(lineText, "--- a/") &&
+		!strings.HasPrefix(lineText, "+++ b/") {
+		return nil
+	}
+	if (!strings.HasPrefix(text, "a/") && !strings.HasPrefix(text, "b/")) || len(text) == 2 {
+		return nil
+	}
+	return &FileLocation{Path: text[2:], RepoRelative: true}
+}
+
+// abbreviated hashes are at least 7 characters, as git prints them by default
+var commitHashPattern = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+
+// isCommitHash returns true if text looks like a git commit hash. It needs both a digit and a letter, so that plain
+// numbers and words like "deadbeef" aren't taken for one.
+func isCommitHash(text string) bool {
+	return commitHashPattern.MatchString(text) && strings.ContainsAny(text, "0123456789") &&
+		strings.ContainsAny(text, "abcdef")
+}
@@ -0,0 +1,116 @@
+package buffer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetLinkAtPositionFindsURLInText(t *testing.T) {
+	b := NewBuffer(40, 3, CellAttributes{})
+	b.Write([]rune("see https://example.com/a for more")...)
+
+	link := b.GetLinkAtPosition(10, 0)
+	require.NotNil(t, link)
+	assert.Equal(t, "https://example.com/a", link.URL)
+	assert.True(t, link.Covers(b.GetCell(4, 0), 4, 0))
+	assert.True(t, link.Covers(b.GetCell(24, 0), 24, 0))
+	assert.False(t, link.Covers(b.GetCell(25, 0), 25, 0))
+	assert.False(t, link.Covers(b.GetCell(10, 1), 10, 1))
+
+	assert.Nil(t, b.GetLinkAtPosition(3, 0))
+	assert.Nil(t, b.GetLinkAtPosition(1, 0))
+	assert.Equal(t, "", b.GetURLAtPosition(30, 0))
+}
+
+func TestGetLinkAtPositionPrefersHyperlink(t *testing.T) {
+	b := NewBuffer(40, 3, CellAttributes{})
+	b.Write([]rune("open ")...)
+	b.SetHyperlink("https://example.com/docs")
+	b.Write([]rune("the docs")...)
+	b.SetHyperlink("")
+	b.Write([]rune(" now")...)
+
+	link := b.GetLinkAtPosition(6, 0)
+	require.NotNil(t, link)
+	assert.Equal(t, "https://example.com/docs", link.URL)
+	assert.True(t, link.Covers(b.GetCell(8, 0), 8, 0))
+	assert.False(t, link.Covers(b.GetCell(13, 0), 13, 0))
+	assert.False(t, link.Covers(b.GetCell(4, 0), 4, 0))
+
+	// the space inside the link is part of it too
+	assert.Equal(t, "https://example.com/docs", b.GetURLAtPosition(8, 0))
+	assert.Equal(t, "", b.GetURLAtPosition(14, 0))
+}
+
+func TestGetLinkAtPositionFindsFileLocation(t *testing.T) {
+	b := NewBuffer(60, 3, CellAttributes{})
+	b.Write([]rune("./cmd/main.go:12:5: undefined: foo\r\n")...)
+	b.Write([]rune("at (lib/util.go:7), then 12:30")...)
+
+	link := b.GetLinkAtPosition(3, 0)
+	require.NotNil(t, link)
+	require.NotNil(t, link.File)
+	assert.Equal(t, FileLocation{Path: "./cmd/main.go", Line: 12, Column: 5}, *link.File)
+	assert.Equal(t, "", link.URL)
+	assert.True(t, link.Covers(b.GetCell(0, 0), 0, 0))
+	assert.True(t, link.Covers(b.GetCell(18, 0), 18, 0))
+	assert.False(t, link.Covers(b.GetCell(19, 0), 19, 0))
+
+	link = b.GetLinkAtPosition(8, 1)
+	require.NotNil(t, link)
+	require.NotNil(t, link.File)
+	assert.Equal(t, "lib/util.go:7", link.File.String())
+	assert.False(t, link.Covers(b.GetCell(3, 1), 3, 1))
+	assert.True(t, link.Covers(b.GetCell(4, 1), 4, 1))
+	assert.False(t, link.Covers(b.GetCell(17, 1), 17, 1))
+
+	assert.Nil(t, b.GetLinkAtPosition(27, 1))
+}
+
+func TestParseFileLocation(t *testing.T) {
+	assert.Equal(t, &FileLocation{Path: "/src/a.c", Line: 3}, parseFileLocation("/src/a.c:3:"))
+	assert.Nil(t, parseFileLocation("main.go"))
+	assert.Nil(t, parseFileLocation("main.go:0"))
+	assert.Nil(t, parseFileLocation("note:12"))
+	assert.Nil(t, parseFileLocation("http://host:80"))
+}
+
+func TestGetLinkAtPositionFindsCommit(t *testing.T) {
+	b := NewBuffer(60, 2, CellAttributes{})
+	b.Write([]rune("d01003c: split the window (was 1234567)\r\n")...)
+	b.Write([]rune("deadbeef 20181012")...)
+
+	link := b.GetLinkAtPosition(2, 0)
+	require.NotNil(t, link)
+	assert.Equal(t, "d01003c", link.Commit)
+	assert.Nil(t, link.File)
+	assert.False(t, link.Covers(b.GetCell(7, 0), 7, 0))
+
+	assert.Nil(t, b.GetLinkAtPosition(33, 0))
+	assert.Nil(t, b.GetLinkAtPosition(2, 1))
+	assert.Nil(t, b.GetLinkAtPosition(12, 1))
+}
+
+func TestGetLinkAtPositionFindsDiffFile(t *testing.T) {
+	b := NewBuffer(60, 3, CellAttributes{})
+	b.Write([]rune("diff --git a/gui/gui.go b/gui/gui.go\r\n")...)
+	b.Write([]rune("+++ b/README.md\r\n")...)
+	b.Write([]rune("see a/notes")...)
+
+	link := b.GetLinkAtPosition(30, 0)
+	require.NotNil(t, link)
+	require.NotNil(t, link.File)
+	assert.Equal(t, FileLocation{Path: "gui/gui.go", RepoRelative: true}, *link.File)
+	assert.Equal(t, "gui/gui.go", link.File.String())
+
+	link = b.GetLinkAtPosition(6, 1)
+	require.NotNil(t, link)
+	require.NotNil(t, link.File)
+	assert.Equal(t, "README.md", link.File.Path)
+
+	// only in diff headers
+	link = b.GetLinkAtPosition(6, 2)
+	assert.True(t, link == nil || link.File == nil)
+}
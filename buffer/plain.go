@@ -0,0 +1,52 @@
+package buffer
+
+// WritePlain writes printable ASCII, as Write would, but fills each line's worth of cells in one go. None of it can be
+// a control character, start a wide cell or join a grapheme cluster, so most of the per-rune work in Write is skipped.
+func (buffer *Buffer) WritePlain(runes []rune) {
+
+	defer buffer.emitDisplayChange()
+
+	if buffer.Charset() != CharsetASCII {
+		// the runes are drawn as something else, which is left to Write
+		buffer.Write(runes...)
+		return
+	}
+
+	for len(runes) > 0 {
+
+		if buffer.replaceMode || buffer.CursorColumn() >= buffer.Width() {
+			// wrapping onto the next line, or dropping text past the end of it, is left to Write
+			buffer.Write(runes[0])
+			runes = runes[1:]
+			continue
+		}
+
+		line := buffer.getCurrentLine()
+		col := int(buffer.CursorColumn())
+		n := int(buffer.Width()) - col
+		if n > len(runes) {
+			n = len(runes)
+		}
+
+		if have := len(line.cells); have < col+n {
+			// grow the line once, rather than a cell at a time, filling any gap before the cursor with background
+			line.cells = append(line.cells, make([]Cell, col+n-have)...)
+			for i := have; i < col; i++ {
+				line.cells[i] = line.blank(buffer.cursorAttr)
+			}
+		}
+		// only wide cells at either end can be cut in two, the ones in between are overwritten entirely
+		line.clearWideCell(col)
+		line.clearWideCell(col + n - 1)
+		attr := buffer.cursorAttrID()
+		for i, r := range runes[:n] {
+			cell := &line.cells[col+i]
+			cell.setRune(r)
+			cell.attr = attr
+			cell.link = buffer.cursorLink
+		}
+
+		buffer.cursorX += uint16(n)
+		runes = runes[n:]
+	}
+}
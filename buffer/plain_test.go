@@ -0,0 +1,39 @@
+package buffer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWritePlainMatchesWrite(t *testing.T) {
+	tests := []struct {
+		name     string
+		before   string
+		text     string
+		autoWrap bool
+	}{
+		{name: "fits on the line", text: "hello", autoWrap: true},
+		{name: "wraps", text: "hello world, hello", autoWrap: true},
+		{name: "drops past the end without wrapping", text: "hello world, hello", autoWrap: false},
+		{name: "overwrites half of a wide cell", before: "ab\U0001F600\U0001F600\r", text: "abc", autoWrap: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			expected := NewBuffer(8, 3, CellAttributes{})
+			expected.SetAutoWrap(test.autoWrap)
+			expected.Write([]rune(test.before)...)
+			expected.Write([]rune(test.text)...)
+
+			actual := NewBuffer(8, 3, CellAttributes{})
+			actual.SetAutoWrap(test.autoWrap)
+			actual.Write([]rune(test.before)...)
+			actual.WritePlain([]rune(test.text))
+
+			assert.Equal(t, expected.GetAllText(), actual.GetAllText())
+			assert.Equal(t, expected.CursorLine(), actual.CursorLine())
+			assert.Equal(t, expected.CursorColumn(), actual.CursorColumn())
+		})
+	}
+}
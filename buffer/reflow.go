@@ -0,0 +1,64 @@
+package buffer
+
+// logicalCursor returns where the cursor is in terms of logical lines, which reflowing doesn't change: the ID of the
+// first line of the wrapped line it is on, and how many cells into that wrapped line it is
+func (buffer *Buffer) logicalCursor() (uint64, int) {
+	buffer.getCurrentLine() // make sure the cursor line exists
+	raw := int(buffer.RawLine())
+	if raw >= len(buffer.lines) {
+		raw = len(buffer.lines) - 1
+	}
+	offset := int(buffer.cursorX)
+	for raw > 0 && buffer.lines[raw].wrapped {
+		raw--
+		offset += len(buffer.lines[raw].cells)
+	}
+	return buffer.lines[raw].id, offset
+}
+
+// placeCursor moves the cursor to a position returned by logicalCursor, after the lines have been reflowed for a new
+// width. If the cursor would end up above the screen because the screen is now shorter, blank lines below it are
+// dropped to make room, as the program will usually redraw them anyway.
+func (buffer *Buffer) placeCursor(lineID uint64, offset int) {
+
+	raw := -1
+	for i := len(buffer.lines) - 1; i >= 0; i-- {
+		if buffer.lines[i].id == lineID {
+			raw = i
+			break
+		}
+	}
+	if raw < 0 {
+		// the line has gone, so fall back to the last line
+		raw = len(buffer.lines) - 1
+		offset = 0
+	}
+
+	// move along the wrapped line to the part the cursor is in
+	for raw+1 < len(buffer.lines) && buffer.lines[raw+1].wrapped && offset >= len(buffer.lines[raw].cells) {
+		offset -= len(buffer.lines[raw].cells)
+		raw++
+	}
+	if offset > int(buffer.viewWidth) {
+		offset = int(buffer.viewWidth)
+	}
+
+	for len(buffer.lines) > int(buffer.viewHeight) && raw < len(buffer.lines)-int(buffer.viewHeight) {
+		last := &buffer.lines[len(buffer.lines)-1]
+		if len(last.cells) > 0 || last.filled || last.wrapped {
+			break
+		}
+		buffer.lines = buffer.lines[:len(buffer.lines)-1]
+	}
+
+	top := len(buffer.lines) - int(buffer.viewHeight)
+	if top < 0 {
+		top = 0
+	}
+	row := raw - top
+	if row < 0 {
+		row = 0
+	}
+	buffer.cursorX = uint16(offset)
+	buffer.cursorY = uint16(row)
+}
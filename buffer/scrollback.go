@@ -0,0 +1,29 @@
+package buffer
+
+// SetScrollbackLimit sets the most lines kept above the screen, the oldest being dropped once there are more. A negative
+// limit keeps every line.
+func (buffer *Buffer) SetScrollbackLimit(lines int) {
+	buffer.scrollbackLimit = lines
+	buffer.trimScrollback()
+}
+
+// trimScrollback drops the oldest lines beyond the scrollback limit. Unlike removeLinesBefore, the view stays scrolled
+// back where it is, so reading the history isn't interrupted by output arriving below it.
+func (buffer *Buffer) trimScrollback() {
+	if buffer.scrollbackLimit < 0 {
+		return
+	}
+	excess := len(buffer.lines) - int(buffer.viewHeight) - buffer.scrollbackLimit
+	if excess <= 0 {
+		return
+	}
+	// let the dropped lines' cells be collected before the slice is next reallocated
+	for i := 0; i < excess; i++ {
+		buffer.lines[i] = Line{}
+	}
+	buffer.lines = buffer.lines[excess:]
+	buffer.lines[0].setWrapped(false)
+	if max := uint(len(buffer.lines) - int(buffer.viewHeight)); buffer.scrollLinesFromBottom > max {
+		buffer.scrollLinesFromBottom = max
+	}
+}
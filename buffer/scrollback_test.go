@@ -0,0 +1,41 @@
+package buffer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScrollbackLimitDropsOldestLines(t *testing.T) {
+	b := NewBuffer(10, 2, CellAttributes{})
+	b.SetScrollbackLimit(2)
+	b.Write([]rune("one\r\ntwo\r\nthree\r\nfour\r\nfive\r\nsix")...)
+
+	assert.Equal(t, 4, b.Height())
+	assert.Equal(t, "three\nfour\nfive\nsix", b.GetAllText())
+	assert.Equal(t, uint16(1), b.CursorLine())
+	assert.Equal(t, uint16(3), b.CursorColumn())
+}
+
+func TestScrollbackLimitKeepsViewScrolledBack(t *testing.T) {
+	b := NewBuffer(10, 2, CellAttributes{})
+	b.SetScrollbackLimit(3)
+	b.Write([]rune("one\r\ntwo\r\nthree\r\nfour\r\nfive")...)
+	b.ScrollUp(3)
+
+	b.Index()
+
+	assert.Equal(t, 5, b.Height())
+	assert.Equal(t, uint(3), b.GetScrollOffset())
+}
+
+func TestNoScrollback(t *testing.T) {
+	b := NewBuffer(10, 2, CellAttributes{})
+	b.Write([]rune("one\r\ntwo\r\nthree")...)
+	b.SetScrollbackLimit(0)
+
+	assert.Equal(t, 2, b.Height())
+	assert.Equal(t, "two\nthree", b.GetAllText())
+	b.ScrollUp(1)
+	assert.Equal(t, uint(0), b.GetScrollOffset())
+}
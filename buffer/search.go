@@ -0,0 +1,169 @@
+package buffer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Match is an occurrence of a search query, positioned by raw line
+type Match struct {
+	Line   int
+	Col    int
+	Length int
+}
+
+// FindAll returns every case-insensitive occurrence of query in the buffer, including scrollback, earliest first
+func (buffer *Buffer) FindAll(query string) []Match {
+
+	needle := []rune(query)
+	for i := range needle {
+		needle[i] = unicode.ToLower(needle[i])
+	}
+
+	matches := []Match{}
+	if len(needle) == 0 {
+		return matches
+	}
+
+	for y, line := range buffer.lines {
+		cells := line.cells
+		for x := 0; x+len(needle) <= len(cells); x++ {
+			found := true
+			for i, r := range needle {
+				if unicode.ToLower(cells[x+i].r) != r {
+					found = false
+					break
+				}
+			}
+			if found {
+				matches = append(matches, Match{
+					Line:   y,
+					Col:    x,
+					Length: len(needle),
+				})
+				x += len(needle) - 1
+			}
+		}
+	}
+
+	return matches
+}
+
+// Find returns every case-insensitive match of pattern in the buffer, including scrollback, earliest first. The
+// pattern is a regular expression if regex is true, otherwise it is plain text. Matches don't span lines.
+func (buffer *Buffer) Find(pattern string, regex bool) ([]Match, error) {
+
+	if !regex {
+		return buffer.FindAll(pattern), nil
+	}
+
+	re, err := regexp.Compile("(?i)" + pattern)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid search pattern: %s", err)
+	}
+
+	matches := []Match{}
+	if pattern == "" {
+		return matches, nil
+	}
+
+	for y := range buffer.lines {
+		text, cols := buffer.lines[y].searchText()
+		for _, loc := range re.FindAllStringIndex(text, -1) {
+			// there is nothing to show for an empty match
+			if loc[0] == loc[1] {
+				continue
+			}
+			matches = append(matches, Match{
+				Line:   y,
+				Col:    cols[loc[0]],
+				Length: cols[loc[1]] - cols[loc[0]],
+			})
+		}
+	}
+
+	return matches, nil
+}
+
+// searchText returns the text of the line for a regular expression to match, along with the column each byte of it
+// came from. The extra column at the end is for matches which run to the end of the line.
+func (line *Line) searchText() (string, []int) {
+	text := strings.Builder{}
+	cols := make([]int, 0, len(line.cells)+1)
+	for x, cell := range line.cells {
+		if cell.continuation {
+			continue
+		}
+		s := " "
+		if cell.r != 0 {
+			s = string(cell.Runes())
+		}
+		for range []byte(s) {
+			cols = append(cols, x)
+		}
+		text.WriteString(s)
+	}
+	cols = append(cols, len(line.cells))
+	return text.String(), cols
+}
+
+// ScrollToLine scrolls the view so the given raw line is visible, centring it if it is currently out of view
+func (buffer *Buffer) ScrollToLine(rawLine int) {
+
+	defer buffer.emitDisplayChange()
+
+	maxOffset := buffer.Height() - int(buffer.viewHeight)
+	if maxOffset <= 0 {
+		return
+	}
+
+	top := maxOffset - int(buffer.scrollLinesFromBottom)
+	if rawLine >= top && rawLine < top+int(buffer.viewHeight) {
+		return
+	}
+
+	offset := maxOffset - (rawLine - int(buffer.viewHeight)/2)
+	if offset < 0 {
+		offset = 0
+	} else if offset > maxOffset {
+		offset = maxOffset
+	}
+	if offset == 0 {
+		buffer.follow()
+		return
+	}
+	buffer.scrollLinesFromBottom = uint(offset)
+	buffer.holdView()
+}
+
+// ScrollLineToTop scrolls the view so the given raw line is at the top of it, or as near as it can get
+func (buffer *Buffer) ScrollLineToTop(rawLine int) {
+
+	defer buffer.emitDisplayChange()
+
+	maxOffset := buffer.Height() - int(buffer.viewHeight)
+	if maxOffset <= 0 {
+		return
+	}
+
+	offset := maxOffset - rawLine
+	if offset <= 0 {
+		buffer.follow()
+		return
+	}
+	if offset > maxOffset {
+		offset = maxOffset
+	}
+	buffer.scrollLinesFromBottom = uint(offset)
+	buffer.holdView()
+}
+
+// SelectMatch replaces the current selection with the given match
+func (buffer *Buffer) SelectMatch(match Match) {
+	defer buffer.emitDisplayChange()
+	buffer.selectionComplete = true
+	buffer.selectionStart = buffer.anchor(match.Line, match.Col)
+	buffer.selectionEnd = buffer.anchor(match.Line, match.Col+match.Length-1)
+}
@@ -0,0 +1,80 @@
+package buffer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindAll(t *testing.T) {
+	b := NewBuffer(20, 3, CellAttributes{})
+	b.Write([]rune("error one\r\n")...)
+	b.Write([]rune("fine\r\n")...)
+	b.Write([]rune("Error, ERROR\r\n")...)
+	b.Write([]rune("done")...)
+
+	matches := b.FindAll("error")
+	require.Len(t, matches, 3)
+	assert.Equal(t, Match{Line: 0, Col: 0, Length: 5}, matches[0])
+	assert.Equal(t, Match{Line: 2, Col: 0, Length: 5}, matches[1])
+	assert.Equal(t, Match{Line: 2, Col: 7, Length: 5}, matches[2])
+
+	assert.Len(t, b.FindAll(""), 0)
+	assert.Len(t, b.FindAll("missing"), 0)
+}
+
+func TestScrollToLine(t *testing.T) {
+	b := NewBuffer(10, 3, CellAttributes{})
+	for i := 0; i < 9; i++ {
+		b.Write([]rune("line\r\n")...)
+	}
+	b.Write([]rune("last")...)
+	require.Equal(t, 10, b.Height())
+
+	b.ScrollToLine(8)
+	assert.Equal(t, uint(0), b.GetScrollOffset())
+
+	b.ScrollToLine(0)
+	assert.Equal(t, uint(7), b.GetScrollOffset())
+
+	b.ScrollToLine(4)
+	assert.Equal(t, uint(4), b.GetScrollOffset())
+}
+
+func TestSelectMatch(t *testing.T) {
+	b := NewBuffer(20, 3, CellAttributes{})
+	b.Write([]rune("find the needle here")...)
+
+	b.SelectMatch(b.FindAll("needle")[0])
+	assert.Equal(t, "needle", b.GetSelectedText())
+}
+
+func TestFindRegex(t *testing.T) {
+	b := NewBuffer(20, 3, CellAttributes{})
+	b.Write([]rune("port 8080 open\r\n")...)
+	b.Write([]rune("PORT 22 closed")...)
+
+	matches, err := b.Find(`port \d+`, true)
+	require.Nil(t, err)
+	require.Len(t, matches, 2)
+	assert.Equal(t, Match{Line: 0, Col: 0, Length: 9}, matches[0])
+	assert.Equal(t, Match{Line: 1, Col: 0, Length: 7}, matches[1])
+
+	matches, err = b.Find(`\d+`, false)
+	require.Nil(t, err)
+	assert.Len(t, matches, 0)
+
+	_, err = b.Find(`port (`, true)
+	assert.NotNil(t, err)
+}
+
+func TestFindRegexAfterWideCharacter(t *testing.T) {
+	b := NewBuffer(20, 3, CellAttributes{})
+	b.Write([]rune("😀 ok")...)
+
+	matches, err := b.Find(`ok`, true)
+	require.Nil(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, b.FindAll("ok")[0], matches[0])
+}
@@ -0,0 +1,144 @@
+package buffer
+
+import "unicode"
+
+// smartRune is a character of the visible text, with where it is in the buffer
+type smartRune struct {
+	r   rune
+	row int // raw line
+	col int
+}
+
+// smartPair is an opening quote or bracket and the one which closes it, as indexes of the visible text
+type smartPair struct {
+	open  int
+	close int
+	quote bool
+}
+
+// SmartSelectAtPosition selects the smallest quoted string, bracketed group or JSON object around a position in the
+// view, looking through all of the visible text so that e.g. a JSON object can span lines. Quoted strings are selected
+// without their quotes, brackets with theirs. It returns false if the position isn't inside any of them.
+func (buffer *Buffer) SmartSelectAtPosition(col uint16, viewRow uint16) bool {
+
+	top := int(buffer.convertViewLineToRawLine(0)) - int(buffer.scrollLinesFromBottom)
+	row := top + int(viewRow)
+
+	text := []smartRune{}
+	click := -1
+	for y := top; y < top+int(buffer.viewHeight) && y < len(buffer.lines); y++ {
+		if y < 0 {
+			continue
+		}
+		line := &buffer.lines[y]
+		if y > top && !line.wrapped {
+			text = append(text, smartRune{r: '\n', row: y, col: -1})
+		}
+		for x := range line.cells {
+			if line.cells[x].continuation {
+				// the second half of a wide character belongs to the first
+				if y == row && x == int(col) {
+					click = len(text) - 1
+				}
+				continue
+			}
+			if y == row && x == int(col) {
+				click = len(text)
+			}
+			text = append(text, smartRune{r: line.cells[x].r, row: y, col: x})
+		}
+	}
+	if click < 0 {
+		return false
+	}
+
+	best := smartPair{open: -1}
+	for _, pair := range matchSmartPairs(text) {
+		if pair.open <= click && click <= pair.close && (best.open < 0 || pair.close-pair.open < best.close-best.open) {
+			best = pair
+		}
+	}
+	if best.open < 0 {
+		return false
+	}
+
+	start, end := text[best.open], text[best.close]
+	if best.quote && best.close-best.open > 1 {
+		start, end = text[best.open+1], text[best.close-1]
+	}
+	buffer.selectionStart = buffer.anchor(start.row, start.col)
+	buffer.selectionEnd = buffer.anchor(end.row, end.col)
+	buffer.selectionComplete = true
+	buffer.emitDisplayChange()
+	return true
+}
+
+// matchSmartPairs finds the quotes and brackets in some text which pair up. Brackets may span lines, but quotes
+// don't. Brackets inside quotes only pair with each other, so that e.g. a "}" in a JSON string doesn't end the object.
+// A single quote or backtick between letters or digits, or straight after one outside a quote, is taken as an apostrophe
+// rather than the start or end of a quote, as in "it's".
+func matchSmartPairs(text []smartRune) []smartPair {
+	pairs := []smartPair{}
+	stack := []int{} // unclosed brackets, and the quote they are in if any, which is never closed past
+	quote := -1
+	endQuote := func() {
+		for k := len(stack) - 1; k >= 0; k-- {
+			if stack[k] == quote {
+				stack = stack[:k]
+				break
+			}
+		}
+		quote = -1
+	}
+
+	for i := 0; i < len(text); i++ {
+		r := text[i].r
+
+		if quote >= 0 {
+			switch {
+			case r == '\n':
+				endQuote()
+				continue
+			case r == '\\':
+				i++
+				continue
+			case r == text[quote].r && (r == '"' || !isWordRune(text, i-1) || !isWordRune(text, i+1)):
+				pairs = append(pairs, smartPair{open: quote, close: i, quote: true})
+				endQuote()
+				continue
+			}
+		} else {
+			for _, q := range enclosingQuotes {
+				if r == q && (q == '"' || !isWordRune(text, i-1)) {
+					quote = i
+					stack = append(stack, i)
+				}
+			}
+			if quote == i {
+				continue
+			}
+		}
+
+		for _, pair := range enclosingPairs {
+			if r == pair[0] {
+				stack = append(stack, i)
+			} else if r == pair[1] {
+				// close the innermost matching bracket, dropping any unclosed ones inside it
+				for k := len(stack) - 1; k >= 0 && stack[k] != quote; k-- {
+					if text[stack[k]].r == pair[0] {
+						pairs = append(pairs, smartPair{open: stack[k], close: i})
+						stack = stack[:k]
+						break
+					}
+				}
+			}
+		}
+	}
+
+	return pairs
+}
+
+// isWordRune returns true if the text has a letter or digit at an index
+func isWordRune(text []smartRune, i int) bool {
+	return i >= 0 && i < len(text) && (unicode.IsLetter(text[i].r) || unicode.IsDigit(text[i].r))
+}
@@ -0,0 +1,39 @@
+package buffer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSmartSelectAtPosition(t *testing.T) {
+	b := NewBuffer(60, 4, CellAttributes{})
+	b.Write([]rune(`level=error msg="can't open (file)" pid=12 (worker's queue)`)...)
+
+	require.True(t, b.SmartSelectAtPosition(20, 0))
+	assert.Equal(t, "can't open (file)", b.GetSelectedText())
+
+	require.True(t, b.SmartSelectAtPosition(30, 0))
+	assert.Equal(t, "(file)", b.GetSelectedText())
+
+	// the apostrophe doesn't start a quote
+	require.True(t, b.SmartSelectAtPosition(50, 0))
+	assert.Equal(t, "(worker's queue)", b.GetSelectedText())
+
+	assert.False(t, b.SmartSelectAtPosition(2, 0))
+}
+
+func TestSmartSelectJSONObjectAcrossLines(t *testing.T) {
+	b := NewBuffer(20, 4, CellAttributes{})
+	b.Write([]rune(`log {"a": "}",`)...)
+	b.CarriageReturn()
+	b.NewLine()
+	b.Write([]rune(` "b": [1, 2]}`)...)
+
+	require.True(t, b.SmartSelectAtPosition(4, 1))
+	assert.Equal(t, `{"a": "}",`+"\n"+` "b": [1, 2]}`, b.GetSelectedText())
+
+	require.True(t, b.SmartSelectAtPosition(9, 1))
+	assert.Equal(t, "[1, 2]", b.GetSelectedText())
+}
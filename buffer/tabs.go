@@ -0,0 +1,83 @@
+package buffer
+
+// columns start off with a tab stop every this many
+const defaultTabWidth = 8
+
+// growTabStops makes sure there is an entry for every column, giving columns the view has gained the default stops
+func (buffer *Buffer) growTabStops() {
+	for col := len(buffer.tabStops); col < int(buffer.viewWidth); col++ {
+		buffer.tabStops = append(buffer.tabStops, col > 0 && col%defaultTabWidth == 0)
+	}
+}
+
+func (buffer *Buffer) isTabStop(col int) bool {
+	return col < len(buffer.tabStops) && buffer.tabStops[col]
+}
+
+// SetTabStop sets a tab stop at the cursor column, as for HTS
+func (buffer *Buffer) SetTabStop() {
+	buffer.growTabStops()
+	if col := int(buffer.cursorX); col < len(buffer.tabStops) {
+		buffer.tabStops[col] = true
+	}
+}
+
+// ClearTabStop clears the tab stop at the cursor column, if there is one, as for TBC 0
+func (buffer *Buffer) ClearTabStop() {
+	buffer.growTabStops()
+	if col := int(buffer.cursorX); col < len(buffer.tabStops) {
+		buffer.tabStops[col] = false
+	}
+}
+
+// ClearAllTabStops clears every tab stop, as for TBC 3
+func (buffer *Buffer) ClearAllTabStops() {
+	buffer.growTabStops()
+	for col := range buffer.tabStops {
+		buffer.tabStops[col] = false
+	}
+}
+
+// Tab moves the cursor to the next tab stop, as for a tab character
+func (buffer *Buffer) Tab() {
+	buffer.TabForward(1)
+}
+
+// TabForward moves the cursor forward n tab stops, as for CHT, stopping at the last column if it runs out of them.
+// Nothing is written to the cells passed over.
+func (buffer *Buffer) TabForward(n int) {
+	defer buffer.emitDisplayChange()
+
+	buffer.growTabStops()
+	last := int(buffer.viewWidth) - 1
+	col := int(buffer.cursorX)
+	if col > last {
+		// a wrap is pending at the end of the line
+		col = last
+	}
+	for ; n > 0 && col < last; n-- {
+		col++
+		for col < last && !buffer.isTabStop(col) {
+			col++
+		}
+	}
+	buffer.cursorX = uint16(col)
+}
+
+// TabBackward moves the cursor back n tab stops, as for CBT, stopping at the first column if it runs out of them
+func (buffer *Buffer) TabBackward(n int) {
+	defer buffer.emitDisplayChange()
+
+	buffer.growTabStops()
+	col := int(buffer.cursorX)
+	if last := int(buffer.viewWidth) - 1; col > last {
+		col = last
+	}
+	for ; n > 0 && col > 0; n-- {
+		col--
+		for col > 0 && !buffer.isTabStop(col) {
+			col--
+		}
+	}
+	buffer.cursorX = uint16(col)
+}
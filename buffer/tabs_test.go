@@ -0,0 +1,60 @@
+package buffer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTabMovesToDefaultStops(t *testing.T) {
+	b := NewBuffer(20, 2, CellAttributes{})
+	b.Write([]rune("a\tb\tc\td")...)
+
+	assert.Equal(t, "a       b       c  d", b.GetAllText())
+}
+
+func TestTabDoesNotOverwrite(t *testing.T) {
+	b := NewBuffer(20, 2, CellAttributes{})
+	b.Write([]rune("abcdefghij\r\t")...)
+
+	assert.Equal(t, uint16(8), b.CursorColumn())
+	assert.Equal(t, "abcdefghij", b.lines[0].String())
+}
+
+func TestSetAndClearTabStops(t *testing.T) {
+	b := NewBuffer(20, 2, CellAttributes{})
+	b.SetPosition(3, 0)
+	b.SetTabStop()
+	b.SetPosition(8, 0)
+	b.ClearTabStop()
+
+	b.SetPosition(0, 0)
+	b.Tab()
+	assert.Equal(t, uint16(3), b.CursorColumn())
+	b.Tab()
+	assert.Equal(t, uint16(16), b.CursorColumn())
+	b.TabBackward(1)
+	assert.Equal(t, uint16(3), b.CursorColumn())
+
+	b.ClearAllTabStops()
+	b.TabBackward(1)
+	assert.Equal(t, uint16(0), b.CursorColumn())
+	b.Tab()
+	assert.Equal(t, uint16(19), b.CursorColumn())
+}
+
+func TestTabForwardAndBackwardByCount(t *testing.T) {
+	b := NewBuffer(30, 2, CellAttributes{})
+	b.TabForward(3)
+	assert.Equal(t, uint16(24), b.CursorColumn())
+	b.TabBackward(2)
+	assert.Equal(t, uint16(8), b.CursorColumn())
+}
+
+func TestWideningGivesNewColumnsDefaultStops(t *testing.T) {
+	b := NewBuffer(10, 2, CellAttributes{})
+	b.ClearAllTabStops()
+	b.ResizeView(20, 2)
+	b.Tab()
+	assert.Equal(t, uint16(16), b.CursorColumn())
+}
@@ -0,0 +1,49 @@
+package buffer
+
+import "unicode"
+
+// wideRanges are the East Asian wide and fullwidth blocks, which take up two columns as they do with wcwidth
+var wideRanges = [][2]rune{
+	{0x1100, 0x115F},   // Hangul Jamo initial consonants
+	{0x2E80, 0x303E},   // CJK radicals, Kangxi radicals, ideographic description characters, CJK symbols and punctuation
+	{0x3041, 0x33FF},   // Hiragana, Katakana, Bopomofo, Hangul compatibility Jamo, Kanbun, CJK strokes, enclosed CJK
+	{0x3400, 0x4DBF},   // CJK unified ideographs extension A
+	{0x4E00, 0x9FFF},   // CJK unified ideographs
+	{0xA000, 0xA4CF},   // Yi syllables and radicals
+	{0xA960, 0xA97F},   // Hangul Jamo extended-A
+	{0xAC00, 0xD7A3},   // Hangul syllables
+	{0xF900, 0xFAFF},   // CJK compatibility ideographs
+	{0xFE10, 0xFE19},   // vertical forms
+	{0xFE30, 0xFE6F},   // CJK compatibility forms, small form variants
+	{0xFF00, 0xFF60},   // fullwidth forms
+	{0xFFE0, 0xFFE6},   // fullwidth signs
+	{0x1F200, 0x1F2FF}, // enclosed ideographic supplement
+	{0x20000, 0x2FFFD}, // CJK unified ideographs extensions B to F, compatibility ideographs supplement
+	{0x30000, 0x3FFFD}, // CJK unified ideographs extension G
+}
+
+// runeWidth returns the number of columns a rune takes up: 0 for combining marks and other zero width runes, which are
+// drawn as part of the cell before them, 2 for East Asian wide characters and emoji, and 1 for everything else
+func runeWidth(r rune) int {
+	switch {
+	case r < 0x300:
+		// ASCII and Latin-1, the most common by far
+		return 1
+	case r >= 0x1160 && r <= 0x11FF:
+		// Hangul Jamo vowels and final consonants, which combine with the initial consonant before them
+		return 0
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case isWideEmoji(r):
+		return 2
+	}
+	if r < wideRanges[0][0] {
+		return 1
+	}
+	for _, wide := range wideRanges {
+		if r >= wide[0] && r <= wide[1] {
+			return 2
+		}
+	}
+	return 1
+}
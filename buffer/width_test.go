@@ -0,0 +1,71 @@
+package buffer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRuneWidth(t *testing.T) {
+	assert.Equal(t, 1, runeWidth('a'))
+	assert.Equal(t, 1, runeWidth('é'))
+	assert.Equal(t, 1, runeWidth('─'))
+	assert.Equal(t, 2, runeWidth('中'))
+	assert.Equal(t, 2, runeWidth('한'))
+	assert.Equal(t, 2, runeWidth('カ'))
+	assert.Equal(t, 2, runeWidth('Ａ'))
+	assert.Equal(t, 2, runeWidth('\U0001F600'))
+	assert.Equal(t, 0, runeWidth('\u0301'))
+	assert.Equal(t, 0, runeWidth('\u200b'))
+}
+
+func TestCJKTakesTwoColumns(t *testing.T) {
+	b := NewBuffer(5, 3, CellAttributes{})
+	b.Write([]rune("中文字")...)
+
+	assert.Equal(t, "中文", b.lines[0].String())
+	assert.True(t, b.lines[0].cells[0].Wide())
+	assert.True(t, b.lines[0].cells[1].IsContinuation())
+	assert.True(t, b.lines[0].cells[3].IsContinuation())
+	assert.Equal(t, "字", b.lines[1].String())
+	assert.Equal(t, uint16(2), b.CursorColumn())
+}
+
+func TestCombiningMarkJoinsCell(t *testing.T) {
+	b := NewBuffer(10, 3, CellAttributes{})
+	b.Write([]rune("e\u0301x")...)
+
+	cells := b.lines[0].cells
+	assert.Len(t, cells, 2)
+	assert.Equal(t, []rune("e\u0301"), cells[0].Runes())
+	assert.Equal(t, uint16(2), b.CursorColumn())
+}
+
+func TestErasingHalfOfCJKErasesBoth(t *testing.T) {
+	b := NewBuffer(10, 3, CellAttributes{})
+	b.Write([]rune("a中b")...)
+	b.SetPosition(2, 0)
+	b.EraseCharacters(1)
+
+	assert.Equal(t, "a  b", b.GetAllText())
+}
+
+func TestSelectingHalfOfWideCellSelectsIt(t *testing.T) {
+	b := NewBuffer(10, 3, CellAttributes{})
+	b.Write([]rune("a中文b")...)
+
+	b.StartSelection(2, 0)
+	b.EndSelection(3, 0, true)
+	assert.Equal(t, "中文", b.GetSelectedText())
+	assert.True(t, b.InSelection(1, 0))
+	assert.True(t, b.InSelection(4, 0))
+	assert.False(t, b.InSelection(5, 0))
+}
+
+func TestWordSelectionSpansWideCells(t *testing.T) {
+	b := NewBuffer(10, 3, CellAttributes{})
+	b.Write([]rune("x 中文 y")...)
+
+	b.SelectWordAtPosition(3, 0)
+	assert.Equal(t, "中文", b.GetSelectedText())
+}
@@ -0,0 +1,25 @@
+package buffer
+
+// Zone is the part of a session a cell was written in, so text can be copied without the parts which aren't wanted
+type Zone uint8
+
+const (
+	ZoneUnknown    Zone = iota // the shell hasn't marked it, e.g. it has no shell integration
+	ZonePrompt                 // the shell's prompt, after OSC 133;A
+	ZoneInput                  // the command typed at the prompt, after OSC 133;B
+	ZoneOutput                 // the output of the command, after OSC 133;C
+	ZoneFullScreen             // written by a full screen program, in the alternate screen
+)
+
+// SetZone sets the zone which cells are written in from now on
+func (buffer *Buffer) SetZone(zone Zone) {
+	buffer.cursorAttr.Zone = zone
+}
+
+// GetSelectedTextWithoutPrompts returns the selected text, leaving out the shell's prompts. Lines which were nothing
+// but prompt, e.g. the first line of a two line prompt, are left out altogether.
+func (buffer *Buffer) GetSelectedTextWithoutPrompts() string {
+	return buffer.selectedText(func(attr *CellAttributes) bool {
+		return attr.Zone == ZonePrompt
+	})
+}
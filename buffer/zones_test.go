@@ -0,0 +1,31 @@
+package buffer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCopyWithoutPrompts(t *testing.T) {
+	b := NewBuffer(20, 6, CellAttributes{})
+
+	b.SetZone(ZonePrompt)
+	b.Write([]rune("~/src (master)")...)
+	b.NewLine()
+	b.CarriageReturn()
+	b.Write([]rune("$ ")...)
+	b.SetZone(ZoneInput)
+	b.Write([]rune("ls")...)
+	b.SetZone(ZoneOutput)
+	b.NewLine()
+	b.CarriageReturn()
+	b.Write([]rune("a.go b.go")...)
+	b.NewLine()
+	b.CarriageReturn()
+	b.SetZone(ZonePrompt)
+	b.Write([]rune("$ ")...)
+
+	b.SelectAll()
+	assert.Equal(t, "~/src (master)\n$ ls\na.go b.go\n$ ", b.GetSelectedText())
+	assert.Equal(t, "ls\na.go b.go", b.GetSelectedTextWithoutPrompts())
+}
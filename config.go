@@ -11,6 +11,24 @@ import (
 	"github.com/liamg/aminal/version"
 )
 
+var (
+	daemonSession string
+	attachSession string
+	listSessions  bool
+	installTerm   bool
+	watchEvents   bool
+	command       string
+	serialDevice  string
+	serialBaud    = 115200
+	serialParity  = "none"
+	connectAddr   string
+	connectTLS    bool
+	recordFile    string
+	replayFile    string
+	sessionFile   string
+	layoutName    string
+)
+
 func getConfig() *config.Config {
 
 	showVersion := false
@@ -25,8 +43,28 @@ func getConfig() *config.Config {
 	conf := loadConfigFile()
 
 	flag.StringVar(&conf.Shell, "shell", conf.Shell, "Specify the shell to use")
+	flag.StringVar(&command, "command", command, "Run the given command with the shell instead of an interactive shell, exiting with its exit status")
+	flag.StringVar(&command, "e", command, "Shorthand for --command")
+	flag.StringVar(&conf.OnExit, "on-exit", conf.OnExit, "What to do when the shell exits: close, hold or restart")
+	flag.StringVar(&conf.Title, "title", conf.Title, "Set a fixed window title, ignoring titles set by programs")
 	flag.BoolVar(&conf.DebugMode, "debug", conf.DebugMode, "Enable debug logging")
 	flag.BoolVar(&conf.Slomo, "slomo", conf.Slomo, "Render in slow motion (useful for debugging)")
+	flag.BoolVar(&conf.ReadOnly, "read-only", conf.ReadOnly, "Start in read-only mode, where input isn't sent to the shell")
+	flag.BoolVar(&conf.Detachable, "detachable", conf.Detachable, "Run the shell in a background session which survives closing the window")
+	flag.StringVar(&attachSession, "attach", attachSession, "Attach to a running detached session")
+	flag.BoolVar(&listSessions, "list-sessions", listSessions, "List running detached sessions and exit")
+	flag.BoolVar(&watchEvents, "events", watchEvents, "Print the events of every running window as lines of JSON, e.g. for a status bar")
+	flag.BoolVar(&installTerm, "install-terminfo", installTerm, "Install or repair the aminal terminfo entry in ~/.terminfo and exit")
+	flag.StringVar(&serialDevice, "serial", serialDevice, "Connect to a serial device, e.g. /dev/ttyUSB0, instead of running a shell")
+	flag.IntVar(&serialBaud, "baud", serialBaud, "Baud rate for --serial")
+	flag.StringVar(&serialParity, "parity", serialParity, "Parity for --serial: none, even or odd")
+	flag.StringVar(&connectAddr, "connect", connectAddr, "Connect to a raw byte stream at host:port or unix:/path instead of running a shell")
+	flag.BoolVar(&connectTLS, "tls", connectTLS, "Use TLS for --connect")
+	flag.StringVar(&recordFile, "record", recordFile, "Record everything the shell outputs to a file, for replaying with --replay")
+	flag.StringVar(&replayFile, "replay", replayFile, "Replay a recording made with --record instead of running a shell")
+	flag.StringVar(&sessionFile, "session", sessionFile, "Open the panes described in a YAML session file, each with its own directory, environment and command")
+	flag.StringVar(&layoutName, "layout", layoutName, "Open a layout saved with the save_layout action, like --session")
+	flag.StringVar(&daemonSession, "daemon", daemonSession, "Serve a detached session with the given name (used internally)")
 
 	flag.Parse()
 
@@ -59,16 +97,25 @@ func loadConfigFile() *config.Config {
 	places = append(places, fmt.Sprintf("%s/.config/aminal/config.toml", home))
 	places = append(places, fmt.Sprintf("%s/.aminal.toml", home))
 
+	invalid := false
 	for _, place := range places {
 		if b, err := ioutil.ReadFile(place); err == nil {
-			if c, err := config.Parse(b); err == nil {
+			c, err := config.Parse(b)
+			if err == nil {
+				c.Path = place
 				return c
 			}
 
 			fmt.Printf("Invalid config at %s: %s\n", place, err)
+			invalid = true
 		}
 	}
 
+	if invalid {
+		// don't write a new config file over one which only needs fixing
+		return &config.DefaultConfig
+	}
+
 	parts := strings.Split(places[0], string(os.PathSeparator))
 	path := strings.Join(parts[0:len(parts)-1], string(os.PathSeparator))
 
@@ -77,12 +124,16 @@ func loadConfigFile() *config.Config {
 		panic(err)
 	}
 
-	if b, err := config.DefaultConfig.Encode(); err != nil {
+	conf := config.DefaultConfig
+	if b, err := conf.Encode(); err != nil {
 		fmt.Printf("Failed to encode config file: %s\n", err)
 	} else {
-		if err := ioutil.WriteFile(fmt.Sprintf("%s/config.toml", path), b, 0644); err != nil {
+		file := fmt.Sprintf("%s/config.toml", path)
+		if err := ioutil.WriteFile(file, b, 0644); err != nil {
 			fmt.Printf("Failed to encode config file: %s\n", err)
+		} else {
+			conf.Path = file
 		}
 	}
-	return &config.DefaultConfig
+	return &conf
 }
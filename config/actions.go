@@ -1,12 +1,121 @@
 package config
 
+import "sort"
+
 type UserAction string
 
 const (
-	ActionCopy        UserAction = "copy"
-	ActionPaste       UserAction = "paste"
-	ActionSearch      UserAction = "search"
-	ActionReportBug   UserAction = "report"
-	ActionToggleDebug UserAction = "debug"
-	ActionToggleSlomo UserAction = "slomo"
+	ActionCopy               UserAction = "copy"
+	ActionCopyWithoutPrompts UserAction = "copy_without_prompts"
+	ActionExpandSelection    UserAction = "expand_selection"
+	ActionSmartSelect        UserAction = "smart_select"
+	ActionNextTheme          UserAction = "next_theme"
+	ActionPaste              UserAction = "paste"
+	ActionPasteSingleLine    UserAction = "paste_single_line"
+	ActionSearch             UserAction = "search"
+	ActionReportBug          UserAction = "report"
+	ActionToggleDebug        UserAction = "debug"
+	ActionToggleSlomo        UserAction = "slomo"
+	ActionShowHelp           UserAction = "help"
+	ActionFind               UserAction = "find"
+	ActionFindPrevious       UserAction = "find_previous"
+	ActionFindNext           UserAction = "find_next"
+	ActionScrollPageUp       UserAction = "scroll_page_up"
+	ActionScrollPageDown     UserAction = "scroll_page_down"
+	ActionPipeSelection      UserAction = "pipe_selection"
+	ActionPipeScreen         UserAction = "pipe_screen"
+	ActionPipeScrollback     UserAction = "pipe_scrollback"
+	ActionHighlightSelection UserAction = "highlight_selection"
+	ActionEditScrollback     UserAction = "edit_scrollback"
+	ActionEditAltScreen      UserAction = "edit_alt_screen_history"
+	ActionInsertMark         UserAction = "insert_mark"
+	ActionClearScrollback    UserAction = "clear_scrollback"
+	ActionClearAll           UserAction = "clear_all"
+	ActionClearToMark        UserAction = "clear_to_mark"
+	ActionToggleReadOnly     UserAction = "read_only"
+	ActionTogglePause        UserAction = "pause_output"
+	ActionCommandPalette     UserAction = "command_palette"
+	ActionOpenConfig         UserAction = "open_config"
+	ActionNewWindow          UserAction = "new_window"
+	ActionIncreaseFontSize   UserAction = "increase_font_size"
+	ActionDecreaseFontSize   UserAction = "decrease_font_size"
+	ActionResetFontSize      UserAction = "reset_font_size"
+	ActionSplitRight         UserAction = "split_right"
+	ActionSplitDown          UserAction = "split_down"
+	ActionClosePane          UserAction = "close_pane"
+	ActionNextPane           UserAction = "next_pane"
+	ActionPaneWider          UserAction = "pane_wider"
+	ActionPaneNarrower       UserAction = "pane_narrower"
+	ActionPaneTaller         UserAction = "pane_taller"
+	ActionPaneShorter        UserAction = "pane_shorter"
+	ActionZoomPane           UserAction = "zoom_pane"
+	ActionSaveLayout         UserAction = "save_layout"
+	ActionResizePanes        UserAction = "resize_panes"
 )
+
+var actionDescriptions = map[UserAction]string{
+	ActionCopy:               "Copy selected text to the clipboard",
+	ActionCopyWithoutPrompts: "Copy selected text to the clipboard, leaving out shell prompts",
+	ActionExpandSelection:    "Expand the selection to the word, quotes or brackets, line, then command output around it",
+	ActionSmartSelect:        "Select the quoted string, brackets or JSON object under the mouse pointer",
+	ActionNextTheme:          "Switch to the next colour theme",
+	ActionPaste:              "Paste from the clipboard",
+	ActionPasteSingleLine:    "Paste from the clipboard as one line, joining its lines with spaces",
+	ActionSearch:             "Search the web for selected text",
+	ActionReportBug:          "Report a bug",
+	ActionToggleDebug:        "Toggle debug overlay",
+	ActionToggleSlomo:        "Toggle slow motion output",
+	ActionShowHelp:           "Show keyboard shortcuts",
+	ActionFind:               "Find text in the scrollback",
+	ActionFindPrevious:       "Jump to the previous, older, match of the last search",
+	ActionFindNext:           "Jump to the next, newer, match of the last search",
+	ActionScrollPageUp:       "Scroll up a page through the scrollback",
+	ActionScrollPageDown:     "Scroll down a page, towards the latest output",
+	ActionPipeSelection:      "Pipe selected text to the pipe command",
+	ActionPipeScreen:         "Pipe the visible screen to the pipe command",
+	ActionPipeScrollback:     "Pipe the entire scrollback to the pipe command",
+	ActionHighlightSelection: "View selected text coloured by the highlighter",
+	ActionEditScrollback:     "Open the scrollback in your editor",
+	ActionEditAltScreen:      "Open what full screen programs showed earlier in your editor",
+	ActionInsertMark:         "Draw a separator above the current line",
+	ActionClearScrollback:    "Clear the scrollback, keeping the screen",
+	ActionClearAll:           "Clear the scrollback and screen, keeping the current line",
+	ActionClearToMark:        "Clear everything above the previous prompt or separator",
+	ActionToggleReadOnly:     "Toggle read-only mode, where input isn't sent to the shell",
+	ActionTogglePause:        "Pause or resume output, to read text which is scrolling past",
+	ActionCommandPalette:     "Search for an action to run",
+	ActionOpenConfig:         "Open the config file in your editor",
+	ActionNewWindow:          "Open a new window in the current directory",
+	ActionIncreaseFontSize:   "Make the text bigger",
+	ActionDecreaseFontSize:   "Make the text smaller",
+	ActionResetFontSize:      "Put the text back to its normal size",
+	ActionSplitRight:         "Split the pane, starting a new shell on the right",
+	ActionSplitDown:          "Split the pane, starting a new shell below",
+	ActionClosePane:          "Close the pane, ending its shell",
+	ActionNextPane:           "Move to the next pane",
+	ActionPaneWider:          "Make the pane wider",
+	ActionPaneNarrower:       "Make the pane narrower",
+	ActionPaneTaller:         "Make the pane taller",
+	ActionPaneShorter:        "Make the pane shorter",
+	ActionZoomPane:           "Zoom the pane to fill the window, or put it back",
+	ActionSaveLayout:         "Save the panes, their directories and commands as a named layout",
+	ActionResizePanes:        "Resize the pane with the arrow keys, until escape is pressed",
+}
+
+// actionNames returns the names of all the actions, in alphabetical order
+func actionNames() []string {
+	names := []string{}
+	for action := range actionDescriptions {
+		names = append(names, string(action))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Description returns a short human readable explanation of what the action does
+func (action UserAction) Description() string {
+	if description, ok := actionDescriptions[action]; ok {
+		return description
+	}
+	return string(action)
+}
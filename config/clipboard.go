@@ -0,0 +1,64 @@
+package config
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ansiSequence matches the escape sequences programs colour and move around text with: CSI sequences, OSC strings,
+// the other strings ended by ST, and the two and three byte escapes
+var ansiSequence = regexp.MustCompile("\x1b(\\[[0-?]*[ -/]*[@-~]|\\][^\x07\x1b]*(\x07|\x1b\\\\)|[PX^_][^\x1b]*\x1b\\\\|[ -/]*[0-~])")
+
+// ClipboardConfig is how text is cleaned up on its way to the clipboard, whether copied from a selection or by a
+// program with OSC 52
+type ClipboardConfig struct {
+	TrimTrailingNewlines bool `toml:"trim_trailing_newlines"` // so pasting a command doesn't run it straight away
+	StripANSI            bool `toml:"strip_ansi"`             // remove escape sequences, e.g. colours copied with OSC 52
+	EscapeControls       bool `toml:"escape_controls"`        // copy control characters in caret notation, e.g. ^[ for ESC
+}
+
+// Sanitise returns text as it should be put on the clipboard
+func (conf *ClipboardConfig) Sanitise(text string) string {
+	if conf.StripANSI {
+		text = ansiSequence.ReplaceAllString(text, "")
+	}
+	if conf.EscapeControls {
+		text = escapeControls(text)
+	}
+	if conf.TrimTrailingNewlines {
+		text = strings.TrimRight(text, "\r\n")
+	}
+	return text
+}
+
+// escapeControls replaces control characters other than tabs and line breaks with their caret notation, so they can
+// be seen, and can't do anything when pasted
+func escapeControls(text string) string {
+	var escaped strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '\t' || r == '\n' || r == '\r':
+			escaped.WriteRune(r)
+		case r < 0x20:
+			escaped.WriteString("^" + string(r+0x40))
+		case r == 0x7f:
+			escaped.WriteString("^?")
+		default:
+			escaped.WriteRune(r)
+		}
+	}
+	return escaped.String()
+}
+
+// JoinLines joins the lines of text with spaces, so a command split over several lines, e.g. in documentation, can be
+// pasted as one. Blank lines, indentation and the backslashes which continue lines are left out.
+func JoinLines(text string) string {
+	parts := []string{}
+	for _, line := range strings.FieldsFunc(text, func(r rune) bool { return r == '\n' || r == '\r' }) {
+		line = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(line), "\\"))
+		if line != "" {
+			parts = append(parts, line)
+		}
+	}
+	return strings.Join(parts, " ")
+}
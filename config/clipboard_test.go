@@ -0,0 +1,38 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClipboardSanitise(t *testing.T) {
+	text := "\x1b[31mred\x1b[0m \x1b]8;;http://example.com\x07link\x1b]8;;\x07 bell\x07\n\n"
+
+	assert.Equal(t, text, DefaultConfig.Clipboard.Sanitise(text), "copied text should be left as it is by default")
+
+	conf := ClipboardConfig{StripANSI: true}
+	assert.Equal(t, "red link bell\x07\n\n", conf.Sanitise(text))
+
+	conf = ClipboardConfig{StripANSI: true, EscapeControls: true, TrimTrailingNewlines: true}
+	assert.Equal(t, "red link bell^G", conf.Sanitise(text))
+
+	conf = ClipboardConfig{EscapeControls: true}
+	assert.Equal(t, "^[[1mbold^[[0m\tdel^?", conf.Sanitise("\x1b[1mbold\x1b[0m\tdel\x7f"))
+}
+
+func TestJoinLines(t *testing.T) {
+	assert.Equal(t, "docker run --rm -it alpine sh", JoinLines("docker run \\\n  --rm -it \\\n  alpine sh\n"))
+	assert.Equal(t, "one two", JoinLines("one\r\n\r\ntwo"))
+	assert.Equal(t, "", JoinLines("\n\n"))
+}
+
+func TestClipboardConfig(t *testing.T) {
+	conf, err := Parse([]byte(`
+[clipboard]
+  trim_trailing_newlines = true
+  strip_ansi = true
+`))
+	assert.Nil(t, err)
+	assert.Equal(t, ClipboardConfig{TrimTrailingNewlines: true, StripANSI: true}, conf.Clipboard)
+}
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"fmt"
+	"math"
+)
+
+// Colour vision deficiencies which colours can be filtered for
+const (
+	ColourFilterNone         = ""
+	ColourFilterProtanopia   = "protanopia"   // no red cones
+	ColourFilterDeuteranopia = "deuteranopia" // no green cones, the most common
+	ColourFilterTritanopia   = "tritanopia"   // no blue cones
+)
+
+// What a colour filter does
+const (
+	ColourFilterSimulate = "simulate" // show colours as someone with the deficiency sees them
+	ColourFilterCorrect  = "correct"  // shift colours so they can be told apart with the deficiency (daltonization)
+)
+
+type colourMatrix [3][3]float64
+
+// simulations are from Machado, Oliveira and Fernandes (2009), at full severity, for linear RGB
+var simulations = map[string]colourMatrix{
+	ColourFilterProtanopia: {
+		{0.152286, 1.052583, -0.204868},
+		{0.114503, 0.786281, 0.099216},
+		{-0.003882, -0.048116, 1.051998},
+	},
+	ColourFilterDeuteranopia: {
+		{0.367322, 0.860646, -0.227968},
+		{0.280085, 0.672501, 0.047413},
+		{-0.011820, 0.042940, 0.968881},
+	},
+	ColourFilterTritanopia: {
+		{1.255528, -0.076749, -0.178779},
+		{-0.078411, 0.930809, 0.147602},
+		{0.004733, 0.691367, 0.303900},
+	},
+}
+
+// corrections move the difference that can't be seen into channels that can, after Fidaner, Lin and Ozguven (2005)
+var corrections = map[string]colourMatrix{
+	ColourFilterProtanopia:   {{0, 0, 0}, {0.7, 1, 0}, {0.7, 0, 1}},
+	ColourFilterDeuteranopia: {{0, 0, 0}, {0.7, 1, 0}, {0.7, 0, 1}},
+	ColourFilterTritanopia:   {{1, 0, 0.7}, {0, 1, 0.7}, {0, 0, 0}},
+}
+
+var identity = colourMatrix{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}
+
+func (m colourMatrix) mul(n colourMatrix) colourMatrix {
+	var out colourMatrix
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			for k := 0; k < 3; k++ {
+				out[i][j] += m[i][k] * n[k][j]
+			}
+		}
+	}
+	return out
+}
+
+func (m colourMatrix) sub(n colourMatrix) colourMatrix {
+	for i := range m {
+		for j := range m[i] {
+			m[i][j] -= n[i][j]
+		}
+	}
+	return m
+}
+
+func (m colourMatrix) add(n colourMatrix) colourMatrix {
+	for i := range m {
+		for j := range m[i] {
+			m[i][j] += n[i][j]
+		}
+	}
+	return m
+}
+
+// ColourFilter changes colours at render time, for colour blind users
+type ColourFilter struct {
+	matrix colourMatrix // applied to linear RGB
+}
+
+// NewColourFilter returns a filter which simulates or corrects for a colour vision deficiency
+func NewColourFilter(deficiency string, mode string) (*ColourFilter, error) {
+	simulation, ok := simulations[deficiency]
+	if !ok {
+		return nil, fmt.Errorf("Invalid colour filter '%s': should be %s, %s or %s", deficiency, ColourFilterProtanopia, ColourFilterDeuteranopia, ColourFilterTritanopia)
+	}
+	switch mode {
+	case ColourFilterSimulate:
+		return &ColourFilter{matrix: simulation}, nil
+	case ColourFilterCorrect:
+		// the colour, plus what is lost to the deficiency moved to where it can be seen
+		lost := identity.sub(simulation)
+		return &ColourFilter{matrix: identity.add(corrections[deficiency].mul(lost))}, nil
+	}
+	return nil, fmt.Errorf("Invalid colour filter mode '%s': should be %s or %s", mode, ColourFilterSimulate, ColourFilterCorrect)
+}
+
+// Apply returns a colour as the filter changes it
+func (filter *ColourFilter) Apply(c Colour) Colour {
+	var linear [3]float64
+	for i := range c {
+		linear[i] = ToLinear(float64(c[i]))
+	}
+	var out Colour
+	for i := range out {
+		v := 0.0
+		for j := range linear {
+			v += filter.matrix[i][j] * linear[j]
+		}
+		out[i] = float32(FromLinear(math.Min(math.Max(v, 0), 1)))
+	}
+	return out
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestColourFilterKeepsGreys(t *testing.T) {
+	for deficiency := range simulations {
+		for _, mode := range []string{ColourFilterSimulate, ColourFilterCorrect} {
+			filter, err := NewColourFilter(deficiency, mode)
+			require.Nil(t, err)
+			for _, grey := range []Colour{{0, 0, 0}, {0.5, 0.5, 0.5}, {1, 1, 1}} {
+				out := filter.Apply(grey)
+				for i := range out {
+					assert.InDelta(t, grey[i], out[i], 0.01, "%s %s %v", deficiency, mode, grey)
+				}
+			}
+		}
+	}
+}
+
+func TestSimulatedDeuteranopiaConfusesRedAndGreen(t *testing.T) {
+	filter, err := NewColourFilter(ColourFilterDeuteranopia, ColourFilterSimulate)
+	require.Nil(t, err)
+	red := filter.Apply(Colour{0.8, 0.3, 0.1})
+	green := filter.Apply(Colour{0.5, 0.5, 0.1})
+	assert.InDelta(t, red[0], green[0], 0.15)
+	assert.InDelta(t, red[1], green[1], 0.15)
+}
+
+func TestCorrectedProtanopiaShiftsRedTowardsBlue(t *testing.T) {
+	filter, err := NewColourFilter(ColourFilterProtanopia, ColourFilterCorrect)
+	require.Nil(t, err)
+	red := filter.Apply(Colour{1, 0, 0})
+	assert.True(t, red[2] > 0.3, "%v", red)
+}
+
+func TestInvalidColourFilter(t *testing.T) {
+	_, err := NewColourFilter("achromatopsia", ColourFilterCorrect)
+	assert.NotNil(t, err)
+	_, err = NewColourFilter(ColourFilterTritanopia, "")
+	assert.NotNil(t, err)
+}
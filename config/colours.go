@@ -44,6 +44,15 @@ func (c *Colour) UnmarshalText(data []byte) error {
 	return err
 }
 
+// UnmarshalTOML allows colours to be decoded where the toml package does not look for a TextUnmarshaler, e.g. map values
+func (c *Colour) UnmarshalTOML(data interface{}) error {
+	s, ok := data.(string)
+	if !ok {
+		return fmt.Errorf("Invalid colour: %v", data)
+	}
+	return c.UnmarshalText([]byte(s))
+}
+
 func (c Colour) MarshalText() (text []byte, err error) {
 	return []byte(fmt.Sprintf(
 		"#%02x%02x%02x",
@@ -75,3 +84,19 @@ type ColourScheme struct {
 	White        Colour `toml:"white"`
 	Selection    Colour `toml:"selection"`
 }
+
+// ToLinear converts an sRGB channel to linear light
+func ToLinear(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+// FromLinear converts a linear light channel to sRGB
+func FromLinear(v float64) float64 {
+	if v <= 0.0031308 {
+		return v * 12.92
+	}
+	return 1.055*math.Pow(v, 1/2.4) - 0.055
+}
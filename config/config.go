@@ -2,28 +2,148 @@ package config
 
 import (
 	"bytes"
+	"fmt"
 
 	"github.com/BurntSushi/toml"
 )
 
+// more snapshots of the alternate screen than this, each a screenful of text, would be a lot of memory to keep
+const maxAltScreenHistory = 1000
+
 type Config struct {
-	DebugMode    bool             `toml:"debug"`
-	Slomo        bool             `toml:"slomo"`
-	ColourScheme ColourScheme     `toml:"colours"`
-	Shell        string           `toml:"shell"`
-	KeyMapping   KeyMappingConfig `toml:"keys"`
-	SearchURL    string           `toml:"search_url"`
+	DebugMode        bool             `toml:"debug"`
+	Slomo            bool             `toml:"slomo"`
+	ColourScheme     ColourScheme     `toml:"colours"`
+	Theme            string           `toml:"theme"`  // the theme used at start, or empty for [colours]
+	Themes           ThemesConfig     `toml:"themes"` // named sets of colours which replace those in [colours]
+	Shell            string           `toml:"shell"`
+	Term             string           `toml:"term"`      // TERM for the shell, falling back to xterm-256color if it is aminal and its terminfo entry can't be installed
+	ColorTerm        string           `toml:"colorterm"` // COLORTERM for the shell, telling programs true colour works
+	KeyMapping       KeyMappingConfig `toml:"keys"`
+	SearchURL        string           `toml:"search_url"`
+	Detachable       bool             `toml:"detachable"`
+	ControlSocket    bool             `toml:"control_socket"` // publish events such as title changes and bells for status bars
+	HostProfiles     []HostProfile    `toml:"host_profiles"`
+	StatusBar        StatusBarConfig  `toml:"status_bar"`
+	Pipe             PipeConfig       `toml:"pipe"`
+	Editor           string           `toml:"editor"`
+	EditorLine       string           `toml:"editor_line"`        // command to open $FILE at $LINE and $COLUMN, defaults to the editor with +$LINE
+	Scrollback       int              `toml:"scrollback_lines"`   // the most lines kept above the screen, 0 for none
+	AltScreenHistory int              `toml:"alt_screen_history"` // snapshots of the alternate screen kept to look back at, 0 for none
+	ScrollOnKey      bool             `toml:"scroll_on_key"`      // go back to the bottom when typing while scrolled back
+	ScrollOnOutput   bool             `toml:"scroll_on_output"`   // go back to the bottom when output arrives while scrolled back
+	Scrollbar        bool             `toml:"scrollbar"`          // show a scrollbar marking prompts, bells and failed commands
+	PaneResizeStep   int              `toml:"pane_resize_step"`   // columns a pane is resized by from the keyboard, and half as many rows
+	Title            string           `toml:"title"`              // fixed window title, which programs can't change
+	LockTitle        bool             `toml:"lock_title"`         // ignore window title changes from programs
+	TitleTemplate    string           `toml:"title_template"`     // window title made from TitleValues placeholders e.g. "{command} in {cwd}"
+	Font             FontConfig       `toml:"font"`
+	Mouse            MouseConfig      `toml:"mouse"`
+	Cursor           CursorConfig     `toml:"cursor"`
+	Input            InputConfig      `toml:"input"`
+	Macros           MacrosConfig     `toml:"macros"`
+	Graphics         GraphicsConfig   `toml:"graphics"`
+	Security         SecurityConfig   `toml:"security"`
+	Hooks            HooksConfig      `toml:"hooks"`
+	Git              GitConfig        `toml:"git"`
+	Clipboard        ClipboardConfig  `toml:"clipboard"`
+	Progress         ProgressConfig   `toml:"progress"`
+	ReadOnly         bool             `toml:"read_only"`     // don't send keyboard, mouse or pasted input to the shell
+	OnExit           string           `toml:"on_exit"`       // one of the OnExit* values
+	ConfirmClose     []string         `toml:"confirm_close"` // programs which, while in the foreground, need confirmation to close the window
+	CloseFreely      []string         `toml:"close_freely"`  // programs which never need confirmation, e.g. nested shells
+	Path             string           `toml:"-"`             // the file the config was loaded from, if any
+}
+
+// PipeConfig is the external command which text can be piped to from the terminal
+type PipeConfig struct {
+	Command     string `toml:"command"`      // run with sh -c, receiving the text on stdin
+	PasteOutput bool   `toml:"paste_output"` // paste whatever the command writes to stdout back into the terminal
+	Highlighter string `toml:"highlighter"`  // run with sh -c to colour text on stdin for viewing, e.g. bat or delta
 }
 
 type KeyMappingConfig map[string]string
 
+// ThemesConfig maps theme names to the colours they change, keyed as in [colours]
+type ThemesConfig map[string]map[string]Colour
+
 func Parse(data []byte) (*Config, error) {
 	c := DefaultConfig
-	err := toml.Unmarshal(data, &c)
+	// copy the default shortcuts, so the ones in the file don't change them for everyone else
+	c.KeyMapping = KeyMappingConfig(map[string]string{})
+	for action, keyStr := range DefaultConfig.KeyMapping {
+		c.KeyMapping[action] = keyStr
+	}
+	meta, err := toml.Decode(string(data), &c)
 	if c.KeyMapping == nil {
 		c.KeyMapping = KeyMappingConfig(map[string]string{})
 	}
-	return &c, err
+	if err != nil {
+		return &c, err
+	}
+	if err := c.KeyMapping.validate(); err != nil {
+		return &c, err
+	}
+	c.KeyMapping.unbindShadowedDefaults(func(action string) bool {
+		return meta.IsDefined("keys", action)
+	})
+	if err := c.StatusBar.validate(); err != nil {
+		return &c, err
+	}
+	if err := c.Font.validate(); err != nil {
+		return &c, err
+	}
+	if err := c.Mouse.validate(); err != nil {
+		return &c, err
+	}
+	if err := c.Cursor.validate(); err != nil {
+		return &c, err
+	}
+	if err := c.Input.validate(); err != nil {
+		return &c, err
+	}
+	if err := c.Macros.validate(); err != nil {
+		return &c, err
+	}
+	if err := c.Graphics.validate(); err != nil {
+		return &c, err
+	}
+	if err := c.Security.validate(); err != nil {
+		return &c, err
+	}
+	if err := c.Hooks.validate(); err != nil {
+		return &c, err
+	}
+	if err := c.Git.validate(); err != nil {
+		return &c, err
+	}
+	if err := c.Progress.validate(); err != nil {
+		return &c, err
+	}
+	if err := validateTitleTemplate(c.TitleTemplate); err != nil {
+		return &c, err
+	}
+	if err := validateOnExit(c.OnExit); err != nil {
+		return &c, err
+	}
+	if c.Scrollback < 0 {
+		return &c, fmt.Errorf("Invalid scrollback_lines %d: should be 0 or more", c.Scrollback)
+	}
+	if c.PaneResizeStep < 1 {
+		return &c, fmt.Errorf("Invalid pane_resize_step %d: should be 1 or more", c.PaneResizeStep)
+	}
+	if c.AltScreenHistory < 0 || c.AltScreenHistory > maxAltScreenHistory {
+		return &c, fmt.Errorf("Invalid alt_screen_history %d: should be from 0 to %d", c.AltScreenHistory, maxAltScreenHistory)
+	}
+	if err := c.validateThemes(); err != nil {
+		return &c, err
+	}
+	for i := range c.HostProfiles {
+		if err := c.HostProfiles[i].validate(); err != nil {
+			return &c, err
+		}
+	}
+	return &c, nil
 }
 
 func (c *Config) Encode() ([]byte, error) {
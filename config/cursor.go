@@ -0,0 +1,21 @@
+package config
+
+import "fmt"
+
+type CursorConfig struct {
+	Smooth            bool `toml:"smooth"`             // glide between cells instead of jumping
+	Trail             bool `toml:"trail"`              // leave a fading trail behind when the cursor moves
+	AnimationDuration int  `toml:"animation_duration"` // milliseconds
+}
+
+func (conf *CursorConfig) validate() error {
+	if conf.AnimationDuration < 0 {
+		return fmt.Errorf("Invalid cursor animation duration %d: should not be negative", conf.AnimationDuration)
+	}
+	return nil
+}
+
+// Animated returns true if the cursor should be animated when it moves
+func (conf *CursorConfig) Animated() bool {
+	return (conf.Smooth || conf.Trail) && conf.AnimationDuration > 0
+}
@@ -0,0 +1,36 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursorAnimation(t *testing.T) {
+	conf, err := Parse([]byte(``))
+	require.Nil(t, err)
+	assert.False(t, conf.Cursor.Animated())
+
+	conf, err = Parse([]byte(`
+[cursor]
+  trail = true
+  animation_duration = 150
+`))
+	require.Nil(t, err)
+	assert.True(t, conf.Cursor.Animated())
+
+	conf, err = Parse([]byte(`
+[cursor]
+  smooth = true
+  animation_duration = 0
+`))
+	require.Nil(t, err)
+	assert.False(t, conf.Cursor.Animated())
+
+	_, err = Parse([]byte(`
+[cursor]
+  animation_duration = -1
+`))
+	assert.NotNil(t, err)
+}
@@ -26,17 +26,143 @@ var DefaultConfig = Config{
 		White:        strToColourNoErr("#f6f6c9"),
 		Selection:    strToColourNoErr("#333366"),
 	},
-	KeyMapping: KeyMappingConfig(map[string]string{}),
-	SearchURL:  "https://www.google.com/search?q=$QUERY",
+	KeyMapping:     KeyMappingConfig(map[string]string{}),
+	SearchURL:      "https://www.google.com/search?q=$QUERY",
+	Scrollback:     10000,
+	ScrollOnKey:    true,
+	Scrollbar:      true,
+	PaneResizeStep: 2,
+	Term:           "aminal",
+	ColorTerm:      "truecolor",
+	ControlSocket:  false,
+	OnExit:         OnExitClose,
+	ConfirmClose:   []string{"*"},
+	CloseFreely:    []string{"bash", "zsh", "fish", "sh", "dash", "tmux", "screen"},
+	Font: FontConfig{
+		Hinting:  "full",
+		Gamma:    1,
+		Contrast: 1,
+		Subpixel: "none",
+	},
+	Pipe: PipeConfig{
+		Highlighter: "bat --color=always --style=plain --paging=never",
+	},
+	Cursor: CursorConfig{
+		AnimationDuration: 100,
+	},
+	Input: InputConfig{
+		Encoding: EncodingXterm,
+	},
+	Macros: MacrosConfig{
+		Prefix: ";;",
+		Expand: "tab",
+	},
+	Git: GitConfig{
+		Commit:   GitActionShow,
+		DiffFile: GitActionOpen,
+	},
+	Progress: ProgressConfig{
+		Position: ProgressBottom,
+		Height:   3,
+		Pattern:  `(?:^|[^\d.])(\d{1,3})(?:\.\d+)?%`,
+	},
+	Graphics: GraphicsConfig{
+		TextureBudget:    256,
+		ColourFilterMode: ColourFilterCorrect,
+		LinearBlending:   true,
+		ColourSpace:      ColourSpaceSRGB,
+		BlinkInterval:    500,
+		WatchdogTimeout:  10,
+	},
+	Mouse: MouseConfig{
+		ContextMenu:         true,
+		BypassModifier:      "shift",
+		CopyOnSelect:        true,
+		LinkModifier:        "ctrl",
+		LinkPreview:         true,
+		WheelLines:          1,
+		AlternateLines:      3,
+		TouchpadSensitivity: 1,
+	},
+	Security: SecurityConfig{
+		ClipboardWrite:   PolicyAllow,
+		ClipboardRead:    PolicyAsk,
+		ClipboardLimit:   1 << 20,
+		TitleReport:      PolicyDeny,
+		FileURLs:         PolicyAsk,
+		WindowOps:        PolicyDeny,
+		Answerback:       PolicyDeny,
+		Hooks:            PolicyAllow,
+		SecretInputGuard: true,
+	},
+	StatusBar: StatusBarConfig{
+		Enabled:  false,
+		Position: "bottom",
+		Segments: []string{
+			StatusSegmentTitle,
+			StatusSegmentCwd,
+			StatusSegmentGit,
+			StatusSegmentScroll,
+			StatusSegmentBell,
+			StatusSegmentActivity,
+			StatusSegmentReadOnly,
+			StatusSegmentPaused,
+			StatusSegmentClock,
+		},
+		ClockFormat: "15:04",
+	},
 }
 
 func init() {
 	DefaultConfig.KeyMapping[string(ActionCopy)] = addMod("c")
+	DefaultConfig.KeyMapping[string(ActionCopyWithoutPrompts)] = ""
+	DefaultConfig.KeyMapping[string(ActionExpandSelection)] = ""
+	DefaultConfig.KeyMapping[string(ActionSmartSelect)] = ""
+	DefaultConfig.KeyMapping[string(ActionNextTheme)] = ""
 	DefaultConfig.KeyMapping[string(ActionPaste)] = addMod("v")
+	DefaultConfig.KeyMapping[string(ActionPasteSingleLine)] = ""
 	DefaultConfig.KeyMapping[string(ActionSearch)] = addMod("g")
 	DefaultConfig.KeyMapping[string(ActionToggleDebug)] = addMod("d")
 	DefaultConfig.KeyMapping[string(ActionToggleSlomo)] = addMod(";")
 	DefaultConfig.KeyMapping[string(ActionReportBug)] = addMod("r")
+	DefaultConfig.KeyMapping[string(ActionShowHelp)] = addMod("/")
+	DefaultConfig.KeyMapping[string(ActionFind)] = addMod("f")
+	DefaultConfig.KeyMapping[string(ActionFindPrevious)] = addMod("up")
+	DefaultConfig.KeyMapping[string(ActionFindNext)] = addMod("down")
+	DefaultConfig.KeyMapping[string(ActionScrollPageUp)] = "shift + page_up"
+	DefaultConfig.KeyMapping[string(ActionScrollPageDown)] = "shift + page_down"
+	DefaultConfig.KeyMapping[string(ActionCommandPalette)] = addMod("p")
+	DefaultConfig.KeyMapping[string(ActionPipeSelection)] = ""
+	DefaultConfig.KeyMapping[string(ActionHighlightSelection)] = addMod("h")
+	DefaultConfig.KeyMapping[string(ActionEditScrollback)] = addMod("e")
+	DefaultConfig.KeyMapping[string(ActionEditAltScreen)] = ""
+	DefaultConfig.KeyMapping[string(ActionInsertMark)] = addMod("m")
+	DefaultConfig.KeyMapping[string(ActionClearScrollback)] = addMod("k")
+	DefaultConfig.KeyMapping[string(ActionToggleReadOnly)] = addMod("o")
+	DefaultConfig.KeyMapping[string(ActionTogglePause)] = addMod("s")
+	DefaultConfig.KeyMapping[string(ActionClearAll)] = ""
+	DefaultConfig.KeyMapping[string(ActionClearToMark)] = ""
+	DefaultConfig.KeyMapping[string(ActionOpenConfig)] = ""
+	DefaultConfig.KeyMapping[string(ActionNewWindow)] = addMod("n")
+	DefaultConfig.KeyMapping[string(ActionIncreaseFontSize)] = addMod("=")
+	DefaultConfig.KeyMapping[string(ActionDecreaseFontSize)] = addMod("-")
+	DefaultConfig.KeyMapping[string(ActionResetFontSize)] = addMod("0")
+	DefaultConfig.KeyMapping[string(ActionSplitRight)] = addMod("]")
+	DefaultConfig.KeyMapping[string(ActionSplitDown)] = addMod("[")
+	DefaultConfig.KeyMapping[string(ActionClosePane)] = addMod("w")
+	DefaultConfig.KeyMapping[string(ActionNextPane)] = addMod("tab")
+	DefaultConfig.KeyMapping[string(ActionPaneWider)] = addMod("alt + right")
+	DefaultConfig.KeyMapping[string(ActionPaneNarrower)] = addMod("alt + left")
+	DefaultConfig.KeyMapping[string(ActionPaneTaller)] = addMod("alt + down")
+	DefaultConfig.KeyMapping[string(ActionPaneShorter)] = addMod("alt + up")
+	DefaultConfig.KeyMapping[string(ActionZoomPane)] = addMod("z")
+	DefaultConfig.KeyMapping[string(ActionSaveLayout)] = ""
+	DefaultConfig.KeyMapping[string(ActionResizePanes)] = addMod("x")
+
+	// macOS already sends momentum scroll events
+	DefaultConfig.Mouse.ScrollMomentum = runtime.GOOS != "darwin"
+	// option types accented characters and symbols on macOS
+	DefaultConfig.Input.AltSendsEscape = runtime.GOOS != "darwin"
 }
 
 func addMod(keys string) string {
@@ -0,0 +1,36 @@
+package config
+
+import "fmt"
+
+// what to do when the shell exits
+const (
+	OnExitClose   = "close"   // close the window
+	OnExitHold    = "hold"    // keep the window open, showing the exit status
+	OnExitRestart = "restart" // show the exit status and start a new shell when enter is pressed
+)
+
+func validateOnExit(policy string) error {
+	switch policy {
+	case OnExitClose, OnExitHold, OnExitRestart:
+		return nil
+	}
+	return fmt.Errorf("Invalid on_exit '%s': should be close, hold or restart", policy)
+}
+
+// ConfirmCloseFor returns true if closing the window while the named program is in the foreground needs confirmation
+func (conf *Config) ConfirmCloseFor(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, program := range conf.CloseFreely {
+		if program == name {
+			return false
+		}
+	}
+	for _, program := range conf.ConfirmClose {
+		if program == name || program == "*" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnExit(t *testing.T) {
+	conf, err := Parse([]byte(``))
+	require.Nil(t, err)
+	assert.Equal(t, OnExitClose, conf.OnExit)
+
+	conf, err = Parse([]byte(`on_exit = "restart"`))
+	require.Nil(t, err)
+	assert.Equal(t, OnExitRestart, conf.OnExit)
+
+	_, err = Parse([]byte(`on_exit = "explode"`))
+	assert.NotNil(t, err)
+}
+
+func TestConfirmCloseFor(t *testing.T) {
+	conf, err := Parse([]byte(`confirm_close = ["vim"]`))
+	require.Nil(t, err)
+	assert.True(t, conf.ConfirmCloseFor("vim"))
+	assert.False(t, conf.ConfirmCloseFor("top"))
+	assert.False(t, conf.ConfirmCloseFor(""))
+
+	conf.ConfirmClose = []string{"*"}
+	assert.True(t, conf.ConfirmCloseFor("top"))
+	assert.False(t, conf.ConfirmCloseFor("zsh"))
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+type FontConfig struct {
+	Hinting  string         `toml:"hinting"`  // "none", "slight" or "full"
+	Gamma    float64        `toml:"gamma"`    // applied to glyph coverage, 1 leaves it unchanged
+	Contrast float64        `toml:"contrast"` // above 1 sharpens glyph edges, below 1 softens them
+	Subpixel string         `toml:"subpixel"` // LCD subpixel order: "none", "rgb" or "bgr"
+	Emoji    string         `toml:"emoji"`    // path to a TrueType font used for emoji, which Hack doesn't have
+	Regular  FontFaceConfig `toml:"regular"`
+	Bold     FontFaceConfig `toml:"bold"`
+}
+
+type FontFaceConfig struct {
+	File     string    `toml:"file"`     // path to a TrueType font, the bundled Hack font is used if empty
+	Features []string  `toml:"features"` // OpenType feature tags to apply e.g. "zero" or "ss01"
+	Weight   AxisValue `toml:"weight"`   // variable fonts only, 0 uses the font's default
+	Width    AxisValue `toml:"width"`    // variable fonts only, 0 uses the font's default
+}
+
+// AxisValue is a variable font axis coordinate, which can be written in the config as an integer (weight = 450) or a
+// float (width = 87.5)
+type AxisValue float64
+
+func (value *AxisValue) UnmarshalTOML(data interface{}) error {
+	switch v := data.(type) {
+	case int64:
+		*value = AxisValue(v)
+	case float64:
+		*value = AxisValue(v)
+	default:
+		return fmt.Errorf("Invalid font axis value: %v", data)
+	}
+	return nil
+}
+
+func (conf *FontConfig) validate() error {
+	switch conf.Hinting {
+	case "none", "slight", "full":
+	default:
+		return fmt.Errorf("Invalid font hinting '%s': should be none, slight or full", conf.Hinting)
+	}
+	switch conf.Subpixel {
+	case "none", "rgb", "bgr":
+	default:
+		return fmt.Errorf("Invalid subpixel order '%s': should be none, rgb or bgr", conf.Subpixel)
+	}
+	if conf.Gamma <= 0 {
+		return fmt.Errorf("Invalid font gamma %v: should be greater than 0", conf.Gamma)
+	}
+	if conf.Contrast <= 0 {
+		return fmt.Errorf("Invalid font contrast %v: should be greater than 0", conf.Contrast)
+	}
+	for _, face := range []FontFaceConfig{conf.Regular, conf.Bold} {
+		if face.Weight < 0 || face.Width < 0 {
+			return fmt.Errorf("Invalid font weight or width: should not be negative")
+		}
+		for _, feature := range face.Features {
+			if strings.HasPrefix(feature, "-") {
+				return fmt.Errorf("Invalid OpenType feature '%s': features are only applied when listed, so there are none to turn off", feature)
+			}
+			if tag := strings.TrimPrefix(feature, "+"); len(tag) != 4 {
+				return fmt.Errorf("Invalid OpenType feature '%s': tags are four characters long", feature)
+			}
+		}
+	}
+	return nil
+}
+
+// EnabledFeatures returns the tags of the features which should be applied to the face
+func (face FontFaceConfig) EnabledFeatures() []string {
+	enabled := []string{}
+	for _, feature := range face.Features {
+		enabled = append(enabled, strings.TrimPrefix(feature, "+"))
+	}
+	return enabled
+}
+
+// Variations returns the variable font axis values to instantiate the face at
+func (face FontFaceConfig) Variations() map[string]float64 {
+	variations := map[string]float64{}
+	if face.Weight > 0 {
+		variations["wght"] = float64(face.Weight)
+	}
+	if face.Width > 0 {
+		variations["wdth"] = float64(face.Width)
+	}
+	return variations
+}
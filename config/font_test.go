@@ -0,0 +1,70 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFontFeatures(t *testing.T) {
+	conf, err := Parse([]byte(`
+[font.regular]
+  features = ["zero", "+ss01"]
+`))
+	require.Nil(t, err)
+
+	assert.Equal(t, []string{"zero", "ss01"}, conf.Font.Regular.EnabledFeatures())
+	assert.Equal(t, []string{}, conf.Font.Bold.EnabledFeatures())
+
+	_, err = Parse([]byte(`
+[font.bold]
+  features = ["ligatures"]
+`))
+	assert.NotNil(t, err)
+
+	_, err = Parse([]byte(`
+[font.bold]
+  features = ["-liga"]
+`))
+	assert.NotNil(t, err)
+}
+
+func TestFontRenderingValidation(t *testing.T) {
+	conf, err := Parse([]byte(`
+[font]
+  hinting = "slight"
+  subpixel = "bgr"
+`))
+	require.Nil(t, err)
+	assert.Equal(t, "slight", conf.Font.Hinting)
+	assert.Equal(t, 1.0, conf.Font.Gamma)
+
+	_, err = Parse([]byte(`
+[font]
+  hinting = "medium"
+`))
+	assert.NotNil(t, err)
+
+	_, err = Parse([]byte(`
+[font]
+  gamma = 0.0
+`))
+	assert.NotNil(t, err)
+}
+
+func TestFontVariations(t *testing.T) {
+	conf, err := Parse([]byte(`
+[font.regular]
+  file = "/usr/share/fonts/Example[wght].ttf"
+  weight = 450
+
+[font.bold]
+  weight = 700
+  width = 87.5
+`))
+	require.Nil(t, err)
+
+	assert.Equal(t, map[string]float64{"wght": 450}, conf.Font.Regular.Variations())
+	assert.Equal(t, map[string]float64{"wght": 700, "wdth": 87.5}, conf.Font.Bold.Variations())
+}
@@ -0,0 +1,31 @@
+package config
+
+import "fmt"
+
+// What clicking a git commit hash or a file in a diff header does
+const (
+	GitActionShow = "show" // run git show on a commit, in a new pane
+	GitActionOpen = "open" // open a file in the editor
+	GitActionCopy = "copy" // copy the hash or path to the clipboard
+	GitActionNone = "none" // treat it as plain text
+)
+
+// GitConfig is what happens when git commit hashes and files in diff headers are clicked
+type GitConfig struct {
+	Commit   string `toml:"commit"`    // show, copy or none
+	DiffFile string `toml:"diff_file"` // open, copy or none, for the a/file and b/file of diff headers
+}
+
+func (conf *GitConfig) validate() error {
+	switch conf.Commit {
+	case GitActionShow, GitActionCopy, GitActionNone:
+	default:
+		return fmt.Errorf("Invalid git commit action '%s': should be %s, %s or %s", conf.Commit, GitActionShow, GitActionCopy, GitActionNone)
+	}
+	switch conf.DiffFile {
+	case GitActionOpen, GitActionCopy, GitActionNone:
+	default:
+		return fmt.Errorf("Invalid git diff_file action '%s': should be %s, %s or %s", conf.DiffFile, GitActionOpen, GitActionCopy, GitActionNone)
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitActions(t *testing.T) {
+	conf, err := Parse([]byte("[git]\ncommit = \"copy\"\n"))
+	require.Nil(t, err)
+	assert.Equal(t, GitActionCopy, conf.Git.Commit)
+	assert.Equal(t, GitActionOpen, conf.Git.DiffFile)
+
+	_, err = Parse([]byte("[git]\ncommit = \"open\"\n"))
+	assert.NotNil(t, err)
+	_, err = Parse([]byte("[git]\ndiff_file = \"show\"\n"))
+	assert.NotNil(t, err)
+}
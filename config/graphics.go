@@ -0,0 +1,60 @@
+package config
+
+import "fmt"
+
+// the smallest texture budget, in megabytes, which still fits the glyphs and images of a full screen
+const minTextureBudget = 16
+
+// Colour spaces of displays
+const (
+	ColourSpaceSRGB      = "srgb"
+	ColourSpaceDisplayP3 = "display-p3" // wide gamut displays, e.g. recent Macs
+)
+
+type GraphicsConfig struct {
+	TextureBudget    int    `toml:"texture_budget"`     // megabytes of GPU memory for glyph and image textures
+	ColourFilter     string `toml:"colour_filter"`      // a colour vision deficiency to filter colours for, or empty for none
+	ColourFilterMode string `toml:"colour_filter_mode"` // simulate or correct
+	LinearBlending   bool   `toml:"linear_blending"`    // blend glyph edges in linear light, where the graphics driver supports it
+	ColourSpace      string `toml:"colour_space"`       // the display's colour space, which colours are converted to
+	PostShader       string `toml:"post_shader"`        // a GLSL fragment shader file each frame is drawn through, e.g. for CRT effects
+	BlinkInterval    int    `toml:"blink_interval"`     // milliseconds blinking text is shown, then hidden, for. 0 draws it dim instead
+	WatchdogTimeout  int    `toml:"watchdog_timeout"`   // seconds the render loop can be stuck for before what it is doing is logged, 0 for never
+}
+
+func (conf *GraphicsConfig) validate() error {
+	if conf.TextureBudget < minTextureBudget {
+		return fmt.Errorf("Invalid texture budget %dMB: should be at least %dMB", conf.TextureBudget, minTextureBudget)
+	}
+	if conf.BlinkInterval < 0 {
+		return fmt.Errorf("Invalid blink interval %d: should not be negative", conf.BlinkInterval)
+	}
+	if conf.WatchdogTimeout < 0 {
+		return fmt.Errorf("Invalid watchdog timeout %d: should not be negative", conf.WatchdogTimeout)
+	}
+	switch conf.ColourSpace {
+	case ColourSpaceSRGB, ColourSpaceDisplayP3:
+	default:
+		return fmt.Errorf("Invalid colour space '%s': should be %s or %s", conf.ColourSpace, ColourSpaceSRGB, ColourSpaceDisplayP3)
+	}
+	if conf.ColourFilter != ColourFilterNone {
+		if _, err := NewColourFilter(conf.ColourFilter, conf.ColourFilterMode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Filter returns the colour filter to render with, or nil if there isn't one
+func (conf *GraphicsConfig) Filter() *ColourFilter {
+	if conf.ColourFilter == ColourFilterNone {
+		return nil
+	}
+	filter, _ := NewColourFilter(conf.ColourFilter, conf.ColourFilterMode)
+	return filter
+}
+
+// TextureBudgetBytes returns the texture budget in bytes
+func (conf *GraphicsConfig) TextureBudgetBytes() int {
+	return conf.TextureBudget * 1024 * 1024
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTextureBudget(t *testing.T) {
+	conf, err := Parse([]byte(`
+[graphics]
+  texture_budget = 64
+`))
+	assert.Nil(t, err)
+	assert.Equal(t, 64*1024*1024, conf.Graphics.TextureBudgetBytes())
+
+	_, err = Parse([]byte(`
+[graphics]
+  texture_budget = 1
+`))
+	assert.NotNil(t, err)
+}
+
+func TestColourFilterConfig(t *testing.T) {
+	conf, err := Parse([]byte(`
+[graphics]
+  colour_filter = "deuteranopia"
+`))
+	assert.Nil(t, err)
+	assert.NotNil(t, conf.Graphics.Filter())
+	assert.Nil(t, DefaultConfig.Graphics.Filter())
+
+	_, err = Parse([]byte(`
+[graphics]
+  colour_filter = "red"
+`))
+	assert.NotNil(t, err)
+
+	_, err = Parse([]byte(`
+[graphics]
+  colour_filter = "tritanopia"
+  colour_filter_mode = "fix"
+`))
+	assert.NotNil(t, err)
+}
+
+func TestColourSpace(t *testing.T) {
+	conf, err := Parse([]byte(`
+[graphics]
+  colour_space = "display-p3"
+`))
+	assert.Nil(t, err)
+	assert.Equal(t, ColourSpaceDisplayP3, conf.Graphics.ColourSpace)
+	assert.Equal(t, ColourSpaceSRGB, DefaultConfig.Graphics.ColourSpace)
+
+	_, err = Parse([]byte(`
+[graphics]
+  colour_space = "adobe-rgb"
+`))
+	assert.NotNil(t, err)
+}
+
+func TestBlinkInterval(t *testing.T) {
+	conf, err := Parse([]byte(`
+[graphics]
+  blink_interval = 0
+`))
+	assert.Nil(t, err)
+	assert.Equal(t, 0, conf.Graphics.BlinkInterval)
+	assert.Equal(t, 500, DefaultConfig.Graphics.BlinkInterval)
+
+	_, err = Parse([]byte(`
+[graphics]
+  blink_interval = -1
+`))
+	assert.NotNil(t, err)
+}
+
+func TestWatchdogTimeout(t *testing.T) {
+	conf, err := Parse([]byte(`
+[graphics]
+  watchdog_timeout = 0
+`))
+	assert.Nil(t, err)
+	assert.Equal(t, 0, conf.Graphics.WatchdogTimeout)
+	assert.Equal(t, 10, DefaultConfig.Graphics.WatchdogTimeout)
+
+	_, err = Parse([]byte(`
+[graphics]
+  watchdog_timeout = -1
+`))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,20 @@
+package config
+
+import "fmt"
+
+// oscUserVar is iTerm2's OSC, whose SetUserVar command always runs the hook command
+const oscUserVar = 1337
+
+// HooksConfig is a command which programs running in the terminal can trigger with escape sequences, so shell
+// scripts can make the terminal do custom things
+type HooksConfig struct {
+	Command string `toml:"command"` // run with sh -c, with the event in $AMINAL_EVENT, $AMINAL_NAME and $AMINAL_VALUE
+	OSC     int    `toml:"osc"`     // a custom OSC number which also runs the command with its payload, 0 for none
+}
+
+func (conf *HooksConfig) validate() error {
+	if conf.OSC < 0 || conf.OSC == oscUserVar {
+		return fmt.Errorf("Invalid hook OSC %d: should be 0 for none, or an OSC number other than %d", conf.OSC, oscUserVar)
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHookOSC(t *testing.T) {
+	conf, err := Parse([]byte(`
+[hooks]
+  command = "notify-send \"$AMINAL_VALUE\""
+  osc     = 7777
+`))
+	assert.Nil(t, err)
+	assert.Equal(t, 7777, conf.Hooks.OSC)
+
+	_, err = Parse([]byte(`
+[hooks]
+  osc = 1337
+`))
+	assert.NotNil(t, err)
+
+	_, err = Parse([]byte(`
+[hooks]
+  osc = -1
+`))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"strings"
+)
+
+// HostProfile changes the look of the terminal while the shell reports (via OSC 7) that it is running on a matching host
+type HostProfile struct {
+	Host       string            `toml:"host"`        // glob pattern matched against the reported hostname, e.g. "prod-*"
+	Accent     *Colour           `toml:"accent"`      // colour of a border drawn around the window
+	Colours    map[string]Colour `toml:"colours"`     // overrides for colours in the colour scheme, keyed as in [colours]
+	PostShader string            `toml:"post_shader"` // used instead of the post-processing shader in [graphics]
+}
+
+// HostProfile returns the first profile matching the given host, or nil if there is none
+func (c *Config) HostProfile(host string) *HostProfile {
+	if host == "" {
+		return nil
+	}
+	for i := range c.HostProfiles {
+		if matched, _ := path.Match(strings.ToLower(c.HostProfiles[i].Host), strings.ToLower(host)); matched {
+			return &c.HostProfiles[i]
+		}
+	}
+	return nil
+}
+
+func (profile *HostProfile) validate() error {
+	if profile.Host == "" {
+		return fmt.Errorf("Host profile has no host pattern")
+	}
+	if _, err := path.Match(profile.Host, ""); err != nil {
+		return fmt.Errorf("Invalid host pattern '%s': %s", profile.Host, err)
+	}
+	_, err := ColourScheme{}.WithOverrides(profile.Colours)
+	return err
+}
+
+// WithOverrides returns a copy of the scheme with the named colours replaced, using the same names as the config file
+func (scheme ColourScheme) WithOverrides(overrides map[string]Colour) (ColourScheme, error) {
+	v := reflect.ValueOf(&scheme).Elem()
+	for name, colour := range overrides {
+		found := false
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).Tag.Get("toml") == name {
+				v.Field(i).Set(reflect.ValueOf(colour))
+				found = true
+				break
+			}
+		}
+		if !found {
+			return scheme, fmt.Errorf("Unknown colour '%s'", name)
+		}
+	}
+	return scheme, nil
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHostProfileMatching(t *testing.T) {
+	conf, err := Parse([]byte(`
+[[host_profiles]]
+  host = "prod-*"
+  accent = "#ff0000"
+
+[[host_profiles]]
+  host = "*"
+  [host_profiles.colours]
+    background = "#000000"
+`))
+	require.Nil(t, err)
+
+	profile := conf.HostProfile("PROD-db1")
+	require.NotNil(t, profile)
+	require.NotNil(t, profile.Accent)
+	assert.Equal(t, Colour{1, 0, 0}, *profile.Accent)
+
+	profile = conf.HostProfile("dev-box")
+	require.NotNil(t, profile)
+	assert.Nil(t, profile.Accent)
+
+	assert.Nil(t, conf.HostProfile(""))
+}
+
+func TestHostProfileWithUnknownColourIsRejected(t *testing.T) {
+	_, err := Parse([]byte(`
+[[host_profiles]]
+  host = "prod-*"
+  [host_profiles.colours]
+    mauve = "#000000"
+`))
+	assert.NotNil(t, err)
+}
+
+func TestColourSchemeOverrides(t *testing.T) {
+	scheme, err := DefaultConfig.ColourScheme.WithOverrides(map[string]Colour{
+		"background": {1, 0, 0},
+	})
+	require.Nil(t, err)
+	assert.Equal(t, Colour{1, 0, 0}, scheme.Background)
+	assert.Equal(t, DefaultConfig.ColourScheme.Foreground, scheme.Foreground)
+}
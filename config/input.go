@@ -0,0 +1,125 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+)
+
+// the encodings for keys which don't type characters, such as arrows and function keys
+const (
+	EncodingXterm = "xterm"
+	EncodingURxvt = "urxvt"
+	EncodingKitty = "kitty" // the disambiguating level of kitty's keyboard protocol, with CSI u sequences
+)
+
+type InputConfig struct {
+	Encoding       string            `toml:"encoding"`         // one of the Encoding* values
+	Overrides      map[string]string `toml:"overrides"`        // what to send for particular keys, e.g. "backspace" = "\b"
+	AltSendsEscape bool              `toml:"alt_sends_escape"` // alt works as meta, sending ESC before the key
+
+	keys  map[keyPress]string
+	chars []charOverride
+}
+
+type keyPress struct {
+	key  glfw.Key
+	mods glfw.ModifierKey
+}
+
+type charOverride struct {
+	combi *KeyCombination
+	send  string
+}
+
+// keyNames are the names keys can be given in overrides
+var keyNames = map[string]glfw.Key{
+	"backspace": glfw.KeyBackspace,
+	"tab":       glfw.KeyTab,
+	"enter":     glfw.KeyEnter,
+	"escape":    glfw.KeyEscape,
+	"insert":    glfw.KeyInsert,
+	"delete":    glfw.KeyDelete,
+	"home":      glfw.KeyHome,
+	"end":       glfw.KeyEnd,
+	"page_up":   glfw.KeyPageUp,
+	"page_down": glfw.KeyPageDown,
+	"up":        glfw.KeyUp,
+	"down":      glfw.KeyDown,
+	"left":      glfw.KeyLeft,
+	"right":     glfw.KeyRight,
+	"kp_enter":  glfw.KeyKPEnter,
+}
+
+func init() {
+	for i := 0; i <= int(glfw.KeyF25-glfw.KeyF1); i++ {
+		keyNames[fmt.Sprintf("f%d", i+1)] = glfw.KeyF1 + glfw.Key(i)
+	}
+}
+
+func (conf *InputConfig) validate() error {
+	switch conf.Encoding {
+	case EncodingXterm, EncodingURxvt, EncodingKitty:
+	default:
+		return fmt.Errorf("Invalid key encoding '%s': should be %s, %s or %s", conf.Encoding, EncodingXterm, EncodingURxvt, EncodingKitty)
+	}
+
+	conf.keys = map[keyPress]string{}
+	conf.chars = nil
+	for name, send := range conf.Overrides {
+		press, named, err := parseKeyPress(name)
+		if err != nil {
+			return fmt.Errorf("Invalid key override '%s': %s", name, err)
+		}
+		if named {
+			conf.keys[press] = send
+			continue
+		}
+		// keys which type characters can only be overridden in combination with ctrl, which stops them typing anything
+		combi, err := parseKeyCombination(name)
+		if err != nil || combi.mods&glfw.ModControl == 0 {
+			return fmt.Errorf("Invalid key override '%s': keys which type characters need ctrl", name)
+		}
+		conf.chars = append(conf.chars, charOverride{combi: combi, send: send})
+	}
+	return nil
+}
+
+// parseKeyPress parses a named key with modifiers e.g. "shift + f1", returning false if the key isn't a named one
+func parseKeyPress(str string) (keyPress, bool, error) {
+	var press keyPress
+	named := false
+	for _, part := range strings.Split(str, "+") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if mod, ok := modMap[KeyMod(part)]; ok {
+			press.mods |= mod
+			continue
+		}
+		if key, ok := keyNames[part]; ok && !named {
+			press.key = key
+			named = true
+			continue
+		}
+		if len(part) != 1 {
+			return press, false, fmt.Errorf("Unknown key '%s'", part)
+		}
+	}
+	return press, named, nil
+}
+
+// Override returns what to send for a key press if it has been overridden. The name is the character the key types,
+// for layout independent matching of keys like ctrl + h.
+func (conf *InputConfig) Override(key glfw.Key, name string, mods glfw.ModifierKey) (string, bool) {
+	if send, ok := conf.keys[keyPress{key: key, mods: mods}]; ok {
+		return send, true
+	}
+	if len(name) == 1 {
+		for _, override := range conf.chars {
+			if override.combi.Match(mods, rune(name[0])) {
+				return override.send, true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyOverrides(t *testing.T) {
+	conf, err := Parse([]byte(`
+[input]
+  encoding = "urxvt"
+  [input.overrides]
+    backspace = "\b"
+    "shift + f13" = "\u001b[99~"
+    "ctrl + h" = "\u007f"
+`))
+	require.Nil(t, err)
+	assert.Equal(t, EncodingURxvt, conf.Input.Encoding)
+
+	send, ok := conf.Input.Override(glfw.KeyBackspace, "", 0)
+	assert.True(t, ok)
+	assert.Equal(t, "\b", send)
+
+	_, ok = conf.Input.Override(glfw.KeyBackspace, "", glfw.ModControl)
+	assert.False(t, ok)
+
+	send, ok = conf.Input.Override(glfw.KeyF13, "", glfw.ModShift)
+	assert.True(t, ok)
+	assert.Equal(t, "\x1b[99~", send)
+
+	send, ok = conf.Input.Override(glfw.KeyH, "h", glfw.ModControl)
+	assert.True(t, ok)
+	assert.Equal(t, "\x7f", send)
+}
+
+func TestInvalidKeyOverrides(t *testing.T) {
+	for _, conf := range []string{
+		`encoding = "vt52"`,
+		`overrides = { "hyperspace" = "x" }`,
+		`overrides = { "a" = "b" }`,
+		`overrides = { "shift + a" = "b" }`,
+	} {
+		_, err := Parse([]byte("[input]\n" + conf))
+		assert.NotNil(t, err, conf)
+	}
+}
@@ -9,7 +9,8 @@ import (
 
 type KeyCombination struct {
 	mods glfw.ModifierKey
-	char rune
+	char rune     // the character the key types, ignoring modifiers
+	key  glfw.Key // or for keys which don't type a character, one of keyNames
 }
 
 type KeyMod string
@@ -28,29 +29,40 @@ var modMap = map[KeyMod]glfw.ModifierKey{
 	super: glfw.ModSuper,
 }
 
-// keyStr e.g. "ctrl + alt + a"
+// keyStr e.g. "ctrl + alt + a" or "shift + page_up"
 func parseKeyCombination(keyStr string) (*KeyCombination, error) {
 
 	var mods glfw.ModifierKey
-	var key rune
+	var char rune
+	var named glfw.Key
 
 	keys := strings.Split(keyStr, "+")
 	for _, k := range keys {
 		k = strings.ToLower(strings.TrimSpace(k))
 		mod, ok := modMap[KeyMod(k)]
 		if ok {
-			mods = mods + mod
+			mods = mods | mod
 			continue
 		}
 
-		if key > 0 {
+		if char > 0 || named != 0 {
 			return nil, fmt.Errorf("Multiple non-modifier keys specified in keyboard shortcut")
 		}
 
-		key = rune(k[0])
+		if key, ok := keyNames[k]; ok {
+			named = key
+			continue
+		}
+		if runes := []rune(k); len(runes) == 1 {
+			char = runes[0]
+			continue
+		}
+		if k != "" {
+			return nil, fmt.Errorf("Unknown key '%s' in keyboard shortcut", k)
+		}
 	}
 
-	if key == 0 {
+	if char == 0 && named == 0 {
 		return nil, fmt.Errorf("No non-modifier key specified in keyboard shortcut")
 	}
 
@@ -60,17 +72,90 @@ func parseKeyCombination(keyStr string) (*KeyCombination, error) {
 
 	return &KeyCombination{
 		mods: mods,
-		char: key,
+		char: char,
+		key:  named,
 	}, nil
 }
 
+// unbindShadowedDefaults unbinds default shortcuts which clash with one set in the config file for another action,
+// e.g. when a config file written by an older version already uses the default of a newer action
+func (keyMapConfig KeyMappingConfig) unbindShadowedDefaults(set func(action string) bool) {
+	for action, keyStr := range keyMapConfig {
+		if !set(action) || strings.TrimSpace(keyStr) == "" {
+			continue
+		}
+		combi, err := parseKeyCombination(keyStr)
+		if err != nil {
+			continue
+		}
+		for other, otherStr := range keyMapConfig {
+			if other == action || set(other) || strings.TrimSpace(otherStr) == "" {
+				continue
+			}
+			if otherCombi, err := parseKeyCombination(otherStr); err == nil && *otherCombi == *combi {
+				keyMapConfig[other] = ""
+			}
+		}
+	}
+}
+
+// Match returns whether a key which types a character, ignoring modifiers, is pressed
 func (combi KeyCombination) Match(pressedMods glfw.ModifierKey, pressedChar rune) bool {
-	return pressedChar == combi.char && pressedMods == combi.mods
+	return combi.key == 0 && pressedChar == combi.char && pressedMods == combi.mods
+}
+
+// MatchKey returns whether a key which doesn't type a character, such as page up or f1, is pressed
+func (combi KeyCombination) MatchKey(pressedMods glfw.ModifierKey, pressedKey glfw.Key) bool {
+	return combi.key != 0 && pressedKey == combi.key && pressedMods == combi.mods
+}
+
+// String returns the combination in the same form it is configured in e.g. "ctrl + shift + c"
+func (combi KeyCombination) String() string {
+	parts := []string{}
+	for _, mod := range []KeyMod{ctrl, alt, shift, super} {
+		if combi.mods&modMap[mod] != 0 {
+			parts = append(parts, string(mod))
+		}
+	}
+	key := string(combi.char)
+	if combi.key != 0 {
+		key = keyName(combi.key)
+	}
+	return strings.Join(append(parts, key), " + ")
+}
+
+// keyName returns the name a key which doesn't type a character is configured by, see keyNames
+func keyName(key glfw.Key) string {
+	for name, k := range keyNames {
+		if k == key {
+			return name
+		}
+	}
+	return ""
+}
+
+// validate checks that every action is one there is, and that each is bound to a shortcut which can be pressed
+func (keyMapConfig KeyMappingConfig) validate() error {
+	for action, keyStr := range keyMapConfig {
+		if _, ok := actionDescriptions[UserAction(action)]; !ok {
+			return fmt.Errorf("Unknown action '%s' in [keys]: should be one of %s", action, strings.Join(actionNames(), ", "))
+		}
+		if strings.TrimSpace(keyStr) == "" {
+			continue
+		}
+		if _, err := parseKeyCombination(keyStr); err != nil {
+			return fmt.Errorf("Invalid shortcut '%s' for %s: %s", keyStr, action, err)
+		}
+	}
+	return nil
 }
 
 func (keyMapConfig KeyMappingConfig) GenerateActionMap() (map[UserAction]*KeyCombination, error) {
 	m := map[UserAction]*KeyCombination{}
 	for actionStr, keyStr := range keyMapConfig {
+		if strings.TrimSpace(keyStr) == "" { // unbound
+			continue
+		}
 		combi, err := parseKeyCombination(keyStr)
 		if err != nil {
 			return nil, err
@@ -25,3 +25,77 @@ func TestKeyCombinations(t *testing.T) {
 	assert.False(t, combi.Match(glfw.ModControl^glfw.ModAlt^glfw.ModShift, 'f'))
 
 }
+
+func TestKeyCombinationString(t *testing.T) {
+
+	combi, err := parseKeyCombination("Shift+ctrl + /")
+	require.Nil(t, err)
+
+	assert.Equal(t, "ctrl + shift + /", combi.String())
+
+}
+
+func TestShortcutsInConfigFileWinOverDefaults(t *testing.T) {
+
+	conf, err := Parse([]byte(`
+[keys]
+  pipe_selection = "` + addMod("p") + `"
+`))
+	require.Nil(t, err)
+
+	assert.Equal(t, addMod("p"), conf.KeyMapping[string(ActionPipeSelection)])
+	assert.Equal(t, "", conf.KeyMapping[string(ActionCommandPalette)])
+	assert.Equal(t, addMod("c"), conf.KeyMapping[string(ActionCopy)])
+
+	// the defaults themselves are untouched
+	assert.Equal(t, addMod("p"), DefaultConfig.KeyMapping[string(ActionCommandPalette)])
+	assert.Equal(t, "", DefaultConfig.KeyMapping[string(ActionPipeSelection)])
+}
+
+func TestNamedKeyCombinations(t *testing.T) {
+
+	combi, err := parseKeyCombination("shift + page_up")
+	require.Nil(t, err)
+
+	assert.True(t, combi.MatchKey(glfw.ModShift, glfw.KeyPageUp))
+	assert.False(t, combi.MatchKey(glfw.ModShift, glfw.KeyPageDown))
+	assert.False(t, combi.MatchKey(0, glfw.KeyPageUp))
+	assert.False(t, combi.Match(glfw.ModShift, 'p'))
+	assert.Equal(t, "shift + page_up", combi.String())
+
+	combi, err = parseKeyCombination("ctrl + f5")
+	require.Nil(t, err)
+	assert.True(t, combi.MatchKey(glfw.ModControl, glfw.KeyF5))
+
+	combi, err = parseKeyCombination("ctrl + shift + alt + right")
+	require.Nil(t, err)
+	assert.True(t, combi.MatchKey(glfw.ModControl|glfw.ModShift|glfw.ModAlt, glfw.KeyRight))
+	assert.False(t, combi.MatchKey(glfw.ModControl|glfw.ModShift, glfw.KeyRight))
+
+	// single characters never match as named keys
+	combi, err = parseKeyCombination("ctrl + c")
+	require.Nil(t, err)
+	assert.False(t, combi.MatchKey(glfw.ModControl, glfw.KeyC))
+
+	_, err = parseKeyCombination("ctrl + pageup")
+	assert.NotNil(t, err)
+	_, err = parseKeyCombination("ctrl + ")
+	assert.NotNil(t, err)
+}
+
+func TestUnknownActionIsAnError(t *testing.T) {
+
+	_, err := Parse([]byte(`
+[keys]
+  google = "ctrl + shift + g"
+`))
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "google")
+	assert.Contains(t, err.Error(), "search")
+
+	_, err = Parse([]byte(`
+[keys]
+  search = "ctrl + shift + bogus"
+`))
+	assert.NotNil(t, err)
+}
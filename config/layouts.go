@@ -0,0 +1,76 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// LayoutsDir returns where named layouts are saved, beside the config file. Layouts are session files, so they can be
+// edited by hand too.
+func LayoutsDir() (string, error) {
+	if xdgHome := os.Getenv("XDG_CONFIG_HOME"); xdgHome != "" {
+		return filepath.Join(xdgHome, "aminal", "layouts"), nil
+	}
+	home := os.Getenv("HOME")
+	if home == "" {
+		return "", fmt.Errorf("Cannot find the layouts directory: HOME is not set")
+	}
+	return filepath.Join(home, ".config", "aminal", "layouts"), nil
+}
+
+// LayoutPath returns the file a named layout is saved in
+func LayoutPath(name string) (string, error) {
+	if name == "" || strings.IndexFunc(name, func(r rune) bool { return !IsAbbreviationRune(r) }) > -1 || strings.HasPrefix(name, ".") {
+		return "", fmt.Errorf("Invalid layout name '%s': should be letters, digits, '-', '_' and '.'", name)
+	}
+	dir, err := LayoutsDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name+".yml"), nil
+}
+
+// SaveLayout saves a layout under a name, replacing any layout already saved under it
+func SaveLayout(name string, session *Session) error {
+	path, err := LayoutPath(name)
+	if err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("Failed to encode layout: %s", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("Failed to create layouts directory: %s", err)
+	}
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("Failed to save layout: %s", err)
+	}
+	return nil
+}
+
+// LayoutNames returns the names of the saved layouts, in alphabetical order
+func LayoutNames() []string {
+	names := []string{}
+	dir, err := LayoutsDir()
+	if err != nil {
+		return names
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return names
+	}
+	for _, file := range files {
+		if name := strings.TrimSuffix(file.Name(), ".yml"); name != file.Name() && !file.IsDir() {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
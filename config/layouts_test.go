@@ -0,0 +1,43 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveLayout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aminal-layouts")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	xdgHome := os.Getenv("XDG_CONFIG_HOME")
+	defer os.Setenv("XDG_CONFIG_HOME", xdgHome)
+	os.Setenv("XDG_CONFIG_HOME", dir)
+
+	assert.Equal(t, []string{}, LayoutNames())
+
+	saved := &Session{PaneLayout: PaneLayout{
+		Split: SplitDown,
+		Size:  0.25,
+		Panes: []PaneLayout{
+			{Directory: "/src/api", Command: "make run"},
+			{Directory: "/src/web"},
+		},
+	}}
+	require.Nil(t, SaveLayout("web-dev", saved))
+	require.Nil(t, SaveLayout("api", &Session{PaneLayout: PaneLayout{Directory: "/src/api"}}))
+	assert.Equal(t, []string{"api", "web-dev"}, LayoutNames())
+
+	path, err := LayoutPath("web-dev")
+	require.Nil(t, err)
+	loaded, err := LoadSession(path)
+	require.Nil(t, err)
+	assert.Equal(t, saved, loaded)
+
+	for _, name := range []string{"", "../config", "a/b", ".hidden"} {
+		assert.NotNil(t, SaveLayout(name, saved), name)
+	}
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+)
+
+// macroPlaceholder is a part of a macro or snippet the user is asked to fill in before it is sent, e.g. {?namespace}
+var macroPlaceholder = regexp.MustCompile(`\{\?([^{}]+)\}`)
+
+// MacrosConfig is text typed for the user, either for a shortcut or expanded from an abbreviation. The text is sent as
+// it is, so it can include escape sequences and "\r" to run a command.
+type MacrosConfig struct {
+	Keys     map[string]string `toml:"keys"`     // shortcut = text to send, e.g. "ctrl + alt + g" = "git status\r"
+	Snippets map[string]string `toml:"snippets"` // abbreviation = text it expands to, e.g. "k8s" = "kubectl -n {?namespace} get pods"
+	Prefix   string            `toml:"prefix"`   // typed before an abbreviation, which is held back from the shell until it's expanded
+	Expand   string            `toml:"expand"`   // the key which expands the abbreviation typed after the prefix
+
+	keys   []charOverride
+	expand keyPress
+}
+
+func (conf *MacrosConfig) validate() error {
+	conf.keys = nil
+	for keyStr, send := range conf.Keys {
+		combi, err := parseKeyCombination(keyStr)
+		if err != nil {
+			return fmt.Errorf("Invalid macro shortcut '%s': %s", keyStr, err)
+		}
+		conf.keys = append(conf.keys, charOverride{combi: combi, send: send})
+	}
+
+	if len(conf.Snippets) == 0 {
+		return nil
+	}
+	if conf.Prefix == "" {
+		return fmt.Errorf("Invalid snippet prefix: it can't be empty, or every word typed would be held back")
+	}
+	press, named, err := parseKeyPress(conf.Expand)
+	if err != nil || !named {
+		return fmt.Errorf("Invalid snippet expand key '%s': should be a key which doesn't type a character, e.g. tab", conf.Expand)
+	}
+	conf.expand = press
+	for abbreviation := range conf.Snippets {
+		if abbreviation == "" || strings.IndexFunc(abbreviation, func(r rune) bool { return !IsAbbreviationRune(r) }) > -1 {
+			return fmt.Errorf("Invalid snippet abbreviation '%s': should be letters, digits, '-', '_' and '.'", abbreviation)
+		}
+	}
+	return nil
+}
+
+// Macro returns the text to send for a shortcut, if one has been set. The name is the character the key types.
+func (conf *MacrosConfig) Macro(key glfw.Key, name string, mods glfw.ModifierKey) (string, bool) {
+	for _, macro := range conf.keys {
+		if macro.combi.MatchKey(mods, key) || (len(name) == 1 && macro.combi.Match(mods, rune(name[0]))) {
+			return macro.send, true
+		}
+	}
+	return "", false
+}
+
+// Snippet returns the text an abbreviation expands to
+func (conf *MacrosConfig) Snippet(abbreviation string) (string, bool) {
+	text, ok := conf.Snippets[abbreviation]
+	return text, ok
+}
+
+// HasSnippets returns true if there are abbreviations to watch out for as the user types
+func (conf *MacrosConfig) HasSnippets() bool {
+	return len(conf.Snippets) > 0
+}
+
+// IsExpandKey returns true for the key which expands abbreviations
+func (conf *MacrosConfig) IsExpandKey(key glfw.Key, mods glfw.ModifierKey) bool {
+	return conf.expand == keyPress{key: key, mods: mods}
+}
+
+// IsAbbreviationRune returns true for the characters abbreviations can be made of
+func IsAbbreviationRune(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.'
+}
+
+// MacroPlaceholders returns the names of the placeholders in a macro's text, in the order they first appear
+func MacroPlaceholders(text string) []string {
+	names := []string{}
+	seen := map[string]bool{}
+	for _, match := range macroPlaceholder.FindAllStringSubmatch(text, -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			names = append(names, match[1])
+		}
+	}
+	return names
+}
+
+// FillMacro replaces the placeholders in a macro's text with the values given for them
+func FillMacro(text string, values map[string]string) string {
+	return macroPlaceholder.ReplaceAllStringFunc(text, func(placeholder string) string {
+		return values[macroPlaceholder.FindStringSubmatch(placeholder)[1]]
+	})
+}
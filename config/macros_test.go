@@ -0,0 +1,68 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMacros(t *testing.T) {
+	conf, err := Parse([]byte(`
+[macros]
+  expand = "shift + tab"
+  [macros.keys]
+    "ctrl + alt + g" = "git status\r"
+    "ctrl + f5" = "\u001b[A\r"
+  [macros.snippets]
+    k8s = "kubectl -n {?namespace} get pods"
+`))
+	require.Nil(t, err)
+
+	send, ok := conf.Macros.Macro(glfw.KeyG, "g", glfw.ModControl|glfw.ModAlt)
+	assert.True(t, ok)
+	assert.Equal(t, "git status\r", send)
+
+	send, ok = conf.Macros.Macro(glfw.KeyF5, "", glfw.ModControl)
+	assert.True(t, ok)
+	assert.Equal(t, "\x1b[A\r", send)
+
+	_, ok = conf.Macros.Macro(glfw.KeyG, "g", glfw.ModControl)
+	assert.False(t, ok)
+
+	assert.True(t, conf.Macros.HasSnippets())
+	assert.Equal(t, ";;", conf.Macros.Prefix)
+	assert.True(t, conf.Macros.IsExpandKey(glfw.KeyTab, glfw.ModShift))
+	assert.False(t, conf.Macros.IsExpandKey(glfw.KeyTab, 0))
+
+	text, ok := conf.Macros.Snippet("k8s")
+	assert.True(t, ok)
+	assert.Equal(t, "kubectl -n {?namespace} get pods", text)
+	_, ok = conf.Macros.Snippet("k9s")
+	assert.False(t, ok)
+}
+
+func TestInvalidMacros(t *testing.T) {
+	for _, conf := range []string{
+		`keys = { "g" = "git" }`,
+		`keys = { "ctrl + hyperspace" = "x" }`,
+		"prefix = \"\"\nsnippets = { k8s = \"kubectl\" }",
+		"expand = \"a\"\nsnippets = { k8s = \"kubectl\" }",
+		`snippets = { "k 8s" = "kubectl" }`,
+	} {
+		_, err := Parse([]byte("[macros]\n" + conf))
+		assert.NotNil(t, err, conf)
+	}
+}
+
+func TestMacroPlaceholders(t *testing.T) {
+	text := "kubectl -n {?namespace} logs {?pod} | awk '{print $1}' # {?namespace}"
+
+	assert.Equal(t, []string{"namespace", "pod"}, MacroPlaceholders(text))
+	assert.Equal(t,
+		"kubectl -n prod logs web-1 | awk '{print $1}' # prod",
+		FillMacro(text, map[string]string{"namespace": "prod", "pod": "web-1"}),
+	)
+	assert.Empty(t, MacroPlaceholders("git status\r"))
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+)
+
+type MouseConfig struct {
+	ContextMenu    bool   `toml:"context_menu"`    // show a menu on right-click
+	BypassModifier string `toml:"bypass_modifier"` // held to open the menu while a program has mouse reporting on
+	ScrollMomentum bool   `toml:"scroll_momentum"` // keep scrolling after a touchpad flick, for platforms which don't
+	CopyOnSelect   bool   `toml:"copy_on_select"`  // copy text to the clipboard as soon as it is selected
+	LinkModifier   string `toml:"link_modifier"`   // held while clicking a link to open it, or empty to open links with a plain click
+	LinkPreview    bool   `toml:"link_preview"`    // show where the link under the mouse goes
+
+	WheelLines          int     `toml:"wheel_lines"`          // lines scrolled per wheel notch
+	AlternateLines      int     `toml:"alternate_lines"`      // arrow keys sent per wheel notch in the alternate screen
+	TouchpadSensitivity float64 `toml:"touchpad_sensitivity"` // multiplies the distance scrolled with a touchpad
+}
+
+func (conf *MouseConfig) validate() error {
+	if conf.WheelLines < 1 || conf.AlternateLines < 1 {
+		return fmt.Errorf("Invalid mouse wheel lines: should be at least 1")
+	}
+	if conf.TouchpadSensitivity <= 0 {
+		return fmt.Errorf("Invalid touchpad sensitivity %v: should be greater than 0", conf.TouchpadSensitivity)
+	}
+	if _, ok := modMap[KeyMod(conf.BypassModifier)]; !ok {
+		return fmt.Errorf("Invalid mouse bypass modifier '%s': should be ctrl, alt, shift or super", conf.BypassModifier)
+	}
+	if _, ok := modMap[KeyMod(conf.LinkModifier)]; !ok && conf.LinkModifier != "" {
+		return fmt.Errorf("Invalid mouse link modifier '%s': should be ctrl, alt, shift, super or empty", conf.LinkModifier)
+	}
+	return nil
+}
+
+// LinkMod returns the modifier key which must be held to open a link with a click, or 0 if none is needed
+func (conf *MouseConfig) LinkMod() glfw.ModifierKey {
+	return modMap[KeyMod(conf.LinkModifier)]
+}
+
+// BypassMod returns the modifier key which sends right-clicks to the context menu rather than the running program
+func (conf *MouseConfig) BypassMod() glfw.ModifierKey {
+	return modMap[KeyMod(conf.BypassModifier)]
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMouseBypassModifier(t *testing.T) {
+	conf, err := Parse([]byte(`
+[mouse]
+  bypass_modifier = "ctrl"
+`))
+	require.Nil(t, err)
+	assert.True(t, conf.Mouse.ContextMenu)
+	assert.Equal(t, glfw.ModControl, conf.Mouse.BypassMod())
+
+	_, err = Parse([]byte(`
+[mouse]
+  bypass_modifier = "hyper"
+`))
+	assert.NotNil(t, err)
+}
+
+func TestMouseScrollSpeeds(t *testing.T) {
+	conf, err := Parse([]byte(`
+[mouse]
+  wheel_lines = 5
+  touchpad_sensitivity = 0.5
+`))
+	require.Nil(t, err)
+	assert.Equal(t, 5, conf.Mouse.WheelLines)
+	assert.Equal(t, 3, conf.Mouse.AlternateLines)
+	assert.Equal(t, 0.5, conf.Mouse.TouchpadSensitivity)
+
+	_, err = Parse([]byte(`
+[mouse]
+  alternate_lines = 0
+`))
+	assert.NotNil(t, err)
+}
+
+func TestMouseLinkModifier(t *testing.T) {
+	conf, err := Parse([]byte(``))
+	require.Nil(t, err)
+	assert.Equal(t, glfw.ModControl, conf.Mouse.LinkMod())
+
+	conf, err = Parse([]byte(`
+[mouse]
+  link_modifier = ""
+`))
+	require.Nil(t, err)
+	assert.Equal(t, glfw.ModifierKey(0), conf.Mouse.LinkMod())
+
+	_, err = Parse([]byte(`
+[mouse]
+  link_modifier = "hyper"
+`))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,75 @@
+package config
+
+// the indexes, after the 256 colours of the palette, of the default foreground, background and cursor colours, as set
+// by OSC 10, 11 and 12
+const (
+	ColourForeground = 256 + iota
+	ColourBackground
+	ColourCursor
+)
+
+// colourCubeLevels are the intensities used by xterm for each axis of the 256 colour palette's colour cube
+var colourCubeLevels = [6]float32{0x00, 0x5f, 0x87, 0xaf, 0xd7, 0xff}
+
+// PaletteColour returns the scheme's colour for one of the 256 colours of the palette, or for one of the default colours
+// numbered after them. Colours are looked up by what they are used for rather than by value, so a theme's colours are
+// used even where the scheme has the same value for two of them, e.g. the cursor and the foreground.
+func (scheme ColourScheme) PaletteColour(index int) Colour {
+
+	// https://en.wikipedia.org/wiki/ANSI_escape_code#8-bit
+
+	switch index {
+	case 0:
+		return scheme.Black
+	case 1:
+		return scheme.Red
+	case 2:
+		return scheme.Green
+	case 3:
+		return scheme.Yellow
+	case 4:
+		return scheme.Blue
+	case 5:
+		return scheme.Magenta
+	case 6:
+		return scheme.Cyan
+	case 7:
+		return scheme.White
+	case 8:
+		return scheme.DarkGrey
+	case 9:
+		return scheme.LightRed
+	case 10:
+		return scheme.LightGreen
+	case 11:
+		return scheme.LightYellow
+	case 12:
+		return scheme.LightBlue
+	case 13:
+		return scheme.LightMagenta
+	case 14:
+		return scheme.LightCyan
+	case 15:
+		return scheme.White
+	case ColourForeground:
+		return scheme.Foreground
+	case ColourBackground:
+		return scheme.Background
+	case ColourCursor:
+		return scheme.Cursor
+	}
+
+	if index < 232 {
+		// 6x6x6 colour cube
+		index -= 16 // 0-215
+		return Colour{
+			colourCubeLevels[index/36] / 0xff,
+			colourCubeLevels[(index/6)%6] / 0xff,
+			colourCubeLevels[index%6] / 0xff,
+		}
+	}
+
+	// greyscale ramp from 0x08 to 0xee
+	c := float32(8+10*(index-232)) / 0xff
+	return Colour{c, c, c}
+}
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaletteColoursAreLookedUpByRole(t *testing.T) {
+	scheme := DefaultConfig.ColourScheme
+	scheme.Cursor = scheme.Foreground
+	theme, err := scheme.WithOverrides(map[string]Colour{
+		"cursor":     {1, 0, 0},
+		"foreground": {0, 1, 0},
+		"background": {0, 0, 1},
+	})
+	assert.Nil(t, err)
+
+	assert.Equal(t, Colour{1, 0, 0}, theme.PaletteColour(ColourCursor))
+	assert.Equal(t, Colour{0, 1, 0}, theme.PaletteColour(ColourForeground))
+	assert.Equal(t, Colour{0, 0, 1}, theme.PaletteColour(ColourBackground))
+	assert.Equal(t, theme.Red, theme.PaletteColour(1))
+	assert.Equal(t, theme.LightCyan, theme.PaletteColour(14))
+}
+
+func TestPaletteColourCubeAndGreys(t *testing.T) {
+	scheme := DefaultConfig.ColourScheme
+	assert.Equal(t, Colour{0, 0, 0}, scheme.PaletteColour(16))
+	assert.Equal(t, Colour{1, 1, 1}, scheme.PaletteColour(231))
+	assert.Equal(t, Colour{float32(0x87) / 0xff, 0, float32(0xd7) / 0xff}, scheme.PaletteColour(16+2*36+4))
+	assert.Equal(t, Colour{float32(8) / 0xff, float32(8) / 0xff, float32(8) / 0xff}, scheme.PaletteColour(232))
+	assert.Equal(t, Colour{float32(0xee) / 0xff, float32(0xee) / 0xff, float32(0xee) / 0xff}, scheme.PaletteColour(255))
+}
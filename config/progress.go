@@ -0,0 +1,49 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// ProgressConfig is the bar drawn along the edge of a pane while a program reports its progress, with OSC 9;4, or
+// while output shows a percentage, if detection is on
+type ProgressConfig struct {
+	Position string `toml:"position"` // "top", "bottom" or "none"
+	Height   int    `toml:"height"`   // pixels
+	Detect   bool   `toml:"detect"`   // also take progress from percentages in output, e.g. "42%"
+	Pattern  string `toml:"pattern"`  // regular expression matching a percentage in output, which is its first group
+}
+
+const (
+	ProgressTop    = "top"
+	ProgressBottom = "bottom"
+	ProgressNone   = "none"
+)
+
+func (conf *ProgressConfig) validate() error {
+	switch conf.Position {
+	case ProgressTop, ProgressBottom, ProgressNone:
+	default:
+		return fmt.Errorf("Invalid progress position '%s': should be top, bottom or none", conf.Position)
+	}
+	if conf.Height < 1 {
+		return fmt.Errorf("Invalid progress height %d: should be at least 1", conf.Height)
+	}
+	pattern, err := regexp.Compile(conf.Pattern)
+	if err != nil {
+		return fmt.Errorf("Invalid progress pattern '%s': %s", conf.Pattern, err)
+	}
+	if pattern.NumSubexp() < 1 {
+		return fmt.Errorf("Invalid progress pattern '%s': should have a group matching the percentage", conf.Pattern)
+	}
+	return nil
+}
+
+// DetectPattern returns the pattern which percentages in output are detected with, or nil if detection is off
+func (conf *ProgressConfig) DetectPattern() *regexp.Regexp {
+	if !conf.Detect || conf.Position == ProgressNone {
+		return nil
+	}
+	pattern, _ := regexp.Compile(conf.Pattern) // validated when the config is parsed
+	return pattern
+}
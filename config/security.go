@@ -0,0 +1,51 @@
+package config
+
+import "fmt"
+
+// the most base64 a program can send to the clipboard, which decodes to 12MB
+const maxClipboardLimit = 1 << 24
+
+// Policy decides whether a program running in the terminal may do something risky
+type Policy string
+
+const (
+	PolicyAllow Policy = "allow"
+	PolicyDeny  Policy = "deny"
+	PolicyAsk   Policy = "ask" // ask the user each time
+)
+
+type SecurityConfig struct {
+	ClipboardWrite    Policy `toml:"clipboard_write"`    // OSC 52 setting the clipboard
+	ClipboardRead     Policy `toml:"clipboard_read"`     // OSC 52 reading the clipboard
+	ClipboardLimit    int    `toml:"clipboard_limit"`    // the most base64 characters OSC 52 sets or reads the clipboard with
+	TitleReport       Policy `toml:"title_report"`       // CSI 21 t reporting the window title
+	FileURLs          Policy `toml:"file_urls"`          // opening file:// links when clicked
+	WindowOps         Policy `toml:"window_ops"`         // CSI t iconifying, restoring and resizing the window
+	Answerback        Policy `toml:"answerback"`         // replying to ENQ
+	Hooks             Policy `toml:"hooks"`              // running the hook command on OSC 1337 SetUserVar or the custom OSC
+	AnswerbackMessage string `toml:"answerback_message"` // the reply to ENQ, if answerback is allowed
+	SecretInputGuard  bool   `toml:"secret_input_guard"` // while echo is off for a password, show it and refuse hooks and OSC 52
+}
+
+func (conf *SecurityConfig) validate() error {
+	policies := map[string]Policy{
+		"clipboard_write": conf.ClipboardWrite,
+		"clipboard_read":  conf.ClipboardRead,
+		"title_report":    conf.TitleReport,
+		"file_urls":       conf.FileURLs,
+		"window_ops":      conf.WindowOps,
+		"answerback":      conf.Answerback,
+		"hooks":           conf.Hooks,
+	}
+	for name, policy := range policies {
+		switch policy {
+		case PolicyAllow, PolicyDeny, PolicyAsk:
+		default:
+			return fmt.Errorf("Invalid security policy '%s' for %s: should be allow, deny or ask", policy, name)
+		}
+	}
+	if conf.ClipboardLimit < 1 || conf.ClipboardLimit > maxClipboardLimit {
+		return fmt.Errorf("Invalid clipboard_limit %d: should be from 1 to %d", conf.ClipboardLimit, maxClipboardLimit)
+	}
+	return nil
+}
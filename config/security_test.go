@@ -0,0 +1,30 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecurityPolicies(t *testing.T) {
+	conf, err := Parse([]byte(`
+[security]
+  clipboard_read = "allow"
+`))
+	require.Nil(t, err)
+	assert.Equal(t, PolicyAllow, conf.Security.ClipboardRead)
+	assert.Equal(t, PolicyDeny, conf.Security.TitleReport)
+
+	_, err = Parse([]byte(`
+[security]
+  window_ops = "sometimes"
+`))
+	assert.NotNil(t, err)
+
+	_, err = Parse([]byte(`
+[security]
+  clipboard_limit = 0
+`))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,137 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// the ways a pane can be split in two
+const (
+	SplitRight = "right" // side by side
+	SplitDown  = "down"  // one above the other
+)
+
+// Session describes a window to open with its panes laid out, e.g. the shells used when working on a project
+type Session struct {
+	Title      string `yaml:"title,omitempty"` // fixed window title
+	PaneLayout `yaml:",inline"`
+}
+
+// PaneLayout is a pane, or a pane split in two with a layout for each side. The panes in a split start from its
+// directory and environment.
+type PaneLayout struct {
+	Directory string            `yaml:"directory,omitempty"` // where the shell starts, relative to the session file or split
+	Env       map[string]string `yaml:"env,omitempty"`       // extra environment variables for the shell
+	Command   string            `yaml:"command,omitempty"`   // typed into the shell once it starts, so the shell is still there when it finishes
+	Split     string            `yaml:"split,omitempty"`     // "right" or "down" to split the pane, with the first of panes on the left or top
+	Size      float64           `yaml:"size,omitempty"`      // the share of the split taken by the first pane, half if not given
+	Panes     []PaneLayout      `yaml:"panes,omitempty"`     // the two sides of a split
+}
+
+// LoadSession reads a session file, resolving directories relative to where it is and expanding environment variables
+// and ~ in them
+func LoadSession(path string) (*Session, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read session file: %s", err)
+	}
+	session, err := parseSession(data, filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("Invalid session file %s: %s", path, err)
+	}
+	return session, nil
+}
+
+func parseSession(data []byte, dir string) (*Session, error) {
+	session := &Session{}
+	if err := yaml.UnmarshalStrict(data, session); err != nil {
+		return nil, err
+	}
+	if err := session.resolve(dir, nil); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// resolve checks the layout makes sense, and works out where each pane starts and what environment it has from the
+// splits around it
+func (pane *PaneLayout) resolve(dir string, env map[string]string) error {
+	if pane.Directory != "" {
+		pane.Directory = os.ExpandEnv(pane.Directory)
+		if pane.Directory == "~" || strings.HasPrefix(pane.Directory, "~/") {
+			pane.Directory = os.Getenv("HOME") + pane.Directory[1:]
+		}
+		if !filepath.IsAbs(pane.Directory) {
+			pane.Directory = filepath.Join(dir, pane.Directory)
+		}
+		dir = pane.Directory
+	}
+	if len(env) > 0 {
+		inherited := map[string]string{}
+		for name, value := range env {
+			inherited[name] = value
+		}
+		for name, value := range pane.Env {
+			inherited[name] = value
+		}
+		pane.Env = inherited
+	}
+
+	switch pane.Split {
+	case "":
+		if len(pane.Panes) > 0 {
+			return fmt.Errorf("Panes are given without saying how to split them: split should be \"%s\" or \"%s\"", SplitRight, SplitDown)
+		}
+		return nil
+	case SplitRight, SplitDown:
+	default:
+		return fmt.Errorf("Invalid split '%s': should be \"%s\" or \"%s\"", pane.Split, SplitRight, SplitDown)
+	}
+	if len(pane.Panes) != 2 {
+		return fmt.Errorf("A split should have two panes, not %d", len(pane.Panes))
+	}
+	if pane.Command != "" {
+		return fmt.Errorf("A split can't have a command, only the panes in it")
+	}
+	if pane.Size == 0 {
+		pane.Size = 0.5
+	}
+	if pane.Size <= 0 || pane.Size >= 1 {
+		return fmt.Errorf("Invalid size %g: should be the share of the split the first pane takes, between 0 and 1", pane.Size)
+	}
+	for i := range pane.Panes {
+		if pane.Panes[i].Directory == "" {
+			pane.Panes[i].Directory = pane.Directory
+		}
+		if err := pane.Panes[i].resolve(dir, pane.Env); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsSplit returns true if the pane is split in two
+func (pane *PaneLayout) IsSplit() bool {
+	return pane.Split != ""
+}
+
+// First returns the pane in the top left of the layout
+func (pane *PaneLayout) First() *PaneLayout {
+	for pane.IsSplit() {
+		pane = &pane.Panes[0]
+	}
+	return pane
+}
+
+// Environ returns the environment for the pane's shell, which is env with the pane's variables added
+func (pane *PaneLayout) Environ(env []string) []string {
+	for name, value := range pane.Env {
+		env = append(env, fmt.Sprintf("%s=%s", name, os.ExpandEnv(value)))
+	}
+	return env
+}
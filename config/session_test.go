@@ -0,0 +1,70 @@
+package config
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSession(t *testing.T) {
+	home := os.Getenv("HOME")
+	defer os.Setenv("HOME", home)
+	os.Setenv("HOME", "/home/test")
+
+	session, err := parseSession([]byte(`
+title: server
+directory: api
+env:
+  PORT: "8080"
+split: right
+size: 0.6
+panes:
+  - command: make run
+    env:
+      DATA: $HOME/data
+  - split: down
+    panes:
+      - directory: ~/notes
+      - directory: web
+        command: npm start
+`), "/projects/app")
+	require.Nil(t, err)
+
+	assert.Equal(t, "server", session.Title)
+	assert.True(t, session.IsSplit())
+	assert.Equal(t, 0.6, session.Size)
+
+	server := session.First()
+	assert.Equal(t, "/projects/app/api", server.Directory)
+	assert.Equal(t, "make run", server.Command)
+	assert.ElementsMatch(t, []string{"USER=test", "PORT=8080", "DATA=/home/test/data"}, server.Environ([]string{"USER=test"}))
+
+	right := session.Panes[1]
+	assert.Equal(t, 0.5, right.Size)
+	assert.Equal(t, "/home/test/notes", right.Panes[0].Directory)
+	assert.Equal(t, "/projects/app/api/web", right.Panes[1].Directory)
+	assert.Equal(t, []string{"PORT=8080"}, right.Panes[1].Environ(nil))
+}
+
+func TestSinglePaneSession(t *testing.T) {
+	session, err := parseSession([]byte(`command: htop`), "/")
+	require.Nil(t, err)
+	assert.False(t, session.IsSplit())
+	assert.Equal(t, "htop", session.First().Command)
+}
+
+func TestInvalidSessionIsRejected(t *testing.T) {
+	for _, data := range []string{
+		"windows: []",
+		"split: left\npanes: [{}, {}]",
+		"split: right\npanes: [{}]",
+		"panes: [{}, {}]",
+		"split: down\ncommand: make\npanes: [{}, {}]",
+		"split: down\nsize: 1.5\npanes: [{}, {}]",
+	} {
+		_, err := parseSession([]byte(data), "/")
+		assert.NotNil(t, err, data)
+	}
+}
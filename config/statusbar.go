@@ -0,0 +1,49 @@
+package config
+
+import "fmt"
+
+type StatusBarConfig struct {
+	Enabled     bool     `toml:"enabled"`
+	Position    string   `toml:"position"`     // "top" or "bottom"
+	Segments    []string `toml:"segments"`     // any of the StatusSegment* values, in display order
+	ClockFormat string   `toml:"clock_format"` // Go time format, e.g. "15:04"
+}
+
+const (
+	StatusSegmentTitle    = "title"
+	StatusSegmentCwd      = "cwd"
+	StatusSegmentGit      = "git"
+	StatusSegmentClock    = "clock"
+	StatusSegmentScroll   = "scroll"
+	StatusSegmentBell     = "bell"
+	StatusSegmentActivity = "activity"
+	StatusSegmentReadOnly = "read_only"
+	StatusSegmentPaused   = "paused"
+)
+
+func (conf *StatusBarConfig) validate() error {
+	if conf.Position != "top" && conf.Position != "bottom" {
+		return fmt.Errorf("Invalid status bar position '%s': should be top or bottom", conf.Position)
+	}
+	for _, segment := range conf.Segments {
+		switch segment {
+		case StatusSegmentTitle, StatusSegmentCwd, StatusSegmentGit, StatusSegmentClock, StatusSegmentScroll, StatusSegmentBell, StatusSegmentActivity, StatusSegmentReadOnly, StatusSegmentPaused:
+		default:
+			return fmt.Errorf("Unknown status bar segment '%s'", segment)
+		}
+	}
+	return nil
+}
+
+// HasSegment returns true if the status bar is enabled and configured to show the given segment
+func (conf *StatusBarConfig) HasSegment(segment string) bool {
+	if !conf.Enabled {
+		return false
+	}
+	for _, s := range conf.Segments {
+		if s == segment {
+			return true
+		}
+	}
+	return false
+}
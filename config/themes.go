@@ -0,0 +1,59 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ThemeNames returns the names of the themes in the config, in order
+func (c *Config) ThemeNames() []string {
+	names := []string{}
+	for name := range c.Themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ThemeColours returns the colour scheme of a theme, which is [colours] with the theme's colours replacing those it
+// sets. The empty name is [colours] as it is.
+func (c *Config) ThemeColours(name string) (ColourScheme, error) {
+	if name == "" {
+		return c.ColourScheme, nil
+	}
+	colours, ok := c.Themes[name]
+	if !ok {
+		return c.ColourScheme, fmt.Errorf("Unknown theme '%s'", name)
+	}
+	scheme, err := c.ColourScheme.WithOverrides(colours)
+	if err != nil {
+		return scheme, fmt.Errorf("Invalid theme '%s': %s", name, err)
+	}
+	return scheme, nil
+}
+
+// NextTheme returns the theme after another when switching between them: [colours], then the named themes in order
+func (c *Config) NextTheme(current string) string {
+	names := append([]string{""}, c.ThemeNames()...)
+	for i, name := range names {
+		if name == current {
+			return names[(i+1)%len(names)]
+		}
+	}
+	return ""
+}
+
+func (c *Config) validateThemes() error {
+	for _, name := range c.ThemeNames() {
+		if name == "" {
+			return fmt.Errorf("Themes must have a name")
+		}
+		if _, err := c.ThemeColours(name); err != nil {
+			return err
+		}
+	}
+	if _, err := c.ThemeColours(c.Theme); err != nil {
+		return err
+	}
+	return nil
+}
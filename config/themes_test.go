@@ -0,0 +1,49 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestThemes(t *testing.T) {
+	conf, err := Parse([]byte(`
+theme = "light"
+
+[themes.light]
+  background = "#ffffff"
+  foreground = "#000000"
+
+[themes.dusk]
+  red = "#ff0000"
+`))
+	require.Nil(t, err)
+	assert.Equal(t, "light", conf.Theme)
+	assert.Equal(t, []string{"dusk", "light"}, conf.ThemeNames())
+
+	scheme, err := conf.ThemeColours("light")
+	require.Nil(t, err)
+	assert.Equal(t, Colour{1, 1, 1}, scheme.Background)
+	assert.Equal(t, Colour{0, 0, 0}, scheme.Foreground)
+	assert.Equal(t, DefaultConfig.ColourScheme.Red, scheme.Red)
+
+	scheme, err = conf.ThemeColours("")
+	require.Nil(t, err)
+	assert.Equal(t, DefaultConfig.ColourScheme, scheme)
+
+	assert.Equal(t, "dusk", conf.NextTheme(""))
+	assert.Equal(t, "light", conf.NextTheme("dusk"))
+	assert.Equal(t, "", conf.NextTheme("light"))
+}
+
+func TestInvalidThemesAreRejected(t *testing.T) {
+	_, err := Parse([]byte(`theme = "missing"`))
+	assert.NotNil(t, err)
+
+	_, err = Parse([]byte(`
+[themes.light]
+  mauve = "#ffffff"
+`))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// TitleValues are what the placeholders in a title template stand for
+type TitleValues struct {
+	Index   int    // {index}, the window's number
+	Cwd     string // {cwd}, the basename of the working directory
+	Command string // {command}, the program in the foreground, if it isn't the shell
+	Title   string // {title}, the title set by the program running in the terminal
+}
+
+var titlePlaceholder = regexp.MustCompile(`\{[^{}]*\}`)
+
+func validateTitleTemplate(template string) error {
+	for _, placeholder := range titlePlaceholder.FindAllString(template, -1) {
+		switch placeholder {
+		case "{index}", "{cwd}", "{command}", "{title}":
+		default:
+			return fmt.Errorf("Unknown placeholder '%s' in title template: should be {index}, {cwd}, {command} or {title}", placeholder)
+		}
+	}
+	return nil
+}
+
+// Expand fills in the placeholders in a title template, trimming any space left at either end by empty values
+func (values TitleValues) Expand(template string) string {
+	return strings.TrimSpace(strings.NewReplacer(
+		"{index}", strconv.Itoa(values.Index),
+		"{cwd}", values.Cwd,
+		"{command}", values.Command,
+		"{title}", values.Title,
+	).Replace(template))
+}
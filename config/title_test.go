@@ -0,0 +1,22 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTitleTemplate(t *testing.T) {
+	values := TitleValues{Index: 1, Cwd: "aminal", Command: "vim", Title: "main.go"}
+	assert.Equal(t, "1: vim in aminal - main.go", values.Expand("{index}: {command} in {cwd} - {title}"))
+	assert.Equal(t, "aminal", TitleValues{Cwd: "aminal"}.Expand("{command} {cwd}"))
+	assert.Equal(t, "no placeholders", values.Expand("no placeholders"))
+}
+
+func TestTitleTemplateValidation(t *testing.T) {
+	_, err := Parse([]byte(`title_template = "{command} {cwd}"`))
+	assert.Nil(t, err)
+
+	_, err = Parse([]byte(`title_template = "{host}"`))
+	assert.NotNil(t, err)
+}
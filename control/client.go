@@ -0,0 +1,35 @@
+package control
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+)
+
+// the longest line read from a control socket, which is mostly the title
+const maxLineLength = 1024 * 1024
+
+// Subscribe connects to the control socket at path and calls handle with each line of JSON it sends, until the window
+// closes. events are the types of event wanted, or all of them if empty.
+func Subscribe(path string, events []string, handle func(line []byte)) error {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	request, err := json.Marshal(Request{Command: "subscribe", Events: events})
+	if err != nil {
+		return err
+	}
+	if _, err := conn.Write(append(request, '\n')); err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 4096), maxLineLength)
+	for scanner.Scan() {
+		handle(scanner.Bytes())
+	}
+	return scanner.Err()
+}
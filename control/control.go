@@ -0,0 +1,58 @@
+package control
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/liamg/aminal/daemon"
+)
+
+// Each window serves a control socket, which tools such as status bars connect to and subscribe to the window's
+// events. Clients send a request as a line of JSON, e.g. {"command":"subscribe","events":["title","bell"]}, and the
+// server replies with the current state of each pane, then an event per line as things happen.
+
+// Event types published on the control socket
+const (
+	EventTitle        = "title"         // a program set the pane's title
+	EventDirectory    = "cwd"           // the shell reported a new working directory, with OSC 7
+	EventBell         = "bell"          // the bell rang
+	EventActivity     = "activity"      // output arrived in a pane which isn't being looked at
+	EventCommandStart = "command_start" // the shell started running a command, with OSC 133;C
+	EventCommandDone  = "command_done"  // the command finished, with OSC 133;D
+	EventFocus        = "focus"         // the focused pane changed, or the window gained or lost focus
+	EventPaneClosed   = "pane_closed"   // the pane was closed
+	EventError        = "error"         // the request couldn't be carried out, after which the server hangs up
+)
+
+// Event is something which happened in one of a window's panes, sent as a line of JSON
+type Event struct {
+	Type       string    `json:"type"`
+	Time       time.Time `json:"time"`
+	Window     int       `json:"window"` // the aminal process, so events from several windows can be told apart
+	Pane       int       `json:"pane"`
+	Title      string    `json:"title,omitempty"`
+	Directory  string    `json:"cwd,omitempty"`
+	Host       string    `json:"host,omitempty"`
+	Command    string    `json:"command,omitempty"`     // the command line, if the shell sent it with OSC 133;C
+	ExitStatus *int      `json:"exit_status,omitempty"` // for command_done, if the shell reported it
+	Duration   float64   `json:"duration,omitempty"`    // seconds the command ran for, for command_done
+	Focused    bool      `json:"focused,omitempty"`     // for focus, whether the window has focus
+	Error      string    `json:"error,omitempty"`
+}
+
+// Request is sent by a client after connecting
+type Request struct {
+	Command string   `json:"command"`          // only "subscribe"
+	Events  []string `json:"events,omitempty"` // the types of event wanted, or all of them if empty
+}
+
+// SocketPath returns the path of the control socket served by the aminal process with the given pid
+func SocketPath(pid int) string {
+	return filepath.Join(daemon.SocketDir(), fmt.Sprintf("%d.control", pid))
+}
+
+// ListSockets returns the paths of the control sockets of all running windows
+func ListSockets() ([]string, error) {
+	return filepath.Glob(filepath.Join(daemon.SocketDir(), "*.control"))
+}
@@ -0,0 +1,136 @@
+package control
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func listen(t *testing.T) (*Server, func()) {
+	dir, err := ioutil.TempDir("", "aminal-control-")
+	require.Nil(t, err)
+	server, err := Listen(filepath.Join(dir, "test.control"), zap.NewNop().Sugar())
+	require.Nil(t, err)
+	go server.Serve()
+	return server, func() {
+		server.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+// subscribe returns a channel of the events sent to a new subscriber, once it is subscribed
+func subscribe(t *testing.T, server *Server, types ...string) <-chan Event {
+	events := make(chan Event, 16)
+	go func() {
+		defer close(events)
+		Subscribe(server.path, types, func(line []byte) {
+			event := Event{}
+			assert.Nil(t, json.Unmarshal(line, &event))
+			events <- event
+		})
+	}()
+	for i := 0; i < 1000; i++ {
+		server.lock.Lock()
+		subscribed := len(server.subscribers) > 0
+		server.lock.Unlock()
+		if subscribed {
+			return events
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("Timed out subscribing")
+	return nil
+}
+
+func next(t *testing.T, events <-chan Event) Event {
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for an event")
+		return Event{}
+	}
+}
+
+func TestSubscribersReceiveEvents(t *testing.T) {
+	server, done := listen(t)
+	defer done()
+
+	events := subscribe(t, server)
+	server.Publish(Event{Type: EventTitle, Pane: 1, Title: "vim"})
+	server.Publish(Event{Type: EventBell, Pane: 2})
+
+	event := next(t, events)
+	assert.Equal(t, EventTitle, event.Type)
+	assert.Equal(t, "vim", event.Title)
+	assert.Equal(t, 1, event.Pane)
+	assert.Equal(t, os.Getpid(), event.Window)
+	assert.False(t, event.Time.IsZero())
+	assert.Equal(t, EventBell, next(t, events).Type)
+}
+
+func TestSubscribersCanChooseEvents(t *testing.T) {
+	server, done := listen(t)
+	defer done()
+
+	events := subscribe(t, server, EventBell)
+	server.Publish(Event{Type: EventTitle, Pane: 1, Title: "vim"})
+	server.Publish(Event{Type: EventBell, Pane: 1})
+	assert.Equal(t, EventBell, next(t, events).Type)
+}
+
+func TestNewSubscribersAreSentTheState(t *testing.T) {
+	server, done := listen(t)
+	defer done()
+
+	server.Publish(Event{Type: EventTitle, Pane: 1, Title: "first"})
+	server.Publish(Event{Type: EventTitle, Pane: 1, Title: "second"})
+	server.Publish(Event{Type: EventTitle, Pane: 2, Title: "closed"})
+	server.Publish(Event{Type: EventPaneClosed, Pane: 2})
+	server.Publish(Event{Type: EventCommandStart, Pane: 1, Command: "make"})
+	server.Publish(Event{Type: EventBell, Pane: 1})
+
+	events := subscribe(t, server)
+	assert.Equal(t, "second", next(t, events).Title)
+	assert.Equal(t, "make", next(t, events).Command)
+
+	server.Publish(Event{Type: EventCommandDone, Pane: 1})
+	assert.Equal(t, EventCommandDone, next(t, events).Type)
+	server.lock.Lock()
+	assert.Len(t, server.state, 1)
+	server.lock.Unlock()
+}
+
+func TestUnknownCommandsAreRefused(t *testing.T) {
+	server, done := listen(t)
+	defer done()
+
+	conn, err := net.Dial("unix", server.path)
+	require.Nil(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte(`{"command":"launch"}` + "\n"))
+	require.Nil(t, err)
+
+	event := Event{}
+	require.Nil(t, json.NewDecoder(conn).Decode(&event))
+	assert.Equal(t, EventError, event.Type)
+	assert.Contains(t, event.Error, "launch")
+}
+
+func TestClosingHangsUpOnSubscribers(t *testing.T) {
+	server, done := listen(t)
+	defer done()
+
+	events := subscribe(t, server)
+	server.Close()
+	_, open := <-events
+	assert.False(t, open)
+}
@@ -0,0 +1,223 @@
+package control
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/liamg/aminal/daemon"
+	"go.uber.org/zap"
+)
+
+// the most events queued for a subscriber which isn't reading them, before it is hung up on
+const subscriberBacklog = 256
+
+// how long a client has to send its request after connecting
+const requestTimeout = 10 * time.Second
+
+// Server publishes a window's events to the clients subscribed to them
+type Server struct {
+	path        string
+	window      int
+	listener    net.Listener
+	logger      *zap.SugaredLogger
+	lock        sync.Mutex
+	state       map[string]Event // the latest title, directory, focus and running command, sent to new subscribers
+	subscribers map[*subscriber]bool
+}
+
+type subscriber struct {
+	types  map[string]bool // the types of event wanted, or nil for all
+	events chan []byte
+}
+
+// Listen creates the control socket at path, replacing a stale one left by a window which did not exit cleanly
+func Listen(path string, logger *zap.SugaredLogger) (*Server, error) {
+	if err := daemon.MakePrivateDir(filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+	_ = os.Remove(path)
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	return &Server{
+		path:        path,
+		window:      os.Getpid(),
+		listener:    listener,
+		logger:      logger,
+		state:       map[string]Event{},
+		subscribers: map[*subscriber]bool{},
+	}, nil
+}
+
+// Serve accepts clients until the server is closed
+func (server *Server) Serve() {
+	for {
+		conn, err := server.listener.Accept()
+		if err != nil {
+			return
+		}
+		go server.handleClient(conn)
+	}
+}
+
+// Close stops accepting clients, hangs up on those subscribed and removes the socket
+func (server *Server) Close() error {
+	err := server.listener.Close()
+	_ = os.Remove(server.path)
+
+	server.lock.Lock()
+	defer server.lock.Unlock()
+	for sub := range server.subscribers {
+		server.unsubscribe(sub)
+	}
+	return err
+}
+
+// Publish sends an event to everyone subscribed to its type
+func (server *Server) Publish(event Event) {
+	event.Window = server.window
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	line, err := encode(event)
+	if err != nil {
+		server.logger.Errorf("Failed to encode %s event: %s", event.Type, err)
+		return
+	}
+
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	server.remember(event)
+	for sub := range server.subscribers {
+		if sub.types != nil && !sub.types[event.Type] {
+			continue
+		}
+		select {
+		case sub.events <- line:
+		default:
+			server.logger.Infof("Control socket client is not keeping up with events, hanging up on it")
+			server.unsubscribe(sub)
+		}
+	}
+}
+
+// remember keeps the events which describe the state of a pane, rather than something which happened once
+func (server *Server) remember(event Event) {
+	pane := fmt.Sprintf("/%d", event.Pane)
+	switch event.Type {
+	case EventTitle, EventDirectory:
+		server.state[event.Type+pane] = event
+	case EventCommandStart:
+		server.state["command"+pane] = event
+	case EventCommandDone:
+		delete(server.state, "command"+pane)
+	case EventFocus:
+		server.state[EventFocus] = event
+	case EventPaneClosed:
+		delete(server.state, EventTitle+pane)
+		delete(server.state, EventDirectory+pane)
+		delete(server.state, "command"+pane)
+	}
+}
+
+// subscribe starts sending events to a client, beginning with the state it has missed. The caller holds the lock.
+func (server *Server) subscribe(types []string) *subscriber {
+	sub := &subscriber{events: make(chan []byte, subscriberBacklog)}
+	if len(types) > 0 {
+		sub.types = map[string]bool{}
+		for _, t := range types {
+			sub.types[t] = true
+		}
+	}
+
+	state := []Event{}
+	for _, event := range server.state {
+		if sub.types == nil || sub.types[event.Type] {
+			state = append(state, event)
+		}
+	}
+	sort.Slice(state, func(i, j int) bool { return state[i].Time.Before(state[j].Time) })
+	for _, event := range state {
+		if line, err := encode(event); err == nil && len(sub.events) < subscriberBacklog {
+			sub.events <- line
+		}
+	}
+
+	server.subscribers[sub] = true
+	return sub
+}
+
+// unsubscribe stops sending events to a client, which is then hung up on. The caller holds the lock.
+func (server *Server) unsubscribe(sub *subscriber) {
+	if server.subscribers[sub] {
+		delete(server.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+func (server *Server) handleClient(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	_ = conn.SetReadDeadline(time.Now().Add(requestTimeout))
+	line, err := reader.ReadBytes('\n')
+	if err != nil {
+		return
+	}
+	_ = conn.SetReadDeadline(time.Time{})
+
+	request := Request{}
+	if err := json.Unmarshal(line, &request); err != nil {
+		server.reply(conn, fmt.Errorf("Invalid request: %s", err))
+		return
+	}
+	if request.Command != "subscribe" {
+		server.reply(conn, fmt.Errorf("Unknown command: %q", request.Command))
+		return
+	}
+
+	server.lock.Lock()
+	sub := server.subscribe(request.Events)
+	server.lock.Unlock()
+
+	// the client has nothing more to say, so reading only finds out when it hangs up
+	go func() {
+		_, _ = io.Copy(ioutil.Discard, reader)
+		server.lock.Lock()
+		defer server.lock.Unlock()
+		server.unsubscribe(sub)
+	}()
+
+	for line := range sub.events {
+		if _, err := conn.Write(line); err != nil {
+			break
+		}
+	}
+}
+
+// reply tells the client why its request failed
+func (server *Server) reply(conn net.Conn, err error) {
+	if line, err := encode(Event{Type: EventError, Window: server.window, Time: time.Now(), Error: err.Error()}); err == nil {
+		_, _ = conn.Write(line)
+	}
+}
+
+func encode(event Event) ([]byte, error) {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	return append(line, '\n'), nil
+}
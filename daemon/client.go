@@ -0,0 +1,50 @@
+package daemon
+
+import (
+	"encoding/binary"
+	"net"
+	"sync"
+)
+
+// Client is a connection to a session served by a Server. It satisfies terminal.Pty, so a terminal can be attached to
+// a detached session exactly as it would be to a local pty.
+type Client struct {
+	conn net.Conn
+	lock sync.Mutex
+}
+
+// Dial attaches to the named session
+func Dial(name string) (*Client, error) {
+	conn, err := net.Dial("unix", SocketPath(name))
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn}, nil
+}
+
+func (client *Client) Read(p []byte) (int, error) {
+	return client.conn.Read(p)
+}
+
+func (client *Client) Write(p []byte) (int, error) {
+	client.lock.Lock()
+	defer client.lock.Unlock()
+	if err := writeFrame(client.conn, frameInput, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (client *Client) Resize(cols uint16, rows uint16) error {
+	client.lock.Lock()
+	defer client.lock.Unlock()
+	payload := make([]byte, 4)
+	binary.BigEndian.PutUint16(payload[0:2], cols)
+	binary.BigEndian.PutUint16(payload[2:4], rows)
+	return writeFrame(client.conn, frameResize, payload)
+}
+
+// Close detaches from the session, leaving it running
+func (client *Client) Close() error {
+	return client.conn.Close()
+}
@@ -0,0 +1,132 @@
+package daemon
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// Sessions are served over a unix socket. Clients send framed messages (a type byte, a big-endian uint32 length and
+// then the payload) so that resizes can be sent alongside input. The server sends raw pty output back, unframed.
+
+const (
+	frameInput  byte = 'i'
+	frameResize byte = 'r'
+)
+
+// maximum amount of raw output kept by the server so that a newly attached window can rebuild its scrollback
+const historyLimit = 1024 * 1024
+
+// SocketDir returns the directory containing the sockets of all running sessions for this user
+func SocketDir() string {
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		return filepath.Join(runtimeDir, "aminal")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("aminal-%d", os.Getuid()))
+}
+
+// MakePrivateDir creates a directory for sockets if it doesn't exist, then checks that it is a directory of this user's
+// which no one else can use. The socket directory is usually in /tmp, where another user could have made it first, to
+// listen in on the sockets or to stand in for them.
+func MakePrivateDir(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("Refusing to use %s for sockets: it isn't a directory", dir)
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok && int(stat.Uid) != os.Getuid() {
+		return fmt.Errorf("Refusing to use %s for sockets: it belongs to another user", dir)
+	}
+	if info.Mode().Perm() != 0700 {
+		return fmt.Errorf("Refusing to use %s for sockets: its mode is %o rather than 700", dir, info.Mode().Perm())
+	}
+	return nil
+}
+
+// SocketPath returns the path of the socket used by the named session
+func SocketPath(name string) string {
+	return filepath.Join(SocketDir(), name+".sock")
+}
+
+// lockPath returns the path of the file the named session's server holds a lock on for as long as it runs
+func lockPath(name string) string {
+	return filepath.Join(SocketDir(), name+".lock")
+}
+
+// lockSession takes the named session's lock, which is released when the file returned is closed, or when the
+// server exits however it does
+func lockSession(name string) (*os.File, error) {
+	f, err := os.OpenFile(lockPath(name), os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("Session %s is already running", name)
+	}
+	return f, nil
+}
+
+// sessionRunning returns true if a server holds the named session's lock
+func sessionRunning(name string) bool {
+	f, err := os.Open(lockPath(name))
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB) == syscall.EWOULDBLOCK
+}
+
+// ListSessions returns the names of all sessions which can be attached to. Sockets left behind by servers which have
+// gone, e.g. crashed or killed, are removed rather than listed.
+func ListSessions() ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(SocketDir(), "*.sock"))
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, match := range matches {
+		name := strings.TrimSuffix(filepath.Base(match), ".sock")
+		if !sessionRunning(name) {
+			_ = os.Remove(match)
+			continue
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func writeFrame(w io.Writer, typ byte, payload []byte) error {
+	header := make([]byte, 5)
+	header[0] = typ
+	binary.BigEndian.PutUint32(header[1:], uint32(len(payload)))
+	if _, err := w.Write(append(header, payload...)); err != nil {
+		return err
+	}
+	return nil
+}
+
+func readFrame(r io.Reader) (byte, []byte, error) {
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, nil, err
+	}
+	size := binary.BigEndian.Uint32(header[1:])
+	if size > historyLimit {
+		return 0, nil, fmt.Errorf("Frame too large: %d bytes", size)
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	return header[0], payload, nil
+}
@@ -0,0 +1,79 @@
+package daemon
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrameRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	require.Nil(t, writeFrame(&buf, frameInput, []byte("ls -la\n")))
+	require.Nil(t, writeFrame(&buf, frameResize, []byte{0, 80, 0, 24}))
+
+	typ, payload, err := readFrame(&buf)
+	require.Nil(t, err)
+	assert.Equal(t, frameInput, typ)
+	assert.Equal(t, "ls -la\n", string(payload))
+
+	typ, payload, err = readFrame(&buf)
+	require.Nil(t, err)
+	assert.Equal(t, frameResize, typ)
+	assert.Equal(t, []byte{0, 80, 0, 24}, payload)
+}
+
+func TestOversizedFrameIsRejected(t *testing.T) {
+	_, _, err := readFrame(bytes.NewReader([]byte{frameInput, 0xff, 0xff, 0xff, 0xff}))
+	assert.NotNil(t, err)
+}
+
+func TestMakePrivateDir(t *testing.T) {
+	parent, err := ioutil.TempDir("", "aminal-test")
+	require.Nil(t, err)
+	defer os.RemoveAll(parent)
+
+	dir := filepath.Join(parent, "sockets")
+	require.Nil(t, MakePrivateDir(dir))
+	require.Nil(t, MakePrivateDir(dir))
+
+	// others can get into it
+	require.Nil(t, os.Chmod(dir, 0755))
+	assert.NotNil(t, MakePrivateDir(dir))
+
+	// a link to somewhere else, even somewhere private
+	private := filepath.Join(parent, "private")
+	require.Nil(t, os.Mkdir(private, 0700))
+	link := filepath.Join(parent, "link")
+	require.Nil(t, os.Symlink(private, link))
+	assert.NotNil(t, MakePrivateDir(link))
+}
+
+func TestStaleSessionsAreNotListed(t *testing.T) {
+	runtimeDir, err := ioutil.TempDir("", "aminal-test")
+	require.Nil(t, err)
+	defer os.RemoveAll(runtimeDir)
+	defer os.Setenv("XDG_RUNTIME_DIR", os.Getenv("XDG_RUNTIME_DIR"))
+	os.Setenv("XDG_RUNTIME_DIR", runtimeDir)
+	require.Nil(t, MakePrivateDir(SocketDir()))
+
+	lock, err := lockSession("live")
+	require.Nil(t, err)
+	defer lock.Close()
+	_, err = lockSession("live")
+	assert.NotNil(t, err)
+
+	for _, name := range []string{"live", "crashed"} {
+		require.Nil(t, ioutil.WriteFile(SocketPath(name), nil, 0600))
+	}
+
+	names, err := ListSessions()
+	require.Nil(t, err)
+	assert.Equal(t, []string{"live"}, names)
+	_, err = os.Stat(SocketPath("crashed"))
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,166 @@
+package daemon
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"os"
+	"sync"
+
+	"github.com/liamg/aminal/terminal"
+	"go.uber.org/zap"
+)
+
+// Server keeps a pty (and therefore the shell running in it) alive independently of any window. A single client can
+// be attached at a time - attaching a new one detaches the previous.
+type Server struct {
+	name    string
+	pty     terminal.Pty
+	logger  *zap.SugaredLogger
+	lock    sync.Mutex
+	history []byte
+	client  net.Conn
+}
+
+func NewServer(name string, pty terminal.Pty, logger *zap.SugaredLogger) *Server {
+	return &Server{
+		name:   name,
+		pty:    pty,
+		logger: logger,
+	}
+}
+
+// Serve accepts clients until the pty is closed, i.e. the shell exits
+func (server *Server) Serve() error {
+
+	path := SocketPath(server.name)
+	if err := MakePrivateDir(SocketDir()); err != nil {
+		return err
+	}
+	lock, err := lockSession(server.name)
+	if err != nil {
+		return err
+	}
+	defer lock.Close()
+	defer os.Remove(lock.Name())
+	_ = os.Remove(path) // stale socket from a session which did not exit cleanly
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(path)
+
+	go func() {
+		server.readPty()
+		listener.Close()
+	}()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			break
+		}
+		server.attach(conn)
+	}
+
+	server.lock.Lock()
+	defer server.lock.Unlock()
+	if server.client != nil {
+		server.client.Close()
+	}
+	return nil
+}
+
+func (server *Server) readPty() {
+	buf := make([]byte, 4096)
+	for {
+		n, err := server.pty.Read(buf)
+		if n > 0 {
+			server.record(buf[:n])
+		}
+		if err != nil {
+			server.logger.Infof("Session %s ended: %s", server.name, err)
+			return
+		}
+	}
+}
+
+func (server *Server) record(data []byte) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	server.history = append(server.history, data...)
+	if len(server.history) > historyLimit {
+		cut := len(server.history) - historyLimit
+		// try not to replay half a line (and potentially half an escape sequence)
+		if i := bytes.IndexByte(server.history[cut:], '\n'); i > -1 {
+			cut += i + 1
+		}
+		server.history = append([]byte{}, server.history[cut:]...)
+	}
+
+	if server.client != nil {
+		if _, err := server.client.Write(data); err != nil {
+			server.logger.Infof("Client detached from session %s", server.name)
+			server.client.Close()
+			server.client = nil
+		}
+	}
+}
+
+func (server *Server) attach(conn net.Conn) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	if server.client != nil {
+		server.logger.Infof("Detaching previous client from session %s", server.name)
+		server.client.Close()
+	}
+
+	if _, err := conn.Write(server.history); err != nil {
+		conn.Close()
+		return
+	}
+
+	server.logger.Infof("Client attached to session %s", server.name)
+	server.client = conn
+	go server.handleClient(conn)
+}
+
+func (server *Server) handleClient(conn net.Conn) {
+
+	defer func() {
+		server.lock.Lock()
+		defer server.lock.Unlock()
+		if server.client == conn {
+			server.client = nil
+		}
+		conn.Close()
+	}()
+
+	for {
+		typ, payload, err := readFrame(conn)
+		if err != nil {
+			return
+		}
+		switch typ {
+		case frameInput:
+			if _, err := server.pty.Write(payload); err != nil {
+				server.logger.Errorf("Failed to write to pty: %s", err)
+			}
+		case frameResize:
+			if len(payload) != 4 {
+				server.logger.Errorf("Invalid resize frame")
+				continue
+			}
+			cols := binary.BigEndian.Uint16(payload[0:2])
+			rows := binary.BigEndian.Uint16(payload[2:4])
+			if err := server.pty.Resize(cols, rows); err != nil {
+				server.logger.Errorf("Failed to resize pty: %s", err)
+			}
+		default:
+			server.logger.Errorf("Unknown frame type: 0x%02X", typ)
+		}
+	}
+}
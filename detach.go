@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+
+	"github.com/liamg/aminal/config"
+	"github.com/liamg/aminal/daemon"
+	"github.com/liamg/aminal/terminal"
+	"go.uber.org/zap"
+)
+
+// runDaemon runs a shell which outlives any window attached to it, until the shell exits
+func runDaemon(name string, conf *config.Config, pane *config.PaneLayout, logger *zap.SugaredLogger) {
+	// the shell outlives the window which started it, so can't rely on that window's control socket
+	os.Unsetenv("AMINAL_CONTROL_SOCKET")
+	pty, err := launchShell(conf, pane, logger)
+	if err != nil {
+		logger.Fatalf("%s", err)
+	}
+	if err := daemon.NewServer(name, pty, logger).Serve(); err != nil {
+		logger.Fatalf("Failed to serve session %s: %s", name, err)
+	}
+}
+
+// startDetachableSession starts a new aminal process in the background to own the shell, and attaches to it
+func startDetachableSession(conf *config.Config, logger *zap.SugaredLogger) (terminal.Pty, error) {
+
+	name := fmt.Sprintf("%d", os.Getpid())
+
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"--daemon", name}
+	if conf.Shell != "" {
+		args = append(args, "--shell", conf.Shell)
+	}
+	if command != "" {
+		args = append(args, "--command", command)
+	}
+	if sessionFile != "" {
+		args = append(args, "--session", sessionFile)
+	}
+
+	logger.Infof("Starting session %s...", name)
+	cmd := exec.Command(executable, args...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	_ = cmd.Process.Release()
+
+	for i := 0; i < 50; i++ {
+		if client, err := daemon.Dial(name); err == nil {
+			return client, nil
+		}
+		time.Sleep(time.Millisecond * 100)
+	}
+
+	return nil, fmt.Errorf("Timed out waiting for session %s to start", name)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/liamg/aminal/control"
+	"go.uber.org/zap"
+)
+
+// startControlServer serves this window's control socket, and tells the shell where it is with $AMINAL_CONTROL_SOCKET
+func startControlServer(logger *zap.SugaredLogger) *control.Server {
+	path := control.SocketPath(os.Getpid())
+	server, err := control.Listen(path, logger)
+	if err != nil {
+		logger.Errorf("Failed to serve the control socket: %s", err)
+		return nil
+	}
+	go server.Serve()
+	os.Setenv("AMINAL_CONTROL_SOCKET", path)
+	return server
+}
+
+// printEvents prints the events of every running window as lines of JSON, until they have all closed
+func printEvents() error {
+	paths, err := control.ListSockets()
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("No windows are running")
+	}
+
+	lock := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	for _, path := range paths {
+		wg.Add(1)
+		go func(path string) {
+			defer wg.Done()
+			// sockets left behind by windows which crashed can't be connected to, and are skipped
+			_ = control.Subscribe(path, nil, func(line []byte) {
+				lock.Lock()
+				defer lock.Unlock()
+				os.Stdout.Write(append(line, '\n'))
+			})
+		}(path)
+	}
+	wg.Wait()
+	return nil
+}
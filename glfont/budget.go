@@ -0,0 +1,96 @@
+package glfont
+
+import (
+	"container/list"
+
+	"github.com/go-gl/gl/all-core/gl"
+)
+
+// TextureBudget tracks the GPU memory used by textures, such as glyphs and image tiles, against a limit. When adding
+// a texture takes it over the limit, the least recently used textures are deleted, and their owners told so they can
+// create them again if they are needed.
+type TextureBudget struct {
+	limit   int
+	used    int
+	order   *list.List // most recently used at the front
+	entries map[uint32]*list.Element
+	delete  func(texture uint32)
+}
+
+type budgetEntry struct {
+	texture uint32
+	bytes   int
+	evicted func()
+}
+
+// NewTextureBudget creates a budget of the given number of bytes
+func NewTextureBudget(limit int) *TextureBudget {
+	return &TextureBudget{
+		limit:   limit,
+		order:   list.New(),
+		entries: map[uint32]*list.Element{},
+		delete: func(texture uint32) {
+			gl.DeleteTextures(1, &texture)
+		},
+	}
+}
+
+// Add tracks a newly created texture of the given size. evicted is called after the texture is deleted to keep within
+// the budget.
+func (budget *TextureBudget) Add(texture uint32, bytes int, evicted func()) {
+	if budget == nil {
+		return
+	}
+	budget.entries[texture] = budget.order.PushFront(&budgetEntry{texture: texture, bytes: bytes, evicted: evicted})
+	budget.used += bytes
+	// the newest texture is about to be drawn, so it is never evicted itself
+	for budget.used > budget.limit && budget.order.Len() > 1 {
+		entry := budget.order.Remove(budget.order.Back()).(*budgetEntry)
+		delete(budget.entries, entry.texture)
+		budget.used -= entry.bytes
+		budget.delete(entry.texture)
+		entry.evicted()
+	}
+}
+
+// Touch marks a texture as just used
+func (budget *TextureBudget) Touch(texture uint32) {
+	if budget == nil {
+		return
+	}
+	if element, ok := budget.entries[texture]; ok {
+		budget.order.MoveToFront(element)
+	}
+}
+
+// Remove stops tracking a texture its owner has deleted
+func (budget *TextureBudget) Remove(texture uint32) {
+	if budget == nil {
+		return
+	}
+	if element, ok := budget.entries[texture]; ok {
+		budget.used -= budget.order.Remove(element).(*budgetEntry).bytes
+		delete(budget.entries, texture)
+	}
+}
+
+// Used returns the number of bytes of textures being tracked
+func (budget *TextureBudget) Used() int {
+	if budget == nil {
+		return 0
+	}
+	return budget.used
+}
+
+// Limit returns the budget in bytes
+func (budget *TextureBudget) Limit() int {
+	if budget == nil {
+		return 0
+	}
+	return budget.limit
+}
+
+// SetTextureBudget makes the font's glyph textures count towards a budget shared with other textures
+func (f *Font) SetTextureBudget(budget *TextureBudget) {
+	f.textures = budget
+}
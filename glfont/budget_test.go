@@ -0,0 +1,68 @@
+package glfont
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestBudget(limit int) (*TextureBudget, *[]uint32) {
+	deleted := []uint32{}
+	budget := NewTextureBudget(limit)
+	budget.delete = func(texture uint32) {
+		deleted = append(deleted, texture)
+	}
+	return budget, &deleted
+}
+
+func TestTextureBudgetEvictsLeastRecentlyUsed(t *testing.T) {
+	budget, deleted := newTestBudget(300)
+	evicted := map[uint32]bool{}
+	add := func(texture uint32) {
+		budget.Add(texture, 100, func() { evicted[texture] = true })
+	}
+
+	add(1)
+	add(2)
+	add(3)
+	assert.Equal(t, 300, budget.Used())
+	assert.Empty(t, *deleted)
+
+	budget.Touch(1)
+	add(4)
+
+	assert.Equal(t, []uint32{2}, *deleted)
+	assert.Equal(t, map[uint32]bool{2: true}, evicted)
+	assert.Equal(t, 300, budget.Used())
+
+	add(5)
+	assert.Equal(t, []uint32{2, 3}, *deleted)
+}
+
+func TestTextureBudgetKeepsNewestTexture(t *testing.T) {
+	budget, deleted := newTestBudget(100)
+	budget.Add(1, 50, func() {})
+	budget.Add(2, 500, func() {})
+
+	// a texture bigger than the whole budget still has to be drawn
+	assert.Equal(t, []uint32{1}, *deleted)
+	assert.Equal(t, 500, budget.Used())
+}
+
+func TestTextureBudgetRemove(t *testing.T) {
+	budget, deleted := newTestBudget(100)
+	budget.Add(1, 60, func() {})
+	budget.Remove(1)
+	budget.Add(2, 60, func() {})
+
+	assert.Empty(t, *deleted)
+	assert.Equal(t, 60, budget.Used())
+}
+
+func TestNilTextureBudget(t *testing.T) {
+	var budget *TextureBudget
+	budget.Add(1, 100, func() {})
+	budget.Touch(1)
+	budget.Remove(1)
+	assert.Equal(t, 0, budget.Used())
+}
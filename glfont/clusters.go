@@ -0,0 +1,121 @@
+package glfont
+
+import (
+	"github.com/golang/freetype/truetype"
+)
+
+type ligature struct {
+	components []truetype.Index // the glyphs which follow the first one
+	glyph      truetype.Index
+}
+
+// clusterFeatures are the features which can join the runes of a single cell into one glyph, such as the parts of a
+// ZWJ emoji sequence or the pair of regional indicators in a flag
+var clusterFeatures = []string{"ccmp", "liga", "rlig"}
+
+// PrintCluster draws a grapheme cluster - several runes which make up a single character - as one glyph. If the font has
+// no ligature for the whole cluster, the first rune is drawn on its own.
+func (f *Font) PrintCluster(x, y float32, cluster []rune) error {
+	if len(cluster) == 0 {
+		return nil
+	}
+	if len(cluster) == 1 {
+		return f.Print(x, y, string(cluster))
+	}
+
+	ch, err := f.getCluster(cluster)
+	if err != nil {
+		return err
+	}
+
+	f.beginPrint()
+	f.drawCharacter(x, y, ch)
+	f.endPrint()
+	return nil
+}
+
+func (f *Font) getCluster(cluster []rune) (*character, error) {
+
+	key := string(cluster)
+	if ch, ok := f.clusters[key]; ok {
+		f.textures.Touch(ch.textureID)
+		return ch, nil
+	}
+
+	rgba, char, err := f.rasterizeGlyph(f.shape(cluster))
+	if err != nil {
+		return nil, err
+	}
+	char.textureID = newGlyphTexture(rgba)
+	if f.clusters == nil {
+		f.clusters = map[string]*character{}
+	}
+	f.clusters[key] = char
+	f.textures.Add(char.textureID, len(rgba.Pix), func() {
+		delete(f.clusters, key)
+	})
+
+	return char, nil
+}
+
+// shape returns the glyph to draw for a cluster
+func (f *Font) shape(cluster []rune) truetype.Index {
+
+	if f.ligatures == nil {
+		ligatures, err := ligatureSubstitutions(f.data, clusterFeatures)
+		if err != nil {
+			ligatures = map[truetype.Index][]ligature{}
+		}
+		f.ligatures = ligatures
+	}
+
+	glyphs := f.glyphs(cluster, false)
+	if glyph, ok := f.ligate(glyphs); ok {
+		return glyph
+	}
+
+	// fonts don't always include variation selectors in their ligatures
+	if glyph, ok := f.ligate(f.glyphs(cluster, true)); ok {
+		return glyph
+	}
+
+	return glyphs[0]
+}
+
+func (f *Font) glyphs(cluster []rune, skipSelectors bool) []truetype.Index {
+	glyphs := []truetype.Index{}
+	for i, r := range cluster {
+		if skipSelectors && i > 0 && r >= 0xFE00 && r <= 0xFE0F {
+			continue
+		}
+		index := f.ttf.Index(r)
+		if substitute, ok := f.substitutions[index]; ok {
+			index = substitute
+		}
+		glyphs = append(glyphs, index)
+	}
+	return glyphs
+}
+
+// ligate returns the ligature which replaces the whole of a glyph sequence, if the font has one
+func (f *Font) ligate(glyphs []truetype.Index) (truetype.Index, bool) {
+	if len(glyphs) == 1 {
+		return glyphs[0], true
+	}
+	for _, lig := range f.ligatures[glyphs[0]] {
+		if len(lig.components) != len(glyphs)-1 {
+			continue
+		}
+		match := true
+		for i, component := range lig.components {
+			if glyphs[i+1] != component {
+				match = false
+				break
+			}
+		}
+		if match {
+			return lig.glyph, true
+		}
+	}
+	return 0, false
+}
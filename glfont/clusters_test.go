@@ -0,0 +1,56 @@
+package glfont
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/golang/freetype/truetype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fontWithGSUB wraps a GSUB table in the minimum sfnt structure needed to find it
+func fontWithGSUB(gsub []uint16) []byte {
+	table := []byte{}
+	for _, v := range gsub {
+		table = append(table, byte(v>>8), byte(v))
+	}
+	data := make([]byte, 28)
+	binary.BigEndian.PutUint16(data[4:], 1)
+	copy(data[12:], "GSUB")
+	binary.BigEndian.PutUint32(data[20:], 28)
+	binary.BigEndian.PutUint32(data[24:], uint32(len(table)))
+	return append(data, table...)
+}
+
+func TestLigatureSubstitutions(t *testing.T) {
+	data := fontWithGSUB([]uint16{
+		1, 0, 0, 10, 24, // header: version, script list, feature list, lookup list
+		1, 'c'<<8 | 'c', 'm'<<8 | 'p', 8, // feature list: one "ccmp" feature
+		0, 1, 0, // feature: one lookup, index 0
+		1, 4, // lookup list: one lookup
+		4, 0, 1, 8, // lookup: ligature type, one subtable
+		1, 8, 1, 14, // ligature subtable: coverage, one ligature set
+		1, 1, 10, // coverage: glyph 10
+		1, 4, // ligature set: one ligature
+		99, 3, 11, 12, // ligature: glyph 99 replaces 10, 11, 12
+	})
+
+	ligatures, err := ligatureSubstitutions(data, []string{"ccmp"})
+	require.Nil(t, err)
+	assert.Equal(t, map[truetype.Index][]ligature{
+		10: {{components: []truetype.Index{11, 12}, glyph: 99}},
+	}, ligatures)
+
+	f := &Font{ligatures: ligatures}
+	glyph, ok := f.ligate([]truetype.Index{10, 11, 12})
+	assert.True(t, ok)
+	assert.Equal(t, truetype.Index(99), glyph)
+
+	_, ok = f.ligate([]truetype.Index{10, 11})
+	assert.False(t, ok)
+
+	ligatures, err = ligatureSubstitutions(data, []string{"liga"})
+	require.Nil(t, err)
+	assert.Len(t, ligatures, 0)
+}
@@ -0,0 +1,29 @@
+package glfont
+
+import (
+	"github.com/go-gl/gl/all-core/gl"
+)
+
+// SetFeatures enables OpenType features (e.g. "zero", "ss01") which swap glyphs for alternate forms
+func (f *Font) SetFeatures(features []string) error {
+	substitutions, err := singleSubstitutions(f.data, features)
+	if err != nil {
+		return err
+	}
+	f.substitutions = substitutions
+	f.clearCache()
+	return nil
+}
+
+func (f *Font) clearCache() {
+	for _, ch := range f.characters {
+		f.textures.Remove(ch.textureID)
+		gl.DeleteTextures(1, &ch.textureID)
+	}
+	f.characters = map[rune]*character{}
+	for _, ch := range f.clusters {
+		f.textures.Remove(ch.textureID)
+		gl.DeleteTextures(1, &ch.textureID)
+	}
+	f.clusters = map[string]*character{}
+}
@@ -1,14 +1,9 @@
 package glfont
 
 import (
-	"fmt"
 	"github.com/go-gl/gl/all-core/gl"
-	"github.com/golang/freetype"
 	"github.com/golang/freetype/truetype"
-	"golang.org/x/image/font"
 	"golang.org/x/image/math/fixed"
-	"image"
-	"image/draw"
 	"io"
 )
 
@@ -16,16 +11,25 @@ const DPI = 72
 
 // A Font allows rendering of text to an OpenGL context.
 type Font struct {
-	characters  map[rune]*character
-	vao         uint32
-	vbo         uint32
-	program     uint32
-	texture     uint32 // Holds the glyph texture id.
-	color       color
-	ttf         *truetype.Font
-	scale       float32
-	linePadding float32
-	lineHeight  float32
+	characters    map[rune]*character
+	vao           uint32
+	vbo           uint32
+	program       uint32
+	modeUniform   int32 // the locations of the program's uniforms which are set for each glyph or print
+	colorUniform  int32
+	texture       uint32 // Holds the glyph texture id.
+	color         color
+	ttf           *truetype.Font
+	data          []byte
+	substitutions map[truetype.Index]truetype.Index
+	ligatures     map[truetype.Index][]ligature
+	clusters      map[string]*character
+	rendering     Rendering
+	variations    *variations
+	scale         float32
+	linePadding   float32
+	lineHeight    float32
+	textures      *TextureBudget
 }
 
 type color struct {
@@ -51,6 +55,8 @@ func LoadFont(reader io.Reader, scale float32, windowWidth int, windowHeight int
 	resUniform := gl.GetUniformLocation(program, gl.Str("resolution\x00"))
 	gl.Uniform2f(resUniform, float32(windowWidth), float32(windowHeight))
 
+	ColourOutput{}.Use(program)
+
 	return LoadTrueTypeFont(program, reader, scale)
 }
 
@@ -62,6 +68,11 @@ func (f *Font) SetColor(red float32, green float32, blue float32, alpha float32)
 	f.color.a = alpha
 }
 
+// SetColourOutput sets how the text colour is written to the window
+func (f *Font) SetColourOutput(output ColourOutput) {
+	output.Use(f.program)
+}
+
 func (f *Font) UpdateResolution(windowWidth int, windowHeight int) {
 	gl.UseProgram(f.program)
 	resUniform := gl.GetUniformLocation(f.program, gl.Str("resolution\x00"))
@@ -87,20 +98,7 @@ func (f *Font) Print(x, y float32, text string) error {
 		return nil
 	}
 
-	//setup blending mode
-	gl.Enable(gl.BLEND)
-	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
-
-	// Activate corresponding render state
-	gl.UseProgram(f.program)
-	//set text color
-	gl.Uniform4f(gl.GetUniformLocation(f.program, gl.Str("textColor\x00")), f.color.r, f.color.g, f.color.b, f.color.a)
-	//set screen resolution
-	//resUniform := gl.GetUniformLocation(f.program, gl.Str("resolution\x00"))
-	//gl.Uniform2f(resUniform, float32(2560), float32(1440))
-
-	gl.ActiveTexture(gl.TEXTURE0)
-	gl.BindVertexArray(f.vao)
+	f.beginPrint()
 
 	// Iterate through all characters in string
 	for i := range indices {
@@ -111,55 +109,81 @@ func (f *Font) Print(x, y float32, text string) error {
 		//find rune in fontChar list
 		ch, err := f.GetRune(runeIndex)
 		if err != nil {
+			f.endPrint()
 			return err // @todo ignore errors?
 		}
 
-		//calculate position and size for current rune
-		xpos := x + float32(ch.bearingH)
-		ypos := y - float32(+ch.height-ch.bearingV)
-		w := float32(ch.width)
-		h := float32(ch.height)
-
-		//set quad positions
-		var x1 = xpos
-		var x2 = xpos + w
-		var y1 = ypos
-		var y2 = ypos + h
-
-		//setup quad array
-		var vertices = []float32{
-			//  X, Y, Z, U, V
-			// Front
-			x1, y1, 0.0, 0.0,
-			x2, y1, 1.0, 0.0,
-			x1, y2, 0.0, 1.0,
-			x1, y2, 0.0, 1.0,
-			x2, y1, 1.0, 0.0,
-			x2, y2, 1.0, 1.0}
-
-		// Render glyph texture over quad
-		gl.BindTexture(gl.TEXTURE_2D, ch.textureID)
-		// Update content of VBO memory
-		gl.BindBuffer(gl.ARRAY_BUFFER, f.vbo)
-
-		//BufferSubData(target Enum, offset int, data []byte)
-		gl.BufferSubData(gl.ARRAY_BUFFER, 0, len(vertices)*4, gl.Ptr(vertices)) // Be sure to use glBufferSubData and not glBufferData
-		// Render quad
-		gl.DrawArrays(gl.TRIANGLES, 0, 24)
-
-		gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+		f.drawCharacter(x, y, ch)
+
 		// Now advance cursors for next glyph (note that advance is number of 1/64 pixels)
 		x += float32((ch.advance >> 6)) // Bitshift by 6 to get value in pixels (2^6 = 64 (divide amount of 1/64th pixels by 64 to get amount of pixels))
 
 	}
 
+	f.endPrint()
+
+	return nil
+}
+
+func (f *Font) beginPrint() {
+	//setup blending mode
+	gl.Enable(gl.BLEND)
+	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+
+	// Activate corresponding render state
+	gl.UseProgram(f.program)
+	//set text color
+	gl.Uniform4f(f.colorUniform, f.color.r, f.color.g, f.color.b, f.color.a)
+
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindVertexArray(f.vao)
+}
+
+func (f *Font) endPrint() {
 	//clear opengl textures and programs
 	gl.BindVertexArray(0)
 	gl.BindTexture(gl.TEXTURE_2D, 0)
 	gl.UseProgram(0)
 	gl.Disable(gl.BLEND)
+}
 
-	return nil
+// drawCharacter draws a single glyph with its origin at x, y
+func (f *Font) drawCharacter(x, y float32, ch *character) {
+
+	//calculate position and size for current rune
+	xpos := x + float32(ch.bearingH)
+	ypos := y - float32(+ch.height-ch.bearingV)
+	w := float32(ch.width)
+	h := float32(ch.height)
+
+	//set quad positions
+	var x1 = xpos
+	var x2 = xpos + w
+	var y1 = ypos
+	var y2 = ypos + h
+
+	//setup quad array
+	var vertices = []float32{
+		//  X, Y, Z, U, V
+		// Front
+		x1, y1, 0.0, 0.0,
+		x2, y1, 1.0, 0.0,
+		x1, y2, 0.0, 1.0,
+		x1, y2, 0.0, 1.0,
+		x2, y1, 1.0, 0.0,
+		x2, y2, 1.0, 1.0}
+
+	// Render glyph texture over quad
+	gl.BindTexture(gl.TEXTURE_2D, ch.textureID)
+	// Update content of VBO memory
+	gl.BindBuffer(gl.ARRAY_BUFFER, f.vbo)
+
+	//BufferSubData(target Enum, offset int, data []byte)
+	gl.BufferSubData(gl.ARRAY_BUFFER, 0, len(vertices)*4, gl.Ptr(vertices)) // Be sure to use glBufferSubData and not glBufferData
+	// Render quad
+	f.drawGlyph()
+
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
 }
 
 //Width returns the width of a piece of text in pixels
@@ -217,90 +241,24 @@ func (f *Font) GetRune(r rune) (*character, error) {
 
 	cc, ok := f.characters[r]
 	if ok {
+		f.textures.Touch(cc.textureID)
 		return cc, nil
 	}
 
-	char := new(character)
-
-	//create new face to measure glyph diamensions
-	ttfFace := truetype.NewFace(f.ttf, &truetype.Options{
-		Size:    float64(f.scale),
-		DPI:     DPI,
-		Hinting: font.HintingFull,
-	})
-
-	gBnd, gAdv, ok := ttfFace.GlyphBounds(r)
-	if ok != true {
-		return nil, fmt.Errorf("ttf face glyphBounds error")
-	}
-
-	gh := int32((gBnd.Max.Y - gBnd.Min.Y) >> 6)
-	gw := int32((gBnd.Max.X - gBnd.Min.X) >> 6)
-
-	//if gylph has no diamensions set to a max value
-	if gw == 0 || gh == 0 {
-		gBnd = f.ttf.Bounds(fixed.Int26_6(f.scale))
-		gw = int32((gBnd.Max.X - gBnd.Min.X) >> 6)
-		gh = int32((gBnd.Max.Y - gBnd.Min.Y) >> 6)
-
-		//above can sometimes yield 0 for font smaller than 48pt, 1 is minimum
-		if gw == 0 || gh == 0 {
-			gw = 1
-			gh = 1
-		}
+	index := f.ttf.Index(r)
+	if substitute, ok := f.substitutions[index]; ok {
+		index = substitute
 	}
 
-	//The glyph's ascent and descent equal -bounds.Min.Y and +bounds.Max.Y.
-	gAscent := int(-gBnd.Min.Y) >> 6
-	gdescent := int(gBnd.Max.Y) >> 6
-
-	//set w,h and adv, bearing V and bearing H in char
-	char.width = int(gw)
-	char.height = int(gh)
-	char.advance = int(gAdv)
-	char.bearingV = gdescent
-	char.bearingH = (int(gBnd.Min.X) >> 6)
-
-	//create image to draw glyph
-	fg, bg := image.White, image.Black
-	rect := image.Rect(0, 0, int(gw), int(gh))
-	rgba := image.NewRGBA(rect)
-	draw.Draw(rgba, rgba.Bounds(), bg, image.ZP, draw.Src)
-
-	//create a freetype context for drawing
-	c := freetype.NewContext()
-	c.SetDPI(DPI)
-	c.SetFont(f.ttf)
-	c.SetFontSize(float64(f.scale))
-	c.SetClip(rgba.Bounds())
-	c.SetDst(rgba)
-	c.SetSrc(fg)
-	c.SetHinting(font.HintingFull)
-
-	//set the glyph dot
-	px := 0 - (int(gBnd.Min.X) >> 6)
-	py := (gAscent)
-	pt := freetype.Pt(px, py)
-
-	// Draw the text from mask to image
-	if _, err := c.DrawString(string(r), pt); err != nil {
+	rgba, char, err := f.rasterizeGlyph(index)
+	if err != nil {
 		return nil, err
 	}
-
-	// Generate texture
-	var texture uint32
-	gl.GenTextures(1, &texture)
-	gl.BindTexture(gl.TEXTURE_2D, texture)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
-	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA, int32(rgba.Rect.Dx()), int32(rgba.Rect.Dy()), 0,
-		gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(rgba.Pix))
-
-	char.textureID = texture
-
+	char.textureID = newGlyphTexture(rgba)
 	f.characters[r] = char
+	f.textures.Add(char.textureID, len(rgba.Pix), func() {
+		delete(f.characters, r)
+	})
 
 	return char, nil
 }
@@ -0,0 +1,230 @@
+package glfont
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/golang/freetype/truetype"
+)
+
+const (
+	lookupSingle    = 1
+	lookupLigature  = 4
+	lookupExtension = 7
+)
+
+// gsubReader reads big-endian values from an OpenType table, failing rather than panicking on truncated data
+type gsubReader struct {
+	data []byte
+	err  error
+}
+
+func (r *gsubReader) u16(offset int) int {
+	if r.err != nil {
+		return 0
+	}
+	if offset < 0 || offset+2 > len(r.data) {
+		r.err = fmt.Errorf("GSUB table is truncated")
+		return 0
+	}
+	return int(binary.BigEndian.Uint16(r.data[offset:]))
+}
+
+func (r *gsubReader) u32(offset int) int {
+	if r.err != nil {
+		return 0
+	}
+	if offset < 0 || offset+4 > len(r.data) {
+		r.err = fmt.Errorf("GSUB table is truncated")
+		return 0
+	}
+	return int(binary.BigEndian.Uint32(r.data[offset:]))
+}
+
+func (r *gsubReader) tag(offset int) string {
+	if r.err != nil {
+		return ""
+	}
+	if offset < 0 || offset+4 > len(r.data) {
+		r.err = fmt.Errorf("GSUB table is truncated")
+		return ""
+	}
+	return string(r.data[offset : offset+4])
+}
+
+// findTable returns the raw bytes of the named table in an sfnt font file, or nil if the font doesn't have one
+func findTable(data []byte, name string) []byte {
+	if len(data) < 12 {
+		return nil
+	}
+	count := int(binary.BigEndian.Uint16(data[4:]))
+	for i := 0; i < count; i++ {
+		record := 12 + 16*i
+		if record+16 > len(data) {
+			return nil
+		}
+		if string(data[record:record+4]) != name {
+			continue
+		}
+		offset := int(binary.BigEndian.Uint32(data[record+8:]))
+		length := int(binary.BigEndian.Uint32(data[record+12:]))
+		if offset+length > len(data) {
+			return nil
+		}
+		return data[offset : offset+length]
+	}
+	return nil
+}
+
+// singleSubstitutions reads the GSUB table of a font, returning the glyph swaps made by the given features.
+// Each cell is rendered on its own, so ligatures can't join the contents of separate cells - they are only used within a
+// single cell's grapheme cluster, see ligatureSubstitutions.
+func singleSubstitutions(data []byte, features []string) (map[truetype.Index]truetype.Index, error) {
+
+	substitutions := map[truetype.Index]truetype.Index{}
+
+	table := findTable(data, "GSUB")
+	if table == nil || len(features) == 0 {
+		return substitutions, nil
+	}
+
+	r := &gsubReader{data: table}
+	r.forEachSubtable(features, func(lookupType int, subtable int) {
+		if lookupType == lookupSingle {
+			r.readSingleSubstitution(subtable, substitutions)
+		}
+	})
+
+	return substitutions, r.err
+}
+
+// ligatureSubstitutions reads the GSUB table of a font, returning the ligatures made by the given features, keyed by
+// their first glyph
+func ligatureSubstitutions(data []byte, features []string) (map[truetype.Index][]ligature, error) {
+
+	ligatures := map[truetype.Index][]ligature{}
+
+	table := findTable(data, "GSUB")
+	if table == nil || len(features) == 0 {
+		return ligatures, nil
+	}
+
+	r := &gsubReader{data: table}
+	r.forEachSubtable(features, func(lookupType int, subtable int) {
+		if lookupType == lookupLigature {
+			r.readLigatureSubstitution(subtable, ligatures)
+		}
+	})
+
+	return ligatures, r.err
+}
+
+// forEachSubtable calls fn with the type and offset of each subtable of the lookups used by the given features
+func (r *gsubReader) forEachSubtable(features []string, fn func(lookupType int, subtable int)) {
+
+	wanted := map[string]bool{}
+	for _, feature := range features {
+		wanted[feature] = true
+	}
+
+	featureList := r.u16(6)
+	lookupList := r.u16(8)
+
+	lookups := map[int]bool{}
+	featureCount := r.u16(featureList)
+	for i := 0; i < featureCount; i++ {
+		record := featureList + 2 + 6*i
+		if !wanted[r.tag(record)] {
+			continue
+		}
+		feature := featureList + r.u16(record+4)
+		lookupCount := r.u16(feature + 2)
+		for j := 0; j < lookupCount; j++ {
+			lookups[r.u16(feature+4+2*j)] = true
+		}
+	}
+
+	lookupCount := r.u16(lookupList)
+	for i := 0; i < lookupCount && r.err == nil; i++ {
+		if !lookups[i] {
+			continue
+		}
+		lookup := lookupList + r.u16(lookupList+2+2*i)
+		lookupType := r.u16(lookup)
+		subtableCount := r.u16(lookup + 4)
+		for j := 0; j < subtableCount; j++ {
+			subtable := lookup + r.u16(lookup+6+2*j)
+			subtableType := lookupType
+			if lookupType == lookupExtension {
+				subtableType = r.u16(subtable + 2)
+				subtable += r.u32(subtable + 4)
+			}
+			fn(subtableType, subtable)
+		}
+	}
+}
+
+func (r *gsubReader) readSingleSubstitution(subtable int, substitutions map[truetype.Index]truetype.Index) {
+	format := r.u16(subtable)
+	coverage := r.coverage(subtable + r.u16(subtable+2))
+	switch format {
+	case 1:
+		delta := int16(r.u16(subtable + 4))
+		for _, glyph := range coverage {
+			substitutions[glyph] = truetype.Index(uint16(int(glyph) + int(delta)))
+		}
+	case 2:
+		count := r.u16(subtable + 4)
+		for i, glyph := range coverage {
+			if i >= count {
+				break
+			}
+			substitutions[glyph] = truetype.Index(r.u16(subtable + 6 + 2*i))
+		}
+	}
+}
+
+func (r *gsubReader) readLigatureSubstitution(subtable int, ligatures map[truetype.Index][]ligature) {
+	if r.u16(subtable) != 1 {
+		return
+	}
+	coverage := r.coverage(subtable + r.u16(subtable+2))
+	setCount := r.u16(subtable + 4)
+	for i, first := range coverage {
+		if i >= setCount || r.err != nil {
+			break
+		}
+		set := subtable + r.u16(subtable+6+2*i)
+		count := r.u16(set)
+		for j := 0; j < count && r.err == nil; j++ {
+			offset := set + r.u16(set+2+2*j)
+			lig := ligature{glyph: truetype.Index(r.u16(offset))}
+			componentCount := r.u16(offset + 2)
+			for k := 1; k < componentCount; k++ {
+				lig.components = append(lig.components, truetype.Index(r.u16(offset+4+2*(k-1))))
+			}
+			ligatures[first] = append(ligatures[first], lig)
+		}
+	}
+}
+
+// coverage returns the glyphs in a coverage table, in coverage index order
+func (r *gsubReader) coverage(offset int) []truetype.Index {
+	glyphs := []truetype.Index{}
+	switch r.u16(offset) {
+	case 1:
+		count := r.u16(offset + 2)
+		for i := 0; i < count && r.err == nil; i++ {
+			glyphs = append(glyphs, truetype.Index(r.u16(offset+4+2*i)))
+		}
+	case 2:
+		count := r.u16(offset + 2)
+		for i := 0; i < count && r.err == nil; i++ {
+			record := offset + 4 + 6*i
+			for glyph := r.u16(record); glyph <= r.u16(record+2) && r.err == nil; glyph++ {
+				glyphs = append(glyphs, truetype.Index(glyph))
+			}
+		}
+	}
+	return glyphs
+}
@@ -0,0 +1,62 @@
+package glfont
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/golang/freetype/truetype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSingleSubstitutions(t *testing.T) {
+	data, err := ioutil.ReadFile("../gui/packed-fonts/Hack Regular Nerd Font Complete.ttf")
+	require.Nil(t, err)
+	ttf, err := truetype.Parse(data)
+	require.Nil(t, err)
+
+	substitutions, err := singleSubstitutions(data, []string{"sups"})
+	require.Nil(t, err)
+	two := ttf.Index('2')
+	require.Contains(t, substitutions, two)
+	assert.NotEqual(t, two, substitutions[two])
+
+	substitutions, err = singleSubstitutions(data, []string{"ss01"})
+	require.Nil(t, err)
+	assert.Len(t, substitutions, 0)
+}
+
+func TestSingleSubstitutionsWithoutGSUB(t *testing.T) {
+	substitutions, err := singleSubstitutions([]byte{0, 1, 0, 0, 0, 0}, []string{"zero"})
+	require.Nil(t, err)
+	assert.Len(t, substitutions, 0)
+}
+
+func TestRasterizeSubstitutedGlyph(t *testing.T) {
+	data, err := ioutil.ReadFile("../gui/packed-fonts/Hack Regular Nerd Font Complete.ttf")
+	require.Nil(t, err)
+	ttf, err := truetype.Parse(data)
+	require.Nil(t, err)
+
+	f := &Font{ttf: ttf, data: data, scale: 32}
+	substitutions, err := singleSubstitutions(data, []string{"sups"})
+	require.Nil(t, err)
+
+	normal, normalMetrics, err := f.rasterizeGlyph(ttf.Index('2'))
+	require.Nil(t, err)
+	sup, supMetrics, err := f.rasterizeGlyph(substitutions[ttf.Index('2')])
+	require.Nil(t, err)
+
+	// superscripts are smaller, and sit higher above the baseline
+	assert.True(t, sup.Rect.Dy() < normal.Rect.Dy())
+	assert.True(t, supMetrics.bearingV < normalMetrics.bearingV)
+	assert.Equal(t, normalMetrics.advance, supMetrics.advance)
+
+	lit := 0
+	for i := 0; i < len(sup.Pix); i += 4 {
+		if sup.Pix[i] > 0 {
+			lit++
+		}
+	}
+	assert.True(t, lit > 0)
+}
@@ -0,0 +1,88 @@
+package glfont
+
+import (
+	"math"
+
+	"github.com/go-gl/gl/all-core/gl"
+	"github.com/liamg/aminal/config"
+)
+
+// ColourOutput is how colours are written to the window
+type ColourOutput struct {
+	Linear bool       // the window encodes colours to sRGB itself, so blending is done in linear light
+	Gamut  [9]float32 // converts linear sRGB to the primaries of the display, column by column. Zero means sRGB.
+}
+
+// GamutSRGB is for displays which show sRGB as it is
+var GamutSRGB = [9]float32{1, 0, 0, 0, 1, 0, 0, 0, 1}
+
+// GamutDisplayP3 is for wide gamut displays, which would show sRGB colours oversaturated
+var GamutDisplayP3 = [9]float32{
+	0.8224621, 0.0331941, 0.0170827,
+	0.1775380, 0.9668058, 0.0723974,
+	0, 0, 0.9105199,
+}
+
+// ColourOutputShader declares outputColour, which converts an sRGB colour for the window as the ColourOutput passed to
+// Use says. It goes after the #version line of a shader.
+const ColourOutputShader = `
+uniform int linearOutput;
+uniform mat3 gamut;
+
+vec3 toLinear(vec3 c) {
+    return mix(c / 12.92, pow((c + 0.055) / 1.055, vec3(2.4)), step(0.04045, c));
+}
+
+vec3 fromLinear(vec3 c) {
+    return mix(c * 12.92, 1.055 * pow(c, vec3(1.0 / 2.4)) - 0.055, step(0.0031308, c));
+}
+
+vec3 outputColour(vec3 c) {
+    vec3 l = clamp(gamut * toLinear(c), 0.0, 1.0);
+    if (linearOutput == 1) {
+        return l;
+    }
+    return fromLinear(l);
+}
+`
+
+func (output ColourOutput) gamut() [9]float32 {
+	if output.Gamut == [9]float32{} {
+		return GamutSRGB
+	}
+	return output.Gamut
+}
+
+// Use sets the uniforms of a program whose shaders include ColourOutputShader
+func (output ColourOutput) Use(program uint32) {
+	gl.UseProgram(program)
+	linear := int32(0)
+	if output.Linear {
+		linear = 1
+	}
+	gl.Uniform1i(gl.GetUniformLocation(program, gl.Str("linearOutput\x00")), linear)
+	gamut := output.gamut()
+	gl.UniformMatrix3fv(gl.GetUniformLocation(program, gl.Str("gamut\x00")), 1, false, &gamut[0])
+}
+
+// Convert converts an sRGB colour as the shaders do, for colours given to OpenGL outside of them, e.g. to clear with
+func (output ColourOutput) Convert(c [3]float32) [3]float32 {
+	var l [3]float64
+	for i := range c {
+		l[i] = config.ToLinear(float64(c[i]))
+	}
+	gamut := output.gamut()
+	var out [3]float32
+	for row := range out {
+		v := 0.0
+		for col := range l {
+			v += float64(gamut[col*3+row]) * l[col]
+		}
+		v = math.Min(math.Max(v, 0), 1)
+		if !output.Linear {
+			v = config.FromLinear(v)
+		}
+		out[row] = float32(v)
+	}
+	return out
+}
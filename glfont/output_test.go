@@ -0,0 +1,29 @@
+package glfont
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestColourOutputConvert(t *testing.T) {
+	grey := [3]float32{0.5, 0.5, 0.5}
+
+	// without linear blending or a wide gamut, colours go out as they are
+	out := ColourOutput{}.Convert(grey)
+	for i := range out {
+		assert.InDelta(t, 0.5, out[i], 0.001)
+	}
+
+	// the framebuffer encodes linear colours itself
+	out = ColourOutput{Linear: true}.Convert(grey)
+	assert.InDelta(t, 0.214, out[0], 0.001)
+
+	// a wide gamut display needs less saturated values for the same red
+	out = ColourOutput{Gamut: GamutDisplayP3}.Convert([3]float32{1, 0, 0})
+	assert.True(t, out[0] < 1 && out[1] > 0.1, "%v", out)
+	out = ColourOutput{Gamut: GamutDisplayP3}.Convert([3]float32{1, 1, 1})
+	for i := range out {
+		assert.InDelta(t, 1, out[i], 0.001)
+	}
+}
@@ -0,0 +1,277 @@
+package glfont
+
+import (
+	"image"
+	"math"
+
+	"github.com/go-gl/gl/all-core/gl"
+	"github.com/golang/freetype/raster"
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+type Hinting int
+
+const (
+	HintingFull Hinting = iota
+	HintingSlight
+	HintingNone
+)
+
+type Subpixel int
+
+const (
+	SubpixelNone Subpixel = iota
+	SubpixelRGB
+	SubpixelBGR
+)
+
+// Rendering controls how glyphs are rasterized. The zero value gives full hinting and greyscale anti-aliasing.
+type Rendering struct {
+	Hinting  Hinting
+	Gamma    float64 // applied to glyph coverage, 0 or 1 leave it unchanged
+	Contrast float64 // steepens (>1) or softens (<1) glyph edges, 0 or 1 leave them unchanged
+	Subpixel Subpixel
+}
+
+// the FIR filter FreeType uses by default to reduce colour fringes when rendering for LCDs
+var lcdFilter = [5]float64{8 / 256.0, 77 / 256.0, 86 / 256.0, 77 / 256.0, 8 / 256.0}
+
+// SetRendering changes how glyphs are rasterized
+func (f *Font) SetRendering(rendering Rendering) {
+	f.rendering = rendering
+	f.clearCache()
+}
+
+// rasterizeGlyph draws a glyph by index, returning it along with its metrics. Each channel of the image holds
+// coverage - for subpixel rendering they differ, otherwise they are all the same.
+func (f *Font) rasterizeGlyph(index truetype.Index) (*image.RGBA, *character, error) {
+
+	points, ends, advance, err := f.loadGlyph(index)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bounds := fixed.Rectangle26_6{}
+	if len(points) > 0 {
+		bounds = fixed.Rectangle26_6{Min: fixed.Point26_6{X: points[0].X, Y: points[0].Y}, Max: fixed.Point26_6{X: points[0].X, Y: points[0].Y}}
+		for _, p := range points {
+			bounds.Min.X, bounds.Max.X = minInt26_6(bounds.Min.X, p.X), maxInt26_6(bounds.Max.X, p.X)
+			bounds.Min.Y, bounds.Max.Y = minInt26_6(bounds.Min.Y, p.Y), maxInt26_6(bounds.Max.Y, p.Y)
+		}
+	}
+
+	xmin := int(bounds.Min.X) >> 6
+	ymin := int(-bounds.Max.Y) >> 6
+	xmax := int(bounds.Max.X+0x3f) >> 6
+	ymax := int(-bounds.Min.Y+0x3f) >> 6
+	if f.rendering.Subpixel != SubpixelNone {
+		// leave room for the filter to spread coverage into neighbouring pixels
+		xmin--
+		xmax++
+	}
+	if xmax <= xmin || ymax <= ymin {
+		xmax, ymax = xmin+1, ymin+1
+	}
+	width, height := xmax-xmin, ymax-ymin
+
+	// rasterize at three times the horizontal resolution for subpixel rendering, one sample per subpixel
+	samples := 1
+	if f.rendering.Subpixel != SubpixelNone {
+		samples = 3
+	}
+
+	coverage := image.NewAlpha(image.Rect(0, 0, width*samples, height))
+	rasterizer := raster.NewRasterizer(width*samples, height)
+	dx := fixed.Int26_6(-xmin * samples << 6)
+	dy := fixed.Int26_6(-ymin << 6)
+	start := 0
+	for _, end := range ends {
+		contour := make([]truetype.Point, end-start)
+		for i, p := range points[start:end] {
+			contour[i] = truetype.Point{X: p.X * fixed.Int26_6(samples), Y: p.Y, Flags: p.Flags}
+		}
+		drawContour(rasterizer, contour, dx, dy)
+		start = end
+	}
+	rasterizer.Rasterize(raster.NewAlphaSrcPainter(coverage))
+
+	rgba := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var r, g, b float64
+			if samples == 1 {
+				r = float64(coverage.AlphaAt(x, y).A) / 255
+				g, b = r, r
+			} else {
+				r = filteredCoverage(coverage, x*3, y)
+				g = filteredCoverage(coverage, x*3+1, y)
+				b = filteredCoverage(coverage, x*3+2, y)
+				if f.rendering.Subpixel == SubpixelBGR {
+					r, b = b, r
+				}
+			}
+			i := rgba.PixOffset(x, y)
+			rgba.Pix[i] = f.adjust(r)
+			rgba.Pix[i+1] = f.adjust(g)
+			rgba.Pix[i+2] = f.adjust(b)
+			rgba.Pix[i+3] = 255
+		}
+	}
+
+	return rgba, &character{
+		width:    width,
+		height:   height,
+		advance:  int(advance),
+		bearingH: xmin,
+		bearingV: ymax,
+	}, nil
+}
+
+// drawGlyph draws the quad in the vertex buffer, blending each subpixel separately when rendering for LCDs
+func (f *Font) drawGlyph() {
+	mode := f.modeUniform
+	if f.rendering.Subpixel == SubpixelNone {
+		gl.Uniform1i(mode, 0)
+		gl.DrawArrays(gl.TRIANGLES, 0, 24)
+		return
+	}
+
+	// darken the background by the coverage of each subpixel, then add the text colour in the same proportion
+	gl.BlendFuncSeparate(gl.ZERO, gl.ONE_MINUS_SRC_COLOR, gl.ZERO, gl.ONE)
+	gl.Uniform1i(mode, 1)
+	gl.DrawArrays(gl.TRIANGLES, 0, 24)
+	gl.BlendFuncSeparate(gl.ONE, gl.ONE, gl.ZERO, gl.ONE)
+	gl.Uniform1i(mode, 2)
+	gl.DrawArrays(gl.TRIANGLES, 0, 24)
+	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+}
+
+// loadGlyph returns the outline of a glyph scaled to the font size, along with its advance
+func (f *Font) loadGlyph(index truetype.Index) ([]truetype.Point, []int, fixed.Int26_6, error) {
+
+	if f.variations != nil {
+		return f.loadVariedGlyph(index)
+	}
+
+	hinting := font.HintingFull
+	if f.rendering.Hinting == HintingNone {
+		hinting = font.HintingNone
+	}
+
+	glyph := &truetype.GlyphBuf{}
+	if err := glyph.Load(f.ttf, fixed.Int26_6(0.5+f.scale*64), index, hinting); err != nil {
+		return nil, nil, 0, err
+	}
+
+	points := glyph.Points
+	if f.rendering.Hinting == HintingSlight {
+		// only snap vertically, keeping the horizontal shape of the glyph as designed
+		points = make([]truetype.Point, len(glyph.Points))
+		for i, p := range glyph.Points {
+			points[i] = truetype.Point{X: glyph.Unhinted[i].X, Y: p.Y, Flags: p.Flags}
+		}
+	}
+
+	return points, glyph.Ends, glyph.AdvanceWidth, nil
+}
+
+func filteredCoverage(coverage *image.Alpha, x int, y int) float64 {
+	total := 0.0
+	for i, weight := range lcdFilter {
+		total += weight * float64(coverage.AlphaAt(x+i-2, y).A) / 255
+	}
+	return total
+}
+
+// adjust applies gamma and contrast to a coverage value, returning it as a byte
+func (f *Font) adjust(v float64) uint8 {
+	if v <= 0 {
+		return 0
+	}
+	if v >= 1 {
+		return 255
+	}
+	if f.rendering.Gamma > 0 && f.rendering.Gamma != 1 {
+		v = math.Pow(v, 1/f.rendering.Gamma)
+	}
+	if f.rendering.Contrast > 0 && f.rendering.Contrast != 1 {
+		// an s-curve through (0,0), (0.5,0.5) and (1,1)
+		a := math.Pow(v, f.rendering.Contrast)
+		v = a / (a + math.Pow(1-v, f.rendering.Contrast))
+	}
+	return uint8(v*255 + 0.5)
+}
+
+func minInt26_6(a, b fixed.Int26_6) fixed.Int26_6 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt26_6(a, b fixed.Int26_6) fixed.Int26_6 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// drawContour adds a glyph contour to the rasterizer, see freetype's Context.drawContour
+func drawContour(r *raster.Rasterizer, ps []truetype.Point, dx, dy fixed.Int26_6) {
+	if len(ps) == 0 {
+		return
+	}
+
+	// the low bit of each point's flags is whether the point is on the curve
+	start := fixed.Point26_6{X: dx + ps[0].X, Y: dy - ps[0].Y}
+	others := ps[1:]
+	if ps[0].Flags&0x01 == 0 {
+		last := fixed.Point26_6{X: dx + ps[len(ps)-1].X, Y: dy - ps[len(ps)-1].Y}
+		if ps[len(ps)-1].Flags&0x01 != 0 {
+			start = last
+			others = ps[:len(ps)-1]
+		} else {
+			start = fixed.Point26_6{X: (start.X + last.X) / 2, Y: (start.Y + last.Y) / 2}
+			others = ps
+		}
+	}
+
+	r.Start(start)
+	q0, on0 := start, true
+	for _, p := range others {
+		q := fixed.Point26_6{X: dx + p.X, Y: dy - p.Y}
+		on := p.Flags&0x01 != 0
+		if on {
+			if on0 {
+				r.Add1(q)
+			} else {
+				r.Add2(q0, q)
+			}
+		} else if !on0 {
+			mid := fixed.Point26_6{X: (q0.X + q.X) / 2, Y: (q0.Y + q.Y) / 2}
+			r.Add2(q0, mid)
+		}
+		q0, on0 = q, on
+	}
+
+	if on0 {
+		r.Add1(start)
+	} else {
+		r.Add2(q0, start)
+	}
+}
+
+func newGlyphTexture(rgba *image.RGBA) uint32 {
+	var texture uint32
+	gl.GenTextures(1, &texture)
+	gl.BindTexture(gl.TEXTURE_2D, texture)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA, int32(rgba.Rect.Dx()), int32(rgba.Rect.Dy()), 0,
+		gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(rgba.Pix))
+	return texture
+}
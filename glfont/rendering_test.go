@@ -0,0 +1,44 @@
+package glfont
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/golang/freetype/truetype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoverageAdjustment(t *testing.T) {
+	f := &Font{}
+	assert.Equal(t, uint8(0), f.adjust(0))
+	assert.Equal(t, uint8(128), f.adjust(0.5))
+	assert.Equal(t, uint8(255), f.adjust(1))
+
+	f.rendering = Rendering{Gamma: 2.2}
+	assert.True(t, f.adjust(0.5) > 128)
+
+	f.rendering = Rendering{Contrast: 3}
+	assert.True(t, f.adjust(0.25) < 64)
+	assert.True(t, f.adjust(0.75) > 191)
+	assert.Equal(t, uint8(128), f.adjust(0.5))
+}
+
+func TestSubpixelRendering(t *testing.T) {
+	data, err := ioutil.ReadFile("../gui/packed-fonts/Hack Regular Nerd Font Complete.ttf")
+	require.Nil(t, err)
+	ttf, err := truetype.Parse(data)
+	require.Nil(t, err)
+
+	f := &Font{ttf: ttf, scale: 14, rendering: Rendering{Subpixel: SubpixelRGB}}
+	img, _, err := f.rasterizeGlyph(ttf.Index('l'))
+	require.Nil(t, err)
+
+	fringed := false
+	for i := 0; i < len(img.Pix); i += 4 {
+		if img.Pix[i] != img.Pix[i+2] {
+			fringed = true
+		}
+	}
+	assert.True(t, fringed, "subpixel rendering should give each channel its own coverage")
+}
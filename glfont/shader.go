@@ -67,17 +67,26 @@ func compileShader(source string, shaderType uint32) (uint32, error) {
 	return shader, nil
 }
 
-var fragmentFontShader = `#version 150 core
+var fragmentFontShader = "#version 150 core\n" + ColourOutputShader + `
 in vec2 fragTexCoord;
 out vec4 outputColor;
 
 uniform sampler2D tex;
 uniform vec4 textColor;
+uniform int mode; // 0: greyscale, 1: subpixel coverage mask, 2: subpixel colour
 
 void main()
 {    
-    vec4 sampled = vec4(1.0, 1.0, 1.0, texture(tex, fragTexCoord).r);
-    outputColor = textColor * sampled;
+    vec3 coverage = texture(tex, fragTexCoord).rgb;
+    vec3 colour = outputColour(textColor.rgb);
+    if (mode == 1) {
+        outputColor = vec4(coverage * textColor.a, 1.0);
+    } else if (mode == 2) {
+        outputColor = vec4(colour * coverage * textColor.a, 1.0);
+    } else {
+        vec4 sampled = vec4(1.0, 1.0, 1.0, coverage.r);
+        outputColor = vec4(colour, textColor.a) * sampled;
+    }
 }` + "\x00"
 
 var vertexFontShader = `#version 150 core
@@ -27,8 +27,11 @@ func LoadTrueTypeFont(program uint32, r io.Reader, scale float32) (*Font, error)
 	//make Font stuct type
 	f := new(Font)
 	f.scale = scale
+	f.data = data
 	f.characters = map[rune]*character{}
 	f.program = program //set shader program
+	f.modeUniform = gl.GetUniformLocation(program, gl.Str("mode\x00"))
+	f.colorUniform = gl.GetUniformLocation(program, gl.Str("textColor\x00"))
 	// Read the truetype font.
 	f.ttf, err = truetype.Parse(data)
 	if err != nil {
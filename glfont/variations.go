@@ -0,0 +1,529 @@
+package glfont
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// gvar tuple flags
+const (
+	sharedPointNumbers = 0x8000
+	tupleCountMask     = 0x0fff
+	embeddedPeakTuple  = 0x8000
+	intermediateRegion = 0x4000
+	privatePointNumber = 0x2000
+	tupleIndexMask     = 0x0fff
+)
+
+type variationAxis struct {
+	tag string
+	min float64
+	def float64
+	max float64
+}
+
+// variations holds what is needed to instantiate glyphs of a variable font at a particular point in its design space
+type variations struct {
+	coords       []float64 // normalised, one per axis
+	gvar         []byte
+	sharedTuples [][]float64
+	glyf         []byte
+	loca         []byte
+	longLoca     bool
+}
+
+// SetVariations picks the instance of a variable font to render, from axis values e.g. {"wght": 450, "wdth": 100}
+func (f *Font) SetVariations(values map[string]float64) error {
+
+	f.variations = nil
+	defer f.clearCache()
+
+	if len(values) == 0 {
+		return nil
+	}
+
+	axes, err := parseAxes(findTable(f.data, "fvar"))
+	if err != nil {
+		return err
+	}
+	if len(axes) == 0 {
+		return fmt.Errorf("Font is not a variable font")
+	}
+
+	coords := make([]float64, len(axes))
+	for tag, value := range values {
+		found := false
+		for i, axis := range axes {
+			if axis.tag == tag {
+				coords[i] = axis.normalise(value)
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("Font has no '%s' axis", tag)
+		}
+	}
+
+	if avar := findTable(f.data, "avar"); avar != nil {
+		if coords, err = applyAvar(avar, coords); err != nil {
+			return err
+		}
+	}
+
+	gvar := findTable(f.data, "gvar")
+	if gvar == nil {
+		// only TrueType outlines can be varied
+		return fmt.Errorf("Font has no glyph variations")
+	}
+
+	head := findTable(f.data, "head")
+	if len(head) < 52 || len(gvar) < 20 {
+		return fmt.Errorf("Font tables are truncated")
+	}
+
+	v := &variations{
+		coords:   coords,
+		gvar:     gvar,
+		glyf:     findTable(f.data, "glyf"),
+		loca:     findTable(f.data, "loca"),
+		longLoca: binary.BigEndian.Uint16(head[50:]) != 0,
+	}
+
+	r := &gsubReader{data: gvar}
+	axisCount := r.u16(4)
+	if axisCount != len(axes) {
+		return fmt.Errorf("Font gvar table has %d axes but fvar has %d", axisCount, len(axes))
+	}
+	sharedCount := r.u16(6)
+	sharedOffset := r.u32(8)
+	for i := 0; i < sharedCount; i++ {
+		tuple := make([]float64, axisCount)
+		for j := range tuple {
+			tuple[j] = f2dot14(r.u16(sharedOffset + 2*(i*axisCount+j)))
+		}
+		v.sharedTuples = append(v.sharedTuples, tuple)
+	}
+	if r.err != nil {
+		return r.err
+	}
+
+	f.variations = v
+	return nil
+}
+
+func parseAxes(fvar []byte) ([]variationAxis, error) {
+	if fvar == nil {
+		return nil, nil
+	}
+	r := &gsubReader{data: fvar}
+	offset := r.u16(4)
+	count := r.u16(8)
+	size := r.u16(10)
+	axes := []variationAxis{}
+	for i := 0; i < count; i++ {
+		record := offset + i*size
+		axes = append(axes, variationAxis{
+			tag: r.tag(record),
+			min: fixed16(r.u32(record + 4)),
+			def: fixed16(r.u32(record + 8)),
+			max: fixed16(r.u32(record + 12)),
+		})
+	}
+	return axes, r.err
+}
+
+// normalise maps a user value onto -1 (axis minimum), 0 (default) and 1 (maximum)
+func (axis variationAxis) normalise(value float64) float64 {
+	value = math.Max(axis.min, math.Min(axis.max, value))
+	switch {
+	case value < axis.def:
+		return -(axis.def - value) / (axis.def - axis.min)
+	case value > axis.def:
+		return (value - axis.def) / (axis.max - axis.def)
+	}
+	return 0
+}
+
+// applyAvar adjusts normalised coordinates using the font's piecewise linear axis mappings
+func applyAvar(avar []byte, coords []float64) ([]float64, error) {
+	r := &gsubReader{data: avar}
+	if r.u16(6) != len(coords) {
+		return coords, nil
+	}
+	mapped := make([]float64, len(coords))
+	offset := 8
+	for i, coord := range coords {
+		count := r.u16(offset)
+		offset += 2
+		mapped[i] = coord
+		for j := 1; j < count; j++ {
+			fromA, toA := f2dot14(r.u16(offset+4*(j-1))), f2dot14(r.u16(offset+4*(j-1)+2))
+			fromB, toB := f2dot14(r.u16(offset+4*j)), f2dot14(r.u16(offset+4*j+2))
+			if coord >= fromA && coord <= fromB && fromB > fromA {
+				mapped[i] = toA + (coord-fromA)/(fromB-fromA)*(toB-toA)
+				break
+			}
+		}
+		offset += 4 * count
+	}
+	return mapped, r.err
+}
+
+// isSimpleGlyph returns true if the glyph is made of its own contours rather than references to other glyphs
+func (v *variations) isSimpleGlyph(index truetype.Index) bool {
+	var start, end int
+	if v.longLoca {
+		if 4*int(index)+8 > len(v.loca) {
+			return false
+		}
+		start = int(binary.BigEndian.Uint32(v.loca[4*index:]))
+		end = int(binary.BigEndian.Uint32(v.loca[4*index+4:]))
+	} else {
+		if 2*int(index)+4 > len(v.loca) {
+			return false
+		}
+		start = 2 * int(binary.BigEndian.Uint16(v.loca[2*index:]))
+		end = 2 * int(binary.BigEndian.Uint16(v.loca[2*index+2:]))
+	}
+	if end-start < 2 || end > len(v.glyf) {
+		return end == start // empty glyphs have no contours but can still have varied metrics
+	}
+	return int16(binary.BigEndian.Uint16(v.glyf[start:])) >= 0
+}
+
+// glyphDeltas returns the x and y offsets for each point of a glyph (plus the four phantom points) at the chosen
+// instance, in font units. ends are the indexes after the last point of each contour, used to infer deltas for points
+// a variation doesn't mention.
+func (v *variations) glyphDeltas(index truetype.Index, original []truetype.Point, ends []int) ([]float64, []float64, error) {
+
+	pointCount := len(original)
+	dx := make([]float64, pointCount)
+	dy := make([]float64, pointCount)
+
+	r := &gsubReader{data: v.gvar}
+	axisCount := r.u16(4)
+	glyphCount := r.u16(12)
+	longOffsets := r.u16(14)&1 != 0
+	arrayOffset := r.u32(16)
+	if int(index) >= glyphCount {
+		return dx, dy, r.err
+	}
+
+	var start, end int
+	if longOffsets {
+		start, end = r.u32(20+4*int(index)), r.u32(20+4*int(index)+4)
+	} else {
+		start, end = 2*r.u16(20+2*int(index)), 2*r.u16(20+2*int(index)+2)
+	}
+	if r.err != nil || end <= start {
+		return dx, dy, r.err
+	}
+	data := arrayOffset + start
+
+	tupleCount := r.u16(data)
+	serialized := data + r.u16(data+2)
+	header := data + 4
+
+	var sharedPoints []int
+	if tupleCount&sharedPointNumbers != 0 {
+		sharedPoints, serialized = r.packedPoints(serialized)
+	}
+
+	for t := 0; t < tupleCount&tupleCountMask && r.err == nil; t++ {
+		size := r.u16(header)
+		tupleIndex := r.u16(header + 2)
+		header += 4
+
+		var peak []float64
+		if tupleIndex&embeddedPeakTuple != 0 {
+			peak = r.tuple(header, axisCount)
+			header += 2 * axisCount
+		} else if i := tupleIndex & tupleIndexMask; i < len(v.sharedTuples) {
+			peak = v.sharedTuples[i]
+		} else {
+			return dx, dy, fmt.Errorf("Glyph variation refers to missing shared tuple %d", i)
+		}
+
+		var startTuple, endTuple []float64
+		if tupleIndex&intermediateRegion != 0 {
+			startTuple = r.tuple(header, axisCount)
+			endTuple = r.tuple(header+2*axisCount, axisCount)
+			header += 4 * axisCount
+		}
+
+		next := serialized + size
+		scalar := tupleScalar(v.coords, peak, startTuple, endTuple)
+		if scalar == 0 {
+			serialized = next
+			continue
+		}
+
+		points := sharedPoints
+		if tupleIndex&privatePointNumber != 0 {
+			points, serialized = r.packedPoints(serialized)
+		}
+		count := len(points)
+		if points == nil {
+			count = pointCount
+		}
+
+		var xs, ys []float64
+		xs, serialized = r.packedDeltas(serialized, count)
+		ys, _ = r.packedDeltas(serialized, count)
+		serialized = next
+
+		if points == nil {
+			for i := 0; i < count && i < pointCount; i++ {
+				dx[i] += scalar * xs[i]
+				dy[i] += scalar * ys[i]
+			}
+			continue
+		}
+
+		tx := make([]float64, pointCount)
+		ty := make([]float64, pointCount)
+		touched := make([]bool, pointCount)
+		for i, point := range points {
+			if point < pointCount {
+				tx[point], ty[point] = xs[i], ys[i]
+				touched[point] = true
+			}
+		}
+		interpolateUntouched(original, ends, tx, ty, touched)
+		for i := range dx {
+			dx[i] += scalar * tx[i]
+			dy[i] += scalar * ty[i]
+		}
+	}
+
+	return dx, dy, r.err
+}
+
+func tupleScalar(coords []float64, peak []float64, start []float64, end []float64) float64 {
+	scalar := 1.0
+	for i, coord := range coords {
+		if i >= len(peak) || peak[i] == 0 || coord == peak[i] {
+			continue
+		}
+		if coord == 0 {
+			return 0
+		}
+		if start == nil {
+			if coord < math.Min(0, peak[i]) || coord > math.Max(0, peak[i]) {
+				return 0
+			}
+			scalar *= coord / peak[i]
+			continue
+		}
+		if coord < start[i] || coord > end[i] {
+			return 0
+		}
+		if coord < peak[i] {
+			scalar *= (coord - start[i]) / (peak[i] - start[i])
+		} else {
+			scalar *= (end[i] - coord) / (end[i] - peak[i])
+		}
+	}
+	return scalar
+}
+
+// interpolateUntouched infers deltas for the points of each contour which a variation doesn't mention, from the
+// nearest mentioned points either side of them (the "IUP" step)
+func interpolateUntouched(original []truetype.Point, ends []int, dx []float64, dy []float64, touched []bool) {
+	start := 0
+	for _, end := range ends {
+		first := -1
+		for i := start; i < end; i++ {
+			if touched[i] {
+				first = i
+				break
+			}
+		}
+		if first == -1 {
+			start = end
+			continue
+		}
+
+		ref := first
+		for {
+			next := ref + 1
+			if next == end {
+				next = start
+			}
+			for !touched[next] {
+				next++
+				if next == end {
+					next = start
+				}
+			}
+			for p := ref + 1; ; p++ {
+				if p == end {
+					p = start
+				}
+				if p == next {
+					break
+				}
+				dx[p] = interpolateDelta(float64(original[p].X), float64(original[ref].X), float64(original[next].X), dx[ref], dx[next])
+				dy[p] = interpolateDelta(float64(original[p].Y), float64(original[ref].Y), float64(original[next].Y), dy[ref], dy[next])
+			}
+			ref = next
+			if ref == first {
+				break
+			}
+		}
+		start = end
+	}
+}
+
+func interpolateDelta(p, a, b, da, db float64) float64 {
+	if a == b {
+		if da == db {
+			return da
+		}
+		return 0
+	}
+	if a > b {
+		a, b, da, db = b, a, db, da
+	}
+	switch {
+	case p <= a:
+		return da
+	case p >= b:
+		return db
+	}
+	return da + (p-a)/(b-a)*(db-da)
+}
+
+func (r *gsubReader) tuple(offset int, axisCount int) []float64 {
+	tuple := make([]float64, axisCount)
+	for i := range tuple {
+		tuple[i] = f2dot14(r.u16(offset + 2*i))
+	}
+	return tuple
+}
+
+func (r *gsubReader) u8(offset int) int {
+	if r.err != nil {
+		return 0
+	}
+	if offset < 0 || offset >= len(r.data) {
+		r.err = fmt.Errorf("Font table is truncated")
+		return 0
+	}
+	return int(r.data[offset])
+}
+
+// packedPoints reads a list of point numbers, returning nil if the data refers to all points
+func (r *gsubReader) packedPoints(offset int) ([]int, int) {
+	count := r.u8(offset)
+	offset++
+	if count == 0 {
+		return nil, offset
+	}
+	if count&0x80 != 0 {
+		count = (count&0x7f)<<8 | r.u8(offset)
+		offset++
+	}
+	points := make([]int, 0, count)
+	point := 0
+	for len(points) < count && r.err == nil {
+		control := r.u8(offset)
+		offset++
+		run := control&0x7f + 1
+		for i := 0; i < run && len(points) < count; i++ {
+			if control&0x80 != 0 {
+				point += r.u16(offset)
+				offset += 2
+			} else {
+				point += r.u8(offset)
+				offset++
+			}
+			points = append(points, point)
+		}
+	}
+	return points, offset
+}
+
+func (r *gsubReader) packedDeltas(offset int, count int) ([]float64, int) {
+	deltas := make([]float64, 0, count)
+	for len(deltas) < count && r.err == nil {
+		control := r.u8(offset)
+		offset++
+		run := control&0x3f + 1
+		for i := 0; i < run && len(deltas) < count; i++ {
+			switch {
+			case control&0x80 != 0:
+				deltas = append(deltas, 0)
+			case control&0x40 != 0:
+				deltas = append(deltas, float64(int16(r.u16(offset))))
+				offset += 2
+			default:
+				deltas = append(deltas, float64(int8(r.u8(offset))))
+				offset++
+			}
+		}
+	}
+	for len(deltas) < count {
+		deltas = append(deltas, 0)
+	}
+	return deltas, offset
+}
+
+// loadVariedGlyph loads the outline of a glyph at the chosen instance of a variable font, scaled to the font size.
+// Hinting instructions are written for the default instance, so varied glyphs are never hinted.
+func (f *Font) loadVariedGlyph(index truetype.Index) ([]truetype.Point, []int, fixed.Int26_6, error) {
+
+	unitsPerEm := fixed.Int26_6(f.ttf.FUnitsPerEm())
+	glyph := &truetype.GlyphBuf{}
+	if err := glyph.Load(f.ttf, unitsPerEm, index, font.HintingNone); err != nil {
+		return nil, nil, 0, err
+	}
+
+	// loading at a scale of one unit per em leaves the points in font units
+	points := append([]truetype.Point{}, glyph.Points...)
+	advance := float64(glyph.AdvanceWidth)
+	shift := 0.0
+
+	if f.variations.isSimpleGlyph(index) {
+		hmetric := f.ttf.HMetric(unitsPerEm, index)
+		// the phantom points give the glyph's horizontal origin and advance
+		withPhantoms := append(points,
+			truetype.Point{X: 0},
+			truetype.Point{X: hmetric.AdvanceWidth},
+			truetype.Point{},
+			truetype.Point{},
+		)
+		dx, dy, err := f.variations.glyphDeltas(index, withPhantoms, glyph.Ends)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		n := len(points)
+		shift = dx[n]
+		advance += dx[n+1] - dx[n]
+		for i := range points {
+			points[i].X += fixed.Int26_6(math.Round(dx[i] - shift))
+			points[i].Y += fixed.Int26_6(math.Round(dy[i]))
+		}
+	}
+
+	scale := float64(f.scale*64) / float64(unitsPerEm)
+	for i := range points {
+		points[i].X = fixed.Int26_6(math.Round(float64(points[i].X) * scale))
+		points[i].Y = fixed.Int26_6(math.Round(float64(points[i].Y) * scale))
+	}
+
+	return points, glyph.Ends, fixed.Int26_6(math.Round(advance * scale)), nil
+}
+
+func f2dot14(v int) float64 {
+	return float64(int16(v)) / (1 << 14)
+}
+
+func fixed16(v int) float64 {
+	return float64(int32(v)) / (1 << 16)
+}
@@ -0,0 +1,121 @@
+package glfont
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/golang/freetype/truetype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAxisNormalisation(t *testing.T) {
+	axis := variationAxis{tag: "wght", min: 100, def: 400, max: 900}
+	assert.Equal(t, 0.0, axis.normalise(400))
+	assert.Equal(t, 1.0, axis.normalise(900))
+	assert.Equal(t, 1.0, axis.normalise(1000))
+	assert.Equal(t, -0.5, axis.normalise(250))
+	assert.Equal(t, 0.5, axis.normalise(650))
+}
+
+func TestAvarMapping(t *testing.T) {
+	avar := []byte{
+		0, 1, 0, 0, // version
+		0, 0, // reserved
+		0, 1, // axis count
+		0, 4, // map count
+		0xc0, 0x00, 0xc0, 0x00, // -1 -> -1
+		0x00, 0x00, 0x00, 0x00, // 0 -> 0
+		0x20, 0x00, 0x30, 0x00, // 0.5 -> 0.75
+		0x40, 0x00, 0x40, 0x00, // 1 -> 1
+	}
+	coords, err := applyAvar(avar, []float64{0.25})
+	require.Nil(t, err)
+	assert.Equal(t, []float64{0.375}, coords)
+
+	coords, err = applyAvar(avar, []float64{0.75})
+	require.Nil(t, err)
+	assert.Equal(t, []float64{0.875}, coords)
+}
+
+func TestTupleScalar(t *testing.T) {
+	assert.Equal(t, 0.5, tupleScalar([]float64{0.5}, []float64{1}, nil, nil))
+	assert.Equal(t, 0.0, tupleScalar([]float64{-0.5}, []float64{1}, nil, nil))
+	assert.Equal(t, 0.0, tupleScalar([]float64{0}, []float64{1}, nil, nil))
+	assert.Equal(t, 1.0, tupleScalar([]float64{0.3}, []float64{0}, nil, nil))
+	assert.Equal(t, 0.5, tupleScalar([]float64{0.75}, []float64{0.5}, []float64{0}, []float64{1}))
+	assert.Equal(t, 0.0, tupleScalar([]float64{0.75}, []float64{0.25}, []float64{0}, []float64{0.5}))
+}
+
+func TestPackedPointsAndDeltas(t *testing.T) {
+	r := &gsubReader{data: []byte{
+		3,       // three points
+		0x02,    // a run of three byte sized point numbers
+		1, 2, 3, // points 1, 3 and 6
+		0x81, // a run of two zero deltas
+		0x40, // a run of one word sized delta
+		0xff, 0x9c,
+	}}
+	points, offset := r.packedPoints(0)
+	assert.Equal(t, []int{1, 3, 6}, points)
+	deltas, _ := r.packedDeltas(offset, 3)
+	assert.Equal(t, []float64{0, 0, -100}, deltas)
+	require.Nil(t, r.err)
+
+	r = &gsubReader{data: []byte{0}}
+	points, _ = r.packedPoints(0)
+	assert.Nil(t, points, "zero means every point")
+}
+
+func TestInterpolateUntouched(t *testing.T) {
+	square := []truetype.Point{{X: 0, Y: 0}, {X: 100, Y: 0}, {X: 100, Y: 100}, {X: 0, Y: 100}}
+	dx := []float64{-10, 0, 10, 0}
+	dy := []float64{0, 0, 20, 0}
+	touched := []bool{true, false, true, false}
+
+	interpolateUntouched(square, []int{4}, dx, dy, touched)
+
+	assert.Equal(t, []float64{-10, 10, 10, -10}, dx)
+	assert.Equal(t, []float64{0, 0, 20, 20}, dy)
+}
+
+func TestGlyphDeltas(t *testing.T) {
+	gvar := []byte{
+		0, 1, 0, 0, // version
+		0, 1, // axis count
+		0, 0, // shared tuple count
+		0, 0, 0, 0, // shared tuples offset
+		0, 1, // glyph count
+		0, 0, // flags: short offsets
+		0, 0, 0, 24, // glyph variation data array offset
+		0, 0, 0, 9, // offsets of glyph 0, divided by two
+		// glyph 0
+		0, 1, // one tuple
+		0, 10, // serialised data offset
+		0, 7, // serialised data size
+		0x80, 0x00, // embedded peak
+		0x40, 0x00, // peak of 1
+		// all points, deltas for x then y
+		0x04, 10, 20, 30, 40, 0, // x deltas for the point and the four phantom points
+		0x84, // y deltas are zero
+		0,    // padding
+	}
+	v := &variations{coords: []float64{0.5}, gvar: gvar}
+
+	points := []truetype.Point{{X: 50, Y: 50}, {}, {X: 600}, {}, {}}
+	dx, dy, err := v.glyphDeltas(0, points, []int{1})
+	require.Nil(t, err)
+	assert.Equal(t, []float64{5, 10, 15, 20, 0}, dx)
+	assert.Equal(t, []float64{0, 0, 0, 0, 0}, dy)
+}
+
+func TestSetVariationsOnStaticFont(t *testing.T) {
+	data, err := ioutil.ReadFile("../gui/packed-fonts/Hack Regular Nerd Font Complete.ttf")
+	require.Nil(t, err)
+	ttf, err := truetype.Parse(data)
+	require.Nil(t, err)
+
+	f := &Font{ttf: ttf, data: data}
+	assert.NotNil(t, f.SetVariations(map[string]float64{"wght": 450}))
+	assert.Nil(t, f.SetVariations(nil))
+}
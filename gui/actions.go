@@ -6,19 +6,88 @@ import (
 	"strings"
 
 	"github.com/liamg/aminal/config"
+	"github.com/liamg/aminal/layout"
 )
 
 var actionMap = map[config.UserAction]func(gui *GUI){
-	config.ActionCopy:        actionCopy,
-	config.ActionPaste:       actionPaste,
-	config.ActionToggleDebug: actionToggleDebug,
-	config.ActionSearch:      actionSearchSelection,
-	config.ActionToggleSlomo: actionToggleSlomo,
-	config.ActionReportBug:   actionReportBug,
+	config.ActionCopy:               actionCopy,
+	config.ActionCopyWithoutPrompts: actionCopyWithoutPrompts,
+	config.ActionExpandSelection:    actionExpandSelection,
+	config.ActionSmartSelect:        actionSmartSelect,
+	config.ActionNextTheme:          actionNextTheme,
+	config.ActionPaste:              actionPaste,
+	config.ActionPasteSingleLine:    actionPasteSingleLine,
+	config.ActionToggleDebug:        actionToggleDebug,
+	config.ActionSearch:             actionSearchSelection,
+	config.ActionToggleSlomo:        actionToggleSlomo,
+	config.ActionReportBug:          actionReportBug,
+	config.ActionShowHelp:           actionShowHelp,
+	config.ActionFind:               actionFind,
+	config.ActionFindPrevious:       actionFindPrevious,
+	config.ActionFindNext:           actionFindNext,
+	config.ActionScrollPageUp:       actionScrollPageUp,
+	config.ActionScrollPageDown:     actionScrollPageDown,
+	config.ActionPipeSelection:      actionPipeSelection,
+	config.ActionPipeScreen:         actionPipeScreen,
+	config.ActionPipeScrollback:     actionPipeScrollback,
+	config.ActionHighlightSelection: actionHighlightSelection,
+	config.ActionEditScrollback:     actionEditScrollback,
+	config.ActionEditAltScreen:      actionEditAltScreen,
+	config.ActionInsertMark:         actionInsertMark,
+	config.ActionClearScrollback:    actionClearScrollback,
+	config.ActionClearAll:           actionClearAll,
+	config.ActionClearToMark:        actionClearToMark,
+	config.ActionToggleReadOnly:     actionToggleReadOnly,
+	config.ActionTogglePause:        actionTogglePause,
+	config.ActionOpenConfig:         actionOpenConfig,
+	config.ActionNewWindow:          actionNewWindow,
+	config.ActionIncreaseFontSize:   actionIncreaseFontSize,
+	config.ActionDecreaseFontSize:   actionDecreaseFontSize,
+	config.ActionResetFontSize:      actionResetFontSize,
+	config.ActionSplitRight:         actionSplitRight,
+	config.ActionSplitDown:          actionSplitDown,
+	config.ActionClosePane:          actionClosePane,
+	config.ActionNextPane:           actionNextPane,
+	config.ActionPaneWider:          actionPaneWider,
+	config.ActionPaneNarrower:       actionPaneNarrower,
+	config.ActionPaneTaller:         actionPaneTaller,
+	config.ActionPaneShorter:        actionPaneShorter,
+	config.ActionZoomPane:           actionZoomPane,
+	config.ActionSaveLayout:         actionSaveLayout,
+	config.ActionResizePanes:        actionResizePanes,
+}
+
+func init() {
+	// the palette lists the actions in the map, so it can't be in the map's own initialiser
+	actionMap[config.ActionCommandPalette] = actionCommandPalette
+}
+
+// copyText puts text on the clipboard, cleaned up as the clipboard config says
+func (gui *GUI) copyText(text string) {
+	gui.window.SetClipboardString(gui.config.Clipboard.Sanitise(text))
 }
 
 func actionCopy(gui *GUI) {
-	gui.window.SetClipboardString(gui.terminal.ActiveBuffer().GetSelectedText())
+	gui.copyText(gui.terminal.ActiveBuffer().GetSelectedText())
+}
+
+func actionCopyWithoutPrompts(gui *GUI) {
+	gui.copyText(gui.terminal.ActiveBuffer().GetSelectedTextWithoutPrompts())
+}
+
+func actionExpandSelection(gui *GUI) {
+	gui.terminal.ActiveBuffer().ExpandSelection()
+}
+
+func actionSmartSelect(gui *GUI) {
+	px, py := gui.window.GetCursorPos()
+	scale := float64(gui.scale())
+	col, row := gui.clampedCell(px/scale, py/scale)
+	gui.terminal.ActiveBuffer().SmartSelectAtPosition(col, row)
+}
+
+func actionNextTheme(gui *GUI) {
+	gui.setTheme(gui.config.NextTheme(gui.theme))
 }
 
 func actionPaste(gui *GUI) {
@@ -27,6 +96,12 @@ func actionPaste(gui *GUI) {
 	}
 }
 
+func actionPasteSingleLine(gui *GUI) {
+	if s, err := gui.window.GetClipboardString(); err == nil {
+		_ = gui.terminal.Paste([]byte(config.JoinLines(s)))
+	}
+}
+
 func actionToggleDebug(gui *GUI) {
 	gui.showDebugInfo = !gui.showDebugInfo
 	gui.terminal.SetDirty()
@@ -43,6 +118,183 @@ func actionToggleSlomo(gui *GUI) {
 	gui.config.Slomo = !gui.config.Slomo
 }
 
+func actionToggleReadOnly(gui *GUI) {
+	gui.config.ReadOnly = !gui.config.ReadOnly
+	gui.window.SetTitle(gui.windowTitle())
+	gui.terminal.SetDirty()
+}
+
+func actionTogglePause(gui *GUI) {
+	gui.terminal.SetOutputPaused(!gui.terminal.OutputPaused())
+	gui.window.SetTitle(gui.windowTitle())
+}
+
 func actionReportBug(gui *GUI) {
 	gui.launchTarget("https://github.com/liamg/aminal/issues/new/choose")
 }
+
+func actionShowHelp(gui *GUI) {
+	if _, ok := gui.overlay.(*helpOverlay); ok {
+		gui.setOverlay(nil)
+		return
+	}
+	gui.setOverlay(newHelpOverlay(gui.keyboardShortcuts))
+}
+
+func actionCommandPalette(gui *GUI) {
+	if _, ok := gui.overlay.(*commandPalette); ok {
+		gui.setOverlay(nil)
+		return
+	}
+	gui.setOverlay(newCommandPalette(gui))
+}
+
+func actionOpenConfig(gui *GUI) {
+	if gui.config.Path == "" {
+		gui.logger.Errorf("No config file is in use")
+		return
+	}
+	if err := gui.openInEditor(gui.config.Path, false); err != nil {
+		gui.logger.Errorf("Failed to open config file: %s", err)
+	}
+}
+
+func actionNewWindow(gui *GUI) {
+	if err := gui.newWindow(); err != nil {
+		gui.logger.Errorf("Failed to open a new window: %s", err)
+	}
+}
+
+func actionFind(gui *GUI) {
+	if _, ok := gui.overlay.(*searchOverlay); ok {
+		gui.setOverlay(nil)
+		return
+	}
+	gui.lastSearch = newSearchOverlay()
+	gui.setOverlay(gui.lastSearch)
+}
+
+func actionFindPrevious(gui *GUI) {
+	gui.findAgain(-1)
+}
+
+func actionFindNext(gui *GUI) {
+	gui.findAgain(1)
+}
+
+func actionScrollPageUp(gui *GUI) {
+	gui.terminal.ScrollPageUp()
+}
+
+func actionScrollPageDown(gui *GUI) {
+	gui.terminal.ScrollPageDown()
+}
+
+func actionPipeSelection(gui *GUI) {
+	gui.pipe(gui.terminal.ActiveBuffer().GetSelectedText())
+}
+
+func actionPipeScreen(gui *GUI) {
+	gui.pipe(gui.terminal.ActiveBuffer().GetVisibleText())
+}
+
+func actionPipeScrollback(gui *GUI) {
+	gui.pipe(gui.terminal.ActiveBuffer().GetAllText())
+}
+
+func actionHighlightSelection(gui *GUI) {
+	gui.highlight(gui.terminal.ActiveBuffer().GetSelectedText())
+}
+
+func actionEditScrollback(gui *GUI) {
+	if err := gui.editScrollback(); err != nil {
+		gui.logger.Errorf("Failed to open scrollback in editor: %s", err)
+	}
+}
+
+func actionEditAltScreen(gui *GUI) {
+	if err := gui.editAltScreenHistory(); err != nil {
+		gui.logger.Errorf("Failed to open alternate screen history in editor: %s", err)
+	}
+}
+
+func actionInsertMark(gui *GUI) {
+	gui.terminal.ActiveBuffer().InsertMark()
+}
+
+func actionClearScrollback(gui *GUI) {
+	gui.terminal.ActiveBuffer().ClearScrollback()
+}
+
+func actionClearAll(gui *GUI) {
+	gui.terminal.ActiveBuffer().ClearAll()
+}
+
+func actionClearToMark(gui *GUI) {
+	gui.terminal.ActiveBuffer().ClearToPreviousMark()
+}
+
+func actionIncreaseFontSize(gui *GUI) {
+	gui.zoom(1)
+}
+
+func actionDecreaseFontSize(gui *GUI) {
+	gui.zoom(-1)
+}
+
+func actionResetFontSize(gui *GUI) {
+	gui.zoom(float64(defaultFontScale - gui.fontScale))
+}
+
+func actionSplitRight(gui *GUI) {
+	if err := gui.splitPane(layout.Horizontal, ""); err != nil {
+		gui.logger.Errorf("Failed to split pane: %s", err)
+	}
+}
+
+func actionSplitDown(gui *GUI) {
+	if err := gui.splitPane(layout.Vertical, ""); err != nil {
+		gui.logger.Errorf("Failed to split pane: %s", err)
+	}
+}
+
+func actionClosePane(gui *GUI) {
+	// closing the window is left to the window's own close button, which asks before closing over a running program
+	if len(gui.panes) > 1 {
+		gui.closePane(gui.pane)
+	}
+}
+
+func actionNextPane(gui *GUI) {
+	gui.focusNextPane()
+}
+
+func actionPaneWider(gui *GUI) {
+	gui.resizePane(layout.Horizontal, 1)
+}
+
+func actionPaneNarrower(gui *GUI) {
+	gui.resizePane(layout.Horizontal, -1)
+}
+
+func actionPaneTaller(gui *GUI) {
+	gui.resizePane(layout.Vertical, 1)
+}
+
+func actionPaneShorter(gui *GUI) {
+	gui.resizePane(layout.Vertical, -1)
+}
+
+func actionZoomPane(gui *GUI) {
+	gui.toggleZoom()
+}
+
+func actionSaveLayout(gui *GUI) {
+	gui.setOverlay(&layoutPrompt{})
+}
+
+func actionResizePanes(gui *GUI) {
+	if len(gui.panes) > 1 {
+		gui.setOverlay(&resizeMode{})
+	}
+}
@@ -0,0 +1,19 @@
+package gui
+
+import "time"
+
+// blinkDisabledAlpha is how blinking text is drawn when blinking is turned off, so it still stands out a little
+const blinkDisabledAlpha = 0.5
+
+// blinkVisible returns true if blinking text is shown at the moment. It also makes sure there is a redraw when blinking
+// text next appears or disappears, as nothing else may change by then.
+func (gui *GUI) blinkVisible() bool {
+	interval := time.Duration(gui.config.Graphics.BlinkInterval) * time.Millisecond
+	phase := time.Duration(time.Now().UnixNano()) / interval
+	next := time.Unix(0, int64((phase+1)*interval))
+	if !next.Equal(gui.blinkRedraw) {
+		gui.blinkRedraw = next
+		time.AfterFunc(time.Until(next), gui.terminal.SetDirty)
+	}
+	return phase%2 == 0
+}
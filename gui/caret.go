@@ -0,0 +1,37 @@
+package gui
+
+// caretRect is the area of the window the cursor takes up, in pixels from the top left
+type caretRect struct {
+	x      int
+	y      int
+	width  int
+	height int
+}
+
+// updateCaret tells the OS where the cursor is when it moves, so IMEs open their candidate windows next to it and
+// screen magnifiers follow it. It is called after the panes are drawn, while the renderer is set up for the focused one.
+func (gui *GUI) updateCaret() {
+	t := gui.terminal
+	r := gui.renderer
+	col := uint(t.GetLogicalCursorX())
+	row := uint(t.GetLogicalCursorY()) + t.GetScrollOffset()
+	if row >= uint(t.ActiveBuffer().ViewHeight()) {
+		// scrolled out of view, so left where it was
+		return
+	}
+
+	caret := caretRect{
+		x:      r.areaX + int(float32(col)*r.cellWidth),
+		y:      r.areaY + int(float32(row)*r.cellHeight),
+		width:  int(r.cellWidth),
+		height: int(r.cellHeight),
+	}
+	if caret == gui.caret {
+		return
+	}
+	gui.caret = caret
+
+	if err := reportCaret(gui.window, caret); err != nil {
+		gui.logger.Debugf("Failed to report the cursor position: %s", err)
+	}
+}
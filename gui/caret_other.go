@@ -0,0 +1,13 @@
+//go:build !windows
+// +build !windows
+
+package gui
+
+import "github.com/go-gl/glfw/v3.2/glfw"
+
+// reportCaret does nothing outside Windows. GLFW 3.2 creates the X11 input context and the Cocoa text input client
+// itself and doesn't hand them out, so there is nothing to set XNSpotLocation or firstRectForCharacterRange on. Until
+// GLFW gains a way to position the IME, this is a known limit, as the README says.
+func reportCaret(window *glfw.Window, caret caretRect) error {
+	return nil
+}
@@ -0,0 +1,60 @@
+//go:build windows
+// +build windows
+
+package gui
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+)
+
+var (
+	user32 = syscall.NewLazyDLL("user32.dll")
+	imm32  = syscall.NewLazyDLL("imm32.dll")
+
+	procCreateCaret             = user32.NewProc("CreateCaret")
+	procSetCaretPos             = user32.NewProc("SetCaretPos")
+	procImmGetContext           = imm32.NewProc("ImmGetContext")
+	procImmReleaseContext       = imm32.NewProc("ImmReleaseContext")
+	procImmSetCompositionWindow = imm32.NewProc("ImmSetCompositionWindow")
+)
+
+// cfsPoint positions the IME composition window at a point, rather than letting it pick
+const cfsPoint = 0x0002
+
+// compositionForm is the Win32 COMPOSITIONFORM struct
+type compositionForm struct {
+	style uint32
+	x     int32
+	y     int32
+	area  [4]int32
+}
+
+// reportCaret moves the system caret and the IME composition window to the cursor. The system caret is never shown, as
+// the cursor is drawn by the terminal, but the magnifier and screen readers follow it all the same. It is created
+// again each time, as its size changes with the font, and creating it replaces the one before.
+func reportCaret(window *glfw.Window, caret caretRect) error {
+	hwnd := uintptr(unsafe.Pointer(window.GetWin32Window()))
+
+	if ok, _, err := procCreateCaret.Call(hwnd, 0, uintptr(caret.width), uintptr(caret.height)); ok == 0 {
+		return fmt.Errorf("Failed to create caret: %s", err)
+	}
+	if ok, _, err := procSetCaretPos.Call(uintptr(caret.x), uintptr(caret.y)); ok == 0 {
+		return fmt.Errorf("Failed to set caret position: %s", err)
+	}
+
+	imc, _, _ := procImmGetContext.Call(hwnd)
+	if imc == 0 {
+		// there is no IME for the window's keyboard layout
+		return nil
+	}
+	defer procImmReleaseContext.Call(hwnd, imc)
+	form := compositionForm{style: cfsPoint, x: int32(caret.x), y: int32(caret.y)}
+	if ok, _, err := procImmSetCompositionWindow.Call(imc, uintptr(unsafe.Pointer(&form))); ok == 0 {
+		return fmt.Errorf("Failed to position IME window: %s", err)
+	}
+	return nil
+}
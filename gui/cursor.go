@@ -0,0 +1,124 @@
+package gui
+
+import (
+	"math"
+	"time"
+
+	"github.com/liamg/aminal/config"
+	"github.com/liamg/aminal/terminal"
+)
+
+// the number of ghost cursors drawn along the path of a trail
+const trailLength = 6
+
+// cursorAnimation tracks the cursor's movement between cells, in pixels
+type cursorAnimation struct {
+	fromX float32
+	fromY float32
+	toX   float32
+	toY   float32
+	start time.Time
+}
+
+// renderAnimatedCursor draws the cursor for the cell it is in, part way through moving there from the cell it was in
+// before. It is drawn after cell backgrounds and before text, like the static cursor.
+func (gui *GUI) renderAnimatedCursor(col uint, row uint) {
+
+	r := gui.renderer
+	a := &gui.cursorAnimation
+	x := float32(r.areaX) + float32(col)*r.cellWidth
+	y := float32(r.areaY) + float32(row)*r.cellHeight
+
+	if a.start.IsZero() {
+		*a = cursorAnimation{fromX: x, fromY: y, toX: x, toY: y, start: time.Now()}
+	} else if x != a.toX || y != a.toY {
+		// start from wherever the cursor is drawn now, so changing direction mid-move doesn't jump
+		currentX, currentY, _ := a.position(gui.cursorDuration())
+		*a = cursorAnimation{fromX: currentX, fromY: currentY, toX: x, toY: y, start: time.Now()}
+	}
+
+	currentX, currentY, progress := a.position(gui.cursorDuration())
+	if !gui.config.Cursor.Smooth {
+		currentX, currentY = x, y
+	}
+
+	colour := gui.renderer.CursorColour()
+
+	if gui.config.Cursor.Trail && progress < 1 {
+		background := gui.colours.Background
+		for i := 0; i < trailLength; i++ {
+			along := float32(i) / trailLength
+			ghostX := a.fromX + (currentX-a.fromX)*along
+			ghostY := a.fromY + (currentY-a.fromY)*along
+			// ghosts fade towards the background as the animation runs, older ones faster
+			fade := (1 - float32(progress)) * (along*0.5 + 0.25)
+			r.DrawRect(gui.cursorRect(ghostX, ghostY, false, mixColour(background, colour, fade)))
+		}
+	}
+
+	r.DrawRect(gui.cursorRect(currentX, currentY, false, colour))
+
+	if progress < 1 {
+		// keep drawing frames until the animation has finished
+		gui.terminal.SetDirty()
+	}
+}
+
+// renderCursorShape draws an underline or bar cursor in the cell it is in, on top of the cell's background. A block
+// cursor is drawn as the cell's background instead.
+func (gui *GUI) renderCursorShape(col uint, row uint, wide bool) {
+	r := gui.renderer
+	x := float32(r.areaX) + float32(col)*r.cellWidth
+	y := float32(r.areaY) + float32(row)*r.cellHeight
+	r.DrawRect(gui.cursorRect(x, y, wide, r.CursorColour()))
+}
+
+// cursorRect returns the part of the cell at x,y the cursor covers in its shape, and its colour, to draw it with. A wide
+// cell's cursor covers both halves of it.
+func (gui *GUI) cursorRect(x float32, y float32, wide bool, colour config.Colour) (float32, float32, float32, float32, config.Colour) {
+	r := gui.renderer
+	width := r.cellWidth
+	if wide {
+		width *= 2
+	}
+	thickness := float32(math.Max(1, math.Round(float64(r.cellHeight)/10)))
+	switch gui.terminal.Modes().CursorShape {
+	case terminal.CursorUnderline:
+		return x, y + r.cellHeight - thickness, width, thickness, colour
+	case terminal.CursorBar:
+		return x, y, thickness, r.cellHeight, colour
+	}
+	return x, y, width, r.cellHeight, colour
+}
+
+// cursorVisible returns false while a blinking cursor is blinked off. Blinking follows the blink interval of blinking
+// text, so setting that to 0 stops the cursor blinking too.
+func (gui *GUI) cursorVisible() bool {
+	if !gui.terminal.Modes().BlinkingCursor || gui.config.Graphics.BlinkInterval == 0 {
+		return true
+	}
+	return gui.blinkVisible()
+}
+
+func (gui *GUI) cursorDuration() time.Duration {
+	return time.Duration(gui.config.Cursor.AnimationDuration) * time.Millisecond
+}
+
+// position returns the point the cursor has reached and how far through the animation it is, from 0 to 1
+func (a *cursorAnimation) position(duration time.Duration) (float32, float32, float64) {
+	progress := float64(time.Since(a.start)) / float64(duration)
+	if progress >= 1 {
+		return a.toX, a.toY, 1
+	}
+	// ease out, so the cursor arrives gently
+	eased := float32(1 - math.Pow(1-progress, 3))
+	return a.fromX + (a.toX-a.fromX)*eased, a.fromY + (a.toY-a.fromY)*eased, progress
+}
+
+func mixColour(from config.Colour, to config.Colour, amount float32) config.Colour {
+	return config.Colour{
+		from[0] + (to[0]-from[0])*amount,
+		from[1] + (to[1]-from[1])*amount,
+		from[2] + (to[2]-from[2])*amount,
+	}
+}
@@ -0,0 +1,82 @@
+package gui
+
+import (
+	"github.com/go-gl/glfw/v3.2/glfw"
+)
+
+// watchDisplays has the window checked again whenever a monitor is connected or disconnected
+func (gui *GUI) watchDisplays() {
+	glfw.SetMonitorCallback(func(monitor *glfw.Monitor, event glfw.MonitorEvent) {
+		if event == glfw.Connected {
+			gui.logger.Infof("Monitor connected: %s", monitor.GetName())
+		} else {
+			gui.logger.Infof("Monitor disconnected")
+		}
+		gui.displayChanged = true
+	})
+}
+
+// checkDisplay lays the window out again if its size or scale has changed without a resize being reported, as happens
+// when it is moved between monitors with different scales, or the machine is resumed or docked. If the monitor it was
+// on has gone, it is moved to one which is still there.
+func (gui *GUI) checkDisplay() {
+	width, height := gui.window.GetFramebufferSize()
+	if width == 0 || height == 0 {
+		return // minimised, so there is nothing to lay out until it is restored
+	}
+	scale := gui.scale()
+	if !gui.displayChanged && width == gui.width && height == gui.height && scale == gui.displayScale {
+		return
+	}
+	gui.displayChanged = false
+
+	gui.logger.Infof("Display changed, laying out again at %dx%d with a scale of %g", width, height, scale)
+	if gui.keepOnScreen() {
+		width, height = gui.window.GetFramebufferSize()
+	}
+	gui.resize(gui.window, width, height)
+	for _, p := range gui.panes {
+		p.terminal.SetDirty()
+	}
+}
+
+// keepOnScreen moves the window to the primary monitor if it isn't on any of them, e.g. because the monitor it was on
+// has been unplugged, shrinking it if it doesn't fit. It returns true if the window was moved.
+func (gui *GUI) keepOnScreen() bool {
+	if gui.window.GetMonitor() != nil {
+		return false // full screen
+	}
+	x, y := gui.window.GetPos()
+	width, height := gui.window.GetSize()
+
+	for _, monitor := range glfw.GetMonitors() {
+		mode := monitor.GetVideoMode()
+		if mode == nil {
+			continue
+		}
+		mx, my := monitor.GetPos()
+		if x < mx+mode.Width && x+width > mx && y < my+mode.Height && y+height > my {
+			return false
+		}
+	}
+
+	primary := glfw.GetPrimaryMonitor()
+	if primary == nil {
+		return false
+	}
+	mode := primary.GetVideoMode()
+	if mode == nil {
+		return false
+	}
+	if width > mode.Width {
+		width = mode.Width
+	}
+	if height > mode.Height {
+		height = mode.Height
+	}
+	mx, my := primary.GetPos()
+	gui.logger.Infof("Window is off screen, moving it to %s", primary.GetName())
+	gui.window.SetSize(width, height)
+	gui.window.SetPos(mx+(mode.Width-width)/2, my+(mode.Height-height)/2)
+	return true
+}
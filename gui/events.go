@@ -0,0 +1,105 @@
+package gui
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/liamg/aminal/control"
+	"github.com/liamg/aminal/terminal"
+)
+
+// publishedPane is what subscribers to the control socket were last told about a pane, so only changes are published
+type publishedPane struct {
+	title    string
+	dir      string
+	host     string
+	activity bool // activity was published, and the pane hasn't been looked at since
+}
+
+// SetControlServer has the window's events published on the control socket
+func (gui *GUI) SetControlServer(server *control.Server) {
+	gui.control = server
+	gui.published = map[int]*publishedPane{}
+	gui.publishedFocus = -1
+}
+
+func (gui *GUI) publish(p *pane, event control.Event) {
+	if gui.control == nil {
+		return
+	}
+	event.Pane = p.id
+	gui.control.Publish(event)
+}
+
+// handleUserEvent acts on an event raised by a program in one of the panes
+func (gui *GUI) handleUserEvent(p *pane, event terminal.UserEvent) {
+	switch event.Type {
+	case "command_start":
+		p.commandStarted = time.Now()
+		p.command = event.Value
+		gui.publish(p, control.Event{Type: control.EventCommandStart, Command: event.Value})
+	case "command_done":
+		done := control.Event{Type: control.EventCommandDone}
+		if status, err := strconv.Atoi(event.Value); err == nil {
+			done.ExitStatus = &status
+		}
+		if !p.commandStarted.IsZero() {
+			done.Duration = time.Since(p.commandStarted).Seconds()
+			p.commandStarted = time.Time{}
+		}
+		p.command = ""
+		gui.publish(p, done)
+	default:
+		gui.runHook(event)
+	}
+}
+
+// publishActivity tells subscribers output has arrived in a pane the user isn't looking at, once until they look
+func (gui *GUI) publishActivity(p *pane) {
+	if gui.control == nil || gui.focused && p == gui.pane {
+		return
+	}
+	if published := gui.published[p.id]; published != nil && !published.activity {
+		published.activity = true
+		gui.publish(p, control.Event{Type: control.EventActivity})
+	}
+}
+
+// publishEvents tells subscribers about changes to the panes' titles and directories, focus and closed panes
+func (gui *GUI) publishEvents() {
+	if gui.control == nil {
+		return
+	}
+
+	for id, p := range gui.panes {
+		published := gui.published[id]
+		if published == nil {
+			published = &publishedPane{}
+			gui.published[id] = published
+		}
+		if title := p.terminal.GetTitle(); title != published.title {
+			published.title = title
+			gui.publish(p, control.Event{Type: control.EventTitle, Title: title})
+		}
+		dir, host := p.terminal.GetWorkingDirectory(), p.terminal.GetHost()
+		if dir != published.dir || host != published.host {
+			published.dir, published.host = dir, host
+			gui.publish(p, control.Event{Type: control.EventDirectory, Directory: dir, Host: host})
+		}
+		if gui.focused && p == gui.pane {
+			published.activity = false
+		}
+	}
+
+	for id := range gui.published {
+		if _, open := gui.panes[id]; !open {
+			delete(gui.published, id)
+			gui.control.Publish(control.Event{Type: control.EventPaneClosed, Pane: id})
+		}
+	}
+
+	if gui.pane.id != gui.publishedFocus || gui.focused != gui.publishedFocused {
+		gui.publishedFocus, gui.publishedFocused = gui.pane.id, gui.focused
+		gui.publish(gui.pane, control.Event{Type: control.EventFocus, Focused: gui.focused})
+	}
+}
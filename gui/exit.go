@@ -0,0 +1,140 @@
+package gui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/liamg/aminal/config"
+	"github.com/liamg/aminal/terminal"
+)
+
+// how long the shell and its children get to exit after the window closes, before they are killed
+const hangupTimeout = 3 * time.Second
+
+// SetLauncher sets the function used to start a new shell when the old one exits, if on_exit is "restart"
+func (gui *GUI) SetLauncher(launch func() (terminal.Pty, error)) {
+	gui.pane.launch = launch
+}
+
+// ExitStatus returns the exit status of the shell once the window has closed, or 0 if it isn't known
+func (gui *GUI) ExitStatus() int {
+	status, _ := gui.terminal.WaitForExit()
+	return status
+}
+
+// runPty reads from a pane's pty until the process on the other end exits, then closes the pane, holds it open or
+// restarts the process, depending on on_exit
+func (gui *GUI) runPty(p *pane) {
+	for {
+		if err := p.terminal.Read(); err != nil {
+			gui.logger.Debugf("Read from pty ended: %s", err)
+		}
+
+		status, known := p.terminal.WaitForExit()
+
+		policy := gui.config.OnExit
+		if policy == config.OnExitRestart && p.launch == nil || p.hold {
+			policy = config.OnExitHold
+		}
+		if policy != config.OnExitHold && policy != config.OnExitRestart {
+			select {
+			case gui.paneExits <- p:
+			case <-p.closed:
+			}
+			return
+		}
+
+		message := "[Process exited]"
+		if known {
+			message = fmt.Sprintf("[Process exited with status %d]", status)
+		}
+		if policy == config.OnExitHold {
+			p.terminal.ShowMessage(message)
+			p.setExited(true)
+			return
+		}
+
+		p.terminal.ShowMessage(message + " Press Enter to restart.")
+		p.setExited(true)
+		for {
+			select {
+			case <-p.restartChan:
+			case <-p.closed:
+				return
+			}
+			pty, err := p.launch()
+			if err == nil {
+				err = p.terminal.SetPty(pty)
+			}
+			if err != nil {
+				p.terminal.ShowMessage(fmt.Sprintf("[Failed to restart: %s] Press Enter to try again.", err))
+				continue
+			}
+			break
+		}
+		p.terminal.EndMessage()
+		p.setExited(false)
+	}
+}
+
+// exitedKey handles keys pressed once the shell in the focused pane has exited, returning true if the key was used
+func (gui *GUI) exitedKey(enter bool) bool {
+	if !gui.pane.hasExited() {
+		return false
+	}
+	if enter && gui.config.OnExit == config.OnExitRestart {
+		select {
+		case gui.pane.restartChan <- true:
+		default:
+		}
+	}
+	return true
+}
+
+// closeRequested asks for confirmation before closing the window while a program listed in confirm_close is running
+// in any of the panes
+func (gui *GUI) closeRequested(w *glfw.Window) {
+	name := ""
+	for _, p := range gui.panes {
+		if process := p.terminal.GetForegroundProcess(); gui.config.ConfirmCloseFor(process) {
+			name = process
+			break
+		}
+	}
+	if name == "" {
+		return
+	}
+	w.SetShouldClose(false)
+	gui.setOverlay(newConfirmOverlay(
+		fmt.Sprintf("%s is still running - close anyway? (y/n)", name),
+		func(gui *GUI) { gui.Close() },
+	))
+}
+
+// confirmOverlay asks a yes or no question, running an action if the answer is yes
+type confirmOverlay struct {
+	helpOverlay
+	confirm func(gui *GUI)
+}
+
+func newConfirmOverlay(question string, confirm func(gui *GUI)) *confirmOverlay {
+	return &confirmOverlay{
+		helpOverlay: helpOverlay{lines: []string{question}},
+		confirm:     confirm,
+	}
+}
+
+func (c *confirmOverlay) key(gui *GUI, key glfw.Key, mods glfw.ModifierKey) {
+	switch key {
+	case glfw.KeyY, glfw.KeyEnter, glfw.KeyKPEnter:
+		gui.setOverlay(nil)
+		c.confirm(gui)
+	case glfw.KeyN:
+		gui.setOverlay(nil)
+	}
+}
+
+func (c *confirmOverlay) char(gui *GUI, r rune) {}
+
+func (c *confirmOverlay) click(gui *GUI, x float64, y float64) {}
@@ -0,0 +1,16 @@
+package gui
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeldPaneStaysOpenWhenItsProcessExits(t *testing.T) {
+	gui, _ := newTestGUI(t, `on_exit = "close"`)
+	gui.pane.hold = true
+
+	gui.runPty(gui.pane)
+	assert.True(t, gui.pane.hasExited())
+	assert.Contains(t, gui.terminal.ActiveBuffer().GetAllText(), "[Process exited]")
+}
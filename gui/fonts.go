@@ -3,16 +3,28 @@ package gui
 import (
 	"bytes"
 	"fmt"
+	"io/ioutil"
 
 	"github.com/gobuffalo/packr"
+	"github.com/liamg/aminal/config"
 	"github.com/liamg/aminal/glfont"
 )
 
-func (gui *GUI) getPackedFont(name string) (*glfont.Font, error) {
-	box := packr.NewBox("./packed-fonts")
-	fontBytes, err := box.Find(name)
-	if err != nil {
-		return nil, fmt.Errorf("packaged font '%s' could not be read: %s", name, err)
+func (gui *GUI) getPackedFont(name string, face config.FontFaceConfig) (*glfont.Font, error) {
+	var fontBytes []byte
+	var err error
+	if face.File != "" {
+		name = face.File
+		fontBytes, err = ioutil.ReadFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("font '%s' could not be read: %s", name, err)
+		}
+	} else {
+		box := packr.NewBox("./packed-fonts")
+		fontBytes, err = box.Find(name)
+		if err != nil {
+			return nil, fmt.Errorf("packaged font '%s' could not be read: %s", name, err)
+		}
 	}
 
 	font, err := glfont.LoadFont(bytes.NewReader(fontBytes), gui.fontScale/gui.scale(), gui.width, gui.height)
@@ -20,6 +32,20 @@ func (gui *GUI) getPackedFont(name string) (*glfont.Font, error) {
 		return nil, fmt.Errorf("font '%s' failed to load: %v", name, err)
 	}
 
+	font.SetRendering(gui.fontRendering())
+	font.SetTextureBudget(gui.textures)
+	font.SetColourOutput(gui.output)
+
+	if variations := face.Variations(); len(variations) > 0 {
+		if err := font.SetVariations(variations); err != nil {
+			return nil, fmt.Errorf("font '%s' variation could not be applied: %v", name, err)
+		}
+	}
+
+	if err := font.SetFeatures(face.EnabledFeatures()); err != nil {
+		return nil, fmt.Errorf("font '%s' features could not be applied: %v", name, err)
+	}
+
 	return font, nil
 }
 
@@ -27,12 +53,12 @@ func (gui *GUI) loadFonts() error {
 
 	// from https://github.com/ryanoasis/nerd-fonts/tree/master/patched-fonts/Hack
 
-	defaultFont, err := gui.getPackedFont("Hack Regular Nerd Font Complete.ttf")
+	defaultFont, err := gui.getPackedFont("Hack Regular Nerd Font Complete.ttf", gui.config.Font.Regular)
 	if err != nil {
 		return err
 	}
 
-	boldFont, err := gui.getPackedFont("Hack Bold Nerd Font Complete.ttf")
+	boldFont, err := gui.getPackedFont("Hack Bold Nerd Font Complete.ttf", gui.config.Font.Bold)
 	if err != nil {
 		return err
 	}
@@ -46,5 +72,47 @@ func (gui *GUI) loadFonts() error {
 
 	// add special non-ascii fonts here
 
+	gui.fontMap.ranges = map[runeRange]*glfont.Font{}
+	gui.fontMap.runeMap = map[rune]*glfont.Font{}
+
+	if gui.config.Font.Emoji != "" {
+		emojiFont, err := gui.getPackedFont("", config.FontFaceConfig{File: gui.config.Font.Emoji})
+		if err != nil {
+			return err
+		}
+		for _, r := range emojiRanges {
+			gui.fontMap.setOverrideRange(r.start, r.end, emojiFont)
+		}
+	}
+
 	return nil
 }
+
+// emojiRanges are the blocks drawn with the emoji font, matching the runes the buffer treats as wide emoji
+var emojiRanges = []runeRange{
+	{start: 0x1F1E6, end: 0x1F1FF},
+	{start: 0x1F300, end: 0x1F64F},
+	{start: 0x1F680, end: 0x1F6FF},
+	{start: 0x1F900, end: 0x1F9FF},
+	{start: 0x1FA70, end: 0x1FAFF},
+}
+
+func (gui *GUI) fontRendering() glfont.Rendering {
+	rendering := glfont.Rendering{
+		Gamma:    gui.config.Font.Gamma,
+		Contrast: gui.config.Font.Contrast,
+	}
+	switch gui.config.Font.Hinting {
+	case "none":
+		rendering.Hinting = glfont.HintingNone
+	case "slight":
+		rendering.Hinting = glfont.HintingSlight
+	}
+	switch gui.config.Font.Subpixel {
+	case "rgb":
+		rendering.Subpixel = glfont.SubpixelRGB
+	case "bgr":
+		rendering.Subpixel = glfont.SubpixelBGR
+	}
+	return rendering
+}
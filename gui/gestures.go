@@ -0,0 +1,237 @@
+package gui
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/liamg/aminal/terminal"
+)
+
+const (
+	momentumDelay     = 60 * time.Millisecond  // gap after the last scroll event before momentum takes over
+	momentumFriction  = 4.0                    // proportion of velocity lost per second
+	momentumMinimum   = 2.0                    // lines per second, below which momentum stops
+	longPressDuration = 500 * time.Millisecond // hold time before a press selects the word under it
+	autoScrollSpeed   = 8.0                    // lines per second for each line the pointer is dragged past the edge
+	defaultFontScale  = 14
+	minFontScale      = 6
+	maxFontScale      = 72
+)
+
+// gestures tracks touchpad and touchscreen input which plays out over several events
+type gestures struct {
+	scrollRemainder float64 // part of a line scrolled but not yet applied
+	velocity        float64 // lines per second, positive is up
+	lastScroll      time.Time
+	lastFrame       time.Time
+	pressTime       time.Time
+	pressX          uint16
+	pressY          uint16
+	longPressed     bool
+	dragOverflow    float64 // pixels the pointer is above (negative) or below the screen while selecting
+	dragCol         uint16
+	dragRow         uint16
+	autoScrolled    float64 // part of a line auto-scrolled but not yet applied
+}
+
+func (gui *GUI) glfwScrollCallback(w *glfw.Window, xoff float64, yoff float64) {
+
+	// touchpads send pinches as scrolling with ctrl held
+	if w.GetKey(glfw.KeyLeftControl) == glfw.Press || w.GetKey(glfw.KeyRightControl) == glfw.Press {
+		gui.zoom(yoff)
+		return
+	}
+
+	if gui.reportWheel(w, yoff) {
+		return
+	}
+
+	now := time.Now()
+	g := &gui.gestures
+
+	// wheels scroll in whole steps, touchpads in fractions of one - only the latter gets momentum
+	touchpad := yoff != math.Trunc(yoff)
+	if touchpad {
+		yoff *= gui.config.Mouse.TouchpadSensitivity
+	} else if gui.alternateScroll() {
+		yoff *= float64(gui.config.Mouse.AlternateLines)
+	} else {
+		yoff *= float64(gui.config.Mouse.WheelLines)
+	}
+
+	if touchpad && now.Sub(g.lastScroll) < momentumDelay*2 {
+		dt := now.Sub(g.lastScroll).Seconds()
+		if dt > 0 {
+			g.velocity = (g.velocity + yoff/dt) / 2
+		}
+	} else {
+		g.velocity = 0
+	}
+	g.lastScroll = now
+
+	gui.scrollBy(yoff)
+}
+
+// scrollBy scrolls by a number of lines, which can be fractional - the remainder is kept for the next scroll
+func (gui *GUI) scrollBy(lines float64) {
+	g := &gui.gestures
+	g.scrollRemainder += lines
+	whole := math.Trunc(g.scrollRemainder)
+	if whole == 0 {
+		return
+	}
+	g.scrollRemainder -= whole
+	if gui.alternateScroll() {
+		gui.sendArrows(int(whole))
+		return
+	}
+	if whole > 0 {
+		gui.terminal.ScrollUp(uint16(whole))
+	} else {
+		gui.terminal.ScrollDown(uint16(-whole))
+	}
+}
+
+// alternateScroll returns true if scrolling should move the cursor of a full screen program such as less, which has
+// no scrollback of its own for us to move through
+func (gui *GUI) alternateScroll() bool {
+	return !gui.terminal.UsingMainBuffer() &&
+		gui.terminal.IsAlternateScrollModeEnabled() &&
+		gui.terminal.GetMouseMode() == terminal.MouseModeNone
+}
+
+// sendArrows sends up arrows for positive counts and down arrows for negative ones
+func (gui *GUI) sendArrows(count int) {
+	final := "A"
+	if count < 0 {
+		final = "B"
+		count = -count
+	}
+	prefix := "\x1b["
+	if gui.terminal.IsApplicationCursorKeysModeEnabled() {
+		prefix = "\x1bO"
+	}
+	gui.terminal.Write([]byte(strings.Repeat(prefix+final, count)))
+}
+
+// updateGestures runs once per frame, continuing momentum scrolling and firing long presses
+func (gui *GUI) updateGestures() {
+
+	g := &gui.gestures
+	now := time.Now()
+	dt := now.Sub(g.lastFrame).Seconds()
+	g.lastFrame = now
+
+	if g.velocity != 0 && now.Sub(g.lastScroll) > momentumDelay {
+		if !gui.config.Mouse.ScrollMomentum || dt > 1 {
+			g.velocity = 0
+		} else {
+			gui.scrollBy(g.velocity * dt)
+			g.velocity *= math.Exp(-momentumFriction * dt)
+			if math.Abs(g.velocity) < momentumMinimum {
+				g.velocity = 0
+				g.scrollRemainder = 0
+			}
+		}
+	}
+
+	if gui.mouseDown && g.dragOverflow != 0 && dt < 1 {
+		gui.autoScroll(dt)
+	}
+
+	if gui.mouseDown && !g.longPressed && !g.pressTime.IsZero() && now.Sub(g.pressTime) > longPressDuration {
+		g.longPressed = true
+		gui.terminal.ActiveBuffer().SelectWordAtPosition(g.pressX, g.pressY)
+	}
+}
+
+// dragSelection extends the selection to the pointer, keeping it on screen, and records how far past the top or
+// bottom of the screen the pointer has gone, for auto-scrolling
+func (gui *GUI) dragSelection(px float64, py float64) {
+	g := &gui.gestures
+	buf := gui.terminal.ActiveBuffer()
+	top := float64(gui.renderer.areaY)
+	bottom := top + float64(buf.ViewHeight())*float64(gui.renderer.CellHeight())
+
+	g.dragOverflow = 0
+	if py < top {
+		g.dragOverflow = py - top
+	} else if py >= bottom {
+		g.dragOverflow = py - bottom + 1
+	}
+
+	g.dragCol, g.dragRow = gui.clampedCell(px, py)
+	gui.movePress(g.dragCol, g.dragRow)
+	buf.EndSelection(g.dragCol, g.dragRow, false)
+}
+
+// autoScroll scrolls while a selection is dragged past the edge of the screen, faster the further past it the pointer
+// is, extending the selection into the lines scrolled onto the screen
+func (gui *GUI) autoScroll(dt float64) {
+	g := &gui.gestures
+	distance := math.Ceil(math.Abs(g.dragOverflow) / float64(gui.renderer.CellHeight()))
+	g.autoScrolled += distance * autoScrollSpeed * dt
+	whole := math.Trunc(g.autoScrolled)
+	if whole == 0 {
+		return
+	}
+	g.autoScrolled -= whole
+	if g.dragOverflow < 0 {
+		gui.terminal.ScrollUp(uint16(whole))
+	} else {
+		gui.terminal.ScrollDown(uint16(whole))
+	}
+	gui.terminal.ActiveBuffer().EndSelection(g.dragCol, g.dragRow, false)
+}
+
+// clampedCell returns the cell at a position in the window, or the closest one to it if it is off the screen
+func (gui *GUI) clampedCell(px float64, py float64) (uint16, uint16) {
+	buf := gui.terminal.ActiveBuffer()
+	col := math.Floor((px - float64(gui.renderer.areaX)) / float64(gui.renderer.CellWidth()))
+	row := math.Floor((py - float64(gui.renderer.areaY)) / float64(gui.renderer.CellHeight()))
+	col = math.Max(0, math.Min(col, float64(buf.ViewWidth())-1))
+	row = math.Max(0, math.Min(row, float64(buf.ViewHeight())-1))
+	return uint16(col), uint16(row)
+}
+
+// startPress records a press for long-press detection, and stops any momentum scrolling
+func (gui *GUI) startPress(x uint16, y uint16) {
+	gui.gestures = gestures{
+		lastFrame: gui.gestures.lastFrame,
+		pressTime: time.Now(),
+		pressX:    x,
+		pressY:    y,
+	}
+}
+
+// movePress cancels a pending long press if the pointer leaves the cell it was pressed in
+func (gui *GUI) movePress(x uint16, y uint16) {
+	if x != gui.gestures.pressX || y != gui.gestures.pressY {
+		gui.gestures.pressTime = time.Time{}
+	}
+}
+
+// endPress returns true if the press which has just ended was a long press
+func (gui *GUI) endPress() bool {
+	gui.gestures.pressTime = time.Time{}
+	gui.gestures.dragOverflow = 0
+	return gui.gestures.longPressed
+}
+
+// zoom changes the font size, re-laying out the terminal to fit
+func (gui *GUI) zoom(delta float64) {
+	scale := gui.fontScale + float32(delta)
+	if scale < minFontScale {
+		scale = minFontScale
+	} else if scale > maxFontScale {
+		scale = maxFontScale
+	}
+	if scale == gui.fontScale {
+		return
+	}
+	gui.fontScale = scale
+	gui.resize(gui.window, gui.width, gui.height)
+	gui.terminal.SetDirty()
+}
@@ -0,0 +1,48 @@
+package gui
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/liamg/aminal/config"
+	"github.com/liamg/aminal/layout"
+)
+
+// openCommit copies a clicked git commit hash, or shows it with git show in a new pane, as configured. The commit is
+// written out in full rather than paged, and the pane stays open once git exits, so it can be scrolled through.
+func (gui *GUI) openCommit(hash string) {
+	switch gui.config.Git.Commit {
+	case config.GitActionCopy:
+		gui.window.SetClipboardString(hash)
+	case config.GitActionShow:
+		if !isLocalHost(gui.terminal.GetHost()) {
+			gui.logger.Infof("Not showing commit %s, which is on %s", hash, gui.terminal.GetHost())
+			return
+		}
+		// split along the longer side, so both halves stay usable
+		direction := layout.Vertical
+		if rect := gui.layout.Rects(gui.panesArea())[gui.pane.id]; rect.Width > rect.Height {
+			direction = layout.Horizontal
+		}
+		if err := gui.splitPane(direction, "git --no-pager show "+shellQuote(hash)); err != nil {
+			gui.logger.Errorf("Failed to show commit %s: %s", hash, err)
+		}
+	}
+}
+
+// gitRoot returns the top directory of the git repository dir is in, or an empty string if it isn't in one
+func gitRoot(dir string) string {
+	if dir == "" {
+		return ""
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
@@ -2,16 +2,22 @@ package gui
 
 import (
 	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/go-gl/gl/all-core/gl"
 	"github.com/go-gl/glfw/v3.2/glfw"
 	"github.com/liamg/aminal/buffer"
 	"github.com/liamg/aminal/config"
+	"github.com/liamg/aminal/control"
+	"github.com/liamg/aminal/glfont"
+	"github.com/liamg/aminal/layout"
 	"github.com/liamg/aminal/terminal"
 	"github.com/liamg/aminal/version"
 	"go.uber.org/zap"
@@ -29,10 +35,59 @@ type GUI struct {
 	renderer          *OpenGLRenderer
 	colourAttr        uint32
 	mouseDown         bool
+	mouseReport       mouseReport
+	hoverLink         *buffer.Link // the link under the mouse, which is underlined
+	hoverCol          uint16       // where the mouse first moved onto the link, which its preview is shown under
+	hoverRow          uint16
 	overlay           overlay
+	lastSearch        *searchOverlay
 	terminalAlpha     float32
 	showDebugInfo     bool
 	keyboardShortcuts map[config.UserAction]*config.KeyCombination
+	hostProfile       *config.HostProfile
+	theme             string              // the colour theme in use, or empty for the colour scheme as it is
+	colours           config.ColourScheme // the colours of the theme, with the host profile's on top, see applyColours
+	focused           bool
+	lastBell          time.Time
+	unseenBell        bool
+	unseenActivity    bool
+	gitBranchCache    gitBranchCache
+	gestures          gestures
+	cursorAnimation   cursorAnimation
+	caret             caretRect // where the OS was last told the cursor is
+	blinkRedraw       time.Time // when blinking text is next redrawn
+	pane              *pane     // the focused pane, whose terminal is gui.terminal
+	panes             map[int]*pane
+	layout            *layout.Tree
+	nextPaneID        int
+	paneExits         chan *pane   // panes whose shells have exited, to be closed
+	overlays          chan overlay // overlays to show, from work done in the background
+	paneLauncher      func(pane *config.PaneLayout, command string) (terminal.Pty, error)
+	startLayout       *config.PaneLayout // the panes to open the window with, see SetLayout
+	dragDivider       *layout.Divider
+	hoverDivider      *layout.Divider // the divider under the mouse, which is highlighted along with one being dragged
+	scrollbarHeld     bool            // the mouse button was pressed on the scrollbar, see scrollbar.go
+	scrollbarRects    []filledRect    // the thumb and ticks of the scrollbar being drawn, kept to save allocating them each frame
+	program           uint32
+	titleChan         chan bool
+	pendingKey        []byte // sent for the last key press unless it types a character
+	pendingName       string // the character the key with the pending sequence types
+	swallowChar       bool   // the last key press sent a sequence, so ignore any character it types
+	titleChecked      time.Time
+	textures          *glfont.TextureBudget // shared by glyphs and images
+	output            glfont.ColourOutput   // how colours are written to the window
+	post              *postProcess          // draws each frame through the user's shader, if there is one
+	postShader        string                // the file post was loaded from, or failed to load from
+	shownTitle        string
+	heartbeat         int64           // when the render loop last ran, in nanoseconds, for the watchdog
+	resetStatus       func() uint32   // reports whether the OpenGL context has been lost, or nil if it can't
+	displayChanged    bool            // monitors have changed since the window was last laid out, see checkDisplay
+	displayScale      float32         // the scale the window was last laid out at
+	control           *control.Server // publishes events for status bars, or nil if the control socket is off
+	published         map[int]*publishedPane
+	publishedFocus    int // the focused pane subscribers were last told about
+	publishedFocused  bool
+	hooks             hookLimiter
 }
 
 func New(config *config.Config, terminal *terminal.Terminal, logger *zap.SugaredLogger) (*GUI, error) {
@@ -42,15 +97,27 @@ func New(config *config.Config, terminal *terminal.Terminal, logger *zap.Sugared
 		return nil, err
 	}
 
+	first := newPane(0, terminal)
+
 	return &GUI{
 		config:            config,
 		logger:            logger,
 		width:             800,
 		height:            600,
 		terminal:          terminal,
-		fontScale:         14.0,
+		pane:              first,
+		panes:             map[int]*pane{first.id: first},
+		layout:            layout.New(first.id, dividerWidth),
+		nextPaneID:        first.id + 1,
+		paneExits:         make(chan *pane),
+		overlays:          make(chan overlay, 1),
+		titleChan:         make(chan bool, 1),
+		fontScale:         defaultFontScale,
 		terminalAlpha:     1,
 		keyboardShortcuts: shortcuts,
+		focused:           true,
+		theme:             config.Theme,
+		textures:          glfont.NewTextureBudget(config.Graphics.TextureBudgetBytes()),
 	}, nil
 }
 
@@ -69,26 +136,20 @@ func (gui *GUI) resize(w *glfw.Window, width int, height int) {
 
 	gui.width = width
 	gui.height = height
+	gui.displayScale = gui.scale()
 
 	gui.logger.Debugf("Updating font resolutions...")
 	gui.loadFonts()
 
 	gui.logger.Debugf("Setting renderer area...")
-	gui.renderer.SetArea(0, 0, width, height)
-
-	gui.logger.Debugf("Calculating size in cols/rows...")
-	cols, rows := gui.renderer.GetTermSize()
+	gui.renderer.SetWindowSize(width, height)
 
-	gui.logger.Debugf("Resizing internal terminal...")
-	if err := gui.terminal.SetSize(cols, rows); err != nil {
-		gui.logger.Errorf("Failed to resize terminal to %d cols, %d rows: %s", cols, rows, err)
-	}
+	gui.logger.Debugf("Resizing internal terminals...")
+	gui.layoutPanes()
 
 	gui.logger.Debugf("Setting viewport size...")
 	gl.Viewport(0, 0, int32(gui.width), int32(gui.height))
 
-	gui.terminal.SetCharSize(gui.renderer.cellWidth, gui.renderer.cellHeight)
-
 	gui.logger.Debugf("Resize complete!")
 
 }
@@ -109,90 +170,41 @@ func (gui *GUI) Render() error {
 	gui.logger.Debugf("Locking OS thread...")
 	runtime.LockOSThread()
 
-	gui.logger.Debugf("Creating window...")
-	var err error
-	gui.window, err = gui.createWindow()
-	if err != nil {
-		return fmt.Errorf("Failed to create window: %s", err)
-	}
 	defer glfw.Terminate()
-
-	gui.logger.Debugf("Initialising OpenGL and creating program...")
-	program, err := gui.createProgram()
-	if err != nil {
-		return fmt.Errorf("Failed to initialise OpenGL: %s", err)
-	}
-
-	gui.colourAttr = uint32(gl.GetAttribLocation(program, gl.Str("inColour\x00")))
-	gl.BindFragDataLocation(program, 0, gl.Str("outColour\x00"))
-
-	gui.logger.Debugf("Loading font...")
-	if err := gui.loadFonts(); err != nil {
-		return fmt.Errorf("Failed to load font: %s", err)
+	if err := gui.setupWindow(); err != nil {
+		return err
 	}
 
-	titleChan := make(chan bool, 1)
-
-	gui.renderer = NewOpenGLRenderer(gui.config, gui.fontMap, 0, 0, gui.width, gui.height, gui.colourAttr, program)
-
-	gui.window.SetFramebufferSizeCallback(gui.resize)
-	gui.window.SetKeyCallback(gui.key)
-	gui.window.SetCharCallback(gui.char)
-	gui.window.SetScrollCallback(gui.glfwScrollCallback)
-	gui.window.SetMouseButtonCallback(gui.mouseButtonCallback)
-	gui.window.SetCursorPosCallback(gui.mouseMoveCallback)
-	gui.window.SetRefreshCallback(func(w *glfw.Window) {
-		gui.terminal.SetDirty()
-	})
-	gui.window.SetFocusCallback(func(w *glfw.Window, focused bool) {
-		if focused {
-			gui.terminal.SetDirty()
-		}
-	})
-
-	w, h := gui.window.GetFramebufferSize()
-	gui.resize(gui.window, w, h)
+	gui.watchDisplays()
 
 	gui.logger.Debugf("Starting pty read handling...")
 
-	go func() {
-		err := gui.terminal.Read()
-		if err != nil {
-			gui.logger.Errorf("Read from pty failed: %s", err)
-		}
-		gui.Close()
-	}()
+	go gui.runPty(gui.pane)
+	gui.openLayout()
 
 	gui.logger.Debugf("Starting render...")
 
-	gl.UseProgram(program)
-
-	// stop smoothing fonts
-	gl.Disable(gl.DEPTH_TEST)
-	gl.TexParameterf(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
-
-	gl.ClearColor(
-		gui.config.ColourScheme.Background[0],
-		gui.config.ColourScheme.Background[1],
-		gui.config.ColourScheme.Background[2],
-		1.0,
-	)
-
-	gui.terminal.AttachTitleChangeHandler(titleChan)
+	gui.terminal.AttachTitleChangeHandler(gui.titleChan)
 
 	ticker := time.NewTicker(time.Second)
 	defer ticker.Stop()
 
-	defaultCell := buffer.NewBackgroundCell(gui.config.ColourScheme.Background)
+	defaultCell := buffer.NewPaletteBackgroundCell(gui.config.ColourScheme.Background, buffer.PaletteColour(config.ColourBackground))
 
 	go func() {
 		for {
 			<-ticker.C
 			gui.logger.Sync()
+			if gui.config.StatusBar.HasSegment(config.StatusSegmentClock) {
+				gui.terminal.SetDirty()
+			}
 		}
 	}()
 
-	gui.terminal.SetProgram(program)
+	done := make(chan struct{})
+	defer close(done)
+	gui.beat()
+	go gui.watchRenderLoop(done)
 
 	latestVersion := ""
 
@@ -208,74 +220,59 @@ func (gui *GUI) Render() error {
 
 	for !gui.window.ShouldClose() {
 
+		gui.beat()
+
 		select {
-		case <-titleChan:
-			gui.window.SetTitle(gui.terminal.GetTitle())
+		case <-gui.titleChan:
+			gui.window.SetTitle(gui.windowTitle())
+		case request := <-gui.terminal.Requests():
+			gui.handleRequest(request)
+		case event := <-gui.terminal.UserEvents():
+			gui.handleUserEvent(gui.pane, event)
+		case p := <-gui.paneExits:
+			gui.closePane(p)
+		case o := <-gui.overlays:
+			gui.setOverlay(o)
 		default:
 			// this is more efficient than glfw.PollEvents()
 			glfw.WaitEventsTimeout(0.02) // up to 50fps on no input, otherwise higher
 		}
+		gui.checkDisplay()
+		gui.pollPanes()
+		gui.publishEvents()
+		if gui.post != nil && gui.post.animated || gui.progressAnimated() {
+			gui.terminal.SetDirty()
+		}
 
-		if gui.terminal.CheckDirty() {
+		gui.flushPendingKey()
+		gui.refreshTitle()
 
-			gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT | gl.STENCIL_BUFFER_BIT)
+		gui.updateGestures()
 
-			lines := gui.terminal.GetVisibleLines()
-			lineCount := int(gui.terminal.ActiveBuffer().ViewHeight())
-			colCount := int(gui.terminal.ActiveBuffer().ViewWidth())
-			for y := 0; y < lineCount; y++ {
-				for x := 0; x < colCount; x++ {
-
-					cell := defaultCell
-
-					if y < len(lines) {
-						cells := lines[y].Cells()
-						if x < len(cells) {
-							cell = cells[x]
-						}
-					}
-
-					cursor := false
-					if gui.terminal.Modes().ShowCursor {
-						cx := uint(gui.terminal.GetLogicalCursorX())
-						cy := uint(gui.terminal.GetLogicalCursorY())
-						cy = cy + uint(gui.terminal.GetScrollOffset())
-						cursor = cx == uint(x) && cy == uint(y)
-					}
+		if gui.checkDirty() {
 
-					var colour *config.Colour
+			gui.updateHostProfile()
+			gui.updatePostProcess()
+			gui.updateIndicators()
 
-					if gui.terminal.ActiveBuffer().InSelection(uint16(x), uint16(y)) {
-						colour = &gui.config.ColourScheme.Selection
-					}
-					if cell.Image() != nil {
-						gui.renderer.DrawCellImage(cell, uint(x), uint(y))
-					} else {
-						gui.renderer.DrawCellBg(cell, uint(x), uint(y), cursor, colour, false)
-					}
-				}
+			if gui.post != nil {
+				gui.post.begin(gui.width, gui.height)
 			}
-			for y := 0; y < lineCount; y++ {
-				for x := 0; x < colCount; x++ {
-
-					cell := defaultCell
-					hasText := false
-
-					if y < len(lines) {
-						cells := lines[y].Cells()
-						if x < len(cells) {
-							cell = cells[x]
-							if cell.Rune() != 0 && cell.Rune() != 32 {
-								hasText = true
-							}
-						}
-					}
+			gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT | gl.STENCIL_BUFFER_BIT)
 
-					if hasText {
-						gui.renderer.DrawCellText(cell, uint(x), uint(y), 1.0, nil)
-					}
-				}
-			}
+			gui.renderPanes(defaultCell)
+			gui.updateCaret()
+
+			lines := gui.terminal.GetVisibleLines()
+			gui.renderHoverLink(lines)
+			gui.renderMarks(lines)
+			gui.renderStatusBar()
+			gui.renderPausedBadge()
+			gui.renderSecretInputBadge()
+			gui.renderAbbreviationBadge()
+			gui.renderNewLinesBadge()
+			gui.renderLinkPreview()
+			gui.renderAccent()
 
 			gui.renderOverlay()
 
@@ -283,12 +280,17 @@ func (gui *GUI) Render() error {
 				gui.textbox(2, 2, fmt.Sprintf(`Cursor:      %d,%d
 View Size:   %d,%d
 Buffer Size: %d lines
+Textures:    %dMB of %dMB
+Latency:     %s
 `,
 					gui.terminal.GetLogicalCursorX(),
 					gui.terminal.GetLogicalCursorY(),
 					gui.terminal.ActiveBuffer().ViewWidth(),
 					gui.terminal.ActiveBuffer().ViewHeight(),
 					gui.terminal.ActiveBuffer().Height(),
+					gui.textures.Used()/1024/1024,
+					gui.textures.Limit()/1024/1024,
+					gui.terminal.Latency().Report(),
 				),
 					[3]float32{1, 1, 1},
 					[3]float32{0.8, 0, 0},
@@ -313,17 +315,100 @@ Buffer Size: %d lines
 				)
 			}
 
+			if gui.post != nil {
+				gui.post.end()
+			}
 			gui.window.SwapBuffers()
+			gui.terminal.Latency().Presented()
 
+			if gui.contextLost() {
+				if err := gui.recoverContext(); err != nil {
+					return err
+				}
+			}
 		}
 
 	}
 
 	gui.logger.Debugf("Stopping render...")
+	gui.window.Hide()
+	gui.hangupPanes()
 	return nil
 
 }
 
+// setupWindow creates the window, and everything drawn with its OpenGL context. It is called again with a new window if
+// the context is lost.
+func (gui *GUI) setupWindow() error {
+
+	gui.logger.Debugf("Creating window...")
+	var err error
+	gui.window, err = gui.createWindow()
+	if err != nil {
+		return fmt.Errorf("Failed to create window: %s", err)
+	}
+
+	gui.logger.Debugf("Initialising OpenGL and creating program...")
+	program, err := gui.createProgram()
+	if err != nil {
+		return fmt.Errorf("Failed to initialise OpenGL: %s", err)
+	}
+	gui.output = gui.colourOutput()
+	gui.output.Use(program)
+	gui.resetStatus = graphicsResetStatus()
+
+	gui.colourAttr = uint32(gl.GetAttribLocation(program, gl.Str("inColour\x00")))
+	gl.BindFragDataLocation(program, 0, gl.Str("outColour\x00"))
+
+	gui.logger.Debugf("Loading font...")
+	if err := gui.loadFonts(); err != nil {
+		return fmt.Errorf("Failed to load font: %s", err)
+	}
+
+	gui.program = program
+	gui.renderer = NewOpenGLRenderer(gui.config, gui.fontMap, gui.textures, 0, 0, gui.width, gui.height, gui.colourAttr, program)
+	gui.renderer.SetColourOutput(gui.output)
+
+	gui.window.SetFramebufferSizeCallback(func(w *glfw.Window, width int, height int) {
+		// resizing can hold up the render loop on some platforms, which isn't it being stuck
+		gui.beat()
+		gui.resize(w, width, height)
+	})
+	gui.window.SetKeyCallback(gui.key)
+	gui.window.SetCharModsCallback(gui.char)
+	gui.window.SetScrollCallback(gui.glfwScrollCallback)
+	gui.window.SetMouseButtonCallback(gui.mouseButtonCallback)
+	gui.window.SetCursorPosCallback(gui.mouseMoveCallback)
+	gui.window.SetCloseCallback(gui.closeRequested)
+	gui.window.SetRefreshCallback(func(w *glfw.Window) {
+		gui.terminal.SetDirty()
+	})
+	gui.window.SetFocusCallback(func(w *glfw.Window, focused bool) {
+		gui.focused = focused
+		if focused {
+			gui.unseenBell = false
+			gui.unseenActivity = false
+			gui.terminal.SetDirty()
+		}
+	})
+
+	w, h := gui.window.GetFramebufferSize()
+	gui.resize(gui.window, w, h)
+
+	gl.UseProgram(program)
+
+	// stop smoothing fonts
+	gl.Disable(gl.DEPTH_TEST)
+	gl.TexParameterf(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+
+	gui.applyColours()
+
+	for _, p := range gui.panes {
+		p.terminal.SetProgram(program)
+	}
+	return nil
+}
+
 func (gui *GUI) createWindow() (*glfw.Window, error) {
 	if err := glfw.Init(); err != nil {
 		return nil, fmt.Errorf("Failed to initialise GLFW: %s", err)
@@ -332,6 +417,11 @@ func (gui *GUI) createWindow() (*glfw.Window, error) {
 	glfw.WindowHint(glfw.Resizable, glfw.True)
 	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
 	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
+	// be told when the driver resets, rather than the context quietly drawing nothing, so it can be recreated
+	glfw.WindowHint(glfw.ContextRobustness, glfw.LoseContextOnReset)
+	if gui.config.Graphics.LinearBlending {
+		glfw.WindowHint(glfw.SRGBCapable, glfw.True)
+	}
 
 	versions := [][2]int{
 		{4, 6},
@@ -374,7 +464,7 @@ func (gui *GUI) createWindowWithOpenGLVersion(major int, minor int) (*glfw.Windo
 	glfw.WindowHint(glfw.ContextVersionMajor, major)
 	glfw.WindowHint(glfw.ContextVersionMinor, minor)
 
-	window, err := glfw.CreateWindow(gui.width, gui.height, "Terminal", nil, nil)
+	window, err := glfw.CreateWindow(gui.width, gui.height, gui.windowTitle(), nil, nil)
 	if err != nil {
 		e := err.Error()
 		if i := strings.Index(e, ", got version "); i > -1 {
@@ -396,6 +486,26 @@ func (gui *GUI) createWindowWithOpenGLVersion(major int, minor int) (*glfw.Windo
 	return window, nil
 }
 
+// colourOutput works out how colours are written to the window. Blending in linear light needs the window to encode
+// colours to sRGB itself, which not every driver can do, so it falls back to blending sRGB values as they are.
+func (gui *GUI) colourOutput() glfont.ColourOutput {
+	output := glfont.ColourOutput{Gamut: glfont.GamutSRGB}
+	if gui.config.Graphics.ColourSpace == config.ColourSpaceDisplayP3 {
+		output.Gamut = glfont.GamutDisplayP3
+	}
+	if gui.config.Graphics.LinearBlending {
+		var encoding int32
+		gl.GetFramebufferAttachmentParameteriv(gl.FRAMEBUFFER, gl.BACK_LEFT, gl.FRAMEBUFFER_ATTACHMENT_COLOR_ENCODING, &encoding)
+		if encoding == gl.SRGB {
+			gl.Enable(gl.FRAMEBUFFER_SRGB)
+			output.Linear = true
+		} else {
+			gui.logger.Warnf("The window can't encode sRGB, so text will be blended without gamma correction")
+		}
+	}
+	return output
+}
+
 // initOpenGL initializes OpenGL and returns an intiialized program.
 func (gui *GUI) createProgram() (uint32, error) {
 	if err := gl.Init(); err != nil {
@@ -405,22 +515,76 @@ func (gui *GUI) createProgram() (uint32, error) {
 
 	gui.logger.Debugf("Compiling shaders...")
 
-	vertexShader, err := compileShader(vertexShaderSource, gl.VERTEX_SHADER)
-	if err != nil {
-		return 0, err
+	return linkProgram(vertexShaderSource, fragmentShaderSource)
+}
+
+// windowTitle returns the fixed title if there is one, otherwise the title set by the running program. With the title
+// locked, programs can still set the title shown in the status bar. Read-only mode is flagged in the title.
+func (gui *GUI) windowTitle() string {
+	title := gui.terminal.GetTitle()
+	if gui.config.Title != "" {
+		title = gui.config.Title
+	} else if gui.config.TitleTemplate != "" {
+		title = gui.templateTitle()
+	} else if gui.config.LockTitle || title == "" {
+		title = "Terminal"
+	}
+	if gui.config.ReadOnly {
+		title += " [read only]"
+	}
+	if gui.terminal.OutputPaused() {
+		title += " [paused]"
 	}
+	return title
+}
 
-	fragmentShader, err := compileShader(fragmentShaderSource, gl.FRAGMENT_SHADER)
-	if err != nil {
-		return 0, err
+// templateTitle fills in the configured title template
+func (gui *GUI) templateTitle() string {
+	values := config.TitleValues{
+		Index:   1,
+		Command: gui.terminal.GetForegroundProcess(),
+	}
+	if dir := gui.terminal.GetWorkingDirectory(); dir != "" {
+		values.Cwd = filepath.Base(dir)
+	}
+	if !gui.config.LockTitle {
+		values.Title = gui.terminal.GetTitle()
 	}
+	if title := values.Expand(gui.config.TitleTemplate); title != "" {
+		return title
+	}
+	return "Terminal"
+}
 
-	prog := gl.CreateProgram()
-	gl.AttachShader(prog, vertexShader)
-	gl.AttachShader(prog, fragmentShader)
-	gl.LinkProgram(prog)
+// refreshTitle updates a templated window title every so often, as the working directory and foreground program
+// change without the terminal's title changing
+func (gui *GUI) refreshTitle() {
+	if gui.config.Title != "" || gui.config.TitleTemplate == "" || time.Since(gui.titleChecked) < time.Second {
+		return
+	}
+	gui.titleChecked = time.Now()
+	if title := gui.windowTitle(); title != gui.shownTitle {
+		gui.shownTitle = title
+		gui.window.SetTitle(title)
+	}
+}
 
-	return prog, nil
+// newWindow starts another aminal with the given arguments, in the directory the shell last reported being in if it is
+// on this machine
+func (gui *GUI) newWindow(args ...string) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(executable, args...)
+	if dir := gui.terminal.GetWorkingDirectory(); dir != "" && isLocalHost(gui.terminal.GetHost()) {
+		cmd.Dir = dir
+	}
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	return cmd.Process.Release()
 }
 
 func (gui *GUI) launchTarget(target string) {
@@ -0,0 +1,81 @@
+package gui
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/liamg/aminal/buffer"
+	"github.com/liamg/aminal/config"
+)
+
+type helpOverlay struct {
+	lines []string
+}
+
+// newHelpOverlay lists the shortcuts currently bound, so what is shown always matches the live configuration
+func newHelpOverlay(shortcuts map[config.UserAction]*config.KeyCombination) *helpOverlay {
+
+	actions := []config.UserAction{}
+	longest := 0
+	for action, combi := range shortcuts {
+		actions = append(actions, action)
+		if l := len(combi.String()); l > longest {
+			longest = l
+		}
+	}
+	sort.Slice(actions, func(i, j int) bool {
+		return actions[i] < actions[j]
+	})
+
+	lines := []string{"Keyboard shortcuts (press Escape to close)", ""}
+	for _, action := range actions {
+		lines = append(lines, fmt.Sprintf("%-*s  %s", longest, shortcuts[action].String(), action.Description()))
+	}
+
+	return &helpOverlay{
+		lines: lines,
+	}
+}
+
+func (h *helpOverlay) render(gui *GUI) {
+
+	width := 0
+	for _, line := range h.lines {
+		if len(line) > width {
+			width = len(line)
+		}
+	}
+	width += 2
+
+	viewWidth := int(gui.terminal.ActiveBuffer().ViewWidth())
+	viewHeight := int(gui.terminal.ActiveBuffer().ViewHeight())
+
+	col := 0
+	if viewWidth > width {
+		col = (viewWidth - width) / 2
+	}
+	row := 0
+	if viewHeight > len(h.lines)+2 {
+		row = (viewHeight - len(h.lines) - 2) / 2
+	}
+
+	bg := buffer.NewBackgroundCell(gui.colours.Black)
+	for y := row; y < row+len(h.lines)+2 && y < viewHeight; y++ {
+		for x := col; x < col+width && x < viewWidth; x++ {
+			gui.renderer.DrawCellBg(bg, uint(x), uint(y), false, nil, true)
+		}
+	}
+
+	f := gui.fontMap.GetFont('X')
+	fg := gui.colours.Foreground
+	f.SetColor(fg[0], fg[1], fg[2], 1)
+
+	x := float32(gui.renderer.areaX) + float32(col+1)*gui.renderer.cellWidth
+	for i, line := range h.lines {
+		if row+i+1 >= viewHeight {
+			break
+		}
+		y := float32(gui.renderer.areaY) + float32(row+i+2)*gui.renderer.cellHeight + f.MinY()
+		f.Print(x, y, line)
+	}
+}
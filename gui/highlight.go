@@ -0,0 +1,158 @@
+package gui
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+	"syscall"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/liamg/aminal/buffer"
+	"github.com/liamg/aminal/terminal"
+)
+
+// the exit status sh gives when the command it is asked to run isn't found
+const commandNotFound = 127
+
+// highlight runs text through the configured highlighter in the background, then shows the coloured result over the
+// terminal
+func (gui *GUI) highlight(text string) {
+
+	if gui.config.Pipe.Highlighter == "" {
+		gui.logger.Errorf("No highlighter is configured")
+		return
+	}
+
+	if text == "" {
+		return
+	}
+
+	go func() {
+		// overlays are only changed by the render loop
+		gui.overlays <- newHighlightOverlay(gui.runHighlighter(text))
+	}()
+}
+
+// runHighlighter runs text through the highlighter, returning a title for the result and the output to show. If the
+// highlighter isn't installed, e.g. bat by default, the text is shown uncoloured.
+func (gui *GUI) runHighlighter(text string) (string, string) {
+	cmd := exec.Command("sh", "-c", gui.config.Pipe.Highlighter)
+	cmd.Stdin = strings.NewReader(text)
+	output := &bytes.Buffer{}
+	cmd.Stdout = output
+	cmd.Stderr = output
+	err := cmd.Run()
+	if err == nil {
+		return "Highlighted selection", output.String()
+	}
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.Sys().(syscall.WaitStatus).ExitStatus() == commandNotFound {
+		gui.logger.Infof("Highlighter not found, showing the selection uncoloured: %s", output.String())
+		return "Selection (highlighter not installed)", text
+	}
+	gui.logger.Errorf("Highlighter failed: %s", err)
+	return fmt.Sprintf("Highlighter failed: %s", err), output.String()
+}
+
+// highlightOverlay shows the highlighter's output over the whole terminal, with a title line at the top
+type highlightOverlay struct {
+	title  string
+	output string
+	lines  []buffer.Line
+	width  uint16
+	offset int
+}
+
+func newHighlightOverlay(title string, output string) *highlightOverlay {
+	return &highlightOverlay{
+		title:  title,
+		output: output,
+	}
+}
+
+func (h *highlightOverlay) render(gui *GUI) {
+
+	viewWidth := gui.terminal.ActiveBuffer().ViewWidth()
+	viewHeight := int(gui.terminal.ActiveBuffer().ViewHeight())
+
+	// lay the output out again if the window has been resized, so it wraps at the new width
+	if h.lines == nil || h.width != viewWidth {
+		h.lines = terminal.RenderColoured(h.output, viewWidth, gui.config)
+		h.width = viewWidth
+		h.clampOffset(gui)
+	}
+
+	titleBg := buffer.NewBackgroundCell(gui.colours.Black)
+	bg := buffer.NewBackgroundCell(gui.colours.Background)
+	for x := 0; x < int(viewWidth); x++ {
+		gui.renderer.DrawCellBg(titleBg, uint(x), 0, false, nil, true)
+	}
+	for y := 1; y < viewHeight; y++ {
+		var cells []buffer.Cell
+		if i := h.offset + y - 1; i < len(h.lines) {
+			cells = h.lines[i].Cells()
+		}
+		for x := 0; x < int(viewWidth); x++ {
+			if x < len(cells) {
+				gui.renderer.DrawCellBg(cells[x], uint(x), uint(y), false, nil, true)
+				gui.renderer.DrawCellText(cells[x], uint(x), uint(y), 1, nil)
+			} else {
+				gui.renderer.DrawCellBg(bg, uint(x), uint(y), false, nil, true)
+			}
+		}
+	}
+
+	f := gui.fontMap.GetFont('X')
+	fg := gui.colours.Foreground
+	f.SetColor(fg[0], fg[1], fg[2], 1)
+	title := fmt.Sprintf(" %s (lines %d-%d of %d, press Escape to close)", h.title, h.offset+1, h.offset+h.pageSize(gui), len(h.lines))
+	f.Print(
+		float32(gui.renderer.areaX),
+		float32(gui.renderer.areaY)+gui.renderer.cellHeight+f.MinY(),
+		title,
+	)
+}
+
+// pageSize is the number of lines of output which fit below the title
+func (h *highlightOverlay) pageSize(gui *GUI) int {
+	size := int(gui.terminal.ActiveBuffer().ViewHeight()) - 1
+	if size > len(h.lines) {
+		size = len(h.lines)
+	}
+	if size < 1 {
+		size = 1
+	}
+	return size
+}
+
+func (h *highlightOverlay) clampOffset(gui *GUI) {
+	if max := len(h.lines) - h.pageSize(gui); h.offset > max {
+		h.offset = max
+	}
+	if h.offset < 0 {
+		h.offset = 0
+	}
+}
+
+func (h *highlightOverlay) key(gui *GUI, key glfw.Key, mods glfw.ModifierKey) {
+	defer gui.terminal.SetDirty()
+	switch key {
+	case glfw.KeyUp:
+		h.offset--
+	case glfw.KeyDown:
+		h.offset++
+	case glfw.KeyPageUp:
+		h.offset -= h.pageSize(gui)
+	case glfw.KeyPageDown, glfw.KeySpace:
+		h.offset += h.pageSize(gui)
+	case glfw.KeyHome:
+		h.offset = 0
+	case glfw.KeyEnd:
+		h.offset = len(h.lines)
+	}
+	h.clampOffset(gui)
+}
+
+func (h *highlightOverlay) char(gui *GUI, r rune) {}
+
+func (h *highlightOverlay) click(gui *GUI, x float64, y float64) {}
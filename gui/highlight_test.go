@@ -0,0 +1,27 @@
+package gui
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHighlighterOutputIsShown(t *testing.T) {
+	gui, _ := newTestGUI(t, `
+[pipe]
+  highlighter = "tr a-z A-Z"
+`)
+	title, output := gui.runHighlighter("hello")
+	assert.Equal(t, "Highlighted selection", title)
+	assert.Equal(t, "HELLO", output)
+}
+
+func TestSelectionIsShownUncolouredWithoutTheHighlighter(t *testing.T) {
+	gui, _ := newTestGUI(t, `
+[pipe]
+  highlighter = "aminal-missing-highlighter --color=always"
+`)
+	title, output := gui.runHighlighter("hello")
+	assert.Equal(t, "Selection (highlighter not installed)", title)
+	assert.Equal(t, "hello", output)
+}
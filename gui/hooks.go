@@ -0,0 +1,81 @@
+package gui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"sync/atomic"
+	"time"
+
+	"github.com/liamg/aminal/config"
+	"github.com/liamg/aminal/terminal"
+)
+
+// a program can trigger hooks as fast as it can print, so only so many are run
+const (
+	maxHooksPerSecond = 10
+	maxHooksRunning   = 4
+)
+
+// hookLimiter counts the hooks run recently and still running, so a program can't start endless processes
+type hookLimiter struct {
+	second  time.Time // the start of the second hooks are being counted in
+	started int       // hooks started in that second
+	running int32     // hooks which haven't finished, updated by their goroutines
+}
+
+// allow returns true if another hook can be started now, counting it if so
+func (limiter *hookLimiter) allow(now time.Time) bool {
+	if now.Sub(limiter.second) >= time.Second {
+		limiter.second = now
+		limiter.started = 0
+	}
+	if limiter.started >= maxHooksPerSecond || atomic.LoadInt32(&limiter.running) >= maxHooksRunning {
+		return false
+	}
+	limiter.started++
+	return true
+}
+
+// runHook runs the configured hook command in the background for an event raised by a program in the terminal, as
+// the hooks security policy and the hook limits allow
+func (gui *GUI) runHook(event terminal.UserEvent) {
+
+	if gui.config.Hooks.Command == "" {
+		gui.logger.Debugf("No hook command is configured for %s %s", event.Type, event.Name)
+		return
+	}
+
+	switch gui.config.Security.Hooks {
+	case config.PolicyAllow:
+		gui.startHook(event)
+	case config.PolicyAsk:
+		gui.setOverlay(newConfirmOverlay(fmt.Sprintf("Run the hook command for %s %s? (y/n)", event.Type, event.Name), func(gui *GUI) {
+			gui.startHook(event)
+		}))
+	default:
+		gui.logger.Infof("Denied by security policy: hook for %s %s", event.Type, event.Name)
+	}
+}
+
+func (gui *GUI) startHook(event terminal.UserEvent) {
+
+	if !gui.hooks.allow(time.Now()) {
+		gui.logger.Errorf("Too many hooks running or run in the last second, dropped %s %s", event.Type, event.Name)
+		return
+	}
+
+	atomic.AddInt32(&gui.hooks.running, 1)
+	go func() {
+		defer atomic.AddInt32(&gui.hooks.running, -1)
+		cmd := exec.Command("sh", "-c", gui.config.Hooks.Command)
+		cmd.Env = append(os.Environ(),
+			"AMINAL_EVENT="+event.Type,
+			"AMINAL_NAME="+event.Name,
+			"AMINAL_VALUE="+event.Value,
+		)
+		if err := cmd.Run(); err != nil {
+			gui.logger.Errorf("Hook command failed: %s", err)
+		}
+	}()
+}
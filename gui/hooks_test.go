@@ -0,0 +1,46 @@
+package gui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/liamg/aminal/terminal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHooksAreLimited(t *testing.T) {
+	limiter := hookLimiter{}
+	now := time.Now()
+	for i := 0; i < maxHooksPerSecond; i++ {
+		assert.True(t, limiter.allow(now))
+	}
+	assert.False(t, limiter.allow(now.Add(500*time.Millisecond)))
+	assert.True(t, limiter.allow(now.Add(time.Second)))
+
+	limiter.running = maxHooksRunning
+	assert.False(t, limiter.allow(now.Add(2*time.Second)))
+}
+
+func TestHooksFollowTheSecurityPolicy(t *testing.T) {
+	event := terminal.UserEvent{Type: "user_var", Name: "status", Value: "done"}
+
+	gui, _ := newTestGUI(t, `
+[hooks]
+  command = "true"
+[security]
+  hooks = "deny"
+`)
+	gui.runHook(event)
+	assert.Nil(t, gui.overlay)
+	assert.Equal(t, 0, gui.hooks.started)
+
+	gui, _ = newTestGUI(t, `
+[hooks]
+  command = "true"
+[security]
+  hooks = "ask"
+`)
+	gui.runHook(event)
+	assert.NotNil(t, gui.overlay)
+	assert.Equal(t, 0, gui.hooks.started)
+}
@@ -0,0 +1,37 @@
+package gui
+
+import (
+	"github.com/liamg/aminal/config"
+)
+
+const accentWidth = 4 // pixels
+
+// updateHostProfile applies the colours of the host profile matching the host the shell is running on, if it changed
+func (gui *GUI) updateHostProfile() {
+
+	profile := gui.config.HostProfile(gui.terminal.GetHost())
+	if profile == gui.hostProfile {
+		return
+	}
+	gui.hostProfile = profile
+
+	if profile != nil {
+		gui.logger.Infof("Applying colour profile for host %s", gui.terminal.GetHost())
+	}
+	gui.applyColours()
+}
+
+func (gui *GUI) renderAccent() {
+	if gui.hostProfile == nil || gui.hostProfile.Accent == nil {
+		return
+	}
+
+	var colour config.Colour = *gui.hostProfile.Accent
+	w := float32(gui.width)
+	h := float32(gui.height)
+
+	gui.renderer.DrawRect(0, 0, w, accentWidth, colour)
+	gui.renderer.DrawRect(0, h-accentWidth, w, accentWidth, colour)
+	gui.renderer.DrawRect(0, 0, accentWidth, h, colour)
+	gui.renderer.DrawRect(w-accentWidth, 0, accentWidth, h, colour)
+}
@@ -1,303 +1,132 @@
 package gui
 
 import (
-	"fmt"
+	"unicode"
 
 	"github.com/go-gl/glfw/v3.2/glfw"
 )
 
-// send typed runes straight through to the pty
-func (gui *GUI) char(w *glfw.Window, r rune) {
-	gui.terminal.Write([]byte(string(r)))
+// send typed runes through to the pty, with alt as meta
+func (gui *GUI) char(w *glfw.Window, r rune, mods glfw.ModifierKey) {
+	if input, ok := gui.overlay.(inputOverlay); ok {
+		if mods&(glfw.ModControl|glfw.ModAlt) == 0 {
+			input.char(gui, r)
+		}
+		return
+	}
+	if gui.swallowChar {
+		gui.swallowChar = false
+		return
+	}
+	if gui.pendingKey != nil && mods&glfw.ModControl != 0 && string(unicode.ToLower(r)) == gui.pendingName {
+		// ctrl + alt typed the key's own character, so it isn't AltGr, and the key's sequence stands
+		return
+	}
+	// the key typed a character, so that's sent rather than the sequence for the key
+	gui.pendingKey = nil
+	if gui.exitedKey(false) {
+		return
+	}
+	if gui.holdAbbreviationChar(r, mods) {
+		return
+	}
+	gui.terminal.Latency().KeyPressed()
+	gui.terminal.Write(gui.terminal.EncodeChar(r, terminalMods(mods)))
 }
 
-func modsPressed(pressed glfw.ModifierKey, mods ...glfw.ModifierKey) bool {
-	for _, mod := range mods {
-		if pressed&mod == 0 {
-			return false
-		}
-		pressed ^= mod
+// mightTypeCharacter returns true for key presses which may or may not type a character, depending on things glfw
+// doesn't tell us: keypad digits depend on num lock, and ctrl + alt is AltGr on some platforms
+func mightTypeCharacter(key glfw.Key, name string, mods glfw.ModifierKey) bool {
+	if isKeypadKey(key) && key != glfw.KeyKPEnter {
+		return true
 	}
-	return pressed == 0
+	return name != "" && mods&glfw.ModControl != 0 && mods&glfw.ModAlt != 0
 }
 
-func getModStr(mods glfw.ModifierKey) string {
+func isKeypadKey(key glfw.Key) bool {
+	return key >= glfw.KeyKP0 && key <= glfw.KeyKPEqual
+}
 
-	switch true {
-	case modsPressed(mods, glfw.ModControl, glfw.ModShift, glfw.ModAlt):
-		return "8"
-	case modsPressed(mods, glfw.ModControl, glfw.ModAlt):
-		return "7"
-	case modsPressed(mods, glfw.ModControl, glfw.ModShift):
-		return "6"
-	case modsPressed(mods, glfw.ModControl):
-		return "5"
-	case modsPressed(mods, glfw.ModAlt, glfw.ModShift):
-		return "4"
-	case modsPressed(mods, glfw.ModAlt):
-		return "3"
-	case modsPressed(mods, glfw.ModShift):
-		return "2"
+// flushPendingKey sends the sequence for the last key press if it turned out not to type a character. Character
+// callbacks follow the key callback for the same press straight away, so this is called once events have been
+// processed, and before handling the next key press.
+func (gui *GUI) flushPendingKey() {
+	if gui.pendingKey != nil {
+		gui.flushAbbreviation()
+		gui.terminal.Latency().KeyPressed()
+		gui.terminal.Write(gui.pendingKey)
 	}
-
-	return ""
+	gui.pendingKey = nil
+	gui.swallowChar = false
 }
 
 func (gui *GUI) key(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
 
 	if action == glfw.Repeat || action == glfw.Press {
-
-		if gui.overlay != nil {
-			if key == glfw.KeyEscape {
-				gui.setOverlay(nil)
-			}
-		}
-
 		// get key name to handle alternative keyboard layouts
-		name := glfw.GetKeyName(key, scancode)
-		if len(name) == 1 {
-			r := rune(name[0])
-			for userAction, shortcut := range gui.keyboardShortcuts {
-				if shortcut.Match(mods, r) {
-					f, ok := actionMap[userAction]
-					if ok {
-						f(gui)
-						break
-					}
-				}
-			}
+		gui.keyPressed(key, glfw.GetKeyName(key, scancode), mods)
+	}
 
-			// standard ctrl codes e.g. ^C
-			if modsPressed(mods, glfw.ModControl) {
-				if r >= 97 && r < 123 {
-					gui.terminal.Write([]byte{byte(r) - 96})
-					return
-				} else if r >= 65 && r < 91 {
-					gui.terminal.Write([]byte{byte(r) - 64})
-					return
-				}
-			}
-		}
+}
 
-		modStr := getModStr(mods)
+// keyPressed handles a key being pressed or repeating. The name is the character the key types, if it types one,
+// which glfw sends to char straight after this.
+func (gui *GUI) keyPressed(key glfw.Key, name string, mods glfw.ModifierKey) {
 
-		switch key {
-		case glfw.KeyF1:
-			gui.terminal.Write([]byte{
-				0x1b,
-				'O',
-				'P',
-			})
-		case glfw.KeyF2:
-			gui.terminal.Write([]byte{
-				0x1b,
-				'O',
-				'Q',
-			})
-		case glfw.KeyF3:
-			gui.terminal.Write([]byte{
-				0x1b,
-				'O',
-				'R',
-			})
-		case glfw.KeyF4:
-			gui.terminal.Write([]byte{
-				0x1b,
-				'O',
-				'S',
-			})
-		case glfw.KeyF5:
-			gui.terminal.Write([]byte{
-				0x1b,
-				'[',
-				'1', '5', '~',
-			})
-		case glfw.KeyF6:
-			gui.terminal.Write([]byte{
-				0x1b,
-				'[',
-				'1', '7', '~',
-			})
-		case glfw.KeyF7:
-			gui.terminal.Write([]byte{
-				0x1b,
-				'[',
-				'1', '8', '~',
-			})
-		case glfw.KeyF8:
-			gui.terminal.Write([]byte{
-				0x1b,
-				'[',
-				'1', '9', '~',
-			})
-		case glfw.KeyF9:
-			gui.terminal.Write([]byte{
-				0x1b,
-				'[',
-				'2', '0', '~',
-			})
-		case glfw.KeyF10:
-			gui.terminal.Write([]byte{
-				0x1b,
-				'[',
-				'2', '1', '~',
-			})
-		case glfw.KeyF11:
-			gui.terminal.Write([]byte{
-				0x1b,
-				'[',
-				'2', '3', '~',
-			})
-		case glfw.KeyF12:
-			gui.terminal.Write([]byte{
-				0x1b,
-				'[',
-				'2', '4', '~',
-			})
-		case glfw.KeyInsert:
-			gui.terminal.Write([]byte{
-				0x1b,
-				'[',
-				'2', '~',
-			})
-		case glfw.KeyDelete:
-			gui.terminal.Write([]byte{
-				0x1b,
-				'[',
-				'3', '~',
-			})
-		case glfw.KeyHome:
-			if modStr == "" {
-				gui.terminal.Write([]byte("\x1b[1~"))
-			} else {
-				gui.terminal.Write([]byte(fmt.Sprintf("\x1b[1;%s~", modStr)))
-			}
-		case glfw.KeyEnd:
-			if modStr == "" {
-				gui.terminal.Write([]byte("\x1b[4~"))
-			} else {
-				gui.terminal.Write([]byte(fmt.Sprintf("\x1b[4;%s~", modStr)))
-			}
-		case glfw.KeyPageUp:
-			if modStr == "" {
-				gui.terminal.Write([]byte("\x1b[5~"))
-			} else {
-				gui.terminal.Write([]byte(fmt.Sprintf("\x1b[5;%s~", modStr)))
-			}
-		case glfw.KeyPageDown:
-			if modStr == "" {
-				gui.terminal.Write([]byte("\x1b[6~"))
-			} else {
-				gui.terminal.Write([]byte(fmt.Sprintf("\x1b[6;%s~", modStr)))
-			}
-		case glfw.KeyEscape:
-			if gui.terminal.IsApplicationCursorKeysModeEnabled() {
-				gui.terminal.Write([]byte{
-					0x1b,
-				})
-			} else {
-				gui.terminal.Write([]byte{
-					0x1b,
-					'[',
-					0x1b,
-				})
-			}
-		case glfw.KeyTab:
-			gui.terminal.Write([]byte{
-				0x09,
-			})
-		case glfw.KeyEnter:
-			gui.terminal.Write([]byte{
-				0x0d,
-			})
-		case glfw.KeyKPEnter:
-			if gui.terminal.IsApplicationCursorKeysModeEnabled() {
-				gui.terminal.Write([]byte{
-					0x1b,
-					'O',
-					'M',
-				})
-			} else {
-				gui.terminal.Write([]byte{
-					0x0d,
-				})
-			}
-		case glfw.KeyBackspace:
-			gui.terminal.Write([]byte{0x08})
-		case glfw.KeyUp:
-			if modStr != "" {
-				gui.terminal.Write([]byte(fmt.Sprintf("\x1b[1;%sA", modStr)))
-			}
+	gui.flushPendingKey()
 
-			if gui.terminal.IsApplicationCursorKeysModeEnabled() {
-				gui.terminal.Write([]byte{
-					0x1b,
-					'O',
-					'A',
-				})
-			} else {
-				gui.terminal.Write([]byte{
-					0x1b,
-					'[',
-					'A',
-				})
-			}
-		case glfw.KeyDown:
+	if gui.overlay != nil {
+		if key == glfw.KeyEscape {
+			gui.setOverlay(nil)
+			return
+		}
+	}
 
-			if modStr != "" {
-				gui.terminal.Write([]byte(fmt.Sprintf("\x1b[1;%sB", modStr)))
+	for userAction, shortcut := range gui.keyboardShortcuts {
+		if shortcut.MatchKey(mods, key) || (len(name) == 1 && shortcut.Match(mods, rune(name[0]))) {
+			f, ok := actionMap[userAction]
+			if ok {
+				f(gui)
+				return
 			}
+		}
+	}
 
-			if gui.terminal.IsApplicationCursorKeysModeEnabled() {
-				gui.terminal.Write([]byte{
-					0x1b,
-					'O',
-					'B',
-				})
-			} else {
-				gui.terminal.Write([]byte{
-					0x1b,
-					'[',
-					'B',
-				})
-			}
-		case glfw.KeyLeft:
-			if modStr != "" {
-				gui.terminal.Write([]byte(fmt.Sprintf("\x1b[1;%sD", modStr)))
-			}
+	if input, ok := gui.overlay.(inputOverlay); ok {
+		input.key(gui, key, mods)
+		return
+	}
 
-			if gui.terminal.IsApplicationCursorKeysModeEnabled() {
-				gui.terminal.Write([]byte{
-					0x1b,
-					'O',
-					'D',
-				})
-			} else {
-				gui.terminal.Write([]byte{
-					0x1b,
-					'[',
-					'D',
-				})
-			}
-		case glfw.KeyRight:
-			if modStr != "" {
-				gui.terminal.Write([]byte(fmt.Sprintf("\x1b[1;%sC", modStr)))
-			}
+	if text, ok := gui.config.Macros.Macro(key, name, mods); ok {
+		gui.flushAbbreviation()
+		gui.runMacro(text)
+		return
+	}
+	if gui.abbreviationKey(key, name, mods) {
+		return
+	}
 
-			if gui.terminal.IsApplicationCursorKeysModeEnabled() {
-				gui.terminal.Write([]byte{
-					0x1b,
-					'O',
-					'C',
-				})
-			} else {
-				gui.terminal.Write([]byte{
-					0x1b,
-					'[',
-					'C',
-				})
-			}
+	if gui.exitedKey(key == glfw.KeyEnter || key == glfw.KeyKPEnter) {
+		return
+	}
+
+	seq := gui.encodeKey(key, name, mods)
+	if mightTypeCharacter(key, name, mods) {
+		if isKeypadKey(key) && gui.terminal.IsApplicationKeypadModeEnabled() {
+			// the application keypad sends its sequences whatever num lock says, so drop any digit typed too
+			gui.swallowChar = true
+		} else {
+			gui.pendingKey = seq
+			gui.pendingName = name
+			return
 		}
 	}
+	if seq != nil {
+		gui.terminal.Latency().KeyPressed()
+		gui.terminal.Write(seq)
+		// the sequence stands for the whole key press, so don't send any character it types as well, e.g. the 2
+		// of ctrl + 2
+		gui.swallowChar = true
+	}
 
 }
@@ -0,0 +1,72 @@
+package gui
+
+import (
+	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/liamg/aminal/terminal"
+)
+
+// terminalKeys are the terminal's names for the glfw keys it encodes which don't type a character - glfw numbers those
+// which do by the character, as the terminal does
+var terminalKeys = map[glfw.Key]terminal.Key{
+	glfw.KeyEscape:     terminal.KeyEscape,
+	glfw.KeyEnter:      terminal.KeyEnter,
+	glfw.KeyTab:        terminal.KeyTab,
+	glfw.KeyBackspace:  terminal.KeyBackspace,
+	glfw.KeyInsert:     terminal.KeyInsert,
+	glfw.KeyDelete:     terminal.KeyDelete,
+	glfw.KeyRight:      terminal.KeyRight,
+	glfw.KeyLeft:       terminal.KeyLeft,
+	glfw.KeyDown:       terminal.KeyDown,
+	glfw.KeyUp:         terminal.KeyUp,
+	glfw.KeyPageUp:     terminal.KeyPageUp,
+	glfw.KeyPageDown:   terminal.KeyPageDown,
+	glfw.KeyHome:       terminal.KeyHome,
+	glfw.KeyEnd:        terminal.KeyEnd,
+	glfw.KeyKPDecimal:  terminal.KeyKPDecimal,
+	glfw.KeyKPDivide:   terminal.KeyKPDivide,
+	glfw.KeyKPMultiply: terminal.KeyKPMultiply,
+	glfw.KeyKPSubtract: terminal.KeyKPSubtract,
+	glfw.KeyKPAdd:      terminal.KeyKPAdd,
+	glfw.KeyKPEnter:    terminal.KeyKPEnter,
+	glfw.KeyKPEqual:    terminal.KeyKPEqual,
+}
+
+// terminalKey returns the terminal's name for a glfw key, or terminal.KeyUnknown if it has nothing to send for it
+func terminalKey(key glfw.Key) terminal.Key {
+	switch {
+	case key >= glfw.KeySpace && key <= glfw.KeyGraveAccent:
+		return terminal.Key(key)
+	case key >= glfw.KeyF1 && key <= glfw.KeyF24:
+		return terminal.KeyF1 + terminal.Key(key-glfw.KeyF1)
+	case key >= glfw.KeyKP0 && key <= glfw.KeyKP9:
+		return terminal.KeyKP0 + terminal.Key(key-glfw.KeyKP0)
+	}
+	return terminalKeys[key]
+}
+
+// terminalMods returns the terminal's modifiers for glfw's
+func terminalMods(mods glfw.ModifierKey) terminal.Modifiers {
+	var m terminal.Modifiers
+	if mods&glfw.ModShift != 0 {
+		m |= terminal.ModShift
+	}
+	if mods&glfw.ModControl != 0 {
+		m |= terminal.ModControl
+	}
+	if mods&glfw.ModAlt != 0 {
+		m |= terminal.ModAlt
+	}
+	if mods&glfw.ModSuper != 0 {
+		m |= terminal.ModSuper
+	}
+	return m
+}
+
+// encodeKey returns the bytes to send to the pty for a key press: the override configured for it, if any, or what the
+// terminal encodes it as
+func (gui *GUI) encodeKey(key glfw.Key, name string, mods glfw.ModifierKey) []byte {
+	if send, ok := gui.config.Input.Override(key, name, mods); ok {
+		return []byte(send)
+	}
+	return gui.terminal.EncodeKey(terminalKey(key), name, terminalMods(mods))
+}
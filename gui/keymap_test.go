@@ -0,0 +1,29 @@
+package gui
+
+import (
+	"testing"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/liamg/aminal/terminal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGLFWKeysAreMappedToTheTerminals(t *testing.T) {
+	assert.Equal(t, terminal.Key('A'), terminalKey(glfw.KeyA))
+	assert.Equal(t, terminal.Key('['), terminalKey(glfw.KeyLeftBracket))
+	assert.Equal(t, terminal.KeySpace, terminalKey(glfw.KeySpace))
+	assert.Equal(t, terminal.KeyF13, terminalKey(glfw.KeyF13))
+	assert.Equal(t, terminal.KeyKP7, terminalKey(glfw.KeyKP7))
+	assert.Equal(t, terminal.KeyPageDown, terminalKey(glfw.KeyPageDown))
+	assert.Equal(t, terminal.KeyUnknown, terminalKey(glfw.KeyLeftShift))
+	assert.Equal(t, terminal.ModControl|terminal.ModAlt, terminalMods(glfw.ModControl|glfw.ModAlt))
+}
+
+func TestKeyOverridesTakePriority(t *testing.T) {
+	gui, _ := newTestGUI(t, `
+[input.overrides]
+  backspace = "\b"
+`)
+	assert.Equal(t, "\b", string(gui.encodeKey(glfw.KeyBackspace, "", 0)))
+	assert.Equal(t, "\x1b[A", string(gui.encodeKey(glfw.KeyUp, "", 0)))
+}
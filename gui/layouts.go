@@ -0,0 +1,96 @@
+package gui
+
+import (
+	"math"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/liamg/aminal/buffer"
+	"github.com/liamg/aminal/config"
+	"github.com/liamg/aminal/layout"
+)
+
+// currentLayout describes how the window is split into panes, with the directory each shell is in and the command
+// running in it, so they can be opened again later
+func (gui *GUI) currentLayout() *config.Session {
+	var describe func(n *layout.Node) config.PaneLayout
+	describe = func(n *layout.Node) config.PaneLayout {
+		if !n.IsPane() {
+			split := config.SplitRight
+			if n.Direction == layout.Vertical {
+				split = config.SplitDown
+			}
+			return config.PaneLayout{
+				Split: split,
+				Size:  math.Round(n.Ratio*100) / 100,
+				Panes: []config.PaneLayout{describe(n.First), describe(n.Second)},
+			}
+		}
+		p := gui.panes[n.Pane]
+		pane := config.PaneLayout{Command: p.command}
+		if pane.Command == "" {
+			pane.Command = p.startCommand
+		}
+		if isLocalHost(p.terminal.GetHost()) {
+			pane.Directory = p.terminal.GetWorkingDirectory()
+		}
+		return pane
+	}
+	return &config.Session{PaneLayout: describe(gui.layout.Describe())}
+}
+
+// openSavedLayout opens a layout saved with save_layout in a new window
+func (gui *GUI) openSavedLayout(name string) {
+	if err := gui.newWindow("--layout", name); err != nil {
+		gui.logger.Errorf("Failed to open layout %s: %s", name, err)
+	}
+}
+
+// layoutPrompt asks for the name to save the window's layout under
+type layoutPrompt struct {
+	input string
+}
+
+func (p *layoutPrompt) render(gui *GUI) {
+	width := int(gui.terminal.ActiveBuffer().ViewWidth())
+	bg := buffer.NewBackgroundCell(gui.colours.Black)
+	for x := 0; x < width; x++ {
+		gui.renderer.DrawCellBg(bg, uint(x), 0, false, nil, true)
+	}
+
+	line := []rune(" Save layout as: " + p.input + "_")
+	if len(line) > width {
+		line = line[len(line)-width:]
+	}
+	f := gui.fontMap.GetFont('X')
+	fg := gui.colours.Foreground
+	f.SetColor(fg[0], fg[1], fg[2], 1)
+	f.Print(float32(gui.renderer.areaX), float32(gui.renderer.areaY)+gui.renderer.cellHeight+f.MinY(), string(line))
+}
+
+func (p *layoutPrompt) key(gui *GUI, key glfw.Key, mods glfw.ModifierKey) {
+	defer gui.terminal.SetDirty()
+	switch key {
+	case glfw.KeyEnter, glfw.KeyKPEnter:
+		gui.setOverlay(nil)
+		if err := config.SaveLayout(p.input, gui.currentLayout()); err != nil {
+			gui.logger.Errorf("Failed to save layout: %s", err)
+		}
+	case glfw.KeyBackspace:
+		if p.input != "" {
+			runes := []rune(p.input)
+			p.input = string(runes[:len(runes)-1])
+		}
+	}
+}
+
+func (p *layoutPrompt) char(gui *GUI, r rune) {
+	// names are made of the same characters as snippet abbreviations, so they are safe file names
+	if config.IsAbbreviationRune(r) {
+		p.input += string(r)
+		gui.terminal.SetDirty()
+	}
+}
+
+func (p *layoutPrompt) click(gui *GUI, x float64, y float64) {
+	gui.setOverlay(nil)
+}
@@ -0,0 +1,187 @@
+package gui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/liamg/aminal/buffer"
+	"github.com/liamg/aminal/config"
+)
+
+// hover underlines the link under the mouse, if there is one, and shows the hand cursor over it
+func (gui *GUI) hover(w *glfw.Window, col uint16, row uint16) {
+	link := gui.linkAtPosition(col, row)
+	if link != nil {
+		w.SetCursor(glfw.CreateStandardCursor(glfw.HandCursor))
+	} else {
+		w.SetCursor(glfw.CreateStandardCursor(glfw.ArrowCursor))
+	}
+	if !sameLink(link, gui.hoverLink) {
+		gui.hoverLink = link
+		gui.hoverCol, gui.hoverRow = col, row
+		gui.terminal.SetDirty()
+	}
+}
+
+// linkAtPosition returns the link at a position in the view of the focused pane, leaving out git commits and diff files
+// when they are configured to be plain text
+func (gui *GUI) linkAtPosition(col uint16, row uint16) *buffer.Link {
+	link := gui.terminal.ActiveBuffer().GetLinkAtPosition(col, row)
+	switch {
+	case link == nil:
+		return nil
+	case link.Commit != "" && gui.config.Git.Commit == config.GitActionNone:
+		return nil
+	case link.File != nil && link.File.RepoRelative && gui.config.Git.DiffFile == config.GitActionNone:
+		return nil
+	}
+	return link
+}
+
+func sameLink(a *buffer.Link, b *buffer.Link) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if a.File != nil && b.File != nil {
+		x, y := *a, *b
+		x.File, y.File = nil, nil
+		return x == y && *a.File == *b.File
+	}
+	return *a == *b
+}
+
+// linkTarget returns where a link goes, for showing to the user
+func linkTarget(link *buffer.Link) string {
+	if link.File != nil {
+		return link.File.String()
+	}
+	if link.Commit != "" {
+		return "commit " + link.Commit
+	}
+	return link.URL
+}
+
+// openLink opens a clicked link, a file location in the editor, a git commit as configured and anything else in the
+// browser or similar
+func (gui *GUI) openLink(link *buffer.Link) {
+	if link.Commit != "" {
+		gui.openCommit(link.Commit)
+		return
+	}
+	if link.File == nil {
+		gui.openURL(link.URL)
+		return
+	}
+	if link.File.RepoRelative && gui.config.Git.DiffFile == config.GitActionCopy {
+		gui.window.SetClipboardString(link.File.Path)
+		return
+	}
+	if !isLocalHost(gui.terminal.GetHost()) {
+		gui.logger.Infof("Not opening %s, which is on %s", link.File, gui.terminal.GetHost())
+		return
+	}
+	path := link.File.Path
+	if link.File.RepoRelative {
+		root := gitRoot(gui.terminal.GetWorkingDirectory())
+		if root == "" {
+			gui.logger.Infof("Not opening %s, as the shell isn't in a git repository", link.File)
+			return
+		}
+		path = filepath.Join(root, path)
+	}
+	if home := os.Getenv("HOME"); home != "" && strings.HasPrefix(path, "~/") {
+		path = filepath.Join(home, path[2:])
+	}
+	// paths in output are relative to wherever the shell was when it was printed, which is the best guess we have
+	if dir := gui.terminal.GetWorkingDirectory(); !filepath.IsAbs(path) && dir != "" {
+		path = filepath.Join(dir, path)
+	}
+	if _, err := os.Stat(path); err != nil {
+		gui.logger.Errorf("Failed to open %s: %s", link.File, err)
+		return
+	}
+	line := link.File.Line
+	if line < 1 {
+		line = 1
+	}
+	if err := gui.openInEditorAt(path, line, link.File.Column); err != nil {
+		gui.logger.Errorf("Failed to open %s in the editor: %s", link.File, err)
+	}
+}
+
+// linkClick returns whether a click with the given modifiers held should open a link
+func (gui *GUI) linkClick(mods glfw.ModifierKey) bool {
+	linkMod := gui.config.Mouse.LinkMod()
+	return linkMod == 0 || mods&linkMod != 0
+}
+
+// renderHoverLink underlines the cells of the link under the mouse
+func (gui *GUI) renderHoverLink(lines []buffer.Line) {
+	if gui.hoverLink == nil {
+		return
+	}
+	r := gui.renderer
+	thickness := r.cellHeight / 16
+	if thickness < 1 {
+		thickness = 1
+	}
+	for y := range lines {
+		if y >= int(gui.terminal.ActiveBuffer().ViewHeight()) {
+			break
+		}
+		cells := lines[y].Cells()
+		for x := range cells {
+			if !gui.hoverLink.Covers(&cells[x], uint16(x), uint16(y)) {
+				continue
+			}
+			colour := r.TextColour(cells[x])
+			left := float32(r.areaX) + float32(x)*r.cellWidth
+			top := float32(r.areaY) + float32(y+1)*r.cellHeight - thickness
+			r.DrawRect(left, top, r.cellWidth, thickness, colour)
+		}
+	}
+}
+
+// renderLinkPreview shows the URL of the link under the mouse just below it, before it's clicked, as the text of a link
+// set with OSC 8 can be different to where it goes
+func (gui *GUI) renderLinkPreview() {
+	if gui.hoverLink == nil || !gui.config.Mouse.LinkPreview {
+		return
+	}
+
+	buf := gui.terminal.ActiveBuffer()
+	viewWidth := int(buf.ViewWidth())
+	maxLength := viewWidth - 2
+	if maxLength < 4 {
+		return
+	}
+	text := []rune(linkTarget(gui.hoverLink))
+	if len(text) > maxLength {
+		text = append(text[:maxLength-3], []rune("...")...)
+	}
+	width := len(text) + 2
+
+	col := int(gui.hoverCol)
+	if col+width > viewWidth {
+		col = viewWidth - width
+	}
+	row := int(gui.hoverRow) + 1
+	if row >= int(buf.ViewHeight()) {
+		row = int(gui.hoverRow) - 1
+	}
+	if row < 0 {
+		return
+	}
+
+	r := gui.renderer
+	scheme := gui.colours
+	x := float32(r.areaX) + float32(col)*r.cellWidth
+	y := float32(r.areaY) + float32(row)*r.cellHeight
+	r.DrawRect(x, y, float32(width)*r.cellWidth, r.cellHeight, scheme.Black)
+
+	f := gui.fontMap.GetFont('X')
+	f.SetColor(scheme.Foreground[0], scheme.Foreground[1], scheme.Foreground[2], 1)
+	f.Print(x, y+r.cellHeight+f.MinY(), " "+string(text))
+}
@@ -0,0 +1,174 @@
+package gui
+
+import (
+	"fmt"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/liamg/aminal/buffer"
+	"github.com/liamg/aminal/config"
+)
+
+// runMacro sends the text of a macro or snippet to the shell, first asking for the values of any placeholders in it
+func (gui *GUI) runMacro(text string) {
+	names := config.MacroPlaceholders(text)
+	if len(names) == 0 {
+		gui.sendMacro(text)
+		return
+	}
+	gui.setOverlay(&macroPrompt{text: text, names: names, values: map[string]string{}})
+}
+
+func (gui *GUI) sendMacro(text string) {
+	gui.terminal.Latency().KeyPressed()
+	if err := gui.terminal.Write([]byte(text)); err != nil {
+		gui.logger.Errorf("Failed to send macro: %s", err)
+	}
+}
+
+// holdAbbreviationChar holds back a typed character which could be part of the snippet prefix or the abbreviation
+// after it, so the shell doesn't see it unless it turns out not to be. It returns true if the character was held.
+func (gui *GUI) holdAbbreviationChar(r rune, mods glfw.ModifierKey) bool {
+	macros := &gui.config.Macros
+	if !gui.abbreviating() || mods&(glfw.ModControl|glfw.ModAlt|glfw.ModSuper) != 0 {
+		gui.flushAbbreviation()
+		return false
+	}
+
+	defer gui.terminal.SetDirty()
+	prefix := []rune(macros.Prefix)
+	held := append(gui.pane.held, r)
+	if len(held) <= len(prefix) && string(held) == string(prefix[:len(held)]) ||
+		len(held) > len(prefix) && config.IsAbbreviationRune(r) {
+		gui.pane.held = held
+		return true
+	}
+	gui.flushAbbreviation()
+	if r == prefix[0] {
+		gui.pane.held = []rune{r}
+		return true
+	}
+	return false
+}
+
+// abbreviating returns true if typed characters should be watched for abbreviations. Passwords aren't held back, and
+// neither is anything typed into full screen programs, which have their own uses for escape.
+func (gui *GUI) abbreviating() bool {
+	return gui.config.Macros.HasSnippets() && gui.terminal.UsingMainBuffer() && !gui.terminal.SecretInput()
+}
+
+// abbreviationKey expands the abbreviation being typed if the expand key is pressed, and handles deleting it or
+// cancelling it with escape. The name is the character the key types, if it types one: those keys are left to
+// holdAbbreviationChar, as glfw sends the character after the key. Other keys send what was held back before them.
+// It returns true if it used the key.
+func (gui *GUI) abbreviationKey(key glfw.Key, name string, mods glfw.ModifierKey) bool {
+	held := gui.pane.held
+	if len(held) == 0 {
+		return false
+	}
+	if (name != "" || key == glfw.KeySpace) && mods&(glfw.ModControl|glfw.ModAlt|glfw.ModSuper) == 0 {
+		return false
+	}
+	defer gui.terminal.SetDirty()
+	if !gui.abbreviating() {
+		gui.flushAbbreviation()
+		return false
+	}
+
+	prefix := []rune(gui.config.Macros.Prefix)
+	switch {
+	case gui.config.Macros.IsExpandKey(key, mods) && len(held) > len(prefix):
+		if text, ok := gui.config.Macros.Snippet(string(held[len(prefix):])); ok {
+			gui.pane.held = nil
+			gui.runMacro(text)
+			return true
+		}
+	case key == glfw.KeyBackspace && mods == 0:
+		gui.pane.held = held[:len(held)-1]
+		return true
+	case key == glfw.KeyEscape && mods == 0:
+		gui.pane.held = nil
+		return true
+	}
+	gui.flushAbbreviation()
+	return false
+}
+
+// flushAbbreviation sends the characters which were held back, as they turned out not to be an abbreviation
+func (gui *GUI) flushAbbreviation() {
+	held := gui.pane.held
+	if len(held) == 0 {
+		return
+	}
+	gui.pane.held = nil
+	gui.terminal.SetDirty()
+	for _, r := range held {
+		gui.terminal.Write(gui.terminal.EncodeChar(r, 0))
+	}
+}
+
+// renderAbbreviationBadge shows the characters held back as an abbreviation is typed, at the cursor
+func (gui *GUI) renderAbbreviationBadge() {
+	if len(gui.pane.held) == 0 || !gui.abbreviating() {
+		return
+	}
+	buf := gui.terminal.ActiveBuffer()
+	row := uint(gui.terminal.GetLogicalCursorY()) + gui.terminal.GetScrollOffset()
+	if row >= uint(buf.ViewHeight()) {
+		return
+	}
+	gui.textbox(gui.terminal.GetLogicalCursorX(), uint16(row), string(gui.pane.held), gui.colours.Background, gui.colours.Yellow)
+}
+
+// macroPrompt asks for the values of a macro's placeholders one at a time, then sends it
+type macroPrompt struct {
+	text    string
+	names   []string
+	values  map[string]string
+	current int
+	input   string
+}
+
+func (p *macroPrompt) render(gui *GUI) {
+	width := int(gui.terminal.ActiveBuffer().ViewWidth())
+	bg := buffer.NewBackgroundCell(gui.colours.Black)
+	for x := 0; x < width; x++ {
+		gui.renderer.DrawCellBg(bg, uint(x), 0, false, nil, true)
+	}
+
+	line := []rune(fmt.Sprintf(" %s (%d/%d): %s_", p.names[p.current], p.current+1, len(p.names), p.input))
+	if len(line) > width {
+		line = line[len(line)-width:] // keep the end being typed in view
+	}
+	f := gui.fontMap.GetFont('X')
+	fg := gui.colours.Foreground
+	f.SetColor(fg[0], fg[1], fg[2], 1)
+	f.Print(float32(gui.renderer.areaX), float32(gui.renderer.areaY)+gui.renderer.cellHeight+f.MinY(), string(line))
+}
+
+func (p *macroPrompt) key(gui *GUI, key glfw.Key, mods glfw.ModifierKey) {
+	defer gui.terminal.SetDirty()
+	switch key {
+	case glfw.KeyEnter, glfw.KeyKPEnter:
+		p.values[p.names[p.current]] = p.input
+		p.input = ""
+		p.current++
+		if p.current == len(p.names) {
+			gui.setOverlay(nil)
+			gui.sendMacro(config.FillMacro(p.text, p.values))
+		}
+	case glfw.KeyBackspace:
+		if p.input != "" {
+			runes := []rune(p.input)
+			p.input = string(runes[:len(runes)-1])
+		}
+	}
+}
+
+func (p *macroPrompt) char(gui *GUI, r rune) {
+	p.input += string(r)
+	gui.terminal.SetDirty()
+}
+
+// click does nothing, so the prompt isn't lost by clicking elsewhere while looking something up
+func (p *macroPrompt) click(gui *GUI, x float64, y float64) {
+}
@@ -0,0 +1,111 @@
+package gui
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/liamg/aminal/config"
+	"github.com/liamg/aminal/terminal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type recordingPty struct {
+	bytes.Buffer
+	secret bool
+}
+
+func (pty *recordingPty) Close() error {
+	return nil
+}
+
+func (pty *recordingPty) Resize(cols uint16, rows uint16) error {
+	return nil
+}
+
+func (pty *recordingPty) SecretInput() bool {
+	return pty.secret
+}
+
+// newTestGUI returns a GUI with a single pane, which can take input without a window
+func newTestGUI(t *testing.T, conf string) (*GUI, *recordingPty) {
+	c, err := config.Parse([]byte(conf))
+	require.Nil(t, err)
+	pty := &recordingPty{}
+	term := terminal.New(pty, zap.NewNop().Sugar(), c)
+	require.Nil(t, term.SetSize(80, 24))
+	return &GUI{
+		config:            c,
+		logger:            zap.NewNop().Sugar(),
+		terminal:          term,
+		pane:              newPane(0, term),
+		keyboardShortcuts: map[config.UserAction]*config.KeyCombination{},
+	}, pty
+}
+
+// typeText presses the key for each character, then types it, in the order glfw calls back for them
+func typeText(gui *GUI, text string) {
+	for _, r := range text {
+		gui.keyPressed(glfw.Key(r), string(r), 0)
+		gui.char(nil, r, 0)
+	}
+	gui.flushPendingKey()
+}
+
+func pressKey(gui *GUI, key glfw.Key) {
+	gui.keyPressed(key, "", 0)
+	gui.flushPendingKey()
+}
+
+const snippetConf = `
+[macros.snippets]
+  k8s = "kubectl get pods"
+`
+
+func TestAbbreviationExpands(t *testing.T) {
+	gui, pty := newTestGUI(t, snippetConf)
+
+	typeText(gui, ";;k8s")
+	assert.Equal(t, "", pty.String())
+	assert.Equal(t, ";;k8s", string(gui.pane.held))
+
+	pressKey(gui, glfw.KeyTab)
+	assert.Equal(t, "kubectl get pods", pty.String())
+	assert.Empty(t, gui.pane.held)
+}
+
+func TestAbbreviationIsSentIfNotOne(t *testing.T) {
+	gui, pty := newTestGUI(t, snippetConf)
+
+	typeText(gui, ";;k8s!")
+	assert.Equal(t, ";;k8s!", pty.String())
+
+	pty.Reset()
+	typeText(gui, ";;nope")
+	pressKey(gui, glfw.KeyTab)
+	assert.Equal(t, ";;nope\t", pty.String())
+
+	pty.Reset()
+	typeText(gui, ";;k8")
+	pressKey(gui, glfw.KeyBackspace)
+	pressKey(gui, glfw.KeyEscape)
+	assert.Equal(t, "", pty.String())
+	assert.Empty(t, gui.pane.held)
+}
+
+func TestAbbreviationsAreNotHeldInSecretsOrFullScreen(t *testing.T) {
+	gui, pty := newTestGUI(t, snippetConf)
+
+	pty.secret = true
+	typeText(gui, ";;k8s")
+	assert.Equal(t, ";;k8s", pty.String())
+
+	pty.Reset()
+	pty.secret = false
+	gui.terminal.UseAltBuffer()
+	typeText(gui, ";;k8s")
+	pressKey(gui, glfw.KeyEscape)
+	assert.Equal(t, ";;k8s\x1b", pty.String())
+}
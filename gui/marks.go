@@ -0,0 +1,21 @@
+package gui
+
+import "github.com/liamg/aminal/buffer"
+
+// renderMarks draws a rule across the top of each visible line which has been marked with Buffer.InsertMark
+func (gui *GUI) renderMarks(lines []buffer.Line) {
+	r := gui.renderer
+	thickness := r.cellHeight / 8
+	if thickness < 1 {
+		thickness = 1
+	}
+	for y := range lines {
+		if y >= int(gui.terminal.ActiveBuffer().ViewHeight()) {
+			break
+		}
+		if lines[y].Marked() {
+			top := float32(r.areaY) + float32(y)*r.cellHeight
+			r.DrawRect(float32(r.areaX), top, float32(r.areaWidth), thickness, gui.colours.DarkGrey)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package gui
+
+import (
+	"fmt"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/liamg/aminal/buffer"
+	"github.com/liamg/aminal/config"
+)
+
+type menuItem struct {
+	label    string
+	shortcut string
+	enabled  bool
+	run      func(gui *GUI)
+}
+
+// contextMenu is the menu shown on right-click, positioned in cells at the point clicked
+type contextMenu struct {
+	items    []menuItem
+	col      int
+	row      int
+	width    int
+	selected int
+}
+
+func newContextMenu(gui *GUI, col uint16, row uint16) *contextMenu {
+
+	buf := gui.terminal.ActiveBuffer()
+	url := buf.GetURLAtPosition(col, row)
+
+	items := []menuItem{
+		{label: "Copy", enabled: buf.GetSelectedText() != "", run: actionCopy},
+		{label: "Paste", enabled: !gui.config.ReadOnly, run: actionPaste},
+		{label: "Paste as One Line", enabled: !gui.config.ReadOnly, run: actionPasteSingleLine},
+		{label: "Select All", enabled: true, run: func(gui *GUI) {
+			gui.terminal.ActiveBuffer().SelectAll()
+		}},
+		{label: "Select Enclosing", enabled: true, run: func(gui *GUI) {
+			gui.terminal.ActiveBuffer().SmartSelectAtPosition(col, row)
+		}},
+	}
+	if url != "" {
+		items = append(items, menuItem{label: "Open URL", enabled: true, run: func(gui *GUI) {
+			gui.openURL(url)
+		}})
+	}
+	items = append(items,
+		menuItem{label: "Split Right", enabled: gui.paneLauncher != nil, run: actionSplitRight},
+		menuItem{label: "Split Down", enabled: gui.paneLauncher != nil, run: actionSplitDown},
+		menuItem{label: "Clear Scrollback", enabled: true, run: actionClearScrollback},
+		menuItem{label: "Settings", enabled: gui.config.Path != "", run: actionOpenConfig},
+	)
+
+	shortcuts := map[string]config.UserAction{
+		"Copy":             config.ActionCopy,
+		"Paste":            config.ActionPaste,
+		"Split Right":      config.ActionSplitRight,
+		"Split Down":       config.ActionSplitDown,
+		"Clear Scrollback": config.ActionClearScrollback,
+		"Settings":         config.ActionOpenConfig,
+	}
+
+	width := 0
+	for i, item := range items {
+		if action, ok := shortcuts[item.label]; ok {
+			if combi, ok := gui.keyboardShortcuts[action]; ok {
+				items[i].shortcut = combi.String()
+			}
+		}
+		if w := len(item.label) + len(items[i].shortcut) + 6; w > width {
+			width = w
+		}
+	}
+
+	m := &contextMenu{
+		items:    items,
+		col:      int(col),
+		row:      int(row),
+		width:    width,
+		selected: -1,
+	}
+
+	// keep the menu on screen, opening it up and to the left of the pointer if there isn't room below and to the right
+	viewWidth := int(buf.ViewWidth())
+	viewHeight := int(buf.ViewHeight())
+	if m.col+m.width > viewWidth {
+		m.col = viewWidth - m.width
+	}
+	if m.row+len(m.items) > viewHeight {
+		m.row -= len(m.items)
+	}
+	if m.col < 0 {
+		m.col = 0
+	}
+	if m.row < 0 {
+		m.row = 0
+	}
+
+	return m
+}
+
+func (m *contextMenu) render(gui *GUI) {
+
+	bg := buffer.NewBackgroundCell(gui.colours.Black)
+	highlight := buffer.NewBackgroundCell(gui.colours.Selection)
+	viewWidth := int(gui.terminal.ActiveBuffer().ViewWidth())
+	viewHeight := int(gui.terminal.ActiveBuffer().ViewHeight())
+
+	f := gui.fontMap.GetFont('X')
+
+	for i, item := range m.items {
+		y := m.row + i
+		if y >= viewHeight {
+			break
+		}
+		cell := bg
+		if i == m.selected && item.enabled {
+			cell = highlight
+		}
+		for x := m.col; x < m.col+m.width && x < viewWidth; x++ {
+			gui.renderer.DrawCellBg(cell, uint(x), uint(y), false, nil, true)
+		}
+
+		fg := gui.colours.Foreground
+		if !item.enabled {
+			fg = gui.colours.DarkGrey
+		}
+		f.SetColor(fg[0], fg[1], fg[2], 1)
+		text := fmt.Sprintf(" %-*s%s ", m.width-len(item.shortcut)-2, item.label, item.shortcut)
+		f.Print(
+			float32(gui.renderer.areaX)+float32(m.col)*gui.renderer.cellWidth,
+			float32(gui.renderer.areaY)+float32(y+1)*gui.renderer.cellHeight+f.MinY(),
+			text,
+		)
+	}
+}
+
+// itemAt returns the index of the item at the given window position, or -1 if there isn't one
+func (m *contextMenu) itemAt(gui *GUI, x float64, y float64) int {
+	col := int((x - float64(gui.renderer.areaX)) / float64(gui.renderer.CellWidth()))
+	row := int((y - float64(gui.renderer.areaY)) / float64(gui.renderer.CellHeight()))
+	if x < float64(gui.renderer.areaX) || col < m.col || col >= m.col+m.width {
+		return -1
+	}
+	if y < float64(gui.renderer.areaY) || row < m.row || row >= m.row+len(m.items) {
+		return -1
+	}
+	return row - m.row
+}
+
+func (m *contextMenu) hover(gui *GUI, x float64, y float64) {
+	if selected := m.itemAt(gui, x, y); selected != m.selected {
+		m.selected = selected
+		gui.terminal.SetDirty()
+	}
+}
+
+func (m *contextMenu) activate(gui *GUI, index int) {
+	if index < 0 || index >= len(m.items) || !m.items[index].enabled {
+		return
+	}
+	gui.setOverlay(nil)
+	m.items[index].run(gui)
+}
+
+func (m *contextMenu) key(gui *GUI, key glfw.Key, mods glfw.ModifierKey) {
+	switch key {
+	case glfw.KeyUp:
+		m.move(gui, -1)
+	case glfw.KeyDown:
+		m.move(gui, 1)
+	case glfw.KeyEnter, glfw.KeyKPEnter:
+		m.activate(gui, m.selected)
+	}
+}
+
+// move selects the next enabled item in the given direction, wrapping around at either end
+func (m *contextMenu) move(gui *GUI, direction int) {
+	defer gui.terminal.SetDirty()
+	selected := m.selected
+	if selected < 0 && direction < 0 {
+		selected = 0
+	}
+	for range m.items {
+		selected = (selected + direction + len(m.items)) % len(m.items)
+		if m.items[selected].enabled {
+			m.selected = selected
+			return
+		}
+	}
+}
+
+func (m *contextMenu) char(gui *GUI, r rune) {}
+
+func (m *contextMenu) click(gui *GUI, x float64, y float64) {
+	index := m.itemAt(gui, x, y)
+	if index < 0 {
+		// clicking anywhere else dismisses the menu
+		gui.setOverlay(nil)
+		return
+	}
+	m.activate(gui, index)
+}
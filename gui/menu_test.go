@@ -0,0 +1,43 @@
+package gui
+
+import (
+	"testing"
+
+	"github.com/liamg/aminal/config"
+	"github.com/liamg/aminal/terminal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// menuItemNamed returns the item in the menu with a label, failing the test if there isn't one
+func menuItemNamed(t *testing.T, m *contextMenu, label string) menuItem {
+	for _, item := range m.items {
+		if item.label == label {
+			return item
+		}
+	}
+	require.FailNow(t, "No menu item "+label)
+	return menuItem{}
+}
+
+func TestContextMenuSplitsPanes(t *testing.T) {
+	gui, _ := newTestGUI(t, "")
+
+	// a session attached to a daemon can't start shells for new panes
+	m := newContextMenu(gui, 0, 0)
+	assert.False(t, menuItemNamed(t, m, "Split Right").enabled)
+	assert.False(t, menuItemNamed(t, m, "Split Down").enabled)
+
+	gui.SetPaneLauncher(func(pane *config.PaneLayout, command string) (terminal.Pty, error) {
+		return &recordingPty{}, nil
+	})
+	shortcuts, err := gui.config.KeyMapping.GenerateActionMap()
+	require.Nil(t, err)
+	gui.keyboardShortcuts = shortcuts
+	m = newContextMenu(gui, 0, 0)
+	right := menuItemNamed(t, m, "Split Right")
+	assert.True(t, right.enabled)
+	assert.NotEmpty(t, right.shortcut)
+	assert.Equal(t, shortcuts[config.ActionSplitRight].String(), right.shortcut)
+	assert.True(t, menuItemNamed(t, m, "Split Down").enabled)
+}
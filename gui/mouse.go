@@ -1,80 +1,108 @@
 package gui
 
 import (
-	"fmt"
 	"math"
 
 	"github.com/go-gl/glfw/v3.2/glfw"
 	"github.com/liamg/aminal/terminal"
 )
 
-func (gui *GUI) glfwScrollCallback(w *glfw.Window, xoff float64, yoff float64) {
-
-	if yoff > 0 {
-		gui.terminal.ScrollUp(1)
-	} else {
-		gui.terminal.ScrollDown(1)
-	}
-}
-
 func (gui *GUI) mouseMoveCallback(w *glfw.Window, px float64, py float64) {
 
 	scale := gui.scale()
 	px = px / float64(scale)
 	py = py / float64(scale)
 
+	if gui.paneMouseMove(w, px, py) {
+		return
+	}
+	if gui.scrollbarHeld {
+		gui.scrollbarDrag(py)
+		return
+	}
+
 	x := uint16(math.Floor((px - float64(gui.renderer.areaX)) / float64(gui.renderer.CellWidth())))
 	y := uint16(math.Floor((py - float64(gui.renderer.areaY)) / float64(gui.renderer.CellHeight())))
 
-	if gui.mouseDown {
-		gui.terminal.ActiveBuffer().EndSelection(x, y, false)
+	gui.reportMotion(w, px, py)
+
+	if menu, ok := gui.overlay.(*contextMenu); ok {
+		menu.hover(gui, px, py)
+	} else if gui.mouseDown {
+		gui.dragSelection(px, py)
 	} else {
 
-		hint := gui.terminal.ActiveBuffer().GetHintAtPosition(x, y)
-		if hint != nil {
-			gui.setOverlay(newAnnotation(hint))
-		} else {
-			gui.setOverlay(nil)
+		// don't replace an overlay the user is interacting with
+		if _, ok := gui.overlay.(inputOverlay); !ok {
+			hint := gui.terminal.ActiveBuffer().GetHintAtPosition(x, y)
+			if hint != nil {
+				gui.setOverlay(newAnnotation(hint))
+			} else {
+				gui.setOverlay(nil)
+			}
 		}
 
 	}
 
-	if url := gui.terminal.ActiveBuffer().GetURLAtPosition(x, y); url != "" {
-		w.SetCursor(glfw.CreateStandardCursor(glfw.HandCursor))
-	} else {
-		w.SetCursor(glfw.CreateStandardCursor(glfw.ArrowCursor))
-	}
+	gui.hover(w, x, y)
 }
 
 func (gui *GUI) mouseButtonCallback(w *glfw.Window, button glfw.MouseButton, action glfw.Action, mod glfw.ModifierKey) {
 
+	// before we forward clicks on (below), we need to handle them locally for url clicking, text highlighting etc.
+	px, py := w.GetCursorPos()
+	scale := gui.scale()
+	px = px / float64(scale)
+	py = py / float64(scale)
+
+	if gui.paneMouseButton(px, py, button, action) {
+		return
+	}
+	if gui.overlay == nil && gui.scrollbarMouseButton(px, py, button, action) {
+		return
+	}
+
 	if gui.overlay != nil {
 		if button == glfw.MouseButtonRight && action == glfw.Release {
 			gui.setOverlay(nil)
+		} else if input, ok := gui.overlay.(inputOverlay); ok && button == glfw.MouseButtonLeft && action == glfw.Press {
+			input.click(gui, px, py)
 		}
 		return
 	}
-
-	// before we forward clicks on (below), we need to handle them locally for url clicking, text highlighting etc.
-	px, py := w.GetCursorPos()
-	scale := gui.scale()
-	px = px / float64(scale)
-	py = py / float64(scale)
 	x := uint16(math.Floor((px - float64(gui.renderer.areaX)) / float64(gui.renderer.CellWidth())))
 	y := uint16(math.Floor((py - float64(gui.renderer.areaY)) / float64(gui.renderer.CellHeight())))
 	tx := int(x) + 1 // vt100 is 1 indexed
 	ty := int(y) + 1
 
+	// right-clicks go to programs which have asked for mouse events, unless the bypass modifier is held
+	if button == glfw.MouseButtonRight && gui.config.Mouse.ContextMenu {
+		if gui.terminal.GetMouseMode() == terminal.MouseModeNone || mod&gui.config.Mouse.BypassMod() != 0 {
+			if action == glfw.Release {
+				gui.setOverlay(newContextMenu(gui, x, y))
+			}
+			return
+		}
+	}
+
 	if button == glfw.MouseButtonLeft {
 
 		if action == glfw.Press {
 			gui.mouseDown = true
+			gui.startPress(x, y)
 			gui.terminal.ActiveBuffer().StartSelection(x, y)
 		} else if action == glfw.Release {
 			gui.mouseDown = false
-			gui.terminal.ActiveBuffer().EndSelection(x, y, true)
-			if url := gui.terminal.ActiveBuffer().GetURLAtPosition(x, y); url != "" {
-				go gui.launchTarget(url)
+			longPress := gui.endPress()
+			col, row := gui.clampedCell(px, py)
+			gui.terminal.ActiveBuffer().EndSelection(col, row, true)
+			if gui.config.Mouse.CopyOnSelect {
+				if text := gui.terminal.ActiveBuffer().GetSelectedText(); text != "" {
+					gui.copyText(text)
+				}
+			}
+			if link := gui.linkAtPosition(x, y); link != nil && !longPress && gui.linkClick(mod) {
+				gui.openLink(link)
 			}
 		}
 	}
@@ -107,12 +135,9 @@ func (gui *GUI) mouseButtonCallback(w *glfw.Window, button glfw.MouseButton, act
 		*/
 
 		if action == glfw.Press {
-			b := rune(button)
-			packet := fmt.Sprintf("\x1b[M%c%c%c", (rune(b + 32)), (rune(tx + 32)), (rune(ty + 32)))
-
-			gui.terminal.Write([]byte(packet))
+			gui.terminal.ReportMouseEvent(byte(button), tx, ty, false)
 		}
-	case terminal.MouseModeVT200: // normal
+	case terminal.MouseModeVT200, terminal.MouseModeButtonEvent, terminal.MouseModeAnyEvent: // normal, and with motion
 		/*
 
 			Normal tracking mode sends an escape sequence on both button press and release.
@@ -135,36 +160,15 @@ func (gui *GUI) mouseButtonCallback(w *glfw.Window, button glfw.MouseButton, act
 
 			Wheel mice may return buttons 4 and 5. Those buttons are represented by the same event codes as buttons 1 and 2 respectively, except that 64 is added to the event code. Release events for the wheel buttons are not reported.
 		*/
-		var b byte
-		if action == glfw.Press {
-			switch button {
-			case glfw.MouseButton1:
-				b = 0
-			case glfw.MouseButton2:
-				b = 1
-			case glfw.MouseButton3:
-				b = 2
-			default:
-				return
-			}
-		} else if action == glfw.Release {
-			b = 3
-		} else {
+		b, ok := mouseButtonCode(button, mod)
+		if !ok || (action != glfw.Press && action != glfw.Release) {
 			return
 		}
-		if mod&glfw.ModShift > 0 {
-			b |= 4
-		}
-		if mod&glfw.ModSuper > 0 {
-			b |= 8
-		}
-		if mod&glfw.ModControl > 0 {
-			b |= 16
-		}
+		gui.mouseReport.held = action == glfw.Press
+		gui.mouseReport.button = b & 3
 
-		packet := fmt.Sprintf("\x1b[M%c%c%c", (rune(b + 32)), (rune(tx + 32)), (rune(ty + 32)))
-		gui.logger.Infof("Sending mouse packet: '%v'", packet)
-		gui.terminal.Write([]byte(packet))
+		// the encoding (including how releases are reported) depends on DECSET 1005/1006/1015, see terminal.EncodeMouseEvent
+		gui.terminal.ReportMouseEvent(b, tx, ty, action == glfw.Release)
 
 	case terminal.MouseModeVT200Highlight:
 		/*
@@ -172,20 +176,6 @@ func (gui *GUI) mouseButtonCallback(w *glfw.Window, button glfw.MouseButton, act
 		*/
 		panic("VT200 mouse highlight mode not supported")
 
-	case terminal.MouseModeButtonEvent:
-		/*
-		   Button-event tracking is essentially the same as normal tracking, but xterm also reports button-motion events. Motion events are reported only if the mouse pointer has moved to a different character cell. It is enabled by specifying parameter 1002 to DECSET. On button press or release, xterm sends the same codes used by normal tracking mode. On button-motion events, xterm adds 32 to the event code (the third character, C b ). The other bits of the event code specify button and modifier keys as in normal mode. For example, motion into cell x,y with button 1 down is reported as CSI M @ C x C y . ( @ = 32 + 0 (button 1) + 32 (motion indicator) ). Similarly, motion with button 3 down is reported as CSI M B C x C y . ( B = 32 + 2 (button 3) + 32 (motion indicator) ).
-		*/
-		panic("Mouse button event mode not supported")
-
-	case terminal.MouseModeAnyEvent:
-		/*
-		   Any-event mode is the same as button-event mode, except that all motion events are reported, even if no mouse button is down. It is enabled by specifying 1003 to DECSET.
-
-
-		*/
-		panic("Mouse any event mode not supported")
-
 	default:
 		panic("Unsupported mouse mode")
 	}
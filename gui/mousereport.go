@@ -0,0 +1,140 @@
+package gui
+
+import (
+	"math"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/liamg/aminal/terminal"
+)
+
+// xterm mouse report button codes, beyond the 0-2 of the three buttons
+const (
+	mouseNoButton   byte = 3  // motion with no button held
+	mouseMotionFlag byte = 32 // added for motion rather than a press or release
+	mouseWheelUp    byte = 64
+	mouseWheelDown  byte = 65
+)
+
+// mouseReport tracks what has been reported to a program which asked for mouse motion, with DECSET 1002 or 1003
+type mouseReport struct {
+	held   bool // whether a button is held down
+	button byte // the button held down, or the last one which was
+	col    uint16
+	row    uint16
+}
+
+// mouseButtonCode returns the xterm code for a button with the modifiers which were held, or false for buttons which
+// aren't reported
+func mouseButtonCode(button glfw.MouseButton, mod glfw.ModifierKey) (byte, bool) {
+	var b byte
+	switch button {
+	case glfw.MouseButton1:
+		b = 0
+	case glfw.MouseButton2:
+		b = 1
+	case glfw.MouseButton3:
+		b = 2
+	default:
+		return 0, false
+	}
+	return b | mouseModifierBits(mod), true
+}
+
+func mouseModifierBits(mod glfw.ModifierKey) byte {
+	var b byte
+	if mod&glfw.ModShift > 0 {
+		b |= 4
+	}
+	if mod&glfw.ModSuper > 0 {
+		b |= 8
+	}
+	if mod&glfw.ModControl > 0 {
+		b |= 16
+	}
+	return b
+}
+
+// heldModifiers returns the modifier keys held down, for events which don't come with them
+func heldModifiers(w *glfw.Window) glfw.ModifierKey {
+	var mod glfw.ModifierKey
+	held := func(keys ...glfw.Key) bool {
+		for _, key := range keys {
+			if w.GetKey(key) == glfw.Press {
+				return true
+			}
+		}
+		return false
+	}
+	if held(glfw.KeyLeftShift, glfw.KeyRightShift) {
+		mod |= glfw.ModShift
+	}
+	if held(glfw.KeyLeftControl, glfw.KeyRightControl) {
+		mod |= glfw.ModControl
+	}
+	if held(glfw.KeyLeftSuper, glfw.KeyRightSuper) {
+		mod |= glfw.ModSuper
+	}
+	return mod
+}
+
+// reportMotion sends pointer movement to a program which asked for it. Button-event tracking (DECSET 1002) reports
+// movement while a button is held, and any-event tracking (DECSET 1003) all movement. Only moves into a different cell
+// are reported. The report is the same as for a press, with 32 added to the button code, which is 3 if no button is
+// held.
+func (gui *GUI) reportMotion(w *glfw.Window, px float64, py float64) {
+
+	if _, ok := gui.overlay.(inputOverlay); ok {
+		return
+	}
+
+	mode := gui.terminal.GetMouseMode()
+	r := &gui.mouseReport
+	if mode != terminal.MouseModeAnyEvent && !(mode == terminal.MouseModeButtonEvent && r.held) {
+		return
+	}
+
+	col, row := gui.clampedCell(px, py)
+	if col == r.col && row == r.row {
+		return
+	}
+	r.col, r.row = col, row
+
+	b := mouseNoButton
+	if r.held {
+		b = r.button
+	}
+	b |= mouseMotionFlag | mouseModifierBits(heldModifiers(w))
+	gui.terminal.ReportMouseEvent(b, int(col)+1, int(row)+1, false)
+}
+
+// reportWheel sends wheel movement to a program which asked for mouse reports, as presses of buttons 4 and 5, returning
+// false if it hasn't asked for them, in which case the wheel scrolls as usual. Fractional touchpad scrolling adds up
+// until it makes a whole step.
+func (gui *GUI) reportWheel(w *glfw.Window, yoff float64) bool {
+
+	switch gui.terminal.GetMouseMode() {
+	case terminal.MouseModeVT200, terminal.MouseModeButtonEvent, terminal.MouseModeAnyEvent:
+	default:
+		return false
+	}
+
+	g := &gui.gestures
+	g.scrollRemainder += yoff
+	steps := math.Trunc(g.scrollRemainder)
+	g.scrollRemainder -= steps
+
+	b := mouseWheelUp
+	if steps < 0 {
+		b = mouseWheelDown
+		steps = -steps
+	}
+	b |= mouseModifierBits(heldModifiers(w))
+
+	scale := gui.scale()
+	px, py := w.GetCursorPos()
+	col, row := gui.clampedCell(px/float64(scale), py/float64(scale))
+	for i := 0; i < int(steps); i++ {
+		gui.terminal.ReportMouseEvent(b, int(col)+1, int(row)+1, false)
+	}
+	return true
+}
@@ -1,9 +1,19 @@
 package gui
 
+import "github.com/go-gl/glfw/v3.2/glfw"
+
 type overlay interface {
 	render(gui *GUI)
 }
 
+// inputOverlay is an overlay which takes keyboard and mouse input instead of the terminal while it is shown
+type inputOverlay interface {
+	overlay
+	key(gui *GUI, key glfw.Key, mods glfw.ModifierKey)
+	char(gui *GUI, r rune)
+	click(gui *GUI, x float64, y float64)
+}
+
 func (gui *GUI) setOverlay(m overlay) {
 	defer gui.terminal.SetDirty()
 	gui.overlay = m
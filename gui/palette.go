@@ -0,0 +1,227 @@
+package gui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/liamg/aminal/buffer"
+	"github.com/liamg/aminal/config"
+)
+
+// the most matching commands the palette shows at once
+const paletteRows = 12
+
+type paletteCommand struct {
+	action   config.UserAction
+	label    string
+	shortcut string
+	run      func(gui *GUI) // runs something other than an action, e.g. opening a saved layout
+}
+
+// commandPalette finds actions by typing part of what they do, so they can be run without knowing their shortcuts
+type commandPalette struct {
+	commands []paletteCommand
+	query    string
+	matches  []paletteCommand
+	selected int
+}
+
+func newCommandPalette(gui *GUI) *commandPalette {
+
+	commands := []paletteCommand{}
+	for action := range actionMap {
+		if action == config.ActionCommandPalette {
+			continue
+		}
+		command := paletteCommand{action: action, label: action.Description()}
+		if combi, ok := gui.keyboardShortcuts[action]; ok {
+			command.shortcut = combi.String()
+		}
+		commands = append(commands, command)
+	}
+	for _, name := range config.LayoutNames() {
+		name := name
+		commands = append(commands, paletteCommand{
+			label: "Open layout: " + name,
+			run: func(gui *GUI) {
+				gui.openSavedLayout(name)
+			},
+		})
+	}
+	sort.Slice(commands, func(i, j int) bool {
+		return commands[i].label < commands[j].label
+	})
+
+	p := &commandPalette{commands: commands}
+	p.filter()
+	return p
+}
+
+// filter lists the commands which match the query, best first
+func (p *commandPalette) filter() {
+	type scored struct {
+		command paletteCommand
+		score   int
+	}
+	found := []scored{}
+	for _, command := range p.commands {
+		score, ok := fuzzyScore(p.query, command.label)
+		// the action's config name works too e.g. "clear_all"
+		if nameScore, nameOk := fuzzyScore(p.query, string(command.action)); nameOk && (!ok || nameScore > score) {
+			score, ok = nameScore, true
+		}
+		if ok {
+			found = append(found, scored{command: command, score: score})
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool {
+		return found[i].score > found[j].score
+	})
+
+	p.matches = p.matches[:0]
+	for _, f := range found {
+		p.matches = append(p.matches, f.command)
+	}
+	p.selected = 0
+}
+
+// fuzzyScore returns how well text matches the query, which it does if the query's characters appear in it in order,
+// though not necessarily together. Characters which follow one another or start words score higher.
+func fuzzyScore(query string, text string) (int, bool) {
+	q := []rune(strings.ToLower(query))
+	t := []rune(strings.ToLower(text))
+	score := 0
+	matched := 0
+	previous := -2
+	for i := 0; i < len(t) && matched < len(q); i++ {
+		if t[i] != q[matched] {
+			continue
+		}
+		score++
+		if i == previous+1 {
+			score += 2
+		}
+		if i == 0 || t[i-1] == ' ' || t[i-1] == '_' || t[i-1] == '-' {
+			score += 3
+		}
+		previous = i
+		matched++
+	}
+	return score, matched == len(q)
+}
+
+// bounds returns the column and row of the palette's top left corner, and its width
+func (p *commandPalette) bounds(gui *GUI) (int, int, int) {
+	viewWidth := int(gui.terminal.ActiveBuffer().ViewWidth())
+	width := 72
+	if width > viewWidth {
+		width = viewWidth
+	}
+	return (viewWidth - width) / 2, 1, width
+}
+
+func (p *commandPalette) render(gui *GUI) {
+
+	col, row, width := p.bounds(gui)
+	viewHeight := int(gui.terminal.ActiveBuffer().ViewHeight())
+
+	bg := buffer.NewBackgroundCell(gui.colours.Black)
+	highlight := buffer.NewBackgroundCell(gui.colours.Selection)
+
+	lines := []string{fmt.Sprintf(" > %s_", p.query)}
+	if len(p.matches) == 0 {
+		lines = append(lines, " No matching actions")
+	}
+	for i, command := range p.matches {
+		if i == paletteRows {
+			break
+		}
+		lines = append(lines, fmt.Sprintf(" %-*s%s ", width-len(command.shortcut)-2, command.label, command.shortcut))
+	}
+
+	f := gui.fontMap.GetFont('X')
+	for i, line := range lines {
+		y := row + i
+		if y >= viewHeight {
+			break
+		}
+		cell := bg
+		if i == p.selected+1 && len(p.matches) > 0 {
+			cell = highlight
+		}
+		for x := col; x < col+width; x++ {
+			gui.renderer.DrawCellBg(cell, uint(x), uint(y), false, nil, true)
+		}
+
+		fg := gui.colours.Foreground
+		if i > 0 && len(p.matches) == 0 {
+			fg = gui.colours.DarkGrey
+		}
+		f.SetColor(fg[0], fg[1], fg[2], 1)
+		f.Print(
+			float32(gui.renderer.areaX)+float32(col)*gui.renderer.cellWidth,
+			float32(gui.renderer.areaY)+float32(y+1)*gui.renderer.cellHeight+f.MinY(),
+			line,
+		)
+	}
+}
+
+// run closes the palette and runs the selected command
+func (p *commandPalette) run(gui *GUI, index int) {
+	if index < 0 || index >= len(p.matches) {
+		return
+	}
+	gui.setOverlay(nil)
+	if command := p.matches[index]; command.run != nil {
+		command.run(gui)
+	} else {
+		actionMap[command.action](gui)
+	}
+}
+
+func (p *commandPalette) key(gui *GUI, key glfw.Key, mods glfw.ModifierKey) {
+	defer gui.terminal.SetDirty()
+	shown := len(p.matches)
+	if shown > paletteRows {
+		shown = paletteRows
+	}
+	switch key {
+	case glfw.KeyUp:
+		if shown > 0 {
+			p.selected = (p.selected - 1 + shown) % shown
+		}
+	case glfw.KeyDown, glfw.KeyTab:
+		if shown > 0 {
+			p.selected = (p.selected + 1) % shown
+		}
+	case glfw.KeyEnter, glfw.KeyKPEnter:
+		p.run(gui, p.selected)
+	case glfw.KeyBackspace:
+		if p.query != "" {
+			runes := []rune(p.query)
+			p.query = string(runes[:len(runes)-1])
+			p.filter()
+		}
+	}
+}
+
+func (p *commandPalette) char(gui *GUI, r rune) {
+	p.query += string(r)
+	p.filter()
+	gui.terminal.SetDirty()
+}
+
+func (p *commandPalette) click(gui *GUI, x float64, y float64) {
+	col, row, width := p.bounds(gui)
+	clickedCol := int((x - float64(gui.renderer.areaX)) / float64(gui.renderer.CellWidth()))
+	clickedRow := int((y - float64(gui.renderer.areaY)) / float64(gui.renderer.CellHeight()))
+	index := clickedRow - row - 1
+	if x < float64(gui.renderer.areaX) || clickedCol < col || clickedCol >= col+width || index < -1 || index >= paletteRows {
+		// clicking anywhere else dismisses the palette
+		gui.setOverlay(nil)
+		return
+	}
+	p.run(gui, index)
+}
@@ -0,0 +1,612 @@
+package gui
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/liamg/aminal/buffer"
+	"github.com/liamg/aminal/config"
+	"github.com/liamg/aminal/layout"
+	"github.com/liamg/aminal/terminal"
+)
+
+// the gap between panes, in pixels
+const dividerWidth = 2
+
+// pane is a terminal in part of the window, which can be split between several of them. The focused pane's terminal
+// is gui.terminal, which takes keyboard input.
+type pane struct {
+	id             int
+	terminal       *terminal.Terminal
+	launch         func() (terminal.Pty, error) // starts a new shell when the old one exits, if on_exit is "restart"
+	exited         int32                        // 1 once the shell has exited and the pane is held open, used atomically as runPty sets it
+	hold           bool                         // the pane is held open when its process exits, whatever on_exit says
+	restartChan    chan bool
+	closed         chan struct{}
+	commandStarted time.Time // when the shell reported the running command started, if there is one
+	command        string    // the running command, as the shell reported it
+	startCommand   string    // typed into the shell when the pane opened, if it was opened from a layout
+	held           []rune    // typed towards a snippet abbreviation, and not yet sent, see macros.go
+}
+
+// hasExited returns true if the pane's shell has exited and the pane is being held open
+func (p *pane) hasExited() bool {
+	return atomic.LoadInt32(&p.exited) == 1
+}
+
+func (p *pane) setExited(exited bool) {
+	var value int32
+	if exited {
+		value = 1
+	}
+	atomic.StoreInt32(&p.exited, value)
+}
+
+func newPane(id int, terminal *terminal.Terminal) *pane {
+	return &pane{
+		id:          id,
+		terminal:    terminal,
+		restartChan: make(chan bool, 1),
+		closed:      make(chan struct{}),
+	}
+}
+
+// SetPaneLauncher sets the function used to start a shell in a new pane, in the pane's directory and environment, and
+// running the given command instead of being interactive if that isn't empty
+func (gui *GUI) SetPaneLauncher(launch func(pane *config.PaneLayout, command string) (terminal.Pty, error)) {
+	gui.paneLauncher = launch
+}
+
+// SetLayout has the window split into the panes described once it opens. The terminal the GUI was created with is
+// the first of them, so it should have been started as that pane describes.
+func (gui *GUI) SetLayout(l *config.PaneLayout) {
+	gui.startLayout = l
+}
+
+// panesArea is the part of the window shared between the panes
+func (gui *GUI) panesArea() layout.Rect {
+	x, y, width, height := gui.terminalArea()
+	return layout.Rect{X: x, Y: y, Width: width, Height: height}
+}
+
+// useArea sets the renderer up to draw a pane, and work out which of its cells the mouse is over
+func (gui *GUI) useArea(p *pane) {
+	rect := gui.layout.Rects(gui.panesArea())[p.id]
+	gui.renderer.SetArea(rect.X, rect.Y, rect.Width, rect.Height)
+}
+
+// layoutPanes fits the terminal of each pane to its share of the window. Panes hidden by a zoomed pane keep their
+// size, so they are as they were when they are shown again.
+func (gui *GUI) layoutPanes() {
+	rects := gui.layout.Rects(gui.panesArea())
+	for _, p := range gui.panes {
+		if _, shown := rects[p.id]; !shown {
+			continue
+		}
+		gui.useArea(p)
+		cols, rows := gui.renderer.GetTermSize()
+		if cols < 1 {
+			cols = 1
+		}
+		if rows < 1 {
+			rows = 1
+		}
+		// the terminal catches up once the size settles, drawing at the old size in the new area until then
+		if err := p.terminal.RequestSize(cols, rows); err != nil {
+			gui.logger.Errorf("Failed to resize terminal to %d cols, %d rows: %s", cols, rows, err)
+		}
+		p.terminal.SetCharSize(gui.renderer.cellWidth, gui.renderer.cellHeight)
+		p.terminal.SetDirty()
+	}
+	gui.useArea(gui.pane)
+}
+
+// splitPane splits the focused pane in two, starting a new shell in the new half, in the same directory. If command
+// isn't empty, the shell runs it instead of being interactive.
+func (gui *GUI) splitPane(direction layout.Direction, command string) error {
+	start := &config.PaneLayout{}
+	if isLocalHost(gui.terminal.GetHost()) {
+		start.Directory = gui.terminal.GetWorkingDirectory()
+	}
+	p, err := gui.startPane(start, command)
+	if err != nil {
+		return err
+	}
+	if err := gui.layout.Split(gui.pane.id, p.id, direction); err != nil {
+		gui.abandonPane(p)
+		return err
+	}
+	gui.applyColours()
+	gui.focusPane(p)
+	gui.layoutPanes()
+
+	go gui.runPty(p)
+	return nil
+}
+
+// startPane starts a shell for a new pane, as described by start, which has yet to be given a place in the layout or
+// have its output read
+func (gui *GUI) startPane(start *config.PaneLayout, command string) (*pane, error) {
+	if gui.paneLauncher == nil {
+		return nil, fmt.Errorf("Panes are not available for this kind of session")
+	}
+	launch := func() (terminal.Pty, error) {
+		return gui.paneLauncher(start, command)
+	}
+	pty, err := launch()
+	if err != nil {
+		return nil, err
+	}
+
+	t := terminal.New(pty, gui.logger, gui.config)
+	t.SetProgram(gui.program)
+	t.AttachTitleChangeHandler(gui.titleChan)
+
+	p := newPane(gui.nextPaneID, t)
+	p.launch = launch
+	if command == "" {
+		p.startCommand = start.Command
+	} else {
+		// a command run in place of the shell is there for its output, which closing the pane would throw away
+		p.hold = true
+	}
+	gui.nextPaneID++
+	gui.panes[p.id] = p
+	return p, nil
+}
+
+// abandonPane hangs up on the shell of a pane which couldn't be laid out
+func (gui *GUI) abandonPane(p *pane) {
+	delete(gui.panes, p.id)
+	close(p.closed)
+	go p.terminal.Hangup(hangupTimeout)
+}
+
+// openLayout splits the window into the panes set with SetLayout, if there are any, starting a shell in each pane but
+// the first, which is the one already open. Panes which fail to start are left out.
+func (gui *GUI) openLayout() {
+	if gui.startLayout == nil {
+		return
+	}
+	gui.pane.startCommand = gui.startLayout.First().Command
+	description := gui.describePanes(gui.startLayout, gui.pane)
+	tree, err := layout.Build(description, dividerWidth)
+	if err != nil {
+		gui.logger.Errorf("Failed to lay out panes: %s", err)
+		for _, p := range gui.panes {
+			if p != gui.pane {
+				gui.abandonPane(p)
+			}
+		}
+		return
+	}
+	gui.layout = tree
+	gui.applyColours()
+	gui.layoutPanes()
+	for _, p := range gui.panes {
+		if p != gui.pane {
+			go gui.runPty(p)
+		}
+	}
+}
+
+// describePanes starts the panes of a layout, other than its first, which is given, and returns how they are laid out
+func (gui *GUI) describePanes(l *config.PaneLayout, first *pane) *layout.Node {
+	if !l.IsSplit() {
+		return &layout.Node{Pane: first.id}
+	}
+	firstSide := gui.describePanes(&l.Panes[0], first)
+	second, err := gui.startPane(l.Panes[1].First(), "")
+	if err != nil {
+		gui.logger.Errorf("Failed to start pane: %s", err)
+		return firstSide
+	}
+	direction := layout.Horizontal
+	if l.Split == config.SplitDown {
+		direction = layout.Vertical
+	}
+	return &layout.Node{
+		Direction: direction,
+		Ratio:     l.Size,
+		First:     firstSide,
+		Second:    gui.describePanes(&l.Panes[1], second),
+	}
+}
+
+// closePane hangs up on the shell in a pane and gives its space to its neighbour. Closing the last pane closes the
+// window.
+func (gui *GUI) closePane(p *pane) {
+	if _, ok := gui.panes[p.id]; !ok {
+		return
+	}
+	if len(gui.panes) == 1 {
+		gui.Close()
+		return
+	}
+	if err := gui.layout.Remove(p.id); err != nil {
+		gui.logger.Errorf("Failed to close pane: %s", err)
+		return
+	}
+	delete(gui.panes, p.id)
+	close(p.closed)
+	go p.terminal.Hangup(hangupTimeout)
+
+	if gui.pane == p {
+		gui.focusPane(gui.panes[gui.layout.Panes()[0]])
+	}
+	gui.layoutPanes()
+}
+
+// focusPane sends keyboard input to a pane
+func (gui *GUI) focusPane(p *pane) {
+	if gui.pane == p {
+		return
+	}
+	gui.pane = p
+	gui.terminal = p.terminal
+	gui.hoverLink = nil
+	if zoomed, ok := gui.layout.Zoomed(); ok && zoomed != p.id {
+		gui.layout.Unzoom()
+		gui.layoutPanes()
+	}
+	// a search is of the buffer it was started in
+	if _, ok := gui.overlay.(*searchOverlay); ok {
+		gui.setOverlay(nil)
+	}
+	gui.lastSearch = nil
+	gui.useArea(p)
+	gui.shownTitle = gui.windowTitle()
+	gui.window.SetTitle(gui.shownTitle)
+	gui.terminal.SetDirty()
+}
+
+// focusNextPane moves focus through the panes in turn, left to right and top to bottom
+func (gui *GUI) focusNextPane() {
+	ids := gui.layout.Panes()
+	for i, id := range ids {
+		if id == gui.pane.id {
+			gui.focusPane(gui.panes[ids[(i+1)%len(ids)]])
+			return
+		}
+	}
+}
+
+// toggleZoom has the focused pane fill the window, or puts it back in its place if it already does
+func (gui *GUI) toggleZoom() {
+	if _, ok := gui.layout.Zoomed(); ok {
+		gui.layout.Unzoom()
+	} else if len(gui.panes) > 1 {
+		if err := gui.layout.Zoom(gui.pane.id); err != nil {
+			gui.logger.Errorf("Failed to zoom pane: %s", err)
+			return
+		}
+	}
+	gui.layoutPanes()
+	for _, p := range gui.panes {
+		p.terminal.SetDirty()
+	}
+}
+
+// resizePane grows the focused pane by a number of steps of pane_resize_step in the given direction, or shrinks it if
+// steps is negative. Cells are about twice as tall as they are wide, so a step is half as many rows as columns.
+func (gui *GUI) resizePane(direction layout.Direction, steps int) {
+	pixels := float32(steps*gui.config.PaneResizeStep) * gui.renderer.cellWidth
+	if direction == layout.Vertical {
+		rows := gui.config.PaneResizeStep / 2
+		if rows < 1 {
+			rows = 1
+		}
+		pixels = float32(steps*rows) * gui.renderer.cellHeight
+	}
+	gui.layout.Resize(gui.panesArea(), gui.pane.id, direction, int(pixels))
+	gui.layoutPanes()
+}
+
+// resizeMode resizes the focused pane with the arrow keys, as dragging a divider with the mouse is imprecise, until
+// escape or enter is pressed
+type resizeMode struct{}
+
+func (m *resizeMode) render(gui *GUI) {
+	width := int(gui.terminal.ActiveBuffer().ViewWidth())
+	bg := buffer.NewBackgroundCell(gui.colours.Black)
+	for x := 0; x < width; x++ {
+		gui.renderer.DrawCellBg(bg, uint(x), 0, false, nil, true)
+	}
+	f := gui.fontMap.GetFont('X')
+	fg := gui.colours.Foreground
+	f.SetColor(fg[0], fg[1], fg[2], 1)
+	f.Print(float32(gui.renderer.areaX), float32(gui.renderer.areaY)+gui.renderer.cellHeight+f.MinY(),
+		" Resize pane: left/right narrower/wider, up/down shorter/taller, escape when done")
+}
+
+func (m *resizeMode) key(gui *GUI, key glfw.Key, mods glfw.ModifierKey) {
+	switch key {
+	case glfw.KeyLeft:
+		gui.resizePane(layout.Horizontal, -1)
+	case glfw.KeyRight:
+		gui.resizePane(layout.Horizontal, 1)
+	case glfw.KeyUp:
+		gui.resizePane(layout.Vertical, -1)
+	case glfw.KeyDown:
+		gui.resizePane(layout.Vertical, 1)
+	case glfw.KeyEnter, glfw.KeyKPEnter:
+		gui.setOverlay(nil)
+	}
+}
+
+func (m *resizeMode) char(gui *GUI, r rune) {
+}
+
+// click ends the resize mode, so it isn't left on by mistake while using the mouse
+func (m *resizeMode) click(gui *GUI, x float64, y float64) {
+	gui.setOverlay(nil)
+}
+
+// paneAt returns the pane at a point in the window, or nil if there isn't one there
+func (gui *GUI) paneAt(x float64, y float64) *pane {
+	for id, rect := range gui.layout.Rects(gui.panesArea()) {
+		if rect.Contains(int(x), int(y)) {
+			return gui.panes[id]
+		}
+	}
+	return nil
+}
+
+// paneMouseButton drags dividers and focuses panes which are clicked on, returning true if it used the click
+func (gui *GUI) paneMouseButton(x float64, y float64, button glfw.MouseButton, action glfw.Action) bool {
+	if button != glfw.MouseButtonLeft || len(gui.panes) == 1 {
+		return false
+	}
+	if action == glfw.Release && gui.dragDivider != nil {
+		gui.dragDivider = nil
+		gui.terminal.SetDirty()
+		return true
+	}
+	if action != glfw.Press {
+		return false
+	}
+	if divider := gui.layout.DividerAt(gui.panesArea(), int(x), int(y)); divider != nil {
+		gui.dragDivider = divider
+		return true
+	}
+	if p := gui.paneAt(x, y); p != nil && p != gui.pane {
+		gui.focusPane(p)
+		return true
+	}
+	return false
+}
+
+// paneMouseMove drags a divider, and shows the resize cursor over one, returning true if the mouse isn't over the
+// focused pane, so it has nothing to do with it
+func (gui *GUI) paneMouseMove(w *glfw.Window, x float64, y float64) bool {
+	if len(gui.panes) == 1 {
+		return false
+	}
+	if gui.dragDivider != nil {
+		gui.dragDivider.MoveTo(int(x), int(y))
+		gui.layoutPanes()
+		return true
+	}
+	var divider *layout.Divider
+	if !gui.mouseDown && gui.paneAt(x, y) != gui.pane {
+		divider = gui.layout.DividerAt(gui.panesArea(), int(x), int(y))
+	}
+	if (divider == nil) != (gui.hoverDivider == nil) || divider != nil && !divider.Is(gui.hoverDivider) {
+		gui.hoverDivider = divider
+		gui.terminal.SetDirty()
+	}
+	if gui.mouseDown || gui.paneAt(x, y) == gui.pane {
+		return false
+	}
+	if divider == nil {
+		w.SetCursor(glfw.CreateStandardCursor(glfw.ArrowCursor))
+	} else if divider.Direction == layout.Horizontal {
+		w.SetCursor(glfw.CreateStandardCursor(glfw.HResizeCursor))
+	} else {
+		w.SetCursor(glfw.CreateStandardCursor(glfw.VResizeCursor))
+	}
+	if gui.hoverLink != nil {
+		gui.hoverLink = nil
+		gui.terminal.SetDirty()
+	}
+	return true
+}
+
+// checkDirty returns true if any pane needs redrawing. Every pane is checked, so they are all reset.
+func (gui *GUI) checkDirty() bool {
+	dirty := false
+	for _, p := range gui.panes {
+		if p.terminal.CheckDirty() {
+			dirty = true
+		}
+	}
+	return dirty
+}
+
+// pollPanes handles requests and events from the panes without focus, which the main loop doesn't wait on
+func (gui *GUI) pollPanes() {
+	for _, p := range gui.panes {
+		if p == gui.pane {
+			continue
+		}
+		select {
+		case request := <-p.terminal.Requests():
+			gui.handleRequest(request)
+		case event := <-p.terminal.UserEvents():
+			gui.handleUserEvent(p, event)
+		default:
+		}
+	}
+}
+
+// hangupPanes hangs up on the shells in all of the panes at once, waiting for them to exit
+func (gui *GUI) hangupPanes() {
+	wg := sync.WaitGroup{}
+	for _, p := range gui.panes {
+		wg.Add(1)
+		go func(p *pane) {
+			defer wg.Done()
+			p.terminal.Hangup(hangupTimeout)
+		}(p)
+	}
+	wg.Wait()
+}
+
+// renderPanes draws every pane, and the dividers between them. The focused pane is drawn last, leaving the renderer
+// set up for it.
+func (gui *GUI) renderPanes(defaultCell buffer.Cell) {
+	rects := gui.layout.Rects(gui.panesArea())
+	for _, p := range gui.panes {
+		if _, shown := rects[p.id]; shown && p != gui.pane {
+			gui.useArea(p)
+			gui.renderTerminal(p.terminal, false, defaultCell)
+		}
+	}
+	gui.useArea(gui.pane)
+	gui.renderTerminal(gui.terminal, true, defaultCell)
+	gui.renderer.SetTerminalColours(nil)
+
+	for _, p := range gui.panes {
+		if rect, shown := rects[p.id]; shown {
+			gui.renderProgress(p, rect)
+		}
+	}
+
+	for _, divider := range gui.layout.Dividers(gui.panesArea()) {
+		rect := divider.Rect
+		colour := gui.colours.DarkGrey
+		if divider.Is(gui.dragDivider) || divider.Is(gui.hoverDivider) {
+			// show which divider is about to move, or is moving
+			colour = gui.colours.LightBlue
+		}
+		gui.renderer.DrawRect(float32(rect.X), float32(rect.Y), float32(rect.Width), float32(rect.Height), colour)
+	}
+}
+
+// renderTerminal draws the cells of a terminal, in the renderer's area. Only the focused terminal shows its cursor and
+// search matches.
+func (gui *GUI) renderTerminal(t *terminal.Terminal, focused bool, defaultCell buffer.Cell) {
+
+	colours := t.ColourOverrides()
+	gui.renderer.SetTerminalColours(colours)
+	if bg, ok := colours[config.ColourBackground]; ok {
+		// the program has changed the background, which is otherwise left as the window's clear colour
+		r := gui.renderer
+		r.DrawRect(float32(r.areaX), float32(r.areaY), float32(r.areaWidth), float32(r.areaHeight), bg)
+	}
+
+	lines := t.GetVisibleLines()
+	lineCount := int(t.ActiveBuffer().ViewHeight())
+	colCount := int(t.ActiveBuffer().ViewWidth())
+	showCursor := focused && t.Modes().ShowCursor && gui.cursorVisible()
+	// only a block cursor is drawn as the background of its cell, other shapes are drawn on top of it
+	blockCursor := t.Modes().CursorShape == terminal.CursorBlock
+	cursorWide := false
+
+	var search *searchOverlay
+	var highlights map[int][]int
+	if focused {
+		search, _ = gui.overlay.(*searchOverlay)
+	}
+	if search != nil {
+		search.refresh(gui)
+		highlights = search.highlights(gui)
+	}
+	selection := t.ActiveBuffer().ViewSelection()
+	for y := 0; y < lineCount; y++ {
+		for x := 0; x < colCount; x++ {
+
+			cell := defaultCell
+
+			if y < len(lines) {
+				cells := lines[y].Cells()
+				if x < len(cells) {
+					cell = cells[x]
+				} else if fill, ok := lines[y].Fill(); ok {
+					cell = fill
+				}
+			}
+
+			cursor := false
+			if showCursor && !gui.config.Cursor.Animated() {
+				cx := uint(t.GetLogicalCursorX())
+				cy := uint(t.GetLogicalCursorY())
+				cy = cy + uint(t.GetScrollOffset())
+				if cx == uint(x) && cy == uint(y) {
+					cursorWide = cell.Wide()
+				}
+				// a cursor on a wide cell covers both halves of it
+				cursor = blockCursor && (cx == uint(x) || cx+1 == uint(x) && cell.IsContinuation()) && cy == uint(y)
+			}
+
+			var colour *config.Colour
+
+			if selection.Contains(uint16(x), uint16(y)) {
+				colour = &gui.colours.Selection
+			}
+			if search != nil {
+				if match := search.matchColour(gui, highlights, x, y); match != nil {
+					colour = match
+				}
+			}
+			gui.renderer.DrawCellBg(cell, uint(x), uint(y), cursor, colour, false)
+		}
+	}
+	// images go over the backgrounds, with the text drawn on top of them
+	for _, placement := range t.ActiveBuffer().VisibleImages() {
+		gui.renderer.DrawImage(placement)
+	}
+	if showCursor && !gui.config.Cursor.Animated() && !blockCursor {
+		cy := uint(t.GetLogicalCursorY()) + uint(t.GetScrollOffset())
+		if cy < uint(lineCount) {
+			gui.renderCursorShape(uint(t.GetLogicalCursorX()), cy, cursorWide)
+		}
+	}
+	if showCursor && gui.config.Cursor.Animated() {
+		cx := uint(t.GetLogicalCursorX())
+		cy := uint(t.GetLogicalCursorY()) + uint(t.GetScrollOffset())
+		if cy < uint(lineCount) {
+			gui.renderAnimatedCursor(cx, cy)
+		}
+	}
+	for y := 0; y < lineCount; y++ {
+		for x := 0; x < colCount; x++ {
+
+			cell := defaultCell
+			hasText := false
+
+			if y < len(lines) {
+				cells := lines[y].Cells()
+				if x < len(cells) {
+					cell = cells[x]
+					if cell.Rune() != 0 && cell.Rune() != 32 {
+						hasText = true
+					}
+				}
+			}
+
+			alpha := float32(1.0)
+			if cell.Attr().Blink {
+				if gui.config.Graphics.BlinkInterval == 0 {
+					alpha = blinkDisabledAlpha
+				} else if !gui.blinkVisible() {
+					continue
+				}
+			}
+
+			if hasText {
+				var fg *[3]float32
+				if search != nil && search.matchColour(gui, highlights, x, y) != nil {
+					// the background of a match is bright, so draw its text in the dark background colour
+					fg = (*[3]float32)(&gui.colours.Background)
+				}
+				gui.renderer.DrawCellText(cell, uint(x), uint(y), alpha, fg)
+			}
+			gui.renderer.DrawCellUnderline(cell, uint(x), uint(y))
+		}
+	}
+	gui.renderScrollbar(t)
+}
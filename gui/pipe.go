@@ -0,0 +1,36 @@
+package gui
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+)
+
+// pipe sends text to the configured pipe command in the background, optionally pasting its output back in
+func (gui *GUI) pipe(text string) {
+
+	if gui.config.Pipe.Command == "" {
+		gui.logger.Errorf("No pipe command is configured")
+		return
+	}
+
+	if text == "" {
+		return
+	}
+
+	go func() {
+		cmd := exec.Command("sh", "-c", gui.config.Pipe.Command)
+		cmd.Stdin = strings.NewReader(text)
+		output := &bytes.Buffer{}
+		cmd.Stdout = output
+		if err := cmd.Run(); err != nil {
+			gui.logger.Errorf("Pipe command failed: %s", err)
+			return
+		}
+		if gui.config.Pipe.PasteOutput && output.Len() > 0 {
+			if err := gui.terminal.Paste(output.Bytes()); err != nil {
+				gui.logger.Errorf("Failed to paste pipe command output: %s", err)
+			}
+		}
+	}()
+}
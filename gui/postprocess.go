@@ -0,0 +1,177 @@
+package gui
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-gl/gl/all-core/gl"
+)
+
+// postVertexShaderSource draws a quad over the whole window, passing the position on it to the fragment shader as uv,
+// from 0,0 at the bottom left to 1,1 at the top right
+const postVertexShaderSource = `
+	#version 150
+	in vec2 position;
+	out vec2 uv;
+	void main() {
+		uv = (position + 1.0) / 2.0;
+		gl_Position = vec4(position, 0.0, 1.0);
+	}
+` + "\x00"
+
+// postProcess draws each frame into a texture, then onto the window through a fragment shader of the user's, e.g. for
+// CRT curvature, scanlines or bloom. The shader gets the frame as the screen sampler, along with the resolution in
+// pixels and the time in seconds.
+type postProcess struct {
+	path     string
+	program  uint32
+	fbo      uint32
+	texture  uint32
+	vao      uint32
+	vbo      uint32
+	width    int
+	height   int
+	srgb     bool      // the frame is stored as sRGB, as the window encodes to sRGB itself
+	animated bool      // the shader uses the time, so the window is redrawn all the time
+	start    time.Time // when the shader was loaded, which the time counts from
+}
+
+// loadPostProcess compiles the fragment shader in a file, returning an error rather than anything half working if it
+// doesn't compile or link
+func loadPostProcess(path string, srgb bool) (*postProcess, error) {
+	if home := os.Getenv("HOME"); home != "" && strings.HasPrefix(path, "~/") {
+		path = filepath.Join(home, path[2:])
+	}
+	source, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read post-processing shader: %s", err)
+	}
+
+	vertexShader, err := compileShader(postVertexShaderSource, gl.VERTEX_SHADER)
+	if err != nil {
+		return nil, err
+	}
+	defer gl.DeleteShader(vertexShader)
+	fragmentShader, err := compileShader(string(source)+"\x00", gl.FRAGMENT_SHADER)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to compile post-processing shader %s: %s", path, err)
+	}
+	defer gl.DeleteShader(fragmentShader)
+
+	program := gl.CreateProgram()
+	gl.AttachShader(program, vertexShader)
+	gl.AttachShader(program, fragmentShader)
+	gl.LinkProgram(program)
+	var status int32
+	gl.GetProgramiv(program, gl.LINK_STATUS, &status)
+	if status == gl.FALSE {
+		var logLength int32
+		gl.GetProgramiv(program, gl.INFO_LOG_LENGTH, &logLength)
+		log := strings.Repeat("\x00", int(logLength+1))
+		gl.GetProgramInfoLog(program, logLength, nil, gl.Str(log))
+		gl.DeleteProgram(program)
+		return nil, fmt.Errorf("Failed to link post-processing shader %s: %s", path, log)
+	}
+
+	p := &postProcess{
+		path:     path,
+		program:  program,
+		srgb:     srgb,
+		animated: gl.GetUniformLocation(program, gl.Str("time\x00")) >= 0,
+		start:    time.Now(),
+	}
+
+	quad := []float32{-1, -1, 1, -1, -1, 1, 1, 1}
+	gl.GenVertexArrays(1, &p.vao)
+	gl.BindVertexArray(p.vao)
+	gl.GenBuffers(1, &p.vbo)
+	gl.BindBuffer(gl.ARRAY_BUFFER, p.vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, 4*len(quad), gl.Ptr(quad), gl.STATIC_DRAW)
+	position := uint32(gl.GetAttribLocation(program, gl.Str("position\x00")))
+	gl.EnableVertexAttribArray(position)
+	gl.VertexAttribPointer(position, 2, gl.FLOAT, false, 0, nil)
+	gl.BindVertexArray(0)
+
+	gl.GenFramebuffers(1, &p.fbo)
+	gl.GenTextures(1, &p.texture)
+	return p, nil
+}
+
+// begin makes the frame draw into the texture, resizing it to fit the window
+func (p *postProcess) begin(width int, height int) {
+	if width != p.width || height != p.height {
+		p.width, p.height = width, height
+		format := int32(gl.RGBA8)
+		if p.srgb {
+			format = gl.SRGB8_ALPHA8
+		}
+		gl.BindTexture(gl.TEXTURE_2D, p.texture)
+		gl.TexImage2D(gl.TEXTURE_2D, 0, format, int32(width), int32(height), 0, gl.RGBA, gl.UNSIGNED_BYTE, nil)
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+		gl.BindTexture(gl.TEXTURE_2D, 0)
+
+		gl.BindFramebuffer(gl.FRAMEBUFFER, p.fbo)
+		gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, p.texture, 0)
+	}
+	gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, p.fbo)
+}
+
+// end draws the frame onto the window through the shader
+func (p *postProcess) end() {
+	gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, 0)
+	gl.Disable(gl.BLEND)
+
+	gl.UseProgram(p.program)
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_2D, p.texture)
+	gl.Uniform1i(gl.GetUniformLocation(p.program, gl.Str("screen\x00")), 0)
+	gl.Uniform2f(gl.GetUniformLocation(p.program, gl.Str("resolution\x00")), float32(p.width), float32(p.height))
+	gl.Uniform1f(gl.GetUniformLocation(p.program, gl.Str("time\x00")), float32(time.Since(p.start).Seconds()))
+
+	gl.BindVertexArray(p.vao)
+	gl.DrawArrays(gl.TRIANGLE_STRIP, 0, 4)
+	gl.BindVertexArray(0)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+}
+
+func (p *postProcess) free() {
+	gl.DeleteFramebuffers(1, &p.fbo)
+	gl.DeleteTextures(1, &p.texture)
+	gl.DeleteBuffers(1, &p.vbo)
+	gl.DeleteVertexArrays(1, &p.vao)
+	gl.DeleteProgram(p.program)
+}
+
+// updatePostProcess loads the post-processing shader of the host profile, or of the config if the profile doesn't have
+// one, when it changes. A shader which fails to load is logged and left out, so the terminal is still usable.
+func (gui *GUI) updatePostProcess() {
+	path := gui.config.Graphics.PostShader
+	if gui.hostProfile != nil && gui.hostProfile.PostShader != "" {
+		path = gui.hostProfile.PostShader
+	}
+	if path == gui.postShader {
+		return
+	}
+	gui.postShader = path
+
+	if gui.post != nil {
+		gui.post.free()
+		gui.post = nil
+	}
+	if path == "" {
+		return
+	}
+	post, err := loadPostProcess(path, gui.output.Linear)
+	if err != nil {
+		gui.logger.Errorf("%s", err)
+		return
+	}
+	gui.post = post
+}
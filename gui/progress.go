@@ -0,0 +1,66 @@
+package gui
+
+import (
+	"math"
+	"time"
+
+	"github.com/liamg/aminal/config"
+	"github.com/liamg/aminal/layout"
+	"github.com/liamg/aminal/terminal"
+)
+
+// how long the block of an indeterminate progress bar takes to go across and back
+const progressSweep = 2 * time.Second
+
+// renderProgress draws a pane's progress, if its program is reporting any, as a bar along its top or bottom edge so it
+// can be seen even once the line showing it has scrolled away
+func (gui *GUI) renderProgress(p *pane, rect layout.Rect) {
+	progress := p.terminal.GetProgress()
+	if progress.State == terminal.ProgressNone || gui.config.Progress.Position == config.ProgressNone {
+		return
+	}
+
+	height := gui.config.Progress.Height
+	y := rect.Y
+	if gui.config.Progress.Position == config.ProgressBottom {
+		y = rect.Y + rect.Height - height
+	}
+
+	scheme := gui.colours
+	colour := scheme.Blue
+	x := float32(rect.X)
+	width := float32(rect.Width) * float32(progress.Percent) / 100
+
+	switch progress.State {
+	case terminal.ProgressError, terminal.ProgressPaused:
+		colour = scheme.Red
+		if progress.State == terminal.ProgressPaused {
+			colour = scheme.Yellow
+		}
+		// with no percentage, the whole bar shows the state
+		if progress.Percent == 0 {
+			width = float32(rect.Width)
+		}
+	case terminal.ProgressIndeterminate:
+		// a block a quarter of the width sweeping back and forth
+		width = float32(rect.Width) / 4
+		phase := float64(time.Now().UnixNano()%int64(progressSweep)) / float64(progressSweep)
+		along := 1 - math.Abs(1-2*phase)
+		x += (float32(rect.Width) - width) * float32(along)
+	}
+
+	gui.renderer.DrawRect(x, float32(y), width, float32(height), colour)
+}
+
+// progressAnimated returns true if any pane's progress bar moves by itself, and needs drawing every frame
+func (gui *GUI) progressAnimated() bool {
+	if gui.config.Progress.Position == config.ProgressNone {
+		return false
+	}
+	for _, p := range gui.panes {
+		if p.terminal.GetProgress().State == terminal.ProgressIndeterminate {
+			return true
+		}
+	}
+	return false
+}
@@ -13,6 +13,8 @@ import (
 type OpenGLRenderer struct {
 	font          *glfont.Font
 	boldFont      *glfont.Font
+	windowWidth   int
+	windowHeight  int
 	areaWidth     int
 	areaHeight    int
 	areaX         int
@@ -27,9 +29,21 @@ type OpenGLRenderer struct {
 	colourAttr    uint32
 	program       uint32
 	textureMap    map[*image.RGBA]uint32
+	images        *imageQuad // set up the first time an image is drawn
+	rects         *rectBatch // set up the first time rectangles are drawn together
+	gutter        float32    // pixels on the right of the area kept clear of cells, for the scrollbar
+	textures      *glfont.TextureBudget
 	fontMap       *FontMap
+	scheme        config.ColourScheme   // the colours cells chosen from the palette are drawn in
+	termColours   map[int]config.Colour // colours changed by the program in the terminal being drawn, by palette index
+	colourFilter  *config.ColourFilter
+	filtered      map[config.Colour]config.Colour // colours already run through the filter, which is slow to apply
+	output        glfont.ColourOutput
 }
 
+// the most filtered colours remembered, so true colour gradients can't grow the cache without limit
+const maxFilteredColours = 4096
+
 type rectangle struct {
 	vao        uint32
 	vbo        uint32
@@ -52,26 +66,89 @@ func (r *OpenGLRenderer) Clean() {
 	r.rectangles = map[[2]uint]*rectangle{}
 }
 
-func (r *OpenGLRenderer) newRectangle(x float32, y float32, colourAttr uint32) *rectangle {
+// SetColourScheme sets the colours of the current theme, with any host profile's on top. Cells whose colours were chosen
+// from the palette or are the default ones are drawn in the scheme's colours for them.
+func (r *OpenGLRenderer) SetColourScheme(scheme config.ColourScheme) {
+	r.scheme = scheme
+}
+
+// SetTerminalColours sets the colours a program has changed in the terminal being drawn, with OSC 4 and 10 to 12,
+// which are drawn instead of the theme's. They are keyed as for Terminal.ColourOverrides.
+func (r *OpenGLRenderer) SetTerminalColours(m map[int]config.Colour) {
+	r.termColours = m
+}
+
+// CursorColour returns the colour of the cursor in the terminal being drawn
+func (r *OpenGLRenderer) CursorColour() config.Colour {
+	if c, ok := r.termColours[config.ColourCursor]; ok {
+		return c
+	}
+	return r.scheme.Cursor
+}
 
-	halfAreaWidth := float32(r.areaWidth / 2)
-	halfAreaHeight := float32(r.areaHeight / 2)
+// SetColourOutput sets how colours are written to the window, which the shaders take care of apart from the clear colour
+func (r *OpenGLRenderer) SetColourOutput(output glfont.ColourOutput) {
+	r.output = output
+	if r.images != nil {
+		output.Use(r.images.program)
+	}
+}
+
+// SetClearColour sets the colour the window is cleared to before each frame
+func (r *OpenGLRenderer) SetClearColour(c config.Colour) {
+	c = r.output.Convert(r.filterColour(c))
+	gl.ClearColor(c[0], c[1], c[2], 1.0)
+}
+
+// SetColourFilter sets a filter all colours are changed by at render time, for colour blind users, or nil for none
+func (r *OpenGLRenderer) SetColourFilter(filter *config.ColourFilter) {
+	r.colourFilter = filter
+	r.filtered = map[config.Colour]config.Colour{}
+}
+
+// cellColour returns the colour one of a cell's colours is drawn in, before filtering: the one the program has set for
+// where in the palette it was chosen from, or the scheme's colour for that. Colours given as RGB are drawn as they are,
+// even if they happen to be the same as a palette colour the program has changed.
+func (r *OpenGLRenderer) cellColour(c config.Colour, index buffer.ColourIndex) config.Colour {
+	n, ok := index.Palette()
+	if !ok {
+		return c
+	}
+	if changed, ok := r.termColours[n]; ok {
+		return changed
+	}
+	return r.scheme.PaletteColour(n)
+}
+
+// TextColour returns the colour a cell's text is drawn in, before filtering
+func (r *OpenGLRenderer) TextColour(cell buffer.Cell) config.Colour {
+	attr := cell.Attr()
+	if attr.Reverse {
+		return r.cellColour(attr.BgColour, attr.BgIndex)
+	}
+	return r.cellColour(attr.FgColour, attr.FgIndex)
+}
+
+func (r *OpenGLRenderer) filterColour(c config.Colour) config.Colour {
+	if r.colourFilter == nil {
+		return c
+	}
+	if filtered, ok := r.filtered[c]; ok {
+		return filtered
+	}
+	if len(r.filtered) >= maxFilteredColours {
+		r.filtered = map[config.Colour]config.Colour{}
+	}
+	filtered := r.colourFilter.Apply(c)
+	r.filtered[c] = filtered
+	return filtered
+}
 
-	x = (x - halfAreaWidth) / halfAreaWidth
-	y = -(y - (halfAreaHeight)) / halfAreaHeight
-	w := r.cellWidth / halfAreaWidth
-	h := (r.cellHeight) / halfAreaHeight
+// x and y are the bottom left corner of the rectangle, in pixels from the top left of the window
+func (r *OpenGLRenderer) newRectangle(x float32, y float32, width float32, height float32, colourAttr uint32) *rectangle {
 
 	rect := &rectangle{
-		points: []float32{
-			x, y, 0,
-			x, y + h, 0,
-			x + w, y + h, 0,
-
-			x + w, y, 0,
-			x, y, 0,
-			x + w, y + h, 0,
-		},
+		points:     r.appendRectPoints(nil, x, y, width, height),
 		colourAttr: colourAttr,
 		prog:       r.program,
 	}
@@ -98,6 +175,29 @@ func (r *OpenGLRenderer) newRectangle(x float32, y float32, colourAttr uint32) *
 	return rect
 }
 
+// appendRectPoints appends the corners of the two triangles making up a rectangle, x and y being its bottom left corner
+// in pixels from the top left of the window
+func (r *OpenGLRenderer) appendRectPoints(points []float32, x float32, y float32, width float32, height float32) []float32 {
+
+	halfWindowWidth := float32(r.windowWidth / 2)
+	halfWindowHeight := float32(r.windowHeight / 2)
+
+	x = (x - halfWindowWidth) / halfWindowWidth
+	y = -(y - (halfWindowHeight)) / halfWindowHeight
+	w := width / halfWindowWidth
+	h := height / halfWindowHeight
+
+	return append(points,
+		x, y, 0,
+		x, y+h, 0,
+		x+w, y+h, 0,
+
+		x+w, y, 0,
+		x, y, 0,
+		x+w, y+h, 0,
+	)
+}
+
 func (rect *rectangle) Draw() {
 	gl.UseProgram(rect.prog)
 	gl.BindVertexArray(rect.vao)
@@ -134,8 +234,10 @@ func (rect *rectangle) Free() {
 	gl.DeleteBuffers(1, &rect.cv)
 }
 
-func NewOpenGLRenderer(config *config.Config, fontMap *FontMap, areaX int, areaY int, areaWidth int, areaHeight int, colourAttr uint32, program uint32) *OpenGLRenderer {
+func NewOpenGLRenderer(config *config.Config, fontMap *FontMap, textures *glfont.TextureBudget, areaX int, areaY int, areaWidth int, areaHeight int, colourAttr uint32, program uint32) *OpenGLRenderer {
 	r := &OpenGLRenderer{
+		windowWidth:   areaWidth,
+		windowHeight:  areaHeight,
 		areaWidth:     areaWidth,
 		areaHeight:    areaHeight,
 		areaX:         areaX,
@@ -146,9 +248,11 @@ func NewOpenGLRenderer(config *config.Config, fontMap *FontMap, areaX int, areaY
 		colourAttr:    colourAttr,
 		program:       program,
 		textureMap:    map[*image.RGBA]uint32{},
+		textures:      textures,
 		fontMap:       fontMap,
 	}
 	r.SetArea(areaX, areaY, areaWidth, areaHeight)
+	r.SetColourFilter(config.Graphics.Filter())
 	return r
 }
 
@@ -156,6 +260,13 @@ func (r *OpenGLRenderer) GetTermSize() (uint, uint) {
 	return r.termCols, r.termRows
 }
 
+// SetWindowSize sets the size of the whole window, which may be larger than the area the terminal is rendered in
+func (r *OpenGLRenderer) SetWindowSize(width int, height int) {
+	r.windowWidth = width
+	r.windowHeight = height
+}
+
+// SetArea sets the part of the window the terminal is rendered in
 func (r *OpenGLRenderer) SetArea(areaX int, areaY int, areaWidth int, areaHeight int) {
 	r.areaWidth = areaWidth
 	r.areaHeight = areaHeight
@@ -165,8 +276,16 @@ func (r *OpenGLRenderer) SetArea(areaX int, areaY int, areaWidth int, areaHeight
 	_, r.cellHeight = f.MaxSize()
 	r.cellWidth, _ = f.Size("X")
 	//= f.LineHeight()   // includes vertical padding
-	r.termCols = uint(math.Floor(float64(float32(r.areaWidth) / r.cellWidth)))
+	r.gutter = 0
+	if r.config.Scrollbar {
+		r.gutter = scrollbarWidth(r.cellWidth)
+	}
+	r.termCols = uint(math.Floor(float64((float32(r.areaWidth) - r.gutter) / r.cellWidth)))
 	r.termRows = uint(math.Floor(float64(float32(r.areaHeight) / r.cellHeight)))
+	// the area changes for each pane drawn, so the rectangles of the last one are freed rather than left behind
+	for _, rect := range r.rectangles {
+		rect.Free()
+	}
 	r.rectangles = map[[2]uint]*rectangle{}
 }
 
@@ -177,39 +296,112 @@ func (r *OpenGLRenderer) getRectangle(col uint, row uint) *rectangle {
 		rect.Free()
 	}
 
-	x := float32(float32(col) * r.cellWidth)
-	y := float32(float32(row)*r.cellHeight) + r.cellHeight
+	x := float32(r.areaX) + float32(col)*r.cellWidth
+	y := float32(r.areaY) + float32(row+1)*r.cellHeight
 
-	r.rectangles[[2]uint{col, row}] = r.newRectangle(x, y, r.colourAttr)
+	r.rectangles[[2]uint{col, row}] = r.newRectangle(x, y, r.cellWidth, r.cellHeight, r.colourAttr)
 	return r.rectangles[[2]uint{col, row}]
 }
 
+// DrawRect draws a solid rectangle, x and y being the top left corner in pixels
+func (r *OpenGLRenderer) DrawRect(x float32, y float32, width float32, height float32, colour config.Colour) {
+	rect := r.newRectangle(x, y+height, width, height, r.colourAttr)
+	rect.setColour(r.filterColour(colour))
+	rect.Draw()
+	rect.Free()
+}
+
+// filledRect is a solid rectangle to draw with DrawRects, x and y being the top left corner in pixels
+type filledRect struct {
+	x, y, width, height float32
+	colour              config.Colour
+}
+
+// rectBatch draws any number of solid rectangles with one set of buffers, kept from frame to frame, rather than
+// a set made and freed for each rectangle
+type rectBatch struct {
+	vao     uint32
+	vbo     uint32
+	cv      uint32
+	points  []float32
+	colours []float32
+}
+
+func (r *OpenGLRenderer) newRectBatch() *rectBatch {
+	batch := &rectBatch{}
+	gl.UseProgram(r.program)
+	gl.GenVertexArrays(1, &batch.vao)
+	gl.BindVertexArray(batch.vao)
+
+	gl.GenBuffers(1, &batch.vbo)
+	gl.BindBuffer(gl.ARRAY_BUFFER, batch.vbo)
+	gl.EnableVertexAttribArray(0)
+	gl.VertexAttribPointer(0, 3, gl.FLOAT, false, 0, nil)
+
+	gl.GenBuffers(1, &batch.cv)
+	gl.BindBuffer(gl.ARRAY_BUFFER, batch.cv)
+	gl.EnableVertexAttribArray(r.colourAttr)
+	gl.VertexAttribPointer(r.colourAttr, 3, gl.FLOAT, false, 0, nil)
+	gl.BindVertexArray(0)
+	return batch
+}
+
+// DrawRects draws solid rectangles all at once, which is much quicker than DrawRect for each when there are many of
+// them, e.g. the marks on a scrollbar
+func (r *OpenGLRenderer) DrawRects(rects []filledRect) {
+	if len(rects) == 0 {
+		return
+	}
+	if r.rects == nil {
+		r.rects = r.newRectBatch()
+	}
+	batch := r.rects
+	batch.points, batch.colours = batch.points[:0], batch.colours[:0]
+	for _, rect := range rects {
+		batch.points = r.appendRectPoints(batch.points, rect.x, rect.y+rect.height, rect.width, rect.height)
+		c := r.filterColour(rect.colour)
+		for i := 0; i < 6; i++ {
+			batch.colours = append(batch.colours, c[0], c[1], c[2])
+		}
+	}
+
+	gl.UseProgram(r.program)
+	gl.BindVertexArray(batch.vao)
+	gl.BindBuffer(gl.ARRAY_BUFFER, batch.vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, 4*len(batch.points), gl.Ptr(batch.points), gl.STREAM_DRAW)
+	gl.BindBuffer(gl.ARRAY_BUFFER, batch.cv)
+	gl.BufferData(gl.ARRAY_BUFFER, 4*len(batch.colours), gl.Ptr(batch.colours), gl.STREAM_DRAW)
+	gl.DrawArrays(gl.TRIANGLES, 0, int32(len(batch.points)/3))
+	gl.BindVertexArray(0)
+}
+
 func (r *OpenGLRenderer) DrawCursor(col uint, row uint, colour config.Colour) {
 	rect := r.getRectangle(col, row)
-	rect.setColour(colour)
+	rect.setColour(r.filterColour(colour))
 	rect.Draw()
 }
 
 func (r *OpenGLRenderer) DrawCellBg(cell buffer.Cell, col uint, row uint, cursor bool, colour *config.Colour, force bool) {
 
-	var bg [3]float32
+	var bg config.Colour
 
 	if colour != nil {
 		bg = *colour
 	} else {
 
+		attr := cell.Attr()
 		if cursor {
-			bg = r.config.ColourScheme.Cursor
-		} else if cell.Attr().Reverse {
-			bg = cell.Fg()
+			bg = r.CursorColour()
+		} else if attr.Reverse {
+			bg = r.cellColour(attr.FgColour, attr.FgIndex)
 		} else {
-			bg = cell.Bg()
+			bg = r.cellColour(attr.BgColour, attr.BgIndex)
 		}
 	}
 
-	if bg != r.config.ColourScheme.Background || force {
+	if bg != r.scheme.Background || force {
 		rect := r.getRectangle(col, row)
-		rect.setColour(bg)
+		rect.setColour(r.filterColour(bg))
 		rect.Draw()
 	}
 
@@ -217,14 +409,12 @@ func (r *OpenGLRenderer) DrawCellBg(cell buffer.Cell, col uint, row uint, cursor
 
 func (r *OpenGLRenderer) DrawCellText(cell buffer.Cell, col uint, row uint, alpha float32, colour *[3]float32) {
 
-	var fg [3]float32
+	var fg config.Colour
 
 	if colour != nil {
 		fg = *colour
-	} else if cell.Attr().Reverse {
-		fg = cell.Bg()
 	} else {
-		fg = cell.Fg()
+		fg = r.TextColour(cell)
 	}
 
 	f := r.fontMap.GetFont(cell.Rune())
@@ -235,71 +425,221 @@ func (r *OpenGLRenderer) DrawCellText(cell buffer.Cell, col uint, row uint, alph
 	if cell.Attr().Dim {
 		alpha = 0.5 * alpha
 	}
+	fg = r.filterColour(fg)
 	f.SetColor(fg[0], fg[1], fg[2], alpha)
 
 	x := float32(r.areaX) + float32(col)*r.cellWidth
 	y := float32(r.areaY) + (float32(row+1) * r.cellHeight) + f.MinY()
 
-	f.Print(x, y, string(cell.Rune()))
+	f.PrintCluster(x, y, cell.Runes())
 }
 
-func (r *OpenGLRenderer) DrawCellImage(cell buffer.Cell, col uint, row uint) {
+// DrawCellUnderline draws the underline of a cell in its style, in the underline colour if it has one, otherwise in the
+// text colour. Each cell draws its own piece, and a curly underline's piece is one whole wave so the pieces join up.
+func (r *OpenGLRenderer) DrawCellUnderline(cell buffer.Cell, col uint, row uint) {
+
+	attr := cell.Attr()
+	if attr.Underline == buffer.UnderlineNone || attr.Hidden {
+		return
+	}
+
+	colour := r.TextColour(cell)
+	if attr.UnderlineColoured {
+		colour = attr.UnderlineColour
+	}
+
+	x := float32(r.areaX) + float32(col)*r.cellWidth
+	bottom := float32(r.areaY) + float32(row+1)*r.cellHeight
+	descent := -r.fontMap.GetFont('X').MinY()
+	baseline := bottom - descent
+	thickness := float32(math.Max(1, math.Round(float64(r.cellHeight)/16)))
+
+	// just below the baseline, but never out of the cell
+	y := baseline + thickness
+	if y > bottom-thickness {
+		y = bottom - thickness
+	}
+
+	switch attr.Underline {
+	case buffer.UnderlineSingle:
+		r.DrawRect(x, y, r.cellWidth, thickness, colour)
+	case buffer.UnderlineDouble:
+		if y+3*thickness > bottom {
+			y = bottom - 3*thickness
+		}
+		r.DrawRect(x, y, r.cellWidth, thickness, colour)
+		r.DrawRect(x, y+2*thickness, r.cellWidth, thickness, colour)
+	case buffer.UnderlineDotted:
+		for dx := float32(0); dx < r.cellWidth; dx += 2 * thickness {
+			r.DrawRect(x+dx, y, float32(math.Min(float64(thickness), float64(r.cellWidth-dx))), thickness, colour)
+		}
+	case buffer.UnderlineDashed:
+		// two dashes with even gaps between them, across cells too
+		dash := r.cellWidth * 3 / 8
+		r.DrawRect(x+r.cellWidth/16, y, dash, thickness, colour)
+		r.DrawRect(x+r.cellWidth*9/16, y, dash, thickness, colour)
+	case buffer.UnderlineCurly:
+		// a sine wave in the space below the baseline, drawn as steps a line thick which are tall enough to join up
+		amplitude := float32(math.Min(float64(descent-thickness)/2, float64(2*thickness)))
+		if amplitude < thickness/2 {
+			amplitude = thickness / 2
+		}
+		centre := baseline + descent/2
+		wave := func(dx float32) float32 {
+			return amplitude * float32(math.Sin(2*math.Pi*float64(dx/r.cellWidth)))
+		}
+		for dx := float32(0); dx < r.cellWidth; dx += thickness {
+			step := float32(math.Min(float64(thickness), float64(r.cellWidth-dx)))
+			from, to := wave(dx), wave(dx+step)
+			top := float32(math.Min(float64(from), float64(to)))
+			height := thickness + float32(math.Abs(float64(to-from)))
+			r.DrawRect(x+dx, centre+top-thickness/2, step, height, colour)
+		}
+	}
+}
 
-	img := cell.Image()
+// DrawImage draws an image placed by a program over the cells it covers, clipped to the pane
+func (r *OpenGLRenderer) DrawImage(placement buffer.ImagePlacement) {
 
+	img := placement.Pixels
 	if img == nil {
 		return
 	}
 
-	ix := float32(col) * r.cellWidth
-	iy := float32(r.areaHeight) - (float32(row+1) * r.cellHeight)
-	iy -= float32(cell.Image().Bounds().Size().Y)
-	gl.UseProgram(r.program)
+	// where the image goes, in pixels from the top left of the window
+	left := float32(r.areaX) + float32(placement.Col)*r.cellWidth
+	top := float32(r.areaY) + float32(placement.Row)*r.cellHeight
+	width := placement.Width * r.cellWidth
+	height := placement.Height * r.cellHeight
+	if width <= 0 || height <= 0 {
+		return
+	}
 
-	var tex uint32
+	clipLeft := float32(math.Max(float64(left), float64(r.areaX)))
+	clipTop := float32(math.Max(float64(top), float64(r.areaY)))
+	clipRight := float32(math.Min(float64(left+width), float64(r.areaX+r.areaWidth)))
+	clipBottom := float32(math.Min(float64(top+height), float64(r.areaY+r.areaHeight)))
+	if clipLeft >= clipRight || clipTop >= clipBottom {
+		return
+	}
 
-	tex, ok := r.textureMap[img]
-	if !ok {
-		gl.Enable(gl.TEXTURE_2D)
-		gl.GenTextures(1, &tex)
-		gl.BindTexture(gl.TEXTURE_2D, tex)
-		gl.TexParameterf(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
-		gl.TexParameterf(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
-		gl.TexParameterf(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
-		gl.TexParameterf(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
-
-		gl.TexImage2D(
-			gl.TEXTURE_2D,
-			0,
-			gl.RGBA,
-			int32(img.Bounds().Size().X),
-			int32(img.Bounds().Size().Y),
-			0,
-			gl.RGBA,
-			gl.UNSIGNED_BYTE,
-			gl.Ptr(img.Pix),
-		)
-		gl.BindTexture(gl.TEXTURE_2D, 0)
-		gl.Disable(gl.TEXTURE_2D)
-
-		gl.Disable(gl.BLEND)
-
-		r.textureMap[img] = tex
-	}
-
-	var w = float32(img.Bounds().Size().X)
-	var h = float32(img.Bounds().Size().Y)
-
-	var readFboId uint32
-	gl.GenFramebuffers(1, &readFboId)
-	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, readFboId)
-
-	gl.FramebufferTexture2D(gl.READ_FRAMEBUFFER, gl.COLOR_ATTACHMENT0,
-		gl.TEXTURE_2D, tex, 0)
-	gl.BlitFramebuffer(0, 0, int32(w), int32(h),
-		int32(ix), int32(iy), int32(ix+w), int32(iy+h),
-		gl.COLOR_BUFFER_BIT, gl.LINEAR)
-	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, 0)
-	gl.DeleteFramebuffers(1, &readFboId)
+	// the part of the image which is left after clipping
+	pixelWidth := float32(img.Bounds().Dx())
+	pixelHeight := float32(img.Bounds().Dy())
+	srcLeft := (clipLeft - left) * pixelWidth / width
+	srcRight := (clipRight - left) * pixelWidth / width
+	srcTop := (clipTop - top) * pixelHeight / height
+	srcBottom := (clipBottom - top) * pixelHeight / height
+
+	tex := r.imageTexture(img)
+	if r.images == nil {
+		images, err := newImageQuad(r.output)
+		if err != nil {
+			return // images can't be drawn without their shaders, but the text can
+		}
+		r.images = images
+	}
+
+	// the window counts up from the bottom, and the first row of the texture is the top of the image
+	x := func(px float32) float32 { return 2*px/float32(r.windowWidth) - 1 }
+	y := func(px float32) float32 { return 1 - 2*px/float32(r.windowHeight) }
+	r.images.draw(tex, [16]float32{
+		x(clipLeft), y(clipTop), srcLeft / pixelWidth, srcTop / pixelHeight,
+		x(clipLeft), y(clipBottom), srcLeft / pixelWidth, srcBottom / pixelHeight,
+		x(clipRight), y(clipTop), srcRight / pixelWidth, srcTop / pixelHeight,
+		x(clipRight), y(clipBottom), srcRight / pixelWidth, srcBottom / pixelHeight,
+	})
+}
 
+// imageQuad draws images as a textured quad, blended over what is under them. The one quad is used for every image,
+// with its corners changed for each.
+type imageQuad struct {
+	program uint32
+	vao     uint32
+	vbo     uint32
+}
+
+func newImageQuad(output glfont.ColourOutput) (*imageQuad, error) {
+	program, err := linkProgram(imageVertexShaderSource, imageFragmentShaderSource)
+	if err != nil {
+		return nil, err
+	}
+	quad := &imageQuad{program: program}
+	output.Use(program)
+	gl.Uniform1i(gl.GetUniformLocation(program, gl.Str("image\x00")), 0)
+
+	gl.GenVertexArrays(1, &quad.vao)
+	gl.BindVertexArray(quad.vao)
+	gl.GenBuffers(1, &quad.vbo)
+	gl.BindBuffer(gl.ARRAY_BUFFER, quad.vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, 16*4, nil, gl.DYNAMIC_DRAW)
+
+	// each corner is its position in the window then in the texture
+	position := uint32(gl.GetAttribLocation(program, gl.Str("position\x00")))
+	gl.EnableVertexAttribArray(position)
+	gl.VertexAttribPointer(position, 2, gl.FLOAT, false, 4*4, gl.PtrOffset(0))
+	texturePosition := uint32(gl.GetAttribLocation(program, gl.Str("texturePosition\x00")))
+	gl.EnableVertexAttribArray(texturePosition)
+	gl.VertexAttribPointer(texturePosition, 2, gl.FLOAT, false, 4*4, gl.PtrOffset(2*4))
+	gl.BindVertexArray(0)
+
+	return quad, nil
+}
+
+// draw draws a texture between four corners, given as top left, bottom left, top right and bottom right
+func (quad *imageQuad) draw(tex uint32, corners [16]float32) {
+	gl.UseProgram(quad.program)
+	gl.Enable(gl.BLEND)
+	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_2D, tex)
+
+	gl.BindVertexArray(quad.vao)
+	gl.BindBuffer(gl.ARRAY_BUFFER, quad.vbo)
+	gl.BufferSubData(gl.ARRAY_BUFFER, 0, len(corners)*4, gl.Ptr(&corners[0]))
+	gl.DrawArrays(gl.TRIANGLE_STRIP, 0, 4)
+	gl.BindVertexArray(0)
+}
+
+// imageTexture returns the texture holding an image, uploading it the first time it is drawn
+func (r *OpenGLRenderer) imageTexture(img *image.RGBA) uint32 {
+
+	if tex, ok := r.textureMap[img]; ok {
+		r.textures.Touch(tex)
+		return tex
+	}
+
+	var tex uint32
+	gl.UseProgram(r.program)
+	gl.Enable(gl.TEXTURE_2D)
+	gl.GenTextures(1, &tex)
+	gl.BindTexture(gl.TEXTURE_2D, tex)
+	// images are usually scaled to fit the cells, which looks best smoothed
+	gl.TexParameterf(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	gl.TexParameterf(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	gl.TexParameterf(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameterf(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+
+	// images are sRGB, which the shader converts for the window as it does every other colour
+	gl.TexImage2D(
+		gl.TEXTURE_2D,
+		0,
+		gl.RGBA,
+		int32(img.Bounds().Size().X),
+		int32(img.Bounds().Size().Y),
+		0,
+		gl.RGBA,
+		gl.UNSIGNED_BYTE,
+		gl.Ptr(img.Pix),
+	)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	gl.Disable(gl.TEXTURE_2D)
+
+	gl.Disable(gl.BLEND)
+
+	r.textureMap[img] = tex
+	r.textures.Add(tex, len(img.Pix), func() {
+		delete(r.textureMap, img)
+	})
+	return tex
 }
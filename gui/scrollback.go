@@ -0,0 +1,126 @@
+package gui
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// editScrollback writes the scrollback to a temporary file and opens it in the user's editor, in a new window
+func (gui *GUI) editScrollback() error {
+
+	f, err := ioutil.TempFile("", "aminal-scrollback-")
+	if err != nil {
+		return err
+	}
+	_, err = f.WriteString(gui.terminal.ActiveBuffer().GetAllText() + "\n")
+	f.Close()
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+
+	if err := gui.openInEditor(f.Name(), true); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
+
+// editAltScreenHistory opens the snapshots of the alternate screen in the user's editor, oldest first, each headed by
+// when it was taken
+func (gui *GUI) editAltScreenHistory() error {
+
+	snapshots := gui.terminal.AltScreenHistory()
+	if len(snapshots) == 0 {
+		if gui.config.AltScreenHistory == 0 {
+			return fmt.Errorf("No history kept: set alt_screen_history to keep some")
+		}
+		return fmt.Errorf("Nothing has been shown on the alternate screen yet")
+	}
+
+	text := strings.Builder{}
+	for _, snapshot := range snapshots {
+		fmt.Fprintf(&text, "--- %s %s\n", snapshot.Time.Format("15:04:05"), snapshot.Title)
+		text.WriteString(snapshot.Text() + "\n\n")
+	}
+
+	f, err := ioutil.TempFile("", "aminal-alt-screen-")
+	if err != nil {
+		return err
+	}
+	_, err = f.WriteString(text.String())
+	f.Close()
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+
+	if err := gui.openInEditor(f.Name(), true); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
+
+// openInEditor opens a file in the user's editor in a new window, optionally deleting it once the editor exits
+func (gui *GUI) openInEditor(file string, remove bool) error {
+
+	path := shellQuote(file)
+	command := fmt.Sprintf("%s %s", gui.editor(), path)
+	if remove {
+		command = fmt.Sprintf("%s; rm -f %s", command, path)
+	}
+	return runInNewWindow(command)
+}
+
+// openInEditorAt opens a file in the editor at a line, and column if the editor_line command takes one
+func (gui *GUI) openInEditorAt(file string, line int, column int) error {
+	if column < 1 {
+		column = 1
+	}
+	command := gui.config.EditorLine
+	if command == "" {
+		command = gui.editor() + " +$LINE $FILE"
+	}
+	command = strings.NewReplacer(
+		"$FILE", shellQuote(file),
+		"$LINE", strconv.Itoa(line),
+		"$COLUMN", strconv.Itoa(column),
+	).Replace(command)
+	return runInNewWindow(command)
+}
+
+func (gui *GUI) editor() string {
+	if gui.config.Editor != "" {
+		return gui.config.Editor
+	}
+	if editor := os.Getenv("EDITOR"); editor != "" {
+		return editor
+	}
+	return "less"
+}
+
+// runInNewWindow runs a shell command in a new aminal window, which closes when it exits
+func runInNewWindow(command string) error {
+
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(executable, "--command", command)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	return cmd.Process.Release()
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
@@ -0,0 +1,148 @@
+package gui
+
+import (
+	"math"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/liamg/aminal/buffer"
+	"github.com/liamg/aminal/config"
+	"github.com/liamg/aminal/terminal"
+)
+
+// scrollbar is where the scrollbar of the pane being drawn goes, in pixels from the top left of the window. It is
+// drawn in a gutter on the right of the pane, kept clear of cells, while there is scrollback, with a tick for each
+// prompt, separator, bell and failed command, so the whole session can be seen at a glance and jumped around by
+// clicking.
+type scrollbar struct {
+	x, y          float32
+	width, height float32
+	lines         int // in the buffer, including the scrollback
+}
+
+// scrollbar returns the scrollbar of the pane in the renderer's area, or false if it isn't shown
+func (gui *GUI) scrollbar(t *terminal.Terminal) (scrollbar, bool) {
+	r := gui.renderer
+	lines := t.ActiveBuffer().Height()
+	if !gui.config.Scrollbar || lines <= int(t.ActiveBuffer().ViewHeight()) {
+		return scrollbar{}, false
+	}
+	width := scrollbarWidth(r.cellWidth)
+	return scrollbar{
+		x:      float32(r.areaX+r.areaWidth) - width,
+		y:      float32(r.areaY),
+		width:  width,
+		height: float32(r.areaHeight),
+		lines:  lines,
+	}, true
+}
+
+// scrollbarWidth returns the width of the gutter the scrollbar is drawn in, for a font with cells of the given width
+func scrollbarWidth(cellWidth float32) float32 {
+	return float32(math.Max(4, math.Round(float64(cellWidth)/2)))
+}
+
+// lineY returns how far down the scrollbar a raw line is
+func (bar scrollbar) lineY(line int) float32 {
+	return bar.y + bar.height*float32(line)/float32(bar.lines)
+}
+
+// lineAt returns the raw line at a height on the scrollbar
+func (bar scrollbar) lineAt(y float64) int {
+	line := int((float32(y) - bar.y) * float32(bar.lines) / bar.height)
+	if line < 0 {
+		return 0
+	}
+	if line >= bar.lines {
+		return bar.lines - 1
+	}
+	return line
+}
+
+func (bar scrollbar) contains(x float64, y float64) bool {
+	return float32(x) >= bar.x && float32(x) < bar.x+bar.width && float32(y) >= bar.y && float32(y) < bar.y+bar.height
+}
+
+// tickHeight is how tall the mark for a landmark line is, at least a couple of pixels however long the scrollback is
+func (bar scrollbar) tickHeight() float32 {
+	return float32(math.Max(2, float64(bar.height/float32(bar.lines))))
+}
+
+// renderScrollbar draws the scrollbar of the pane in the renderer's area, if it has one
+func (gui *GUI) renderScrollbar(t *terminal.Terminal) {
+	bar, ok := gui.scrollbar(t)
+	if !ok {
+		return
+	}
+	buf := t.ActiveBuffer()
+	r := gui.renderer
+
+	// the part of the buffer in view
+	top := bar.lines - int(buf.ViewHeight()) - int(buf.GetScrollOffset())
+	thumbHeight := float32(math.Max(float64(bar.height)*float64(buf.ViewHeight())/float64(bar.lines), float64(r.cellHeight)/2))
+	thumbY := float32(math.Min(float64(bar.lineY(top)), float64(bar.y+bar.height-thumbHeight)))
+	gui.scrollbarRects = append(gui.scrollbarRects[:0], filledRect{bar.x, thumbY, bar.width, thumbHeight, gui.colours.Selection})
+
+	tickHeight := bar.tickHeight()
+	for _, landmark := range buf.Landmarks() {
+		y := float32(math.Min(float64(bar.lineY(landmark.Line)), float64(bar.y+bar.height-tickHeight)))
+		tick := filledRect{bar.x, y, bar.width, tickHeight, gui.landmarkColour(landmark.Kind)}
+		// long scrollback puts many landmarks on the same pixels, which only need drawing once
+		if last := gui.scrollbarRects[len(gui.scrollbarRects)-1]; last != tick {
+			gui.scrollbarRects = append(gui.scrollbarRects, tick)
+		}
+	}
+	r.DrawRects(gui.scrollbarRects)
+}
+
+func (gui *GUI) landmarkColour(kind buffer.LandmarkKind) config.Colour {
+	switch kind {
+	case buffer.LandmarkMark:
+		return gui.colours.Foreground
+	case buffer.LandmarkBell:
+		return gui.colours.Yellow
+	case buffer.LandmarkError:
+		return gui.colours.Red
+	default:
+		return gui.colours.LightBlue
+	}
+}
+
+// scrollbarMouseButton jumps to the landmark clicked on in the focused pane's scrollbar, or to wherever it was clicked
+// if there isn't one there, and has the view follow the mouse until the button is released. It returns true if it used
+// the click.
+func (gui *GUI) scrollbarMouseButton(px float64, py float64, button glfw.MouseButton, action glfw.Action) bool {
+	if button != glfw.MouseButtonLeft {
+		return false
+	}
+	if action == glfw.Release && gui.scrollbarHeld {
+		gui.scrollbarHeld = false
+		return true
+	}
+	bar, ok := gui.scrollbar(gui.terminal)
+	if action != glfw.Press || !ok || !bar.contains(px, py) {
+		return false
+	}
+	gui.scrollbarHeld = true
+
+	buf := gui.terminal.ActiveBuffer()
+	// ticks are small, so clicks near one count
+	closest, distance := -1, float64(gui.renderer.cellHeight)/2
+	for _, landmark := range buf.Landmarks() {
+		if d := math.Abs(float64(bar.lineY(landmark.Line)+bar.tickHeight()/2) - py); d <= distance {
+			closest, distance = landmark.Line, d
+		}
+	}
+	if closest > -1 {
+		buf.ScrollLineToTop(closest)
+	} else {
+		gui.scrollbarDrag(py)
+	}
+	return true
+}
+
+// scrollbarDrag scrolls so the line under the mouse on the scrollbar is in the middle of the view
+func (gui *GUI) scrollbarDrag(py float64) {
+	if bar, ok := gui.scrollbar(gui.terminal); ok {
+		gui.terminal.ActiveBuffer().ScrollLineToTop(bar.lineAt(py) - int(gui.terminal.ActiveBuffer().ViewHeight())/2)
+	}
+}
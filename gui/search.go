@@ -0,0 +1,240 @@
+package gui
+
+import (
+	"fmt"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/liamg/aminal/buffer"
+	"github.com/liamg/aminal/config"
+)
+
+const minimapWidth = 8
+
+// searchOverlay finds text in the scrollback, highlighting the matches and marking every one of them on a minimap down
+// the right hand side of the terminal
+type searchOverlay struct {
+	query          string
+	regex          bool // ctrl + r switches between plain text and regular expressions
+	err            error
+	matches        []buffer.Match
+	current        int
+	searchedHeight int
+	searchedLines  [2]uint64 // the ids of the first and last lines searched, see Buffer.LineIDs
+}
+
+func newSearchOverlay() *searchOverlay {
+	return &searchOverlay{}
+}
+
+func (s *searchOverlay) search(gui *GUI) {
+	s.find(gui)
+	// start from the most recent output and work backwards
+	s.current = len(s.matches) - 1
+	s.jump(gui)
+}
+
+func (s *searchOverlay) find(gui *GUI) {
+	buf := gui.terminal.ActiveBuffer()
+	s.matches, s.err = buf.Find(s.query, s.regex)
+	s.searchedHeight = buf.Height()
+	s.searchedLines[0], s.searchedLines[1] = buf.LineIDs()
+}
+
+// refresh searches again when output has changed the buffer, staying on the same match if it's still there
+func (s *searchOverlay) refresh(gui *GUI) {
+	first, last := gui.terminal.ActiveBuffer().LineIDs()
+	if [2]uint64{first, last} == s.searchedLines {
+		return
+	}
+	current := s.current
+	s.find(gui)
+	if current < len(s.matches) {
+		s.current = current
+	} else {
+		s.current = len(s.matches) - 1
+	}
+}
+
+// step moves to the match the given number of matches along, wrapping around at either end
+func (s *searchOverlay) step(gui *GUI, n int) {
+	if len(s.matches) == 0 {
+		return
+	}
+	s.current = ((s.current+n)%len(s.matches) + len(s.matches)) % len(s.matches)
+	s.jump(gui)
+}
+
+// findAgain reopens the last search if it was closed, then moves through its matches, older first for a negative n
+func (gui *GUI) findAgain(n int) {
+	s := gui.lastSearch
+	if s == nil || s.query == "" {
+		return
+	}
+	if gui.overlay != s {
+		gui.setOverlay(s)
+	}
+	s.refresh(gui)
+	s.step(gui, n)
+}
+
+func (s *searchOverlay) jump(gui *GUI) {
+	if s.current < 0 || s.current >= len(s.matches) {
+		return
+	}
+	match := s.matches[s.current]
+	buf := gui.terminal.ActiveBuffer()
+	buf.SelectMatch(match)
+	buf.ScrollToLine(match.Line)
+	gui.terminal.SetDirty()
+}
+
+func (s *searchOverlay) key(gui *GUI, key glfw.Key, mods glfw.ModifierKey) {
+	if key == glfw.KeyR && mods&glfw.ModControl > 0 {
+		s.regex = !s.regex
+		s.search(gui)
+		gui.terminal.SetDirty()
+		return
+	}
+	if len(s.matches) == 0 && key != glfw.KeyBackspace {
+		return
+	}
+	switch key {
+	case glfw.KeyEnter, glfw.KeyKPEnter:
+		if mods&glfw.ModShift > 0 {
+			s.step(gui, 1)
+		} else {
+			s.step(gui, -1)
+		}
+	case glfw.KeyBackspace:
+		if s.query != "" {
+			runes := []rune(s.query)
+			s.query = string(runes[:len(runes)-1])
+			s.search(gui)
+		}
+		gui.terminal.SetDirty()
+	}
+}
+
+func (s *searchOverlay) char(gui *GUI, r rune) {
+	s.query += string(r)
+	s.search(gui)
+	gui.terminal.SetDirty()
+}
+
+// click jumps to the match nearest to where the minimap was clicked
+func (s *searchOverlay) click(gui *GUI, x float64, y float64) {
+
+	r := gui.renderer
+	if x < float64(r.areaX+r.areaWidth-minimapWidth) || len(s.matches) == 0 {
+		return
+	}
+
+	line := int((y - float64(r.areaY)) / float64(r.areaHeight) * float64(s.searchedHeight))
+	nearest := 0
+	for i, match := range s.matches {
+		if abs(match.Line-line) < abs(s.matches[nearest].Line-line) {
+			nearest = i
+		}
+	}
+	s.current = nearest
+	s.jump(gui)
+}
+
+func (s *searchOverlay) render(gui *GUI) {
+
+	buf := gui.terminal.ActiveBuffer()
+	s.refresh(gui)
+
+	r := gui.renderer
+	scheme := gui.colours
+
+	// minimap
+	x := float32(r.areaX + r.areaWidth - minimapWidth)
+	r.DrawRect(x, float32(r.areaY), minimapWidth, float32(r.areaHeight), scheme.Black)
+	if s.searchedHeight > 0 {
+		scale := float32(r.areaHeight) / float32(s.searchedHeight)
+		markHeight := scale
+		if markHeight < 2 {
+			markHeight = 2
+		}
+
+		viewTop := s.searchedHeight - int(buf.ViewHeight()) - int(buf.GetScrollOffset())
+		if viewTop < 0 {
+			viewTop = 0
+		}
+		r.DrawRect(x, float32(r.areaY)+float32(viewTop)*scale, minimapWidth, float32(buf.ViewHeight())*scale, scheme.Selection)
+
+		for i, match := range s.matches {
+			colour := scheme.Yellow
+			if i == s.current {
+				colour = scheme.LightRed
+			}
+			r.DrawRect(x, float32(r.areaY)+float32(match.Line)*scale, minimapWidth, markHeight, colour)
+		}
+	}
+
+	// query and match readout
+	status := "no matches"
+	if s.err != nil {
+		status = "invalid pattern"
+	} else if len(s.matches) > 0 {
+		status = fmt.Sprintf("match %d of %d", s.current+1, len(s.matches))
+	} else if s.query == "" {
+		status = "type to search"
+	}
+	label := "Find"
+	if s.regex {
+		label = "Find regex"
+	}
+	text := fmt.Sprintf(" %s: %s_  %s ", label, s.query, status)
+
+	width := float32(len([]rune(text))) * r.cellWidth
+	boxX := x - width
+	if boxX < float32(r.areaX) {
+		boxX = float32(r.areaX)
+	}
+	r.DrawRect(boxX, float32(r.areaY), width, r.cellHeight, scheme.Black)
+
+	f := gui.fontMap.GetFont('X')
+	f.SetColor(scheme.Foreground[0], scheme.Foreground[1], scheme.Foreground[2], 1)
+	f.Print(boxX, float32(r.areaY)+r.cellHeight+f.MinY(), text)
+}
+
+// highlights returns the matches which are in view, by the row of the view they are on
+func (s *searchOverlay) highlights(gui *GUI) map[int][]int {
+	buf := gui.terminal.ActiveBuffer()
+	viewTop := buf.Height() - int(buf.ViewHeight()) - int(buf.GetScrollOffset())
+	if viewTop < 0 {
+		viewTop = 0
+	}
+	rows := map[int][]int{}
+	for i, match := range s.matches {
+		if row := match.Line - viewTop; row >= 0 && row < int(buf.ViewHeight()) {
+			rows[row] = append(rows[row], i)
+		}
+	}
+	return rows
+}
+
+// matchColour returns the colour to highlight the given cell with if it's part of a match, the current match
+// standing out from the rest
+func (s *searchOverlay) matchColour(gui *GUI, highlights map[int][]int, x int, y int) *config.Colour {
+	for _, i := range highlights[y] {
+		match := s.matches[i]
+		if x < match.Col || x >= match.Col+match.Length {
+			continue
+		}
+		if i == s.current {
+			return &gui.colours.LightRed
+		}
+		return &gui.colours.Yellow
+	}
+	return nil
+}
+
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
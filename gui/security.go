@@ -0,0 +1,54 @@
+package gui
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/liamg/aminal/config"
+	"github.com/liamg/aminal/terminal"
+)
+
+// handleRequest runs a request from the terminal which needs the window, asking the user first if the policy says to
+func (gui *GUI) handleRequest(request terminal.Request) {
+	if !request.Ask {
+		request.Run(gui.window)
+		return
+	}
+	gui.setOverlay(newConfirmOverlay(request.Question+" (y/n)", func(gui *GUI) {
+		request.Run(gui.window)
+	}))
+}
+
+// linkSchemes are the schemes of the links which can be opened when clicked on. Anything else, e.g. a javascript: or
+// smb: link from an OSC 8 hyperlink, could run something or leak credentials when handed to xdg-open or open.
+var linkSchemes = map[string]bool{
+	"http":   true,
+	"https":  true,
+	"mailto": true,
+	"file":   true,
+}
+
+// openURL opens a link which has been clicked on, if it has one of linkSchemes, applying the security policy to links
+// to local files
+func (gui *GUI) openURL(target string) {
+	link, err := url.Parse(target)
+	if err != nil || !linkSchemes[strings.ToLower(link.Scheme)] {
+		gui.logger.Infof("Not opening %s: only http, https, mailto and file links are opened", target)
+		return
+	}
+	if strings.ToLower(link.Scheme) != "file" {
+		go gui.launchTarget(target)
+		return
+	}
+	switch gui.config.Security.FileURLs {
+	case config.PolicyAllow:
+		go gui.launchTarget(target)
+	case config.PolicyAsk:
+		gui.setOverlay(newConfirmOverlay(fmt.Sprintf("Open %s? (y/n)", target), func(gui *GUI) {
+			go gui.launchTarget(target)
+		}))
+	default:
+		gui.logger.Infof("Denied by security policy: opening %s", target)
+	}
+}
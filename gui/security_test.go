@@ -0,0 +1,22 @@
+package gui
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOnlyLinksWithKnownSchemesAreOpened(t *testing.T) {
+	gui, _ := newTestGUI(t, `
+[security]
+  file_urls = "ask"
+`)
+
+	for _, link := range []string{"javascript:alert(1)", "smb://host/share", "ms-settings:", "%zz"} {
+		gui.openURL(link)
+		assert.Nil(t, gui.overlay, link)
+	}
+
+	gui.openURL("FILE:///etc/passwd")
+	assert.NotNil(t, gui.overlay)
+}
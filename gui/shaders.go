@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/go-gl/gl/all-core/gl" // OR: github.com/go-gl/gl/v2.1/gl
+	"github.com/liamg/aminal/glfont"
 )
 
 const (
@@ -21,14 +22,63 @@ const (
 
 	fragmentShaderSource = `
 		#version 150
+	` + glfont.ColourOutputShader + `
 		smooth in vec3 theColour;
 		out vec4 outColour;
 		void main() {
-			outColour = vec4(theColour, 1.0); 
+			outColour = vec4(outputColour(theColour), 1.0);
+		}
+	` + "\x00"
+
+	// images are drawn as a textured quad, blended over the cells under them
+	imageVertexShaderSource = `
+		#version 150
+		in vec2 position;
+		in vec2 texturePosition;
+		out vec2 fragTexturePosition;
+		void main() {
+			gl_Position = vec4(position, 0.0, 1.0);
+			fragTexturePosition = texturePosition;
+		}
+	` + "\x00"
+
+	imageFragmentShaderSource = `
+		#version 150
+	` + glfont.ColourOutputShader + `
+		uniform sampler2D image;
+		in vec2 fragTexturePosition;
+		out vec4 outColour;
+		void main() {
+			vec4 c = texture(image, fragTexturePosition);
+			if (c.a == 0.0) {
+				discard;
+			}
+			// the pixels have their alpha multiplied in, which is taken out to convert the colour
+			outColour = vec4(outputColour(c.rgb / c.a), c.a);
 		}
 	` + "\x00"
 )
 
+// linkProgram compiles and links a vertex and fragment shader into a program
+func linkProgram(vertexSource string, fragmentSource string) (uint32, error) {
+	vertexShader, err := compileShader(vertexSource, gl.VERTEX_SHADER)
+	if err != nil {
+		return 0, err
+	}
+
+	fragmentShader, err := compileShader(fragmentSource, gl.FRAGMENT_SHADER)
+	if err != nil {
+		return 0, err
+	}
+
+	prog := gl.CreateProgram()
+	gl.AttachShader(prog, vertexShader)
+	gl.AttachShader(prog, fragmentShader)
+	gl.LinkProgram(prog)
+
+	return prog, nil
+}
+
 func compileShader(source string, shaderType uint32) (uint32, error) {
 	shader := gl.CreateShader(shaderType)
 
@@ -0,0 +1,247 @@
+package gui
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/liamg/aminal/config"
+	"github.com/liamg/aminal/control"
+)
+
+const bellIndicatorDuration = time.Second * 2
+
+type gitBranchCache struct {
+	dir     string
+	branch  string
+	checked time.Time
+}
+
+// statusBarHeight returns the height in pixels reserved for the status bar
+func (gui *GUI) statusBarHeight() int {
+	if !gui.config.StatusBar.Enabled {
+		return 0
+	}
+	_, h := gui.fontMap.GetFont('X').MaxSize()
+	return int(math.Ceil(float64(h)))
+}
+
+// terminalArea returns the area of the window the terminal grid is rendered in
+func (gui *GUI) terminalArea() (int, int, int, int) {
+	barHeight := gui.statusBarHeight()
+	if gui.config.StatusBar.Position == "top" {
+		return 0, barHeight, gui.width, gui.height - barHeight
+	}
+	return 0, 0, gui.width, gui.height - barHeight
+}
+
+// updateIndicators records bells and output which the user may not have noticed, in any of the panes
+func (gui *GUI) updateIndicators() {
+	for _, p := range gui.panes {
+		if p.terminal.CheckBell() {
+			gui.lastBell = time.Now()
+			if !gui.focused {
+				gui.unseenBell = true
+			}
+			time.AfterFunc(bellIndicatorDuration, gui.terminal.SetDirty)
+			gui.publish(p, control.Event{Type: control.EventBell})
+		}
+		if p.terminal.CheckActivity() {
+			if !gui.focused {
+				gui.unseenActivity = true
+			}
+			gui.publishActivity(p)
+		}
+	}
+}
+
+// renderPausedBadge shows that output is paused in the top right corner, as the screen looks no different otherwise
+func (gui *GUI) renderPausedBadge() {
+	if !gui.terminal.OutputPaused() {
+		return
+	}
+	text := "paused"
+	width := uint16(len(text) + 2)
+	if gui.terminal.ActiveBuffer().ViewWidth() < width+1 {
+		return
+	}
+	gui.textbox(gui.terminal.ActiveBuffer().ViewWidth()-width-1, 1, text, gui.colours.Background, gui.colours.Yellow)
+}
+
+// renderSecretInputBadge shows that what is typed isn't echoed, e.g. at a password prompt, at the end of the cursor's
+// line
+func (gui *GUI) renderSecretInputBadge() {
+	if !gui.terminal.SecretInput() {
+		return
+	}
+	text := "password"
+	buf := gui.terminal.ActiveBuffer()
+	width := uint16(len(text) + 2)
+	row := uint(gui.terminal.GetLogicalCursorY()) + gui.terminal.GetScrollOffset()
+	if buf.ViewWidth() < width+1 || row >= uint(buf.ViewHeight()) {
+		return
+	}
+	gui.textbox(buf.ViewWidth()-width-1, uint16(row), text, gui.colours.Background, gui.colours.DarkGrey)
+}
+
+// renderNewLinesBadge shows how much output has arrived below the view while it was held, in the bottom right corner
+func (gui *GUI) renderNewLinesBadge() {
+	n := gui.terminal.NewLinesBelow()
+	if n == 0 {
+		return
+	}
+	text := fmt.Sprintf("%d new lines below", n)
+	if n == 1 {
+		text = "1 new line below"
+	}
+	buf := gui.terminal.ActiveBuffer()
+	width := uint16(len(text) + 2)
+	if buf.ViewWidth() < width+1 || buf.ViewHeight() < 3 {
+		return
+	}
+	gui.textbox(buf.ViewWidth()-width-1, buf.ViewHeight()-2, text, gui.colours.Background, gui.colours.Cyan)
+}
+
+func (gui *GUI) renderStatusBar() {
+
+	if !gui.config.StatusBar.Enabled {
+		return
+	}
+
+	height := float32(gui.statusBarHeight())
+	y := float32(0)
+	if gui.config.StatusBar.Position == "bottom" {
+		y = float32(gui.height) - height
+	}
+
+	gui.renderer.DrawRect(0, y, float32(gui.width), height, gui.colours.Black)
+
+	parts := []string{}
+	for _, segment := range gui.config.StatusBar.Segments {
+		if text := gui.statusSegment(segment); text != "" {
+			parts = append(parts, text)
+		}
+	}
+
+	text := []rune(" " + strings.Join(parts, " | "))
+	if max := int(float32(gui.width) / gui.renderer.CellWidth()); len(text) > max && max > 0 {
+		text = text[:max]
+	}
+
+	f := gui.fontMap.GetFont('X')
+	fg := gui.colours.Foreground
+	f.SetColor(fg[0], fg[1], fg[2], 1)
+	f.Print(0, y+height+f.MinY(), string(text))
+}
+
+func (gui *GUI) statusSegment(segment string) string {
+	switch segment {
+	case config.StatusSegmentTitle:
+		return gui.terminal.GetTitle()
+	case config.StatusSegmentCwd:
+		dir := gui.terminal.GetWorkingDirectory()
+		if !isLocalHost(gui.terminal.GetHost()) {
+			return fmt.Sprintf("%s:%s", gui.terminal.GetHost(), dir)
+		}
+		if home := os.Getenv("HOME"); home != "" && strings.HasPrefix(dir, home) {
+			dir = "~" + strings.TrimPrefix(dir, home)
+		}
+		return dir
+	case config.StatusSegmentGit:
+		if !isLocalHost(gui.terminal.GetHost()) {
+			return ""
+		}
+		if branch := gui.gitBranch(gui.terminal.GetWorkingDirectory()); branch != "" {
+			return "git:" + branch
+		}
+	case config.StatusSegmentClock:
+		return time.Now().Format(gui.config.StatusBar.ClockFormat)
+	case config.StatusSegmentScroll:
+		if offset := gui.terminal.GetScrollOffset(); offset > 0 {
+			if n := gui.terminal.NewLinesBelow(); n > 0 {
+				return fmt.Sprintf("scrolled back %d lines, %d new", offset, n)
+			}
+			return fmt.Sprintf("scrolled back %d lines", offset)
+		}
+	case config.StatusSegmentReadOnly:
+		if gui.config.ReadOnly {
+			return "[read only]"
+		}
+	case config.StatusSegmentPaused:
+		if gui.terminal.OutputPaused() {
+			return "[paused]"
+		}
+	case config.StatusSegmentBell:
+		if gui.unseenBell || time.Since(gui.lastBell) < bellIndicatorDuration {
+			return "[bell]"
+		}
+	case config.StatusSegmentActivity:
+		if gui.unseenActivity {
+			return "[activity]"
+		}
+	}
+	return ""
+}
+
+// gitBranch returns the checked out branch of the repository containing dir, if any
+func (gui *GUI) gitBranch(dir string) string {
+	if dir == "" {
+		return ""
+	}
+	if gui.gitBranchCache.dir == dir && time.Since(gui.gitBranchCache.checked) < time.Second {
+		return gui.gitBranchCache.branch
+	}
+	gui.gitBranchCache = gitBranchCache{
+		dir:     dir,
+		branch:  readGitBranch(dir),
+		checked: time.Now(),
+	}
+	return gui.gitBranchCache.branch
+}
+
+func readGitBranch(dir string) string {
+	for {
+		gitDir := filepath.Join(dir, ".git")
+		if info, err := os.Stat(gitDir); err == nil {
+			if !info.IsDir() { // worktrees and submodules point to the real git dir
+				data, err := ioutil.ReadFile(gitDir)
+				if err != nil {
+					return ""
+				}
+				gitDir = strings.TrimSpace(strings.TrimPrefix(string(data), "gitdir:"))
+				if !filepath.IsAbs(gitDir) {
+					gitDir = filepath.Join(dir, gitDir)
+				}
+			}
+			head, err := ioutil.ReadFile(filepath.Join(gitDir, "HEAD"))
+			if err != nil {
+				return ""
+			}
+			ref := strings.TrimSpace(string(head))
+			if strings.HasPrefix(ref, "ref: refs/heads/") {
+				return strings.TrimPrefix(ref, "ref: refs/heads/")
+			}
+			if len(ref) > 7 { // detached head
+				return ref[:7]
+			}
+			return ref
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+func isLocalHost(host string) bool {
+	if host == "" || host == "localhost" {
+		return true
+	}
+	hostname, err := os.Hostname()
+	return err == nil && hostname == host
+}
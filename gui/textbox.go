@@ -87,13 +87,13 @@ DONE:
 		}
 	}
 
-	x := float32(col) * gui.renderer.cellWidth
+	x := float32(gui.renderer.areaX) + float32(col)*gui.renderer.cellWidth
 
 	f := gui.fontMap.GetFont('X')
 	f.SetColor(fg[0], fg[1], fg[2], 1)
 
 	for i, line := range lines {
-		y := float32(row+1+uint16(i))*gui.renderer.cellHeight + f.MinY()
+		y := float32(gui.renderer.areaY) + float32(row+1+uint16(i))*gui.renderer.cellHeight + f.MinY()
 		f.Print(x, y, fmt.Sprintf(" %s", line))
 	}
 
@@ -0,0 +1,28 @@
+package gui
+
+// applyColours draws the terminal and everything around it in the colours of the current theme, with the colours of the
+// host profile, if there is one, on top
+func (gui *GUI) applyColours() {
+	scheme, _ := gui.config.ThemeColours(gui.theme) // themes are validated when the config is parsed
+	if gui.hostProfile != nil {
+		scheme, _ = scheme.WithOverrides(gui.hostProfile.Colours)
+	}
+	gui.colours = scheme
+	gui.renderer.SetColourScheme(scheme)
+	gui.renderer.SetClearColour(scheme.Background)
+	for _, p := range gui.panes {
+		p.terminal.SetDisplayColours(scheme)
+	}
+}
+
+// setTheme switches to a theme, redrawing everything in its colours
+func (gui *GUI) setTheme(name string) {
+	gui.theme = name
+	if name == "" {
+		gui.logger.Infof("Switching to the default colours")
+	} else {
+		gui.logger.Infof("Switching to theme %s", name)
+	}
+	gui.applyColours()
+	gui.terminal.SetDirty()
+}
@@ -0,0 +1,47 @@
+package gui
+
+import (
+	"testing"
+
+	"github.com/liamg/aminal/buffer"
+	"github.com/liamg/aminal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestThemeColoursAreUsedByRole(t *testing.T) {
+	// the default cursor and foreground are the same colour, which the theme gives different ones
+	gui, _ := newTestGUI(t, `
+[themes.light]
+  cursor = "#ff0000"
+  foreground = "#000000"
+  red = "#800000"
+`)
+	require.Equal(t, gui.config.ColourScheme.Cursor, gui.config.ColourScheme.Foreground)
+	theme, err := gui.config.ThemeColours("light")
+	require.Nil(t, err)
+	r := &OpenGLRenderer{}
+	r.SetColourScheme(theme)
+
+	buf := gui.terminal.ActiveBuffer()
+	buf.ResizeView(10, 2)
+	buf.Write('a')
+	buf.CursorAttr().FgColour = gui.config.ColourScheme.Red
+	buf.CursorAttr().FgIndex = 0 // given as RGB, e.g. by SGR 38;2
+	buf.Write('b')
+
+	assert.Equal(t, config.Colour{0, 0, 0}, r.TextColour(*buf.GetCell(0, 0)))
+	assert.Equal(t, config.Colour{1, 0, 0}, r.CursorColour())
+	assert.Equal(t, gui.config.ColourScheme.Red, r.TextColour(*buf.GetCell(1, 0)))
+}
+
+func TestProgramColoursApplyToPaletteCellsOnly(t *testing.T) {
+	scheme := config.DefaultConfig.ColourScheme
+	purple := config.Colour{0.5, 0, 0.5}
+	r := &OpenGLRenderer{scheme: scheme, termColours: map[int]config.Colour{1: purple}}
+
+	assert.Equal(t, purple, r.cellColour(scheme.Red, buffer.PaletteColour(1)))
+	// true colour which happens to be the palette's red is left alone
+	assert.Equal(t, scheme.Red, r.cellColour(scheme.Red, buffer.ColourRGB))
+	assert.Equal(t, scheme.Green, r.cellColour(scheme.Red, buffer.PaletteColour(2)))
+}
@@ -0,0 +1,140 @@
+package gui
+
+import (
+	"fmt"
+	"runtime"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-gl/gl/all-core/gl"
+	"github.com/liamg/aminal/glfont"
+)
+
+// the render loop going this long without running, e.g. while the machine was suspended, is taken to mean what is on
+// screen may not have survived, and everything is redrawn and laid out again
+const stallRedraw = 5 * time.Second
+
+// how many times recreating the window is tried after the OpenGL context is lost, a second apart as the driver may
+// still be resetting
+const contextRecoveryAttempts = 5
+
+// beat records that the render loop is running, for the watchdog
+func (gui *GUI) beat() {
+	now := time.Now().UnixNano()
+	last := atomic.SwapInt64(&gui.heartbeat, now)
+	if last != 0 && time.Duration(now-last) > stallRedraw {
+		gui.logger.Warnf("Render loop stalled for %s, redrawing everything", time.Duration(now-last).Round(time.Second))
+		for _, p := range gui.panes {
+			p.terminal.SetDirty()
+		}
+		// the displays may have changed while it was stalled, e.g. a laptop suspended while docked and resumed without
+		gui.displayChanged = true
+	}
+}
+
+// watchRenderLoop logs what every goroutine is doing if the render loop stops running for the watchdog timeout, e.g.
+// stuck in the graphics driver, as the window can't show anything itself then. It returns once done is closed.
+func (gui *GUI) watchRenderLoop(done <-chan struct{}) {
+	timeout := time.Duration(gui.config.Graphics.WatchdogTimeout) * time.Second
+	if timeout == 0 {
+		return
+	}
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	stalled := false
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+		since := time.Since(time.Unix(0, atomic.LoadInt64(&gui.heartbeat)))
+		if since < timeout {
+			if stalled {
+				gui.logger.Infof("Render loop is running again")
+				stalled = false
+			}
+			continue
+		}
+		if !stalled {
+			stalled = true
+			stack := make([]byte, 1<<20)
+			stack = stack[:runtime.Stack(stack, true)]
+			gui.logger.Errorf("Render loop has not run for %s, goroutines:\n%s", since.Round(time.Second), stack)
+		}
+	}
+}
+
+// graphicsResetStatus returns the function which reports whether the OpenGL context has been lost, or nil if the
+// driver can't say
+func graphicsResetStatus() func() uint32 {
+	var major, minor int32
+	gl.GetIntegerv(gl.MAJOR_VERSION, &major)
+	gl.GetIntegerv(gl.MINOR_VERSION, &minor)
+	if major > 4 || major == 4 && minor >= 5 {
+		return gl.GetGraphicsResetStatus
+	}
+	var extensions int32
+	gl.GetIntegerv(gl.NUM_EXTENSIONS, &extensions)
+	for i := uint32(0); i < uint32(extensions); i++ {
+		switch gl.GoStr(gl.GetStringi(gl.EXTENSIONS, i)) {
+		case "GL_KHR_robustness":
+			return gl.GetGraphicsResetStatusKHR
+		case "GL_ARB_robustness":
+			return gl.GetGraphicsResetStatusARB
+		}
+	}
+	return nil
+}
+
+// contextLost returns true if the graphics driver has reset, e.g. after the GPU hung, taking everything created with
+// the OpenGL context with it
+func (gui *GUI) contextLost() bool {
+	return gui.resetStatus != nil && gui.resetStatus() != gl.NO_ERROR
+}
+
+// recoverContext replaces the window, whose OpenGL context has been lost, with a new one. Everything is drawn again from
+// the terminals, which don't depend on the context.
+func (gui *GUI) recoverContext() error {
+	gui.logger.Errorf("OpenGL context lost, recreating the window")
+
+	old := gui.window
+	x, y := old.GetPos()
+	width, height := old.GetSize()
+
+	// nothing created with the old context can be used with the new one
+	gui.textures = glfont.NewTextureBudget(gui.config.Graphics.TextureBudgetBytes())
+	gui.fontMap = nil
+	gui.post = nil
+	gui.postShader = ""
+	gui.caret = caretRect{}
+
+	var err error
+	for attempt := 1; attempt <= contextRecoveryAttempts; attempt++ {
+		if attempt > 1 {
+			time.Sleep(time.Second)
+		}
+		if err = gui.setupWindow(); err == nil {
+			break
+		}
+		if gui.window != nil && gui.window != old {
+			gui.window.Destroy()
+		}
+		gui.window = old
+		gui.logger.Errorf("Failed to recreate the window, attempt %d of %d: %s", attempt, contextRecoveryAttempts, err)
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to recover from losing the OpenGL context: %s", err)
+	}
+
+	old.Destroy()
+	gui.window.SetPos(x, y)
+	gui.window.SetSize(width, height)
+	gui.displayChanged = true
+	for _, p := range gui.panes {
+		p.terminal.SetDirty()
+	}
+	gui.logger.Infof("Recovered from losing the OpenGL context")
+	return nil
+}
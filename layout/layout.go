@@ -0,0 +1,388 @@
+package layout
+
+import (
+	"fmt"
+)
+
+// Direction is the way a pane is split in two
+type Direction int
+
+const (
+	Horizontal Direction = iota // side by side
+	Vertical                    // one above the other
+)
+
+// panes can't be resized smaller than this share of the space they are split from
+const minRatio = 0.1
+
+// dividers can be grabbed this many pixels either side of them, as they are only a pixel or two wide
+const dividerSlop = 3
+
+// Rect is an area of the window, in pixels from the top left
+type Rect struct {
+	X      int
+	Y      int
+	Width  int
+	Height int
+}
+
+// Contains returns true if the point is inside the rectangle
+func (rect Rect) Contains(x int, y int) bool {
+	return x >= rect.X && x < rect.X+rect.Width && y >= rect.Y && y < rect.Y+rect.Height
+}
+
+// node is either a pane, or a split between two other nodes
+type node struct {
+	pane      int
+	parent    *node
+	direction Direction
+	ratio     float64 // the share of the space taken by first
+	first     *node
+	second    *node
+}
+
+func (n *node) isPane() bool {
+	return n.first == nil
+}
+
+// Tree lays out panes, identified by number, by splitting the window between them
+type Tree struct {
+	root    *node
+	divider int   // the gap between panes, in pixels
+	zoomed  *node // the pane taking up the whole area, hiding the others, if there is one
+}
+
+// New returns a tree with a single pane, which has the whole area to itself
+func New(pane int, divider int) *Tree {
+	return &Tree{
+		root:    &node{pane: pane},
+		divider: divider,
+	}
+}
+
+func (tree *Tree) find(pane int) *node {
+	var found *node
+	tree.walk(func(n *node) {
+		if n.isPane() && n.pane == pane {
+			found = n
+		}
+	})
+	return found
+}
+
+func (tree *Tree) walk(f func(n *node)) {
+	var visit func(n *node)
+	visit = func(n *node) {
+		f(n)
+		if !n.isPane() {
+			visit(n.first)
+			visit(n.second)
+		}
+	}
+	visit(tree.root)
+}
+
+// Panes returns the panes in order, left to right and top to bottom
+func (tree *Tree) Panes() []int {
+	panes := []int{}
+	tree.walk(func(n *node) {
+		if n.isPane() {
+			panes = append(panes, n.pane)
+		}
+	})
+	return panes
+}
+
+// Split halves the space taken by a pane, giving the right or bottom half to a new one
+func (tree *Tree) Split(pane int, newPane int, direction Direction) error {
+	n := tree.find(pane)
+	if n == nil {
+		return fmt.Errorf("No pane %d to split", pane)
+	}
+	if tree.find(newPane) != nil {
+		return fmt.Errorf("Pane %d already exists", newPane)
+	}
+	tree.zoomed = nil
+	n.first = &node{pane: n.pane, parent: n}
+	n.second = &node{pane: newPane, parent: n}
+	n.direction = direction
+	n.ratio = 0.5
+	return nil
+}
+
+// Remove takes a pane out of the tree, giving its space to the pane or panes it was split from
+func (tree *Tree) Remove(pane int) error {
+	n := tree.find(pane)
+	if n == nil {
+		return fmt.Errorf("No pane %d to remove", pane)
+	}
+	if n.parent == nil {
+		return fmt.Errorf("Cannot remove the last pane")
+	}
+	if tree.zoomed != nil {
+		// the nodes are rearranged, so the zoomed pane is found again afterwards
+		zoomed := tree.zoomed.pane
+		defer func() {
+			tree.zoomed = tree.find(zoomed)
+		}()
+	}
+	sibling := n.parent.first
+	if sibling == n {
+		sibling = n.parent.second
+	}
+	// the sibling takes the parent's place
+	parent := n.parent
+	*parent = node{
+		pane:      sibling.pane,
+		parent:    parent.parent,
+		direction: sibling.direction,
+		ratio:     sibling.ratio,
+		first:     sibling.first,
+		second:    sibling.second,
+	}
+	if !parent.isPane() {
+		parent.first.parent = parent
+		parent.second.parent = parent
+	}
+	return nil
+}
+
+// split divides an area between the two sides of a split, leaving a gap for the divider
+func (tree *Tree) split(n *node, area Rect) (Rect, Rect) {
+	first, second := area, area
+	if n.direction == Horizontal {
+		first.Width = int(float64(area.Width-tree.divider) * n.ratio)
+		second.X = area.X + first.Width + tree.divider
+		second.Width = area.Width - first.Width - tree.divider
+	} else {
+		first.Height = int(float64(area.Height-tree.divider) * n.ratio)
+		second.Y = area.Y + first.Height + tree.divider
+		second.Height = area.Height - first.Height - tree.divider
+	}
+	return first, second
+}
+
+func (tree *Tree) layout(area Rect, f func(n *node, area Rect)) {
+	var visit func(n *node, area Rect)
+	visit = func(n *node, area Rect) {
+		f(n, area)
+		if !n.isPane() {
+			first, second := tree.split(n, area)
+			visit(n.first, first)
+			visit(n.second, second)
+		}
+	}
+	visit(tree.root, area)
+}
+
+// Zoom has a pane take up the whole area, hiding the others, until Unzoom is called or the layout changes. The hidden
+// panes keep their places.
+func (tree *Tree) Zoom(pane int) error {
+	n := tree.find(pane)
+	if n == nil {
+		return fmt.Errorf("No pane %d to zoom", pane)
+	}
+	tree.zoomed = n
+	return nil
+}
+
+// Unzoom shows all of the panes again
+func (tree *Tree) Unzoom() {
+	tree.zoomed = nil
+}
+
+// Zoomed returns the pane taking up the whole area, if one is
+func (tree *Tree) Zoomed() (int, bool) {
+	if tree.zoomed == nil {
+		return 0, false
+	}
+	return tree.zoomed.pane, true
+}
+
+// Rects returns the area of the window each pane takes up. Panes hidden by a zoomed one aren't included.
+func (tree *Tree) Rects(area Rect) map[int]Rect {
+	if tree.zoomed != nil {
+		return map[int]Rect{tree.zoomed.pane: area}
+	}
+	rects := map[int]Rect{}
+	tree.layout(area, func(n *node, area Rect) {
+		if n.isPane() {
+			rects[n.pane] = area
+		}
+	})
+	return rects
+}
+
+// Divider is the gap between the two sides of a split, which can be dragged to resize them
+type Divider struct {
+	Rect      Rect
+	Direction Direction
+	split     *node
+	area      Rect // the area the split divides
+	gap       int
+}
+
+// Dividers returns the gaps between panes
+func (tree *Tree) Dividers(area Rect) []Divider {
+	dividers := []Divider{}
+	if tree.zoomed != nil {
+		return dividers
+	}
+	tree.layout(area, func(n *node, area Rect) {
+		if n.isPane() {
+			return
+		}
+		first, _ := tree.split(n, area)
+		rect := area
+		if n.direction == Horizontal {
+			rect.X = first.X + first.Width
+			rect.Width = tree.divider
+		} else {
+			rect.Y = first.Y + first.Height
+			rect.Height = tree.divider
+		}
+		dividers = append(dividers, Divider{Rect: rect, Direction: n.direction, split: n, area: area, gap: tree.divider})
+	})
+	return dividers
+}
+
+// DividerAt returns the divider at a point, or nil if there isn't one there
+func (tree *Tree) DividerAt(area Rect, x int, y int) *Divider {
+	for _, divider := range tree.Dividers(area) {
+		grab := divider.Rect
+		grab.X -= dividerSlop
+		grab.Y -= dividerSlop
+		grab.Width += dividerSlop * 2
+		grab.Height += dividerSlop * 2
+		if grab.Contains(x, y) {
+			return &divider
+		}
+	}
+	return nil
+}
+
+// Is returns true if the divider is between the same panes as another, which may have been found at another time
+func (divider Divider) Is(other *Divider) bool {
+	return other != nil && divider.split == other.split
+}
+
+// MoveTo moves the divider to a point, e.g. where it has been dragged to
+func (divider *Divider) MoveTo(x int, y int) {
+	n := divider.split
+	if n.direction == Horizontal {
+		n.ratio = float64(x-divider.area.X) / float64(divider.area.Width-divider.gap)
+	} else {
+		n.ratio = float64(y-divider.area.Y) / float64(divider.area.Height-divider.gap)
+	}
+	n.clampRatio()
+}
+
+func (n *node) clampRatio() {
+	if n.ratio < minRatio {
+		n.ratio = minRatio
+	} else if n.ratio > 1-minRatio {
+		n.ratio = 1 - minRatio
+	}
+}
+
+// Resize grows a pane by a number of pixels in the given direction, or shrinks it if pixels is negative, by moving the
+// divider of the closest split in that direction which the pane is part of
+func (tree *Tree) Resize(area Rect, pane int, direction Direction, pixels int) {
+	n := tree.find(pane)
+	if n == nil {
+		return
+	}
+	tree.zoomed = nil
+	var split *node
+	for child := n; child.parent != nil; child = child.parent {
+		if child.parent.direction == direction {
+			split = child.parent
+			n = child
+			break
+		}
+	}
+	if split == nil {
+		return
+	}
+
+	size := 0
+	tree.layout(area, func(n *node, area Rect) {
+		if n == split {
+			size = area.Width - tree.divider
+			if direction == Vertical {
+				size = area.Height - tree.divider
+			}
+		}
+	})
+	if size <= 0 {
+		return
+	}
+
+	change := float64(pixels) / float64(size)
+	if n == split.second {
+		change = -change
+	}
+	split.ratio += change
+	split.clampRatio()
+}
+
+// Node describes a layout, e.g. to save it and build it again later: either a pane, or a split between two other nodes
+type Node struct {
+	Pane      int
+	Direction Direction
+	Ratio     float64 // the share of the space taken by First
+	First     *Node   // the left or top of a split, or nil for a pane
+	Second    *Node
+}
+
+// IsPane returns true if the node is a pane rather than a split
+func (n *Node) IsPane() bool {
+	return n.First == nil
+}
+
+// Describe returns the layout of the panes, as if none of them were zoomed
+func (tree *Tree) Describe() *Node {
+	var describe func(n *node) *Node
+	describe = func(n *node) *Node {
+		if n.isPane() {
+			return &Node{Pane: n.pane}
+		}
+		return &Node{Direction: n.direction, Ratio: n.ratio, First: describe(n.first), Second: describe(n.second)}
+	}
+	return describe(tree.root)
+}
+
+// Build returns a tree laid out as described
+func Build(description *Node, divider int) (*Tree, error) {
+	tree := &Tree{divider: divider}
+	seen := map[int]bool{}
+	var build func(d *Node, parent *node) (*node, error)
+	build = func(d *Node, parent *node) (*node, error) {
+		if d.IsPane() {
+			if seen[d.Pane] {
+				return nil, fmt.Errorf("Pane %d is in the layout twice", d.Pane)
+			}
+			seen[d.Pane] = true
+			return &node{pane: d.Pane, parent: parent}, nil
+		}
+		if d.Second == nil {
+			return nil, fmt.Errorf("A split needs two sides")
+		}
+		n := &node{parent: parent, direction: d.Direction, ratio: d.Ratio}
+		n.clampRatio()
+		var err error
+		if n.first, err = build(d.First, n); err != nil {
+			return nil, err
+		}
+		if n.second, err = build(d.Second, n); err != nil {
+			return nil, err
+		}
+		return n, nil
+	}
+	root, err := build(description, nil)
+	if err != nil {
+		return nil, err
+	}
+	tree.root = root
+	return tree, nil
+}
@@ -0,0 +1,163 @@
+package layout
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var window = Rect{X: 0, Y: 10, Width: 101, Height: 51}
+
+func TestSinglePaneTakesWholeArea(t *testing.T) {
+	tree := New(1, 1)
+
+	assert.Equal(t, []int{1}, tree.Panes())
+	assert.Equal(t, map[int]Rect{1: window}, tree.Rects(window))
+	assert.Len(t, tree.Dividers(window), 0)
+}
+
+func TestSplit(t *testing.T) {
+	tree := New(1, 1)
+	require.Nil(t, tree.Split(1, 2, Horizontal))
+	require.Nil(t, tree.Split(2, 3, Vertical))
+
+	assert.Equal(t, []int{1, 2, 3}, tree.Panes())
+	assert.Equal(t, map[int]Rect{
+		1: {X: 0, Y: 10, Width: 50, Height: 51},
+		2: {X: 51, Y: 10, Width: 50, Height: 25},
+		3: {X: 51, Y: 36, Width: 50, Height: 25},
+	}, tree.Rects(window))
+
+	dividers := tree.Dividers(window)
+	require.Len(t, dividers, 2)
+	assert.Equal(t, Rect{X: 50, Y: 10, Width: 1, Height: 51}, dividers[0].Rect)
+	assert.Equal(t, Rect{X: 51, Y: 35, Width: 50, Height: 1}, dividers[1].Rect)
+
+	assert.NotNil(t, tree.Split(4, 5, Vertical))
+	assert.NotNil(t, tree.Split(1, 3, Vertical))
+}
+
+func TestRemove(t *testing.T) {
+	tree := New(1, 1)
+	require.Nil(t, tree.Split(1, 2, Horizontal))
+	require.Nil(t, tree.Split(2, 3, Vertical))
+
+	require.Nil(t, tree.Remove(1))
+	assert.Equal(t, []int{2, 3}, tree.Panes())
+	assert.Equal(t, map[int]Rect{
+		2: {X: 0, Y: 10, Width: 101, Height: 25},
+		3: {X: 0, Y: 36, Width: 101, Height: 25},
+	}, tree.Rects(window))
+
+	require.Nil(t, tree.Remove(3))
+	assert.Equal(t, map[int]Rect{2: window}, tree.Rects(window))
+
+	assert.NotNil(t, tree.Remove(2))
+	assert.NotNil(t, tree.Remove(7))
+}
+
+func TestDragDivider(t *testing.T) {
+	tree := New(1, 1)
+	require.Nil(t, tree.Split(1, 2, Horizontal))
+
+	assert.Nil(t, tree.DividerAt(window, 20, 20))
+	divider := tree.DividerAt(window, 52, 20)
+	require.NotNil(t, divider)
+	assert.Equal(t, Horizontal, divider.Direction)
+
+	divider.MoveTo(25, 20)
+	assert.Equal(t, 25, tree.Rects(window)[1].Width)
+
+	// neither side can be dragged away to nothing
+	divider.MoveTo(100, 20)
+	assert.Equal(t, 90, tree.Rects(window)[1].Width)
+}
+
+func TestResize(t *testing.T) {
+	tree := New(1, 1)
+	require.Nil(t, tree.Split(1, 2, Horizontal))
+	require.Nil(t, tree.Split(2, 3, Vertical))
+
+	tree.Resize(window, 3, Horizontal, 10)
+	assert.Equal(t, 40, tree.Rects(window)[1].Width)
+	assert.Equal(t, 60, tree.Rects(window)[3].Width)
+
+	tree.Resize(window, 1, Horizontal, 5)
+	assert.Equal(t, 45, tree.Rects(window)[1].Width)
+
+	// pane 1 isn't part of a vertical split
+	tree.Resize(window, 1, Vertical, 5)
+	assert.Equal(t, 51, tree.Rects(window)[1].Height)
+
+	tree.Resize(window, 2, Vertical, -5)
+	assert.Equal(t, 20, tree.Rects(window)[2].Height)
+}
+
+func TestZoom(t *testing.T) {
+	tree := New(1, 1)
+	require.Nil(t, tree.Split(1, 2, Horizontal))
+	require.Nil(t, tree.Split(2, 3, Vertical))
+	split := tree.Rects(window)
+
+	require.Nil(t, tree.Zoom(2))
+	zoomed, ok := tree.Zoomed()
+	assert.True(t, ok)
+	assert.Equal(t, 2, zoomed)
+	assert.Equal(t, map[int]Rect{2: window}, tree.Rects(window))
+	assert.Len(t, tree.Dividers(window), 0)
+	assert.Equal(t, []int{1, 2, 3}, tree.Panes())
+
+	tree.Unzoom()
+	assert.Equal(t, split, tree.Rects(window))
+
+	// closing a hidden pane leaves the zoom as it is, closing the zoomed one or splitting it ends it
+	require.Nil(t, tree.Zoom(2))
+	require.Nil(t, tree.Remove(3))
+	assert.Equal(t, map[int]Rect{2: window}, tree.Rects(window))
+	require.Nil(t, tree.Remove(2))
+	_, ok = tree.Zoomed()
+	assert.False(t, ok)
+
+	require.Nil(t, tree.Zoom(1))
+	require.Nil(t, tree.Split(1, 4, Vertical))
+	assert.Len(t, tree.Rects(window), 2)
+
+	assert.NotNil(t, tree.Zoom(7))
+}
+
+func TestDescribeAndBuild(t *testing.T) {
+	tree := New(1, 1)
+	require.Nil(t, tree.Split(1, 2, Horizontal))
+	require.Nil(t, tree.Split(2, 3, Vertical))
+	tree.Resize(window, 1, Horizontal, 20)
+
+	description := tree.Describe()
+	assert.False(t, description.IsPane())
+	assert.Equal(t, Horizontal, description.Direction)
+	assert.Equal(t, 1, description.First.Pane)
+	assert.Equal(t, Vertical, description.Second.Direction)
+
+	built, err := Build(description, 1)
+	require.Nil(t, err)
+	assert.Equal(t, []int{1, 2, 3}, built.Panes())
+	assert.Equal(t, tree.Rects(window), built.Rects(window))
+
+	_, err = Build(&Node{First: &Node{Pane: 1}, Second: &Node{Pane: 1}, Ratio: 0.5}, 1)
+	assert.NotNil(t, err)
+	_, err = Build(&Node{First: &Node{Pane: 1}, Ratio: 0.5}, 1)
+	assert.NotNil(t, err)
+}
+
+func TestDividerIs(t *testing.T) {
+	tree := New(1, 1)
+	require.Nil(t, tree.Split(1, 2, Horizontal))
+	require.Nil(t, tree.Split(2, 3, Vertical))
+
+	dragged := tree.DividerAt(window, 50, 20)
+	require.NotNil(t, dragged)
+	dividers := tree.Dividers(window)
+	assert.True(t, dividers[0].Is(dragged))
+	assert.False(t, dividers[1].Is(dragged))
+	assert.False(t, dividers[0].Is(nil))
+}
@@ -8,9 +8,14 @@ import (
 	"syscall"
 
 	"github.com/kr/pty"
+	"github.com/liamg/aminal/config"
+	"github.com/liamg/aminal/control"
+	"github.com/liamg/aminal/daemon"
 	"github.com/liamg/aminal/gui"
 	"github.com/liamg/aminal/terminal"
+	"github.com/liamg/aminal/terminfo"
 	"github.com/riywo/loginshell"
+	"go.uber.org/zap"
 )
 
 func main() {
@@ -25,43 +30,206 @@ func main() {
 	}
 	defer logger.Sync()
 
+	if layoutName != "" {
+		if sessionFile, err = config.LayoutPath(layoutName); err != nil {
+			logger.Fatalf("%s", err)
+		}
+	}
+	var session *config.Session
+	var first *config.PaneLayout // where the first pane's shell starts
+	if sessionFile != "" {
+		if session, err = openSession(conf); err != nil {
+			logger.Fatalf("%s", err)
+		}
+		first = session.First()
+	}
+
+	if daemonSession != "" {
+		runDaemon(daemonSession, conf, first, logger)
+		return
+	}
+
+	if installTerm {
+		if err := terminfo.Ensure(); err != nil {
+			logger.Fatalf("Failed to install the terminfo entry: %s", err)
+		}
+		fmt.Printf("The %s terminfo entry is installed and up to date\n", terminfo.Name)
+		return
+	}
+
+	if listSessions {
+		names, err := daemon.ListSessions()
+		if err != nil {
+			logger.Fatalf("Failed to list sessions: %s", err)
+		}
+		for _, name := range names {
+			fmt.Println(name)
+		}
+		return
+	}
+
+	if watchEvents {
+		if err := printEvents(); err != nil {
+			logger.Fatalf("Failed to watch events: %s", err)
+		}
+		return
+	}
+
+	var server *control.Server
+	if conf.ControlSocket {
+		server = startControlServer(logger)
+	}
+
+	relaunch := func() (terminal.Pty, error) {
+		return launch(conf, first, logger)
+	}
+	// new panes always get a shell on this machine, whatever the first one is attached to
+	launchPane := func(pane *config.PaneLayout, command string) (terminal.Pty, error) {
+		return startShell(conf, pane, command, logger)
+	}
+	pty, err := relaunch()
+	if err != nil {
+		logger.Fatalf("%s", err)
+	}
+
+	// keep the window open at the end of a replay, so what went wrong can be looked at
+	if replayFile != "" && conf.OnExit == config.OnExitClose {
+		conf.OnExit = config.OnExitHold
+	}
+
+	var recorder *terminal.Recorder
+	if recordFile != "" {
+		recorder, err = terminal.NewRecorder(recordFile)
+		if err != nil {
+			logger.Fatalf("%s", err)
+		}
+		defer recorder.Close()
+	}
+
+	logger.Infof("Creating terminal...")
+	terminal := terminal.New(pty, logger, conf)
+	if recorder != nil {
+		terminal.Record(recorder)
+	}
+
+	g, err := gui.New(conf, terminal, logger)
+	if err != nil {
+		logger.Fatalf("Cannot start: %s", err)
+	}
+	g.SetLauncher(relaunch)
+	g.SetPaneLauncher(launchPane)
+	if session != nil && session.IsSplit() {
+		g.SetLayout(&session.PaneLayout)
+	}
+	if server != nil {
+		g.SetControlServer(server)
+		defer server.Close()
+	}
+	if err := g.Render(); err != nil {
+		logger.Fatalf("Render error: %s", err)
+	}
+
+	// pass on the exit status of a command run with --command, so aminal can be used in scripts
+	if command != "" {
+		logger.Sync()
+		if server != nil {
+			server.Close()
+		}
+		os.Exit(g.ExitStatus())
+	}
+}
+
+// launch starts whatever the terminal is attached to - a shell, or one of the alternatives given on the command line
+func launch(conf *config.Config, pane *config.PaneLayout, logger *zap.SugaredLogger) (terminal.Pty, error) {
+	switch {
+	case replayFile != "":
+		logger.Infof("Replaying %s...", replayFile)
+		return terminal.OpenReplay(replayFile)
+	case serialDevice != "":
+		logger.Infof("Opening serial device %s...", serialDevice)
+		pty, err := terminal.OpenSerial(serialDevice, serialBaud, serialParity)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to open serial device %s: %s", serialDevice, err)
+		}
+		return pty, nil
+	case connectAddr != "":
+		logger.Infof("Connecting to %s...", connectAddr)
+		return terminal.DialRemote(connectAddr, connectTLS)
+	case attachSession != "":
+		logger.Infof("Attaching to session %s...", attachSession)
+		pty, err := daemon.Dial(attachSession)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to attach to session %s: %s", attachSession, err)
+		}
+		return pty, nil
+	case conf.Detachable:
+		pty, err := startDetachableSession(conf, logger)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to start detachable session: %s", err)
+		}
+		return pty, nil
+	default:
+		return launchShell(conf, pane, logger)
+	}
+}
+
+// launchShell starts the user's shell on a newly allocated pty, returning the master side. The shell starts in the
+// session pane's directory and environment, if there is one.
+func launchShell(conf *config.Config, pane *config.PaneLayout, logger *zap.SugaredLogger) (terminal.ProcessPty, error) {
+	return startShell(conf, pane, command, logger)
+}
+
+// startShell starts the user's shell, running command instead of being interactive if it isn't empty
+func startShell(conf *config.Config, pane *config.PaneLayout, command string, logger *zap.SugaredLogger) (terminal.ProcessPty, error) {
+
 	logger.Infof("Allocating pty...")
 	pty, tty, err := pty.Open()
 	if err != nil {
-		logger.Fatalf("Failed to allocate pty: %s", err)
+		return nil, fmt.Errorf("Failed to allocate pty: %s", err)
 	}
 
 	shellStr, err := loginshell.Shell()
 	if err != nil {
-		logger.Fatalf("Failed to ascertain your shell: %s", err)
+		return nil, fmt.Errorf("Failed to ascertain your shell: %s", err)
 	}
 
 	if conf.Shell != "" {
 		shellStr = conf.Shell
 	}
 
-	os.Setenv("TERM", "xterm-256color") // controversial! easier than installing terminfo everywhere, but obviously going to be slightly different to xterm functionality, so we'll see...
-	os.Setenv("COLORTERM", "truecolor")
+	os.Setenv("TERM", termName(conf, logger))
+	if conf.ColorTerm != "" {
+		os.Setenv("COLORTERM", conf.ColorTerm)
+	} else {
+		os.Unsetenv("COLORTERM")
+	}
 
 	shell := exec.Command(shellStr)
+	if command != "" {
+		shell = exec.Command(shellStr, "-c", command)
+	}
 	shell.Stdout = tty
 	shell.Stdin = tty
 	shell.Stderr = tty
 	shell.SysProcAttr = &syscall.SysProcAttr{Setctty: true, Setsid: true}
+	if pane != nil {
+		shell.Dir = pane.Directory
+		shell.Env = pane.Environ(os.Environ())
+	}
 	if err := shell.Start(); err != nil {
 		pty.Close()
-		logger.Fatalf("Failed to start your shell: %s", err)
+		return nil, fmt.Errorf("Failed to start your shell: %s", err)
 	}
 
-	logger.Infof("Creating terminal...")
-	terminal := terminal.New(pty, logger, conf)
+	// the shell has its own handle on the tty now - closing ours means reads from the pty fail once the shell exits
+	tty.Close()
 
-	g, err := gui.New(conf, terminal, logger)
-	if err != nil {
-		logger.Fatalf("Cannot start: %s", err)
-	}
-	if err := g.Render(); err != nil {
-		logger.Fatalf("Render error: %s", err)
+	if pane != nil && pane.Command != "" && command == "" {
+		// typed in as if by the user, so the shell is still there when the command finishes
+		if _, err := pty.Write([]byte(pane.Command + "\n")); err != nil {
+			logger.Errorf("Failed to run the session pane's command: %s", err)
+		}
 	}
 
+	return terminal.NewLocalPty(pty, shell), nil
 }
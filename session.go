@@ -0,0 +1,19 @@
+package main
+
+import (
+	"github.com/liamg/aminal/config"
+)
+
+// openSession reads the --session file, whose panes are laid out in this window
+func openSession(conf *config.Config) (*config.Session, error) {
+
+	session, err := config.LoadSession(sessionFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if session.Title != "" {
+		conf.Title = session.Title
+	}
+	return session, nil
+}
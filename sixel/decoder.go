@@ -0,0 +1,170 @@
+package sixel
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type decoderState int
+
+const (
+	stateHeader decoderState = iota
+	stateColour
+	statePixels
+)
+
+// Decoder parses sixel data a rune at a time as it arrives, so the string never has to be held in memory in full
+type Decoder struct {
+	six        Sixel
+	state      decoderState
+	header     strings.Builder
+	colour     strings.Builder
+	repeating  bool
+	count      strings.Builder
+	colourMap  map[string]colour
+	selected   colour
+	remainMode bool
+	ratio      uint
+	x          uint
+	y          uint
+	err        error
+}
+
+// NewDecoder creates a decoder for everything after ESC P and before ST
+func NewDecoder() *Decoder {
+	return &Decoder{
+		colourMap: map[string]colour{},
+	}
+}
+
+// Write parses the next rune. Once the data is found to be invalid, the error is returned and the rest is ignored.
+func (d *Decoder) Write(r rune) error {
+	if d.err != nil {
+		return d.err
+	}
+
+	// expand repeats e.g. !14@ before anything else
+	if d.repeating {
+		if r >= 0x30 && r <= 0x39 {
+			d.count.WriteRune(r)
+			return nil
+		}
+		d.repeating = false
+		count, _ := strconv.Atoi(d.count.String())
+		for i := 0; i < count && d.err == nil; i++ {
+			d.handle(r)
+		}
+		return d.err
+	}
+	if r == '!' {
+		d.repeating = true
+		d.count.Reset()
+		return nil
+	}
+
+	d.handle(r)
+	return d.err
+}
+
+func (d *Decoder) handle(r rune) {
+	switch d.state {
+	case stateHeader:
+		if r != 'q' {
+			d.header.WriteRune(r)
+			return
+		}
+		headers := strings.Split(d.header.String(), ";")
+		switch headers[0] {
+		case "0", "1":
+			d.ratio = 5
+		case "2":
+			d.ratio = 3
+		case "3", "4", "5", "6":
+			d.ratio = 2
+		case "7", "8", "9", "":
+			d.ratio = 1
+		}
+		if len(headers) > 1 {
+			d.remainMode = headers[1] == "1"
+		}
+		d.state = statePixels
+	case stateColour:
+		if d.colour.Len() == 0 || r >= 0x30 && r <= 0x3b {
+			d.colour.WriteRune(r)
+			return
+		}
+		d.endColour()
+		if d.err == nil {
+			d.handle(r)
+		}
+	default:
+		switch r {
+		case '-':
+			d.y += 6
+			d.x = 0
+		case '$':
+			d.x = 0
+		case '#':
+			d.state = stateColour
+			d.colour.Reset()
+		default:
+			if r < 63 || r > 126 {
+				return
+			}
+			b := (r & 0xff) - 0x3f
+			for bit := 5; bit >= 0; bit-- {
+				if b&(1<<uint(bit)) > 0 {
+					d.six.setPixel(d.x, d.y+uint(bit), d.selected, d.ratio)
+				} else if !d.remainMode {
+					// @todo use background colour here
+				}
+			}
+			d.x++
+		}
+	}
+}
+
+// endColour selects or defines the colour which has just been read
+func (d *Decoder) endColour() {
+	d.state = statePixels
+	colourStr := d.colour.String()
+	parts := strings.Split(colourStr, ";")
+
+	if len(parts) == 1 {
+		if c, ok := d.colourMap[parts[0]]; ok {
+			d.selected = c
+		}
+		return
+	}
+	if len(parts) != 5 {
+		d.err = fmt.Errorf("Invalid colour directive: #%s", colourStr)
+		return
+	}
+	switch parts[1] {
+	case "1":
+		d.err = fmt.Errorf("HSL colours are not yet supported")
+	case "2":
+		r, _ := strconv.Atoi(parts[2])
+		g, _ := strconv.Atoi(parts[3])
+		b, _ := strconv.Atoi(parts[4])
+		d.colourMap[parts[0]] = colour([3]uint8{
+			uint8(r & 0xff),
+			uint8(g & 0xff),
+			uint8(b & 0xff),
+		})
+	default:
+		d.err = fmt.Errorf("Unknown colour definition type: %s", parts[1])
+	}
+}
+
+// Sixel finishes parsing, returning the image
+func (d *Decoder) Sixel() (*Sixel, error) {
+	if d.err == nil && d.state == stateColour {
+		d.endColour()
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	return &d.six, nil
+}
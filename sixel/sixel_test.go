@@ -22,3 +22,30 @@ func TestParsing(t *testing.T) {
 	require.NotNil(t, img)
 
 }
+
+func TestDecoderExpandsRepeatsAndColours(t *testing.T) {
+	decoder := NewDecoder()
+	for _, r := range "q#0;2;100;0;0#0!3~-#0@" {
+		require.Nil(t, decoder.Write(r))
+	}
+	six, err := decoder.Sixel()
+	require.Nil(t, err)
+
+	red := colour{100, 0, 0}
+	require.Len(t, six.px, 3)
+	for x := uint(0); x < 3; x++ {
+		for y := uint(0); y < 6; y++ {
+			require.Equal(t, red, six.px[x][y])
+		}
+	}
+	require.Equal(t, red, six.px[0][6])
+}
+
+func TestDecoderStopsAtInvalidColour(t *testing.T) {
+	decoder := NewDecoder()
+	for _, r := range "q#0;1;0;0;0~" {
+		_ = decoder.Write(r)
+	}
+	_, err := decoder.Sixel()
+	require.NotNil(t, err)
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/liamg/aminal/config"
+	"github.com/liamg/aminal/terminfo"
+	"go.uber.org/zap"
+)
+
+var (
+	termOnce sync.Once
+	term     string
+)
+
+// termName returns TERM for shells. aminal's own terminfo entry is installed, or repaired if it is out of date, the
+// first time it is needed by this version of aminal, and if that can't be done, e.g. tic isn't installed,
+// xterm-256color is used instead.
+func termName(conf *config.Config, logger *zap.SugaredLogger) string {
+	termOnce.Do(func() {
+		term = conf.Term
+		switch term {
+		case "":
+			term = terminfo.Fallback
+		case terminfo.Name:
+			if err := terminfo.EnsureCached(); err != nil {
+				logger.Warnf("Using TERM=%s, as the %s terminfo entry couldn't be installed: %s", terminfo.Fallback, terminfo.Name, err)
+				term = terminfo.Fallback
+			}
+		}
+	})
+	return term
+}
@@ -0,0 +1,86 @@
+package terminal
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// the alternate screen is snapshotted at most this often while a program is redrawing it, and again as it exits
+const altScreenSnapshotInterval = 10 * time.Second
+
+// ScreenSnapshot is the text of the alternate screen at a moment, e.g. what htop showed a minute ago
+type ScreenSnapshot struct {
+	Time  time.Time
+	Title string
+	Lines []string
+}
+
+// Text returns the snapshot's lines, with blank lines at the bottom trimmed
+func (snapshot ScreenSnapshot) Text() string {
+	return strings.TrimRight(strings.Join(snapshot.Lines, "\n"), "\n")
+}
+
+// altScreenHistory keeps the latest snapshots of the alternate screen, which is otherwise lost as programs redraw it.
+// It holds text only, so it takes at most a screenful of runes per snapshot.
+type altScreenHistory struct {
+	mu        sync.Mutex
+	limit     int
+	snapshots []ScreenSnapshot // oldest first
+	lastTaken time.Time
+}
+
+func (history *altScreenHistory) add(snapshot ScreenSnapshot) {
+	history.mu.Lock()
+	defer history.mu.Unlock()
+
+	history.lastTaken = snapshot.Time
+	if n := len(history.snapshots); n > 0 && history.snapshots[n-1].Text() == snapshot.Text() {
+		history.snapshots[n-1].Time = snapshot.Time
+		return
+	}
+	history.snapshots = append(history.snapshots, snapshot)
+	if len(history.snapshots) > history.limit {
+		history.snapshots = append(history.snapshots[:0], history.snapshots[len(history.snapshots)-history.limit:]...)
+	}
+}
+
+// started puts off the first snapshot after a program switches to the alternate screen, until it has had time to draw
+func (history *altScreenHistory) started(now time.Time) {
+	history.mu.Lock()
+	defer history.mu.Unlock()
+	history.lastTaken = now
+}
+
+func (history *altScreenHistory) due(now time.Time) bool {
+	history.mu.Lock()
+	defer history.mu.Unlock()
+	return now.Sub(history.lastTaken) >= altScreenSnapshotInterval
+}
+
+// AltScreenHistory returns the snapshots taken of the alternate screen, oldest first. It is empty unless
+// alt_screen_history is set in the config.
+func (terminal *Terminal) AltScreenHistory() []ScreenSnapshot {
+	history := &terminal.altHistory
+	history.mu.Lock()
+	defer history.mu.Unlock()
+	return append([]ScreenSnapshot{}, history.snapshots...)
+}
+
+// snapshotAltScreen records what the alternate screen shows, if it is in use and a snapshot is due. force takes one
+// regardless of when the last was, for the last look at it before the program leaves it.
+func (terminal *Terminal) snapshotAltScreen(force bool) {
+	if terminal.altHistory.limit == 0 || terminal.activeBufferIndex != AltBuffer {
+		return
+	}
+	now := time.Now()
+	if !force && !terminal.altHistory.due(now) {
+		return
+	}
+
+	lines := []string{}
+	for _, line := range terminal.buffers[AltBuffer].GetVisibleLines() {
+		lines = append(lines, line.String())
+	}
+	terminal.altHistory.add(ScreenSnapshot{Time: now, Title: terminal.GetTitle(), Lines: lines})
+}
@@ -0,0 +1,45 @@
+package terminal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAltScreenHistory(t *testing.T) {
+	term, _ := newTestTerminal()
+	term.SetSize(10, 3)
+	term.altHistory.limit = 2
+
+	// snapshots are taken as the program leaves the alternate screen
+	require.Nil(t, parse(term, "\x1b[?1049hfirst\x1b[?1049l"))
+	require.Nil(t, parse(term, "\x1b[?1049hsecond\r\n\x1b[?1049l"))
+	history := term.AltScreenHistory()
+	require.Len(t, history, 2)
+	assert.Equal(t, "first", history[0].Text())
+	assert.Equal(t, "second", history[1].Text())
+
+	// and while it is in use, once one is due
+	require.Nil(t, parse(term, "\x1b[?1049hthird"))
+	term.snapshotAltScreen(false)
+	assert.Len(t, term.AltScreenHistory(), 2)
+	term.altHistory.started(time.Now().Add(-altScreenSnapshotInterval))
+	term.snapshotAltScreen(false)
+
+	// the oldest are dropped, and the same screen isn't kept twice
+	require.Nil(t, parse(term, "\x1b[?1049l"))
+	history = term.AltScreenHistory()
+	require.Len(t, history, 2)
+	assert.Equal(t, "second", history[0].Text())
+	assert.Equal(t, "third", history[1].Text())
+}
+
+func TestAltScreenHistoryIsOffByDefault(t *testing.T) {
+	term, _ := newTestTerminal()
+	term.SetSize(10, 3)
+
+	require.Nil(t, parse(term, "\x1b[?1049hhidden\x1b[?1049l"))
+	assert.Empty(t, term.AltScreenHistory())
+}
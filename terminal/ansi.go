@@ -1,70 +1,107 @@
 package terminal
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/liamg/aminal/buffer"
+)
 
 // https://www.xfree86.org/4.8.0/ctlseqs.html
 // https://vt100.net/docs/vt100-ug/chapter3.html
 
-var ansiSequenceMap = map[rune]escapeSequenceHandler{
-	'[': csiHandler,
-	']': oscHandler,
-	'7': saveCursorHandler,
-	'8': restoreCursorHandler,
-	'D': indexHandler,
-	'M': reverseIndexHandler,
-	'P': sixelHandler,
-	'c': risHandler,        //RIS
-	'(': swallowHandler(1), // character set bullshit
-	')': swallowHandler(1), // character set bullshit
-	'*': swallowHandler(1), // character set bullshit
-	'+': swallowHandler(1), // character set bullshit
-	'>': swallowHandler(0), // numeric char selection  //@todo
-	'=': swallowHandler(0), // alt char selection  //@todo
+// escape sequences without intermediates, by their final character. CSI, OSC, DCS and the control strings are started
+// by the parser itself.
+var ansiSequenceMap = map[rune]controlHandler{
+	'7':  saveCursorHandler,
+	'8':  restoreCursorHandler,
+	'D':  indexHandler,
+	'H':  tabSetHandler, // HTS
+	'M':  reverseIndexHandler,
+	'c':  risHandler,           //RIS
+	'>':  keypadHandler(false), // DECKPNM
+	'=':  keypadHandler(true),  // DECKPAM
+	'\\': stringTerminatorHandler,
 }
 
-func swallowHandler(n int) func(pty chan rune, terminal *Terminal) error {
-	return func(pty chan rune, terminal *Terminal) error {
-		for i := 0; i < n; i++ {
-			<-pty
+// escDispatch carries out an escape sequence once its final character has been read
+func escDispatch(intermediate string, final rune, terminal *Terminal) error {
+	switch intermediate {
+	case "":
+		if handler, ok := ansiSequenceMap[final]; ok {
+			return handler(terminal)
 		}
+	case "(":
+		return designateCharset(0, final, terminal)
+	case ")":
+		return designateCharset(1, final, terminal)
+	case "*", "+": // G2 and G3 are only used by single shifts, which aren't supported
 		return nil
 	}
+	return fmt.Errorf("Unknown ANSI control sequence: ESC %s%c", intermediate, final)
+}
+
+// stringTerminatorHandler handles ST (ESC \) on its own. The string it ends has already been ended by the ESC.
+func stringTerminatorHandler(terminal *Terminal) error {
+	return nil
 }
 
-func risHandler(pty chan rune, terminal *Terminal) error {
+// keypadHandler switches the keypad between sending application sequences and acting as digits and cursor keys
+func keypadHandler(application bool) controlHandler {
+	return func(terminal *Terminal) error {
+		terminal.modes.ApplicationKeypad = application
+		return nil
+	}
+}
+
+// designateCharset sets the character set used as G0 or G1, e.g. ESC ( 0 for line drawing
+func designateCharset(set int, final rune, terminal *Terminal) error {
+	switch final {
+	case 'B':
+		terminal.ActiveBuffer().DesignateCharset(set, buffer.CharsetASCII)
+	case '0':
+		terminal.ActiveBuffer().DesignateCharset(set, buffer.CharsetDECSpecialGraphics)
+	case 'A':
+		terminal.ActiveBuffer().DesignateCharset(set, buffer.CharsetUK)
+	default:
+		terminal.ActiveBuffer().DesignateCharset(set, buffer.CharsetASCII)
+		return fmt.Errorf("Unsupported character set: %q", final)
+	}
+	return nil
+}
+
+func risHandler(terminal *Terminal) error {
 	terminal.ActiveBuffer().Clear()
+	for _, b := range terminal.buffers {
+		b.ResetCharsets()
+	}
+	terminal.modes.CursorShape = CursorBlock
+	terminal.modes.BlinkingCursor = false
+	terminal.setProgress(Progress{})
+	terminal.resetColours()
 	return nil
 }
 
-func indexHandler(pty chan rune, terminal *Terminal) error {
+func indexHandler(terminal *Terminal) error {
 	terminal.ActiveBuffer().Index()
 	return nil
 }
 
-func reverseIndexHandler(pty chan rune, terminal *Terminal) error {
+func tabSetHandler(terminal *Terminal) error {
+	terminal.ActiveBuffer().SetTabStop()
+	return nil
+}
+
+func reverseIndexHandler(terminal *Terminal) error {
 	terminal.ActiveBuffer().ReverseIndex()
 	return nil
 }
 
-func saveCursorHandler(pty chan rune, terminal *Terminal) error {
+func saveCursorHandler(terminal *Terminal) error {
 	terminal.ActiveBuffer().SaveCursor()
 	return nil
 }
 
-func restoreCursorHandler(pty chan rune, terminal *Terminal) error {
+func restoreCursorHandler(terminal *Terminal) error {
 	terminal.ActiveBuffer().RestoreCursor()
 	return nil
 }
-
-func ansiHandler(pty chan rune, terminal *Terminal) error {
-	// if the byte is an escape character, read the next byte to determine which one
-	b := <-pty
-
-	handler, ok := ansiSequenceMap[b]
-	if ok {
-		//terminal.logger.Debugf("Handling ansi sequence %c", b)
-		return handler(pty, terminal)
-	}
-
-	return fmt.Errorf("Unknown ANSI control sequence byte: 0x%02X [%v]", b, string(b))
-}
@@ -0,0 +1,93 @@
+package terminal
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// StringHandler handles an APC or PM string for the protocol it was registered for. The payload is everything
+// between the introducer and the string terminator, including the prefix which identified the protocol.
+type StringHandler func(terminal *Terminal, payload string) error
+
+// stringProtocols routes control strings to handlers by the prefix of their payload, e.g. "G" for kitty graphics
+type stringProtocols struct {
+	mu       sync.RWMutex
+	handlers map[string]StringHandler
+}
+
+var (
+	apcProtocols = &stringProtocols{handlers: map[string]StringHandler{}}
+	pmProtocols  = &stringProtocols{handlers: map[string]StringHandler{}}
+)
+
+// RegisterAPCHandler routes APC strings (ESC _ ... ESC \) whose payload starts with prefix to handler. Registering
+// the same prefix again replaces the handler.
+func RegisterAPCHandler(prefix string, handler StringHandler) {
+	apcProtocols.register(prefix, handler)
+}
+
+// RegisterPMHandler routes privacy message strings (ESC ^ ... ESC \) whose payload starts with prefix to handler
+func RegisterPMHandler(prefix string, handler StringHandler) {
+	pmProtocols.register(prefix, handler)
+}
+
+func (protocols *stringProtocols) register(prefix string, handler StringHandler) {
+	protocols.mu.Lock()
+	defer protocols.mu.Unlock()
+	protocols.handlers[prefix] = handler
+}
+
+// lookup finds the handler registered for the longest prefix of payload, or nil if no protocol matches
+func (protocols *stringProtocols) lookup(payload string) StringHandler {
+	if protocols == nil {
+		return nil
+	}
+	protocols.mu.RLock()
+	defer protocols.mu.RUnlock()
+	var handler StringHandler
+	longest := -1
+	for prefix, h := range protocols.handlers {
+		if len(prefix) > longest && strings.HasPrefix(payload, prefix) {
+			handler = h
+			longest = len(prefix)
+		}
+	}
+	return handler
+}
+
+// controlString reads an APC, PM or SOS string, so none of it is shown as text, and passes it to the handler registered
+// for it. Strings which no handler recognises are discarded.
+type controlString struct {
+	name      string
+	protocols *stringProtocols
+	payload   strings.Builder
+	length    int
+}
+
+func newControlString(name string, protocols *stringProtocols) *controlString {
+	return &controlString{name: name, protocols: protocols}
+}
+
+func (str *controlString) put(b rune) {
+	str.length++
+	if str.length > maxAPCLength {
+		// the rest of the string is dropped, but still read to its end so it isn't shown as text
+		return
+	}
+	str.payload.WriteRune(b)
+}
+
+func (str *controlString) end(terminal *Terminal, terminator string) error {
+
+	if str.length > maxAPCLength {
+		return fmt.Errorf("%s string too long: %d runes", str.name, str.length)
+	}
+
+	handler := str.protocols.lookup(str.payload.String())
+	if handler == nil {
+		terminal.logger.Debugf("Discarded unrecognised %s string of %d runes", str.name, str.length)
+		return nil
+	}
+	return handler(terminal, str.payload.String())
+}
@@ -0,0 +1,46 @@
+package terminal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestControlStringsAreNotShown(t *testing.T) {
+	term, _ := newTestTerminal()
+	term.SetSize(20, 3)
+
+	pty := feed("a\x1b_Gf=100,a=T;iVBORw0KGgo=\x1b\\b\x1b^private\x1b\\c\x1bXstart of string\x1b\\d")
+	for len(pty) > 0 {
+		term.processRune(<-pty, pty)
+	}
+
+	assert.Equal(t, "abcd", term.ActiveBuffer().GetAllText())
+}
+
+func TestControlStringsAreRoutedByPrefix(t *testing.T) {
+	protocols := &stringProtocols{handlers: map[string]StringHandler{}}
+	var got []string
+	protocols.register("ext", func(terminal *Terminal, payload string) error {
+		got = append(got, "ext:"+payload)
+		return nil
+	})
+	protocols.register("ext-v2", func(terminal *Terminal, payload string) error {
+		got = append(got, "ext-v2:"+payload)
+		return nil
+	})
+
+	term, _ := newTestTerminal()
+	apc := func(payload string) error {
+		str := newControlString("APC", protocols)
+		for _, r := range payload {
+			str.put(r)
+		}
+		return str.end(term, "\x1b\\")
+	}
+	assert.Nil(t, apc("ext;one"))
+	assert.Nil(t, apc("ext-v2;two"))
+	assert.Nil(t, apc("unknown"))
+
+	assert.Equal(t, []string{"ext:ext;one", "ext-v2:ext-v2;two"}, got)
+}
@@ -0,0 +1,61 @@
+package terminal
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+)
+
+// base64ChunkSize is how much base64 is collected before decoding it, a multiple of the 4 character quantum
+const base64ChunkSize = 4096
+
+// base64Stream decodes base64 a chunk at a time as it arrives, so only the decoded data is held in memory
+type base64Stream struct {
+	chunk   []byte
+	decoded bytes.Buffer
+	written int
+	err     error
+}
+
+func (stream *base64Stream) WriteRune(r rune) {
+	if r == '\r' || r == '\n' {
+		return
+	}
+	stream.written++
+	if stream.err != nil {
+		return
+	}
+	if r > 0x7f {
+		stream.err = fmt.Errorf("Invalid base64 character %q", r)
+		return
+	}
+	stream.chunk = append(stream.chunk, byte(r))
+	if len(stream.chunk) == base64ChunkSize {
+		stream.flush()
+	}
+}
+
+func (stream *base64Stream) flush() {
+	if stream.err != nil || len(stream.chunk) == 0 {
+		return
+	}
+	decoded := make([]byte, base64.StdEncoding.DecodedLen(len(stream.chunk)))
+	n, err := base64.StdEncoding.Decode(decoded, stream.chunk)
+	if err != nil {
+		stream.err = err
+		return
+	}
+	stream.decoded.Write(decoded[:n])
+	stream.chunk = stream.chunk[:0]
+}
+
+// isQuery returns true if all that was written was "?", which OSC 52 uses to read the clipboard
+func (stream *base64Stream) isQuery() bool {
+	return stream.written == 1 && len(stream.chunk) == 1 && stream.chunk[0] == '?'
+}
+
+// Bytes decodes whatever is left, returning all of the decoded data
+func (stream *base64Stream) Bytes() ([]byte, error) {
+	stream.flush()
+	return stream.decoded.Bytes(), stream.err
+}
@@ -0,0 +1,39 @@
+package terminal
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBase64StreamDecodesInChunks(t *testing.T) {
+	text := strings.Repeat("the quick brown fox ", 1000)
+	stream := &base64Stream{}
+	for _, r := range base64.StdEncoding.EncodeToString([]byte(text)) {
+		stream.WriteRune(r)
+	}
+	assert.True(t, len(stream.chunk) < base64ChunkSize)
+
+	decoded, err := stream.Bytes()
+	require.Nil(t, err)
+	assert.Equal(t, text, string(decoded))
+	assert.False(t, stream.isQuery())
+}
+
+func TestBase64StreamRejectsInvalidData(t *testing.T) {
+	stream := &base64Stream{}
+	for _, r := range "not base64!" {
+		stream.WriteRune(r)
+	}
+	_, err := stream.Bytes()
+	assert.NotNil(t, err)
+}
+
+func TestBase64StreamQuery(t *testing.T) {
+	stream := &base64Stream{}
+	stream.WriteRune('?')
+	assert.True(t, stream.isQuery())
+}
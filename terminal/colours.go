@@ -0,0 +1,179 @@
+package terminal
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/liamg/aminal/config"
+)
+
+// colourOSC handles setting and querying colours of the palette with OSC 4, and the default foreground, background and
+// cursor colours with OSC 10, 11 and 12, as well as putting them back with OSC 104, 110, 111 and 112. Colours the
+// program changes are drawn in place of the colours in the config, including in what has already been written.
+func (terminal *Terminal) colourOSC(params []string, terminator string) error {
+	defer terminal.updateColourOverrides()
+
+	n, _ := strconv.Atoi(params[0])
+	switch n {
+	case 4: // 4;index;colour, repeated for any number of colours
+		args := params[1:]
+		if len(args) == 0 || len(args)%2 != 0 {
+			return fmt.Errorf("Invalid OSC 4: %s", strings.Join(params, ";"))
+		}
+		for i := 0; i < len(args); i += 2 {
+			index, err := paletteIndex(args[i])
+			if err != nil {
+				return err
+			}
+			if err := terminal.setOrQueryColour(index, args[i+1], "4;"+args[i], terminator); err != nil {
+				return err
+			}
+		}
+	case 10, 11, 12: // further colours set the ones after, e.g. 10;fg;bg
+		if len(params) < 2 {
+			return fmt.Errorf("Missing colour for OSC %d", n)
+		}
+		for i, spec := range params[1:] {
+			if n+i > 12 {
+				break
+			}
+			if err := terminal.setOrQueryColour(config.ColourForeground+n-10+i, spec, strconv.Itoa(n+i), terminator); err != nil {
+				return err
+			}
+		}
+	case 104: // with no indexes, the whole palette
+		if len(params) == 1 || len(params) == 2 && params[1] == "" {
+			for index := range terminal.colours {
+				if index < config.ColourForeground {
+					delete(terminal.colours, index)
+				}
+			}
+			return nil
+		}
+		for _, arg := range params[1:] {
+			index, err := paletteIndex(arg)
+			if err != nil {
+				return err
+			}
+			delete(terminal.colours, index)
+		}
+	case 110, 111, 112:
+		delete(terminal.colours, config.ColourForeground+n-110)
+	}
+	return nil
+}
+
+func paletteIndex(s string) (int, error) {
+	index, err := strconv.Atoi(s)
+	if err != nil || index < 0 || index > 255 {
+		return 0, fmt.Errorf("Invalid palette index: %s", s)
+	}
+	return index, nil
+}
+
+// setOrQueryColour sets a colour, or replies with it if the colour is "?"
+func (terminal *Terminal) setOrQueryColour(index int, spec string, reply string, terminator string) error {
+	if spec == "?" {
+		return terminal.respond([]byte("\x1b]" + reply + ";" + formatXColour(terminal.currentColour(index)) + terminator))
+	}
+	c, err := parseXColour(spec)
+	if err != nil {
+		return err
+	}
+	terminal.colours[index] = c
+	return nil
+}
+
+// defaultColour returns the colour in the config of one of the palette or dynamic colours
+func (terminal *Terminal) defaultColour(index int) config.Colour {
+	return terminal.config.ColourScheme.PaletteColour(index)
+}
+
+// currentColour returns the colour one of the palette or dynamic colours is drawn in
+func (terminal *Terminal) currentColour(index int) config.Colour {
+	if c, ok := terminal.colours[index]; ok {
+		return c
+	}
+	terminal.coloursLock.Lock()
+	defer terminal.coloursLock.Unlock()
+	if terminal.displayColours != nil {
+		return terminal.displayColours.PaletteColour(index)
+	}
+	return terminal.defaultColour(index)
+}
+
+// SetDisplayColours sets the colours the colours in the config are drawn in, e.g. by a theme, so that programs asking
+// what the colours are get the ones they can see
+func (terminal *Terminal) SetDisplayColours(scheme config.ColourScheme) {
+	terminal.coloursLock.Lock()
+	defer terminal.coloursLock.Unlock()
+	terminal.displayColours = &scheme
+}
+
+// ColourOverrides returns the colours the program has changed, by their index in the palette, or config.ColourForeground,
+// ColourBackground or ColourCursor for the default colours. The map mustn't be changed.
+func (terminal *Terminal) ColourOverrides() map[int]config.Colour {
+	terminal.coloursLock.Lock()
+	defer terminal.coloursLock.Unlock()
+	return terminal.colourOverrides
+}
+
+// updateColourOverrides replaces, rather than changes, the overrides, as they are read while drawing
+func (terminal *Terminal) updateColourOverrides() {
+	overrides := make(map[int]config.Colour, len(terminal.colours))
+	for index, c := range terminal.colours {
+		overrides[index] = c
+	}
+	terminal.coloursLock.Lock()
+	terminal.colourOverrides = overrides
+	terminal.coloursLock.Unlock()
+	terminal.SetDirty()
+}
+
+// resetColours puts back all the colours the program has changed
+func (terminal *Terminal) resetColours() {
+	terminal.colours = map[int]config.Colour{}
+	terminal.updateColourOverrides()
+}
+
+// parseXColour parses a colour as used by xterm: rgb:r/g/b with 1 to 4 hex digits for each, or # followed by 1 to 4
+// hex digits for each
+func parseXColour(spec string) (config.Colour, error) {
+	var parts []string
+	switch {
+	case strings.HasPrefix(spec, "rgb:"):
+		parts = strings.Split(spec[4:], "/")
+	case strings.HasPrefix(spec, "#") && len(spec) > 1 && (len(spec)-1)%3 == 0:
+		digits := (len(spec) - 1) / 3
+		for i := 0; i < 3; i++ {
+			parts = append(parts, spec[1+i*digits:1+(i+1)*digits])
+		}
+	}
+	if len(parts) != 3 {
+		return config.Colour{}, fmt.Errorf("Unsupported colour: %s", spec)
+	}
+
+	var c config.Colour
+	for i, part := range parts {
+		if len(part) < 1 || len(part) > 4 {
+			return c, fmt.Errorf("Invalid colour: %s", spec)
+		}
+		v, err := strconv.ParseUint(part, 16, 16)
+		if err != nil {
+			return c, fmt.Errorf("Invalid colour: %s", spec)
+		}
+		c[i] = float32(v) / float32(uint64(1)<<(4*uint(len(part)))-1)
+	}
+	return c, nil
+}
+
+// formatXColour formats a colour as xterm replies with it, as rgb:rrrr/gggg/bbbb
+func formatXColour(c config.Colour) string {
+	var v [3]uint16
+	for i := range c {
+		v[i] = uint16(math.Round(float64(c[i]) * 0xffff))
+	}
+	return fmt.Sprintf("rgb:%04x/%04x/%04x", v[0], v[1], v[2])
+}
@@ -0,0 +1,79 @@
+package terminal
+
+import (
+	"testing"
+
+	"github.com/liamg/aminal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetAndQueryPaletteColour(t *testing.T) {
+	term, pty := newTestTerminal()
+
+	require.Nil(t, parse(term, "\x1b]4;1;rgb:ff/80/00;200;#0000ff\x07"))
+	colours := term.ColourOverrides()
+	assert.Len(t, colours, 2)
+	assert.Equal(t, config.Colour{1, float32(0x80) / 0xff, 0}, colours[1])
+	assert.Equal(t, config.Colour{0, 0, 1}, colours[200])
+
+	require.Nil(t, parse(term, "\x1b]4;1;?\x07"))
+	assert.Equal(t, "\x1b]4;1;rgb:ffff/8080/0000\x07", pty.String())
+
+	// the whole palette is put back, and replies end with ST if the request did
+	require.Nil(t, parse(term, "\x1b]104\x1b\\"))
+	pty.Reset()
+	require.Nil(t, parse(term, "\x1b]4;1;?\x1b\\"))
+	assert.Equal(t, "\x1b]4;1;"+formatXColour(term.config.ColourScheme.Red)+"\x1b\\", pty.String())
+
+	assert.NotNil(t, parse(term, "\x1b]4;256;#000\x07"))
+	assert.NotNil(t, parse(term, "\x1b]4;1\x07"))
+	assert.NotNil(t, parse(term, "\x1b]4;1;mauve\x07"))
+}
+
+func TestSetAndQueryDynamicColours(t *testing.T) {
+	term, pty := newTestTerminal()
+
+	require.Nil(t, parse(term, "\x1b]11;?\x07"))
+	assert.Equal(t, "\x1b]11;"+formatXColour(term.config.ColourScheme.Background)+"\x07", pty.String())
+
+	// a theme changes what the program sees
+	theme := term.config.ColourScheme
+	theme.Background = config.Colour{1, 1, 1}
+	term.SetDisplayColours(theme)
+	pty.Reset()
+	require.Nil(t, parse(term, "\x1b]11;?\x07"))
+	assert.Equal(t, "\x1b]11;rgb:ffff/ffff/ffff\x07", pty.String())
+
+	// further colours set the ones after
+	require.Nil(t, parse(term, "\x1b]10;#fff;#000;#f00\x07"))
+	colours := term.ColourOverrides()
+	assert.Equal(t, config.Colour{1, 1, 1}, colours[config.ColourForeground])
+	assert.Equal(t, config.Colour{0, 0, 0}, colours[config.ColourBackground])
+	assert.Equal(t, config.Colour{1, 0, 0}, colours[config.ColourCursor])
+
+	require.Nil(t, parse(term, "\x1b]112\x07"))
+	_, changed := term.ColourOverrides()[config.ColourCursor]
+	assert.False(t, changed)
+
+	require.Nil(t, risHandler(term))
+	assert.Len(t, term.ColourOverrides(), 0)
+}
+
+func TestParseXColour(t *testing.T) {
+	for spec, expected := range map[string]config.Colour{
+		"rgb:f/0/f":          {1, 0, 1},
+		"rgb:ffff/0000/8080": {1, 0, float32(0x8080) / 0xffff},
+		"#f00":               {1, 0, 0},
+		"#00ff00":            {0, 1, 0},
+		"#00000000ffff":      {0, 0, 1},
+	} {
+		c, err := parseXColour(spec)
+		require.Nil(t, err, spec)
+		assert.Equal(t, expected, c, spec)
+	}
+	for _, spec := range []string{"red", "rgb:f/0", "#ff", "rgb:fffff/0/0", "#ggg"} {
+		_, err := parseXColour(spec)
+		assert.NotNil(t, err, spec)
+	}
+}
@@ -0,0 +1,152 @@
+package terminal
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var conformanceReport = flag.String("conformance-report", "", "Write a markdown report of the conformance cases to this file")
+
+// conformanceCase is a vttest style check: some output is written to a terminal of a given size, and the screen which
+// results is compared to the expected grid. See testdata/conformance/README.md for the file format.
+type conformanceCase struct {
+	area         string
+	name         string
+	cols         uint
+	rows         uint
+	input        string
+	grid         []string
+	knownFailure bool
+}
+
+func loadConformanceCases(t *testing.T) []*conformanceCase {
+
+	files, err := filepath.Glob("testdata/conformance/*.vt")
+	require.Nil(t, err)
+	require.NotEmpty(t, files)
+
+	cases := []*conformanceCase{}
+	for _, file := range files {
+		f, err := os.Open(file)
+		require.Nil(t, err)
+
+		area := strings.TrimSuffix(filepath.Base(file), ".vt")
+		var current *conformanceCase
+		scanner := bufio.NewScanner(f)
+		for n := 1; scanner.Scan(); n++ {
+			line := scanner.Text()
+			where := fmt.Sprintf("%s:%d", file, n)
+			switch {
+			case line == "" || strings.HasPrefix(line, "#"):
+			case strings.HasPrefix(line, "|"):
+				require.NotNil(t, current, where)
+				current.grid = append(current.grid, strings.TrimRight(line[1:], " "))
+			case strings.HasPrefix(line, "name: "):
+				current = &conformanceCase{area: area, name: strings.TrimPrefix(line, "name: "), cols: 10, rows: 4}
+				cases = append(cases, current)
+			case strings.HasPrefix(line, "size: "):
+				require.NotNil(t, current, where)
+				_, err := fmt.Sscanf(strings.TrimPrefix(line, "size: "), "%dx%d", &current.cols, &current.rows)
+				require.Nil(t, err, where)
+			case strings.HasPrefix(line, "input: "):
+				require.NotNil(t, current, where)
+				input, err := strconv.Unquote(`"` + strings.TrimPrefix(line, "input: ") + `"`)
+				require.Nil(t, err, where)
+				current.input += input
+			case line == "known failure":
+				require.NotNil(t, current, where)
+				current.knownFailure = true
+			default:
+				t.Fatalf("%s: unexpected line %q", where, line)
+			}
+		}
+		require.Nil(t, scanner.Err())
+		f.Close()
+	}
+	return cases
+}
+
+// run writes the input to a new terminal and returns the screen, with trailing spaces trimmed from each line
+func (c *conformanceCase) run() []string {
+
+	term, _ := newTestTerminal()
+	term.SetSize(c.cols, c.rows)
+
+	pty := make(chan rune, len(c.input))
+	for _, r := range c.input {
+		pty <- r
+	}
+	for len(pty) > 0 {
+		term.processRune(<-pty, pty)
+	}
+	return screen(term)
+}
+
+// screen returns the text on the terminal's screen, with trailing spaces trimmed from each line
+func screen(term *Terminal) []string {
+	buf := term.ActiveBuffer()
+	screen := []string{}
+	for row := uint16(0); row < buf.ViewHeight(); row++ {
+		line := ""
+		for col := uint16(0); col < buf.ViewWidth(); col++ {
+			cell := buf.GetCell(col, row)
+			if cell == nil || cell.Rune() == 0 {
+				line += " "
+			} else {
+				line += string(cell.Rune())
+			}
+		}
+		screen = append(screen, strings.TrimRight(line, " "))
+	}
+	return screen
+}
+
+func TestConformance(t *testing.T) {
+
+	cases := loadConformanceCases(t)
+	report := []string{
+		"# Conformance",
+		"",
+		"Generated by `make conformance` from the cases in `terminal/testdata/conformance`.",
+		"",
+		"| Area | Case | Result |",
+		"| ---- | ---- | ------ |",
+	}
+	passed := 0
+
+	for _, c := range cases {
+		screen := c.run()
+		ok := strings.Join(screen, "\n") == strings.Join(c.grid, "\n")
+		result := "pass"
+		if ok {
+			passed++
+		} else {
+			result = "**fail**"
+		}
+		report = append(report, fmt.Sprintf("| %s | %s | %s |", c.area, c.name, result))
+
+		t.Run(c.area+"/"+c.name, func(t *testing.T) {
+			switch {
+			case ok && c.knownFailure:
+				t.Errorf("Passes, but is marked as a known failure - remove the marker")
+			case !ok && !c.knownFailure:
+				t.Errorf("Screen doesn't match:\nexpected:\n|%s\nactual:\n|%s", strings.Join(c.grid, "\n|"), strings.Join(screen, "\n|"))
+			}
+		})
+	}
+
+	report = append(report, "", fmt.Sprintf("%d of %d cases pass.", passed, len(cases)), "")
+
+	if *conformanceReport != "" {
+		require.Nil(t, ioutil.WriteFile(*conformanceReport, []byte(strings.Join(report, "\n")), 0644))
+	}
+}
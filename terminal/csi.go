@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+
+	"github.com/liamg/aminal/version"
 )
 
 type csiSequenceHandler func(params []string, intermediate string, terminal *Terminal) error
@@ -24,12 +26,16 @@ var csiSequences = []csiMapping{
 	{id: 'c', handler: csiSendDeviceAttributesHandler, description: " Send Device Attributes (Primary/Secondary/Tertiary DA)"},
 	{id: 'd', handler: csiLinePositionAbsolute, expectedParams: &expectedParams{min: 0, max: 1}, description: "Line Position Absolute  [row] (default = [1,column]) (VPA)"},
 	{id: 'f', handler: csiCursorPositionHandler, description: "Horizontal and Vertical Position [row;column] (default = [1,1]) (HVP)"},
-	{id: 'h', handler: csiSetModeHandler, expectedParams: &expectedParams{min: 1, max: 1}, description: "Set Mode (SM)"},
-	{id: 'l', handler: csiResetModeHandler, expectedParams: &expectedParams{min: 1, max: 1}, description: "Reset Mode (RM)"},
+	{id: 'g', handler: csiTabClearHandler, expectedParams: &expectedParams{min: 0, max: 1}, description: "Tab Clear (TBC)"},
+	{id: 'h', handler: csiSetModeHandler, expectedParams: &expectedParams{min: 1, max: 16}, description: "Set Mode (SM)"},
+	{id: 'l', handler: csiResetModeHandler, expectedParams: &expectedParams{min: 1, max: 16}, description: "Reset Mode (RM)"},
 	{id: 'm', handler: sgrSequenceHandler, description: "Character Attributes (SGR)"},
 	{id: 'n', handler: csiDeviceStatusReportHandler, description: "Device Status Report (DSR)"},
+	{id: 'q', handler: csiSetCursorStyleHandler, expectedParams: &expectedParams{min: 0, max: 1}, description: "Set cursor style (DECSCUSR)"},
 	{id: 'r', handler: csiSetMarginsHandler, expectedParams: &expectedParams{min: 0, max: 2}, description: "Set Scrolling Region [top;bottom] (default = full size of window) (DECSTBM), VT100"},
+	{id: 's', handler: csiSaveCursorHandler, expectedParams: &expectedParams{min: 0, max: 0}, description: "Save cursor (SCOSC)"},
 	{id: 't', handler: csiWindowManipulation, description: "Window manipulation"},
+	{id: 'u', handler: csiRestoreCursorHandler, expectedParams: &expectedParams{min: 0, max: 0}, description: "Restore cursor (SCORC)"},
 	{id: 'A', handler: csiCursorUpHandler, description: "Cursor Up Ps Times (default = 1) (CUU)"},
 	{id: 'B', handler: csiCursorDownHandler, description: "Cursor Down Ps Times (default = 1) (CUD)"},
 	{id: 'C', handler: csiCursorForwardHandler, description: "Cursor Forward Ps Times (default = 1) (CUF)"},
@@ -38,6 +44,7 @@ var csiSequences = []csiMapping{
 	{id: 'F', handler: csiCursorPrecedingLineHandler, description: "Cursor Preceding Line Ps Times (default = 1) (CPL)"},
 	{id: 'G', handler: csiCursorCharacterAbsoluteHandler, description: "Cursor Character Absolute  [column] (default = [row,1]) (CHA)"},
 	{id: 'H', handler: csiCursorPositionHandler, description: "Cursor Position [row;column] (default = [1,1]) (CUP)"},
+	{id: 'I', handler: csiCursorForwardTabulationHandler, expectedParams: &expectedParams{min: 0, max: 1}, description: "Cursor Forward Tabulation Ps tab stops (default = 1) (CHT)"},
 	{id: 'J', handler: csiEraseInDisplayHandler, description: "Erase in Display (ED), VT100"},
 	{id: 'K', handler: csiEraseInLineHandler, description: "Erase in Line (EL), VT100"},
 	{id: 'L', handler: csiInsertLinesHandler, description: "Insert Ps Line(s) (default = 1) (IL)"},
@@ -46,28 +53,13 @@ var csiSequences = []csiMapping{
 	{id: 'S', handler: csiScrollUpHandler, description: "Scroll up Ps lines (default = 1) (SU), VT420, ECMA-48"},
 	{id: 'T', handler: csiScrollDownHandler, description: "Scroll down Ps lines (default = 1) (SD), VT420"},
 	{id: 'X', handler: csiEraseCharactersHandler, description: "Erase Ps Character(s) (default = 1) (ECH"},
+	{id: 'Z', handler: csiCursorBackwardTabulationHandler, expectedParams: &expectedParams{min: 0, max: 1}, description: "Cursor Backward Tabulation Ps tab stops (default = 1) (CBT)"},
 	{id: '@', handler: csiInsertBlankCharactersHandler, description: "Insert Ps (Blank) Character(s) (default = 1) (ICH)"},
 }
 
-func csiHandler(pty chan rune, terminal *Terminal) error {
-	var final rune
-	var b rune
-	param := ""
-	intermediate := ""
-CSI:
-	for {
-		b = <-pty
-		switch true {
-		case b >= 0x30 && b <= 0x3F:
-			param = param + string(b)
-		case b >= 0x20 && b <= 0x2F:
-			//intermediate? useful?
-			intermediate += string(b)
-		case b >= 0x40 && b <= 0x7e:
-			final = b
-			break CSI
-		}
-	}
+// csiDispatch carries out a CSI sequence once the parser has read its final character. Private markers such as ? are
+// left at the start of param, so they end up in the first of the params passed to the handler.
+func csiDispatch(param string, intermediate string, final rune, terminal *Terminal) error {
 
 	params := strings.Split(param, ";")
 	if param == "" {
@@ -89,14 +81,48 @@ CSI:
 	return fmt.Errorf("Unknown CSI control sequence: 0x%02X (ESC[%s%s%s)", final, param, intermediate, string(final))
 }
 
+// primaryDeviceAttributes is the reply to DA1: a VT220 (62) with sixel graphics (4) and ANSI colour (22)
+const primaryDeviceAttributes = "\x1b[?62;4;22c"
+
+// tertiaryDeviceAttributes is the reply to DA3, DECRPTUI, with a unit ID of zeros as xterm does
+const tertiaryDeviceAttributes = "\x1bP!|00000000\x1b\\"
+
 func csiSendDeviceAttributesHandler(params []string, intermediate string, terminal *Terminal) error {
 
-	if len(params) > 0 && len(params[0]) > 0 && params[0][0] == '>' { // secondary
-		_ = terminal.Write([]byte("\x1b[0;0;0c")) // report VT100
-		return nil
+	param := ""
+	if len(params) > 0 {
+		param = params[0]
 	}
 
-	return fmt.Errorf("Unsupported SDA identifier")
+	switch param {
+	case "", "0": // primary
+		_ = terminal.respond([]byte(primaryDeviceAttributes))
+	case ">", ">0": // secondary: the terminal type, a VT220, and the version
+		_ = terminal.respond([]byte(fmt.Sprintf("\x1b[>1;%d;0c", versionNumber(version.Version))))
+	case "=", "=0": // tertiary
+		_ = terminal.respond([]byte(tertiaryDeviceAttributes))
+	default:
+		return fmt.Errorf("Unsupported Device Attributes request: %s", strings.Join(params, ";"))
+	}
+	return nil
+}
+
+// versionNumber turns a version like v1.2.3 into a number like 10203 for DA2, or 0 if it isn't in that form, e.g. in
+// development builds
+func versionNumber(v string) int {
+	parts := strings.Split(strings.TrimPrefix(v, "v"), ".")
+	if len(parts) != 3 {
+		return 0
+	}
+	n := 0
+	for _, part := range parts {
+		p, err := strconv.Atoi(part)
+		if err != nil || p < 0 || p > 99 {
+			return 0
+		}
+		n = n*100 + p
+	}
+	return n
 }
 
 func csiDeviceStatusReportHandler(params []string, intermediate string, terminal *Terminal) error {
@@ -107,13 +133,13 @@ func csiDeviceStatusReportHandler(params []string, intermediate string, terminal
 
 	switch params[0] {
 	case "5":
-		_ = terminal.Write([]byte("\x1b[0n")) // everything is cool
-	case "6": // report cursor position
-		_ = terminal.Write([]byte(fmt.Sprintf(
-			"\x1b[%d;%dR",
-			terminal.ActiveBuffer().CursorLine()+1,
-			terminal.ActiveBuffer().CursorColumn()+1,
-		)))
+		_ = terminal.respond([]byte("\x1b[0n")) // everything is cool
+	case "6": // report cursor position (CPR)
+		line, col := terminal.reportedCursorPosition()
+		_ = terminal.respond([]byte(fmt.Sprintf("\x1b[%d;%dR", line, col)))
+	case "?6": // report cursor position with the page, which is always the first (DECXCPR)
+		line, col := terminal.reportedCursorPosition()
+		_ = terminal.respond([]byte(fmt.Sprintf("\x1b[?%d;%d;1R", line, col)))
 	default:
 		return fmt.Errorf("Unknown Device Status Report identifier: %s", params[0])
 	}
@@ -121,6 +147,21 @@ func csiDeviceStatusReportHandler(params []string, intermediate string, terminal
 	return nil
 }
 
+// reportedCursorPosition returns the cursor position as programs see it: counted from 1, from the top margin in origin
+// mode, and in the last column while a wrap is pending
+func (terminal *Terminal) reportedCursorPosition() (uint, uint) {
+	buf := terminal.ActiveBuffer()
+	line := uint(buf.CursorLine())
+	if buf.OriginMode() && line >= buf.TopMargin() {
+		line -= buf.TopMargin()
+	}
+	col := buf.CursorColumn()
+	if col >= buf.ViewWidth() && buf.ViewWidth() > 0 {
+		col = buf.ViewWidth() - 1
+	}
+	return line + 1, uint(col) + 1
+}
+
 func csiCursorUpHandler(params []string, intermediate string, terminal *Terminal) error {
 	distance := 1
 	if len(params) > 0 {
@@ -239,7 +280,7 @@ func csiCursorPositionHandler(params []string, intermediate string, terminal *Te
 		}
 	}
 
-	terminal.ActiveBuffer().SetPosition(uint16(x-1), uint16(y-1))
+	terminal.ActiveBuffer().MoveTo(uint16(x-1), uint16(y-1))
 	return nil
 }
 
@@ -331,6 +372,16 @@ func csiScrollDownHandler(params []string, intermediate string, terminal *Termin
 	return nil
 }
 
+func csiSaveCursorHandler(params []string, intermediate string, terminal *Terminal) error {
+	terminal.ActiveBuffer().SaveCursor()
+	return nil
+}
+
+func csiRestoreCursorHandler(params []string, intermediate string, terminal *Terminal) error {
+	terminal.ActiveBuffer().RestoreCursor()
+	return nil
+}
+
 // DECSTBM
 func csiSetMarginsHandler(params []string, intermediate string, terminal *Terminal) error {
 	top := 1
@@ -355,11 +406,15 @@ func csiSetMarginsHandler(params []string, intermediate string, terminal *Termin
 			}
 		}
 	}
+	// the region must be at least two lines, otherwise the sequence is ignored
+	if top >= bottom {
+		return fmt.Errorf("Invalid margins %d;%d: top should be above bottom", top, bottom)
+	}
 	top--
 	bottom--
 
 	terminal.ActiveBuffer().SetVerticalMargins(uint(top), uint(bottom))
-	terminal.ActiveBuffer().SetPosition(0, 0)
+	terminal.ActiveBuffer().MoveTo(0, 0)
 
 	return nil
 }
@@ -380,15 +435,26 @@ func csiEraseCharactersHandler(params []string, intermediate string, terminal *T
 }
 
 func csiResetModeHandler(params []string, intermediate string, terminal *Terminal) error {
-	return csiSetMode(strings.Join(params, ""), false, terminal)
+	return csiSetModes(params, false, terminal)
 }
 
 func csiSetModeHandler(params []string, intermediate string, terminal *Terminal) error {
-	return csiSetMode(strings.Join(params, ""), true, terminal)
+	return csiSetModes(params, true, terminal)
 }
 
-func csiWindowManipulation(params []string, intermediate string, terminal *Terminal) error {
-	return fmt.Errorf("Window manipulation is not yet supported")
+// csiSetModes applies each mode in a sequence such as CSI ? 1000 ; 1006 h - the ? applies to all of them
+func csiSetModes(params []string, enabled bool, terminal *Terminal) error {
+	private := strings.HasPrefix(params[0], "?")
+	var firstErr error
+	for _, param := range params {
+		if private && !strings.HasPrefix(param, "?") {
+			param = "?" + param
+		}
+		if err := csiSetMode(param, enabled, terminal); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
 }
 
 func csiLinePositionAbsolute(params []string, intermediate string, terminal *Terminal) error {
@@ -401,7 +467,7 @@ func csiLinePositionAbsolute(params []string, intermediate string, terminal *Ter
 		}
 	}
 
-	terminal.ActiveBuffer().SetPosition(terminal.ActiveBuffer().CursorColumn(), uint16(row-1))
+	terminal.ActiveBuffer().MoveTo(terminal.ActiveBuffer().CursorColumn(), uint16(row-1))
 
 	return nil
 }
@@ -462,3 +528,76 @@ func csiEraseInLineHandler(params []string, intermediate string, terminal *Termi
 	}
 	return nil
 }
+
+// cursorStyles are the shapes of DECSCUSR, and whether they blink. 0 is aminal's default cursor.
+var cursorStyles = map[string]struct {
+	shape CursorShape
+	blink bool
+}{
+	"0": {CursorBlock, false},
+	"1": {CursorBlock, true},
+	"2": {CursorBlock, false},
+	"3": {CursorUnderline, true},
+	"4": {CursorUnderline, false},
+	"5": {CursorBar, true},
+	"6": {CursorBar, false},
+}
+
+// CSI Ps SP q
+func csiSetCursorStyleHandler(params []string, intermediate string, terminal *Terminal) error {
+	if intermediate != " " {
+		return fmt.Errorf("Unsupported CSI %s q", intermediate)
+	}
+	style := "0"
+	if len(params) > 0 && params[0] != "" {
+		style = params[0]
+	}
+	s, ok := cursorStyles[style]
+	if !ok {
+		return fmt.Errorf("Unsupported cursor style %s", style)
+	}
+	terminal.modes.CursorShape = s.shape
+	terminal.modes.BlinkingCursor = s.blink
+	terminal.SetDirty()
+	return nil
+}
+
+// CSI Ps g
+func csiTabClearHandler(params []string, intermediate string, terminal *Terminal) error {
+	mode := "0"
+	if len(params) > 0 && params[0] != "" {
+		mode = params[0]
+	}
+	switch mode {
+	case "0":
+		terminal.ActiveBuffer().ClearTabStop()
+	case "3":
+		terminal.ActiveBuffer().ClearAllTabStops()
+	default:
+		return fmt.Errorf("Unsupported tab clear mode %s", mode)
+	}
+	return nil
+}
+
+func csiCursorForwardTabulationHandler(params []string, intermediate string, terminal *Terminal) error {
+	terminal.ActiveBuffer().TabForward(tabCount(params))
+	return nil
+}
+
+func csiCursorBackwardTabulationHandler(params []string, intermediate string, terminal *Terminal) error {
+	terminal.ActiveBuffer().TabBackward(tabCount(params))
+	return nil
+}
+
+// tabCount returns the number of tab stops asked for by CHT or CBT
+func tabCount(params []string) int {
+	count := 1
+	if len(params) > 0 {
+		var err error
+		count, err = strconv.Atoi(params[0])
+		if err != nil || count < 1 {
+			count = 1
+		}
+	}
+	return count
+}
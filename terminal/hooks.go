@@ -0,0 +1,49 @@
+package terminal
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// UserEvent is raised by a program with an escape sequence, for the hook command or the control socket to act on
+type UserEvent struct {
+	Type  string // "user_var" for OSC 1337 SetUserVar, "osc" for the custom OSC in the hooks config, or "command_start" and "command_done" for OSC 133;C and 133;D
+	Name  string // the variable's name, for SetUserVar
+	Value string // the variable's decoded value, the custom OSC's payload, the command line if the shell sent one with command_start, or the exit status for command_done
+}
+
+// UserEvents returns the channel events raised by programs are sent to
+func (terminal *Terminal) UserEvents() <-chan UserEvent {
+	return terminal.userEvents
+}
+
+func (terminal *Terminal) raiseUserEvent(event UserEvent) {
+	if terminal.SecretInput() {
+		// nothing should be acting on what is shown while a password is typed
+		terminal.logger.Debugf("Dropped %s %s during secret input", event.Type, event.Name)
+		return
+	}
+	select {
+	case terminal.userEvents <- event:
+	default:
+		terminal.logger.Errorf("Too many user events waiting, dropped %s %s", event.Type, event.Name)
+	}
+}
+
+// userVarOSC handles OSC 1337 SetUserVar=name=value, where the value is base64 encoded
+func (terminal *Terminal) userVarOSC(pT string) error {
+	if !strings.HasPrefix(pT, "SetUserVar=") {
+		return fmt.Errorf("Unsupported OSC 1337 command: %s", pT)
+	}
+	parts := strings.SplitN(strings.TrimPrefix(pT, "SetUserVar="), "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return fmt.Errorf("Invalid SetUserVar: %s", pT)
+	}
+	value, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return fmt.Errorf("Invalid SetUserVar value for %s: %s", parts[0], err)
+	}
+	terminal.raiseUserEvent(UserEvent{Type: "user_var", Name: parts[0], Value: string(value)})
+	return nil
+}
@@ -0,0 +1,89 @@
+package terminal
+
+import (
+	"testing"
+
+	"github.com/liamg/aminal/buffer"
+	"github.com/liamg/aminal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestSetUserVarRaisesEvent(t *testing.T) {
+	term, _ := newTestTerminal()
+
+	// "build done" in base64
+	require.Nil(t, parse(term, "\x1b]1337;SetUserVar=status=YnVpbGQgZG9uZQ==\x07"))
+	require.Len(t, term.UserEvents(), 1)
+	assert.Equal(t, UserEvent{Type: "user_var", Name: "status", Value: "build done"}, <-term.UserEvents())
+
+	assert.NotNil(t, parse(term, "\x1b]1337;SetUserVar=status=not base64\x07"))
+	assert.NotNil(t, parse(term, "\x1b]1337;SetUserVar=\x07"))
+	assert.NotNil(t, parse(term, "\x1b]1337;File=inline=1:AAAA\x07"))
+	assert.Len(t, term.UserEvents(), 0)
+}
+
+func TestCustomOSCRaisesEvent(t *testing.T) {
+	term, _ := newTestTerminal()
+
+	assert.NotNil(t, parse(term, "\x1b]7777;deploy;prod\x07"))
+	assert.Len(t, term.UserEvents(), 0)
+
+	term.config.Hooks.OSC = 7777
+	require.Nil(t, parse(term, "\x1b]7777;deploy;prod\x07"))
+	require.Len(t, term.UserEvents(), 1)
+	assert.Equal(t, UserEvent{Type: "osc", Value: "deploy;prod"}, <-term.UserEvents())
+}
+
+func TestShellIntegrationRaisesCommandEvents(t *testing.T) {
+	term, _ := newTestTerminal()
+
+	require.Nil(t, parse(term, "\x1b]133;A\x07$ \x1b]133;B\x07make\r\n\x1b]133;C;cmdline=make\x07"))
+	require.Nil(t, parse(term, "out\r\n\x1b]133;D;2\x07\x1b]133;C\x07\x1b]133;D\x07"))
+	require.Len(t, term.UserEvents(), 4)
+	assert.Equal(t, UserEvent{Type: "command_start", Value: "make"}, <-term.UserEvents())
+	assert.Equal(t, UserEvent{Type: "command_done", Value: "2"}, <-term.UserEvents())
+	assert.Equal(t, UserEvent{Type: "command_start"}, <-term.UserEvents())
+	assert.Equal(t, UserEvent{Type: "command_done"}, <-term.UserEvents())
+}
+
+func TestFailedCommandsAndBellsAreLandmarks(t *testing.T) {
+	term, _ := newTestTerminal()
+	require.Nil(t, term.SetSize(20, 5))
+
+	require.Nil(t, parse(term, "\x1b]133;A\x07$ make\r\n\x1b]133;C\x07out\a\r\n\x1b]133;D;2\x07"))
+	require.Nil(t, parse(term, "\x1b]133;A\x07$ true\r\n\x1b]133;C\x07\x1b]133;D;0\x07"))
+
+	assert.Equal(t, []buffer.Landmark{
+		{Line: 0, Kind: buffer.LandmarkPrompt},
+		{Line: 0, Kind: buffer.LandmarkError},
+		{Line: 1, Kind: buffer.LandmarkBell},
+		{Line: 2, Kind: buffer.LandmarkPrompt},
+	}, term.ActiveBuffer().Landmarks())
+}
+
+// secretPty is a pty at a password prompt
+type secretPty struct {
+	recordingPty
+}
+
+func (pty *secretPty) SecretInput() bool {
+	return true
+}
+
+func TestSecretInputDropsEventsAndClipboard(t *testing.T) {
+	conf := config.DefaultConfig
+	term := New(&secretPty{}, zap.NewNop().Sugar(), &conf)
+
+	require.True(t, term.SecretInput())
+	require.Nil(t, parse(term, "\x1b]1337;SetUserVar=status=YnVpbGQgZG9uZQ==\x07"))
+	assert.Len(t, term.UserEvents(), 0)
+	assert.NotNil(t, parse(term, "\x1b]52;c;aGVsbG8=\x07"))
+	assert.Len(t, term.Requests(), 0)
+
+	conf.Security.SecretInputGuard = false
+	assert.False(t, term.SecretInput())
+	require.Nil(t, parse(term, "\x1b]1337;SetUserVar=status=YnVpbGQgZG9uZQ==\x07"))
+	assert.Len(t, term.UserEvents(), 1)
+}
@@ -0,0 +1,185 @@
+package terminal
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif" // formats for iTerm2 inline images
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/liamg/aminal/buffer"
+)
+
+// imageCursor is where the cursor goes after an image is placed
+type imageCursor int
+
+const (
+	cursorBesideImage imageCursor = iota // to the right of the image's last line, as kitty and iTerm2 do
+	cursorBelowImage                     // to the start of the line below the image, as sixel does
+	cursorStays                          // where it was, for kitty's C=1
+)
+
+// checkCellSize returns an error if images can't be sized in cells yet, before the window has been laid out
+func (terminal *Terminal) checkCellSize() error {
+	if terminal.charWidth <= 0 || terminal.charHeight <= 0 {
+		return fmt.Errorf("Cannot place an image before the size of a cell is known")
+	}
+	return nil
+}
+
+// fitToScreen scales an image down to fit on the screen, as there is no point keeping more of it than can be seen
+func (terminal *Terminal) fitToScreen(img *image.RGBA) *image.RGBA {
+	return fitImage(
+		img,
+		int(float32(terminal.ActiveBuffer().ViewWidth())*terminal.charWidth),
+		int(float32(terminal.ActiveBuffer().ViewHeight())*terminal.charHeight),
+	)
+}
+
+// placeImage shows an image at the cursor covering width by height cells, scrolling to make room for it unless the
+// cursor stays put
+func (terminal *Terminal) placeImage(img *image.RGBA, id uint32, width float32, height float32, cursor imageCursor) {
+	buf := terminal.ActiveBuffer()
+	x := buf.CursorColumn()
+	placed := &buffer.Image{ID: id, Pixels: img, Width: width, Height: height}
+	buf.PlaceImage(placed)
+
+	if cursor == cursorStays {
+		return
+	}
+	for i := 1; i < placed.Rows(); i++ {
+		buf.Index()
+	}
+	if cursor == cursorBelowImage {
+		buf.NewLine()
+		return
+	}
+	buf.SetPosition(x+uint16(placed.Cols()), buf.CursorLine())
+}
+
+// decodeImage decodes a PNG, JPEG or GIF, refusing those so big they would exhaust memory before they are scaled down
+func decodeImage(data []byte) (*image.RGBA, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if err := checkImageSize(config.Width, config.Height); err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return toRGBA(img), nil
+}
+
+func checkImageSize(width int, height int) error {
+	if width <= 0 || height <= 0 || width*height > maxImagePixels {
+		return fmt.Errorf("Invalid image size %dx%d: should be at most %d pixels", width, height, maxImagePixels)
+	}
+	return nil
+}
+
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Bounds().Min == (image.Point{}) {
+		return rgba
+	}
+	rgba := image.NewRGBA(image.Rect(0, 0, img.Bounds().Dx(), img.Bounds().Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+	return rgba
+}
+
+// fitCells returns the size in cells to show an image of the given size in pixels, given a size in cells for either
+// or both dimensions, or 0 to work it out. Missing dimensions keep the image's aspect ratio, as does fitting it in
+// both if preserveAspect is set. Images are shrunk to fit on the screen, whatever size is asked for.
+func (terminal *Terminal) fitCells(pixelWidth int, pixelHeight int, width float32, height float32, preserveAspect bool) (float32, float32) {
+	naturalWidth := float32(pixelWidth) / terminal.charWidth
+	naturalHeight := float32(pixelHeight) / terminal.charHeight
+
+	switch {
+	case width == 0 && height == 0:
+		width, height = naturalWidth, naturalHeight
+	case width == 0:
+		width = height * naturalWidth / naturalHeight
+	case height == 0:
+		height = width * naturalHeight / naturalWidth
+	case preserveAspect:
+		scale := float32(math.Min(float64(width/naturalWidth), float64(height/naturalHeight)))
+		width, height = naturalWidth*scale, naturalHeight*scale
+	}
+
+	scale := math.Min(
+		float64(terminal.ActiveBuffer().ViewWidth())/float64(width),
+		float64(terminal.ActiveBuffer().ViewHeight())/float64(height),
+	)
+	if scale < 1 {
+		width, height = width*float32(scale), height*float32(scale)
+	}
+	return width, height
+}
+
+// inlineImageOSC shows an image sent with iTerm2's OSC 1337;File=args:data, where args are key=value pairs separated
+// by semicolons, e.g. width=10;height=50%;inline=1
+func (terminal *Terminal) inlineImageOSC(args string, data *base64Stream) error {
+
+	if err := terminal.checkCellSize(); err != nil {
+		return err
+	}
+
+	options := map[string]string{}
+	for _, arg := range strings.Split(strings.TrimPrefix(args, "File="), ";") {
+		if parts := strings.SplitN(arg, "=", 2); len(parts) == 2 {
+			options[parts[0]] = parts[1]
+		}
+	}
+	if options["inline"] != "1" {
+		return fmt.Errorf("Unsupported iTerm2 file transfer: only inline images are shown")
+	}
+
+	width, err := inlineImageDimension(options["width"], terminal.charWidth, terminal.ActiveBuffer().ViewWidth())
+	if err != nil {
+		return err
+	}
+	height, err := inlineImageDimension(options["height"], terminal.charHeight, terminal.ActiveBuffer().ViewHeight())
+	if err != nil {
+		return err
+	}
+
+	decoded, err := data.Bytes()
+	if err != nil {
+		return fmt.Errorf("Invalid inline image data: %s", err)
+	}
+	img, err := decodeImage(decoded)
+	if err != nil {
+		return fmt.Errorf("Failed to decode inline image: %s", err)
+	}
+
+	width, height = terminal.fitCells(img.Bounds().Dx(), img.Bounds().Dy(), width, height, options["preserveAspectRatio"] != "0")
+	terminal.placeImage(terminal.fitToScreen(img), 0, width, height, cursorBesideImage)
+	return nil
+}
+
+// inlineImageDimension converts an iTerm2 image width or height to cells. It is N cells, Npx, N% of the screen, or
+// auto (or missing) for 0.
+func inlineImageDimension(spec string, cellSize float32, screenCells uint16) (float32, error) {
+	if spec == "" || spec == "auto" {
+		return 0, nil
+	}
+	unit := float32(1)
+	switch {
+	case strings.HasSuffix(spec, "px"):
+		spec, unit = strings.TrimSuffix(spec, "px"), 1/cellSize
+	case strings.HasSuffix(spec, "%"):
+		spec, unit = strings.TrimSuffix(spec, "%"), float32(screenCells)/100
+	}
+	n, err := strconv.ParseFloat(spec, 32)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("Invalid inline image size: %s", spec)
+	}
+	return float32(n) * unit, nil
+}
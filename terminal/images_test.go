@@ -0,0 +1,63 @@
+package terminal
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodePNG(width int, height int) string {
+	var data bytes.Buffer
+	_ = png.Encode(&data, image.NewRGBA(image.Rect(0, 0, width, height)))
+	return base64.StdEncoding.EncodeToString(data.Bytes())
+}
+
+func TestInlineImagesAreShown(t *testing.T) {
+	term, _ := newImageTestTerminal()
+
+	require.Nil(t, parse(term, "\x1b]1337;File=name=eC5wbmc=;inline=1:"+encodePNG(40, 40)+"\x07"))
+	placed := term.ActiveBuffer().VisibleImages()
+	require.Len(t, placed, 1)
+	assert.Equal(t, float32(4), placed[0].Width)
+	assert.Equal(t, float32(2), placed[0].Height)
+
+	// a width alone keeps the aspect ratio
+	require.Nil(t, parse(term, "\r\n\x1b]1337;File=width=2;inline=1:"+encodePNG(40, 40)+"\x1b\\"))
+	placed = term.ActiveBuffer().VisibleImages()
+	require.Len(t, placed, 2)
+	assert.Equal(t, float32(2), placed[1].Width)
+	assert.Equal(t, float32(1), placed[1].Height)
+
+	// files which aren't inline are downloads, which aren't supported
+	assert.NotNil(t, parse(term, "\x1b]1337;File=name=eC5wbmc=:"+encodePNG(1, 1)+"\x07"))
+	assert.Len(t, term.ActiveBuffer().VisibleImages(), 2)
+}
+
+func TestInlineImageDimensions(t *testing.T) {
+	for spec, cells := range map[string]float32{"": 0, "auto": 0, "3": 3, "50px": 5, "50%": 40} {
+		n, err := inlineImageDimension(spec, 10, 80)
+		require.Nil(t, err, spec)
+		assert.Equal(t, cells, n, spec)
+	}
+	_, err := inlineImageDimension("-1", 10, 80)
+	assert.NotNil(t, err)
+	_, err = inlineImageDimension("big", 10, 80)
+	assert.NotNil(t, err)
+}
+
+func TestImagesAreShrunkToFitTheScreen(t *testing.T) {
+	term, _ := newImageTestTerminal()
+
+	width, height := term.fitCells(400, 100, 0, 0, true)
+	assert.Equal(t, float32(20), width)
+	assert.Equal(t, float32(2.5), height)
+
+	width, height = term.fitCells(10, 20, 100, 50, false)
+	assert.Equal(t, float32(20), width)
+	assert.Equal(t, float32(10), height)
+}
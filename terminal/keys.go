@@ -0,0 +1,445 @@
+package terminal
+
+import (
+	"fmt"
+	"unicode"
+
+	"github.com/liamg/aminal/config"
+)
+
+// Key is a key pressed on the keyboard, as far as encoding it for the pty goes. Keys which type a character on a US
+// keyboard are numbered by that character, with letters in upper case, so ctrl can send control characters from
+// layouts without latin letters. The others are numbered from KeyEscape.
+type Key int
+
+const (
+	KeyUnknown Key = 0
+	KeySpace   Key = ' '
+)
+
+const (
+	KeyEscape Key = 256 + iota
+	KeyEnter
+	KeyTab
+	KeyBackspace
+	KeyInsert
+	KeyDelete
+	KeyRight
+	KeyLeft
+	KeyDown
+	KeyUp
+	KeyPageUp
+	KeyPageDown
+	KeyHome
+	KeyEnd
+	KeyF1
+	KeyF2
+	KeyF3
+	KeyF4
+	KeyF5
+	KeyF6
+	KeyF7
+	KeyF8
+	KeyF9
+	KeyF10
+	KeyF11
+	KeyF12
+	KeyF13
+	KeyF14
+	KeyF15
+	KeyF16
+	KeyF17
+	KeyF18
+	KeyF19
+	KeyF20
+	KeyF21
+	KeyF22
+	KeyF23
+	KeyF24
+	KeyKP0
+	KeyKP1
+	KeyKP2
+	KeyKP3
+	KeyKP4
+	KeyKP5
+	KeyKP6
+	KeyKP7
+	KeyKP8
+	KeyKP9
+	KeyKPDecimal
+	KeyKPDivide
+	KeyKPMultiply
+	KeyKPSubtract
+	KeyKPAdd
+	KeyKPEnter
+	KeyKPEqual
+)
+
+// Modifiers are the modifier keys held with a key or character
+type Modifiers int
+
+const (
+	ModShift Modifiers = 1 << iota
+	ModControl
+	ModAlt
+	ModSuper
+)
+
+// the final bytes of the cursor keys, which are sent as CSI or SS3 sequences
+var cursorKeyFinals = map[Key]byte{
+	KeyUp:    'A',
+	KeyDown:  'B',
+	KeyRight: 'C',
+	KeyLeft:  'D',
+	KeyHome:  'H',
+	KeyEnd:   'F',
+}
+
+// the final bytes of F1 to F4, which xterm sends as SS3 sequences
+var pfKeyFinals = map[Key]byte{
+	KeyF1: 'P',
+	KeyF2: 'Q',
+	KeyF3: 'R',
+	KeyF4: 'S',
+}
+
+// the numbers of the keys which are sent as CSI n ~
+var tildeKeyNumbers = map[Key]int{
+	KeyInsert:   2,
+	KeyDelete:   3,
+	KeyPageUp:   5,
+	KeyPageDown: 6,
+	KeyF5:       15,
+	KeyF6:       17,
+	KeyF7:       18,
+	KeyF8:       19,
+	KeyF9:       20,
+	KeyF10:      21,
+	KeyF11:      23,
+	KeyF12:      24,
+}
+
+// rxvt sends home, end and F1 to F4 as CSI n ~ too
+var urxvtTildeKeyNumbers = map[Key]int{
+	KeyHome: 7,
+	KeyEnd:  8,
+	KeyF1:   11,
+	KeyF2:   12,
+	KeyF3:   13,
+	KeyF4:   14,
+}
+
+// rxvt's F13 to F24, which follow on from F12 and then reuse the shifted and ctrl-ed sequences of earlier keys
+var urxvtHighFunctionKeys = map[Key]string{
+	KeyF13: "25~",
+	KeyF14: "26~",
+	KeyF15: "28~",
+	KeyF16: "29~",
+	KeyF17: "31~",
+	KeyF18: "32~",
+	KeyF19: "33~",
+	KeyF20: "34~",
+	KeyF21: "23$",
+	KeyF22: "24$",
+	KeyF23: "11^",
+	KeyF24: "12^",
+}
+
+// the final bytes of the SS3 sequences the keypad sends in application keypad mode
+var keypadApplicationFinals = map[Key]byte{
+	KeyKP0:        'p',
+	KeyKP1:        'q',
+	KeyKP2:        'r',
+	KeyKP3:        's',
+	KeyKP4:        't',
+	KeyKP5:        'u',
+	KeyKP6:        'v',
+	KeyKP7:        'w',
+	KeyKP8:        'x',
+	KeyKP9:        'y',
+	KeyKPDecimal:  'n',
+	KeyKPDivide:   'o',
+	KeyKPMultiply: 'j',
+	KeyKPSubtract: 'm',
+	KeyKPAdd:      'k',
+	KeyKPEnter:    'M',
+	KeyKPEqual:    'X',
+}
+
+// the keys the keypad's digits act as without num lock
+var keypadNavigationKeys = map[Key]Key{
+	KeyKP0:       KeyInsert,
+	KeyKP1:       KeyEnd,
+	KeyKP2:       KeyDown,
+	KeyKP3:       KeyPageDown,
+	KeyKP4:       KeyLeft,
+	KeyKP6:       KeyRight,
+	KeyKP7:       KeyHome,
+	KeyKP8:       KeyUp,
+	KeyKP9:       KeyPageUp,
+	KeyKPDecimal: KeyDelete,
+}
+
+// kitty's keyboard protocol numbers F13 onwards in the unicode private use area
+const kittyF13 = 57376
+
+// the codes kitty sends for keys which send control characters, when they have modifiers
+var kittyKeyCodes = map[Key]int{
+	KeyEnter:     13,
+	KeyTab:       9,
+	KeyBackspace: 127,
+}
+
+// EncodeKey returns the bytes to send to the pty for a key press, in the configured encoding, or nil if there is
+// nothing to send for it here - keys which type characters are sent by the character callback instead. The name is
+// the character the key types on the current layout, if any. Keypad digits are encoded as they are without num
+// lock, as the window system may not report it - with it on, they type digits, which should be sent instead.
+func (terminal *Terminal) EncodeKey(key Key, name string, mods Modifiers) []byte {
+	if final, ok := keypadApplicationFinals[key]; ok && terminal.IsApplicationKeypadModeEnabled() {
+		return []byte{0x1b, 'O', final}
+	}
+	if key == KeyKP5 {
+		// the middle of the keypad has nothing to do without num lock, so it sends xterm's "begin" key
+		return []byte("\x1b[E")
+	}
+	if equivalent, ok := keypadNavigationKeys[key]; ok {
+		key = equivalent
+	}
+	switch terminal.config.Input.Encoding {
+	case config.EncodingURxvt:
+		return encodeURxvtKey(key, name, mods, terminal.modes)
+	case config.EncodingKitty:
+		return encodeKittyKey(key, name, mods, terminal.modes)
+	}
+	return encodeXtermKey(key, name, mods, terminal.modes)
+}
+
+func encodeXtermKey(key Key, name string, mods Modifiers, modes Modes) []byte {
+	if key >= KeyF13 && key <= KeyF24 {
+		// xterm sends F13 to F24 as shifted F1 to F12
+		return encodeXtermKey(key-12, name, mods|ModShift, modes)
+	}
+	if seq := encodeFunctionKey(key, mods, modes.ApplicationCursorKeys); seq != nil {
+		return seq
+	}
+	if seq := encodeEditingKey(key, mods); seq != nil {
+		return modes.withMeta(seq, mods)
+	}
+	return modes.withMeta(controlCode(key, name, mods), mods)
+}
+
+func encodeURxvtKey(key Key, name string, mods Modifiers, modes Modes) []byte {
+	if n, ok := urxvtTildeKeyNumbers[key]; ok {
+		return []byte(fmt.Sprintf("\x1b[%d%c", n, urxvtSuffix(mods)))
+	}
+	if n, ok := tildeKeyNumbers[key]; ok {
+		return []byte(fmt.Sprintf("\x1b[%d%c", n, urxvtSuffix(mods)))
+	}
+	if seq, ok := urxvtHighFunctionKeys[key]; ok {
+		return []byte("\x1b[" + seq)
+	}
+	if final, ok := cursorKeyFinals[key]; ok {
+		// rxvt marks shifted and ctrl-ed arrows by making the final byte lower case
+		switch mods {
+		case ModShift:
+			return []byte{0x1b, '[', final + 'a' - 'A'}
+		case ModControl:
+			return []byte{0x1b, 'O', final + 'a' - 'A'}
+		}
+		return encodeFunctionKey(key, 0, modes.ApplicationCursorKeys)
+	}
+	if seq := encodeEditingKey(key, mods); seq != nil {
+		return modes.withMeta(seq, mods)
+	}
+	return modes.withMeta(controlCode(key, name, mods), mods)
+}
+
+// urxvtSuffix returns the final byte rxvt uses for CSI n ~ keys with modifiers held
+func urxvtSuffix(mods Modifiers) byte {
+	switch mods & (ModShift | ModControl) {
+	case ModShift:
+		return '$'
+	case ModControl:
+		return '^'
+	case ModShift | ModControl:
+		return '@'
+	}
+	return '~'
+}
+
+func encodeKittyKey(key Key, name string, mods Modifiers, modes Modes) []byte {
+	m := modifierParam(mods)
+	if key >= KeyF13 && key <= KeyF24 {
+		return csiU(kittyF13+int(key-KeyF13), m)
+	}
+	switch key {
+	case KeyEscape:
+		// an escape key press can't be mistaken for the start of a sequence
+		return csiU(27, m)
+	case KeyEnter, KeyTab, KeyBackspace:
+		if m > 1 {
+			return csiU(kittyKeyCodes[key], m)
+		}
+	}
+	if seq := encodeFunctionKey(key, mods, modes.ApplicationCursorKeys); seq != nil {
+		return seq
+	}
+	if seq := encodeEditingKey(key, mods); seq != nil {
+		return seq
+	}
+	if runes := []rune(name); mods&(ModControl|ModAlt) != 0 && len(runes) == 1 {
+		return csiU(int(unicode.ToLower(runes[0])), m)
+	}
+	return nil
+}
+
+func csiU(code int, m int) []byte {
+	if m > 1 {
+		return []byte(fmt.Sprintf("\x1b[%d;%du", code, m))
+	}
+	return []byte(fmt.Sprintf("\x1b[%du", code))
+}
+
+// encodeFunctionKey returns the xterm sequence for cursor, editing and function keys, or nil for other keys
+func encodeFunctionKey(key Key, mods Modifiers, appCursor bool) []byte {
+	m := modifierParam(mods)
+	if final, ok := cursorKeyFinals[key]; ok {
+		if m > 1 {
+			return []byte(fmt.Sprintf("\x1b[1;%d%c", m, final))
+		}
+		if appCursor {
+			return []byte{0x1b, 'O', final}
+		}
+		return []byte{0x1b, '[', final}
+	}
+	if final, ok := pfKeyFinals[key]; ok {
+		if m > 1 {
+			return []byte(fmt.Sprintf("\x1b[1;%d%c", m, final))
+		}
+		return []byte{0x1b, 'O', final}
+	}
+	if n, ok := tildeKeyNumbers[key]; ok {
+		if m > 1 {
+			return []byte(fmt.Sprintf("\x1b[%d;%d~", n, m))
+		}
+		return []byte(fmt.Sprintf("\x1b[%d~", n))
+	}
+	return nil
+}
+
+// encodeEditingKey returns the control characters sent by escape, enter, tab and backspace, or nil for other keys
+func encodeEditingKey(key Key, mods Modifiers) []byte {
+	switch key {
+	case KeyEscape:
+		return []byte{0x1b}
+	case KeyEnter, KeyKPEnter:
+		return []byte{0x0d}
+	case KeyTab:
+		if mods&^ModAlt == ModShift {
+			return []byte("\x1b[Z")
+		}
+		return []byte{0x09}
+	case KeyBackspace:
+		if mods&^ModAlt == ModControl {
+			return []byte{0x08}
+		}
+		return []byte{0x7f}
+	}
+	return nil
+}
+
+// controlCode returns the control character ctrl sends with a key, e.g. ^C, or nil if there isn't one. Alt may be
+// held too, for the caller to apply.
+func controlCode(key Key, name string, mods Modifiers) []byte {
+	if mods&^ModAlt != ModControl {
+		return nil
+	}
+	r := keyCharacter(key, name)
+	if r >= 'a' && r <= 'z' {
+		return []byte{byte(r) - 'a' + 1}
+	}
+	if c, ok := controlCharacters[r]; ok {
+		return []byte{c}
+	}
+	return nil
+}
+
+// controlCharacters are the control characters xterm sends for ctrl with keys other than letters
+var controlCharacters = map[rune]byte{
+	' ':  0x00,
+	'@':  0x00,
+	'2':  0x00,
+	'[':  0x1b,
+	'3':  0x1b,
+	'\\': 0x1c,
+	'4':  0x1c,
+	']':  0x1d,
+	'5':  0x1d,
+	'^':  0x1e,
+	'6':  0x1e,
+	'_':  0x1f,
+	'-':  0x1f,
+	'/':  0x1f,
+	'7':  0x1f,
+	'?':  0x7f,
+	'8':  0x7f,
+}
+
+// keyCharacter returns the lower case ASCII character a key types, or 0 if it doesn't type one. Characters come from
+// the layout, so ctrl + the key labelled c sends ^C with dvorak, falling back to the key's position on a US keyboard
+// for layouts without latin letters, like cyrillic ones.
+func keyCharacter(key Key, name string) rune {
+	if key == KeySpace {
+		return ' '
+	}
+	if len(name) == 1 {
+		return unicode.ToLower(rune(name[0]))
+	}
+	if name != "" && key > KeySpace && key <= '`' {
+		// these keys are numbered by the ASCII character they type on a US keyboard
+		return unicode.ToLower(rune(key))
+	}
+	return 0
+}
+
+// withMeta applies alt to a control character, which has no modifier parameter to carry it: alt sends an ESC first,
+// or sets the eighth bit if the program has asked for that instead
+func (modes Modes) withMeta(seq []byte, mods Modifiers) []byte {
+	if seq == nil || mods&ModAlt == 0 {
+		return seq
+	}
+	if modes.AltSendsEscape {
+		return append([]byte{0x1b}, seq...)
+	}
+	if modes.EightBitMeta && len(seq) == 1 && seq[0] < 0x80 {
+		return []byte(string(rune(seq[0] | 0x80)))
+	}
+	return seq
+}
+
+// EncodeChar returns the bytes to send for a typed character, with alt acting as meta. Characters typed with ctrl and
+// alt held come from AltGr, so are sent as they are.
+func (terminal *Terminal) EncodeChar(r rune, mods Modifiers) []byte {
+	if mods&ModControl != 0 {
+		return []byte(string(r))
+	}
+	return terminal.modes.withMeta([]byte(string(r)), mods)
+}
+
+// modifierParam returns the xterm modifier parameter for the held modifiers, which is 1 when none are held
+func modifierParam(mods Modifiers) int {
+	m := 1
+	if mods&ModShift != 0 {
+		m += 1
+	}
+	if mods&ModAlt != 0 {
+		m += 2
+	}
+	if mods&ModControl != 0 {
+		m += 4
+	}
+	if mods&ModSuper != 0 {
+		m += 8
+	}
+	return m
+}
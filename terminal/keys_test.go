@@ -0,0 +1,100 @@
+package terminal
+
+import (
+	"testing"
+
+	"github.com/liamg/aminal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyEncodings(t *testing.T) {
+
+	tests := []struct {
+		encoding  string
+		key       Key
+		name      string
+		mods      Modifiers
+		appCursor bool
+		expected  string
+	}{
+		{encoding: config.EncodingXterm, key: KeyUp, expected: "\x1b[A"},
+		{encoding: config.EncodingXterm, key: KeyUp, appCursor: true, expected: "\x1bOA"},
+		{encoding: config.EncodingXterm, key: KeyUp, mods: ModControl, expected: "\x1b[1;5A"},
+		{encoding: config.EncodingXterm, key: KeyHome, expected: "\x1b[H"},
+		{encoding: config.EncodingXterm, key: KeyF1, expected: "\x1bOP"},
+		{encoding: config.EncodingXterm, key: KeyF5, mods: ModShift, expected: "\x1b[15;2~"},
+		{encoding: config.EncodingXterm, key: KeyF13, expected: "\x1b[1;2P"},
+		{encoding: config.EncodingXterm, key: KeyDelete, expected: "\x1b[3~"},
+		{encoding: config.EncodingXterm, key: KeyTab, mods: ModShift, expected: "\x1b[Z"},
+		{encoding: config.EncodingXterm, key: KeyBackspace, expected: "\x7f"},
+		{encoding: config.EncodingXterm, key: KeyEscape, expected: "\x1b"},
+		{encoding: config.EncodingXterm, key: Key('C'), name: "c", mods: ModControl, expected: "\x03"},
+		{encoding: config.EncodingXterm, key: Key('C'), name: "c", expected: ""},
+		{encoding: config.EncodingXterm, key: Key('J'), name: "c", mods: ModControl, expected: "\x03"},
+		{encoding: config.EncodingXterm, key: Key('C'), name: "с", mods: ModControl, expected: "\x03"},
+		{encoding: config.EncodingXterm, key: KeySpace, mods: ModControl, expected: "\x00"},
+		{encoding: config.EncodingXterm, key: Key('['), name: "[", mods: ModControl, expected: "\x1b"},
+		{encoding: config.EncodingXterm, key: Key('2'), name: "2", mods: ModControl, expected: "\x00"},
+		{encoding: config.EncodingXterm, key: Key('8'), name: "8", mods: ModControl, expected: "\x7f"},
+		{encoding: config.EncodingXterm, key: Key('/'), name: "/", mods: ModControl, expected: "\x1f"},
+		{encoding: config.EncodingXterm, key: Key('A'), name: "a", mods: ModControl | ModAlt, expected: "\x1b\x01"},
+		{encoding: config.EncodingXterm, key: KeyBackspace, mods: ModAlt, expected: "\x1b\x7f"},
+		{encoding: config.EncodingXterm, key: Key('A'), name: "a", mods: ModAlt, expected: ""},
+		{encoding: config.EncodingXterm, key: KeyKP1, expected: "\x1b[F"},
+		{encoding: config.EncodingXterm, key: KeyKP8, appCursor: true, expected: "\x1bOA"},
+		{encoding: config.EncodingXterm, key: KeyKP5, expected: "\x1b[E"},
+		{encoding: config.EncodingXterm, key: KeyKPAdd, expected: ""},
+		{encoding: config.EncodingXterm, key: KeyKPEnter, expected: "\r"},
+		{encoding: config.EncodingURxvt, key: KeyHome, expected: "\x1b[7~"},
+		{encoding: config.EncodingURxvt, key: KeyKP7, expected: "\x1b[7~"},
+		{encoding: config.EncodingURxvt, key: KeyF1, expected: "\x1b[11~"},
+		{encoding: config.EncodingURxvt, key: KeyDelete, mods: ModControl, expected: "\x1b[3^"},
+		{encoding: config.EncodingURxvt, key: KeyUp, mods: ModShift, expected: "\x1b[a"},
+		{encoding: config.EncodingURxvt, key: KeyUp, mods: ModControl, expected: "\x1bOa"},
+		{encoding: config.EncodingURxvt, key: KeyF13, expected: "\x1b[25~"},
+		{encoding: config.EncodingKitty, key: KeyEscape, expected: "\x1b[27u"},
+		{encoding: config.EncodingKitty, key: KeyEnter, expected: "\r"},
+		{encoding: config.EncodingKitty, key: KeyEnter, mods: ModShift, expected: "\x1b[13;2u"},
+		{encoding: config.EncodingKitty, key: Key('I'), name: "i", mods: ModControl, expected: "\x1b[105;5u"},
+		{encoding: config.EncodingKitty, key: KeyF13, expected: "\x1b[57376u"},
+		{encoding: config.EncodingKitty, key: KeyLeft, mods: ModAlt, expected: "\x1b[1;3D"},
+	}
+
+	for _, test := range tests {
+		terminal, _ := newTestTerminal()
+		terminal.config.Input.Encoding = test.encoding
+		terminal.modes.ApplicationCursorKeys = test.appCursor
+		terminal.modes.AltSendsEscape = true
+		assert.Equal(t, test.expected, string(terminal.EncodeKey(test.key, test.name, test.mods)), "%s %d %d", test.encoding, test.key, test.mods)
+	}
+}
+
+func TestApplicationKeypad(t *testing.T) {
+	terminal, _ := newTestTerminal()
+
+	assert.Nil(t, keypadHandler(true)(terminal))
+	assert.True(t, terminal.IsApplicationKeypadModeEnabled())
+	assert.Equal(t, "\x1bOq", string(terminal.EncodeKey(KeyKP1, "", 0)))
+	assert.Equal(t, "\x1bOk", string(terminal.EncodeKey(KeyKPAdd, "", 0)))
+	assert.Equal(t, "\x1bOM", string(terminal.EncodeKey(KeyKPEnter, "", 0)))
+
+	assert.Nil(t, keypadHandler(false)(terminal))
+	assert.Equal(t, "\r", string(terminal.EncodeKey(KeyKPEnter, "", 0)))
+}
+
+func TestAltAsMeta(t *testing.T) {
+	terminal, _ := newTestTerminal()
+	terminal.modes.AltSendsEscape = true
+
+	assert.Equal(t, "\x1ba", string(terminal.EncodeChar('a', ModAlt)))
+	assert.Equal(t, "a", string(terminal.EncodeChar('a', 0)))
+	// ctrl + alt is AltGr
+	assert.Equal(t, "@", string(terminal.EncodeChar('@', ModControl|ModAlt)))
+
+	assert.Nil(t, csiSetMode("?1036", false, terminal))
+	assert.Equal(t, "a", string(terminal.EncodeChar('a', ModAlt)))
+
+	assert.Nil(t, csiSetMode("?1034", true, terminal))
+	assert.Equal(t, "\u00e1", string(terminal.EncodeChar('a', ModAlt)))
+	assert.Equal(t, "\u0081", string(terminal.EncodeKey(Key('A'), "a", ModControl|ModAlt)))
+}
@@ -0,0 +1,330 @@
+package terminal
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/liamg/aminal/buffer"
+)
+
+// The kitty graphics protocol sends images in APC strings, ESC _ G keys;base64 ESC \, where keys are comma separated
+// key=value pairs, e.g. a=T,f=100,i=1. Images are transmitted directly in the escape codes, in one or more chunks,
+// and can be stored by id to be placed later. Reading images from files is not supported, as it would let anything
+// printed to the terminal read files the user can.
+// See https://sw.kovidgoyal.net/kitty/graphics-protocol/
+
+func init() {
+	RegisterAPCHandler("G", kittyGraphicsHandler)
+}
+
+// kittyGraphics is the state of the protocol for a terminal
+type kittyGraphics struct {
+	images   map[uint32]*image.RGBA // stored to be placed by id
+	order    []uint32               // ids in the order they were stored, to drop the oldest
+	bytes    int                    // the size of the pixels of the stored images
+	transfer *kittyTransfer         // an image arriving in chunks
+}
+
+// kittyTransfer collects the chunks of an image sent with m=1
+type kittyTransfer struct {
+	keys   kittyKeys
+	data   *base64Stream
+	length int
+}
+
+type kittyKeys map[string]string
+
+// number returns the value of a numeric key, or 0 if it isn't given
+func (keys kittyKeys) number(key string) (int, error) {
+	value, ok := keys[key]
+	if !ok {
+		return 0, nil
+	}
+	n, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid kitty graphics key %s=%s", key, value)
+	}
+	return int(n), nil
+}
+
+func parseKittyKeys(control string) (kittyKeys, error) {
+	keys := kittyKeys{}
+	if control == "" {
+		return keys, nil
+	}
+	for _, pair := range strings.Split(control, ",") {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("Invalid kitty graphics key: %s", pair)
+		}
+		keys[parts[0]] = parts[1]
+	}
+	return keys, nil
+}
+
+func kittyGraphicsHandler(terminal *Terminal, payload string) error {
+
+	control, data := strings.TrimPrefix(payload, "G"), ""
+	if i := strings.IndexByte(control, ';'); i > -1 {
+		control, data = control[:i], control[i+1:]
+	}
+	keys, err := parseKittyKeys(control)
+	if err != nil {
+		return err
+	}
+
+	kitty := &terminal.kitty
+	transfer := kitty.transfer
+	if transfer == nil {
+		transfer = &kittyTransfer{keys: keys, data: &base64Stream{}}
+	}
+	transfer.length += len(data)
+	if transfer.length > maxKittyTransfer {
+		kitty.transfer = nil
+		return terminal.kittyReply(transfer.keys, fmt.Errorf("EFBIG:image data is too large"))
+	}
+	for _, r := range data {
+		transfer.data.WriteRune(r)
+	}
+
+	if keys["m"] == "1" {
+		kitty.transfer = transfer
+		return nil
+	}
+	kitty.transfer = nil
+	return terminal.kittyReply(transfer.keys, terminal.kittyCommand(transfer.keys, transfer.data))
+}
+
+// kittyReply tells the program whether a command worked, if it gave an image id and hasn't asked for quiet, and
+// returns the error
+func (terminal *Terminal) kittyReply(keys kittyKeys, err error) error {
+	id, _ := keys.number("i")
+	quiet, _ := keys.number("q")
+	if id == 0 || quiet >= 2 || quiet == 1 && err == nil {
+		return err
+	}
+	message := "OK"
+	if err != nil {
+		message = err.Error()
+	}
+	_ = terminal.respond([]byte(fmt.Sprintf("\x1b_Gi=%d;%s\x1b\\", id, message)))
+	return err
+}
+
+// kittyCommand carries out a command once all of its data has arrived. Errors start with the code kitty replies with.
+func (terminal *Terminal) kittyCommand(keys kittyKeys, data *base64Stream) error {
+	id, err := keys.number("i")
+	if err != nil {
+		return fmt.Errorf("EINVAL:%s", err)
+	}
+
+	switch action := keys["a"]; action {
+	case "", "t", "T", "q":
+		img, err := decodeKittyImage(keys, data)
+		if err != nil {
+			return fmt.Errorf("EINVAL:%s", err)
+		}
+		if action == "q" {
+			return nil // only checking the image could be shown
+		}
+		if id != 0 {
+			terminal.kitty.store(uint32(id), img)
+		}
+		if action == "T" {
+			return terminal.kittyPlace(keys, img, uint32(id))
+		}
+		return nil
+	case "p":
+		img, ok := terminal.kitty.images[uint32(id)]
+		if !ok {
+			return fmt.Errorf("ENOENT:no image with id %d", id)
+		}
+		return terminal.kittyPlace(keys, img, uint32(id))
+	case "d":
+		return terminal.kittyDelete(keys, uint32(id))
+	default:
+		return fmt.Errorf("EINVAL:unsupported action %s", action)
+	}
+}
+
+// decodeKittyImage decodes raw RGB or RGBA pixels (f=24 or f=32, with their size in s and v) or a PNG (f=100),
+// optionally compressed with zlib (o=z)
+func decodeKittyImage(keys kittyKeys, data *base64Stream) (*image.RGBA, error) {
+	if medium := keys["t"]; medium != "" && medium != "d" {
+		return nil, fmt.Errorf("only direct transmission is supported")
+	}
+	raw, err := data.Bytes()
+	if err != nil {
+		return nil, fmt.Errorf("invalid image data: %s", err)
+	}
+
+	width, err := keys.number("s")
+	if err != nil {
+		return nil, err
+	}
+	height, err := keys.number("v")
+	if err != nil {
+		return nil, err
+	}
+	format, err := keys.number("f")
+	if err != nil {
+		return nil, err
+	}
+	if format == 0 {
+		format = 32
+	}
+
+	switch keys["o"] {
+	case "":
+	case "z":
+		reader, err := zlib.NewReader(bytes.NewReader(raw))
+		if err != nil {
+			return nil, fmt.Errorf("invalid compressed image data: %s", err)
+		}
+		// a small amount of compressed data can expand to fill memory
+		raw, err = ioutil.ReadAll(io.LimitReader(reader, maxImagePixels*4+1))
+		if err != nil {
+			return nil, fmt.Errorf("invalid compressed image data: %s", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported compression %s", keys["o"])
+	}
+
+	switch format {
+	case 24, 32:
+		if err := checkImageSize(width, height); err != nil {
+			return nil, err
+		}
+		bytesPerPixel := format / 8
+		if len(raw) != width*height*bytesPerPixel {
+			return nil, fmt.Errorf("expected %d bytes of image data for %dx%d pixels, got %d", width*height*bytesPerPixel, width, height, len(raw))
+		}
+		img := image.NewRGBA(image.Rect(0, 0, width, height))
+		if format == 32 {
+			copy(img.Pix, raw)
+			return img, nil
+		}
+		for i := 0; i < width*height; i++ {
+			copy(img.Pix[i*4:], raw[i*3:i*3+3])
+			img.Pix[i*4+3] = 0xff
+		}
+		return img, nil
+	case 100:
+		return decodeImage(raw)
+	default:
+		return nil, fmt.Errorf("unsupported format %d", format)
+	}
+}
+
+// store keeps an image to be placed by id, replacing any with the same id. The oldest images are dropped to keep
+// within both the number of images and the bytes of pixels allowed.
+func (kitty *kittyGraphics) store(id uint32, img *image.RGBA) {
+	if kitty.images == nil {
+		kitty.images = map[uint32]*image.RGBA{}
+	}
+	if old, exists := kitty.images[id]; exists {
+		kitty.bytes -= imageBytes(old)
+	} else {
+		kitty.order = append(kitty.order, id)
+	}
+	kitty.images[id] = img
+	kitty.bytes += imageBytes(img)
+	for len(kitty.order) > maxKittyImages || kitty.bytes > maxKittyBytes && len(kitty.order) > 1 {
+		kitty.bytes -= imageBytes(kitty.images[kitty.order[0]])
+		delete(kitty.images, kitty.order[0])
+		kitty.order = kitty.order[1:]
+	}
+}
+
+// imageBytes returns the size of an image's pixels
+func imageBytes(img *image.RGBA) int {
+	return 4 * img.Rect.Dx() * img.Rect.Dy()
+}
+
+// forget drops stored images for which drop returns true
+func (kitty *kittyGraphics) forget(drop func(id uint32) bool) {
+	kept := []uint32{}
+	for _, id := range kitty.order {
+		if drop(id) {
+			kitty.bytes -= imageBytes(kitty.images[id])
+			delete(kitty.images, id)
+		} else {
+			kept = append(kept, id)
+		}
+	}
+	kitty.order = kept
+}
+
+// kittyPlace shows an image at the cursor. Its size is c columns by r rows, keeping its aspect ratio if only one of
+// them is given, and it can be cropped to the x, y, w and h of the source rectangle in pixels.
+func (terminal *Terminal) kittyPlace(keys kittyKeys, img *image.RGBA, id uint32) error {
+	if err := terminal.checkCellSize(); err != nil {
+		return fmt.Errorf("EINVAL:%s", err)
+	}
+
+	crop := map[string]int{}
+	for _, key := range []string{"x", "y", "w", "h", "c", "r", "C"} {
+		n, err := keys.number(key)
+		if err != nil {
+			return fmt.Errorf("EINVAL:%s", err)
+		}
+		crop[key] = n
+	}
+	bounds := img.Bounds()
+	source := image.Rect(crop["x"], crop["y"], bounds.Dx(), bounds.Dy())
+	if crop["w"] > 0 {
+		source.Max.X = crop["x"] + crop["w"]
+	}
+	if crop["h"] > 0 {
+		source.Max.Y = crop["y"] + crop["h"]
+	}
+	source = source.Intersect(bounds)
+	if source.Empty() {
+		return fmt.Errorf("EINVAL:the source rectangle is outside the image")
+	}
+	if source != bounds {
+		img = toRGBA(img.SubImage(source))
+	}
+
+	width, height := terminal.fitCells(source.Dx(), source.Dy(), float32(crop["c"]), float32(crop["r"]), false)
+	cursor := cursorBesideImage
+	if crop["C"] == 1 {
+		cursor = cursorStays
+	}
+	terminal.placeImage(terminal.fitToScreen(img), id, width, height, cursor)
+	return nil
+}
+
+// kittyDelete removes placed images: d=a for all those on screen, or d=i for those with an id. The upper case forms
+// also free the stored images.
+func (terminal *Terminal) kittyDelete(keys kittyKeys, id uint32) error {
+	buf := terminal.ActiveBuffer()
+	switch what := keys["d"]; what {
+	case "", "a", "A":
+		buf.DeleteImages(func(placement buffer.ImagePlacement) bool {
+			return placement.Row >= 0 && placement.Row < int(buf.ViewHeight())
+		})
+		if what == "A" {
+			terminal.kitty.forget(func(uint32) bool { return true })
+		}
+	case "i", "I":
+		if id == 0 {
+			return fmt.Errorf("EINVAL:no image id to delete")
+		}
+		buf.DeleteImages(func(placement buffer.ImagePlacement) bool {
+			return placement.ID == id
+		})
+		if what == "I" {
+			terminal.kitty.forget(func(stored uint32) bool { return stored == id })
+		}
+	default:
+		return fmt.Errorf("EINVAL:unsupported deletion %s", what)
+	}
+	return nil
+}
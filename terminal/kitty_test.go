@@ -0,0 +1,117 @@
+package terminal
+
+import (
+	"encoding/base64"
+	"image"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newImageTestTerminal() (*Terminal, *recordingPty) {
+	term, pty := newTestTerminal()
+	term.SetCharSize(10, 20)
+	_ = term.SetSize(20, 10)
+	return term, pty
+}
+
+// rgbPixels is base64 encoded raw RGB data for an image of the given size in pixels
+func rgbPixels(width int, height int) string {
+	return base64.StdEncoding.EncodeToString(make([]byte, width*height*3))
+}
+
+func TestKittyImagesArePlacedAtTheCursor(t *testing.T) {
+	term, pty := newImageTestTerminal()
+
+	require.Nil(t, parse(term, "ab\x1b_Ga=T,f=24,s=30,v=40,i=7;"+rgbPixels(30, 40)+"\x1b\\"))
+
+	placed := term.ActiveBuffer().VisibleImages()
+	require.Len(t, placed, 1)
+	assert.Equal(t, uint32(7), placed[0].ID)
+	assert.Equal(t, 2, placed[0].Col)
+	assert.Equal(t, 0, placed[0].Row)
+	assert.Equal(t, float32(3), placed[0].Width)
+	assert.Equal(t, float32(2), placed[0].Height)
+	assert.Equal(t, "\x1b_Gi=7;OK\x1b\\", pty.String())
+
+	// the cursor goes after the image, on its last line
+	assert.Equal(t, uint16(5), term.ActiveBuffer().CursorColumn())
+	assert.Equal(t, uint16(1), term.ActiveBuffer().CursorLine())
+}
+
+func TestKittyImagesCanBeSentInChunksAndPlacedLater(t *testing.T) {
+	term, pty := newImageTestTerminal()
+
+	data := rgbPixels(10, 20)
+	half := len(data) / 2
+	require.Nil(t, parse(term, "\x1b_Ga=t,f=24,s=10,v=20,i=3,q=1,m=1;"+data[:half]+"\x1b\\"))
+	require.Nil(t, parse(term, "\x1b_Gm=0;"+data[half:]+"\x1b\\"))
+	assert.Empty(t, term.ActiveBuffer().VisibleImages())
+
+	require.Nil(t, parse(term, "\x1b_Ga=p,i=3,c=4,q=1\x1b\\"))
+	placed := term.ActiveBuffer().VisibleImages()
+	require.Len(t, placed, 1)
+	assert.Equal(t, float32(4), placed[0].Width)
+	assert.Equal(t, float32(4), placed[0].Height)
+	assert.Equal(t, "", pty.String())
+}
+
+func TestKittyErrorsAreReported(t *testing.T) {
+	term, pty := newImageTestTerminal()
+
+	// files can't be read
+	assert.NotNil(t, parse(term, "\x1b_Ga=T,t=f,i=1;L2V0Yy9wYXNzd2Q=\x1b\\"))
+	assert.True(t, strings.HasPrefix(pty.String(), "\x1b_Gi=1;EINVAL:"))
+	pty.Reset()
+
+	assert.NotNil(t, parse(term, "\x1b_Ga=p,i=9\x1b\\"))
+	assert.True(t, strings.HasPrefix(pty.String(), "\x1b_Gi=9;ENOENT:"))
+	pty.Reset()
+
+	// the size doesn't match the data
+	assert.NotNil(t, parse(term, "\x1b_Ga=q,f=24,s=10,v=10,i=2;"+rgbPixels(5, 5)+"\x1b\\"))
+	assert.True(t, strings.HasPrefix(pty.String(), "\x1b_Gi=2;EINVAL:"))
+	assert.Empty(t, term.ActiveBuffer().VisibleImages())
+}
+
+func TestKittyImagesCanBeDeleted(t *testing.T) {
+	term, _ := newImageTestTerminal()
+
+	require.Nil(t, parse(term, "\x1b_Ga=T,f=24,s=10,v=20,i=1,q=2;"+rgbPixels(10, 20)+"\x1b\\"))
+	require.Nil(t, parse(term, "\x1b_Ga=T,f=24,s=10,v=20,i=2,q=2;"+rgbPixels(10, 20)+"\x1b\\"))
+
+	require.Nil(t, parse(term, "\x1b_Ga=d,d=I,i=1,q=2\x1b\\"))
+	placed := term.ActiveBuffer().VisibleImages()
+	require.Len(t, placed, 1)
+	assert.Equal(t, uint32(2), placed[0].ID)
+	assert.NotNil(t, parse(term, "\x1b_Ga=p,i=1,q=2\x1b\\"))
+
+	// deleting what is on screen keeps the image to be placed again
+	require.Nil(t, parse(term, "\x1b_Ga=d,q=2\x1b\\"))
+	assert.Empty(t, term.ActiveBuffer().VisibleImages())
+	require.Nil(t, parse(term, "\x1b_Ga=p,i=2,q=2\x1b\\"))
+	assert.Len(t, term.ActiveBuffer().VisibleImages(), 1)
+}
+
+func TestKittyStoreKeepsWithinItsByteQuota(t *testing.T) {
+	kitty := &kittyGraphics{}
+	// the pixels aren't needed to count them, so the images needn't take up the memory
+	large := func() *image.RGBA { return &image.RGBA{Rect: image.Rect(0, 0, 4096, 4096)} }
+	perImage := imageBytes(large())
+	for id := uint32(1); id <= maxKittyBytes/uint32(perImage)+2; id++ {
+		kitty.store(id, large())
+	}
+	assert.True(t, kitty.bytes <= maxKittyBytes)
+	assert.Equal(t, maxKittyBytes/perImage, len(kitty.order))
+	assert.Equal(t, len(kitty.order)*perImage, kitty.bytes)
+	assert.Nil(t, kitty.images[1])
+	assert.NotNil(t, kitty.images[kitty.order[len(kitty.order)-1]])
+
+	// replacing an image counts only the new one, and forgetting one frees its bytes
+	kitty.store(kitty.order[0], &image.RGBA{Rect: image.Rect(0, 0, 1, 1)})
+	assert.Equal(t, (len(kitty.order)-1)*perImage+4, kitty.bytes)
+	kitty.forget(func(uint32) bool { return true })
+	assert.Equal(t, 0, kitty.bytes)
+}
@@ -0,0 +1,149 @@
+package terminal
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// latencySamples is how many of the most recent key presses the percentiles are worked out from
+const latencySamples = 256
+
+// latencyTimeout is how long to wait for a key press to show up on screen before giving up on it, e.g. when the
+// program doesn't echo what is typed
+const latencyTimeout = time.Second
+
+// the stages a key press goes through on the way to the screen
+const (
+	latencyIdle int32 = iota
+	latencyPressed
+	latencyEchoed
+	latencyParsed
+)
+
+// Latency measures how long key presses take to show on screen: from the key press, to the program's echo arriving
+// from the pty, to that output being parsed, to the frame showing it being presented. One key press is tracked at a
+// time.
+type Latency struct {
+	stage     int32 // one of the latency* stages, read without the lock on the busy paths
+	mutex     sync.Mutex
+	pressedAt time.Time
+	echoedAt  time.Time
+	parsedAt  time.Time
+	samples   []latencySample
+	next      int
+	now       func() time.Time
+}
+
+type latencySample struct {
+	echo   time.Duration
+	parse  time.Duration
+	render time.Duration
+}
+
+func (sample latencySample) total() time.Duration {
+	return sample.echo + sample.parse + sample.render
+}
+
+// Latency returns the terminal's key press latency measurements
+func (terminal *Terminal) Latency() *Latency {
+	return &terminal.latency
+}
+
+func (latency *Latency) time() time.Time {
+	if latency.now != nil {
+		return latency.now()
+	}
+	return time.Now()
+}
+
+// KeyPressed is called when a key press is sent to the pty
+func (latency *Latency) KeyPressed() {
+	latency.mutex.Lock()
+	defer latency.mutex.Unlock()
+	now := latency.time()
+	if atomic.LoadInt32(&latency.stage) != latencyIdle && now.Sub(latency.pressedAt) < latencyTimeout {
+		return
+	}
+	latency.pressedAt = now
+	atomic.StoreInt32(&latency.stage, latencyPressed)
+}
+
+// echoed is called as output is read from the pty
+func (latency *Latency) echoed() {
+	latency.advance(latencyPressed, latencyEchoed, &latency.echoedAt)
+}
+
+// parsed is called when all of the output read so far has been parsed
+func (latency *Latency) parsed() {
+	latency.advance(latencyEchoed, latencyParsed, &latency.parsedAt)
+}
+
+func (latency *Latency) advance(from int32, to int32, at *time.Time) {
+	if atomic.LoadInt32(&latency.stage) != from {
+		return
+	}
+	latency.mutex.Lock()
+	defer latency.mutex.Unlock()
+	if latency.stage == from {
+		*at = latency.time()
+		atomic.StoreInt32(&latency.stage, to)
+	}
+}
+
+// Presented is called once a frame has been presented, which shows the echo if it has been parsed
+func (latency *Latency) Presented() {
+	if atomic.LoadInt32(&latency.stage) != latencyParsed {
+		return
+	}
+	latency.mutex.Lock()
+	defer latency.mutex.Unlock()
+	sample := latencySample{
+		echo:   latency.echoedAt.Sub(latency.pressedAt),
+		parse:  latency.parsedAt.Sub(latency.echoedAt),
+		render: latency.time().Sub(latency.parsedAt),
+	}
+	if len(latency.samples) < latencySamples {
+		latency.samples = append(latency.samples, sample)
+	} else {
+		latency.samples[latency.next] = sample
+	}
+	latency.next = (latency.next + 1) % latencySamples
+	atomic.StoreInt32(&latency.stage, latencyIdle)
+}
+
+// Report summarises the measurements, with percentiles of the total latency and the median of each stage
+func (latency *Latency) Report() string {
+	latency.mutex.Lock()
+	samples := append([]latencySample{}, latency.samples...)
+	latency.mutex.Unlock()
+
+	if len(samples) == 0 {
+		return "no key presses measured yet"
+	}
+
+	percentile := func(p int, duration func(latencySample) time.Duration) time.Duration {
+		sort.Slice(samples, func(i, j int) bool {
+			return duration(samples[i]) < duration(samples[j])
+		})
+		return duration(samples[(len(samples)-1)*p/100])
+	}
+	total := func(sample latencySample) time.Duration { return sample.total() }
+
+	return fmt.Sprintf(
+		"p50 %s, p90 %s, p99 %s (echo %s, parse %s, render %s) over %d key presses",
+		round(percentile(50, total)),
+		round(percentile(90, total)),
+		round(percentile(99, total)),
+		round(percentile(50, func(sample latencySample) time.Duration { return sample.echo })),
+		round(percentile(50, func(sample latencySample) time.Duration { return sample.parse })),
+		round(percentile(50, func(sample latencySample) time.Duration { return sample.render })),
+		len(samples),
+	)
+}
+
+func round(duration time.Duration) time.Duration {
+	return duration.Round(100 * time.Microsecond)
+}
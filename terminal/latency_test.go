@@ -0,0 +1,67 @@
+package terminal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (clock *fakeClock) advance(ms int) {
+	clock.now = clock.now.Add(time.Duration(ms) * time.Millisecond)
+}
+
+func TestLatencyStages(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	latency := &Latency{now: func() time.Time { return clock.now }}
+
+	assert.Equal(t, "no key presses measured yet", latency.Report())
+
+	latency.KeyPressed()
+	clock.advance(3)
+	latency.echoed()
+	clock.advance(2)
+	// output which isn't the echo of the key press, and a key press while one is being measured, are ignored
+	latency.echoed()
+	latency.KeyPressed()
+	latency.parsed()
+	clock.advance(10)
+	latency.Presented()
+
+	require.Len(t, latency.samples, 1)
+	assert.Equal(t, latencySample{echo: 3 * time.Millisecond, parse: 2 * time.Millisecond, render: 10 * time.Millisecond}, latency.samples[0])
+	assert.Equal(t, "p50 15ms, p90 15ms, p99 15ms (echo 3ms, parse 2ms, render 10ms) over 1 key presses", latency.Report())
+
+	// frames without a key press to show aren't measured
+	latency.Presented()
+	assert.Len(t, latency.samples, 1)
+}
+
+func TestLatencyGivesUpOnKeyPressesWhichAreNotEchoed(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	latency := &Latency{now: func() time.Time { return clock.now }}
+
+	latency.KeyPressed()
+	clock.advance(2000)
+	latency.KeyPressed()
+	clock.advance(1)
+	latency.echoed()
+	latency.parsed()
+	latency.Presented()
+
+	require.Len(t, latency.samples, 1)
+	assert.Equal(t, time.Millisecond, latency.samples[0].echo)
+}
+
+func TestLatencyPercentiles(t *testing.T) {
+	latency := &Latency{}
+	for i := 1; i <= 100; i++ {
+		latency.samples = append(latency.samples, latencySample{render: time.Duration(i) * time.Millisecond})
+	}
+	assert.Contains(t, latency.Report(), "p50 50ms, p90 90ms, p99 99ms")
+}
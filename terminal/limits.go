@@ -0,0 +1,40 @@
+package terminal
+
+import "time"
+
+// limits which stop pathological or malicious output, e.g. from cat-ing an untrusted file, freezing or exhausting the
+// memory of the terminal
+const (
+	outputBufferSize = 0xffff                 // runes read ahead of the parser, after which reading stops and the program is blocked
+	maxOSCLength     = 1 << 20                // runes in an OSC string, other than OSC 52 which has clipboard_limit
+	maxDCSLength     = 1 << 23                // runes in a DCS string such as a sixel image
+	maxAPCLength     = 1 << 20                // runes in an APC, PM or SOS string, e.g. a chunk of a kitty graphics image
+	maxParamLength   = 1024                   // runes of parameters or intermediates in a sequence, after which it is ignored
+	maxImagePixels   = 1 << 24                // pixels in an image from a program, before it is scaled to fit on screen
+	maxKittyImages   = 64                     // kitty graphics images kept to be placed by id, the oldest being dropped first
+	maxKittyBytes    = 1 << 28                // bytes of pixels in the kitty graphics images kept, the oldest being dropped first
+	maxKittyTransfer = 1 << 25                // base64 runes in a kitty graphics image sent in chunks
+	maxReplayFrame   = 1 << 24                // bytes in a frame of a recording, past which it is taken to be corrupt
+	minBellInterval  = 100 * time.Millisecond // bells rung closer together than this are ignored
+	parseBudget      = 20 * time.Millisecond  // time spent parsing without a break before pausing for the renderer
+	parseBudgetPause = 2 * time.Millisecond
+)
+
+// parseTimer tracks how long the parser has been busy without running out of input
+type parseTimer struct {
+	busySince time.Time
+}
+
+// pace is called before each rune is parsed. When output has been arriving non-stop for longer than the budget, it
+// pauses briefly so the parser can't starve rendering and input.
+func (timer *parseTimer) pace(idle bool) {
+	now := time.Now()
+	if idle || timer.busySince.IsZero() {
+		timer.busySince = now
+		return
+	}
+	if now.Sub(timer.busySince) > parseBudget {
+		time.Sleep(parseBudgetPause)
+		timer.busySince = time.Now()
+	}
+}
@@ -0,0 +1,75 @@
+package terminal
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/liamg/aminal/config"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func newTestTerminal() (*Terminal, *recordingPty) {
+	conf := config.DefaultConfig
+	pty := &recordingPty{}
+	return New(pty, zap.NewNop().Sugar(), &conf), pty
+}
+
+func feed(s string) chan rune {
+	runes := []rune(s)
+	pty := make(chan rune, len(runes))
+	for _, r := range runes {
+		pty <- r
+	}
+	return pty
+}
+
+// parse writes s to the terminal a rune at a time, returning the error from the last sequence in it which failed
+func parse(term *Terminal, s string) error {
+	var err error
+	for _, r := range s {
+		if e := term.advance(r); e != nil {
+			err = e
+		}
+	}
+	return err
+}
+
+func TestOversizedOSCIsDiscarded(t *testing.T) {
+	term, _ := newTestTerminal()
+
+	assert.NotNil(t, parse(term, "\x1b]0;"+strings.Repeat("x", maxOSCLength+1)+"\x07after"))
+	assert.Equal(t, "", term.GetTitle())
+
+	// the whole string is dropped, leaving what follows it
+	assert.Equal(t, "after", term.ActiveBuffer().GetAllText())
+}
+
+func TestOversizedAPCIsDiscarded(t *testing.T) {
+	term, _ := newTestTerminal()
+
+	assert.NotNil(t, parse(term, "\x1b_G"+strings.Repeat("x", maxAPCLength+1)+"\x1b\\after"))
+	assert.Equal(t, "after", term.ActiveBuffer().GetAllText())
+}
+
+func TestBellRateLimit(t *testing.T) {
+	term, _ := newTestTerminal()
+
+	term.RingBell()
+	assert.True(t, term.CheckBell())
+	term.RingBell()
+	assert.False(t, term.CheckBell())
+}
+
+func TestTitleChangesCoalesce(t *testing.T) {
+	term, _ := newTestTerminal()
+	titleChan := make(chan bool, 1)
+	term.AttachTitleChangeHandler(titleChan)
+
+	for i := 0; i < 1000; i++ {
+		term.SetTitle(strings.Repeat("t", i))
+	}
+
+	assert.Len(t, titleChan, 1)
+	assert.Equal(t, strings.Repeat("t", 999), term.GetTitle())
+}
@@ -31,6 +31,10 @@ func csiSetMode(modeStr string, enabled bool, terminal *Terminal) error {
 		}
 	case "?1":
 		terminal.modes.ApplicationCursorKeys = enabled
+	case "?6":
+		// origin mode
+		//DECOM
+		terminal.ActiveBuffer().SetOriginMode(enabled)
 	case "?7":
 		// auto-wrap mode
 		//DECAWM
@@ -47,13 +51,23 @@ func csiSetMode(modeStr string, enabled bool, terminal *Terminal) error {
 		terminal.modes.BlinkingCursor = enabled
 	case "?25":
 		terminal.modes.ShowCursor = enabled
-	case "?47", "?1047":
+	case "?47":
 		if enabled {
 			terminal.UseAltBuffer()
 		} else {
 			terminal.UseMainBuffer()
 		}
-	case "?1000", "?1006;1000", "?10061000": // ?10061000 seen from htop
+	case "?1047":
+		if enabled {
+			terminal.UseAltBuffer()
+		} else {
+			// the alternate screen is cleared on the way out, so it is blank the next time it is used
+			if !terminal.UsingMainBuffer() {
+				terminal.ActiveBuffer().EraseDisplay()
+			}
+			terminal.UseMainBuffer()
+		}
+	case "?1000":
 		// enable mouse tracking
 		// 1000 refers to ext mode for extended mouse click area - otherwise only x <= 255-31
 		if enabled {
@@ -63,6 +77,36 @@ func csiSetMode(modeStr string, enabled bool, terminal *Terminal) error {
 			terminal.logger.Infof("Turning off VT200 mouse mode")
 			terminal.SetMouseMode(MouseModeNone)
 		}
+	case "?1002":
+		// as 1000, but also reporting movement while a button is held
+		if enabled {
+			terminal.logger.Infof("Turning on button event mouse mode")
+			terminal.SetMouseMode(MouseModeButtonEvent)
+		} else {
+			terminal.logger.Infof("Turning off button event mouse mode")
+			terminal.SetMouseMode(MouseModeNone)
+		}
+	case "?1003":
+		// as 1000, but also reporting all movement
+		if enabled {
+			terminal.logger.Infof("Turning on any event mouse mode")
+			terminal.SetMouseMode(MouseModeAnyEvent)
+		} else {
+			terminal.logger.Infof("Turning off any event mouse mode")
+			terminal.SetMouseMode(MouseModeNone)
+		}
+	case "?1005":
+		terminal.setMouseExtMode(MouseExtUTF8, enabled)
+	case "?1006":
+		terminal.setMouseExtMode(MouseExtSGR, enabled)
+	case "?1015":
+		terminal.setMouseExtMode(MouseExtURXVT, enabled)
+	case "?1007":
+		terminal.modes.AlternateScroll = enabled
+	case "?1034":
+		terminal.modes.EightBitMeta = enabled
+	case "?1036", "?1039":
+		terminal.modes.AltSendsEscape = enabled
 	case "?1048":
 		if enabled {
 			terminal.ActiveBuffer().SaveCursor()
@@ -71,9 +115,9 @@ func csiSetMode(modeStr string, enabled bool, terminal *Terminal) error {
 		}
 	case "?1049":
 		if enabled {
-			terminal.UseAltBuffer()
+			terminal.enterAltScreen()
 		} else {
-			terminal.UseMainBuffer()
+			terminal.exitAltScreen()
 		}
 	case "?2004":
 		terminal.SetBracketedPasteMode(enabled)
@@ -83,3 +127,12 @@ func csiSetMode(modeStr string, enabled bool, terminal *Terminal) error {
 
 	return nil
 }
+
+// setMouseExtMode switches a mouse coordinate encoding on or off - turning off one which isn't in use does nothing
+func (terminal *Terminal) setMouseExtMode(mode MouseExtMode, enabled bool) {
+	if enabled {
+		terminal.SetMouseExtMode(mode)
+	} else if terminal.GetMouseExtMode() == mode {
+		terminal.SetMouseExtMode(MouseExtNone)
+	}
+}
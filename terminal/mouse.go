@@ -0,0 +1,69 @@
+package terminal
+
+import (
+	"fmt"
+)
+
+// MouseExtMode is the encoding used for the coordinates in mouse reports, set with DECSET 1005, 1006 or 1015
+type MouseExtMode uint
+
+const (
+	MouseExtNone  MouseExtMode = iota // one byte per value, limited to 223 columns/rows
+	MouseExtUTF8                      // 1005: values as UTF-8 characters, up to 2015
+	MouseExtSGR                       // 1006: decimal values, with M or m for press or release
+	MouseExtURXVT                     // 1015: decimal values, release reported as button 3
+)
+
+func (terminal *Terminal) SetMouseExtMode(mode MouseExtMode) {
+	terminal.mouseExtMode = mode
+}
+
+func (terminal *Terminal) GetMouseExtMode() MouseExtMode {
+	return terminal.mouseExtMode
+}
+
+// ReportMouseEvent sends a mouse event to the running program. button is the xterm button code, including modifier
+// bits, and x and y are 1-indexed cell coordinates.
+func (terminal *Terminal) ReportMouseEvent(button byte, x int, y int, release bool) {
+	if packet, ok := EncodeMouseEvent(terminal.mouseExtMode, button, x, y, release); ok {
+		terminal.logger.Debugf("Sending mouse packet: %q", packet)
+		terminal.Write(packet)
+	}
+}
+
+// EncodeMouseEvent builds a mouse report in the given encoding, returning false if the position is too far from the
+// top left to be represented in it
+func EncodeMouseEvent(mode MouseExtMode, button byte, x int, y int, release bool) ([]byte, bool) {
+
+	if mode == MouseExtSGR {
+		final := 'M'
+		if release {
+			final = 'm'
+		}
+		return []byte(fmt.Sprintf("\x1b[<%d;%d;%d%c", button, x, y, final)), true
+	}
+
+	// the other encodings can't say which button was released
+	if release {
+		button = button&^3 | 3
+	}
+
+	switch mode {
+	case MouseExtURXVT:
+		return []byte(fmt.Sprintf("\x1b[%d;%d;%dM", int(button)+32, x, y)), true
+	case MouseExtUTF8:
+		packet := []byte("\x1b[M")
+		for _, v := range []int{int(button), x, y} {
+			if v+32 > 0x7ff { // two byte UTF-8 sequences only
+				return nil, false
+			}
+			packet = append(packet, string(rune(v+32))...)
+		}
+		return packet, true
+	default:
+		if x+32 > 0xff || y+32 > 0xff {
+			return nil, false
+		}
+		return []byte{0x1b, '[', 'M', button + 32, byte(x + 32), byte(y + 32)}, true
+	}
+}
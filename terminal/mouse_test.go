@@ -0,0 +1,67 @@
+package terminal
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeMouseEvent(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		mode    MouseExtMode
+		button  byte
+		x       int
+		y       int
+		release bool
+		want    string
+		ok      bool
+	}{
+		{name: "normal press", mode: MouseExtNone, button: 0, x: 1, y: 1, want: "\x1b[M !!", ok: true},
+		{name: "normal release", mode: MouseExtNone, button: 2, x: 10, y: 5, release: true, want: "\x1b[M#*%", ok: true},
+		{name: "normal out of range", mode: MouseExtNone, button: 0, x: 300, y: 1},
+		{name: "utf8 press", mode: MouseExtUTF8, button: 0, x: 300, y: 1, want: "\x1b[M Ō!", ok: true},
+		{name: "utf8 out of range", mode: MouseExtUTF8, button: 0, x: 3000, y: 1},
+		{name: "sgr press", mode: MouseExtSGR, button: 16, x: 300, y: 40, want: "\x1b[<16;300;40M", ok: true},
+		{name: "sgr release", mode: MouseExtSGR, button: 2, x: 3, y: 4, release: true, want: "\x1b[<2;3;4m", ok: true},
+		{name: "urxvt press", mode: MouseExtURXVT, button: 1, x: 300, y: 40, want: "\x1b[33;300;40M", ok: true},
+		{name: "urxvt release", mode: MouseExtURXVT, button: 1, x: 3, y: 4, release: true, want: "\x1b[35;3;4M", ok: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			packet, ok := EncodeMouseEvent(test.mode, test.button, test.x, test.y, test.release)
+			assert.Equal(t, test.ok, ok)
+			assert.Equal(t, test.want, string(packet))
+		})
+	}
+}
+
+func TestMouseTrackingModes(t *testing.T) {
+	tests := []struct {
+		set  string
+		mode MouseMode
+	}{
+		{set: "\x1b[?9h", mode: MouseModeX10},
+		{set: "\x1b[?1000h", mode: MouseModeVT200},
+		{set: "\x1b[?1002h", mode: MouseModeButtonEvent},
+		{set: "\x1b[?1003h", mode: MouseModeAnyEvent},
+	}
+
+	for _, test := range tests {
+		term, _ := newTestTerminal()
+		input := feed(test.set)
+		for len(input) > 0 {
+			term.processRune(<-input, input)
+		}
+		assert.Equal(t, test.mode, term.GetMouseMode(), test.set)
+
+		input = feed(strings.Replace(test.set, "h", "l", 1))
+		for len(input) > 0 {
+			term.processRune(<-input, input)
+		}
+		assert.Equal(t, MouseModeNone, term.GetMouseMode(), test.set)
+	}
+}
@@ -2,28 +2,89 @@ package terminal
 
 import (
 	"fmt"
+	"net/url"
+	"strconv"
 	"strings"
+
+	"github.com/liamg/aminal/buffer"
 )
 
-func oscHandler(pty chan rune, terminal *Terminal) error {
+// oscString reads an OSC string, splitting it into params as it arrives
+type oscString struct {
+	params []string
+	param  strings.Builder
+	length int
+	// the data of OSC 52, which can be large, is decoded as it arrives rather than kept as text
+	clipboard      *base64Stream
+	clipboardLimit int // base64 characters of it, see SecurityConfig.ClipboardLimit
+	// likewise the image of OSC 1337;File=args:data, which can be as big as a sixel image
+	image     *base64Stream
+	imageArgs string
+}
 
-	params := []string{}
-	param := ""
+// tooLong returns true once the string is longer than is kept, after which the rest of it is dropped
+func (osc *oscString) tooLong() bool {
+	if osc.clipboard != nil {
+		return osc.clipboard.written > osc.clipboardLimit
+	}
+	if osc.image != nil {
+		return osc.length > maxDCSLength
+	}
+	return osc.length > maxOSCLength
+}
 
-	for {
-		b := <-pty
-		if b == 0x07 || b == 0x5c {
-			params = append(params, param)
-			break
+func (osc *oscString) put(b rune) {
+	osc.length++
+	if osc.tooLong() {
+		// the rest of the string is dropped, but still read to its end so it isn't shown as text
+		return
+	}
+	if osc.clipboard != nil {
+		osc.clipboard.WriteRune(b)
+		return
+	}
+	if osc.image != nil {
+		osc.image.WriteRune(b)
+		return
+	}
+	if b == ':' && len(osc.params) > 0 && osc.params[0] == "1337" {
+		args := strings.Join(append(append([]string{}, osc.params[1:]...), osc.param.String()), ";")
+		if strings.HasPrefix(args, "File=") {
+			osc.imageArgs = args
+			osc.image = &base64Stream{}
+			return
+		}
+	}
+	if b == ';' {
+		osc.params = append(osc.params, osc.param.String())
+		osc.param.Reset()
+		if len(osc.params) == 2 && osc.params[0] == "52" {
+			osc.clipboard = &base64Stream{}
 		}
-		if b == ';' {
-			params = append(params, param)
-			param = ""
-			continue
+		return
+	}
+	osc.param.WriteRune(b)
+}
+
+// end carries out the OSC sequence. Replies to it end with the same terminator.
+func (osc *oscString) end(terminal *Terminal, terminator string) error {
+
+	if osc.clipboard != nil {
+		if osc.tooLong() {
+			return fmt.Errorf("Clipboard data too long: more than %d base64 characters", osc.clipboardLimit)
 		}
-		param = fmt.Sprintf("%s%c", param, b)
+		return terminal.clipboardOSC(osc.params[1], osc.clipboard, terminator)
+	}
+	if osc.tooLong() {
+		return fmt.Errorf("OSC string too long: %d runes", osc.length)
 	}
 
+	if osc.image != nil {
+		return terminal.inlineImageOSC(osc.imageArgs, osc.image)
+	}
+
+	params := append(osc.params, osc.param.String())
+
 	if len(params) == 0 {
 		return fmt.Errorf("OSC with no params")
 	}
@@ -39,19 +100,64 @@ func oscHandler(pty chan rune, terminal *Terminal) error {
 	switch pS[0] {
 	case "0", "2":
 		terminal.SetTitle(pT)
-	case "10": // get/set foreground colour
-		if len(pS) > 1 {
-			if pS[1] == "?" {
-				terminal.Write([]byte("\x1b]10;15"))
-			}
+	case "7": // current working directory, as file://host/path
+		u, err := url.Parse(pT)
+		if err != nil || u.Scheme != "file" {
+			return fmt.Errorf("Invalid working directory URL: %s", pT)
+		}
+		terminal.SetWorkingDirectory(u.Host, u.Path)
+	case "8": // hyperlink, as 8;params;URI - the URI may itself contain semicolons, and an empty one ends the link
+		if len(params) < 3 {
+			return fmt.Errorf("Invalid hyperlink: %s", strings.Join(params, ";"))
+		}
+		terminal.ActiveBuffer().SetHyperlink(strings.Join(params[2:], ";"))
+	case "9": // only progress, 9;4, from the ConEmu extensions, as others clash with iTerm2's 9 for notifications
+		if len(params) < 2 || params[1] != "4" {
+			return fmt.Errorf("Unsupported OSC 9 command: %s", strings.Join(params, ";"))
 		}
-	case "11": // get/set background colour
-		if len(pS) > 1 {
-			if pS[1] == "?" {
-				terminal.Write([]byte("\x1b]10;0"))
+		return terminal.progressOSC(params[2:])
+	case "52": // clipboard, handled above once there is a selection
+		return fmt.Errorf("Missing clipboard selection")
+	case "133": // shell integration marks, see Buffer.ClearToPreviousMark and Buffer.SetZone
+		mark := ""
+		if len(params) > 1 {
+			mark = params[1]
+		}
+		switch {
+		case strings.HasPrefix(mark, "A"):
+			terminal.ActiveBuffer().MarkPrompt()
+			terminal.clearDetectedProgress()
+			terminal.ActiveBuffer().SetZone(buffer.ZonePrompt)
+		case strings.HasPrefix(mark, "B"):
+			terminal.ActiveBuffer().SetZone(buffer.ZoneInput)
+		case strings.HasPrefix(mark, "C"):
+			terminal.ActiveBuffer().SetZone(buffer.ZoneOutput)
+			terminal.ActiveBuffer().StartCommand()
+			event := UserEvent{Type: "command_start"}
+			for _, option := range params[2:] {
+				if strings.HasPrefix(option, "cmdline=") {
+					event.Value = strings.TrimPrefix(option, "cmdline=")
+				}
+			}
+			terminal.raiseUserEvent(event)
+		case strings.HasPrefix(mark, "D"):
+			terminal.ActiveBuffer().SetZone(buffer.ZoneUnknown)
+			status := ""
+			if len(params) > 2 {
+				status = params[2]
 			}
+			terminal.ActiveBuffer().EndCommand(status != "" && status != "0")
+			terminal.raiseUserEvent(UserEvent{Type: "command_done", Value: status})
 		}
+	case "4", "10", "11", "12", "104", "110", "111", "112": // set, query and reset colours
+		return terminal.colourOSC(params, terminator)
+	case "1337":
+		return terminal.userVarOSC(pT)
 	default:
+		if n, err := strconv.Atoi(pS[0]); err == nil && n != 0 && n == terminal.config.Hooks.OSC {
+			terminal.raiseUserEvent(UserEvent{Type: "osc", Value: strings.Join(params[1:], ";")})
+			return nil
+		}
 		return fmt.Errorf("Unknown OSC control sequence: %s", strings.Join(params, ";"))
 	}
 	return nil
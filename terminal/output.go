@@ -2,16 +2,17 @@ package terminal
 
 import (
 	"context"
+	"sync/atomic"
 	"time"
 )
 
 // Wish list here: http://invisible-island.net/xterm/ctlseqs/ctlseqs.html
 
-type TerminalCharSet int
+// controlHandler carries out a control character, or an escape sequence once the parser has read all of it
+type controlHandler func(terminal *Terminal) error
 
-type escapeSequenceHandler func(pty chan rune, terminal *Terminal) error
-
-var escapeSequenceMap = map[rune]escapeSequenceHandler{
+// C0 control characters, which are carried out wherever they turn up, even in the middle of a sequence
+var controlCharacterMap = map[rune]controlHandler{
 	0x05: enqSequenceHandler,
 	0x07: bellSequenceHandler,
 	0x08: backspaceSequenceHandler,
@@ -22,46 +23,43 @@ var escapeSequenceMap = map[rune]escapeSequenceHandler{
 	0x0d: carriageReturnSequenceHandler,
 	0x0e: shiftOutSequenceHandler,
 	0x0f: shiftInSequenceHandler,
-	0x1b: ansiHandler,
 }
 
-func newLineSequenceHandler(pty chan rune, terminal *Terminal) error {
+func newLineSequenceHandler(terminal *Terminal) error {
+	terminal.detectProgress()
 	terminal.ActiveBuffer().NewLine()
 	return nil
 }
 
-func tabSequenceHandler(pty chan rune, terminal *Terminal) error {
+func tabSequenceHandler(terminal *Terminal) error {
 	terminal.ActiveBuffer().Tab()
 	return nil
 }
 
-func carriageReturnSequenceHandler(pty chan rune, terminal *Terminal) error {
+func carriageReturnSequenceHandler(terminal *Terminal) error {
+	terminal.detectProgress()
 	terminal.ActiveBuffer().CarriageReturn()
 	return nil
 }
 
-func backspaceSequenceHandler(pty chan rune, terminal *Terminal) error {
+func backspaceSequenceHandler(terminal *Terminal) error {
 	terminal.ActiveBuffer().Backspace()
 	return nil
 }
 
-func bellSequenceHandler(pty chan rune, terminal *Terminal) error {
-	// @todo ring bell - flash red or some shit?
+func bellSequenceHandler(terminal *Terminal) error {
+	terminal.ActiveBuffer().MarkBell()
+	terminal.RingBell()
 	return nil
 }
 
-func enqSequenceHandler(pty chan rune, terminal *Terminal) error {
-	terminal.logger.Errorf("Received ENQ!")
+func shiftOutSequenceHandler(terminal *Terminal) error {
+	terminal.ActiveBuffer().ShiftOut()
 	return nil
 }
 
-func shiftOutSequenceHandler(pty chan rune, terminal *Terminal) error {
-	terminal.logger.Errorf("Received shift out")
-	return nil
-}
-
-func shiftInSequenceHandler(pty chan rune, terminal *Terminal) error {
-	terminal.logger.Errorf("Received shift in")
+func shiftInSequenceHandler(terminal *Terminal) error {
+	terminal.ActiveBuffer().ShiftIn()
 	return nil
 }
 
@@ -69,6 +67,12 @@ func (terminal *Terminal) processInput(ctx context.Context, pty chan rune) {
 
 	// https://en.wikipedia.org/wiki/ANSI_escape_code
 
+	atomic.AddInt32(&terminal.parsers, 1)
+	defer atomic.AddInt32(&terminal.parsers, -1)
+
+	timer := parseTimer{}
+	var settled <-chan time.Time // fires once a size asked for with RequestSize has stopped changing
+
 	for {
 
 		select {
@@ -77,7 +81,7 @@ func (terminal *Terminal) processInput(ctx context.Context, pty chan rune) {
 			terminal.logger.Debugf("Terminal suspended")
 			<-terminal.resumeChan
 		case <-ctx.Done():
-			break
+			return
 		default:
 		}
 
@@ -85,27 +89,53 @@ func (terminal *Terminal) processInput(ctx context.Context, pty chan rune) {
 			time.Sleep(time.Millisecond * 100)
 		}
 
-		b := <-pty
-
-		terminal.logger.Debugf("0x%q", string(b))
-
-		handler, ok := escapeSequenceMap[b]
-
-		if ok {
-			//terminal.logger.Debugf("Handling escape sequence: 0x%x", b)
-			if err := handler(pty, terminal); err != nil {
-				terminal.logger.Errorf("Error handling escape sequence: %s", err)
-			}
-		} else {
-			//terminal.logger.Debugf("Received character 0x%X: %q", b, string(b))
-			if b >= 0x20 {
-				//terminal.logger.Debugf("%c", b)
-				terminal.ActiveBuffer().Write(b)
-			} else {
-				terminal.logger.Error("Non-readable rune received: 0x%X", b)
-			}
+		timer.pace(len(pty) == 0)
+
+		// while output is paused, nothing is read, so the program is held back once the buffer fills
+		input := pty
+		if terminal.OutputPaused() {
+			input = nil
 		}
 
-		terminal.isDirty = true
+		var b rune
+		select {
+		case <-ctx.Done():
+			return
+		case <-terminal.resizeChan:
+			settled = time.After(resizeDelay)
+			continue
+		case <-settled:
+			settled = nil
+			terminal.applyPendingSize()
+			continue
+		case <-terminal.pauseToggled:
+			continue
+		case b = <-input:
+		}
+
+		terminal.processRune(b, pty)
+		if len(pty) == 0 {
+			terminal.latency.parsed()
+			terminal.snapshotAltScreen(false)
+			terminal.followOutput()
+		}
 	}
 }
+
+// processRune passes a rune of output to the parser. Runs of plain text are written in one go, reading the rest of the
+// run from pty as far as it has arrived.
+func (terminal *Terminal) processRune(b rune, pty chan rune) {
+
+	terminal.logger.Debugf("0x%q", string(b))
+
+	if terminal.parser.state == stateGround && isPlain(b) && !terminal.config.Slomo {
+		if next, ok := terminal.writePlain(b, pty); ok {
+			terminal.processRune(next, pty)
+		}
+	} else if err := terminal.advance(b); err != nil {
+		terminal.logger.Errorf("Error handling escape sequence: %s", err)
+	}
+
+	terminal.isDirty = true
+	terminal.hasActivity = true
+}
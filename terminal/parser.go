@@ -0,0 +1,262 @@
+package terminal
+
+import (
+	"fmt"
+)
+
+// The parser is Paul Williams' state machine for DEC compatible terminals, https://vt100.net/emu/dec_ansi_parser. Output
+// is fed to it a rune at a time, and every rune moves it to a well defined state, so malformed or cut off sequences are
+// dropped at a known point instead of swallowing the text after them.
+
+type parserState int
+
+const (
+	stateGround parserState = iota
+	stateEscape
+	stateEscapeIntermediate
+	stateCSIEntry
+	stateCSIParam
+	stateCSIIntermediate
+	stateCSIIgnore
+	stateOSCString
+	stateDCSEntry
+	stateDCSParam
+	stateDCSIntermediate
+	stateDCSPassthrough
+	stateDCSIgnore
+	stateControlString // SOS, PM and APC strings
+)
+
+// stringSequence receives the data of an OSC, DCS, APC, PM or SOS string a rune at a time as it arrives, as some of
+// them, like sixel images, are far too big to hold on to as text
+type stringSequence interface {
+	put(r rune)
+	// end is called once the string is terminated, with the terminator which ended it, so replies can end the same way
+	end(terminal *Terminal, terminator string) error
+}
+
+type parser struct {
+	state        parserState
+	param        []rune
+	intermediate []rune
+	str          stringSequence // the string being read in the OSC, DCS passthrough and control string states
+}
+
+func (p *parser) clear() {
+	p.param = p.param[:0]
+	p.intermediate = p.intermediate[:0]
+}
+
+func (p *parser) collectParam(r rune, ignore parserState) {
+	if len(p.param) >= maxParamLength {
+		p.state = ignore
+		return
+	}
+	p.param = append(p.param, r)
+}
+
+func (p *parser) collectIntermediate(r rune, ignore parserState) {
+	if len(p.intermediate) >= maxParamLength {
+		p.state = ignore
+		return
+	}
+	p.intermediate = append(p.intermediate, r)
+}
+
+// endString passes the end of the string being read, if there is one, to its handler
+func (terminal *Terminal) endString(terminator string) error {
+	str := terminal.parser.str
+	terminal.parser.str = nil
+	if str == nil {
+		return nil
+	}
+	return str.end(terminal, terminator)
+}
+
+func isC0(r rune) bool {
+	return r < 0x20
+}
+
+// advance moves the parser on by one rune of output, carrying out whatever it completes
+func (terminal *Terminal) advance(r rune) error {
+	p := &terminal.parser
+
+	// transitions from anywhere
+	switch {
+	case r == 0x18 || r == 0x1a: // CAN and SUB abandon the sequence, including any string being read
+		p.str = nil
+		p.state = stateGround
+		return nil
+	case r == 0x1b:
+		err := terminal.endString("\x1b\\")
+		p.clear()
+		p.state = stateEscape
+		return err
+	case r >= 0x80 && r <= 0x9f: // C1 controls are the same as ESC followed by the character 0x40 lower
+		err := terminal.advance(0x1b)
+		if err2 := terminal.advance(r - 0x40); err == nil {
+			err = err2
+		}
+		return err
+	}
+
+	switch p.state {
+	case stateGround:
+		switch {
+		case isC0(r):
+			return terminal.execute(r)
+		case r != 0x7f:
+			terminal.ActiveBuffer().Write(r)
+		}
+
+	case stateEscape:
+		switch {
+		case isC0(r):
+			return terminal.execute(r)
+		case r >= 0x20 && r <= 0x2f:
+			p.state = stateEscapeIntermediate
+			p.collectIntermediate(r, stateEscapeIntermediate)
+		case r == '[':
+			p.state = stateCSIEntry
+		case r == ']':
+			p.str = &oscString{clipboardLimit: terminal.config.Security.ClipboardLimit}
+			p.state = stateOSCString
+		case r == 'P':
+			p.state = stateDCSEntry
+		case r == 'X':
+			p.str = newControlString("SOS", nil)
+			p.state = stateControlString
+		case r == '^':
+			p.str = newControlString("PM", pmProtocols)
+			p.state = stateControlString
+		case r == '_':
+			p.str = newControlString("APC", apcProtocols)
+			p.state = stateControlString
+		case r != 0x7f:
+			p.state = stateGround
+			return escDispatch("", r, terminal)
+		}
+
+	case stateEscapeIntermediate:
+		switch {
+		case isC0(r):
+			return terminal.execute(r)
+		case r >= 0x20 && r <= 0x2f:
+			p.collectIntermediate(r, stateEscapeIntermediate)
+		case r != 0x7f:
+			p.state = stateGround
+			return escDispatch(string(p.intermediate), r, terminal)
+		}
+
+	case stateCSIEntry, stateCSIParam, stateCSIIntermediate, stateCSIIgnore:
+		return terminal.advanceCSI(r)
+
+	case stateOSCString:
+		switch {
+		case r == 0x07:
+			err := terminal.endString("\x07")
+			p.state = stateGround
+			return err
+		case !isC0(r):
+			p.str.put(r)
+		}
+
+	case stateDCSEntry, stateDCSParam, stateDCSIntermediate:
+		return terminal.advanceDCS(r)
+
+	case stateDCSPassthrough:
+		if r != 0x7f {
+			p.str.put(r)
+		}
+
+	case stateDCSIgnore:
+
+	case stateControlString:
+		switch {
+		case r == 0x07: // not in the standard, but some programs end these with BEL as for OSC
+			err := terminal.endString("\x07")
+			p.state = stateGround
+			return err
+		case !isC0(r):
+			p.str.put(r)
+		}
+	}
+	return nil
+}
+
+func (terminal *Terminal) advanceCSI(r rune) error {
+	p := &terminal.parser
+
+	switch {
+	case isC0(r): // control characters are carried out in the middle of a sequence, as on a VT100
+		return terminal.execute(r)
+	case r == 0x7f:
+	case r > 0x7e:
+		p.state = stateCSIIgnore
+	case r >= 0x40:
+		ignored := p.state == stateCSIIgnore
+		p.state = stateGround
+		if ignored {
+			return fmt.Errorf("Ignored malformed CSI sequence ending %q", r)
+		}
+		return csiDispatch(string(p.param), string(p.intermediate), r, terminal)
+	case p.state == stateCSIIgnore:
+	case r >= 0x20 && r <= 0x2f:
+		p.collectIntermediate(r, stateCSIIgnore)
+		if p.state != stateCSIIgnore {
+			p.state = stateCSIIntermediate
+		}
+	case p.state == stateCSIIntermediate: // parameters can't follow intermediates
+		p.state = stateCSIIgnore
+	case r >= 0x3c && r <= 0x3f && p.state == stateCSIParam: // private markers can only come first
+		p.state = stateCSIIgnore
+	default:
+		p.state = stateCSIParam
+		p.collectParam(r, stateCSIIgnore)
+	}
+	return nil
+}
+
+func (terminal *Terminal) advanceDCS(r rune) error {
+	p := &terminal.parser
+
+	switch {
+	case isC0(r) || r == 0x7f:
+	case r > 0x7e:
+		p.state = stateDCSIgnore
+	case r >= 0x40:
+		p.str = dcsHook(string(p.param), string(p.intermediate), r)
+		if p.str == nil {
+			p.state = stateDCSIgnore
+			return fmt.Errorf("Unsupported DCS sequence: %s%s%c", string(p.param), string(p.intermediate), r)
+		}
+		p.state = stateDCSPassthrough
+	case r >= 0x20 && r <= 0x2f:
+		p.state = stateDCSIntermediate
+		p.collectIntermediate(r, stateDCSIgnore)
+	case p.state == stateDCSIntermediate:
+		p.state = stateDCSIgnore
+	case r >= 0x3c && r <= 0x3f && p.state == stateDCSParam:
+		p.state = stateDCSIgnore
+	default:
+		p.state = stateDCSParam
+		p.collectParam(r, stateDCSIgnore)
+	}
+	return nil
+}
+
+// execute carries out a C0 control character
+func (terminal *Terminal) execute(r rune) error {
+	if handler, ok := controlCharacterMap[r]; ok {
+		return handler(terminal)
+	}
+	return nil
+}
+
+// dcsHook returns what reads the string of a DCS sequence, or nil if the sequence isn't supported
+func dcsHook(param string, intermediate string, final rune) stringSequence {
+	if final == 'q' && intermediate == "" {
+		return newSixelString(param)
+	}
+	return nil
+}
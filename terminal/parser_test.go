@@ -0,0 +1,159 @@
+package terminal
+
+import (
+	"bufio"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParserReturnsToGround(t *testing.T) {
+	for _, input := range []string{
+		"\x1b[1;2m",
+		"\x1b[?1049h",
+		"\x1b[ q",
+		"\x1b(0",
+		"\x1b]0;title\x07",
+		"\x1b]0;title\x1b\\",
+		"\x1bP0;1;0q#0;2;0;0;0#0~\x1b\\",
+		"\x1b_Gi=1\x1b\\",
+		"\x1b[1;2\x18",
+		"\x1b]0;unfinished\x1a",
+		"\x1bP1$r0m\x1b\\",
+		"\u009b1m",
+	} {
+		term, _ := newTestTerminal()
+		term.SetSize(10, 4)
+		parse(term, input)
+		assert.Equal(t, stateGround, term.parser.state, "%q", input)
+		assert.Nil(t, term.parser.str, "%q", input)
+	}
+}
+
+func TestParserIgnoresOverlongParams(t *testing.T) {
+	term, _ := newTestTerminal()
+	term.SetSize(10, 2)
+
+	long := make([]rune, maxParamLength+1)
+	for i := range long {
+		long[i] = '1'
+	}
+	assert.NotNil(t, parse(term, "\x1b["+string(long)+"Cx"))
+	assert.Equal(t, "x", term.ActiveBuffer().GetAllText())
+	assert.Len(t, term.parser.param, maxParamLength)
+}
+
+func TestCANAbandonsStrings(t *testing.T) {
+	term, _ := newTestTerminal()
+	require.Nil(t, parse(term, "\x1b]0;first\x07"))
+	require.Nil(t, parse(term, "\x1b]0;second\x18\x1b\\"))
+	assert.Equal(t, "first", term.GetTitle())
+}
+
+// fuzzAlphabet is weighted towards the characters which start, end and make up sequences
+var fuzzAlphabet = []rune("\x1b\x1b\x1b[[]]P_^X\x07\x18\x1a\x7f\r\n\b\t;;:?>=$!\"' 0123456789mHJKqhl\\\u009b\u009c\u009d\u0090aé")
+
+// corrupt returns input with runes inserted and removed at random
+func corrupt(r *rand.Rand, input []rune) []rune {
+	out := append([]rune{}, input...)
+	for n := r.Intn(8); n >= 0; n-- {
+		i := r.Intn(len(out) + 1)
+		if r.Intn(4) == 0 && i < len(out) {
+			out = append(out[:i], out[i+1:]...)
+			continue
+		}
+		junk := make([]rune, 1+r.Intn(6))
+		for j := range junk {
+			junk[j] = fuzzAlphabet[r.Intn(len(fuzzAlphabet))]
+		}
+		out = append(out[:i], append(junk, out[i:]...)...)
+	}
+	return out
+}
+
+// fuzzSeed is output for the fuzzer to corrupt, and the size of terminal it was written for
+type fuzzSeed struct {
+	name  string
+	cols  uint
+	rows  uint
+	input string
+}
+
+// loadFuzzSeeds returns the conformance inputs, and the output of the real programs captured in testdata/fuzz, see the
+// README there
+func loadFuzzSeeds(t *testing.T) []fuzzSeed {
+	seeds := []fuzzSeed{}
+	for _, c := range loadConformanceCases(t) {
+		seeds = append(seeds, fuzzSeed{name: c.name, cols: c.cols, rows: c.rows, input: c.input})
+	}
+
+	files, err := filepath.Glob("testdata/fuzz/*.rec")
+	require.Nil(t, err)
+	for _, file := range files {
+		f, err := os.Open(file)
+		require.Nil(t, err)
+		r := bufio.NewReader(f)
+		magic := make([]byte, len(recordingMagic))
+		_, err = io.ReadFull(r, magic)
+		require.Nil(t, err, file)
+		require.Equal(t, recordingMagic, string(magic), file)
+
+		seed := fuzzSeed{name: file, cols: 80, rows: 24}
+		for {
+			frame, err := readFrame(r)
+			if err == io.EOF {
+				break
+			}
+			require.Nil(t, err, file)
+			switch frame.kind {
+			case frameOutput:
+				seed.input += string(frame.data)
+			case frameSize:
+				// the size the output starts at, as the fuzzer can't resize part way through
+				if cols, rows := frame.size(); seed.input == "" && cols > 0 && rows > 0 {
+					seed.cols, seed.rows = uint(cols), uint(rows)
+				}
+			}
+		}
+		f.Close()
+		seeds = append(seeds, seed)
+	}
+	return seeds
+}
+
+// TestParserFuzz corrupts the conformance inputs and captured program output, and checks the parser never blocks,
+// panics or loses track of where sequences start, and that writing plain text in runs puts the same on screen as the
+// state machine on its own
+func TestParserFuzz(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for _, c := range loadFuzzSeeds(t) {
+		for i := 0; i < 50; i++ {
+			input := corrupt(r, []rune(c.input))
+
+			term, _ := newTestTerminal()
+			term.SetSize(c.cols, c.rows)
+			pty := make(chan rune, len(input))
+			for _, b := range input {
+				pty <- b
+			}
+			for len(pty) > 0 {
+				term.processRune(<-pty, pty)
+			}
+
+			oneByOne, _ := newTestTerminal()
+			oneByOne.SetSize(c.cols, c.rows)
+			parse(oneByOne, string(input))
+			require.Equal(t, screen(oneByOne), screen(term), "%s: %q", c.name, string(input))
+
+			// whatever state the junk left the parser in, CAN gets it back in step
+			require.Nil(t, parse(term, "\x18\x1b]2;in step\x07"), "%s: %q", c.name, string(input))
+			require.Equal(t, "in step", term.GetTitle(), "%s: %q", c.name, string(input))
+		}
+	}
+}
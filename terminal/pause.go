@@ -0,0 +1,27 @@
+package terminal
+
+import "sync/atomic"
+
+// SetOutputPaused stops or restarts parsing the program's output, freezing the screen so fast scrolling text can be
+// read. While paused, output is read ahead of the parser until outputBufferSize runes are waiting, and after that the
+// program is blocked when it writes any more, until output is resumed.
+func (terminal *Terminal) SetOutputPaused(paused bool) {
+	var value int32
+	if paused {
+		value = 1
+	}
+	if atomic.SwapInt32(&terminal.outputPaused, value) == value {
+		return
+	}
+	// wake the parser, which is waiting for output it may no longer be paused for
+	select {
+	case terminal.pauseToggled <- true:
+	default:
+	}
+	terminal.SetDirty()
+}
+
+// OutputPaused returns true if parsing the program's output has been paused with SetOutputPaused
+func (terminal *Terminal) OutputPaused() bool {
+	return atomic.LoadInt32(&terminal.outputPaused) == 1
+}
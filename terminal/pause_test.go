@@ -0,0 +1,42 @@
+package terminal
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPausedOutputIsHeldUntilResumed(t *testing.T) {
+	term, _ := newTestTerminal()
+	term.SetSize(20, 3)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	input := make(chan rune, 16)
+	term.SetOutputPaused(true)
+	go term.processInput(ctx, input)
+	for atomic.LoadInt32(&term.parsers) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	for _, r := range "held" {
+		input <- r
+	}
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, 4, len(input))
+
+	term.SetOutputPaused(false)
+	for len(input) > 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	for atomic.LoadInt32(&term.parsers) != 0 {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, "held", term.ActiveBuffer().GetAllText())
+	assert.False(t, term.OutputPaused())
+}
@@ -0,0 +1,32 @@
+package terminal
+
+// maxPlainRun limits how much plain text is gathered before it is written, so that a long stream of it still shows up
+// as it arrives
+const maxPlainRun = 4096
+
+// isPlain returns true for printable ASCII, which needs no handling beyond being written to the buffer
+func isPlain(r rune) bool {
+	return r >= 0x20 && r < 0x7f
+}
+
+// writePlain writes a run of plain text starting with b to the buffer in one go, along with as much of the rest of the
+// run as has already arrived. Output such as logs is mostly plain text, so this skips most of the per-rune work. It
+// returns the rune which ended the run, if it read one.
+func (terminal *Terminal) writePlain(b rune, pty chan rune) (rune, bool) {
+
+	run := append(terminal.plainRun[:0], b)
+	next, more := rune(0), false
+
+	for len(run) < maxPlainRun && len(pty) > 0 {
+		r := <-pty
+		if !isPlain(r) {
+			next, more = r, true
+			break
+		}
+		run = append(run, r)
+	}
+
+	terminal.ActiveBuffer().WritePlain(run)
+	terminal.plainRun = run
+	return next, more
+}
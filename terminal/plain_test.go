@@ -0,0 +1,46 @@
+package terminal
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlainRunEndsAtEscapeSequence(t *testing.T) {
+	term, _ := newTestTerminal()
+	term.SetSize(20, 3)
+
+	pty := feed("plain\x1b[31mred\x1b[0m\r\nnext")
+	for len(pty) > 0 {
+		term.processRune(<-pty, pty)
+	}
+
+	buf := term.ActiveBuffer()
+	assert.Equal(t, "plainred\nnext", buf.GetAllText())
+	assert.Equal(t, [3]float32(term.config.ColourScheme.Red), buf.GetCell(5, 0).Fg())
+	assert.Equal(t, [3]float32(term.config.ColourScheme.Foreground), buf.GetCell(0, 0).Fg())
+	assert.Equal(t, [3]float32(term.config.ColourScheme.Foreground), buf.GetCell(0, 1).Fg())
+}
+
+// BenchmarkLogOutput writes lines like those from a busy server log, mostly plain text with a little colour
+func BenchmarkLogOutput(b *testing.B) {
+	var log strings.Builder
+	for i := 0; i < 200; i++ {
+		fmt.Fprintf(&log, "2019-01-02T15:04:05Z \x1b[32mINFO\x1b[0m request %d GET /api/v1/users?page=%d status=200 duration=12ms\r\n", i, i)
+	}
+	text := log.String()
+
+	term, _ := newTestTerminal()
+	term.SetSize(120, 40)
+
+	b.SetBytes(int64(len(text)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pty := feed(text)
+		for len(pty) > 0 {
+			term.processRune(<-pty, pty)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package terminal
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// ProgressState is how a task a program is working on is going, as reported with OSC 9;4
+type ProgressState int
+
+const (
+	ProgressNone          ProgressState = iota // no task, or it has finished
+	ProgressNormal                             // working, Percent of the way through
+	ProgressError                              // failing, at Percent if it is known
+	ProgressIndeterminate                      // working, with no idea how far through
+	ProgressPaused                             // waiting, at Percent if it is known
+)
+
+// Progress is the progress of the task a program is working on, if any
+type Progress struct {
+	State    ProgressState
+	Percent  int  // 0 to 100
+	Detected bool // taken from a percentage in the output, rather than reported by the program
+}
+
+// GetProgress returns the progress of the task a program is working on, which has State ProgressNone if there isn't one
+func (terminal *Terminal) GetProgress() Progress {
+	terminal.progressLock.Lock()
+	defer terminal.progressLock.Unlock()
+	return terminal.progress
+}
+
+// setProgress is only called while parsing, so the parser can read the progress without the lock
+func (terminal *Terminal) setProgress(progress Progress) {
+	terminal.progressLock.Lock()
+	changed := progress != terminal.progress
+	terminal.progress = progress
+	terminal.progressLock.Unlock()
+	if changed {
+		terminal.SetDirty()
+	}
+}
+
+// progressOSC handles OSC 9;4;state;percent, as introduced by ConEmu and supported by e.g. Windows Terminal, winget
+// and systemd. The percent can be left out, and is kept as it was for the error and paused states.
+func (terminal *Terminal) progressOSC(args []string) error {
+	state := ProgressNone
+	if len(args) > 0 && args[0] != "" {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < int(ProgressNone) || n > int(ProgressPaused) {
+			return fmt.Errorf("Invalid progress state: %s", args[0])
+		}
+		state = ProgressState(n)
+	}
+
+	progress := Progress{State: state}
+	if len(args) > 1 && args[1] != "" {
+		n, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("Invalid progress percentage: %s", args[1])
+		}
+		if n < 0 {
+			n = 0
+		} else if n > 100 {
+			n = 100
+		}
+		progress.Percent = n
+	} else if state == ProgressError || state == ProgressPaused {
+		progress.Percent = terminal.progress.Percent
+	}
+	terminal.setProgress(progress)
+	return nil
+}
+
+// detectProgress takes progress from the last percentage on the cursor line, if detection is on and the program
+// hasn't reported its progress itself. It is called as lines end or are returned to, so a progress line which is
+// redrawn in place is seen each time it changes.
+func (terminal *Terminal) detectProgress() {
+	if terminal.progressPattern == nil || terminal.progress.State != ProgressNone && !terminal.progress.Detected {
+		return
+	}
+	matches := terminal.progressPattern.FindAllStringSubmatch(terminal.ActiveBuffer().CursorLineText(), -1)
+	if len(matches) == 0 {
+		return
+	}
+	n, err := strconv.Atoi(matches[len(matches)-1][1])
+	if err != nil || n > 100 {
+		return
+	}
+	if n == 100 {
+		terminal.setProgress(Progress{})
+		return
+	}
+	terminal.setProgress(Progress{State: ProgressNormal, Percent: n, Detected: true})
+}
+
+// clearDetectedProgress hides detected progress, e.g. when the shell shows its prompt again, as programs may not get
+// to 100% before finishing
+func (terminal *Terminal) clearDetectedProgress() {
+	if terminal.progress.Detected {
+		terminal.setProgress(Progress{})
+	}
+}
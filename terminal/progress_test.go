@@ -0,0 +1,56 @@
+package terminal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgressOSC(t *testing.T) {
+	term, _ := newTestTerminal()
+
+	require.Nil(t, parse(term, "\x1b]9;4;1;42\x07"))
+	assert.Equal(t, Progress{State: ProgressNormal, Percent: 42}, term.GetProgress())
+
+	// the error and paused states keep the percentage if it is left out
+	require.Nil(t, parse(term, "\x1b]9;4;2\x07"))
+	assert.Equal(t, Progress{State: ProgressError, Percent: 42}, term.GetProgress())
+
+	require.Nil(t, parse(term, "\x1b]9;4;3\x07"))
+	assert.Equal(t, ProgressIndeterminate, term.GetProgress().State)
+
+	require.Nil(t, parse(term, "\x1b]9;4;1;250\x07"))
+	assert.Equal(t, 100, term.GetProgress().Percent)
+
+	require.Nil(t, parse(term, "\x1b]9;4;0\x07"))
+	assert.Equal(t, ProgressNone, term.GetProgress().State)
+
+	assert.NotNil(t, parse(term, "\x1b]9;4;5;10\x07"))
+	assert.NotNil(t, parse(term, "\x1b]9;hello\x07"))
+}
+
+func TestDetectedProgress(t *testing.T) {
+	term, _ := newTestTerminal()
+	term.config.Progress.Detect = true
+	term.progressPattern = term.config.Progress.DetectPattern()
+	term.ActiveBuffer().ResizeView(40, 5)
+
+	write := func(s string) {
+		term.ActiveBuffer().CarriageReturn()
+		term.ActiveBuffer().Write([]rune(s)...)
+		require.Nil(t, carriageReturnSequenceHandler(term))
+	}
+
+	write("downloading 1.5% of 20MB")
+	assert.Equal(t, Progress{State: ProgressNormal, Percent: 1, Detected: true}, term.GetProgress())
+	write("50% done, 12% of them failed")
+	assert.Equal(t, 12, term.GetProgress().Percent)
+	write("100% done                   ")
+	assert.Equal(t, ProgressNone, term.GetProgress().State)
+
+	// progress the program reports itself isn't overridden
+	require.Nil(t, parse(term, "\x1b]9;4;1;70\x07"))
+	write("30%")
+	assert.Equal(t, Progress{State: ProgressNormal, Percent: 70}, term.GetProgress())
+}
@@ -0,0 +1,139 @@
+package terminal
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// Pty is the stream of bytes a terminal is attached to - usually the master side of a local pty, but it can be anything
+// which can carry input/output and be told about size changes
+type Pty interface {
+	io.ReadWriteCloser
+	Resize(cols uint16, rows uint16) error
+}
+
+// ProcessPty is implemented by ptys which started the process at the other end, and so can tell how it exited
+type ProcessPty interface {
+	Pty
+	Wait() (int, error)
+	Hangup(timeout time.Duration)
+	Foreground() string
+}
+
+// SecretPty is implemented by ptys which can tell when the program at the other end is reading something secret
+type SecretPty interface {
+	SecretInput() bool
+}
+
+type localPty struct {
+	*os.File
+	process    *exec.Cmd
+	exited     chan struct{}
+	exitStatus int
+	exitErr    error
+}
+
+// NewLocalPty wraps the master side of a pty allocated by this process, with the process started on its slave side.
+// The process is reaped as soon as it exits, so it never lingers as a zombie.
+func NewLocalPty(f *os.File, process *exec.Cmd) ProcessPty {
+	pty := &localPty{File: f, process: process, exited: make(chan struct{})}
+	go func() {
+		pty.exitStatus, pty.exitErr = exitStatus(process.Wait())
+		close(pty.exited)
+	}()
+	return pty
+}
+
+// Wait waits for the process to exit, returning its exit status
+func (pty *localPty) Wait() (int, error) {
+	<-pty.exited
+	return pty.exitStatus, pty.exitErr
+}
+
+// Hangup asks everything in the process group to exit, as if the terminal had been disconnected, killing anything
+// still running after the timeout. The shell is killed by its pid too, in case it has moved to another group.
+func (pty *localPty) Hangup(timeout time.Duration) {
+	pgid := pty.process.Process.Pid
+	select {
+	case <-pty.exited:
+		return
+	default:
+	}
+	_ = syscall.Kill(-pgid, syscall.SIGHUP)
+	select {
+	case <-pty.exited:
+	case <-time.After(timeout):
+		_ = syscall.Kill(-pgid, syscall.SIGKILL)
+		_ = syscall.Kill(pgid, syscall.SIGKILL)
+		<-pty.exited
+	}
+}
+
+// SecretInput returns true while echo is off but input is still read a line at a time, which is how password prompts
+// like sudo's and ssh's read. Full screen programs turn echo off too, but read each key as it is pressed.
+func (pty *localPty) SecretInput() bool {
+	var termios syscall.Termios
+	if err := ioctlTermios(pty.Fd(), ioctlGetTermios, &termios); err != nil {
+		return false
+	}
+	return termios.Lflag&syscall.ECHO == 0 && termios.Lflag&syscall.ICANON != 0
+}
+
+// Foreground returns the name of the program in the foreground, or an empty string if that is the shell itself
+func (pty *localPty) Foreground() string {
+	var pgid int32
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, pty.Fd(), uintptr(syscall.TIOCGPGRP), uintptr(unsafe.Pointer(&pgid)))
+	if errno != 0 || int(pgid) == pty.process.Process.Pid {
+		return ""
+	}
+	return processName(int(pgid))
+}
+
+func exitStatus(err error) (int, error) {
+	if err == nil {
+		return 0, nil
+	}
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok {
+			if status.Signaled() {
+				return 128 + int(status.Signal()), nil
+			}
+			return status.ExitStatus(), nil
+		}
+	}
+	return 0, err
+}
+
+// processName returns the command name of a process, from /proc where there is one, otherwise from ps
+func processName(pid int) string {
+	if comm, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/comm", pid)); err == nil {
+		return strings.TrimSpace(string(comm))
+	}
+	out, err := exec.Command("ps", "-o", "comm=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return ""
+	}
+	return filepath.Base(strings.TrimSpace(string(out)))
+}
+
+func (pty *localPty) Resize(cols uint16, rows uint16) error {
+	size := Winsize{
+		Width:  cols,
+		Height: rows,
+	}
+	_, _, err := syscall.Syscall(syscall.SYS_IOCTL, uintptr(pty.Fd()),
+		uintptr(syscall.TIOCSWINSZ), uintptr(unsafe.Pointer(&size)))
+	if err != 0 {
+		return fmt.Errorf("Failed to set terminal size vai ioctl: Error no %d", err)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package terminal
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/kr/pty"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalPtyWait(t *testing.T) {
+	cmd := exec.Command("sh", "-c", "echo bye; exit 3")
+	f, err := pty.Start(cmd)
+	require.Nil(t, err)
+
+	p := NewLocalPty(f, cmd)
+	defer p.Close()
+
+	// reading fails once the process has gone, as that closes the slave side
+	output, _ := ioutil.ReadAll(p)
+	assert.Contains(t, string(output), "bye")
+
+	status, err := p.Wait()
+	require.Nil(t, err)
+	assert.Equal(t, 3, status)
+}
+
+func TestLocalPtyHangup(t *testing.T) {
+	cmd := exec.Command("sh", "-c", "sleep 30")
+	f, err := pty.Start(cmd)
+	require.Nil(t, err)
+
+	p := NewLocalPty(f, cmd)
+	defer p.Close()
+
+	p.Hangup(time.Second)
+
+	status, err := p.Wait()
+	require.Nil(t, err)
+	assert.Equal(t, 128+int(syscall.SIGHUP), status)
+}
+
+func TestLocalPtyHangupKillsAShellOutsideItsGroup(t *testing.T) {
+	// started without a session of its own, so the shell isn't the leader of a process group, and it ignores SIGHUP
+	cmd := exec.Command("sh", "-c", "trap '' HUP; exec sleep 30")
+	f, err := os.Open(os.DevNull)
+	require.Nil(t, err)
+	require.Nil(t, cmd.Start())
+
+	p := NewLocalPty(f, cmd)
+	defer p.Close()
+
+	p.Hangup(100 * time.Millisecond)
+
+	status, err := p.Wait()
+	require.Nil(t, err)
+	assert.Equal(t, 128+int(syscall.SIGKILL), status)
+}
+
+func TestProcessName(t *testing.T) {
+	cmd := exec.Command("sleep", "30")
+	require.Nil(t, cmd.Start())
+	defer func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}()
+
+	assert.Equal(t, "sleep", processName(cmd.Process.Pid))
+}
+
+func TestLocalPtySecretInput(t *testing.T) {
+	master, tty, err := pty.Open()
+	require.Nil(t, err)
+	defer master.Close()
+	defer tty.Close()
+	p := &localPty{File: master}
+
+	var termios syscall.Termios
+	require.Nil(t, ioctlTermios(tty.Fd(), ioctlGetTermios, &termios))
+	assert.False(t, p.SecretInput())
+
+	// as at a password prompt
+	termios.Lflag &^= syscall.ECHO
+	require.Nil(t, ioctlTermios(tty.Fd(), ioctlSetTermios, &termios))
+	assert.True(t, p.SecretInput())
+
+	// as in a full screen program
+	termios.Lflag &^= syscall.ICANON
+	require.Nil(t, ioctlTermios(tty.Fd(), ioctlSetTermios, &termios))
+	assert.False(t, p.SecretInput())
+}
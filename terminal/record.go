@@ -0,0 +1,189 @@
+package terminal
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// recordingMagic starts every recording, so a replay of the wrong kind of file fails early
+const recordingMagic = "aminal recording 2\n"
+
+// A recording is the magic string followed by frames: the time since the recording started in nanoseconds as a
+// big-endian uint64, the kind of frame as a byte, the length of the data as a big-endian uint32, then the data itself.
+// There is an output frame for each read from the pty, and a size frame for the size the terminal starts at and each
+// time it is resized, with the columns then rows as big-endian uint16s, as output only makes sense at the size it was
+// written for.
+const (
+	frameOutput byte = iota
+	frameSize
+)
+
+const frameHeaderLength = 13
+
+// Recorder writes everything read from a pty to a file, with timings, so it can be replayed exactly
+type Recorder struct {
+	w      io.WriteCloser
+	buf    *bufio.Writer
+	start  time.Time
+	lock   sync.Mutex
+	failed bool
+}
+
+// NewRecorder creates a recording at the given path, overwriting any file already there
+func NewRecorder(path string) (*Recorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create recording: %s", err)
+	}
+	recorder := &Recorder{w: f, buf: bufio.NewWriter(f), start: time.Now()}
+	if _, err := recorder.buf.WriteString(recordingMagic); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("Failed to write recording: %s", err)
+	}
+	return recorder, nil
+}
+
+// Write records output from the pty. Errors stop the recording rather than being returned, as they'd otherwise stop
+// the terminal.
+func (recorder *Recorder) Write(p []byte) (int, error) {
+	recorder.writeFrame(frameOutput, p)
+	return len(p), nil
+}
+
+// Resize records the size of the terminal, in characters
+func (recorder *Recorder) Resize(cols uint16, rows uint16) {
+	size := make([]byte, 4)
+	binary.BigEndian.PutUint16(size, cols)
+	binary.BigEndian.PutUint16(size[2:], rows)
+	recorder.writeFrame(frameSize, size)
+}
+
+func (recorder *Recorder) writeFrame(kind byte, data []byte) {
+	recorder.lock.Lock()
+	defer recorder.lock.Unlock()
+
+	if recorder.failed {
+		return
+	}
+
+	header := make([]byte, frameHeaderLength)
+	binary.BigEndian.PutUint64(header, uint64(time.Since(recorder.start)))
+	header[8] = kind
+	binary.BigEndian.PutUint32(header[9:], uint32(len(data)))
+	recorder.buf.Write(header)
+	recorder.buf.Write(data)
+	// flush every frame, so a recording of a crash is complete
+	if err := recorder.buf.Flush(); err != nil {
+		recorder.failed = true
+	}
+}
+
+func (recorder *Recorder) Close() error {
+	recorder.lock.Lock()
+	defer recorder.lock.Unlock()
+	recorder.buf.Flush()
+	return recorder.w.Close()
+}
+
+// frame is one frame of a recording
+type frame struct {
+	at   time.Duration // since the recording started
+	kind byte
+	data []byte
+}
+
+// readFrame reads the next frame of a recording, returning io.EOF at the end of it
+func readFrame(r io.Reader) (frame, error) {
+	header := make([]byte, frameHeaderLength)
+	if _, err := io.ReadFull(r, header); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+		return frame{}, err
+	}
+	length := binary.BigEndian.Uint32(header[9:])
+	if length > maxReplayFrame {
+		return frame{}, fmt.Errorf("Recording frame too long: %d bytes", length)
+	}
+	f := frame{at: time.Duration(binary.BigEndian.Uint64(header)), kind: header[8], data: make([]byte, length)}
+	if _, err := io.ReadFull(r, f.data); err != nil {
+		return frame{}, io.EOF
+	}
+	if f.kind == frameSize && len(f.data) != 4 {
+		return frame{}, fmt.Errorf("Invalid size frame in recording")
+	}
+	return f, nil
+}
+
+// size returns the columns and rows of a size frame
+func (f frame) size() (uint16, uint16) {
+	return binary.BigEndian.Uint16(f.data), binary.BigEndian.Uint16(f.data[2:])
+}
+
+// replayPty plays a recording back as if it were coming from a pty, with the original timing. Input is ignored. The
+// recorded sizes are replayed as requests to resize the window, which are made in order with the output.
+type replayPty struct {
+	f       *os.File
+	r       *bufio.Reader
+	start   time.Time
+	pending []byte
+	closed  chan struct{}
+}
+
+// OpenReplay opens a recording made with a Recorder for replay
+func OpenReplay(path string) (Pty, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open recording: %s", err)
+	}
+	r := bufio.NewReader(f)
+	magic := make([]byte, len(recordingMagic))
+	if _, err := io.ReadFull(r, magic); err != nil || string(magic) != recordingMagic {
+		f.Close()
+		return nil, fmt.Errorf("%s is not an aminal recording", path)
+	}
+	return &replayPty{f: f, r: r, start: time.Now(), closed: make(chan struct{})}, nil
+}
+
+func (pty *replayPty) Read(p []byte) (int, error) {
+	for len(pty.pending) == 0 {
+		frame, err := readFrame(pty.r)
+		if err != nil {
+			return 0, err
+		}
+		select {
+		case <-time.After(frame.at - time.Since(pty.start)):
+		case <-pty.closed:
+			return 0, io.EOF
+		}
+		switch frame.kind {
+		case frameOutput:
+			pty.pending = frame.data
+		case frameSize:
+			if cols, rows := frame.size(); cols > 0 && rows > 0 {
+				pty.pending = []byte(fmt.Sprintf("\x1b[8;%d;%dt", rows, cols))
+			}
+		}
+	}
+	n := copy(p, pty.pending)
+	pty.pending = pty.pending[n:]
+	return n, nil
+}
+
+func (pty *replayPty) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (pty *replayPty) Close() error {
+	close(pty.closed)
+	return pty.f.Close()
+}
+
+func (pty *replayPty) Resize(cols uint16, rows uint16) error {
+	return nil
+}
@@ -0,0 +1,70 @@
+package terminal
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "aminal-record")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "capture")
+
+	recorder, err := NewRecorder(path)
+	require.Nil(t, err)
+	recorder.Resize(80, 24)
+	recorder.Write([]byte("\x1b[1mbold"))
+	time.Sleep(50 * time.Millisecond)
+	recorder.Resize(0, 0)
+	recorder.Resize(100, 30)
+	recorder.Write([]byte("\xff not utf-8"))
+	require.Nil(t, recorder.Close())
+
+	replay, err := OpenReplay(path)
+	require.Nil(t, err)
+	defer replay.Close()
+
+	start := time.Now()
+	output, err := ioutil.ReadAll(replay)
+	require.Nil(t, err)
+	assert.Equal(t, "\x1b[8;24;80t\x1b[1mbold\x1b[8;30;100t\xff not utf-8", string(output))
+	assert.True(t, time.Since(start) >= 50*time.Millisecond, "the replay should keep the original timing")
+
+	n, err := replay.Write([]byte("typing"))
+	assert.Nil(t, err)
+	assert.Equal(t, 6, n)
+}
+
+func TestReplayRejectsOtherFiles(t *testing.T) {
+	f, err := ioutil.TempFile("", "aminal-record")
+	require.Nil(t, err)
+	defer os.Remove(f.Name())
+	f.WriteString("just some text")
+	f.Close()
+
+	_, err = OpenReplay(f.Name())
+	assert.NotNil(t, err)
+}
+
+func TestReplayRejectsOverlongFrames(t *testing.T) {
+	f, err := ioutil.TempFile("", "aminal-record")
+	require.Nil(t, err)
+	defer os.Remove(f.Name())
+	f.WriteString(recordingMagic)
+	f.Write([]byte{0, 0, 0, 0, 0, 0, 0, 0, frameOutput, 0xff, 0xff, 0xff, 0xff})
+	f.Close()
+
+	replay, err := OpenReplay(f.Name())
+	require.Nil(t, err)
+	defer replay.Close()
+	_, err = replay.Read(make([]byte, 16))
+	assert.NotNil(t, err)
+}
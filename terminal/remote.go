@@ -0,0 +1,44 @@
+package terminal
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// remotePty is a raw byte stream from another machine or process, such as a qemu serial console. Like a serial line,
+// nothing at the other end is listening for size changes.
+type remotePty struct {
+	net.Conn
+}
+
+// DialRemote connects to "host:port" over TCP, optionally with TLS, or to a Unix socket given as "unix:/path"
+func DialRemote(address string, useTLS bool) (Pty, error) {
+
+	network := "tcp"
+	if strings.HasPrefix(address, "unix:") {
+		network = "unix"
+		address = strings.TrimPrefix(address, "unix:")
+	}
+
+	var conn net.Conn
+	var err error
+	if useTLS {
+		if network == "unix" {
+			return nil, fmt.Errorf("TLS is not supported over Unix sockets")
+		}
+		conn, err = tls.Dial(network, address, &tls.Config{})
+	} else {
+		conn, err = net.Dial(network, address)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Failed to connect to %s: %s", address, err)
+	}
+
+	return &remotePty{Conn: conn}, nil
+}
+
+func (pty *remotePty) Resize(cols uint16, rows uint16) error {
+	return nil
+}
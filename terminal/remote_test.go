@@ -0,0 +1,60 @@
+package terminal
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func echoOnce(t *testing.T, listener net.Listener) {
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		buf := make([]byte, 64)
+		n, _ := conn.Read(buf)
+		conn.Write(buf[:n])
+	}()
+}
+
+func TestDialRemote(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "aminal-remote")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	tcp, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	defer tcp.Close()
+
+	socket := filepath.Join(dir, "console.sock")
+	unix, err := net.Listen("unix", socket)
+	require.Nil(t, err)
+	defer unix.Close()
+
+	for address, listener := range map[string]net.Listener{
+		tcp.Addr().String(): tcp,
+		"unix:" + socket:    unix,
+	} {
+		echoOnce(t, listener)
+
+		pty, err := DialRemote(address, false)
+		require.Nil(t, err)
+
+		_, err = pty.Write([]byte("hello"))
+		require.Nil(t, err)
+		buf := make([]byte, 5)
+		_, err = pty.Read(buf)
+		require.Nil(t, err)
+		assert.Equal(t, "hello", string(buf))
+		assert.Nil(t, pty.Resize(80, 24))
+		pty.Close()
+	}
+}
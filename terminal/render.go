@@ -0,0 +1,75 @@
+package terminal
+
+import (
+	"strings"
+
+	"github.com/liamg/aminal/buffer"
+	"github.com/liamg/aminal/config"
+	"go.uber.org/zap"
+)
+
+// RenderColoured lays out text containing SGR sequences, such as the output of a syntax highlighter, as lines of cells
+// the given number of columns wide. Any other escape sequences are dropped, so the text can't move the cursor about
+// or change modes.
+func RenderColoured(text string, width uint16, conf *config.Config) []buffer.Line {
+	scratch := New(nil, zap.NewNop().Sugar(), conf)
+	buf := scratch.ActiveBuffer()
+	buf.ResizeView(width, 1)
+
+	runes := colourOnly(text)
+	pty := make(chan rune, len(runes))
+	for _, r := range runes {
+		pty <- r
+	}
+	for len(pty) > 0 {
+		scratch.processRune(<-pty, pty)
+	}
+
+	return buf.GetAllLines()
+}
+
+// colourOnly keeps the printable text, line breaks, tabs and complete SGR sequences, dropping everything else
+func colourOnly(text string) []rune {
+	in := []rune(strings.Replace(text, "\r\n", "\n", -1))
+	out := make([]rune, 0, len(in))
+	for i := 0; i < len(in); i++ {
+		r := in[i]
+		switch {
+		case r == 0x1b && i+1 < len(in) && in[i+1] == '[':
+			j := i + 2
+			for j < len(in) && in[j] >= 0x20 && in[j] <= 0x3f {
+				j++
+			}
+			if j < len(in) && in[j] == 'm' {
+				out = append(out, in[i:j+1]...)
+			}
+			if j < len(in) && in[j] >= 0x40 && in[j] <= 0x7e {
+				i = j
+			} else {
+				i = j - 1
+			}
+		case r == 0x1b && i+1 < len(in) && in[i+1] == ']':
+			// skip OSC strings up to the bell or string terminator
+			j := i + 2
+			for j < len(in) && in[j] != 0x07 && !(in[j] == 0x1b && j+1 < len(in) && in[j+1] == '\\') {
+				j++
+			}
+			if j < len(in) && in[j] == 0x1b {
+				j++
+			}
+			i = j
+		case r == 0x1b:
+			// skip two and three character sequences e.g. ESC = and ESC ( B
+			j := i + 1
+			for j < len(in) && in[j] >= 0x20 && in[j] <= 0x2f {
+				j++
+			}
+			i = j
+		case r == '\n':
+			out = append(out, '\r', '\n')
+		case r == '\t', r >= 0x20 && r != 0x7f:
+			out = append(out, r)
+		}
+	}
+	return out
+}
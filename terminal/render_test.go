@@ -0,0 +1,40 @@
+package terminal
+
+import (
+	"testing"
+
+	"github.com/liamg/aminal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderColoured(t *testing.T) {
+	conf := config.DefaultConfig
+	lines := RenderColoured("\x1b[31m-old\x1b[0m\n\x1b[32m+new\x1b[0m\n", 10, &conf)
+
+	require.True(t, len(lines) >= 2)
+	assert.Equal(t, "-old", lines[0].String())
+	assert.Equal(t, "+new", lines[1].String())
+
+	cells := lines[0].Cells()
+	assert.Equal(t, [3]float32(conf.ColourScheme.Red), cells[0].Fg())
+	cells = lines[1].Cells()
+	assert.Equal(t, [3]float32(conf.ColourScheme.Green), cells[0].Fg())
+}
+
+func TestRenderColouredWraps(t *testing.T) {
+	conf := config.DefaultConfig
+	lines := RenderColoured("abcdef", 4, &conf)
+
+	require.Len(t, lines, 2)
+	assert.Equal(t, "abcd", lines[0].String())
+	assert.Equal(t, "ef", lines[1].String())
+}
+
+func TestRenderColouredDropsOtherSequences(t *testing.T) {
+	conf := config.DefaultConfig
+	lines := RenderColoured("a\x1b[2J\x1b[Hb\x1b]0;title\x07c\x1b(Bd\x1b[?1049he\x1b[3", 20, &conf)
+
+	require.Len(t, lines, 1)
+	assert.Equal(t, "abcde", lines[0].String())
+}
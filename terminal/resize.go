@@ -0,0 +1,57 @@
+package terminal
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// resizeDelay is how long a requested size has to stay the same before it is applied, so that dragging the edge of the
+// window reflows the buffer and signals the program once, rather than for every step of the drag
+const resizeDelay = 50 * time.Millisecond
+
+// pendingSize is the latest size asked for with RequestSize, waiting to be applied by the parser
+type pendingSize struct {
+	sync.Mutex
+	cols  uint
+	lines uint
+	set   bool
+}
+
+// RequestSize resizes the terminal once the size has settled. While output is being parsed, the resize is applied by the
+// parser between runes, so it never lands part way through an escape sequence or a reflow, and the pty is told about
+// it at the same time as the buffer is reflowed. Otherwise it is applied straight away.
+func (terminal *Terminal) RequestSize(cols uint, lines uint) error {
+	if atomic.LoadInt32(&terminal.parsers) == 0 {
+		return terminal.SetSize(cols, lines)
+	}
+
+	terminal.pendingSize.Lock()
+	terminal.pendingSize.cols = cols
+	terminal.pendingSize.lines = lines
+	terminal.pendingSize.set = true
+	terminal.pendingSize.Unlock()
+
+	select {
+	case terminal.resizeChan <- true:
+	default:
+		// the parser has already been told, and will pick up the latest size
+	}
+	return nil
+}
+
+// applyPendingSize applies the latest size asked for with RequestSize, if there is one
+func (terminal *Terminal) applyPendingSize() {
+	terminal.pendingSize.Lock()
+	cols, lines, set := terminal.pendingSize.cols, terminal.pendingSize.lines, terminal.pendingSize.set
+	terminal.pendingSize.set = false
+	terminal.pendingSize.Unlock()
+
+	if !set {
+		return
+	}
+	if err := terminal.SetSize(cols, lines); err != nil {
+		terminal.logger.Errorf("Failed to resize terminal to %d cols, %d rows: %s", cols, lines, err)
+	}
+	terminal.SetDirty()
+}
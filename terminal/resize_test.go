@@ -0,0 +1,74 @@
+package terminal
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/liamg/aminal/config"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// resizingPty records the sizes it is given
+type resizingPty struct {
+	recordingPty
+	lock  sync.Mutex
+	sizes []string
+}
+
+func (pty *resizingPty) Resize(cols uint16, rows uint16) error {
+	pty.lock.Lock()
+	defer pty.lock.Unlock()
+	pty.sizes = append(pty.sizes, fmt.Sprintf("%dx%d", cols, rows))
+	return nil
+}
+
+func (pty *resizingPty) Sizes() []string {
+	pty.lock.Lock()
+	defer pty.lock.Unlock()
+	return append([]string{}, pty.sizes...)
+}
+
+func TestRequestSizeWithoutParserIsImmediate(t *testing.T) {
+	conf := config.DefaultConfig
+	pty := &resizingPty{}
+	term := New(pty, zap.NewNop().Sugar(), &conf)
+
+	assert.Nil(t, term.RequestSize(80, 24))
+
+	assert.Equal(t, []string{"80x24"}, pty.Sizes())
+	assert.Equal(t, uint16(80), term.ActiveBuffer().ViewWidth())
+}
+
+func TestRequestSizeCoalescesWhileParsing(t *testing.T) {
+	conf := config.DefaultConfig
+	pty := &resizingPty{}
+	term := New(pty, zap.NewNop().Sugar(), &conf)
+	assert.Nil(t, term.SetSize(40, 10))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	input := make(chan rune, 16)
+	go term.processInput(ctx, input)
+	for atomic.LoadInt32(&term.parsers) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	for _, cols := range []uint{50, 60, 70, 80} {
+		assert.Nil(t, term.RequestSize(cols, 24))
+	}
+
+	time.Sleep(resizeDelay * 3)
+	cancel()
+	for atomic.LoadInt32(&term.parsers) != 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	assert.Equal(t, []string{"40x10", "80x24"}, pty.Sizes())
+	assert.Equal(t, uint16(80), term.ActiveBuffer().ViewWidth())
+	assert.Equal(t, uint16(24), term.ActiveBuffer().ViewHeight())
+}
@@ -0,0 +1,136 @@
+package terminal
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+
+	"github.com/liamg/aminal/config"
+)
+
+// Window is the part of the window a Request can use. It is only safe to use on the GUI's thread.
+type Window interface {
+	GetClipboardString() (string, error)
+	SetClipboardString(str string)
+	Iconify() error
+	Restore() error
+	SetSize(width, height int)
+}
+
+// Request is something a program has asked for which only the window can do, such as using the clipboard. Requests
+// have already been checked against the security policy - if Ask is set, the user should be asked before running it.
+type Request struct {
+	Question string
+	Ask      bool
+	Run      func(window Window)
+}
+
+// Requests returns the channel requests are sent to once the security policy has allowed them
+func (terminal *Terminal) Requests() <-chan Request {
+	return terminal.requests
+}
+
+func (terminal *Terminal) request(policy config.Policy, question string, run func(window Window)) {
+	if policy != config.PolicyAllow && policy != config.PolicyAsk {
+		terminal.logger.Infof("Denied by security policy: %s", question)
+		return
+	}
+	select {
+	case terminal.requests <- Request{Question: question, Ask: policy == config.PolicyAsk, Run: run}:
+	default:
+		terminal.logger.Errorf("Too many requests waiting, dropped: %s", question)
+	}
+}
+
+// clipboardOSC handles OSC 52, which sets the clipboard to base64 encoded data, or reads it back if the data is "?".
+// Clipboard contents too big to send back within the clipboard limit are read back as empty.
+func (terminal *Terminal) clipboardOSC(selection string, data *base64Stream, terminator string) error {
+
+	if terminal.SecretInput() {
+		return fmt.Errorf("Refused clipboard access during secret input")
+	}
+
+	if data.isQuery() {
+		terminal.request(terminal.config.Security.ClipboardRead, "Allow the program to read the clipboard?", func(window Window) {
+			text, err := window.GetClipboardString()
+			if err != nil {
+				text = ""
+			}
+			encoded := base64.StdEncoding.EncodeToString([]byte(text))
+			if len(encoded) > terminal.config.Security.ClipboardLimit {
+				terminal.logger.Infof("Clipboard too big to read back: %d base64 characters", len(encoded))
+				encoded = ""
+			}
+			terminal.respond([]byte(fmt.Sprintf("\x1b]52;%s;%s%s", selection, encoded, terminator)))
+		})
+		return nil
+	}
+
+	text, err := data.Bytes()
+	if err != nil {
+		return fmt.Errorf("Invalid clipboard data: %s", err)
+	}
+	terminal.request(terminal.config.Security.ClipboardWrite, "Allow the program to set the clipboard?", func(window Window) {
+		window.SetClipboardString(terminal.config.Clipboard.Sanitise(string(text)))
+	})
+	return nil
+}
+
+func csiWindowManipulation(params []string, intermediate string, terminal *Terminal) error {
+
+	if len(params) == 0 {
+		return fmt.Errorf("Missing window manipulation operation")
+	}
+
+	policy := terminal.config.Security.WindowOps
+
+	switch params[0] {
+	case "1":
+		terminal.request(policy, "Allow the program to restore the window?", func(window Window) {
+			_ = window.Restore()
+		})
+	case "2":
+		terminal.request(policy, "Allow the program to minimise the window?", func(window Window) {
+			_ = window.Iconify()
+		})
+	case "8": // resize the text area, in characters
+		if len(params) < 3 {
+			return fmt.Errorf("Missing window size")
+		}
+		rows, err := strconv.Atoi(params[1])
+		if err != nil || rows < 1 {
+			return fmt.Errorf("Invalid window height: %s", params[1])
+		}
+		cols, err := strconv.Atoi(params[2])
+		if err != nil || cols < 1 {
+			return fmt.Errorf("Invalid window width: %s", params[2])
+		}
+		width, height := int(float32(cols)*terminal.charWidth), int(float32(rows)*terminal.charHeight)
+		if _, ok := terminal.pty.(*replayPty); ok {
+			// a replay resizes the window as it was when recorded, which isn't the program asking
+			policy = config.PolicyAllow
+		}
+		terminal.request(policy, fmt.Sprintf("Allow the program to resize the window to %dx%d?", cols, rows), func(window Window) {
+			window.SetSize(width, height)
+		})
+	case "18": // report the size of the text area, which programs can find out through the pty anyway
+		terminal.respond([]byte(fmt.Sprintf("\x1b[8;%d;%dt", terminal.size.Height, terminal.size.Width)))
+	case "21":
+		title := terminal.GetTitle()
+		terminal.request(terminal.config.Security.TitleReport, "Allow the program to read the window title?", func(window Window) {
+			terminal.respond([]byte(fmt.Sprintf("\x1b]l%s\x1b\\", title)))
+		})
+	case "22", "23": // push and pop the title, which are harmless to ignore
+	default:
+		return fmt.Errorf("Unsupported window manipulation: %s", params[0])
+	}
+	return nil
+}
+
+func enqSequenceHandler(terminal *Terminal) error {
+	message := terminal.config.Security.AnswerbackMessage
+	terminal.request(terminal.config.Security.Answerback, "Allow the program to read the answerback message?", func(window Window) {
+		terminal.respond([]byte(message))
+	})
+	return nil
+}
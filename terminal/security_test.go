@@ -0,0 +1,99 @@
+package terminal
+
+import (
+	"testing"
+
+	"github.com/liamg/aminal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type fakeWindow struct {
+	clipboard string
+	width     int
+	height    int
+}
+
+func (w *fakeWindow) GetClipboardString() (string, error) { return w.clipboard, nil }
+func (w *fakeWindow) SetClipboardString(str string)       { w.clipboard = str }
+func (w *fakeWindow) Iconify() error                      { return nil }
+func (w *fakeWindow) Restore() error                      { return nil }
+func (w *fakeWindow) SetSize(width, height int)           { w.width, w.height = width, height }
+
+func TestClipboardPolicy(t *testing.T) {
+
+	conf := config.DefaultConfig
+	conf.Security.ClipboardWrite = config.PolicyAllow
+	conf.Security.ClipboardRead = config.PolicyDeny
+	pty := &recordingPty{}
+	term := New(pty, zap.NewNop().Sugar(), &conf)
+	window := &fakeWindow{clipboard: "secret"}
+
+	require.Nil(t, parse(term, "\x1b]52;c;aGVsbG8=\x07"))
+	require.Len(t, term.requests, 1)
+	request := <-term.Requests()
+	assert.False(t, request.Ask)
+	request.Run(window)
+	assert.Equal(t, "hello", window.clipboard)
+
+	require.Nil(t, parse(term, "\x1b]52;c;?\x07"))
+	assert.Len(t, term.requests, 0)
+
+	conf.Security.ClipboardRead = config.PolicyAsk
+	require.Nil(t, parse(term, "\x1b]52;c;?\x07"))
+	require.Len(t, term.requests, 1)
+	request = <-term.Requests()
+	assert.True(t, request.Ask)
+	request.Run(window)
+	assert.Equal(t, "\x1b]52;c;aGVsbG8=\x07", pty.String())
+}
+
+func TestClipboardLimit(t *testing.T) {
+
+	conf := config.DefaultConfig
+	conf.Security.ClipboardRead = config.PolicyAllow
+	conf.Security.ClipboardLimit = 8
+	pty := &recordingPty{}
+	term := New(pty, zap.NewNop().Sugar(), &conf)
+	window := &fakeWindow{clipboard: "hello"}
+
+	assert.NotNil(t, parse(term, "\x1b]52;c;aGVsbG8gd29ybGQ=\x07after"))
+	assert.Len(t, term.requests, 0)
+	assert.Equal(t, "after", term.ActiveBuffer().GetAllText())
+
+	require.Nil(t, parse(term, "\x1b]52;c;aGVsbG8=\x07"))
+	require.Len(t, term.requests, 1)
+	(<-term.Requests()).Run(window)
+	assert.Equal(t, "hello", window.clipboard)
+
+	// the reply ends like the query did, and is empty if it would be too long
+	require.Nil(t, parse(term, "\x1b]52;c;?\x1b\\"))
+	(<-term.Requests()).Run(window)
+	assert.Equal(t, "\x1b]52;c;aGVsbG8=\x1b\\", pty.String())
+	pty.Reset()
+
+	window.clipboard = "hello world"
+	require.Nil(t, parse(term, "\x1b]52;c;?\x07"))
+	(<-term.Requests()).Run(window)
+	assert.Equal(t, "\x1b]52;c;\x07", pty.String())
+}
+
+func TestWindowOpsPolicy(t *testing.T) {
+
+	conf := config.DefaultConfig
+	pty := &recordingPty{}
+	term := New(pty, zap.NewNop().Sugar(), &conf)
+	term.SetCharSize(10, 20)
+
+	require.Nil(t, csiWindowManipulation([]string{"8", "24", "80"}, "", term))
+	assert.Len(t, term.requests, 0)
+
+	conf.Security.WindowOps = config.PolicyAllow
+	require.Nil(t, csiWindowManipulation([]string{"8", "24", "80"}, "", term))
+	require.Len(t, term.requests, 1)
+	window := &fakeWindow{}
+	(<-term.Requests()).Run(window)
+	assert.Equal(t, 800, window.width)
+	assert.Equal(t, 480, window.height)
+}
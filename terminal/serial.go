@@ -0,0 +1,81 @@
+package terminal
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// serialPty is a serial line, e.g. the console of a board plugged in over USB. There is no shell at the other end to
+// tell about size changes, so resizing does nothing.
+type serialPty struct {
+	*os.File
+}
+
+// OpenSerial opens a serial device in raw mode at the given baud rate, with parity "none", "even" or "odd"
+func OpenSerial(device string, baud int, parity string) (Pty, error) {
+
+	f, err := os.OpenFile(device, os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open serial device: %s", err)
+	}
+
+	if err := configureSerial(f.Fd(), baud, parity); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &serialPty{File: f}, nil
+}
+
+func (pty *serialPty) Resize(cols uint16, rows uint16) error {
+	return nil
+}
+
+func configureSerial(fd uintptr, baud int, parity string) error {
+
+	var termios syscall.Termios
+	if err := ioctlTermios(fd, ioctlGetTermios, &termios); err != nil {
+		return fmt.Errorf("Failed to read serial line settings: %s", err)
+	}
+
+	// raw mode - everything typed goes down the line untouched, and everything received is handed to the terminal
+	termios.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP | syscall.INLCR |
+		syscall.IGNCR | syscall.ICRNL | syscall.IXON | syscall.INPCK
+	termios.Oflag &^= syscall.OPOST
+	termios.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	termios.Cflag &^= syscall.CSIZE | syscall.PARENB | syscall.PARODD | syscall.CSTOPB
+	termios.Cflag |= syscall.CS8 | syscall.CREAD | syscall.CLOCAL
+	termios.Cc[syscall.VMIN] = 1
+	termios.Cc[syscall.VTIME] = 0
+
+	switch parity {
+	case "none", "":
+	case "even":
+		termios.Cflag |= syscall.PARENB
+		termios.Iflag |= syscall.INPCK
+	case "odd":
+		termios.Cflag |= syscall.PARENB | syscall.PARODD
+		termios.Iflag |= syscall.INPCK
+	default:
+		return fmt.Errorf("Invalid parity '%s': should be none, even or odd", parity)
+	}
+
+	if err := setSpeed(&termios, baud); err != nil {
+		return err
+	}
+
+	if err := ioctlTermios(fd, ioctlSetTermios, &termios); err != nil {
+		return fmt.Errorf("Failed to apply serial line settings: %s", err)
+	}
+	return nil
+}
+
+func ioctlTermios(fd uintptr, request uintptr, termios *syscall.Termios) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, request, uintptr(unsafe.Pointer(termios)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package terminal
+
+import (
+	"fmt"
+	"syscall"
+)
+
+const (
+	ioctlGetTermios = syscall.TIOCGETA
+	ioctlSetTermios = syscall.TIOCSETA
+)
+
+func setSpeed(termios *syscall.Termios, baud int) error {
+	// macOS takes the speed as a plain number, so any rate the driver supports can be asked for
+	if baud <= 0 {
+		return fmt.Errorf("Unsupported baud rate %d", baud)
+	}
+	termios.Ispeed = uint64(baud)
+	termios.Ospeed = uint64(baud)
+	return nil
+}
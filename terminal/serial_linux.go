@@ -0,0 +1,42 @@
+package terminal
+
+import (
+	"fmt"
+	"syscall"
+)
+
+const (
+	ioctlGetTermios = syscall.TCGETS
+	ioctlSetTermios = syscall.TCSETS
+	cbaud           = 0x100f // the bits of Cflag which hold the speed
+)
+
+var baudRates = map[int]uint32{
+	1200:    syscall.B1200,
+	2400:    syscall.B2400,
+	4800:    syscall.B4800,
+	9600:    syscall.B9600,
+	19200:   syscall.B19200,
+	38400:   syscall.B38400,
+	57600:   syscall.B57600,
+	115200:  syscall.B115200,
+	230400:  syscall.B230400,
+	460800:  syscall.B460800,
+	921600:  syscall.B921600,
+	1000000: syscall.B1000000,
+	1500000: syscall.B1500000,
+	2000000: syscall.B2000000,
+	3000000: syscall.B3000000,
+	4000000: syscall.B4000000,
+}
+
+func setSpeed(termios *syscall.Termios, baud int) error {
+	speed, ok := baudRates[baud]
+	if !ok {
+		return fmt.Errorf("Unsupported baud rate %d", baud)
+	}
+	termios.Cflag = termios.Cflag&^cbaud | speed
+	termios.Ispeed = speed
+	termios.Ospeed = speed
+	return nil
+}
@@ -0,0 +1,50 @@
+package terminal
+
+import (
+	"syscall"
+	"testing"
+
+	"github.com/kr/pty"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenSerial(t *testing.T) {
+
+	// a pty's slave side takes the same line settings as a serial port, so it stands in for one - except for parity,
+	// which the pty driver ignores
+	master, tty, err := pty.Open()
+	require.Nil(t, err)
+	defer master.Close()
+	defer tty.Close()
+
+	serial, err := OpenSerial(tty.Name(), 9600, "even")
+	require.Nil(t, err)
+	defer serial.Close()
+
+	var termios syscall.Termios
+	require.Nil(t, ioctlTermios(tty.Fd(), ioctlGetTermios, &termios))
+	assert.Zero(t, termios.Lflag&syscall.ICANON)
+	assert.Zero(t, termios.Lflag&syscall.ECHO)
+
+	// raw mode passes bytes through without waiting for a newline or translating them
+	_, err = master.Write([]byte("ok\r"))
+	require.Nil(t, err)
+	buf := make([]byte, 3)
+	n, err := serial.Read(buf)
+	require.Nil(t, err)
+	assert.Equal(t, "ok\r", string(buf[:n]))
+}
+
+func TestOpenSerialRejectsBadSettings(t *testing.T) {
+	master, tty, err := pty.Open()
+	require.Nil(t, err)
+	defer master.Close()
+	defer tty.Close()
+
+	_, err = OpenSerial(tty.Name(), 9600, "mark")
+	assert.NotNil(t, err)
+
+	_, err = OpenSerial(tty.Name(), 12345, "none")
+	assert.NotNil(t, err)
+}
@@ -15,23 +15,30 @@ func sgrSequenceHandler(params []string, intermediate string, terminal *Terminal
 		params = []string{"0"}
 	}
 
-	for i := range params {
+	for i := 0; i < len(params); i++ {
 
 		p := strings.Replace(strings.Replace(params[i], "[", "", -1), "]", "", -1)
 
+		if strings.Contains(p, ":") {
+			if err := terminal.handleSGRSubParams(strings.Split(p, ":")); err != nil {
+				return err
+			}
+			continue
+		}
+
 		switch p {
 		case "00", "0":
 			attr := terminal.ActiveBuffer().CursorAttr()
-			*attr = buffer.CellAttributes{
-				FgColour: terminal.config.ColourScheme.Foreground,
-				BgColour: terminal.config.ColourScheme.Background,
-			}
+			// the zone isn't a display attribute, it is only changed by OSC 133
+			zone := attr.Zone
+			*attr = defaultAttributes(terminal.config.ColourScheme)
+			attr.Zone = zone
 		case "1", "01":
 			terminal.ActiveBuffer().CursorAttr().Bold = true
 		case "2", "02":
 			terminal.ActiveBuffer().CursorAttr().Dim = true
 		case "4", "04":
-			terminal.ActiveBuffer().CursorAttr().Underline = true
+			terminal.ActiveBuffer().CursorAttr().Underline = buffer.UnderlineSingle
 		case "5", "05":
 			terminal.ActiveBuffer().CursorAttr().Blink = true
 		case "7", "07":
@@ -45,7 +52,7 @@ func sgrSequenceHandler(params []string, intermediate string, terminal *Terminal
 		case "23":
 			// not italic
 		case "24":
-			terminal.ActiveBuffer().CursorAttr().Underline = false
+			terminal.ActiveBuffer().CursorAttr().Underline = buffer.UnderlineNone
 		case "25":
 			terminal.ActiveBuffer().CursorAttr().Blink = false
 		case "27":
@@ -55,87 +62,99 @@ func sgrSequenceHandler(params []string, intermediate string, terminal *Terminal
 		case "29":
 			// not strikethrough
 		case "39":
-			terminal.ActiveBuffer().CursorAttr().FgColour = terminal.config.ColourScheme.Foreground
+			terminal.setForeground(config.ColourForeground)
 		case "30":
-			terminal.ActiveBuffer().CursorAttr().FgColour = terminal.config.ColourScheme.Black
+			terminal.setForeground(0)
 		case "31":
-			terminal.ActiveBuffer().CursorAttr().FgColour = terminal.config.ColourScheme.Red
+			terminal.setForeground(1)
 		case "32":
-			terminal.ActiveBuffer().CursorAttr().FgColour = terminal.config.ColourScheme.Green
+			terminal.setForeground(2)
 		case "33":
-			terminal.ActiveBuffer().CursorAttr().FgColour = terminal.config.ColourScheme.Yellow
+			terminal.setForeground(3)
 		case "34":
-			terminal.ActiveBuffer().CursorAttr().FgColour = terminal.config.ColourScheme.Blue
+			terminal.setForeground(4)
 		case "35":
-			terminal.ActiveBuffer().CursorAttr().FgColour = terminal.config.ColourScheme.Magenta
+			terminal.setForeground(5)
 		case "36":
-			terminal.ActiveBuffer().CursorAttr().FgColour = terminal.config.ColourScheme.Cyan
+			terminal.setForeground(6)
 		case "37":
-			terminal.ActiveBuffer().CursorAttr().FgColour = terminal.config.ColourScheme.White
+			terminal.setForeground(7)
 		case "90":
-			terminal.ActiveBuffer().CursorAttr().FgColour = terminal.config.ColourScheme.DarkGrey
+			terminal.setForeground(8)
 		case "91":
-			terminal.ActiveBuffer().CursorAttr().FgColour = terminal.config.ColourScheme.LightRed
+			terminal.setForeground(9)
 		case "92":
-			terminal.ActiveBuffer().CursorAttr().FgColour = terminal.config.ColourScheme.LightGreen
+			terminal.setForeground(10)
 		case "93":
-			terminal.ActiveBuffer().CursorAttr().FgColour = terminal.config.ColourScheme.LightYellow
+			terminal.setForeground(11)
 		case "94":
-			terminal.ActiveBuffer().CursorAttr().FgColour = terminal.config.ColourScheme.LightBlue
+			terminal.setForeground(12)
 		case "95":
-			terminal.ActiveBuffer().CursorAttr().FgColour = terminal.config.ColourScheme.LightMagenta
+			terminal.setForeground(13)
 		case "96":
-			terminal.ActiveBuffer().CursorAttr().FgColour = terminal.config.ColourScheme.LightCyan
+			terminal.setForeground(14)
 		case "97":
-			terminal.ActiveBuffer().CursorAttr().FgColour = terminal.config.ColourScheme.White
+			terminal.setForeground(15)
 		case "49":
-			terminal.ActiveBuffer().CursorAttr().BgColour = terminal.config.ColourScheme.Background
+			terminal.setBackground(config.ColourBackground)
 		case "40":
-			terminal.ActiveBuffer().CursorAttr().BgColour = terminal.config.ColourScheme.Black
+			terminal.setBackground(0)
 		case "41":
-			terminal.ActiveBuffer().CursorAttr().BgColour = terminal.config.ColourScheme.Red
+			terminal.setBackground(1)
 		case "42":
-			terminal.ActiveBuffer().CursorAttr().BgColour = terminal.config.ColourScheme.Green
+			terminal.setBackground(2)
 		case "43":
-			terminal.ActiveBuffer().CursorAttr().BgColour = terminal.config.ColourScheme.Yellow
+			terminal.setBackground(3)
 		case "44":
-			terminal.ActiveBuffer().CursorAttr().BgColour = terminal.config.ColourScheme.Blue
+			terminal.setBackground(4)
 		case "45":
-			terminal.ActiveBuffer().CursorAttr().BgColour = terminal.config.ColourScheme.Magenta
+			terminal.setBackground(5)
 		case "46":
-			terminal.ActiveBuffer().CursorAttr().BgColour = terminal.config.ColourScheme.Cyan
+			terminal.setBackground(6)
 		case "47":
-			terminal.ActiveBuffer().CursorAttr().BgColour = terminal.config.ColourScheme.White
+			terminal.setBackground(7)
 		case "100":
-			terminal.ActiveBuffer().CursorAttr().BgColour = terminal.config.ColourScheme.DarkGrey
+			terminal.setBackground(8)
 		case "101":
-			terminal.ActiveBuffer().CursorAttr().BgColour = terminal.config.ColourScheme.LightRed
+			terminal.setBackground(9)
 		case "102":
-			terminal.ActiveBuffer().CursorAttr().BgColour = terminal.config.ColourScheme.LightGreen
+			terminal.setBackground(10)
 		case "103":
-			terminal.ActiveBuffer().CursorAttr().BgColour = terminal.config.ColourScheme.LightYellow
+			terminal.setBackground(11)
 		case "104":
-			terminal.ActiveBuffer().CursorAttr().BgColour = terminal.config.ColourScheme.LightBlue
+			terminal.setBackground(12)
 		case "105":
-			terminal.ActiveBuffer().CursorAttr().BgColour = terminal.config.ColourScheme.LightMagenta
+			terminal.setBackground(13)
 		case "106":
-			terminal.ActiveBuffer().CursorAttr().BgColour = terminal.config.ColourScheme.LightCyan
+			terminal.setBackground(14)
 		case "107":
-			terminal.ActiveBuffer().CursorAttr().BgColour = terminal.config.ColourScheme.White
+			terminal.setBackground(15)
 		case "38": // set foreground
-			c, err := terminal.getANSIColour(params[i:])
+			c, index, n, err := terminal.getANSIColour(params[i:])
 			if err != nil {
 				return err
 			}
 			terminal.ActiveBuffer().CursorAttr().FgColour = c
-			return nil
+			terminal.ActiveBuffer().CursorAttr().FgIndex = index
+			i += n
 		case "48": // set background
-			c, err := terminal.getANSIColour(params[i:])
+			c, index, n, err := terminal.getANSIColour(params[i:])
 			if err != nil {
 				return err
 			}
 			terminal.ActiveBuffer().CursorAttr().BgColour = c
-			return nil
+			terminal.ActiveBuffer().CursorAttr().BgIndex = index
+			i += n
+		case "58": // set underline colour
+			c, _, n, err := terminal.getANSIColour(params[i:])
+			if err != nil {
+				return err
+			}
+			terminal.ActiveBuffer().CursorAttr().UnderlineColour = c
+			terminal.ActiveBuffer().CursorAttr().UnderlineColoured = true
+			i += n
+		case "59":
+			terminal.ActiveBuffer().CursorAttr().UnderlineColoured = false
 		default:
 			return fmt.Errorf("Unknown SGR control sequence: (ESC[%s%sm)", params[i:], intermediate)
 		}
@@ -146,7 +165,60 @@ func sgrSequenceHandler(params []string, intermediate string, terminal *Terminal
 	return nil
 }
 
-func (terminal *Terminal) getANSIColour(params []string) (config.Colour, error) {
+// underlineStyles are the styles of SGR 4:n, as set by kitty-aware programs
+// such as neovim for spell checking
+var underlineStyles = map[string]buffer.UnderlineStyle{
+	"0": buffer.UnderlineNone,
+	"1": buffer.UnderlineSingle,
+	"2": buffer.UnderlineDouble,
+	"3": buffer.UnderlineCurly,
+	"4": buffer.UnderlineDotted,
+	"5": buffer.UnderlineDashed,
+}
+
+// handleSGRSubParams handles the colon separated form of the extended colour
+// sequences, e.g. ESC[38:2::r:g:bm, which some programs send instead of
+// semicolons. The colour space id of the ISO 8613-6 form is optional. It also
+// handles underline styles, e.g. ESC[4:3m for a curly underline.
+func (terminal *Terminal) handleSGRSubParams(sub []string) error {
+	if sub[0] == "4" {
+		style, ok := underlineStyles[sub[1]]
+		if !ok || len(sub) != 2 {
+			return fmt.Errorf("Unknown underline style: (ESC[%sm)", strings.Join(sub, ":"))
+		}
+		terminal.ActiveBuffer().CursorAttr().Underline = style
+		return nil
+	}
+
+	if len(sub) > 5 && sub[1] == "2" {
+		sub = append([]string{sub[0], sub[1]}, sub[3:]...)
+	}
+
+	c, index, _, err := terminal.getANSIColour(sub)
+	if err != nil {
+		return err
+	}
+
+	switch sub[0] {
+	case "38":
+		terminal.ActiveBuffer().CursorAttr().FgColour = c
+		terminal.ActiveBuffer().CursorAttr().FgIndex = index
+	case "48":
+		terminal.ActiveBuffer().CursorAttr().BgColour = c
+		terminal.ActiveBuffer().CursorAttr().BgIndex = index
+	case "58":
+		terminal.ActiveBuffer().CursorAttr().UnderlineColour = c
+		terminal.ActiveBuffer().CursorAttr().UnderlineColoured = true
+	default:
+		return fmt.Errorf("Unknown SGR control sequence: (ESC[%sm)", strings.Join(sub, ":"))
+	}
+	return nil
+}
+
+// getANSIColour parses an extended colour from params, where params[0] is the
+// 38 or 48 that introduced it. It returns the colour and where it was chosen
+// from, along with the number of params after params[0] that it used up.
+func (terminal *Terminal) getANSIColour(params []string) (config.Colour, buffer.ColourIndex, int, error) {
 
 	if len(params) > 2 {
 		switch params[1] {
@@ -155,110 +227,51 @@ func (terminal *Terminal) getANSIColour(params []string) (config.Colour, error)
 			colNum, err := strconv.Atoi(params[2])
 
 			if err != nil || colNum >= 256 || colNum < 0 {
-				return [3]float32{0, 0, 0}, fmt.Errorf("Invalid 8-bit colour specifier")
+				return [3]float32{0, 0, 0}, buffer.ColourRGB, 0, fmt.Errorf("Invalid 8-bit colour specifier")
 			}
-			return terminal.get8BitSGRColour(uint8(colNum)), nil
+			return terminal.config.ColourScheme.PaletteColour(colNum), buffer.PaletteColour(colNum), 2, nil
 
 		case "2":
-			if len(params) < 4 {
-				return [3]float32{0, 0, 0}, fmt.Errorf("Invalid true colour specifier")
-			}
 			// 24 bit colour
-			if len(params) == 5 { // standard true colour
-
-				r, err := strconv.Atoi(params[2])
-				if err != nil {
-					return [3]float32{0, 0, 0}, fmt.Errorf("Invalid true colour specifier")
-				}
-				g, err := strconv.Atoi(params[3])
-				if err != nil {
-					return [3]float32{0, 0, 0}, fmt.Errorf("Invalid true colour specifier")
-				}
-				b, err := strconv.Atoi(params[4])
-				if err != nil {
-					return [3]float32{0, 0, 0}, fmt.Errorf("Invalid true colour specifier")
-				}
-				return [3]float32{
-					float32(r) / 0xff,
-					float32(g) / 0xff,
-					float32(b) / 0xff,
-				}, nil
-			} else if len(params) > 5 { // ISO/IEC International Standard 8613-6
-				r, err := strconv.Atoi(params[3])
-				if err != nil {
-					return [3]float32{0, 0, 0}, fmt.Errorf("Invalid true colour specifier")
-				}
-				g, err := strconv.Atoi(params[4])
-				if err != nil {
-					return [3]float32{0, 0, 0}, fmt.Errorf("Invalid true colour specifier")
-				}
-				b, err := strconv.Atoi(params[5])
-				if err != nil {
-					return [3]float32{0, 0, 0}, fmt.Errorf("Invalid true colour specifier")
+			if len(params) < 5 {
+				return [3]float32{0, 0, 0}, buffer.ColourRGB, 0, fmt.Errorf("Invalid true colour specifier")
+			}
+			var rgb [3]float32
+			for j := range rgb {
+				v, err := strconv.Atoi(params[2+j])
+				if err != nil || v > 0xff || v < 0 {
+					return [3]float32{0, 0, 0}, buffer.ColourRGB, 0, fmt.Errorf("Invalid true colour specifier")
 				}
-				return [3]float32{
-					float32(r) / 0xff,
-					float32(g) / 0xff,
-					float32(b) / 0xff,
-				}, nil
+				rgb[j] = float32(v) / 0xff
 			}
+			return rgb, buffer.ColourRGB, 4, nil
 		}
 	}
 
-	return [3]float32{}, fmt.Errorf("Unknown ANSI colour format identifier")
+	return [3]float32{}, buffer.ColourRGB, 0, fmt.Errorf("Unknown ANSI colour format identifier")
 
 }
 
-func (terminal *Terminal) get8BitSGRColour(colNum uint8) [3]float32 {
-
-	// https://en.wikipedia.org/wiki/ANSI_escape_code#8-bit
-
-	switch colNum {
-	case 0:
-		return terminal.config.ColourScheme.Black
-	case 1:
-		return terminal.config.ColourScheme.Red
-	case 2:
-		return terminal.config.ColourScheme.Green
-	case 3:
-		return terminal.config.ColourScheme.Yellow
-	case 4:
-		return terminal.config.ColourScheme.Blue
-	case 5:
-		return terminal.config.ColourScheme.Magenta
-	case 6:
-		return terminal.config.ColourScheme.Cyan
-	case 7:
-		return terminal.config.ColourScheme.White
-	case 8:
-		return terminal.config.ColourScheme.DarkGrey
-	case 9:
-		return terminal.config.ColourScheme.LightRed
-	case 10:
-		return terminal.config.ColourScheme.LightGreen
-	case 11:
-		return terminal.config.ColourScheme.LightYellow
-	case 12:
-		return terminal.config.ColourScheme.LightBlue
-	case 13:
-		return terminal.config.ColourScheme.LightMagenta
-	case 14:
-		return terminal.config.ColourScheme.LightCyan
-	case 15:
-		return terminal.config.ColourScheme.White
-	}
-
-	if colNum < 232 {
+// setForeground sets the colour text is written in to one of the palette or default colours
+func (terminal *Terminal) setForeground(index int) {
+	attr := terminal.ActiveBuffer().CursorAttr()
+	attr.FgColour = terminal.config.ColourScheme.PaletteColour(index)
+	attr.FgIndex = buffer.PaletteColour(index)
+}
 
-		index := int(colNum - 16) // 0-216
-		rgb := (index * 0xffffff) / 216
-		r := float32((rgb&0xff0000)>>16) / 0xff
-		g := float32((rgb&0xff00)>>8) / 0xff
-		b := float32(rgb&0xff) / 0xff
+// setBackground sets the colour text is written on to one of the palette or default colours
+func (terminal *Terminal) setBackground(index int) {
+	attr := terminal.ActiveBuffer().CursorAttr()
+	attr.BgColour = terminal.config.ColourScheme.PaletteColour(index)
+	attr.BgIndex = buffer.PaletteColour(index)
+}
 
-		return [3]float32{r, g, b}
+// defaultAttributes returns the attributes text is written in after a reset, in the default colours
+func defaultAttributes(scheme config.ColourScheme) buffer.CellAttributes {
+	return buffer.CellAttributes{
+		FgColour: scheme.Foreground,
+		BgColour: scheme.Background,
+		FgIndex:  buffer.PaletteColour(config.ColourForeground),
+		BgIndex:  buffer.PaletteColour(config.ColourBackground),
 	}
-
-	c := float32(colNum-232) / 0x18
-	return [3]float32{c, c, c}
 }
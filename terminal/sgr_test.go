@@ -0,0 +1,112 @@
+package terminal
+
+import (
+	"testing"
+
+	"github.com/liamg/aminal/buffer"
+	"github.com/liamg/aminal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sgrAttr(t *testing.T, params ...string) (*Terminal, [3]float32, [3]float32) {
+	term, _ := newTestTerminal()
+	require.Nil(t, sgrSequenceHandler(params, "", term))
+	attr := term.ActiveBuffer().CursorAttr()
+	return term, [3]float32(attr.FgColour), [3]float32(attr.BgColour)
+}
+
+func TestSGRIndexedColours(t *testing.T) {
+	term, fg, bg := sgrAttr(t, "38", "5", "1", "48", "5", "196")
+	assert.Equal(t, [3]float32(term.config.ColourScheme.Red), fg)
+	assert.Equal(t, [3]float32{1, 0, 0}, bg)
+
+	_, fg, bg = sgrAttr(t, "38", "5", "67", "48", "5", "244")
+	assert.Equal(t, [3]float32{float32(0x5f) / 0xff, float32(0x87) / 0xff, float32(0xaf) / 0xff}, fg)
+	assert.Equal(t, [3]float32{float32(0x80) / 0xff, float32(0x80) / 0xff, float32(0x80) / 0xff}, bg)
+}
+
+func TestSGRTrueColour(t *testing.T) {
+	_, fg, bg := sgrAttr(t, "38", "2", "255", "128", "0", "48", "2", "0", "0", "255")
+	assert.Equal(t, [3]float32{1, float32(128) / 0xff, 0}, fg)
+	assert.Equal(t, [3]float32{0, 0, 1}, bg)
+}
+
+func TestSGRColoursRememberWhereTheyCameFrom(t *testing.T) {
+	term, _ := newTestTerminal()
+	attr := term.ActiveBuffer().CursorAttr()
+	assert.Equal(t, buffer.PaletteColour(config.ColourForeground), attr.FgIndex)
+	assert.Equal(t, buffer.PaletteColour(config.ColourBackground), attr.BgIndex)
+
+	require.Nil(t, sgrSequenceHandler([]string{"31", "104"}, "", term))
+	assert.Equal(t, buffer.PaletteColour(1), attr.FgIndex)
+	assert.Equal(t, buffer.PaletteColour(12), attr.BgIndex)
+
+	require.Nil(t, sgrSequenceHandler([]string{"38", "5", "200", "48", "2", "1", "2", "3"}, "", term))
+	assert.Equal(t, buffer.PaletteColour(200), attr.FgIndex)
+	assert.Equal(t, buffer.ColourRGB, attr.BgIndex)
+
+	require.Nil(t, sgrSequenceHandler([]string{"0"}, "", term))
+	assert.Equal(t, buffer.PaletteColour(config.ColourForeground), attr.FgIndex)
+	assert.Equal(t, buffer.PaletteColour(config.ColourBackground), attr.BgIndex)
+}
+
+func TestSGRParamsAfterExtendedColour(t *testing.T) {
+	term, _, _ := sgrAttr(t, "38", "2", "1", "2", "3", "1", "4")
+	assert.True(t, term.ActiveBuffer().CursorAttr().Bold)
+	assert.Equal(t, buffer.UnderlineSingle, term.ActiveBuffer().CursorAttr().Underline)
+}
+
+func TestSGRColonSubParams(t *testing.T) {
+	_, fg, bg := sgrAttr(t, "38:2::255:0:0", "48:5:21")
+	assert.Equal(t, [3]float32{1, 0, 0}, fg)
+	assert.Equal(t, [3]float32{0, 0, 1}, bg)
+
+	_, fg, _ = sgrAttr(t, "38:2:0:255:0")
+	assert.Equal(t, [3]float32{0, 1, 0}, fg)
+}
+
+func TestSGRInvalidExtendedColour(t *testing.T) {
+	term, _ := newTestTerminal()
+	assert.NotNil(t, sgrSequenceHandler([]string{"38", "2", "1", "2"}, "", term))
+	assert.NotNil(t, sgrSequenceHandler([]string{"38", "2", "1", "2", "256"}, "", term))
+	assert.NotNil(t, sgrSequenceHandler([]string{"48", "5", "256"}, "", term))
+}
+
+func TestSGRUnderlineStyles(t *testing.T) {
+	term, _ := newTestTerminal()
+	attr := term.ActiveBuffer().CursorAttr()
+
+	require.Nil(t, sgrSequenceHandler([]string{"4:3"}, "", term))
+	assert.Equal(t, buffer.UnderlineCurly, attr.Underline)
+	require.Nil(t, sgrSequenceHandler([]string{"4:5"}, "", term))
+	assert.Equal(t, buffer.UnderlineDashed, attr.Underline)
+	require.Nil(t, sgrSequenceHandler([]string{"4:0"}, "", term))
+	assert.Equal(t, buffer.UnderlineNone, attr.Underline)
+	require.Nil(t, sgrSequenceHandler([]string{"4"}, "", term))
+	assert.Equal(t, buffer.UnderlineSingle, attr.Underline)
+	require.Nil(t, sgrSequenceHandler([]string{"24"}, "", term))
+	assert.Equal(t, buffer.UnderlineNone, attr.Underline)
+
+	assert.NotNil(t, sgrSequenceHandler([]string{"4:6"}, "", term))
+}
+
+func TestSGRUnderlineColour(t *testing.T) {
+	term, _ := newTestTerminal()
+	attr := term.ActiveBuffer().CursorAttr()
+
+	require.Nil(t, sgrSequenceHandler([]string{"58", "2", "255", "0", "0"}, "", term))
+	assert.True(t, attr.UnderlineColoured)
+	assert.Equal(t, [3]float32{1, 0, 0}, attr.UnderlineColour)
+
+	require.Nil(t, sgrSequenceHandler([]string{"58:2::0:0:255"}, "", term))
+	assert.Equal(t, [3]float32{0, 0, 1}, attr.UnderlineColour)
+
+	require.Nil(t, sgrSequenceHandler([]string{"59"}, "", term))
+	assert.False(t, attr.UnderlineColoured)
+
+	require.Nil(t, sgrSequenceHandler([]string{"58", "5", "196", "4:3"}, "", term))
+	assert.True(t, attr.UnderlineColoured)
+	assert.Equal(t, [3]float32{1, 0, 0}, attr.UnderlineColour)
+	assert.Equal(t, buffer.UnderlineCurly, attr.Underline)
+}
@@ -3,70 +3,71 @@ package terminal
 import (
 	"fmt"
 	"image"
-	"image/draw"
 	"math"
-	"strings"
 
 	"github.com/liamg/aminal/sixel"
 )
 
-func sixelHandler(pty chan rune, terminal *Terminal) error {
+// sixelString reads a sixel image, DCS Ps q ... ST. The image is decoded as the data arrives, rather than holding on to
+// what could be megabytes of it.
+type sixelString struct {
+	decoder *sixel.Decoder
+	length  int
+}
 
-	data := []rune{}
+func newSixelString(param string) *sixelString {
+	str := &sixelString{decoder: sixel.NewDecoder()}
+	for _, r := range param + "q" {
+		_ = str.decoder.Write(r)
+	}
+	return str
+}
 
-	for {
-		b := <-pty
-		if b == 0x1b { // terminated by ESC bell or ESC \
-			_ = <-pty // swallow \ or bell
-			break
-		}
-		if b >= 33 {
-			data = append(data, b)
-		}
+func (str *sixelString) put(b rune) {
+	if b >= 33 && str.length <= maxDCSLength {
+		str.length++
+		_ = str.decoder.Write(b)
+	}
+}
+
+func (str *sixelString) end(terminal *Terminal, terminator string) error {
+
+	if str.length > maxDCSLength {
+		return fmt.Errorf("Sixel data too long")
+	}
+	if err := terminal.checkCellSize(); err != nil {
+		return err
 	}
 
-	six, err := sixel.ParseString(string(data))
+	six, err := str.decoder.Sixel()
 	if err != nil {
 		return fmt.Errorf("Failed to parse sixel data: %s", err)
 	}
 
-	originalImage := six.RGBA()
-
-	w := originalImage.Bounds().Size().X
-	h := originalImage.Bounds().Size().Y
-
-	x, y := terminal.ActiveBuffer().CursorColumn(), terminal.ActiveBuffer().CursorLine()
+	img := terminal.fitToScreen(six.RGBA())
+	width := float32(img.Bounds().Dx()) / terminal.charWidth
+	height := float32(img.Bounds().Dy()) / terminal.charHeight
+	terminal.placeImage(img, 0, width, height, cursorBelowImage)
+	return nil
+}
 
-	fromBottom := int(terminal.ActiveBuffer().ViewHeight() - y)
-	lines := int(math.Ceil(float64(h) / float64(terminal.charHeight)))
-	if fromBottom < lines+2 {
-		y -= (uint16(lines+2) - uint16(fromBottom))
+// fitImage scales an image down to fit within the given size, keeping its aspect ratio
+func fitImage(img *image.RGBA, maxWidth int, maxHeight int) *image.RGBA {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if maxWidth <= 0 || maxHeight <= 0 || (w <= maxWidth && h <= maxHeight) {
+		return img
 	}
-	for l := 0; l <= int(lines); l++ {
-		terminal.ActiveBuffer().Write([]rune(strings.Repeat(" ", int(terminal.ActiveBuffer().ViewWidth())))...)
-		terminal.ActiveBuffer().NewLine()
-	}
-	cols := int(math.Ceil(float64(w) / float64(terminal.charWidth)))
-
-	for offsetY := 0; offsetY < lines-1; offsetY++ {
-		for offsetX := 0; offsetX < cols-1; offsetX++ {
 
-			cell := terminal.ActiveBuffer().GetCell(x+uint16(offsetX), y+uint16((lines-2)-offsetY))
-			if cell == nil {
-				continue
-			}
-			img := originalImage.SubImage(image.Rect(
-				offsetX*int(terminal.charWidth),
-				offsetY*int(terminal.charHeight),
-				(offsetX*int(terminal.charWidth))+int(terminal.charWidth),
-				(offsetY*int(terminal.charHeight))+int(terminal.charHeight),
-			))
+	scale := math.Min(float64(maxWidth)/float64(w), float64(maxHeight)/float64(h))
+	scaledWidth := int(math.Max(1, float64(w)*scale))
+	scaledHeight := int(math.Max(1, float64(h)*scale))
 
-			rgba := image.NewRGBA(image.Rect(0, 0, int(terminal.charWidth), int(terminal.charHeight)))
-			draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
-			cell.SetImage(rgba)
+	scaled := image.NewRGBA(image.Rect(0, 0, scaledWidth, scaledHeight))
+	for y := 0; y < scaledHeight; y++ {
+		for x := 0; x < scaledWidth; x++ {
+			scaled.SetRGBA(x, y, img.RGBAAt(bounds.Min.X+x*w/scaledWidth, bounds.Min.Y+y*h/scaledHeight))
 		}
 	}
-
-	return nil
+	return scaled
 }
@@ -0,0 +1,25 @@
+package terminal
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFitImage(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 400, 100))
+	img.SetRGBA(0, 0, color.RGBA{R: 255, A: 255})
+
+	fitted := fitImage(img, 200, 200)
+	assert.Equal(t, image.Rect(0, 0, 200, 50), fitted.Bounds())
+	assert.Equal(t, color.RGBA{R: 255, A: 255}, fitted.RGBAAt(0, 0))
+
+	fitted = fitImage(img, 1000, 20)
+	assert.Equal(t, image.Rect(0, 0, 80, 20), fitted.Bounds())
+
+	// images which already fit are left alone
+	assert.Equal(t, img, fitImage(img, 400, 100))
+	assert.Equal(t, img, fitImage(img, 0, 0))
+}
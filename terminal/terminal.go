@@ -5,10 +5,10 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"os"
+	"regexp"
+	"strings"
 	"sync"
-	"syscall"
-	"unsafe"
+	"time"
 
 	"github.com/liamg/aminal/buffer"
 	"github.com/liamg/aminal/config"
@@ -37,27 +37,67 @@ type Terminal struct {
 	buffers            []*buffer.Buffer
 	activeBufferIndex  uint8
 	lock               sync.Mutex
-	pty                *os.File
+	pty                Pty
 	logger             *zap.SugaredLogger
 	title              string
+	host               string
+	workingDir         string
 	size               Winsize
 	config             *config.Config
 	titleHandlers      []chan bool
 	pauseChan          chan bool
 	resumeChan         chan bool
+	outputPaused       int32 // see SetOutputPaused
+	pauseToggled       chan bool
 	modes              Modes
+	latency            Latency
+	plainRun           []rune // reused by writePlain, to save allocating for every run
+	parser             parser
+	parsers            int32 // the number of processInput loops running, see RequestSize
+	pendingSize        pendingSize
+	resizeChan         chan bool
 	mouseMode          MouseMode
+	mouseExtMode       MouseExtMode
 	bracketedPasteMode bool
 	isDirty            bool
+	hasActivity        bool
+	bellRung           bool
+	lastBell           time.Time
 	charWidth          float32
 	charHeight         float32
 	lastBuffer         uint8
-}
+	requests           chan Request
+	userEvents         chan UserEvent
+	recorder           *Recorder
+	progress           Progress
+	progressLock       sync.Mutex            // held to change progress, which the renderer reads
+	progressPattern    *regexp.Regexp        // detects progress in output, or nil if detection is off
+	colours            map[int]config.Colour // palette, foreground, background and cursor colours changed by the program
+	colourOverrides    map[int]config.Colour // a copy of colours for the renderer, see ColourOverrides
+	displayColours     *config.ColourScheme  // see SetDisplayColours
+	coloursLock        sync.Mutex            // held for colourOverrides and displayColours, which are shared with the gui
+	altHistory         altScreenHistory
+	kitty              kittyGraphics
+}
+
+// CursorShape is how the cursor is drawn, as set by DECSCUSR, e.g. by shells to show vi mode
+type CursorShape int
+
+const (
+	CursorBlock CursorShape = iota
+	CursorUnderline
+	CursorBar
+)
 
 type Modes struct {
 	ShowCursor            bool
 	ApplicationCursorKeys bool
+	ApplicationKeypad     bool // the keypad sends SS3 sequences rather than digits
+	AltSendsEscape        bool // alt sends ESC before the key, so it works as meta
+	EightBitMeta          bool // alt sets the eighth bit of ASCII characters, when it doesn't send ESC
 	BlinkingCursor        bool
+	CursorShape           CursorShape
+	AlternateScroll       bool // send the mouse wheel as arrow keys in the alternate screen
 }
 
 type Winsize struct {
@@ -67,21 +107,12 @@ type Winsize struct {
 	y      uint16 //ignored, but necessary for ioctl calls
 }
 
-func New(pty *os.File, logger *zap.SugaredLogger, config *config.Config) *Terminal {
+func New(pty Pty, logger *zap.SugaredLogger, config *config.Config) *Terminal {
 	t := &Terminal{
 		buffers: []*buffer.Buffer{
-			buffer.NewBuffer(1, 1, buffer.CellAttributes{
-				FgColour: config.ColourScheme.Foreground,
-				BgColour: config.ColourScheme.Background,
-			}),
-			buffer.NewBuffer(1, 1, buffer.CellAttributes{
-				FgColour: config.ColourScheme.Foreground,
-				BgColour: config.ColourScheme.Background,
-			}),
-			buffer.NewBuffer(1, 1, buffer.CellAttributes{
-				FgColour: config.ColourScheme.Foreground,
-				BgColour: config.ColourScheme.Background,
-			}),
+			buffer.NewBuffer(1, 1, defaultAttributes(config.ColourScheme)),
+			buffer.NewBuffer(1, 1, defaultAttributes(config.ColourScheme)),
+			buffer.NewBuffer(1, 1, defaultAttributes(config.ColourScheme)),
 		},
 		pty:           pty,
 		logger:        logger,
@@ -89,10 +120,23 @@ func New(pty *os.File, logger *zap.SugaredLogger, config *config.Config) *Termin
 		titleHandlers: []chan bool{},
 		pauseChan:     make(chan bool, 1),
 		resumeChan:    make(chan bool, 1),
+		pauseToggled:  make(chan bool, 1),
+		resizeChan:    make(chan bool, 1),
+		requests:      make(chan Request, 16),
+		userEvents:    make(chan UserEvent, 16),
 		modes: Modes{
-			ShowCursor: true,
+			ShowCursor:      true,
+			AlternateScroll: true,
+			AltSendsEscape:  config.Input.AltSendsEscape,
 		},
+		progressPattern: config.Progress.DetectPattern(),
 	}
+	// programs in the alternate screen redraw it themselves, so nothing needs to be kept above it
+	t.buffers[MainBuffer].SetScrollbackLimit(config.Scrollback)
+	t.altHistory.limit = config.AltScreenHistory
+	t.buffers[AltBuffer].SetScrollbackLimit(0)
+	t.buffers[AltBuffer].SetZone(buffer.ZoneFullScreen)
+	t.resetColours()
 
 	return t
 
@@ -116,10 +160,43 @@ func (terminal *Terminal) SetDirty() {
 	terminal.isDirty = true
 }
 
+// CheckActivity returns true if output has been received since it was last called
+func (terminal *Terminal) CheckActivity() bool {
+	a := terminal.hasActivity
+	terminal.hasActivity = false
+	return a
+}
+
+// CheckBell returns true if the bell has been rung since it was last called
+func (terminal *Terminal) CheckBell() bool {
+	b := terminal.bellRung
+	terminal.bellRung = false
+	return b
+}
+
+// RingBell rings the bell, unless it was rung very recently
+func (terminal *Terminal) RingBell() {
+	if time.Since(terminal.lastBell) < minBellInterval {
+		return
+	}
+	terminal.lastBell = time.Now()
+	terminal.bellRung = true
+	terminal.SetDirty()
+}
+
 func (terminal *Terminal) IsApplicationCursorKeysModeEnabled() bool {
 	return terminal.modes.ApplicationCursorKeys
 }
 
+func (terminal *Terminal) IsApplicationKeypadModeEnabled() bool {
+	return terminal.modes.ApplicationKeypad
+}
+
+// IsAlternateScrollModeEnabled returns true if the mouse wheel should send arrow keys while in the alternate screen
+func (terminal *Terminal) IsAlternateScrollModeEnabled() bool {
+	return terminal.modes.AlternateScroll
+}
+
 func (terminal *Terminal) SetMouseMode(mode MouseMode) {
 	terminal.mouseMode = mode
 }
@@ -129,15 +206,39 @@ func (terminal *Terminal) GetMouseMode() MouseMode {
 }
 
 func (terminal *Terminal) UseMainBuffer() {
+	terminal.snapshotAltScreen(true)
 	terminal.activeBufferIndex = MainBuffer
 	terminal.SetSize(uint(terminal.size.Width), uint(terminal.size.Height))
 }
 
 func (terminal *Terminal) UseAltBuffer() {
+	if terminal.activeBufferIndex != AltBuffer {
+		terminal.altHistory.started(time.Now())
+	}
 	terminal.activeBufferIndex = AltBuffer
 	terminal.SetSize(uint(terminal.size.Width), uint(terminal.size.Height))
 }
 
+// enterAltScreen saves the cursor and switches to a cleared alternate screen, as for smcup. The main screen is left as
+// it is, to be shown again by exitAltScreen.
+func (terminal *Terminal) enterAltScreen() {
+	if terminal.UsingMainBuffer() {
+		terminal.ActiveBuffer().SaveCursor()
+	}
+	terminal.UseAltBuffer()
+	terminal.ActiveBuffer().EraseDisplay()
+	terminal.ActiveBuffer().SetPosition(0, 0)
+}
+
+// exitAltScreen switches back to the main screen and restores the cursor saved by enterAltScreen, as for rmcup
+func (terminal *Terminal) exitAltScreen() {
+	if terminal.UsingMainBuffer() {
+		return
+	}
+	terminal.UseMainBuffer()
+	terminal.ActiveBuffer().RestoreCursor()
+}
+
 func (terminal *Terminal) UseInternalBuffer() {
 	terminal.pauseChan <- true
 	terminal.activeBufferIndex = InternalBuffer
@@ -185,6 +286,18 @@ func (terminal *Terminal) ScrollToEnd() {
 	terminal.ActiveBuffer().ScrollToEnd()
 }
 
+// followOutput goes back to the bottom after output arrives, if the user has scrolled back and scroll_on_output is set
+func (terminal *Terminal) followOutput() {
+	if terminal.config.ScrollOnOutput && terminal.ActiveBuffer().GetScrollOffset() > 0 {
+		terminal.ActiveBuffer().ScrollToEnd()
+	}
+}
+
+// NewLinesBelow returns how many lines of output have arrived since the user scrolled up or started selecting
+func (terminal *Terminal) NewLinesBelow() int {
+	return terminal.ActiveBuffer().NewLinesBelow()
+}
+
 func (terminal *Terminal) GetVisibleLines() []buffer.Line {
 	return terminal.ActiveBuffer().GetVisibleLines()
 }
@@ -201,11 +314,15 @@ func (terminal *Terminal) Modes() Modes {
 	return terminal.modes
 }
 
+// emitTitleChange tells handlers the title has changed. A handler which hasn't yet dealt with the last change isn't
+// told again, so a flood of title changes is coalesced - handlers should be buffered channels, and read the title
+// when they receive.
 func (terminal *Terminal) emitTitleChange() {
 	for _, h := range terminal.titleHandlers {
-		go func(c chan bool) {
-			c <- true
-		}(h)
+		select {
+		case h <- true:
+		default:
+		}
 	}
 }
 
@@ -234,27 +351,149 @@ func (terminal *Terminal) SetTitle(title string) {
 	terminal.emitTitleChange()
 }
 
-// Write sends data, i.e. locally typed keystrokes to the pty
+// GetHost returns the host the shell last reported it was running on, if any
+func (terminal *Terminal) GetHost() string {
+	return terminal.host
+}
+
+// GetWorkingDirectory returns the directory the shell last reported it was in, if any
+func (terminal *Terminal) GetWorkingDirectory() string {
+	return terminal.workingDir
+}
+
+func (terminal *Terminal) SetWorkingDirectory(host string, dir string) {
+	terminal.host = host
+	terminal.workingDir = dir
+	terminal.SetDirty()
+	// the window title can include the directory
+	terminal.emitTitleChange()
+}
+
+// Write sends data, i.e. locally typed keystrokes to the pty. Nothing is sent in read-only mode.
 func (terminal *Terminal) Write(data []byte) error {
+	if terminal.config.ReadOnly {
+		return nil
+	}
+	// typing goes back to following output, so the user can see what they are typing
+	if terminal.config.ScrollOnKey {
+		terminal.ActiveBuffer().ScrollToEnd()
+	}
+	_, err := terminal.pty.Write(data)
+	return err
+}
+
+// respond answers a query from the program running in the terminal, which it may be waiting on even in read-only mode
+func (terminal *Terminal) respond(data []byte) error {
 	_, err := terminal.pty.Write(data)
 	return err
 }
 
 func (terminal *Terminal) Paste(data []byte) error {
 
+	if terminal.config.ReadOnly {
+		return nil
+	}
+
 	if terminal.bracketedPasteMode {
-		data = []byte(fmt.Sprintf("\x1b[200~%s\x1b[201~", string(data)))
+		// pasted text could otherwise end the paste early, and have the rest of it run as if it was typed
+		text := strings.NewReplacer("\x1b[200~", "", "\x1b[201~", "").Replace(string(data))
+		data = []byte(fmt.Sprintf("\x1b[200~%s\x1b[201~", text))
 	}
 	_, err := terminal.pty.Write(data)
 	return err
 }
 
+// SetPty attaches the terminal to a new pty, e.g. when the shell is restarted after exiting
+func (terminal *Terminal) SetPty(pty Pty) error {
+	terminal.lock.Lock()
+	defer terminal.lock.Unlock()
+	terminal.pty = pty
+	return pty.Resize(terminal.size.Width, terminal.size.Height)
+}
+
+// WaitForExit returns the exit status of the process at the other end of the pty, if the pty knows about it
+func (terminal *Terminal) WaitForExit() (int, bool) {
+	process, ok := terminal.pty.(ProcessPty)
+	if !ok {
+		return 0, false
+	}
+	status, err := process.Wait()
+	if err != nil {
+		terminal.logger.Errorf("Failed to wait for process to exit: %s", err)
+		return 0, false
+	}
+	return status, true
+}
+
+// Hangup ends the process at the other end of the pty, if it was started by aminal. Anything still running after the
+// timeout is killed.
+func (terminal *Terminal) Hangup(timeout time.Duration) {
+	if process, ok := terminal.pty.(ProcessPty); ok {
+		process.Hangup(timeout)
+	}
+}
+
+// SecretInput returns true while the program is reading input which isn't echoed, such as a password, unless the
+// secret input guard is turned off
+func (terminal *Terminal) SecretInput() bool {
+	if !terminal.config.Security.SecretInputGuard {
+		return false
+	}
+	if pty, ok := terminal.pty.(SecretPty); ok {
+		return pty.SecretInput()
+	}
+	return false
+}
+
+// GetForegroundProcess returns the name of the program in the foreground, if it isn't the shell and can be found out
+func (terminal *Terminal) GetForegroundProcess() string {
+	if process, ok := terminal.pty.(ProcessPty); ok {
+		return process.Foreground()
+	}
+	return ""
+}
+
+// ShowMessage writes a line of text from aminal itself, rather than the program running in it, on the cursor's line if
+// it is empty or else below it
+func (terminal *Terminal) ShowMessage(text string) {
+	if !terminal.UsingMainBuffer() {
+		terminal.UseMainBuffer()
+	}
+	buf := terminal.ActiveBuffer()
+	if buf.CursorColumn() > 0 {
+		buf.CarriageReturn()
+		buf.NewLine()
+	}
+	buf.Write([]rune(text)...)
+	terminal.SetDirty()
+}
+
+// EndMessage moves the cursor below the message last shown, so what a restarted program writes starts on its own line
+func (terminal *Terminal) EndMessage() {
+	buf := terminal.ActiveBuffer()
+	buf.CarriageReturn()
+	buf.NewLine()
+	terminal.SetDirty()
+}
+
+// Record records all output read from the pty from now on, and the size of the terminal as it changes
+func (terminal *Terminal) Record(recorder *Recorder) {
+	terminal.lock.Lock()
+	defer terminal.lock.Unlock()
+	terminal.recorder = recorder
+	recorder.Resize(terminal.size.Width, terminal.size.Height)
+}
+
 // Read needs to be run on a goroutine, as it continually reads output to set on the terminal
 func (terminal *Terminal) Read() error {
 
-	buffer := make(chan rune, 0xffff)
+	buffer := make(chan rune, outputBufferSize)
 
-	reader := bufio.NewReader(terminal.pty)
+	var source io.Reader = terminal.pty
+	if terminal.recorder != nil {
+		source = io.TeeReader(source, terminal.recorder)
+	}
+	reader := bufio.NewReader(source)
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
@@ -265,16 +504,25 @@ func (terminal *Terminal) Read() error {
 			if err == io.EOF {
 				break
 			}
+			terminal.drain(buffer)
 			return err
 		} else if size > 0 {
+			terminal.latency.echoed()
 			buffer <- r
 		}
 	}
 
-	//clean exit
+	terminal.drain(buffer)
 	return nil
 }
 
+// drain gives output which has been read a moment to be processed, so it is on screen before whatever happens next
+func (terminal *Terminal) drain(buffer chan rune) {
+	for i := 0; len(buffer) > 0 && i < 100; i++ {
+		time.Sleep(time.Millisecond * 10)
+	}
+}
+
 func (terminal *Terminal) Clear() {
 	terminal.ActiveBuffer().Clear()
 }
@@ -290,10 +538,11 @@ func (terminal *Terminal) SetSize(newCols uint, newLines uint) error {
 	terminal.size.Width = uint16(newCols)
 	terminal.size.Height = uint16(newLines)
 
-	_, _, err := syscall.Syscall(syscall.SYS_IOCTL, uintptr(terminal.pty.Fd()),
-		uintptr(syscall.TIOCSWINSZ), uintptr(unsafe.Pointer(&terminal.size)))
-	if err != 0 {
-		return fmt.Errorf("Failed to set terminal size vai ioctl: Error no %d", err)
+	if err := terminal.pty.Resize(terminal.size.Width, terminal.size.Height); err != nil {
+		return err
+	}
+	if terminal.recorder != nil {
+		terminal.recorder.Resize(terminal.size.Width, terminal.size.Height)
 	}
 
 	terminal.ActiveBuffer().ResizeView(terminal.size.Width, terminal.size.Height)
@@ -0,0 +1,225 @@
+package terminal
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/liamg/aminal/buffer"
+	"github.com/liamg/aminal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type recordingPty struct {
+	bytes.Buffer
+}
+
+func (pty *recordingPty) Close() error {
+	return nil
+}
+
+func (pty *recordingPty) Resize(cols uint16, rows uint16) error {
+	return nil
+}
+
+func TestReadOnlyDropsInput(t *testing.T) {
+
+	conf := config.DefaultConfig
+	conf.ReadOnly = true
+	pty := &recordingPty{}
+	term := New(pty, zap.NewNop().Sugar(), &conf)
+
+	assert.Nil(t, term.Write([]byte("rm -rf /\r")))
+	assert.Nil(t, term.Paste([]byte("oops")))
+	assert.Equal(t, "", pty.String())
+
+	// programs waiting on answers to their queries still get them
+	assert.Nil(t, csiDeviceStatusReportHandler([]string{"5"}, "", term))
+	assert.Equal(t, "\x1b[0n", pty.String())
+
+	conf.ReadOnly = false
+	assert.Nil(t, term.Write([]byte("ls\r")))
+	assert.Equal(t, "\x1b[0nls\r", pty.String())
+}
+
+func TestScrollingBackToTheBottom(t *testing.T) {
+	term, _ := newTestTerminal()
+	term.SetSize(10, 2)
+	require.Nil(t, parse(term, "1\r\n2\r\n3\r\n4\r\n5"))
+
+	term.ScrollPageUp()
+	assert.Equal(t, uint(2), term.GetScrollOffset())
+	term.config.ScrollOnKey = false
+	assert.Nil(t, term.Write([]byte("l")))
+	assert.Equal(t, uint(2), term.GetScrollOffset())
+	term.config.ScrollOnKey = true
+	assert.Nil(t, term.Write([]byte("s")))
+	assert.Equal(t, uint(0), term.GetScrollOffset())
+
+	// output doesn't move the view unless scroll_on_output is set
+	term.ScrollPageUp()
+	require.Nil(t, parse(term, "\r\n6"))
+	term.followOutput()
+	assert.Equal(t, uint(3), term.GetScrollOffset())
+	term.config.ScrollOnOutput = true
+	require.Nil(t, parse(term, "\r\n7"))
+	term.followOutput()
+	assert.Equal(t, uint(0), term.GetScrollOffset())
+}
+
+func TestWorkingDirectoryChangesTitle(t *testing.T) {
+	term, _ := newTestTerminal()
+	titleChan := make(chan bool, 1)
+	term.AttachTitleChangeHandler(titleChan)
+
+	assert.Nil(t, parse(term, "\x1b]7;file://host/home/user/src\x07"))
+
+	assert.Len(t, titleChan, 1)
+	assert.Equal(t, "/home/user/src", term.GetWorkingDirectory())
+	assert.Equal(t, "host", term.GetHost())
+}
+
+func TestBracketedPaste(t *testing.T) {
+	term, pty := newTestTerminal()
+
+	assert.Nil(t, term.Paste([]byte("ls\r")))
+	assert.Equal(t, "ls\r", pty.String())
+	pty.Reset()
+
+	input := feed("\x1b[?2004h")
+	for len(input) > 0 {
+		term.processRune(<-input, input)
+	}
+	assert.Nil(t, term.Paste([]byte("ls\r")))
+	assert.Equal(t, "\x1b[200~ls\r\x1b[201~", pty.String())
+	pty.Reset()
+
+	// the pasted text can't end the paste itself
+	assert.Nil(t, term.Paste([]byte("echo\x1b[201~rm -rf ~\r")))
+	assert.Equal(t, "\x1b[200~echorm -rf ~\r\x1b[201~", pty.String())
+}
+
+func TestHyperlink(t *testing.T) {
+	term, _ := newTestTerminal()
+	term.SetSize(40, 3)
+
+	pty := feed("a \x1b]8;id=1;https://example.com/?a=1;b=2\x1b\\\x1b[1mli\x1b[0mnk\x1b]8;;\x1b\\ b")
+	for len(pty) > 0 {
+		term.processRune(<-pty, pty)
+	}
+
+	buf := term.ActiveBuffer()
+	assert.Equal(t, "a link b", buf.GetAllText())
+	assert.Equal(t, "", buf.CellHyperlink(buf.GetCell(0, 0)))
+	assert.Equal(t, "https://example.com/?a=1;b=2", buf.CellHyperlink(buf.GetCell(2, 0)))
+	// resetting the attributes after the bold text doesn't end the link
+	assert.False(t, buf.GetCell(4, 0).Attr().Bold)
+	assert.Equal(t, "https://example.com/?a=1;b=2", buf.CellHyperlink(buf.GetCell(4, 0)))
+	assert.Equal(t, "", buf.CellHyperlink(buf.GetCell(7, 0)))
+}
+
+func TestShellIntegrationZones(t *testing.T) {
+	term, _ := newTestTerminal()
+	term.SetSize(20, 5)
+
+	pty := feed("\x1b]133;A\x07\x1b[32m$\x1b[0m \x1b]133;B\x07ls\r\n\x1b]133;C;cmdline=ls\x07out\r\n\x1b]133;D;0\x07")
+	for len(pty) > 0 {
+		term.processRune(<-pty, pty)
+	}
+
+	buf := term.ActiveBuffer()
+	assert.Equal(t, buffer.ZonePrompt, buf.GetCell(0, 0).Attr().Zone)
+	// resetting the colour doesn't leave the prompt
+	assert.Equal(t, buffer.ZonePrompt, buf.GetCell(1, 0).Attr().Zone)
+	assert.Equal(t, buffer.ZoneInput, buf.GetCell(2, 0).Attr().Zone)
+	assert.Equal(t, buffer.ZoneOutput, buf.GetCell(0, 1).Attr().Zone)
+	assert.Equal(t, buffer.ZoneUnknown, buf.CursorAttr().Zone)
+
+	term.UseAltBuffer()
+	assert.Equal(t, buffer.ZoneFullScreen, term.ActiveBuffer().CursorAttr().Zone)
+}
+
+func TestCursorStyle(t *testing.T) {
+	term, _ := newTestTerminal()
+
+	require.Nil(t, parse(term, "\x1b[6 q"))
+	assert.Equal(t, CursorBar, term.Modes().CursorShape)
+	assert.False(t, term.Modes().BlinkingCursor)
+
+	require.Nil(t, parse(term, "\x1b[3 q"))
+	assert.Equal(t, CursorUnderline, term.Modes().CursorShape)
+	assert.True(t, term.Modes().BlinkingCursor)
+
+	require.Nil(t, parse(term, "\x1b[ q"))
+	assert.Equal(t, CursorBlock, term.Modes().CursorShape)
+	assert.False(t, term.Modes().BlinkingCursor)
+
+	assert.NotNil(t, parse(term, "\x1b[7 q"))
+	assert.NotNil(t, parse(term, "\x1b[5q"))
+}
+
+func TestDeviceAttributes(t *testing.T) {
+	term, pty := newTestTerminal()
+
+	require.Nil(t, parse(term, "\x1b[c"))
+	assert.Equal(t, primaryDeviceAttributes, pty.String())
+
+	pty.Reset()
+	require.Nil(t, parse(term, "\x1b[>c"))
+	assert.Equal(t, "\x1b[>1;0;0c", pty.String())
+
+	pty.Reset()
+	require.Nil(t, parse(term, "\x1b[=0c"))
+	assert.Equal(t, tertiaryDeviceAttributes, pty.String())
+
+	assert.Equal(t, 10203, versionNumber("v1.2.3"))
+	assert.Equal(t, 0, versionNumber("dev"))
+}
+
+func TestCursorPositionReport(t *testing.T) {
+	term, pty := newTestTerminal()
+	term.ActiveBuffer().ResizeView(10, 5)
+
+	term.ActiveBuffer().SetPosition(3, 2)
+	require.Nil(t, parse(term, "\x1b[6n"))
+	assert.Equal(t, "\x1b[3;4R", pty.String())
+
+	pty.Reset()
+	require.Nil(t, parse(term, "\x1b[?6n"))
+	assert.Equal(t, "\x1b[?3;4;1R", pty.String())
+
+	// a pending wrap is reported in the last column
+	pty.Reset()
+	term.ActiveBuffer().SetPosition(0, 0)
+	term.ActiveBuffer().Write([]rune("0123456789")...)
+	require.Nil(t, parse(term, "\x1b[6n"))
+	assert.Equal(t, "\x1b[1;10R", pty.String())
+
+	// in origin mode, lines are counted from the top margin
+	pty.Reset()
+	require.Nil(t, parse(term, "\x1b[2;4r"))
+	require.Nil(t, parse(term, "\x1b[?6h"))
+	require.Nil(t, parse(term, "\x1b[2;3H"))
+	require.Nil(t, parse(term, "\x1b[6n"))
+	assert.Equal(t, "\x1b[2;3R", pty.String())
+}
+
+func TestResetDesignatesASCIIAgain(t *testing.T) {
+	term, _ := newTestTerminal()
+	term.SetSize(10, 2)
+	parse(term, "\x1b(0\x1b)0\x0eq\x1bcq\x0eq")
+	buf := term.ActiveBuffer()
+	assert.Equal(t, buffer.CharsetASCII, buf.Charset())
+	assert.Equal(t, "qq", buf.CursorLineText())
+}
+
+func TestMessagesDontLeaveBlankLines(t *testing.T) {
+	term, _ := newTestTerminal()
+	term.SetSize(40, 5)
+	parse(term, "$ exit\r\n")
+	term.ShowMessage("[Process exited]")
+	term.EndMessage()
+	parse(term, "$ ")
+	assert.Equal(t, "$ exit\n[Process exited]\n$", term.ActiveBuffer().GetAllText())
+}
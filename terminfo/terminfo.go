@@ -0,0 +1,155 @@
+package terminfo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Name is the TERM of aminal's own terminfo entry
+const Name = "aminal"
+
+// Fallback is the TERM used when the one configured has no terminfo entry. aminal is close enough to xterm that
+// almost everything works with it.
+const Fallback = "xterm-256color"
+
+// Source is aminal's terminfo entry: xterm's, plus the extensions it supports for true colour, styled and coloured
+// underlines, cursor shapes and setting the clipboard
+const Source = `aminal|aminal terminal emulator,
+	Tc,
+	Ms=\E]52;%p1%s;%p2%s\007,
+	Se=\E[2 q,
+	Setulc=\E[58\:2\:\:%p1%{65536}%/%d\:%p1%{256}%/%{255}%&%d\:%p1%{255}%&%dm,
+	Smulx=\E[4\:%p1%dm,
+	Ss=\E[%p1%d q,
+	use=xterm-256color,
+`
+
+// Dir returns the directory terminfo entries are installed in for the user, which ncurses looks in before the
+// system's
+func Dir() (string, error) {
+	home := os.Getenv("HOME")
+	if home == "" {
+		return "", fmt.Errorf("Cannot find the terminfo directory: HOME is not set")
+	}
+	return filepath.Join(home, ".terminfo"), nil
+}
+
+// Installed returns true if there is a terminfo entry for term, wherever ncurses finds it
+func Installed(term string) bool {
+	return exec.Command("infocmp", term).Run() == nil
+}
+
+// Install compiles the entry into dir with tic, replacing whatever was there
+func Install(dir string) error {
+	f, err := ioutil.TempFile("", "aminal-terminfo-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(Source)
+	f.Close()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	if out, err := exec.Command("tic", "-x", "-o", dir, f.Name()).CombinedOutput(); err != nil {
+		return fmt.Errorf("Failed to compile terminfo entry: %s: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// Verify returns an error if the entry ncurses finds for aminal is missing, or isn't the same as Source, e.g. it was
+// installed by an older version
+func Verify() error {
+	installed, err := describe()
+	if err != nil {
+		return fmt.Errorf("The %s terminfo entry is not installed", Name)
+	}
+
+	dir, err := ioutil.TempDir("", "aminal-terminfo-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	if err := Install(dir); err != nil {
+		return err
+	}
+	expected, err := describe("-A", dir)
+	if err != nil {
+		return err
+	}
+
+	if installed != expected {
+		return fmt.Errorf("The installed %s terminfo entry is out of date", Name)
+	}
+	return nil
+}
+
+// Ensure installs the entry for the user if it is missing, and repairs it if it is out of date
+func Ensure() error {
+	if Verify() == nil {
+		return nil
+	}
+	dir, err := Dir()
+	if err != nil {
+		return err
+	}
+	if err := Install(dir); err != nil {
+		return err
+	}
+	return Verify()
+}
+
+// EnsureCached does the same as Ensure, but only the first time it is run for this version of the entry, which takes
+// running infocmp twice and tic rather than on every launch. A stamp file in Dir records that the entry was verified.
+// If the entry is later removed, Ensure or --install-terminfo puts it back.
+func EnsureCached() error {
+	dir, err := Dir()
+	if err != nil {
+		return err
+	}
+	stamp := filepath.Join(dir, stampFile)
+	if data, err := ioutil.ReadFile(stamp); err == nil && string(data) == sourceHash() {
+		return nil
+	}
+	if err := Ensure(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(stamp, []byte(sourceHash()), 0644)
+}
+
+// stampFile records the version of the entry last verified, see EnsureCached
+const stampFile = ".aminal-verified"
+
+// sourceHash identifies the version of the entry
+func sourceHash() string {
+	sum := sha256.Sum256([]byte(Source))
+	return hex.EncodeToString(sum[:])
+}
+
+// describe returns infocmp's description of the entry, without the comment saying which file it came from
+func describe(args ...string) (string, error) {
+	out, err := exec.Command("infocmp", append(append([]string{"-x"}, args...), Name)...).Output()
+	if err != nil {
+		return "", err
+	}
+	lines := []string{}
+	for _, line := range strings.Split(string(out), "\n") {
+		if !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
@@ -0,0 +1,72 @@
+package terminfo
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnsureInstallsAndRepairs(t *testing.T) {
+	if _, err := exec.LookPath("tic"); err != nil {
+		t.Skip("tic is not installed")
+	}
+
+	home, err := ioutil.TempDir("", "aminal-home-")
+	require.Nil(t, err)
+	defer os.RemoveAll(home)
+
+	for _, name := range []string{"HOME", "TERMINFO", "TERMINFO_DIRS"} {
+		defer os.Setenv(name, os.Getenv(name))
+	}
+	os.Setenv("HOME", home)
+	os.Unsetenv("TERMINFO")
+	os.Unsetenv("TERMINFO_DIRS")
+
+	if Installed(Name) {
+		t.Skip("an aminal terminfo entry is installed system wide")
+	}
+	assert.NotNil(t, Verify())
+
+	require.Nil(t, Ensure())
+	assert.True(t, Installed(Name))
+	assert.Nil(t, Verify())
+
+	// an entry left by an older version is replaced
+	old := filepath.Join(home, "old.terminfo")
+	require.Nil(t, ioutil.WriteFile(old, []byte("aminal|aminal terminal emulator,\n\tuse=xterm-256color,\n"), 0644))
+	require.Nil(t, exec.Command("tic", "-x", "-o", filepath.Join(home, ".terminfo"), old).Run())
+	assert.NotNil(t, Verify())
+
+	require.Nil(t, Ensure())
+	assert.Nil(t, Verify())
+}
+
+func TestEnsureCachedTrustsItsStamp(t *testing.T) {
+	home, err := ioutil.TempDir("", "aminal-home-")
+	require.Nil(t, err)
+	defer os.RemoveAll(home)
+
+	for _, name := range []string{"HOME", "PATH"} {
+		defer os.Setenv(name, os.Getenv(name))
+	}
+	os.Setenv("HOME", home)
+	// without infocmp or tic, the entry can only be taken on trust
+	os.Setenv("PATH", home)
+
+	assert.NotNil(t, EnsureCached())
+
+	dir, err := Dir()
+	require.Nil(t, err)
+	require.Nil(t, os.MkdirAll(dir, 0755))
+	require.Nil(t, ioutil.WriteFile(filepath.Join(dir, stampFile), []byte(sourceHash()), 0644))
+	assert.Nil(t, EnsureCached())
+
+	// a stamp left by a version with a different entry isn't trusted
+	require.Nil(t, ioutil.WriteFile(filepath.Join(dir, stampFile), []byte("older"), 0644))
+	assert.NotNil(t, EnsureCached())
+}